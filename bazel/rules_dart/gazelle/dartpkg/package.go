@@ -0,0 +1,142 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dartpkg tracks the pub package enclosing each directory for the
+// gazelle extensions of rules_dart and rules_flutter, and generates the
+// libraries of the directories inside a package without a pubspec.yaml.
+package dartpkg
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+
+	"github.com/google/rules_dart/gazelle/dartsrc"
+	"github.com/google/rules_dart/gazelle/pubspec"
+)
+
+// Package is the nearest package at or above a directory.
+type Package struct {
+	// Name is the name of the package, and Dir the slash-separated
+	// directory of its pubspec.yaml, relative to the repository root.
+	Name string
+	Dir  string
+}
+
+// ReadPubspec parses the pubspec.yaml of the directory rel with parse, and
+// reports whether there is one. Invalid pubspecs are logged and skipped.
+func ReadPubspec[P any](c *config.Config, rel string, parse func(string) (P, error)) (P, bool) {
+	var zero P
+	pubspecPath := filepath.Join(c.RepoRoot, filepath.FromSlash(rel), "pubspec.yaml")
+	if _, err := os.Stat(pubspecPath); err != nil {
+		return zero, false
+	}
+	p, err := parse(pubspecPath)
+	if err != nil {
+		log.Printf("%s: %v", pubspecPath, err)
+		return zero, false
+	}
+	return p, true
+}
+
+// Subdir returns the first directory of the path from the package directory
+// to rel, or "" if rel is the package directory or outside it.
+func (p Package) Subdir(rel string) string {
+	if p.Name == "" || rel == p.Dir {
+		return ""
+	}
+	sub := rel
+	if p.Dir != "" {
+		var ok bool
+		if sub, ok = strings.CutPrefix(rel, p.Dir+"/"); !ok {
+			return ""
+		}
+	}
+	first, _, _ := strings.Cut(sub, "/")
+	return first
+}
+
+// Label returns the label of the external pub package pkg: its target in
+// depsRepo, a repository with a package //<name> for each pub package, or
+// that of its own repository if depsRepo is empty.
+func Label(depsRepo, pkg string) label.Label {
+	if depsRepo != "" {
+		return label.New(depsRepo, pkg, pkg)
+	}
+	return label.New(pubspec.RepositoryName(pkg), "", pkg)
+}
+
+// DirImport returns the import of the library of the directory rel.
+func DirImport(rel string) string {
+	return "//" + rel
+}
+
+// DirectoryLibrary returns a rule of the given kind for the Dart files of a
+// directory without a pubspec.yaml inside p, such as tool/ or a
+// pubspec-less example/, and its imports. Hidden and build directories have
+// no library of their own, nor do those under the subdirectories skip of
+// the package, such as lib/ and test/.
+func (p Package) DirectoryLibrary(kind string, args language.GenerateArgs, skip ...string) (*rule.Rule, []string) {
+	sub := p.Subdir(args.Rel)
+	if sub == "" || sub == "build" || strings.HasPrefix(sub, ".") {
+		return nil, nil
+	}
+	for _, s := range skip {
+		if sub == s {
+			return nil, nil
+		}
+	}
+
+	var srcs []string
+	for _, file := range args.RegularFiles {
+		if strings.HasSuffix(file, ".dart") {
+			srcs = append(srcs, file)
+		}
+	}
+	if len(srcs) == 0 {
+		return nil, nil
+	}
+	sort.Strings(srcs)
+
+	r := rule.NewRule(kind, path.Base(args.Rel))
+	r.SetAttr("srcs", srcs)
+
+	// Relative imports of lib/ are imports of the package library, and
+	// those of other directories of their libraries.
+	imports := dartsrc.ScanImports(args.Dir, srcs)
+	libDir := path.Join(p.Dir, "lib")
+	for _, d := range dartsrc.ScanRelativeImports(args.Dir, srcs) {
+		target := path.Join(args.Rel, d)
+		switch {
+		case target == args.Rel:
+		case target == libDir || strings.HasPrefix(target, libDir+"/"):
+			imports = append(imports, p.Name)
+		default:
+			imports = append(imports, DirImport(target))
+		}
+	}
+	sort.Strings(imports)
+	r.SetPrivateAttr(config.GazelleImportsKey, imports)
+	return r, imports
+}
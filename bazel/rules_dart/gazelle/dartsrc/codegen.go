@@ -0,0 +1,110 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dartsrc
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+// CodegenKind is the kind of the rules running build_runner.
+const CodegenKind = "dart_build_runner"
+
+// partRe matches the URIs of part directives.
+var partRe = regexp.MustCompile(`(?m)^\s*part\s+['"]([^'"]+)['"]\s*;`)
+
+// generatedSuffixes are the suffixes of the files generated by the
+// builders that dart_build_runner collects.
+var generatedSuffixes = []string{".g.dart", ".freezed.dart"}
+
+func isGenerated(file string) bool {
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(file, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedParts returns the set of generated files, relative to dir, that
+// are parts of the Dart files of dir.
+func generatedParts(dir string, files []string) map[string]bool {
+	parts := make(map[string]bool)
+	for _, file := range files {
+		if filepath.Ext(file) != ".dart" || isGenerated(file) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		for _, m := range partRe.FindAllSubmatch(content, -1) {
+			if part := path.Join(path.Dir(filepath.ToSlash(file)), string(m[1])); isGenerated(part) {
+				parts[part] = true
+			}
+		}
+	}
+	return parts
+}
+
+// CodegenRule returns a dart_build_runner rule generating the parts of the
+// sources of package pkg, given its dev_dependencies, if it uses
+// build_runner, and the sources without the generated parts, which may be
+// checked in. It returns a nil rule if the package does not use build_runner.
+func CodegenRule(dir, pkg string, devDependencies map[string]interface{}, srcs []string) (*rule.Rule, []string) {
+	if _, ok := devDependencies["build_runner"]; !ok {
+		return nil, srcs
+	}
+	parts := generatedParts(dir, srcs)
+	if len(parts) == 0 {
+		return nil, srcs
+	}
+
+	var kept, inputs []string
+	for _, src := range srcs {
+		if parts[filepath.ToSlash(src)] {
+			continue
+		}
+		kept = append(kept, src)
+		if filepath.Ext(src) == ".dart" {
+			inputs = append(inputs, src)
+		}
+	}
+
+	// Builders are conventionally named after their annotations, or with a
+	// _generator suffix.
+	var generators []string
+	for dep := range devDependencies {
+		if dep == "freezed" || dep == "json_serializable" || strings.HasSuffix(dep, "_generator") {
+			generators = append(generators, dep)
+		}
+	}
+	sort.Strings(generators)
+
+	r := rule.NewRule(CodegenKind, pkg+"_codegen")
+	r.SetAttr("srcs", inputs)
+	if len(generators) > 0 {
+		r.SetAttr("generators", generators)
+	}
+	return r, kept
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dartsrc scans Dart sources for the gazelle extensions of rules_dart
+// and rules_flutter: the packages and directories they import, and the parts
+// that build_runner generates for them.
+package dartsrc
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// directiveRe matches import and export directives, up to their
+	// terminating semicolon so that conditional imports are included.
+	directiveRe = regexp.MustCompile(`(?m)^\s*(?:import|export)\b[^;]*;`)
+	// packageURIRe matches the package name of a package: URI.
+	packageURIRe = regexp.MustCompile(`['"]package:([a-zA-Z_][a-zA-Z0-9_]*)/`)
+	// uriRe matches the URIs of a directive.
+	uriRe = regexp.MustCompile(`['"]([^'"]+)['"]`)
+)
+
+// ScanImports returns the sorted packages imported or exported by the Dart
+// files of dir, given relative to dir. Files that cannot be read are skipped.
+func ScanImports(dir string, files []string) []string {
+	seen := make(map[string]bool)
+	var pkgs []string
+	for _, file := range files {
+		if filepath.Ext(file) != ".dart" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		for _, directive := range directiveRe.FindAll(content, -1) {
+			for _, m := range packageURIRe.FindAllSubmatch(directive, -1) {
+				pkg := string(m[1])
+				if !seen[pkg] {
+					seen[pkg] = true
+					pkgs = append(pkgs, pkg)
+				}
+			}
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// ScanRelativeImports returns the sorted directories, relative to dir, of the
+// files imported or exported with relative URIs by the Dart files of dir.
+func ScanRelativeImports(dir string, files []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, file := range files {
+		if filepath.Ext(file) != ".dart" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		for _, directive := range directiveRe.FindAll(content, -1) {
+			for _, m := range uriRe.FindAllSubmatch(directive, -1) {
+				uri := string(m[1])
+				if strings.Contains(uri, ":") {
+					continue
+				}
+				d := path.Dir(path.Join(path.Dir(filepath.ToSlash(file)), uri))
+				if !seen[d] {
+					seen[d] = true
+					dirs = append(dirs, d)
+				}
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package language
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+
+	"github.com/google/rules_dart/gazelle/dartpkg"
+	"github.com/google/rules_dart/gazelle/pubspec"
+)
+
+// dartConfig is the configuration of a directory, stored in the Exts of its
+// config.Config and inherited by subdirectories.
+type dartConfig struct {
+	// lockfile is the nearest pubspec.lock at or above the directory, and
+	// lockDir the slash-separated directory containing it, relative to the
+	// repository root.
+	lockfile *pubspec.Lockfile
+	lockDir  string
+	// pkg is the nearest package at or above the directory.
+	pkg dartpkg.Package
+	// testTags are the tags of generated dart_test rules, set by the
+	// dart_test_tags directive.
+	testTags []string
+	// depsRepo is the repository holding a target for each pub package,
+	// set by the dart_deps_repo directive. If empty, each package has its
+	// own repository.
+	depsRepo string
+}
+
+const (
+	// testTagsDirective sets the tags of the generated dart_test rules, as a
+	// comma-separated list. An empty value clears them.
+	testTagsDirective = "dart_test_tags"
+	// depsRepoDirective sets a repository whose package //<name> holds the
+	// target of each pub package, as in `# gazelle:dart_deps_repo @pub_deps`.
+	depsRepoDirective = "dart_deps_repo"
+)
+
+func getDartConfig(c *config.Config) *dartConfig {
+	if dc, ok := c.Exts[dartName].(*dartConfig); ok {
+		return dc
+	}
+	return &dartConfig{}
+}
+
+// configureDirectives applies the directives of f to dc.
+func (dc *dartConfig) configureDirectives(f *rule.File) {
+	if f == nil {
+		return
+	}
+	for _, directive := range f.Directives {
+		switch directive.Key {
+		case testTagsDirective:
+			dc.testTags = nil
+			for _, tag := range strings.Split(directive.Value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					dc.testTags = append(dc.testTags, tag)
+				}
+			}
+		case depsRepoDirective:
+			dc.depsRepo = strings.TrimLeft(strings.TrimSpace(directive.Value), "@")
+		}
+	}
+}
+
+// configurePackage records the package of the directory rel, if any.
+func (dc *dartConfig) configurePackage(c *config.Config, rel string) {
+	if p, ok := dartpkg.ReadPubspec(c, rel, pubspec.ParsePubspec); ok {
+		dc.pkg = dartpkg.Package{Name: p.Name, Dir: rel}
+	}
+}
+
+// configureLockfile reads the pubspec.lock of the directory rel, if any. It
+// must run after configureDirectives: the packages of lockfiles resolved
+// through a dart_deps_repo are not declared by the repository macro, since
+// nothing refers to their own repositories.
+func (d *dartLang) configureLockfile(c *config.Config, rel string, dc *dartConfig) {
+	lockPath := filepath.Join(c.RepoRoot, filepath.FromSlash(rel), "pubspec.lock")
+	if _, err := os.Stat(lockPath); err == nil {
+		l, err := pubspec.ParseLockfile(lockPath)
+		if err != nil {
+			log.Printf("%s: %v", lockPath, err)
+		} else {
+			dc.lockfile = l
+			dc.lockDir = rel
+			if dc.depsRepo == "" {
+				d.addHosted(l)
+			} else {
+				d.depsRepoLockfiles++
+			}
+		}
+	}
+}
+
+// addHosted records the hosted packages of l for the repository macro.
+func (d *dartLang) addHosted(l *pubspec.Lockfile) {
+	if d.hosted == nil {
+		d.hosted = make(map[string]pubspec.HostedPackage)
+	}
+	for _, pkg := range l.HostedPackages() {
+		if prev, ok := d.hosted[pkg.Name]; ok && prev.Version != pkg.Version {
+			log.Printf("pub package %s is locked at both %s and %s; using %s", pkg.Name, prev.Version, pkg.Version, prev.Version)
+			continue
+		}
+		d.hosted[pkg.Name] = pkg
+	}
+}
+
+// resolveLocked returns the label of the pub package imp as locked by the
+// lockfile of dc. It reports false if the package cannot be resolved to a
+// target, such as packages of the SDK.
+func (dc *dartConfig) resolveLocked(imp string) (label.Label, bool) {
+	entry, ok := dc.lockfile.Packages[imp]
+	if !ok {
+		log.Printf("pub package %s is not in %s", imp, path.Join(dc.lockDir, "pubspec.lock"))
+		return label.NoLabel, false
+	}
+	switch entry.Source {
+	case "hosted", "git":
+		// Git packages are not fetched by the dart_deps extension and must be
+		// declared under the same repository name by the workspace.
+		return dartpkg.Label(dc.depsRepo, imp), true
+	case "path":
+		desc, err := entry.AsPath()
+		if err != nil {
+			log.Printf("pub package %s: %v", imp, err)
+			return label.NoLabel, false
+		}
+		if !desc.Relative {
+			log.Printf("pub package %s has an absolute path %s outside the workspace", imp, desc.Path)
+			return label.NoLabel, false
+		}
+		pkg := path.Join(dc.lockDir, filepath.ToSlash(desc.Path))
+		if pkg == ".." || strings.HasPrefix(pkg, "../") {
+			log.Printf("pub package %s has a path %s outside the workspace", imp, desc.Path)
+			return label.NoLabel, false
+		}
+		if pkg == "." {
+			pkg = ""
+		}
+		return label.New("", pkg, imp), true
+	default:
+		return label.NoLabel, false
+	}
+}
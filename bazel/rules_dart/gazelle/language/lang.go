@@ -17,8 +17,12 @@
 package language
 
 import (
+	"bytes"
+	"errors"
 	"flag"
+	"fmt"
 	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -31,12 +35,25 @@ import (
 	"github.com/bazelbuild/bazel-gazelle/resolve"
 	"github.com/bazelbuild/bazel-gazelle/rule"
 
+	"github.com/google/rules_dart/gazelle/dartpkg"
+	"github.com/google/rules_dart/gazelle/dartsrc"
 	"github.com/google/rules_dart/gazelle/pubspec"
 )
 
 const dartName = "dart"
 
-type dartLang struct{}
+type dartLang struct {
+	repoRoot string
+	// macro is the file%name of the repository macro to write for the
+	// hosted packages, if any.
+	macro  string
+	hosted map[string]pubspec.HostedPackage
+	// depsRepoLockfiles counts the lockfiles whose packages resolve through
+	// a dart_deps_repo, and so are left out of the macro.
+	depsRepoLockfiles int
+}
+
+var _ language.FinishableLanguage = (*dartLang)(nil)
 
 func NewLanguage() language.Language {
 	return &dartLang{}
@@ -44,11 +61,21 @@ func NewLanguage() language.Language {
 
 func (d *dartLang) Name() string { return dartName }
 
-func (d *dartLang) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {}
+func (d *dartLang) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Config) {
+	fs.StringVar(&d.macro, "dart_repository_macro", "", "file%macro, relative to the repository root, of a macro to write declaring the hosted pub packages of the pubspec.lock files")
+}
 
-func (d *dartLang) CheckFlags(fs *flag.FlagSet, c *config.Config) error { return nil }
+func (d *dartLang) CheckFlags(fs *flag.FlagSet, c *config.Config) error {
+	d.repoRoot = c.RepoRoot
+	if d.macro != "" && !strings.HasSuffix(strings.SplitN(d.macro, "%", 2)[0], ".bzl") {
+		return fmt.Errorf("-dart_repository_macro: %q is not a .bzl file", d.macro)
+	}
+	return nil
+}
 
-func (d *dartLang) KnownDirectives() []string { return nil }
+func (d *dartLang) KnownDirectives() []string {
+	return []string{testTagsDirective, depsRepoDirective}
+}
 
 func (d *dartLang) Kinds() map[string]rule.KindInfo {
 	return map[string]rule.KindInfo{
@@ -57,6 +84,19 @@ func (d *dartLang) Kinds() map[string]rule.KindInfo {
 			MergeableAttrs: map[string]bool{"srcs": true, "deps": true},
 			ResolveAttrs:   map[string]bool{"deps": true},
 		},
+		"dart_binary": {
+			NonEmptyAttrs:  map[string]bool{"main": true},
+			MergeableAttrs: map[string]bool{"main": true, "srcs": true, "deps": true},
+			ResolveAttrs:   map[string]bool{"deps": true},
+		},
+		dartsrc.CodegenKind: {
+			NonEmptyAttrs:  map[string]bool{"srcs": true},
+			MergeableAttrs: map[string]bool{"srcs": true, "generators": true},
+		},
+		repositoryKind: {
+			NonEmptyAttrs:  map[string]bool{"package_name": true},
+			MergeableAttrs: map[string]bool{"version": true, "sha256": true, "url": true},
+		},
 		"dart_test": {
 			NonEmptyAttrs:  map[string]bool{"srcs": true},
 			MergeableAttrs: map[string]bool{"srcs": true, "deps": true},
@@ -69,20 +109,39 @@ func (d *dartLang) Loads() []rule.LoadInfo {
 	return []rule.LoadInfo{
 		{
 			Name:    "@rules_dart//:defs.bzl",
-			Symbols: []string{"dart_library", "dart_test"},
+			Symbols: []string{"dart_binary", "dart_library", "dart_test"},
+		},
+		{
+			Name:    "@rules_dart//:build_runner.bzl",
+			Symbols: []string{dartsrc.CodegenKind},
+		},
+		{
+			Name:    "@rules_dart//:repositories.bzl",
+			Symbols: []string{repositoryKind},
 		},
 	}
 }
 
 func (d *dartLang) Fix(c *config.Config, f *rule.File) {}
 
-func (d *dartLang) Configure(c *config.Config, rel string, f *rule.File) {}
+func (d *dartLang) Configure(c *config.Config, rel string, f *rule.File) {
+	dc := *getDartConfig(c)
+	dc.configurePackage(c, rel)
+	dc.configureDirectives(f)
+	d.configureLockfile(c, rel, &dc)
+	c.Exts[dartName] = &dc
+}
 
 func (d *dartLang) Imports(c *config.Config, r *rule.Rule, f *rule.File) []resolve.ImportSpec {
-	if r.Kind() == "dart_library" {
+	if r.Kind() != "dart_library" {
+		return nil
+	}
+	// Package libraries are imported by package name, and the libraries of
+	// other directories by the directory.
+	if r.AttrString("pubspec") != "" {
 		return []resolve.ImportSpec{{Lang: dartName, Imp: r.Name()}}
 	}
-	return nil
+	return []resolve.ImportSpec{{Lang: dartName, Imp: dartpkg.DirImport(f.Pkg)}}
 }
 
 func (d *dartLang) Embeds(r *rule.Rule, from label.Label) []label.Label { return nil }
@@ -90,14 +149,23 @@ func (d *dartLang) Embeds(r *rule.Rule, from label.Label) []label.Label { return
 func (d *dartLang) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.RemoteCache, r *rule.Rule, imports interface{}, from label.Label) {
 	deps := r.AttrStrings("deps")
 	importList := imports.([]string)
+	dc := getDartConfig(c)
 
 	for _, imp := range importList {
 		matches := ix.FindRulesByImportWithConfig(c, resolve.ImportSpec{Lang: dartName, Imp: imp}, dartName)
 		if len(matches) > 0 {
-			deps = append(deps, matches[0].Label.String())
+			if !matches[0].Label.Equal(from) {
+				deps = append(deps, matches[0].Label.String())
+			}
+		} else if strings.HasPrefix(imp, "//") {
+			log.Printf("%s: no dart_library for the imported files of %s", from, imp)
+		} else if dc.lockfile != nil {
+			if l, ok := dc.resolveLocked(imp); ok {
+				deps = append(deps, l.String())
+			}
 		} else {
-			l := label.Label{Repo: "dart_deps_" + imp, Name: imp}
-			deps = append(deps, l.String())
+			// Without a lockfile, assume a hosted package.
+			deps = append(deps, dartpkg.Label(dc.depsRepo, imp).String())
 		}
 	}
 
@@ -116,12 +184,47 @@ func (d *dartLang) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Rem
 	}
 }
 
+// DoneGeneratingRules writes the repository macro, if requested. It is not
+// written if every lockfile resolves through a dart_deps_repo, whose
+// repository the workspace declares instead.
+func (d *dartLang) DoneGeneratingRules() {
+	if d.macro == "" {
+		return
+	}
+	if len(d.hosted) == 0 && d.depsRepoLockfiles > 0 {
+		log.Printf("not writing repository macro %s: pub packages resolve through %s", d.macro, depsRepoDirective)
+		return
+	}
+	file, macro, ok := strings.Cut(d.macro, "%")
+	if !ok {
+		macro = "dart_dependencies"
+	}
+	pkgs := make([]pubspec.HostedPackage, 0, len(d.hosted))
+	for _, pkg := range d.hosted {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+
+	var buf bytes.Buffer
+	pubspec.WriteRepositoryMacro(&buf, macro, pkgs)
+	if err := os.WriteFile(filepath.Join(d.repoRoot, filepath.FromSlash(file)), buf.Bytes(), 0o644); err != nil {
+		log.Printf("failed to write repository macro: %v", err)
+	}
+}
+
 func (d *dartLang) GenerateRules(args language.GenerateArgs) language.GenerateResult {
 	res := language.GenerateResult{}
 
 	// Look for pubspec.yaml
 	pubspecPath := filepath.Join(args.Dir, "pubspec.yaml")
 	p, err := pubspec.ParsePubspec(pubspecPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		if r, imports := directoryLibrary(args); r != nil {
+			res.Gen = append(res.Gen, r)
+			res.Imports = append(res.Imports, imports)
+		}
+		return res
+	}
 	if err != nil {
 		return res
 	}
@@ -145,20 +248,58 @@ func (d *dartLang) GenerateRules(args language.GenerateArgs) language.GenerateRe
 		return nil
 	})
 	sort.Strings(srcs)
-	r.SetAttr("srcs", srcs)
+
+	// Generated parts are outputs of build_runner rather than sources
+	codegen, srcs := dartsrc.CodegenRule(args.Dir, p.Name, p.DevDependencies, srcs)
+	if codegen != nil {
+		r.SetAttr("srcs", append(srcs, ":"+codegen.Name()))
+	} else {
+		r.SetAttr("srcs", srcs)
+	}
 
 	r.SetAttr("pubspec", "pubspec.yaml")
 
-	// Add imports (dependencies)
-	var imports []string
-	for dep := range p.Dependencies {
-		imports = append(imports, dep)
-	}
-	sort.Strings(imports)
+	// Add imports (the packages imported by the sources)
+	imports, _ := splitSelf(dartsrc.ScanImports(args.Dir, srcs), p.Name)
 	r.SetPrivateAttr(config.GazelleImportsKey, imports)
 
 	res.Gen = append(res.Gen, r)
 	res.Imports = append(res.Imports, imports)
+	if codegen != nil {
+		res.Gen = append(res.Gen, codegen)
+		res.Imports = append(res.Imports, []string{})
+	}
+	used := make(map[string]bool)
+	for _, imp := range imports {
+		used[imp] = true
+	}
+
+	// Generate dart_binary targets
+	for _, b := range binaryRules(args.Dir, p) {
+		binImports := dartsrc.ScanImports(args.Dir, append([]string{b.AttrString("main")}, b.AttrStrings("srcs")...))
+		binImports, self := splitSelf(binImports, p.Name)
+		if self {
+			b.SetAttr("deps", []string{":" + p.Name})
+		}
+		for _, imp := range binImports {
+			used[imp] = true
+		}
+		b.SetPrivateAttr(config.GazelleImportsKey, binImports)
+		res.Gen = append(res.Gen, b)
+		res.Imports = append(res.Imports, binImports)
+	}
+
+	// Flag mismatches between the imports and the pubspec.
+	for _, imp := range sortedKeys(used) {
+		if _, ok := p.Dependencies[imp]; !ok {
+			log.Printf("%s: package %s is imported by lib/ or bin/ but is not in dependencies", pubspecPath, imp)
+		}
+	}
+	for _, dep := range sortedKeys(p.Dependencies) {
+		if !used[dep] {
+			log.Printf("%s: dependency %s is not imported by lib/ or bin/", pubspecPath, dep)
+		}
+	}
 
 	// Generate dart_test targets
 	testDir := filepath.Join(args.Dir, "test")
@@ -169,12 +310,117 @@ func (d *dartLang) GenerateRules(args language.GenerateArgs) language.GenerateRe
 				name := strings.TrimSuffix(entry.Name(), ".dart")
 				t := rule.NewRule("dart_test", name)
 				t.SetAttr("main", "test/"+entry.Name())
-				t.SetAttr("deps", []string{":" + p.Name})
+				if tags := getDartConfig(args.Config).testTags; len(tags) > 0 {
+					t.SetAttr("tags", tags)
+				}
+				testImports, self := splitSelf(dartsrc.ScanImports(args.Dir, []string{"test/" + entry.Name()}), p.Name)
+				if self {
+					t.SetAttr("deps", []string{":" + p.Name})
+				}
+				t.SetPrivateAttr(config.GazelleImportsKey, testImports)
 				res.Gen = append(res.Gen, t)
-				res.Imports = append(res.Imports, []string{})
+				res.Imports = append(res.Imports, testImports)
 			}
 		}
 	}
 
 	return res
 }
+
+// directoryLibrary returns a dart_library for the Dart files of a directory
+// without a pubspec.yaml inside a package, and its imports. The directories
+// of the package library, binaries and tests have no library of their own.
+func directoryLibrary(args language.GenerateArgs) (*rule.Rule, []string) {
+	return getDartConfig(args.Config).pkg.DirectoryLibrary("dart_library", args, "lib", "bin", "test")
+}
+
+// binaryRules returns a dart_binary rule for each entrypoint of the package:
+// the scripts named by the executables of the pubspec, and the other
+// bin/*.dart files. Dart files in subdirectories of bin/ are not entrypoints
+// and become srcs of every binary.
+func binaryRules(dir string, p *pubspec.Pubspec) []*rule.Rule {
+	binDir := filepath.Join(dir, "bin")
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		return nil
+	}
+
+	// Map each script to the name of its target.
+	names := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".dart") {
+			script := strings.TrimSuffix(entry.Name(), ".dart")
+			names[script] = script
+		}
+	}
+	for executable, script := range p.Executables {
+		if script == "" {
+			script = executable
+		}
+		if _, ok := names[script]; ok {
+			names[script] = executable
+		}
+	}
+
+	var srcs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		filepath.WalkDir(filepath.Join(binDir, entry.Name()), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), ".dart") {
+				rel, err := filepath.Rel(dir, path)
+				if err == nil {
+					srcs = append(srcs, filepath.ToSlash(rel))
+				}
+			}
+			return nil
+		})
+	}
+	sort.Strings(srcs)
+
+	scripts := make([]string, 0, len(names))
+	for script := range names {
+		scripts = append(scripts, script)
+	}
+	sort.Strings(scripts)
+
+	var rules []*rule.Rule
+	for _, script := range scripts {
+		name := names[script]
+		// The library takes the package name, which executables often share.
+		if name == p.Name {
+			name += "_bin"
+		}
+		b := rule.NewRule("dart_binary", name)
+		b.SetAttr("main", "bin/"+script+".dart")
+		if len(srcs) > 0 {
+			b.SetAttr("srcs", srcs)
+		}
+		rules = append(rules, b)
+	}
+	return rules
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitSelf removes self from pkgs and reports whether it was there.
+func splitSelf(pkgs []string, self string) ([]string, bool) {
+	for i, pkg := range pkgs {
+		if pkg == self {
+			return append(pkgs[:i:i], pkgs[i+1:]...), true
+		}
+	}
+	return pkgs, false
+}
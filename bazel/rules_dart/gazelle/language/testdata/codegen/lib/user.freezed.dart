@@ -0,0 +1 @@
+part of 'user.dart';
@@ -0,0 +1 @@
+import 'package:http/http.dart' as http;
@@ -0,0 +1,3 @@
+import 'package:app/app.dart';
+
+void main() => print(greet());
@@ -0,0 +1,4 @@
+import 'package:http/http.dart' as http;
+import 'package:util/util.dart';
+
+export 'src/impl.dart';
@@ -0,0 +1 @@
+String greet() => 'hi';
@@ -0,0 +1,4 @@
+import 'package:app/app.dart';
+import 'package:test/test.dart';
+
+void main() => test('greet', () => expect(greet(), 'hi'));
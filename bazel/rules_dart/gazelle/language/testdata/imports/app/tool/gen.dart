@@ -0,0 +1,5 @@
+import 'package:http/http.dart' as http;
+
+import '../lib/app.dart';
+
+void main() => print(greet());
@@ -0,0 +1 @@
+int twice(int x) => 2 * x;
@@ -0,0 +1,2 @@
+import 'package:http/http.dart' as http;
+import 'package:mirrored/mirrored.dart';
@@ -0,0 +1,110 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package language
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+
+	"github.com/google/rules_dart/gazelle/pubspec"
+)
+
+// repositoryKind is the kind of the repository rules of hosted packages.
+const repositoryKind = pubspec.RepositoryKind
+
+var (
+	_ language.RepoImporter = (*dartLang)(nil)
+	_ language.RepoUpdater  = (*dartLang)(nil)
+)
+
+// CanImport reports whether path is a pubspec.lock, for
+// `gazelle update-repos -from_file=pubspec.lock`.
+func (d *dartLang) CanImport(path string) bool {
+	return filepath.Base(path) == "pubspec.lock"
+}
+
+// ImportRepos returns a repository rule for each hosted package of a
+// pubspec.lock. Git and path packages are skipped.
+func (d *dartLang) ImportRepos(args language.ImportReposArgs) language.ImportReposResult {
+	l, err := pubspec.ParseLockfile(args.Path)
+	if err != nil {
+		return language.ImportReposResult{Error: err}
+	}
+	res := language.ImportReposResult{Gen: repositoryRules(l.HostedPackages())}
+	for _, name := range sortedKeys(l.Packages) {
+		if source := l.Packages[name].Source; source == "git" || source == "path" {
+			log.Printf("%s: skipping %s package %s", args.Path, source, name)
+		}
+	}
+
+	if args.Prune {
+		gen := make(map[string]bool)
+		for _, r := range res.Gen {
+			gen[r.Name()] = true
+		}
+		for _, r := range args.Config.Repos {
+			if r.Kind() == repositoryKind && !gen[r.Name()] {
+				res.Empty = append(res.Empty, rule.NewRule(repositoryKind, r.Name()))
+			}
+		}
+	}
+	return res
+}
+
+// UpdateRepos returns the repository rules of the packages named by the
+// imports, as locked by the pubspec.lock at the root of the repository, for
+// `gazelle update-repos -lang=dart <package>...`.
+func (d *dartLang) UpdateRepos(args language.UpdateReposArgs) language.UpdateReposResult {
+	lockPath := filepath.Join(args.Config.RepoRoot, "pubspec.lock")
+	l, err := pubspec.ParseLockfile(lockPath)
+	if err != nil {
+		return language.UpdateReposResult{Error: err}
+	}
+	hosted := make(map[string]pubspec.HostedPackage)
+	for _, pkg := range l.HostedPackages() {
+		hosted[pkg.Name] = pkg
+	}
+	var pkgs []pubspec.HostedPackage
+	for _, imp := range args.Imports {
+		pkg, ok := hosted[imp]
+		if !ok {
+			return language.UpdateReposResult{Error: fmt.Errorf("%s: no hosted package %s", lockPath, imp)}
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return language.UpdateReposResult{Gen: repositoryRules(pkgs)}
+}
+
+// repositoryRules returns the repository rules of pkgs.
+func repositoryRules(pkgs []pubspec.HostedPackage) []*rule.Rule {
+	rules := make([]*rule.Rule, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		r := rule.NewRule(repositoryKind, pubspec.RepositoryName(pkg.Name))
+		r.SetAttr("package_name", pkg.Name)
+		r.SetAttr("version", pkg.Version)
+		r.SetAttr("sha256", pkg.Sha256)
+		if pkg.URL != "" && pkg.URL != pubspec.DefaultHostedURL {
+			r.SetAttr("url", pkg.URL)
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
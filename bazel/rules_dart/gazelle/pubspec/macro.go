@@ -0,0 +1,89 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pubspec
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DefaultHostedURL is the URL of the default package server.
+const DefaultHostedURL = "https://pub.dev"
+
+// HostedPackage is a hosted package pinned by a lockfile.
+type HostedPackage struct {
+	Name    string
+	Version string
+	Sha256  string
+	// URL is the URL of the package server.
+	URL string
+}
+
+// HostedPackages returns the hosted packages of the lockfile, sorted by name.
+func (l *Lockfile) HostedPackages() []HostedPackage {
+	var pkgs []HostedPackage
+	for name, entry := range l.Packages {
+		hosted, err := entry.AsHosted()
+		if err != nil {
+			continue
+		}
+		pkgs = append(pkgs, HostedPackage{Name: name, Version: entry.Version, Sha256: hosted.Sha256, URL: hosted.Url})
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs
+}
+
+// RepositoryKind is the repository rule of hosted packages, declared both by
+// the repository macro and by `gazelle update-repos`.
+const RepositoryKind = "dart_package"
+
+// RepositoryName returns the name of the repository of a hosted package.
+func RepositoryName(pkg string) string {
+	return "dart_deps_" + pkg
+}
+
+// WriteRepositoryMacro writes a .bzl file defining a macro that declares a
+// RepositoryKind repository for each of pkgs.
+func WriteRepositoryMacro(w io.Writer, macro string, pkgs []HostedPackage) error {
+	fmt.Fprintln(w, "# Generated by rules_dart gazelle. DO NOT EDIT.")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "load(\"@rules_dart//:repositories.bzl\", \"%s\")\n", RepositoryKind)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "def %s():\n", macro)
+	fmt.Fprintln(w, "    \"\"\"Declares the hosted pub packages of the workspace.\"\"\"")
+	if len(pkgs) == 0 {
+		fmt.Fprintln(w, "    pass")
+	}
+	for i, pkg := range pkgs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "    %s(\n", RepositoryKind)
+		fmt.Fprintf(w, "        name = \"%s\",\n", RepositoryName(pkg.Name))
+		fmt.Fprintf(w, "        package_name = \"%s\",\n", pkg.Name)
+		fmt.Fprintf(w, "        version = \"%s\",\n", pkg.Version)
+		fmt.Fprintf(w, "        sha256 = \"%s\",\n", pkg.Sha256)
+		if pkg.URL != "" && pkg.URL != DefaultHostedURL {
+			fmt.Fprintf(w, "        url = \"%s\",\n", pkg.URL)
+		}
+		if _, err := fmt.Fprintln(w, "    )"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
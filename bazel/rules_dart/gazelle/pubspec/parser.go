@@ -77,3 +77,49 @@ func (p PackageEntry) AsHosted() (*HostedDescription, error) {
 
 	return h, nil
 }
+
+func (p PackageEntry) AsGit() (*GitDescription, error) {
+	if p.Source != "git" {
+		return nil, fmt.Errorf("not a git package")
+	}
+	m, ok := p.Description.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("description is not a map")
+	}
+
+	g := &GitDescription{}
+	if val, ok := m["path"].(string); ok {
+		g.Path = val
+	}
+	if val, ok := m["ref"].(string); ok {
+		g.Ref = val
+	}
+	if val, ok := m["resolved-ref"].(string); ok {
+		g.ResolvedRef = val
+	}
+	if val, ok := m["url"].(string); ok {
+		g.Url = val
+	}
+
+	return g, nil
+}
+
+func (p PackageEntry) AsPath() (*PathDescription, error) {
+	if p.Source != "path" {
+		return nil, fmt.Errorf("not a path package")
+	}
+	m, ok := p.Description.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("description is not a map")
+	}
+
+	d := &PathDescription{}
+	if val, ok := m["path"].(string); ok {
+		d.Path = val
+	}
+	if val, ok := m["relative"].(bool); ok {
+		d.Relative = val
+	}
+
+	return d, nil
+}
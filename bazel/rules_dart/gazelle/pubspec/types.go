@@ -22,6 +22,10 @@ type Pubspec struct {
 	Dependencies    map[string]interface{} `yaml:"dependencies"`
 	DevDependencies map[string]interface{} `yaml:"dev_dependencies"`
 	Environment     map[string]string      `yaml:"environment"`
+	// Executables maps executable names to the scripts in bin/ that
+	// implement them, without the .dart extension. An empty script means
+	// the script has the same name as the executable.
+	Executables map[string]string `yaml:"executables"`
 }
 
 // Lockfile represents pubspec.lock
@@ -51,3 +55,9 @@ type GitDescription struct {
 	ResolvedRef string `yaml:"resolved-ref"`
 	Url         string `yaml:"url"`
 }
+
+// PathDescription represents the description block for source: path
+type PathDescription struct {
+	Path     string `yaml:"path"`
+	Relative bool   `yaml:"relative"`
+}
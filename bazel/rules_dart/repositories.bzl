@@ -315,7 +315,8 @@ def _extract_pubspec_deps(content):
     return deps
 
 def _dart_package_impl(ctx):
-    url = "https://pub.dev/api/archives/{}-{}.tar.gz".format(ctx.attr.package_name, ctx.attr.version)
+    hosted_url = ctx.attr.url or "https://pub.dev"
+    url = "{}/api/archives/{}-{}.tar.gz".format(hosted_url.rstrip("/"), ctx.attr.package_name, ctx.attr.version)
 
     # Use manual download and extraction to avoid Java GZIP issues with some packages
     archive = "package.tar.gz"
@@ -360,10 +361,19 @@ dart_library(
 
 dart_package = repository_rule(
     implementation = _dart_package_impl,
+    doc = """A hosted pub package, as declared by the gazelle repository macro and `gazelle update-repos` from pubspec.lock.
+
+    The repository must be named dart_deps_<package_name> so that the packages
+    depending on it find it.
+    """,
     attrs = {
         "package_name": attr.string(mandatory = True),
         "version": attr.string(mandatory = True),
         "sha256": attr.string(mandatory = True),
+        "url": attr.string(
+            doc = "URL of the package server, from the hosted description of pubspec.lock.",
+            default = "https://pub.dev",
+        ),
         "rules_dart_label": attr.string(default = "@rules_dart//:defs.bzl"),
     },
 )
@@ -4,6 +4,7 @@ go 1.23
 
 require (
 	github.com/bazelbuild/bazel-gazelle v0.35.0
+	github.com/google/rules_dart/gazelle v0.0.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -13,3 +14,7 @@ require (
 	golang.org/x/sys v0.15.0 // indirect
 	golang.org/x/tools/go/vcs v0.1.0-deprecated // indirect
 )
+
+// The Dart source scanning and package tracking are shared with the gazelle
+// extension of rules_dart, which is developed alongside this module.
+replace github.com/google/rules_dart/gazelle => ../../rules_dart/gazelle
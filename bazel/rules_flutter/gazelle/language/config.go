@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package language
+
+import (
+	"path"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+
+	"github.com/google/rules_dart/gazelle/dartpkg"
+	"github.com/google/rules_flutter/gazelle/pubspec"
+)
+
+const (
+	// testTagsDirective sets the tags of the generated test rules, as a
+	// comma-separated list. An empty value clears them.
+	testTagsDirective = "flutter_test_tags"
+	// assetsDirDirective sets the directory whose files are the assets of a
+	// package without an assets section in its pubspec.
+	assetsDirDirective = "flutter_assets_dir"
+	// depsRepoDirective sets a repository whose package //<name> holds the
+	// target of each pub package, as in `# gazelle:flutter_deps_repo @pub_deps`.
+	depsRepoDirective = "flutter_deps_repo"
+)
+
+// flutterConfig is the configuration of a directory, stored in the Exts of
+// its config.Config and inherited by subdirectories.
+type flutterConfig struct {
+	testTags  []string
+	assetsDir string
+	// depsRepo is the repository holding a target for each pub package. If
+	// empty, each package has its own dart_deps_<name> repository.
+	depsRepo string
+	// pkg is the nearest package at or above the directory, and
+	// packageKind the kind of its library.
+	pkg         dartpkg.Package
+	packageKind string
+}
+
+func getFlutterConfig(c *config.Config) *flutterConfig {
+	if fc, ok := c.Exts[flutterName].(*flutterConfig); ok {
+		return fc
+	}
+	return &flutterConfig{assetsDir: "assets"}
+}
+
+// configureDirectives applies the directives of f to fc.
+func (fc *flutterConfig) configureDirectives(f *rule.File) {
+	if f == nil {
+		return
+	}
+	for _, directive := range f.Directives {
+		switch directive.Key {
+		case testTagsDirective:
+			fc.testTags = nil
+			for _, tag := range strings.Split(directive.Value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					fc.testTags = append(fc.testTags, tag)
+				}
+			}
+		case assetsDirDirective:
+			fc.assetsDir = path.Clean(strings.TrimSpace(directive.Value))
+		case depsRepoDirective:
+			fc.depsRepo = strings.TrimLeft(strings.TrimSpace(directive.Value), "@")
+		}
+	}
+}
+
+// configurePackage records the package of the directory rel, if any.
+func (fc *flutterConfig) configurePackage(c *config.Config, rel string) {
+	p, ok := dartpkg.ReadPubspec(c, rel, pubspec.ParsePubspec)
+	if !ok {
+		return
+	}
+	fc.pkg = dartpkg.Package{Name: p.Name, Dir: rel}
+	fc.packageKind = "dart_library"
+	if p.IsFlutterPackage() {
+		fc.packageKind = "flutter_library"
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package language
+
+import (
+	"flag"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/merger"
+	"github.com/bazelbuild/bazel-gazelle/repo"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+// The golden tests run the extension over each directory of testdata as
+// `gazelle update` would, reading BUILD.in files as the existing build files,
+// and compare the result with the BUILD.out file of every directory.
+
+func TestGazelle(t *testing.T) {
+	cases, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range cases {
+		if !tc.IsDir() {
+			continue
+		}
+		t.Run(tc.Name(), func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.CopyFS(root, os.DirFS(filepath.Join("testdata", tc.Name()))); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, root, runGazelle(t, root))
+		})
+	}
+}
+
+// runGazelle updates the repository at root and returns the resulting build
+// file of each directory with rules, keyed by its path relative to root.
+func runGazelle(t *testing.T, root string) map[string][]byte {
+	t.Helper()
+	c := config.New()
+	c.WorkDir = root
+	lang := NewLanguage()
+	cexts := []config.Configurer{&config.CommonConfigurer{}, &resolve.Configurer{}, lang}
+	fs := flag.NewFlagSet("gazelle", flag.ContinueOnError)
+	for _, cext := range cexts {
+		cext.RegisterFlags(fs, "update", c)
+	}
+	if err := fs.Parse([]string{"-repo_root=" + root, "-build_file_name=BUILD.in"}); err != nil {
+		t.Fatalf("parsing flags: %v", err)
+	}
+	for _, cext := range cexts {
+		if err := cext.CheckFlags(fs, c); err != nil {
+			t.Fatalf("CheckFlags() returned error: %v", err)
+		}
+	}
+
+	kinds := lang.Kinds()
+	resolver := lang.(resolve.Resolver)
+	ix := resolve.NewRuleIndex(func(r *rule.Rule, pkgRel string) resolve.Resolver {
+		if _, ok := kinds[r.Kind()]; ok {
+			return resolver
+		}
+		return nil
+	})
+	type visit struct {
+		c       *config.Config
+		f       *rule.File
+		gen     []*rule.Rule
+		imports []interface{}
+	}
+	var visits []visit
+	// Directories are configured top-down and generated bottom-up, as by
+	// gazelle's walk.
+	var visitDir func(parent *config.Config, rel string)
+	visitDir = func(parent *config.Config, rel string) {
+		dir := filepath.Join(c.RepoRoot, filepath.FromSlash(rel))
+		var f *rule.File
+		if data, err := os.ReadFile(filepath.Join(dir, "BUILD.in")); err == nil {
+			if f, err = rule.LoadData(filepath.Join(dir, "BUILD.in"), rel, data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c := parent.Clone()
+		for _, cext := range cexts {
+			cext.Configure(c, rel, f)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var subdirs, regularFiles []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				subdirs = append(subdirs, entry.Name())
+				visitDir(c, path.Join(rel, entry.Name()))
+			} else {
+				regularFiles = append(regularFiles, entry.Name())
+			}
+		}
+
+		res := lang.GenerateRules(language.GenerateArgs{
+			Config:       c,
+			Dir:          dir,
+			Rel:          rel,
+			File:         f,
+			Subdirs:      subdirs,
+			RegularFiles: regularFiles,
+		})
+		if f == nil {
+			if len(res.Gen) == 0 {
+				return
+			}
+			f = rule.EmptyFile(filepath.Join(dir, "BUILD.in"), rel)
+		}
+		merger.MergeFile(f, res.Empty, res.Gen, merger.PreResolve, kinds)
+		for _, r := range res.Gen {
+			ix.AddRule(c, r, f)
+		}
+		visits = append(visits, visit{c: c, f: f, gen: res.Gen, imports: res.Imports})
+	}
+	visitDir(c, "")
+	ix.Finish()
+
+	rc, cleanup := repo.NewRemoteCache(nil)
+	defer cleanup()
+	files := make(map[string][]byte)
+	for _, v := range visits {
+		for i, r := range v.gen {
+			resolver.Resolve(v.c, ix, rc, r, v.imports[i], label.New(v.c.RepoName, v.f.Pkg, r.Name()))
+		}
+		merger.MergeFile(v.f, nil, v.gen, merger.PostResolve, kinds)
+		merger.FixLoads(v.f, lang.Loads())
+		files[v.f.Pkg] = v.f.Format()
+	}
+	return files
+}
+
+// checkGolden compares files with the BUILD.out files of root.
+func checkGolden(t *testing.T, root string, files map[string][]byte) {
+	t.Helper()
+	for pkg, got := range files {
+		want, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(pkg), "BUILD.out"))
+		if err != nil {
+			t.Errorf("%s: generated rules but has no BUILD.out:\n%s", path.Join(pkg, "BUILD"), got)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", path.Join(pkg, "BUILD"), got, want)
+		}
+	}
+	filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "BUILD.out" {
+			return err
+		}
+		rel, _ := filepath.Rel(root, filepath.Dir(p))
+		if pkg := filepath.ToSlash(rel); files[pkgOrRoot(pkg)] == nil {
+			t.Errorf("%s: expected rules, but none were generated", path.Join(pkg, "BUILD.out"))
+		}
+		return nil
+	})
+}
+
+// pkgOrRoot returns pkg, or "" for the root directory ".".
+func pkgOrRoot(pkg string) string {
+	if pkg == "." {
+		return ""
+	}
+	return pkg
+}
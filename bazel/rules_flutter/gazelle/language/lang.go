@@ -17,8 +17,10 @@
 package language
 
 import (
+	"errors"
 	"flag"
 	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -31,6 +33,8 @@ import (
 	"github.com/bazelbuild/bazel-gazelle/resolve"
 	"github.com/bazelbuild/bazel-gazelle/rule"
 
+	"github.com/google/rules_dart/gazelle/dartpkg"
+	"github.com/google/rules_dart/gazelle/dartsrc"
 	"github.com/google/rules_flutter/gazelle/pubspec"
 )
 
@@ -48,7 +52,9 @@ func (d *flutterLang) RegisterFlags(fs *flag.FlagSet, cmd string, c *config.Conf
 
 func (d *flutterLang) CheckFlags(fs *flag.FlagSet, c *config.Config) error { return nil }
 
-func (d *flutterLang) KnownDirectives() []string { return nil }
+func (d *flutterLang) KnownDirectives() []string {
+	return []string{testTagsDirective, assetsDirDirective, depsRepoDirective}
+}
 
 func (d *flutterLang) Kinds() map[string]rule.KindInfo {
 	return map[string]rule.KindInfo{
@@ -62,11 +68,23 @@ func (d *flutterLang) Kinds() map[string]rule.KindInfo {
 			MergeableAttrs: map[string]bool{"srcs": true, "deps": true},
 			ResolveAttrs:   map[string]bool{"deps": true},
 		},
+		"flutter_application": {
+			NonEmptyAttrs:  map[string]bool{"deps": true},
+			MergeableAttrs: map[string]bool{"deps": true, "target_platform": true},
+		},
+		"flutter_binary": {
+			NonEmptyAttrs:  map[string]bool{"deps": true},
+			MergeableAttrs: map[string]bool{"deps": true},
+		},
 		"dart_library": {
 			NonEmptyAttrs:  map[string]bool{"srcs": true},
 			MergeableAttrs: map[string]bool{"srcs": true, "deps": true},
 			ResolveAttrs:   map[string]bool{"deps": true},
 		},
+		dartsrc.CodegenKind: {
+			NonEmptyAttrs:  map[string]bool{"srcs": true},
+			MergeableAttrs: map[string]bool{"srcs": true, "generators": true},
+		},
 	}
 }
 
@@ -74,24 +92,38 @@ func (d *flutterLang) Loads() []rule.LoadInfo {
 	return []rule.LoadInfo{
 		{
 			Name:    "@rules_flutter//:defs.bzl",
-			Symbols: []string{"flutter_library", "flutter_test"},
+			Symbols: []string{"flutter_application", "flutter_binary", "flutter_library", "flutter_test"},
 		},
 		{
 			Name:    "@rules_dart//:defs.bzl",
 			Symbols: []string{"dart_library"},
 		},
+		{
+			Name:    "@rules_dart//:build_runner.bzl",
+			Symbols: []string{dartsrc.CodegenKind},
+		},
 	}
 }
 
 func (d *flutterLang) Fix(c *config.Config, f *rule.File) {}
 
-func (d *flutterLang) Configure(c *config.Config, rel string, f *rule.File) {}
+func (d *flutterLang) Configure(c *config.Config, rel string, f *rule.File) {
+	fc := *getFlutterConfig(c)
+	fc.configurePackage(c, rel)
+	fc.configureDirectives(f)
+	c.Exts[flutterName] = &fc
+}
 
 func (d *flutterLang) Imports(c *config.Config, r *rule.Rule, f *rule.File) []resolve.ImportSpec {
-	if r.Kind() == "flutter_library" || r.Kind() == "dart_library" {
+	if r.Kind() != "flutter_library" && r.Kind() != "dart_library" {
+		return nil
+	}
+	// Package libraries are imported by package name, and the libraries of
+	// other directories by the directory.
+	if r.AttrString("pubspec") != "" {
 		return []resolve.ImportSpec{{Lang: flutterName, Imp: r.Name()}}
 	}
-	return nil
+	return []resolve.ImportSpec{{Lang: flutterName, Imp: dartpkg.DirImport(f.Pkg)}}
 }
 
 func (d *flutterLang) Embeds(r *rule.Rule, from label.Label) []label.Label { return nil }
@@ -103,13 +135,15 @@ func (d *flutterLang) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.
 	for _, imp := range importList {
 		matches := ix.FindRulesByImportWithConfig(c, resolve.ImportSpec{Lang: flutterName, Imp: imp}, flutterName)
 		if len(matches) > 0 {
-			deps = append(deps, matches[0].Label.String())
+			if !matches[0].Label.Equal(from) {
+				deps = append(deps, matches[0].Label.String())
+			}
+		} else if strings.HasPrefix(imp, "//") {
+			log.Printf("%s: no library for the imported files of %s", from, imp)
 		} else {
-			// Assume external dependency managed by dart_deps/flutter_deps
-			// Note: rules_flutter might share dependency namespace or have its own
-			// For now, let's assume it resolves to dart_deps_PACKAGE
-			l := label.Label{Repo: "dart_deps_" + imp, Name: imp}
-			deps = append(deps, l.String())
+			// Assume external dependency managed by dart_deps/flutter_deps,
+			// or by the repository of the flutter_deps_repo directive
+			deps = append(deps, dartpkg.Label(getFlutterConfig(c).depsRepo, imp).String())
 		}
 	}
 
@@ -134,6 +168,13 @@ func (d *flutterLang) GenerateRules(args language.GenerateArgs) language.Generat
 	// Look for pubspec.yaml
 	pubspecPath := filepath.Join(args.Dir, "pubspec.yaml")
 	p, err := pubspec.ParsePubspec(pubspecPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		if r, imports := directoryLibrary(args); r != nil {
+			res.Gen = append(res.Gen, r)
+			res.Imports = append(res.Imports, imports)
+		}
+		return res
+	}
 	if err != nil {
 		return res
 	}
@@ -162,11 +203,13 @@ func (d *flutterLang) GenerateRules(args language.GenerateArgs) language.Generat
 		}
 		return nil
 	})
-	
-	// Collect assets if Flutter
-	if isFlutter {
+
+	// Collect assets if Flutter, preferring the assets of the pubspec
+	if assets := p.Assets(); isFlutter && len(assets) > 0 {
+		r.SetAttr("assets", assetGlob(assets))
+	} else if isFlutter {
 		assets := []string{}
-		assetsDir := filepath.Join(args.Dir, "assets")
+		assetsDir := filepath.Join(args.Dir, filepath.FromSlash(getFlutterConfig(args.Config).assetsDir))
 		filepath.WalkDir(assetsDir, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return nil
@@ -186,7 +229,14 @@ func (d *flutterLang) GenerateRules(args language.GenerateArgs) language.Generat
 	}
 
 	sort.Strings(srcs)
-	r.SetAttr("srcs", srcs)
+
+	// Generated parts are outputs of build_runner rather than sources
+	codegen, srcs := dartsrc.CodegenRule(args.Dir, p.Name, p.DevDependencies, srcs)
+	if codegen != nil {
+		r.SetAttr("srcs", append(srcs, ":"+codegen.Name()))
+	} else {
+		r.SetAttr("srcs", srcs)
+	}
 
 	r.SetAttr("pubspec", "pubspec.yaml")
 
@@ -200,6 +250,18 @@ func (d *flutterLang) GenerateRules(args language.GenerateArgs) language.Generat
 
 	res.Gen = append(res.Gen, r)
 	res.Imports = append(res.Imports, imports)
+	if codegen != nil {
+		res.Gen = append(res.Gen, codegen)
+		res.Imports = append(res.Imports, []string{})
+	}
+
+	// Generate application targets
+	if isFlutter {
+		for _, app := range appRules(args.Dir, p.Name) {
+			res.Gen = append(res.Gen, app)
+			res.Imports = append(res.Imports, []string{})
+		}
+	}
 
 	// Generate test targets
 	testDir := filepath.Join(args.Dir, "test")
@@ -214,9 +276,12 @@ func (d *flutterLang) GenerateRules(args language.GenerateArgs) language.Generat
 				if isFlutter {
 					testKind = "flutter_test"
 				}
-				
+
 				t := rule.NewRule(testKind, name)
 				t.SetAttr("main", "test/"+entry.Name())
+				if tags := getFlutterConfig(args.Config).testTags; len(tags) > 0 {
+					t.SetAttr("tags", tags)
+				}
 				t.SetAttr("deps", []string{":" + p.Name})
 				res.Gen = append(res.Gen, t)
 				res.Imports = append(res.Imports, []string{})
@@ -226,3 +291,55 @@ func (d *flutterLang) GenerateRules(args language.GenerateArgs) language.Generat
 
 	return res
 }
+
+// directoryLibrary returns a library for the Dart files of a directory
+// without a pubspec.yaml inside a package, and its imports. The directories
+// of the package library and tests, and platform directories, have no
+// library of their own.
+func directoryLibrary(args language.GenerateArgs) (*rule.Rule, []string) {
+	fc := getFlutterConfig(args.Config)
+	return fc.pkg.DirectoryLibrary(fc.packageKind, args, append([]string{"lib", "test"}, platforms...)...)
+}
+
+// platforms are the platform folders of a Flutter app, in the order of their
+// targets.
+var platforms = []string{"android", "ios", "web", "macos", "linux", "windows"}
+
+// appRules returns the targets of a Flutter app with an entrypoint at
+// lib/main.dart: a flutter_binary to run it, and a flutter_application for
+// each platform folder of the package.
+func appRules(dir, name string) []*rule.Rule {
+	if _, err := os.Stat(filepath.Join(dir, "lib", "main.dart")); err != nil {
+		return nil
+	}
+	deps := []string{":" + name}
+
+	run := rule.NewRule("flutter_binary", name+"_run")
+	run.SetAttr("deps", deps)
+	rules := []*rule.Rule{run}
+
+	for _, platform := range platforms {
+		info, err := os.Stat(filepath.Join(dir, platform))
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		app := rule.NewRule("flutter_application", name+"_"+platform)
+		app.SetAttr("deps", deps)
+		app.SetAttr("target_platform", platform)
+		rules = append(rules, app)
+	}
+	return rules
+}
+
+// assetGlob returns a glob of the assets of a pubspec. Like Flutter, a
+// directory includes its files but not its subdirectories.
+func assetGlob(assets []string) rule.GlobValue {
+	patterns := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		if strings.HasSuffix(asset, "/") {
+			asset += "*"
+		}
+		patterns = append(patterns, asset)
+	}
+	return rule.GlobValue{Patterns: patterns}
+}
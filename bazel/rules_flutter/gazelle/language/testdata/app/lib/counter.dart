@@ -0,0 +1,3 @@
+class Counter {
+  int value = 0;
+}
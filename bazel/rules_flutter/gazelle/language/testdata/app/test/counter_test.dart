@@ -0,0 +1,3 @@
+import 'package:counter/counter.dart';
+
+void main() {}
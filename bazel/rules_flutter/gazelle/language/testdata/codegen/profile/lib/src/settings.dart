@@ -0,0 +1,6 @@
+import 'package:json_annotation/json_annotation.dart';
+
+part 'settings.g.dart';
+
+@JsonSerializable()
+class Settings {}
@@ -0,0 +1 @@
+part of 'settings.dart';
@@ -0,0 +1,3 @@
+import 'package:shop/cart.dart';
+
+void main() {}
@@ -0,0 +1,3 @@
+import '../tool/gen.dart' as gen;
+
+void main() => gen.main();
@@ -0,0 +1 @@
+void pump() {}
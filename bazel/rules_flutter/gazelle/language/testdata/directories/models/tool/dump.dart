@@ -0,0 +1,3 @@
+import '../lib/models.dart';
+
+void main() => print(Model());
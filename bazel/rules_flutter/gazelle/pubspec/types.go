@@ -35,3 +35,22 @@ func (p *Pubspec) IsFlutterPackage() bool {
 	}
 	return false
 }
+
+// Assets returns the paths of the assets section of the flutter section.
+// Paths ending in a slash name directories whose files are all assets.
+func (p *Pubspec) Assets() []string {
+	entries, _ := p.Flutter["assets"].([]interface{})
+	var assets []string
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case string:
+			assets = append(assets, e)
+		case map[string]interface{}:
+			// Assets with flavors or transformers are given as maps.
+			if path, ok := e["path"].(string); ok {
+				assets = append(assets, path)
+			}
+		}
+	}
+	return assets
+}
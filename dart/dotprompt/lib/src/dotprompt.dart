@@ -56,9 +56,11 @@ import "dart:convert";
 import "package:handlebarrz/handlebarrz.dart";
 
 import "error.dart";
+import "helpers/if_equals_helper.dart";
 import "helpers/media_helper.dart";
 import "helpers/role_helper.dart";
 import "helpers/section_helper.dart";
+import "helpers/unless_equals_helper.dart";
 import "models/models.dart";
 import "parse.dart";
 import "picoschema.dart";
@@ -360,7 +362,7 @@ class Dotprompt {
       })
       // Register ifEquals block helper
       ..registerHelper("ifEquals", (args, options) {
-        if (args.length >= 2 && args[0] == args[1]) {
+        if (args.length >= 2 && IfEqualsHelper.areEqual(args[0], args[1])) {
           return options.fn(options.context);
         } else {
           return options.inverse(options.context);
@@ -368,7 +370,7 @@ class Dotprompt {
       })
       // Register unlessEquals block helper
       ..registerHelper("unlessEquals", (args, options) {
-        if (args.length >= 2 && args[0] != args[1]) {
+        if (args.length >= 2 && UnlessEqualsHelper.areNotEqual(args[0], args[1])) {
           return options.fn(options.context);
         } else {
           return options.inverse(options.context);
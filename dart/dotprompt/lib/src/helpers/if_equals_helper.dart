@@ -41,16 +41,27 @@ class IfEqualsHelper {
 
   /// Checks if two values are equal.
   ///
-  /// Values are considered equal if:
-  /// - They are identical (===)
-  /// - Their string representations are equal
-  /// - Both are null
+  /// Values of different types are never equal, numbers compare by value
+  /// whether they are ints or doubles, and maps and lists are compared
+  /// element by element. The other runtimes follow the same rules.
   static bool areEqual(dynamic a, dynamic b) {
     if (identical(a, b)) return true;
-    if (a == null && b == null) return true;
     if (a == null || b == null) return false;
-
-    // Compare as strings for flexible matching
-    return a.toString() == b.toString();
+    if (a is num && b is num) return a == b;
+    if (a is List && b is List) {
+      if (a.length != b.length) return false;
+      for (var i = 0; i < a.length; i++) {
+        if (!areEqual(a[i], b[i])) return false;
+      }
+      return true;
+    }
+    if (a is Map && b is Map) {
+      if (a.length != b.length) return false;
+      for (final key in a.keys) {
+        if (!b.containsKey(key) || !areEqual(a[key], b[key])) return false;
+      }
+      return true;
+    }
+    return a == b;
   }
 }
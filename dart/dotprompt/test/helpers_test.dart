@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/// Unit tests for the ifEquals and unlessEquals helpers, mirroring the
+/// number_comparison and deep_comparison cases of spec/helpers/ifEquals.yaml
+/// and spec/helpers/unlessEquals.yaml.
+library;
+
+import "package:dotprompt/dotprompt.dart";
+import "package:test/test.dart";
+
+void main() {
+  // Each case is (description, value1, value2, equal).
+  final cases = <(String, dynamic, dynamic, bool)>[
+    ("treats integers and equal floats as equal", 5, 5.0, true),
+    ("treats booleans and numbers as not equal", true, 1, false),
+    ("treats strings and numbers as not equal", "5", 5, false),
+    ("treats null and a value as not equal", null, 0, false),
+    ("treats objects with equal fields as equal", {"a": 1, "b": ["x", "y"]}, {"b": ["x", "y"], "a": 1}, true),
+    ("treats objects with different fields as not equal", {"a": 1}, {"a": 1, "b": 2}, false),
+    ("treats arrays with equal elements as equal", [1, [2, 3]], [1, [2, 3]], true),
+    ("treats arrays in a different order as not equal", [1, 2], [2, 1], false),
+  ];
+
+  group("IfEqualsHelper.areEqual", () {
+    for (final (desc, a, b, equal) in cases) {
+      test(desc, () {
+        expect(IfEqualsHelper.areEqual(a, b), equals(equal));
+        expect(IfEqualsHelper.areEqual(b, a), equals(equal));
+      });
+    }
+  });
+
+  group("UnlessEqualsHelper.areNotEqual", () {
+    for (final (desc, a, b, equal) in cases) {
+      test(desc, () {
+        expect(UnlessEqualsHelper.areNotEqual(a, b), equals(!equal));
+      });
+    }
+  });
+
+  group("render", () {
+    late Dotprompt dotprompt;
+
+    setUp(() {
+      dotprompt = Dotprompt();
+    });
+
+    Future<String> render(String template, dynamic value1, dynamic value2) async {
+      final result = await dotprompt.render(template, DataArgument(input: {"value1": value1, "value2": value2}));
+      return (result.messages.first.content.first as TextPart).text.trim();
+    }
+
+    for (final (desc, a, b, equal) in cases) {
+      test("ifEquals $desc", () async {
+        expect(
+          await render("{{#ifEquals value1 value2}}equal{{else}}not equal{{/ifEquals}}", a, b),
+          equals(equal ? "equal" : "not equal"),
+        );
+      });
+
+      test("unlessEquals $desc", () async {
+        expect(
+          await render("{{#unlessEquals value1 value2}}not equal{{else}}equal{{/unlessEquals}}", a, b),
+          equals(equal ? "equal" : "not equal"),
+        );
+      });
+    }
+  });
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command dotprompt-registry serves a directory of prompts as a central
+// prompt registry, for use with registry.Client, registry.GRPCClient or any
+// gRPC or Connect client of registry.proto. It serves HTTP/1.1 and
+// unencrypted HTTP/2, which gRPC clients need without TLS.
+//
+// Usage:
+//
+//	dotprompt-registry [-addr localhost:8080] [-read-only] [-keep-versions] [-poll 2s] [root]
+//
+// The registry does not authenticate its callers, so it listens on localhost
+// by default. Pass -read-only before exposing it on other interfaces, unless
+// it is behind an authenticating proxy.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/registry"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stderr))
+}
+
+// run serves the registry until ctx is done and returns the exit code.
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("dotprompt-registry", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	readOnly := fs.Bool("read-only", false, "reject Save, SavePartial and Delete")
+	keepVersions := fs.Bool("keep-versions", false, "archive every saved version of a prompt")
+	poll := fs.Duration("poll", registry.DefaultPollInterval, "how often watch streams check the directory for changes")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(stderr, "dotprompt-registry: at most one root directory is allowed")
+		return 2
+	}
+	root := "."
+	if fs.NArg() == 1 {
+		root = fs.Arg(0)
+	}
+
+	store, err := dotprompt.NewDirStore(root)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotprompt-registry: %v\n", err)
+		return 1
+	}
+	store.KeepVersions = *keepVersions
+	server := registry.NewServer(store)
+	server.PollInterval = *poll
+	server.ReadOnly = *readOnly
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	httpServer := &http.Server{Addr: *addr, Handler: server, Protocols: &protocols}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	logger := log.New(stderr, "", log.LstdFlags)
+	logger.Printf("serving %s on %s", store.Root, *addr)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		logger.Print(err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command dotprompt-wasm exposes the Go dotprompt runtime to JavaScript, so
+// browser playgrounds and editor webviews render prompts exactly as servers
+// do. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o dotprompt.wasm ./cmd/dotprompt-wasm
+//
+// and load it with the dotprompt.mjs loader in this directory, after the
+// toolchain's $(go env GOROOT)/lib/wasm/wasm_exec.js. Once running, the
+// module defines globalThis.dotprompt with three functions:
+//
+//	parse(source)                    -> ParsedPrompt
+//	render(source, data?, options?)  -> RenderedPrompt
+//	validate(source, options?)       -> Diagnostic[]
+//
+// data is {input, context} and options is {partials: {name: source}}. Each
+// function returns {result} on success and {error} on failure.
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/lint"
+)
+
+// options are the options shared by render and validate.
+type options struct {
+	// Partials maps partial names to their sources.
+	Partials map[string]string `json:"partials"`
+}
+
+// renderData is the data argument accepted by render. Like the HTTP
+// handler, only input and context are accepted from JSON.
+type renderData struct {
+	Input   map[string]any `json:"input"`
+	Context map[string]any `json:"context"`
+}
+
+// parse parses source without rendering it.
+func parse(source string) (dotprompt.ParsedPrompt, error) {
+	return dotprompt.ParseDocument(source)
+}
+
+// render renders source with data, using the same engine as server-side
+// rendering.
+func render(source string, data renderData, opts options) (dotprompt.RenderedPrompt, error) {
+	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{Partials: opts.Partials})
+	return dp.Render(source, &dotprompt.DataArgument{Input: data.Input, Context: data.Context}, nil)
+}
+
+// validate lints source, resolving partial references against
+// opts.Partials.
+func validate(source string, opts options) []lint.Diagnostic {
+	diags := lint.Source("prompt", source, &lint.Options{Store: partialStore(opts.Partials)})
+	if diags == nil {
+		diags = []lint.Diagnostic{}
+	}
+	return diags
+}
+
+// partialStore is a prompt store holding only partials, keyed by name.
+type partialStore map[string]string
+
+func (s partialStore) List(dotprompt.ListPromptsOptions) (dotprompt.ListPromptsResult[dotprompt.PromptRef], error) {
+	return dotprompt.ListPromptsResult[dotprompt.PromptRef]{}, nil
+}
+
+func (s partialStore) ListPartials(dotprompt.ListPartialsOptions) (dotprompt.ListPartialsResult[dotprompt.PartialRef], error) {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result dotprompt.ListPartialsResult[dotprompt.PartialRef]
+	for _, name := range names {
+		result.Items = append(result.Items, dotprompt.PartialRef{Name: name})
+	}
+	return result, nil
+}
+
+func (s partialStore) Load(name string, _ dotprompt.LoadPromptOptions) (dotprompt.PromptData, error) {
+	return dotprompt.PromptData{}, fmt.Errorf("prompt %q %w", name, dotprompt.ErrNotFound)
+}
+
+func (s partialStore) LoadPartial(name string, _ dotprompt.LoadPartialOptions) (dotprompt.PartialData, error) {
+	source, ok := s[name]
+	if !ok {
+		return dotprompt.PartialData{}, fmt.Errorf("partial %q %w", name, dotprompt.ErrNotFound)
+	}
+	return dotprompt.PartialData{PartialRef: dotprompt.PartialRef{Name: name}, Source: source}, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+func TestParse(t *testing.T) {
+	parsed, err := parse("---\nmodel: test-model\n---\nHello {{name}}")
+	if err != nil {
+		t.Fatalf("parse() returned error: %v", err)
+	}
+	if parsed.Model != "test-model" {
+		t.Errorf("parse() model = %q, want %q", parsed.Model, "test-model")
+	}
+	if parsed.Template != "Hello {{name}}" {
+		t.Errorf("parse() template = %q, want %q", parsed.Template, "Hello {{name}}")
+	}
+}
+
+func TestRender(t *testing.T) {
+	rendered, err := render("Hello {{name}}{{> punct}}",
+		renderData{Input: map[string]any{"name": "Ada"}},
+		options{Partials: map[string]string{"punct": "!"}})
+	if err != nil {
+		t.Fatalf("render() returned error: %v", err)
+	}
+	if len(rendered.Messages) != 1 || len(rendered.Messages[0].Content) != 1 {
+		t.Fatalf("render() messages = %+v, want one message with one part", rendered.Messages)
+	}
+	if got, want := rendered.Messages[0].Content[0].(*dotprompt.TextPart).Text, "Hello Ada!"; got != want {
+		t.Errorf("render() text = %q, want %q", got, want)
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	if _, err := render("{{#if}}", renderData{}, options{}); err == nil {
+		t.Error("render() returned nil error for an invalid template")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		partials map[string]string
+		want     []string
+	}{
+		{
+			name:   "clean",
+			source: "Hello",
+			want:   []string{},
+		},
+		{
+			name:   "missing partial",
+			source: "{{> footer}}",
+			want:   []string{"missing-partial"},
+		},
+		{
+			name:     "known partial",
+			source:   "{{> footer}}",
+			partials: map[string]string{"footer": "Bye"},
+			want:     []string{},
+		},
+		{
+			name:   "invalid template",
+			source: "{{#if}}",
+			want:   []string{"invalid-template"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, d := range validate(tt.source, options{Partials: tt.partials}) {
+				got = append(got, d.Code)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("validate() codes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
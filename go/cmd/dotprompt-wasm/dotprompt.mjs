@@ -0,0 +1,77 @@
+/**
+ * Copyright 2026 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+/**
+ * Loader for the dotprompt WebAssembly module built from this directory.
+ *
+ * The Go `wasm_exec.js` support file must be loaded first so that the global
+ * `Go` class is defined:
+ *
+ *   import { load } from './dotprompt.mjs';
+ *   const dotprompt = await load(new URL('./dotprompt.wasm', import.meta.url));
+ *   const rendered = dotprompt.render(source, { input: { name: 'Ada' } });
+ *
+ * The returned functions throw an Error when the Go side reports one.
+ */
+
+/**
+ * Instantiates the module at `url` and returns its exported functions.
+ *
+ * @param {string | URL | Response | BufferSource} source The module URL,
+ *     a fetch response or the module bytes.
+ */
+export async function load(source) {
+  if (typeof globalThis.Go !== 'function') {
+    throw new Error('dotprompt: wasm_exec.js must be loaded before dotprompt.mjs');
+  }
+  const go = new globalThis.Go();
+  const { instance } = await instantiate(source, go.importObject);
+  go.run(instance);
+
+  const api = globalThis.dotprompt;
+  if (!api) {
+    throw new Error('dotprompt: module did not register its functions');
+  }
+  return {
+    parse: (source) => unwrap(api.parse(source)),
+    render: (source, data, options) => unwrap(api.render(source, data, options)),
+    validate: (source, options) => unwrap(api.validate(source, options)),
+  };
+}
+
+async function instantiate(source, imports) {
+  if (source instanceof ArrayBuffer || ArrayBuffer.isView(source)) {
+    return WebAssembly.instantiate(source, imports);
+  }
+  const response = source instanceof Response ? source : await fetch(source);
+  if (WebAssembly.instantiateStreaming) {
+    try {
+      return await WebAssembly.instantiateStreaming(response.clone(), imports);
+    } catch {
+      // Servers that do not send application/wasm need the fallback below.
+    }
+  }
+  return WebAssembly.instantiate(await response.arrayBuffer(), imports);
+}
+
+function unwrap(value) {
+  if (value.error !== undefined) {
+    throw new Error(value.error);
+  }
+  return value.result;
+}
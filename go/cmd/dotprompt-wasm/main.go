@@ -0,0 +1,104 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+)
+
+func main() {
+	api := js.Global().Get("Object").New()
+	api.Set("parse", export(func(args []js.Value) (any, error) {
+		return parse(stringArg(args, 0))
+	}))
+	api.Set("render", export(func(args []js.Value) (any, error) {
+		var data renderData
+		var opts options
+		if err := decodeArg(args, 1, &data); err != nil {
+			return nil, err
+		}
+		if err := decodeArg(args, 2, &opts); err != nil {
+			return nil, err
+		}
+		return render(stringArg(args, 0), data, opts)
+	}))
+	api.Set("validate", export(func(args []js.Value) (any, error) {
+		var opts options
+		if err := decodeArg(args, 1, &opts); err != nil {
+			return nil, err
+		}
+		return validate(stringArg(args, 0), opts), nil
+	}))
+	js.Global().Set("dotprompt", api)
+
+	// Keep the runtime alive so the exported functions stay callable.
+	select {}
+}
+
+// export wraps fn as a JavaScript function returning {result} or {error}.
+// Values cross the boundary as JSON so they match the HTTP API exactly.
+func export(fn func(args []js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(_ js.Value, args []js.Value) (ret any) {
+		defer func() {
+			if r := recover(); r != nil {
+				ret = errorValue(fmt.Errorf("panic: %v", r))
+			}
+		}()
+		result, err := fn(args)
+		if err != nil {
+			return errorValue(err)
+		}
+		b, err := json.Marshal(result)
+		if err != nil {
+			return errorValue(err)
+		}
+		obj := js.Global().Get("Object").New()
+		obj.Set("result", js.Global().Get("JSON").Call("parse", string(b)))
+		return obj
+	})
+}
+
+func errorValue(err error) js.Value {
+	obj := js.Global().Get("Object").New()
+	obj.Set("error", err.Error())
+	return obj
+}
+
+// stringArg returns args[i] as a string, or "" when it is missing.
+func stringArg(args []js.Value, i int) string {
+	if i >= len(args) || args[i].Type() != js.TypeString {
+		return ""
+	}
+	return args[i].String()
+}
+
+// decodeArg decodes args[i] into v through JSON. Missing, null and
+// undefined arguments leave v unchanged.
+func decodeArg(args []js.Value, i int, v any) error {
+	if i >= len(args) || args[i].IsNull() || args[i].IsUndefined() {
+		return nil
+	}
+	s := js.Global().Get("JSON").Call("stringify", args[i]).String()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		return fmt.Errorf("argument %d: %w", i+1, err)
+	}
+	return nil
+}
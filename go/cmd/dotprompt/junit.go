@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes results as a JUnit XML report with one test suite per
+// file.
+func writeJUnit(w io.Writer, results []testResult) error {
+	report := junitTestSuites{}
+	index := make(map[string]int)
+	var durations []time.Duration
+	for _, r := range results {
+		i, ok := index[r.Suite]
+		if !ok {
+			i = len(report.Suites)
+			index[r.Suite] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: r.Suite})
+			durations = append(durations, 0)
+		}
+		durations[i] += r.Duration
+		suite := &report.Suites[i]
+
+		tc := junitTestCase{
+			Name:      r.Name,
+			ClassName: r.Suite,
+			Time:      fmt.Sprintf("%.3f", r.Duration.Seconds()),
+		}
+		if len(r.Failures) > 0 {
+			tc.Failure = &junitFailure{
+				Message: r.Failures[0],
+				Text:    strings.Join(r.Failures, "\n"),
+			}
+			suite.Failures++
+			report.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+		report.Tests++
+	}
+	for i, d := range durations {
+		report.Suites[i].Time = fmt.Sprintf("%.3f", d.Seconds())
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
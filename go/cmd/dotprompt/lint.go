@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/lint"
+)
+
+// runLint implements `dotprompt lint [-format text|json|sarif] [paths...]`.
+//
+// Each path may be a .prompt file or a directory; a trailing `/...` is
+// accepted for directories and they are always searched recursively. Partials
+// are resolved against the directory containing each file. The exit code is 1
+// if any error-level diagnostic is reported.
+func runLint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "text", "output format: text, json or sarif")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if !checkFormat("lint", *format, stderr) {
+		return 2
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var diags []lint.Diagnostic
+	for _, path := range paths {
+		d, err := lintPath(strings.TrimSuffix(path, "/..."))
+		if err != nil {
+			fmt.Fprintf(stderr, "dotprompt lint: %v\n", err)
+			return 2
+		}
+		diags = append(diags, d...)
+	}
+
+	if err := writeDiagnostics(stdout, *format, diags); err != nil {
+		fmt.Fprintf(stderr, "dotprompt lint: %v\n", err)
+		return 2
+	}
+	if lint.HasErrors(diags) {
+		return 1
+	}
+	return 0
+}
+
+// diagnosticFormats are the values of the -format flag of lint and validate.
+var diagnosticFormats = []string{"text", "json", "sarif"}
+
+// checkFormat reports whether format is one of diagnosticFormats, writing an
+// error for the command cmd to stderr if not.
+func checkFormat(cmd, format string, stderr io.Writer) bool {
+	if slices.Contains(diagnosticFormats, format) {
+		return true
+	}
+	fmt.Fprintf(stderr, "dotprompt %s: unknown format %q\n", cmd, format)
+	return false
+}
+
+// writeDiagnostics writes diags to w in format, one of diagnosticFormats.
+func writeDiagnostics(w io.Writer, format string, diags []lint.Diagnostic) error {
+	switch format {
+	case "json":
+		return lint.WriteJSON(w, diags)
+	case "sarif":
+		return lint.WriteSARIF(w, "dotprompt", diags)
+	default:
+		return lint.WriteText(w, diags)
+	}
+}
+
+// lintPath lints a single file or every prompt under a directory.
+func lintPath(path string) ([]lint.Diagnostic, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		store, err := dotprompt.NewDirStore(path)
+		if err != nil {
+			return nil, err
+		}
+		diags, err := lint.Store(store)
+		if err != nil {
+			return nil, err
+		}
+		for i := range diags {
+			diags[i].File = filepath.ToSlash(filepath.Join(path, diags[i].File))
+		}
+		return diags, nil
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	store, err := dotprompt.NewDirStore(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return lint.Source(filepath.ToSlash(path), string(source), &lint.Options{Store: store}), nil
+}
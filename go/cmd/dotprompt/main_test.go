@@ -0,0 +1,170 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bogus"}, &stdout, &stderr); code != 2 {
+		t.Errorf("run() = %d, want 2", code)
+	}
+	if !strings.Contains(stderr.String(), "lint") {
+		t.Errorf("usage %q does not list the lint command", stderr.String())
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{
+		"good.prompt": "Hello {{> sig}}",
+		"_sig.prompt": "Bye",
+		"bad.prompt":  "Hello {{> missing}}",
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"lint", dir + "/..."}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("run(lint) = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	want := filepath.ToSlash(filepath.Join(dir, "bad.prompt")) + ":1:7: error[missing-partial]: partial 'missing' not found\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestLintFormats(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{"good.prompt": "Hello"})
+	file := filepath.Join(dir, "good.prompt")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"lint", "-format", "json", file}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run(lint -format json) = %d, want 0; stderr: %s", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != "[]" {
+		t.Errorf("json output = %q, want []", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"lint", "-format", "sarif", file}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run(lint -format sarif) = %d, want 0", code)
+	}
+	var log map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil || log["version"] != "2.1.0" {
+		t.Errorf("sarif output = %q, want a SARIF 2.1.0 log", stdout.String())
+	}
+
+	if code := run([]string{"lint", "-format", "xml", file}, &stdout, &stderr); code != 2 {
+		t.Errorf("run(lint -format xml) = %d, want 2", code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{
+		"good.prompt":    "Hello",
+		"bad.prompt":     "---\nmodle: x/y\n---\nHello",
+		"_unused.prompt": "Bye",
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"validate", "-format", "json", dir + "/..."}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("run(validate) = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	var diags []struct {
+		File string `json:"file"`
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		t.Fatalf("json output %q: %v", stdout.String(), err)
+	}
+	got := make(map[string]string)
+	for _, d := range diags {
+		got[d.Code] = filepath.Base(d.File)
+	}
+	if got["invalid-frontmatter"] != "bad.prompt" || got["unused-partial"] != "_unused.prompt" {
+		t.Errorf("diagnostics = %+v, want invalid-frontmatter in bad.prompt and unused-partial in _unused.prompt", diags)
+	}
+}
+
+func TestValidateBrokenYAMLOutput(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{
+		"broken.prompt": "---\nmodel: [\n---\nHello",
+	})
+
+	// The report must be the only thing written to the process's stdout, so
+	// capture os.Stdout rather than a buffer.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	var stderr bytes.Buffer
+	code := run([]string{"validate", "-format", "json", dir}, w, &stderr)
+	os.Stdout = saved
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != 1 {
+		t.Fatalf("run(validate) = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	var diags []struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(out, &diags); err != nil {
+		t.Fatalf("stdout %q is not JSON: %v", out, err)
+	}
+	if len(diags) == 0 || diags[0].Code != "invalid-yaml" {
+		t.Errorf("diagnostics = %+v, want invalid-yaml", diags)
+	}
+}
+
+func TestFlagsCheckedFirst(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"lint", "-format", "xml", missing}, `unknown format "xml"`},
+		{[]string{"validate", "-format", "xml", missing}, `unknown format "xml"`},
+		{[]string{"watch", "-interval", "0s", missing}, "-interval must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("run(%q) = %d, want 2", tt.args, code)
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("run(%q) stderr = %q, want it to contain %q", tt.args, stderr.String(), tt.want)
+			}
+		})
+	}
+}
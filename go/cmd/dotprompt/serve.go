@@ -0,0 +1,103 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/dotprompthttp"
+)
+
+// runServe implements `dotprompt serve [-addr host:port] [dir]`.
+//
+// It serves the prompts in dir, which defaults to the current directory,
+// with the dotprompthttp endpoints and the playground UI until interrupted.
+func runServe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(paths) > 1 {
+		fmt.Fprintln(stderr, "dotprompt serve: at most one prompt directory is allowed")
+		return 2
+	}
+	root := "."
+	if len(paths) == 1 {
+		root = paths[0]
+	}
+
+	handler, err := newServeHandler(root)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotprompt serve: %v\n", err)
+		return 1
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotprompt serve: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	server := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(stdout, "Serving %s at http://%s/\n", root, listener.Addr())
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "dotprompt serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// newServeHandler returns the handler that serves the prompts in root, with
+// partials resolved from the same directory.
+func newServeHandler(root string) (http.Handler, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	store, err := dotprompt.NewDirStore(root)
+	if err != nil {
+		return nil, err
+	}
+	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{PartialStore: store})
+	handler := dotprompthttp.NewHandler(dp, store)
+	handler.UI = true
+	return handler, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+func TestServeHandler(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{
+		"greet.prompt": "Hello {{> sig}}",
+		"_sig.prompt":  "Bye",
+	})
+	handler, err := newServeHandler(dir)
+	if err != nil {
+		t.Fatalf("newServeHandler() returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/prompts/greet/render", nil))
+	if !strings.Contains(rec.Body.String(), "Hello Bye") {
+		t.Errorf("render = %s, want partial resolved from the directory", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET / status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{"greet.prompt": "Hello"})
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"too many directories", []string{dir, dir}, 2},
+		{"missing directory", []string{dir + "/missing"}, 1},
+		{"file", []string{dir + "/greet.prompt"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(append([]string{"serve"}, tt.args...), &stdout, &stderr); code != tt.want {
+				t.Errorf("run(serve) = %d, want %d; stderr: %s", code, tt.want, stderr.String())
+			}
+		})
+	}
+}
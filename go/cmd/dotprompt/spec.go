@@ -0,0 +1,313 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/goccy/go-yaml"
+	"github.com/invopop/jsonschema"
+
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+// specSuite is a suite in the spec test format used by the files in the
+// repository's spec directory.
+type specSuite struct {
+	Name             string                              `json:"name"`
+	Template         string                              `json:"template"`
+	Data             map[string]any                      `json:"data"`
+	Schemas          map[string]*jsonschema.Schema       `json:"schemas"`
+	Tools            map[string]dotprompt.ToolDefinition `json:"tools"`
+	Partials         map[string]string                   `json:"partials"`
+	ResolverPartials map[string]string                   `json:"resolverPartials"`
+	Tests            []specTest                          `json:"tests"`
+}
+
+// specTest is a single test in a specSuite. Every key of Expect must match
+// the corresponding field of the rendered prompt; nested objects are compared
+// as subsets, so fields absent from Expect are ignored.
+type specTest struct {
+	Desc    string         `json:"desc"`
+	Data    map[string]any `json:"data"`
+	Options map[string]any `json:"options"`
+	Expect  map[string]any `json:"expect"`
+}
+
+// parseSpecFile decodes a spec-format YAML file. It reports false if the
+// content is not a list of suites.
+func parseSpecFile(content []byte) ([]specSuite, bool, error) {
+	var raw any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, false, err
+	}
+	if _, ok := raw.([]any); !ok {
+		return nil, false, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	var suites []specSuite
+	if err := json.Unmarshal(b, &suites); err != nil {
+		return nil, false, err
+	}
+	return suites, true, nil
+}
+
+// runSpecSuites runs every test in suites whose "file/suite/desc" name matches
+// filter.
+func runSpecSuites(file string, suites []specSuite, filter *regexp.Regexp) []testResult {
+	var results []testResult
+	for _, suite := range suites {
+		for _, tc := range suite.Tests {
+			name := suite.Name + "/" + tc.Desc
+			if filter != nil && !filter.MatchString(file+"/"+name) {
+				continue
+			}
+			start := time.Now()
+			failures := runSpecTest(suite, tc)
+			results = append(results, testResult{
+				Suite:    file,
+				Name:     name,
+				Failures: failures,
+				Duration: time.Since(start),
+			})
+		}
+	}
+	return results
+}
+
+// runSpecTest renders a single spec test and compares it to its expectation.
+func runSpecTest(suite specSuite, tc specTest) []string {
+	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{
+		Schemas:  suite.Schemas,
+		Tools:    suite.Tools,
+		Partials: suite.Partials,
+		PartialResolver: func(name string) (string, error) {
+			return suite.ResolverPartials[name], nil
+		},
+	})
+
+	options := &dotprompt.PromptMetadata{}
+	if err := mapstructure.Decode(tc.Options, options); err != nil {
+		return []string{fmt.Sprintf("invalid options: %v", err)}
+	}
+	data := specData(suite.Data)
+	mergeSpecData(&data, specData(tc.Data))
+
+	rendered, err := dp.Render(suite.Template, &data, options)
+	if err != nil {
+		return []string{fmt.Sprintf("render failed: %v", err)}
+	}
+
+	b, err := json.Marshal(rendered)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to encode rendered prompt: %v", err)}
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		return []string{fmt.Sprintf("failed to decode rendered prompt: %v", err)}
+	}
+
+	for _, section := range []string{"input", "output"} {
+		if m, ok := got[section].(map[string]any); ok && m["schema"] != nil {
+			m["schema"] = normalizeSchema(m["schema"])
+		}
+	}
+
+	keys := make([]string, 0, len(tc.Expect))
+	for key := range tc.Expect {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var failures []string
+	for _, key := range keys {
+		if !jsonSubset(tc.Expect[key], got[key]) {
+			want, _ := json.Marshal(tc.Expect[key])
+			have, _ := json.Marshal(got[key])
+			failures = append(failures, fmt.Sprintf("%s: got %s, want %s", key, have, want))
+		}
+	}
+	return failures
+}
+
+// normalizeSchema rewrites a JSON schema produced by Picoschema into the form
+// used by the spec files: optional fields are written as a type list rather
+// than an `anyOf` with null, `true` is written as `{}`, and objects with
+// properties are closed unless they say otherwise.
+func normalizeSchema(schema any) any {
+	if b, ok := schema.(bool); ok && b {
+		return map[string]any{}
+	}
+	m, ok := schema.(map[string]any)
+	if !ok {
+		return schema
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		switch k {
+		case "properties":
+			props, _ := v.(map[string]any)
+			normalized := make(map[string]any, len(props))
+			for name, prop := range props {
+				normalized[name] = normalizeSchema(prop)
+			}
+			out[k] = normalized
+		case "items", "additionalProperties":
+			out[k] = normalizeSchema(v)
+		default:
+			out[k] = v
+		}
+	}
+	if anyOf, ok := m["anyOf"].([]any); ok && len(anyOf) == 2 {
+		if null, _ := anyOf[1].(map[string]any); null["type"] == "null" {
+			delete(out, "anyOf")
+			if t, ok := out["type"].(string); ok {
+				out["type"] = []any{t, "null"}
+			}
+		}
+	}
+	if _, ok := out["properties"]; ok {
+		if _, ok := out["additionalProperties"]; !ok {
+			out["additionalProperties"] = false
+		}
+	}
+	return out
+}
+
+// jsonSubset reports whether every field of want is present in got with an
+// equal value. Arrays must have the same length.
+func jsonSubset(want, got any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range w {
+			if !jsonSubset(v, g[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !jsonSubset(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+// specData converts the `data` entry of a spec suite or test.
+func specData(raw map[string]any) dotprompt.DataArgument {
+	data := dotprompt.DataArgument{}
+	data.Input, _ = raw["input"].(map[string]any)
+	data.Context, _ = raw["context"].(map[string]any)
+	if messages, ok := raw["messages"].([]any); ok {
+		for _, m := range messages {
+			msg, _ := m.(map[string]any)
+			role, _ := msg["role"].(string)
+			meta, _ := msg["metadata"].(map[string]any)
+			data.Messages = append(data.Messages, dotprompt.Message{
+				HasMetadata: dotprompt.HasMetadata{Metadata: meta},
+				Role:        dotprompt.Role(role),
+				Content:     specParts(msg["content"]),
+			})
+		}
+	}
+	if docs, ok := raw["docs"].([]any); ok {
+		for _, d := range docs {
+			doc, _ := d.(map[string]any)
+			meta, _ := doc["metadata"].(map[string]any)
+			data.Docs = append(data.Docs, dotprompt.Document{
+				HasMetadata: dotprompt.HasMetadata{Metadata: meta},
+				Content:     specParts(doc["content"]),
+			})
+		}
+	}
+	return data
+}
+
+// specParts converts a list of JSON parts into typed parts.
+func specParts(raw any) []dotprompt.Part {
+	list, _ := raw.([]any)
+	parts := make([]dotprompt.Part, 0, len(list))
+	for _, item := range list {
+		p, _ := item.(map[string]any)
+		meta, _ := p["metadata"].(map[string]any)
+		hm := dotprompt.HasMetadata{Metadata: meta}
+		switch {
+		case p["text"] != nil:
+			text, _ := p["text"].(string)
+			parts = append(parts, &dotprompt.TextPart{HasMetadata: hm, Text: text})
+		case p["data"] != nil:
+			data, _ := p["data"].(map[string]any)
+			parts = append(parts, &dotprompt.DataPart{HasMetadata: hm, Data: data})
+		case p["media"] != nil:
+			media, _ := p["media"].(map[string]any)
+			url, _ := media["url"].(string)
+			contentType, _ := media["contentType"].(string)
+			parts = append(parts, &dotprompt.MediaPart{HasMetadata: hm, Media: dotprompt.Media{URL: url, ContentType: contentType}})
+		case p["toolRequest"] != nil:
+			req, _ := p["toolRequest"].(map[string]any)
+			parts = append(parts, &dotprompt.ToolRequestPart{HasMetadata: hm, ToolRequest: req})
+		case p["toolResponse"] != nil:
+			resp, _ := p["toolResponse"].(map[string]any)
+			parts = append(parts, &dotprompt.ToolResponsePart{HasMetadata: hm, ToolResponse: resp})
+		default:
+			parts = append(parts, &dotprompt.PendingPart{HasMetadata: hm})
+		}
+	}
+	return parts
+}
+
+// mergeSpecData merges test data over suite data without modifying the
+// suite's maps.
+func mergeSpecData(dst *dotprompt.DataArgument, src dotprompt.DataArgument) {
+	if src.Input != nil {
+		dst.Input = maps.Clone(dst.Input)
+		if dst.Input == nil {
+			dst.Input = make(map[string]any)
+		}
+		maps.Copy(dst.Input, src.Input)
+	}
+	if src.Context != nil {
+		dst.Context = maps.Clone(dst.Context)
+		if dst.Context == nil {
+			dst.Context = make(map[string]any)
+		}
+		maps.Copy(dst.Context, src.Context)
+	}
+	dst.Docs = append(dst.Docs, src.Docs...)
+	dst.Messages = append(dst.Messages, src.Messages...)
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+// testResult is the outcome of a single test case.
+type testResult struct {
+	// Suite is the file that declared the test.
+	Suite    string
+	Name     string
+	Failures []string
+	Duration time.Duration
+}
+
+// runTest implements `dotprompt test [-run regexp] [-junit file.xml]
+// [-v] [paths...]`.
+//
+// Directories are loaded as prompt stores and the `tests:` declared in each
+// prompt's frontmatter are run. YAML files, given directly or found in a
+// directory, are run as spec-format test suites. The exit code is 1 if any
+// test fails.
+func runTest(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	runFlag := flags.String("run", "", "run only tests whose name matches the regular expression")
+	junit := flags.String("junit", "", "write a JUnit XML report to the file")
+	verbose := flags.Bool("v", false, "print passing tests")
+	paths, err := parseArgs(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var filter *regexp.Regexp
+	if *runFlag != "" {
+		if filter, err = regexp.Compile(*runFlag); err != nil {
+			fmt.Fprintf(stderr, "dotprompt test: invalid -run: %v\n", err)
+			return 2
+		}
+	}
+
+	var results []testResult
+	for _, path := range paths {
+		r, err := testPath(strings.TrimSuffix(path, "/..."), *runFlag, filter)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotprompt test: %v\n", err)
+			return 2
+		}
+		results = append(results, r...)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if len(r.Failures) == 0 {
+			if *verbose {
+				fmt.Fprintf(stdout, "PASS %s %s (%.3fs)\n", r.Suite, r.Name, r.Duration.Seconds())
+			}
+			continue
+		}
+		failed++
+		fmt.Fprintf(stdout, "FAIL %s %s (%.3fs)\n", r.Suite, r.Name, r.Duration.Seconds())
+		for _, f := range r.Failures {
+			fmt.Fprintf(stdout, "    %s\n", f)
+		}
+	}
+	fmt.Fprintf(stdout, "%d passed, %d failed\n", len(results)-failed, failed)
+
+	if *junit != "" {
+		f, err := os.Create(*junit)
+		if err == nil {
+			err = writeJUnit(f, results)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "dotprompt test: %v\n", err)
+			return 2
+		}
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// testPath runs the prompt tests and spec files under path.
+func testPath(path, run string, filter *regexp.Regexp) ([]testResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return specFileResults(path, filter, true)
+	}
+
+	store, err := dotprompt.NewDirStore(path)
+	if err != nil {
+		return nil, err
+	}
+	report, err := dotprompt.RunPromptTests(store, run)
+	if err != nil {
+		return nil, err
+	}
+	var results []testResult
+	for _, r := range report.Results {
+		file := r.Prompt
+		if r.Variant != "" {
+			file += "." + r.Variant
+		}
+		results = append(results, testResult{
+			Suite:    filepath.ToSlash(filepath.Join(path, file+".prompt")),
+			Name:     r.Name,
+			Failures: r.Failures,
+			Duration: r.Duration,
+		})
+	}
+
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") && file != path {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(file); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		r, err := specFileResults(file, filter, false)
+		results = append(results, r...)
+		return err
+	})
+	return results, err
+}
+
+// specFileResults runs a spec-format YAML file. Files that are not lists of
+// suites are an error only when required is set.
+func specFileResults(file string, filter *regexp.Regexp, required bool) ([]testResult, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	suites, ok, err := parseSpecFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if !ok {
+		if required {
+			return nil, fmt.Errorf("%s: not a spec test file", file)
+		}
+		return nil, nil
+	}
+	return runSpecSuites(filepath.ToSlash(file), suites, filter), nil
+}
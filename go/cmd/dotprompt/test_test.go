@@ -0,0 +1,172 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+const greetPrompt = `---
+tests:
+  - name: passes
+    input: {name: Ada}
+    assert:
+      - contains: Hello, Ada
+  - name: fails
+    input: {name: Bob}
+    assert:
+      - contains: Hello, Ada
+---
+Hello, {{name}}!
+`
+
+const greetSpec = `- name: greeting
+  template: "Hi {{name}}!"
+  data:
+    input: {name: Suite}
+  tests:
+    - desc: uses suite data
+      expect:
+        messages:
+          - role: user
+            content: [{text: "Hi Suite!"}]
+    - desc: overrides suite data
+      data:
+        input: {name: Test}
+      expect:
+        messages:
+          - role: user
+            content: [{text: "Hi Test!"}]
+`
+
+func TestTestCommand(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{
+		"greet.prompt": greetPrompt,
+		"greet.yaml":   greetSpec,
+		"other.yaml":   "not: a spec file\n",
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "all",
+			args:     []string{"-v", dir},
+			wantCode: 1,
+			want: []string{
+				"PASS " + filepath.ToSlash(filepath.Join(dir, "greet.prompt")) + " passes",
+				"FAIL " + filepath.ToSlash(filepath.Join(dir, "greet.prompt")) + " fails",
+				`    expected output to contain "Hello, Ada"`,
+				"PASS " + filepath.ToSlash(filepath.Join(dir, "greet.yaml")) + " greeting/uses suite data",
+				"PASS " + filepath.ToSlash(filepath.Join(dir, "greet.yaml")) + " greeting/overrides suite data",
+				"3 passed, 1 failed",
+			},
+		},
+		{
+			name:     "run filter",
+			args:     []string{dir, "-run", "passes|overrides"},
+			wantCode: 0,
+			want:     []string{"2 passed, 0 failed"},
+		},
+		{
+			name:     "spec file",
+			args:     []string{filepath.Join(dir, "greet.yaml")},
+			wantCode: 0,
+			want:     []string{"2 passed, 0 failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(append([]string{"test"}, tt.args...), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("run(test) = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output %q does not contain %q", stdout.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestTestCommandJUnit(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{"greet.prompt": greetPrompt})
+	report := filepath.Join(t.TempDir(), "report.xml")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"test", "-junit", report, dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("run(test) = %d, want 1; stderr: %s", code, stderr.String())
+	}
+
+	content, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(content, &got); err != nil {
+		t.Fatalf("xml.Unmarshal() returned error: %v", err)
+	}
+	if got.Tests != 2 || got.Failures != 1 || len(got.Suites) != 1 {
+		t.Fatalf("report = %+v, want 2 tests and 1 failure in 1 suite", got)
+	}
+	if tc := got.Suites[0].Cases[1]; tc.Name != "fails" || tc.Failure == nil {
+		t.Errorf("Cases[1] = %+v, want failed test %q", tc, "fails")
+	}
+}
+
+func TestTestPathDurations(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{"greet.prompt": greetPrompt, "greet.yaml": greetSpec})
+	results, err := testPath(dir, "", nil)
+	if err != nil {
+		t.Fatalf("testPath() returned error: %v", err)
+	}
+	for _, r := range results {
+		if r.Duration <= 0 {
+			t.Errorf("%s %s: Duration = %v, want it timed", r.Suite, r.Name, r.Duration)
+		}
+	}
+}
+
+func TestSpecTestSubset(t *testing.T) {
+	tests := []struct {
+		want, got any
+		ok        bool
+	}{
+		{map[string]any{"a": 1.0}, map[string]any{"a": 1.0, "b": 2.0}, true},
+		{map[string]any{"a": 1.0}, map[string]any{"a": 2.0}, false},
+		{[]any{1.0}, []any{1.0, 2.0}, false},
+		{[]any{map[string]any{"x": "y"}}, []any{map[string]any{"x": "y", "z": "w"}}, true},
+	}
+	for _, tt := range tests {
+		if got := jsonSubset(tt.want, tt.got); got != tt.ok {
+			t.Errorf("jsonSubset(%v, %v) = %v, want %v", tt.want, tt.got, got, tt.ok)
+		}
+	}
+}
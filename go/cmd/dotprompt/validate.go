@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/lint"
+)
+
+// runValidate implements `dotprompt validate [-format text|json|sarif]
+// [dirs...]`.
+//
+// Each directory is loaded as a prompt store and checked by lint.ValidateStore:
+// every prompt and partial is linted, its frontmatter is checked against the
+// schema, and unused partials are reported. A trailing `/...` is accepted. The
+// exit code is 1 if any error-level diagnostic is reported.
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "text", "output format: text, json or sarif")
+	dirs, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if !checkFormat("validate", *format, stderr) {
+		return 2
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var diags []lint.Diagnostic
+	for _, dir := range dirs {
+		d, err := validateDir(strings.TrimSuffix(dir, "/..."))
+		if err != nil {
+			fmt.Fprintf(stderr, "dotprompt validate: %v\n", err)
+			return 2
+		}
+		diags = append(diags, d...)
+	}
+
+	if err := writeDiagnostics(stdout, *format, diags); err != nil {
+		fmt.Fprintf(stderr, "dotprompt validate: %v\n", err)
+		return 2
+	}
+	if lint.HasErrors(diags) {
+		return 1
+	}
+	return 0
+}
+
+// validateDir validates the prompt store in dir.
+func validateDir(dir string) ([]lint.Diagnostic, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	store, err := dotprompt.NewDirStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	report, err := lint.ValidateStore(store)
+	if err != nil {
+		return nil, err
+	}
+	diags := report.Diagnostics()
+	for i := range diags {
+		diags[i].File = filepath.ToSlash(filepath.Join(dir, diags[i].File))
+	}
+	return diags, nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+// ANSI escape sequences used for diff output.
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// runWatch implements `dotprompt watch [-input file.json] [-interval d]
+// [-no-color] file.prompt`.
+//
+// The prompt is rendered once and then re-rendered whenever the prompt, the
+// input file or a partial in the prompt's directory changes. After the first
+// render only a diff of the rendered messages is printed.
+func runWatch(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "JSON file containing the prompt input")
+	interval := fs.Duration("interval", 500*time.Millisecond, "polling interval")
+	noColor := fs.Bool("no-color", false, "disable colored diff output")
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return 2
+	}
+	if *interval <= 0 {
+		fmt.Fprintf(stderr, "dotprompt watch: -interval must be positive, got %v\n", *interval)
+		return 2
+	}
+	if len(paths) != 1 {
+		fmt.Fprintln(stderr, "dotprompt watch: exactly one prompt file is required")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := &watcher{
+		path:      paths[0],
+		inputPath: *input,
+		interval:  *interval,
+		color:     !*noColor,
+		out:       stdout,
+	}
+	w.run(ctx)
+	return 0
+}
+
+// watcher re-renders a prompt file whenever its inputs change.
+type watcher struct {
+	path      string
+	inputPath string
+	interval  time.Duration
+	color     bool
+	out       io.Writer
+
+	// store resolves partials; its directory index is invalidated whenever
+	// the watched files change.
+	store *dotprompt.DirStore
+}
+
+// run polls for changes until ctx is done.
+func (w *watcher) run(ctx context.Context) {
+	var lastStamp, lastText string
+	rendered := false
+	for {
+		if stamp := w.stamp(); stamp != lastStamp {
+			lastStamp = stamp
+			if w.store != nil {
+				w.store.Invalidate()
+			}
+			text, err := w.render()
+			switch {
+			case err != nil:
+				fmt.Fprintf(w.out, "error: %v\n", err)
+			case !rendered:
+				fmt.Fprint(w.out, text)
+				rendered = true
+			case text != lastText:
+				fmt.Fprintf(w.out, "--- %s\n", time.Now().Format(time.TimeOnly))
+				fmt.Fprint(w.out, w.formatDiff(dotprompt.DiffLines(lastText, text)))
+			}
+			if err == nil {
+				lastText = text
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// stamp returns a fingerprint of the modification times and sizes of the
+// prompt, the input file and the partials next to the prompt.
+func (w *watcher) stamp() string {
+	files := []string{w.path}
+	if w.inputPath != "" {
+		files = append(files, w.inputPath)
+	}
+	partials, _ := filepath.Glob(filepath.Join(filepath.Dir(w.path), "_*.prompt"))
+	files = append(files, partials...)
+
+	var sb strings.Builder
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			fmt.Fprintf(&sb, "%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+		}
+	}
+	return sb.String()
+}
+
+// render renders the prompt and formats its messages as text.
+func (w *watcher) render() (string, error) {
+	source, err := os.ReadFile(w.path)
+	if err != nil {
+		return "", err
+	}
+
+	data := &dotprompt.DataArgument{}
+	if w.inputPath != "" {
+		raw, err := os.ReadFile(w.inputPath)
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(raw, &data.Input); err != nil {
+			return "", fmt.Errorf("parsing %s: %w", w.inputPath, err)
+		}
+	}
+
+	if w.store == nil {
+		store, err := dotprompt.NewDirStore(filepath.Dir(w.path))
+		if err != nil {
+			return "", err
+		}
+		w.store = store
+	}
+	store := w.store
+	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{
+		PartialResolver: func(name string) (string, error) {
+			partial, err := store.LoadPartial(name, dotprompt.LoadPartialOptions{})
+			if err != nil {
+				return "", err
+			}
+			return partial.Source, nil
+		},
+	})
+	rendered, err := dp.Render(string(source), data, nil)
+	if err != nil {
+		return "", err
+	}
+	return formatMessages(rendered.Messages), nil
+}
+
+// formatMessages renders messages as text, one `[role]` header per message
+// followed by its parts.
+func formatMessages(messages []dotprompt.Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&sb, "[%s]\n", msg.Role)
+		for _, part := range msg.Content {
+			switch p := part.(type) {
+			case *dotprompt.TextPart:
+				sb.WriteString(p.Text)
+			case *dotprompt.MediaPart:
+				fmt.Fprintf(&sb, "<media %s>", p.Media.URL)
+			default:
+				b, _ := json.Marshal(part)
+				sb.Write(b)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// formatDiff renders the changed lines of a diff, colored if enabled.
+func (w *watcher) formatDiff(lines []dotprompt.LineDiff) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		if line.Op == dotprompt.DiffEqual {
+			continue
+		}
+		color := ansiGreen
+		if line.Op == dotprompt.DiffDelete {
+			color = ansiRed
+		}
+		if w.color {
+			sb.WriteString(color)
+		}
+		sb.WriteString(string(line.Op))
+		sb.WriteString(line.Text)
+		if w.color {
+			sb.WriteString(ansiReset)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, buf *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(buf.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("output %q does not contain %q", buf.String(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := storetest.WriteFiles(t, map[string]string{
+		"hello.prompt":  "{{> greet}}, {{name}}!",
+		"_greet.prompt": "Hello",
+		"input.json":    `{"name": "Ada"}`,
+	})
+
+	var out syncBuffer
+	w := &watcher{
+		path:      filepath.Join(dir, "hello.prompt"),
+		inputPath: filepath.Join(dir, "input.json"),
+		interval:  5 * time.Millisecond,
+		out:       &out,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, &out, "[user]\nHello, Ada!\n")
+
+	if err := os.WriteFile(filepath.Join(dir, "_greet.prompt"), []byte("Goodbye"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, &out, "-Hello, Ada!\n+Goodbye, Ada!\n")
+
+	if err := os.WriteFile(filepath.Join(dir, "hello.prompt"), []byte("{{#if}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, &out, "error: ")
+
+	cancel()
+	<-done
+}
+
+func TestFormatMessages(t *testing.T) {
+	messages := []dotprompt.Message{
+		{Role: dotprompt.RoleSystem, Content: []dotprompt.Part{&dotprompt.TextPart{Text: "Be brief."}}},
+		{Role: dotprompt.RoleUser, Content: []dotprompt.Part{
+			&dotprompt.TextPart{Text: "Describe:"},
+			&dotprompt.MediaPart{Media: dotprompt.Media{URL: "https://example.com/cat.png"}},
+		}},
+	}
+	want := "[system]\nBe brief.\n[user]\nDescribe:\n<media https://example.com/cat.png>\n"
+	if got := formatMessages(messages); got != want {
+		t.Errorf("formatMessages() = %q, want %q", got, want)
+	}
+}
+
+func TestWatcherFormatDiff(t *testing.T) {
+	lines := dotprompt.DiffLines("a\nb", "a\nc")
+	w := &watcher{color: true}
+	want := ansiRed + "-b" + ansiReset + "\n" + ansiGreen + "+c" + ansiReset + "\n"
+	if got := w.formatDiff(lines); got != want {
+		t.Errorf("formatDiff() = %q, want %q", got, want)
+	}
+}
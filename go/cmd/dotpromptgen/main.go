@@ -0,0 +1,81 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command dotpromptgen generates Go source that embeds a directory of
+// prompts already parsed, with typed input structs derived from their input
+// schemas; see package codegen. It is meant for go:generate:
+//
+//	//go:generate go run github.com/google/dotprompt/go/cmd/dotpromptgen -pkg prompts -o prompts_gen.go ./prompts
+//
+// Usage:
+//
+//	dotpromptgen [-pkg name] [-o file] [root]
+//
+// Without -o, the source is written to standard output.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/codegen"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run generates the source and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("dotpromptgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pkg := fs.String("pkg", "prompts", "name of the generated package")
+	out := fs.String("o", "", "file to write the generated source to, instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(stderr, "dotpromptgen: at most one root directory is allowed")
+		return 2
+	}
+	root := "."
+	if fs.NArg() == 1 {
+		root = fs.Arg(0)
+	}
+
+	store, err := dotprompt.NewDirStore(root)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotpromptgen: %v\n", err)
+		return 1
+	}
+	src, err := codegen.Generate(store, codegen.Options{Package: *pkg})
+	if err != nil {
+		fmt.Fprintf(stderr, "dotpromptgen: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		stdout.Write(src)
+		return 0
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintf(stderr, "dotpromptgen: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,310 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrAccessDenied is returned by the stores of an ACLStore when the caller
+// may not load, save or delete a prompt or partial.
+var ErrAccessDenied = errors.New("dotprompt: access denied")
+
+// ACLAction is an action checked by an ACLStore.
+type ACLAction string
+
+const (
+	// ACLRead covers listing and loading.
+	ACLRead ACLAction = "read"
+	// ACLWrite covers saving and deleting.
+	ACLWrite ACLAction = "write"
+)
+
+// ACL is the access control list of a prompt or partial, declared in the
+// `acl` ext namespace of its frontmatter:
+//
+//	acl.read: [team-a, team-b]
+//	acl.write: [alice]
+//
+// or, equivalently, as a top-level `acl` map:
+//
+//	acl:
+//	  read: [team-a, team-b]
+//	  write: [alice]
+//
+// Each list holds the principals allowed to perform the action; "*" allows
+// every principal, and an absent list does not restrict the action.
+type ACL struct {
+	Read  []string `mapstructure:"read"`
+	Write []string `mapstructure:"write"`
+}
+
+// Allows reports whether the list for action admits principal.
+func (a ACL) Allows(principal string, action ACLAction) bool {
+	list := a.Read
+	if action == ACLWrite {
+		list = a.Write
+	}
+	return list == nil || slices.Contains(list, "*") || slices.Contains(list, principal)
+}
+
+// ParseACL returns the ACL declared in the frontmatter of source. Unlike
+// ParseDocument, it fails if the frontmatter is not valid YAML, so that a
+// broken file is not mistaken for one without an ACL.
+func ParseACL(source string) (ACL, error) {
+	source, err := NormalizeSourceEncoding(source)
+	if err != nil {
+		return ACL{}, err
+	}
+	frontmatter, body, _ := extractFrontmatterAndBody(source)
+	parsed, err := parseSections(source, frontmatter, body, true, TrimDefault)
+	if err != nil {
+		return ACL{}, err
+	}
+	var acl ACL
+	if nested, ok := parsed.Raw["acl"]; ok {
+		fields, ok := nested.(map[string]any)
+		if !ok {
+			return ACL{}, fmt.Errorf("frontmatter acl is a %T, not a map", nested)
+		}
+		if err := decodeFields(fields, &acl); err != nil {
+			return ACL{}, fmt.Errorf("decoding frontmatter acl: %w", err)
+		}
+	}
+	if err := parsed.ExtAs("acl", &acl); err != nil {
+		return ACL{}, err
+	}
+	return acl, nil
+}
+
+// ACLRequest describes an access to be authorized by an AuthzFunc.
+type ACLRequest struct {
+	// Principal is the caller, as set with WithPrincipal, or empty if none
+	// was set.
+	Principal string
+	Action    ACLAction
+	Name      string
+	Variant   string
+	// Partial is set if Name is the name of a partial.
+	Partial bool
+	// ACL is the list declared by the prompt or partial.
+	ACL ACL
+}
+
+// AuthzFunc reports whether an access is allowed.
+type AuthzFunc func(ctx context.Context, req ACLRequest) bool
+
+// DefaultAuthz allows the accesses admitted by the prompt's ACL.
+func DefaultAuthz(_ context.Context, req ACLRequest) bool {
+	return req.ACL.Allows(req.Principal, req.Action)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the principal checked by
+// ACLStore.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set with WithPrincipal.
+func PrincipalFromContext(ctx context.Context) (string, bool) {
+	principal, ok := ctx.Value(principalKey{}).(string)
+	return principal, ok
+}
+
+// ACLStore decorates a PromptStore to enforce ACLs for multi-tenant prompt
+// services. Since PromptStore methods take no context, an ACLStore is not a
+// store itself: For returns the store seen by the caller of a request.
+type ACLStore struct {
+	inner PromptStore
+	authz AuthzFunc
+}
+
+// NewACLStore returns an ACLStore that checks accesses to inner with authz,
+// or with DefaultAuthz if authz is nil.
+func NewACLStore(inner PromptStore, authz AuthzFunc) *ACLStore {
+	if authz == nil {
+		authz = DefaultAuthz
+	}
+	return &ACLStore{inner: inner, authz: authz}
+}
+
+// For returns the view of the store for the principal carried by ctx. It
+// lists only the prompts and partials the principal may read, and returns
+// ErrAccessDenied from the other methods when an access is not allowed. The
+// view is a WritablePromptStore if the decorated store is.
+//
+// Listing filters each page of the decorated store, so pages may hold fewer
+// items than requested, and leaves out items that cannot be loaded. Prompts
+// whose ACL cannot be decoded are checked with an ACL that admits no
+// principal. Versions other than the current one are checked against the
+// ACL of the current one.
+func (s *ACLStore) For(ctx context.Context) PromptStore {
+	view := &aclView{ctx: ctx, store: s}
+	if writable, ok := s.inner.(WritablePromptStore); ok {
+		return &aclWritableView{aclView: view, inner: writable}
+	}
+	return view
+}
+
+// aclView is the view of an ACLStore for one context.
+type aclView struct {
+	ctx   context.Context
+	store *ACLStore
+}
+
+// allowed checks an access to a prompt or partial whose source is known.
+func (v *aclView) allowed(action ACLAction, name, variant string, partial bool, source string) bool {
+	acl, err := ParseACL(source)
+	if err != nil {
+		acl = ACL{Read: []string{}, Write: []string{}}
+	}
+	principal, _ := PrincipalFromContext(v.ctx)
+	return v.store.authz(v.ctx, ACLRequest{
+		Principal: principal,
+		Action:    action,
+		Name:      name,
+		Variant:   variant,
+		Partial:   partial,
+		ACL:       acl,
+	})
+}
+
+// denied returns the error for an access that is not allowed.
+func (v *aclView) denied(action ACLAction, kind, name string) error {
+	return fmt.Errorf("%w: %s %s %q", ErrAccessDenied, action, kind, name)
+}
+
+// List lists the prompts the principal may read.
+func (v *aclView) List(options ListPromptsOptions) (ListPromptsResult[PromptRef], error) {
+	result, err := v.store.inner.List(options)
+	if err != nil {
+		return result, err
+	}
+	items := result.Items[:0:0]
+	for _, ref := range result.Items {
+		// Prompts that cannot be loaded, e.g. because they were deleted
+		// since the listing, are left out.
+		prompt, err := v.store.inner.Load(ref.Name, LoadPromptOptions{Variant: ref.Variant})
+		if err == nil && v.allowed(ACLRead, ref.Name, ref.Variant, false, prompt.Source) {
+			items = append(items, ref)
+		}
+	}
+	result.Items = items
+	return result, nil
+}
+
+// ListPartials lists the partials the principal may read.
+func (v *aclView) ListPartials(options ListPartialsOptions) (ListPartialsResult[PartialRef], error) {
+	result, err := v.store.inner.ListPartials(options)
+	if err != nil {
+		return result, err
+	}
+	items := result.Items[:0:0]
+	for _, ref := range result.Items {
+		partial, err := v.store.inner.LoadPartial(ref.Name, LoadPartialOptions{Variant: ref.Variant})
+		if err == nil && v.allowed(ACLRead, ref.Name, ref.Variant, true, partial.Source) {
+			items = append(items, ref)
+		}
+	}
+	result.Items = items
+	return result, nil
+}
+
+// Load loads a prompt if the principal may read it. Earlier versions are
+// checked against the ACL of the current one, so that tightening an ACL also
+// protects the archived versions; they are denied if the current prompt
+// cannot be loaded.
+func (v *aclView) Load(name string, options LoadPromptOptions) (PromptData, error) {
+	current, err := v.store.inner.Load(name, LoadPromptOptions{Variant: options.Variant})
+	if err != nil {
+		if options.Version != "" {
+			return PromptData{}, fmt.Errorf("%w: read prompt %q: %w", ErrAccessDenied, name, err)
+		}
+		return PromptData{}, err
+	}
+	if !v.allowed(ACLRead, name, current.Variant, false, current.Source) {
+		return PromptData{}, v.denied(ACLRead, "prompt", name)
+	}
+	if options.Version == "" {
+		return current, nil
+	}
+	return v.store.inner.Load(name, options)
+}
+
+// LoadPartial loads a partial if the principal may read it. Like Load, it
+// checks earlier versions against the ACL of the current one.
+func (v *aclView) LoadPartial(name string, options LoadPartialOptions) (PartialData, error) {
+	current, err := v.store.inner.LoadPartial(name, LoadPartialOptions{Variant: options.Variant})
+	if err != nil {
+		if options.Version != "" {
+			return PartialData{}, fmt.Errorf("%w: read partial %q: %w", ErrAccessDenied, name, err)
+		}
+		return PartialData{}, err
+	}
+	if !v.allowed(ACLRead, name, current.Variant, true, current.Source) {
+		return PartialData{}, v.denied(ACLRead, "partial", name)
+	}
+	if options.Version == "" {
+		return current, nil
+	}
+	return v.store.inner.LoadPartial(name, options)
+}
+
+// aclWritableView is the view of an ACLStore that decorates a
+// WritablePromptStore.
+type aclWritableView struct {
+	*aclView
+	inner WritablePromptStore
+}
+
+var _ WritablePromptStore = (*aclWritableView)(nil)
+
+// Save saves a prompt if the principal may write both the prompt it replaces,
+// if any, and the new prompt, so that callers cannot lock themselves out. If
+// the prompt it replaces cannot be loaded for a reason other than not
+// existing, the save is denied.
+func (v *aclWritableView) Save(prompt PromptData) error {
+	existing, err := v.inner.Load(prompt.Name, LoadPromptOptions{Variant: prompt.Variant})
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("%w: write prompt %q: %w", ErrAccessDenied, prompt.Name, err)
+	case !v.allowed(ACLWrite, prompt.Name, prompt.Variant, false, existing.Source):
+		return v.denied(ACLWrite, "prompt", prompt.Name)
+	}
+	if !v.allowed(ACLWrite, prompt.Name, prompt.Variant, false, prompt.Source) {
+		return v.denied(ACLWrite, "prompt", prompt.Name)
+	}
+	return v.inner.Save(prompt)
+}
+
+// Delete deletes a prompt if the principal may write it.
+func (v *aclWritableView) Delete(name string, options PromptStoreDeleteOptions) error {
+	existing, err := v.inner.Load(name, LoadPromptOptions{Variant: options.Variant})
+	if err != nil {
+		return err
+	}
+	if !v.allowed(ACLWrite, name, options.Variant, false, existing.Source) {
+		return v.denied(ACLWrite, "prompt", name)
+	}
+	return v.inner.Delete(name, options)
+}
@@ -0,0 +1,312 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newACLTestStore(t *testing.T) (*DirStore, *ACLStore) {
+	t.Helper()
+	inner, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	prompts := map[string]string{
+		"public":  "hello",
+		"team":    "---\nacl.read: [alice, bob]\nacl.write: [alice]\n---\nteam",
+		"private": "---\nacl.read: [carol]\nacl.write: [carol]\n---\nprivate",
+		"broken":  "---\nacl.read: {x: 1}\n---\nbroken",
+	}
+	for name, source := range prompts {
+		if err := inner.Save(PromptData{PromptRef: PromptRef{Name: name}, Source: source}); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	if err := inner.SavePartial(PartialData{PartialRef: PartialRef{Name: "secret"}, Source: "---\nacl.read: [carol]\n---\nsecret"}); err != nil {
+		t.Fatalf("SavePartial() returned error: %v", err)
+	}
+	return inner, NewACLStore(inner, nil)
+}
+
+func TestACLAllows(t *testing.T) {
+	acl := ACL{Read: []string{"*"}, Write: []string{"alice"}}
+	tests := []struct {
+		principal string
+		action    ACLAction
+		want      bool
+	}{
+		{"bob", ACLRead, true},
+		{"alice", ACLWrite, true},
+		{"bob", ACLWrite, false},
+		{"", ACLWrite, false},
+	}
+	for _, tt := range tests {
+		if got := acl.Allows(tt.principal, tt.action); got != tt.want {
+			t.Errorf("Allows(%q, %q) = %v, want %v", tt.principal, tt.action, got, tt.want)
+		}
+	}
+	if !(ACL{}).Allows("", ACLWrite) {
+		t.Errorf("empty ACL does not allow writes")
+	}
+}
+
+func TestACLStoreList(t *testing.T) {
+	_, acls := newACLTestStore(t)
+	tests := []struct {
+		principal string
+		want      []string
+	}{
+		{"alice", []string{"public", "team"}},
+		{"carol", []string{"private", "public"}},
+		{"", []string{"public"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.principal, func(t *testing.T) {
+			store := acls.For(WithPrincipal(context.Background(), tt.principal))
+			result, err := store.List(ListPromptsOptions{})
+			if err != nil {
+				t.Fatalf("List() returned error: %v", err)
+			}
+			var names []string
+			for _, ref := range result.Items {
+				names = append(names, ref.Name)
+			}
+			if diff := cmp.Diff(tt.want, names); diff != "" {
+				t.Errorf("List() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	partials, err := acls.For(WithPrincipal(context.Background(), "alice")).ListPartials(ListPartialsOptions{})
+	if err != nil {
+		t.Fatalf("ListPartials() returned error: %v", err)
+	}
+	if len(partials.Items) != 0 {
+		t.Errorf("ListPartials() = %v, want none", partials.Items)
+	}
+}
+
+func TestACLStoreLoad(t *testing.T) {
+	_, acls := newACLTestStore(t)
+	store := acls.For(WithPrincipal(context.Background(), "bob"))
+
+	if _, err := store.Load("team", LoadPromptOptions{}); err != nil {
+		t.Errorf("Load(team) returned error: %v", err)
+	}
+	if _, err := store.Load("private", LoadPromptOptions{}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Load(private) error = %v, want ErrAccessDenied", err)
+	}
+	if _, err := store.LoadPartial("secret", LoadPartialOptions{}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("LoadPartial(secret) error = %v, want ErrAccessDenied", err)
+	}
+	if _, err := store.Load("missing", LoadPromptOptions{}); err == nil || errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Load(missing) error = %v, want not found", err)
+	}
+}
+
+func TestACLStoreWrite(t *testing.T) {
+	inner, acls := newACLTestStore(t)
+	ctx := context.Background()
+	bob := acls.For(WithPrincipal(ctx, "bob")).(WritablePromptStore)
+	alice := acls.For(WithPrincipal(ctx, "alice")).(WritablePromptStore)
+
+	if err := bob.Save(PromptData{PromptRef: PromptRef{Name: "team"}, Source: "overwritten"}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Save() by reader error = %v, want ErrAccessDenied", err)
+	}
+	locked := "---\nacl.write: [carol]\n---\nmine"
+	if err := alice.Save(PromptData{PromptRef: PromptRef{Name: "team"}, Source: locked}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Save() locking out the writer error = %v, want ErrAccessDenied", err)
+	}
+	updated := "---\nacl.read: [alice, bob]\nacl.write: [alice]\n---\nupdated"
+	if err := alice.Save(PromptData{PromptRef: PromptRef{Name: "team"}, Source: updated}); err != nil {
+		t.Errorf("Save() by writer returned error: %v", err)
+	}
+	if err := bob.Delete("team", PromptStoreDeleteOptions{}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Delete() by reader error = %v, want ErrAccessDenied", err)
+	}
+	if err := alice.Delete("team", PromptStoreDeleteOptions{}); err != nil {
+		t.Errorf("Delete() by writer returned error: %v", err)
+	}
+	if _, err := inner.Load("team", LoadPromptOptions{}); err == nil {
+		t.Errorf("Load() after Delete() returned no error")
+	}
+}
+
+func TestACLStoreCustomAuthz(t *testing.T) {
+	inner, _ := newACLTestStore(t)
+	var got []ACLRequest
+	acls := NewACLStore(inner, func(_ context.Context, req ACLRequest) bool {
+		got = append(got, req)
+		return req.Principal == "admin"
+	})
+	if _, err := acls.For(WithPrincipal(context.Background(), "admin")).Load("private", LoadPromptOptions{}); err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	want := []ACLRequest{{
+		Principal: "admin",
+		Action:    ACLRead,
+		Name:      "private",
+		ACL:       ACL{Read: []string{"carol"}, Write: []string{"carol"}},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("authz requests mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestACLStoreReadOnly(t *testing.T) {
+	inner, _ := newACLTestStore(t)
+	store := NewACLStore(struct{ PromptStore }{inner}, nil).For(context.Background())
+	if _, ok := store.(WritablePromptStore); ok {
+		t.Errorf("For() of a read-only store is writable")
+	}
+}
+
+func TestParseACL(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    ACL
+		wantErr bool
+	}{
+		{name: "none", source: "hello", want: ACL{}},
+		{name: "dotted", source: "---\nacl.read: [alice]\n---\nx", want: ACL{Read: []string{"alice"}}},
+		{name: "nested", source: "---\nacl:\n  read: [alice]\n  write: [bob]\n---\nx", want: ACL{Read: []string{"alice"}, Write: []string{"bob"}}},
+		{name: "nested not a map", source: "---\nacl: [alice]\n---\nx", wantErr: true},
+		{name: "malformed frontmatter", source: "---\nacl.read: [alice]\nmodel: [\n---\nx", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseACL(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseACL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseACL() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestACLStoreUnreadableACL(t *testing.T) {
+	inner, acls := newACLTestStore(t)
+	prompts := map[string]string{
+		"malformed": "---\nacl.read: [alice]\nmodel: [\n---\nmalformed",
+		"nested":    "---\nacl:\n  read: [carol]\n  write: [carol]\n---\nnested",
+	}
+	for name, source := range prompts {
+		if err := inner.Save(PromptData{PromptRef: PromptRef{Name: name}, Source: source}); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	alice := acls.For(WithPrincipal(context.Background(), "alice")).(WritablePromptStore)
+	for name := range prompts {
+		if _, err := alice.Load(name, LoadPromptOptions{}); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("Load(%s) error = %v, want ErrAccessDenied", name, err)
+		}
+		if err := alice.Save(PromptData{PromptRef: PromptRef{Name: name}, Source: "mine"}); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("Save(%s) error = %v, want ErrAccessDenied", name, err)
+		}
+	}
+	if _, err := acls.For(WithPrincipal(context.Background(), "carol")).Load("nested", LoadPromptOptions{}); err != nil {
+		t.Errorf("Load(nested) by reader returned error: %v", err)
+	}
+}
+
+// failingLoadStore is a store whose prompts cannot be loaded.
+type failingLoadStore struct {
+	WritablePromptStore
+}
+
+func (failingLoadStore) Load(string, LoadPromptOptions) (PromptData, error) {
+	return PromptData{}, errors.New("disk on fire")
+}
+
+func TestACLStoreSaveLoadError(t *testing.T) {
+	inner, _ := newACLTestStore(t)
+	store := NewACLStore(failingLoadStore{inner}, nil).For(WithPrincipal(context.Background(), "alice")).(WritablePromptStore)
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "private"}, Source: "mine"}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Save() error = %v, want ErrAccessDenied", err)
+	}
+	if prompt, err := inner.Load("private", LoadPromptOptions{}); err != nil || prompt.Source == "mine" {
+		t.Errorf("Load() after a denied Save() = %q, %v, want the original prompt", prompt.Source, err)
+	}
+}
+
+func TestACLStoreArchivedVersions(t *testing.T) {
+	inner, acls := newACLTestStore(t)
+	inner.KeepVersions = true
+	if err := inner.Save(PromptData{PromptRef: PromptRef{Name: "p"}, Source: "secret v1"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	v1, err := inner.Load("p", LoadPromptOptions{})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if err := inner.Save(PromptData{PromptRef: PromptRef{Name: "p"}, Source: "---\nacl.read: [carol]\n---\nsecret v2"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	mallory := acls.For(WithPrincipal(context.Background(), "mallory")).(WritablePromptStore)
+	if prompt, err := mallory.Load("p", LoadPromptOptions{Version: v1.Version}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Load(p, v1) = %q, %v, want ErrAccessDenied", prompt.Source, err)
+	}
+	carol := acls.For(WithPrincipal(context.Background(), "carol")).(WritablePromptStore)
+	if prompt, err := carol.Load("p", LoadPromptOptions{Version: v1.Version}); err != nil || prompt.Source != "secret v1" {
+		t.Errorf("Load(p, v1) by reader = %q, %v, want the archived version", prompt.Source, err)
+	}
+
+	if err := carol.Delete("p", PromptStoreDeleteOptions{}); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if prompt, err := mallory.Load("p", LoadPromptOptions{Version: v1.Version}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Load(p, v1) of a deleted prompt = %q, %v, want ErrAccessDenied", prompt.Source, err)
+	}
+}
+
+// vanishingStore is a store whose prompt "team" is listed but cannot be
+// loaded, as if it was deleted since the listing.
+type vanishingStore struct {
+	WritablePromptStore
+}
+
+func (s vanishingStore) Load(name string, options LoadPromptOptions) (PromptData, error) {
+	if name == "team" {
+		return PromptData{}, ErrNotFound
+	}
+	return s.WritablePromptStore.Load(name, options)
+}
+
+func TestACLStoreListSkipsUnloadable(t *testing.T) {
+	inner, _ := newACLTestStore(t)
+	store := NewACLStore(vanishingStore{inner}, nil).For(WithPrincipal(context.Background(), "alice"))
+	result, err := store.List(ListPromptsOptions{})
+	if err != nil {
+		t.Fatalf("List() returned error: %v", err)
+	}
+	var names []string
+	for _, ref := range result.Items {
+		names = append(names, ref.Name)
+	}
+	if diff := cmp.Diff([]string{"public"}, names); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+}
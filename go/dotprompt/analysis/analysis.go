@@ -0,0 +1,351 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package analysis provides source-level information about `.prompt` files
+// for editor tooling: diagnostics, the symbols referenced by a prompt and
+// hover text and definitions at a position. It is designed to back a dotprompt
+// language server without reimplementing parsing.
+//
+// Positions are 1-based lines and byte columns within the whole file,
+// including the frontmatter. Language servers speaking LSP must convert
+// columns to UTF-16 code units.
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/lint"
+)
+
+// Position is a location in a source file.
+type Position struct {
+	// Line is the 1-based line number.
+	Line int `json:"line"`
+	// Column is the 1-based byte offset within the line.
+	Column int `json:"column"`
+}
+
+// Range is a half-open span of a source file.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Contains reports whether pos lies within r.
+func (r Range) Contains(pos Position) bool {
+	if pos.Line < r.Start.Line || pos.Line > r.End.Line {
+		return false
+	}
+	if pos.Line == r.Start.Line && pos.Column < r.Start.Column {
+		return false
+	}
+	if pos.Line == r.End.Line && pos.Column >= r.End.Column {
+		return false
+	}
+	return true
+}
+
+// SymbolKind is the kind of a Symbol.
+type SymbolKind string
+
+// Symbol kinds.
+const (
+	// SymbolVariable is a reference to an input variable in the template.
+	SymbolVariable SymbolKind = "variable"
+	// SymbolPartial is a reference to a partial in the template.
+	SymbolPartial SymbolKind = "partial"
+	// SymbolHelper is a reference to a helper in the template.
+	SymbolHelper SymbolKind = "helper"
+	// SymbolSchemaField is a field declared in the input or output schema.
+	SymbolSchemaField SymbolKind = "schema-field"
+)
+
+// Symbol is a named element of a prompt file.
+type Symbol struct {
+	Kind SymbolKind `json:"kind"`
+	// Name is the symbol name. For schema fields it is the dotted path from
+	// the schema root, e.g. `user.name`, prefixed with `input.` or `output.`.
+	Name  string `json:"name"`
+	Range Range  `json:"range"`
+	// Detail is the Picoschema type and description of a schema field.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Location is a range within a file.
+type Location struct {
+	File  string `json:"file"`
+	Range Range  `json:"range"`
+}
+
+// Options configures Analyze.
+type Options struct {
+	// File is the name of the file being analyzed, used in diagnostics and
+	// locations.
+	File string
+	// Store resolves partials for diagnostics, hover and definitions. When
+	// nil, partials are not resolved.
+	Store dp.PromptStore
+}
+
+// Document is the result of analyzing a single prompt file.
+type Document struct {
+	Source      string
+	File        string
+	Diagnostics []lint.Diagnostic
+	// Symbols are sorted by position.
+	Symbols []Symbol
+
+	store      dp.PromptStore
+	lineStarts []int
+}
+
+// Analyze parses source and collects its diagnostics and symbols. Analysis is
+// best effort: symbols are still reported for the parts of a file that parse.
+func Analyze(source string, opts *Options) *Document {
+	if opts == nil {
+		opts = &Options{}
+	}
+	d := &Document{
+		Source:      source,
+		File:        opts.File,
+		Diagnostics: lint.Source(opts.File, source, &lint.Options{Store: opts.Store}),
+		store:       opts.Store,
+		lineStarts:  lineStarts(source),
+	}
+
+	frontmatter, frontmatterOffset, body, bodyOffset := split(source)
+	if frontmatter != "" {
+		d.schemaSymbols(frontmatter, frontmatterOffset)
+	}
+	d.templateSymbols(body, bodyOffset)
+
+	sort.SliceStable(d.Symbols, func(i, j int) bool {
+		a, b := d.Symbols[i].Range.Start, d.Symbols[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return d
+}
+
+// SymbolAt returns the symbol whose range contains pos.
+func (d *Document) SymbolAt(pos Position) (Symbol, bool) {
+	for _, s := range d.Symbols {
+		if s.Range.Contains(pos) {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// Definition returns the location that defines the symbol at pos: the schema
+// field declaring a variable, or the file of a partial in the store.
+func (d *Document) Definition(pos Position) (Location, bool) {
+	s, ok := d.SymbolAt(pos)
+	if !ok {
+		return Location{}, false
+	}
+	switch s.Kind {
+	case SymbolVariable:
+		if field, ok := d.schemaField("input." + variableName(s.Name)); ok {
+			return Location{File: d.File, Range: field.Range}, true
+		}
+	case SymbolPartial:
+		if d.store == nil {
+			return Location{}, false
+		}
+		partial, err := d.store.LoadPartial(s.Name, dp.LoadPartialOptions{})
+		if err != nil {
+			return Location{}, false
+		}
+		return Location{File: partialFile(partial.PartialRef)}, true
+	case SymbolSchemaField:
+		return Location{File: d.File, Range: s.Range}, true
+	}
+	return Location{}, false
+}
+
+// schemaField returns the schema field symbol with the given name.
+func (d *Document) schemaField(name string) (Symbol, bool) {
+	for _, s := range d.Symbols {
+		if s.Kind == SymbolSchemaField && s.Name == name {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// templateSymbols adds the variables, partials and helpers referenced by the
+// template body, which starts at byte offset base of the source.
+func (d *Document) templateSymbols(body string, base int) {
+	if vars, err := dp.TemplateVariables(body); err == nil {
+		for _, v := range vars {
+			d.addSymbol(SymbolVariable, v.Path, base+v.Pos, len(v.Path), "")
+		}
+	}
+	if partials, err := dp.TemplatePartials(body); err == nil {
+		for _, p := range partials {
+			// The partial position is that of the opening `{{`.
+			start := base + p.Pos
+			if i := strings.Index(d.Source[start:], p.Name); i >= 0 {
+				start += i
+			}
+			d.addSymbol(SymbolPartial, p.Name, start, len(p.Name), "")
+		}
+	}
+	if helpers, err := dp.TemplateHelpers(body); err == nil {
+		for _, h := range helpers {
+			d.addSymbol(SymbolHelper, h.Name, base+h.Pos, len(h.Name), "")
+		}
+	}
+}
+
+// schemaSymbols adds the fields of the input and output schemas declared in
+// frontmatter, which starts at byte offset base of the source.
+func (d *Document) schemaSymbols(frontmatter string, base int) {
+	file, err := parser.ParseBytes([]byte(frontmatter), 0)
+	if err != nil || len(file.Docs) == 0 {
+		return
+	}
+	for _, section := range []string{"input", "output"} {
+		sectionNode := mappingValue(file.Docs[0].Body, section)
+		if sectionNode == nil {
+			continue
+		}
+		if schema := mappingValue(sectionNode, "schema"); schema != nil {
+			d.schemaFields(schema, section, base)
+		}
+	}
+}
+
+// schemaFields adds a symbol for each Picoschema field of node.
+func (d *Document) schemaFields(node ast.Node, prefix string, base int) {
+	for _, mv := range mappingValues(node) {
+		key := mv.Key.GetToken()
+		if key == nil {
+			continue
+		}
+		name, detail := fieldName(key.Value)
+		if name == "properties" || name == "type" {
+			// A JSON Schema rather than a Picoschema; only the properties
+			// are fields.
+			if name == "properties" {
+				d.schemaFields(mv.Value, prefix, base)
+			}
+			continue
+		}
+		if detail == "" {
+			if _, nested := mv.Value.(*ast.MappingNode); !nested {
+				detail = strings.TrimSpace(mv.Value.String())
+			}
+		}
+		start := base + key.Position.Offset - 1
+		d.addSymbol(SymbolSchemaField, prefix+"."+name, start, len(key.Value), detail)
+		d.schemaFields(mv.Value, prefix+"."+name, base)
+	}
+}
+
+// addSymbol appends a symbol spanning length bytes at byte offset start.
+func (d *Document) addSymbol(kind SymbolKind, name string, start, length int, detail string) {
+	d.Symbols = append(d.Symbols, Symbol{
+		Kind:   kind,
+		Name:   name,
+		Range:  Range{Start: d.position(start), End: d.position(start + length)},
+		Detail: detail,
+	})
+}
+
+// position converts a byte offset of the source into a Position.
+func (d *Document) position(offset int) Position {
+	line := sort.Search(len(d.lineStarts), func(i int) bool { return d.lineStarts[i] > offset })
+	return Position{Line: line, Column: offset - d.lineStarts[line-1] + 1}
+}
+
+// lineStarts returns the byte offset at which each line of source starts.
+func lineStarts(source string) []int {
+	starts := []int{0}
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// split returns the frontmatter and body of source with their byte offsets.
+func split(source string) (frontmatter string, frontmatterOffset int, body string, bodyOffset int) {
+	fmStart, fmEnd, bodyStart, ok := dp.FrontmatterBounds(source)
+	if !ok {
+		return "", 0, source, 0
+	}
+	return source[fmStart:fmEnd], fmStart, source[bodyStart:], bodyStart
+}
+
+// mappingValues returns the entries of a YAML mapping node.
+func mappingValues(node ast.Node) []*ast.MappingValueNode {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		return n.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a YAML mapping node.
+func mappingValue(node ast.Node, key string) ast.Node {
+	for _, mv := range mappingValues(node) {
+		if tok := mv.Key.GetToken(); tok != nil && tok.Value == key {
+			return mv.Value
+		}
+	}
+	return nil
+}
+
+// fieldName splits a Picoschema key such as `name?` or `tags(array, the
+// tags)` into the field name and the parenthetical detail.
+func fieldName(key string) (name, detail string) {
+	name = key
+	if i := strings.Index(name, "("); i >= 0 && strings.HasSuffix(name, ")") {
+		detail = name[i+1 : len(name)-1]
+		name = name[:i]
+	}
+	return strings.TrimSuffix(strings.TrimSpace(name), "?"), detail
+}
+
+// variableName returns the top-level name of a dotted variable path.
+func variableName(path string) string {
+	name, _, _ := strings.Cut(path, ".")
+	return name
+}
+
+// partialFile returns the store-relative file name of a partial.
+func partialFile(ref dp.PartialRef) string {
+	dir, base := "", ref.Name
+	if i := strings.LastIndex(ref.Name, "/"); i >= 0 {
+		dir, base = ref.Name[:i+1], ref.Name[i+1:]
+	}
+	if ref.Variant != "" {
+		base += "." + ref.Variant
+	}
+	return dir + "_" + base + ".prompt"
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+const testSource = `---
+input:
+  schema:
+    name: string, the user's name
+    address?:
+      city: string
+output:
+  schema:
+    tags(array, list of tags): string
+---
+{{role "system"}}
+Hello {{name}} from {{address.city}}!
+{{> footer}}
+`
+
+func span(line, start, end int) Range {
+	return Range{Start: Position{Line: line, Column: start}, End: Position{Line: line, Column: end}}
+}
+
+func TestAnalyzeSymbols(t *testing.T) {
+	doc := Analyze(testSource, &Options{File: "test.prompt"})
+
+	want := []Symbol{
+		{Kind: SymbolSchemaField, Name: "input.name", Range: span(4, 5, 9), Detail: "string, the user's name"},
+		{Kind: SymbolSchemaField, Name: "input.address", Range: span(5, 5, 13)},
+		{Kind: SymbolSchemaField, Name: "input.address.city", Range: span(6, 7, 11), Detail: "string"},
+		{Kind: SymbolSchemaField, Name: "output.tags", Range: span(9, 5, 30), Detail: "array, list of tags"},
+		{Kind: SymbolHelper, Name: "role", Range: span(11, 3, 7)},
+		{Kind: SymbolVariable, Name: "name", Range: span(12, 9, 13)},
+		{Kind: SymbolVariable, Name: "address.city", Range: span(12, 23, 35)},
+		{Kind: SymbolPartial, Name: "footer", Range: span(13, 5, 11)},
+	}
+	if diff := cmp.Diff(want, doc.Symbols); diff != "" {
+		t.Errorf("Symbols mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAnalyzeDiagnostics(t *testing.T) {
+	store := storetest.NewDirStore(t, nil)
+	doc := Analyze(testSource, &Options{File: "test.prompt", Store: store})
+	if len(doc.Diagnostics) != 1 || doc.Diagnostics[0].Code != "missing-partial" {
+		t.Errorf("Diagnostics = %v, want one missing-partial", doc.Diagnostics)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	store := storetest.NewDirStore(t, map[string]string{"_footer.prompt": "Bye"})
+	doc := Analyze(testSource, &Options{File: "test.prompt", Store: store})
+
+	tests := []struct {
+		name string
+		pos  Position
+		want Location
+		ok   bool
+	}{
+		{"variable", Position{Line: 12, Column: 10}, Location{File: "test.prompt", Range: span(4, 5, 9)}, true},
+		{"nested variable", Position{Line: 12, Column: 30}, Location{File: "test.prompt", Range: span(5, 5, 13)}, true},
+		{"partial", Position{Line: 13, Column: 5}, Location{File: "_footer.prompt"}, true},
+		{"helper", Position{Line: 11, Column: 3}, Location{}, false},
+		{"whitespace", Position{Line: 12, Column: 1}, Location{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := doc.Definition(tt.pos)
+			if ok != tt.ok {
+				t.Fatalf("Definition(%v) ok = %v, want %v", tt.pos, ok, tt.ok)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Definition(%v) mismatch (-want +got):\n%s", tt.pos, diff)
+			}
+		})
+	}
+}
+
+func TestAnalyzeInvalidTemplate(t *testing.T) {
+	doc := Analyze("---\ninput:\n  schema:\n    name: string\n---\n{{#if name}}", nil)
+	if len(doc.Diagnostics) == 0 || doc.Diagnostics[0].Code != "invalid-template" {
+		t.Errorf("Diagnostics = %v, want invalid-template", doc.Diagnostics)
+	}
+	if len(doc.Symbols) != 1 || doc.Symbols[0].Name != "input.name" {
+		t.Errorf("Symbols = %v, want the schema field only", doc.Symbols)
+	}
+}
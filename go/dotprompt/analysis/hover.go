@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// helperDocs documents the built-in Handlebars and dotprompt helpers.
+var helperDocs = map[string]string{
+	"if":           "Renders the block if the argument is truthy, otherwise the `{{else}}` block.",
+	"unless":       "Renders the block if the argument is falsy, otherwise the `{{else}}` block.",
+	"each":         "Renders the block once for each item of an array or object.",
+	"with":         "Renders the block with the argument as the context.",
+	"lookup":       "Looks up a field of an object or an index of an array.",
+	"log":          "Logs its arguments.",
+	"json":         "Serializes the argument as JSON. Accepts an optional `indent` hash argument.",
+	"role":         "Starts a new message with the given role, e.g. `{{role \"system\"}}`.",
+	"history":      "Inserts the conversation history from the `messages` data argument.",
+	"section":      "Marks the start of a named section of the prompt.",
+	"media":        "Inserts a media part, e.g. `{{media url=imageUrl contentType=\"image/png\"}}`.",
+	"ifEquals":     "Renders the block if the two arguments are equal.",
+	"unlessEquals": "Renders the block unless the two arguments are equal.",
+}
+
+// Hover returns Markdown hover text for the symbol at pos.
+func (d *Document) Hover(pos Position) (string, bool) {
+	s, ok := d.SymbolAt(pos)
+	if !ok {
+		return "", false
+	}
+
+	switch s.Kind {
+	case SymbolVariable:
+		text := fmt.Sprintf("**%s** (input variable)", s.Name)
+		if field, ok := d.schemaField("input." + variableName(s.Name)); ok && field.Detail != "" {
+			text += "\n\n" + field.Detail
+		}
+		return text, true
+
+	case SymbolSchemaField:
+		text := fmt.Sprintf("**%s** (schema field)", s.Name)
+		if s.Detail != "" {
+			text += "\n\n" + s.Detail
+		}
+		return text, true
+
+	case SymbolHelper:
+		text := fmt.Sprintf("**%s** (helper)", s.Name)
+		if doc, ok := helperDocs[s.Name]; ok {
+			text += "\n\n" + doc
+		}
+		return text, true
+
+	case SymbolPartial:
+		text := fmt.Sprintf("**%s** (partial)", s.Name)
+		if d.store != nil {
+			if partial, err := d.store.LoadPartial(s.Name, dp.LoadPartialOptions{}); err == nil {
+				text += "\n\n```handlebars\n" + strings.TrimRight(partial.Source, "\n") + "\n```"
+			} else {
+				text += "\n\nNot found."
+			}
+		}
+		return text, true
+	}
+	return "", false
+}
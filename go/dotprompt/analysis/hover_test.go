@@ -0,0 +1,54 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package analysis
+
+import (
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+func TestHover(t *testing.T) {
+	store := storetest.NewDirStore(t, map[string]string{"_footer.prompt": "Bye\n"})
+	doc := Analyze(testSource, &Options{File: "test.prompt", Store: store})
+
+	tests := []struct {
+		name string
+		pos  Position
+		want string
+	}{
+		{"variable", Position{Line: 12, Column: 9}, "**name** (input variable)\n\nstring, the user's name"},
+		{"schema field", Position{Line: 9, Column: 6}, "**output.tags** (schema field)\n\narray, list of tags"},
+		{"helper", Position{Line: 11, Column: 4}, "**role** (helper)\n\n" + helperDocs["role"]},
+		{"partial", Position{Line: 13, Column: 10}, "**footer** (partial)\n\n```handlebars\nBye\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := doc.Hover(tt.pos)
+			if !ok {
+				t.Fatalf("Hover(%v) found nothing", tt.pos)
+			}
+			if got != tt.want {
+				t.Errorf("Hover(%v) = %q, want %q", tt.pos, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := doc.Hover(Position{Line: 1, Column: 1}); ok {
+		t.Error("Hover() on frontmatter delimiter found a symbol")
+	}
+}
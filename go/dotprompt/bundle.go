@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// Bundle is a set of prompts compiled from a PromptBundle. Lookups do not
+// touch the filesystem and a Bundle is safe for concurrent rendering.
+type Bundle struct {
+	prompts map[string]PromptFunction
+	meta    map[string]PromptMetadata
+	deps    map[string]PromptDependencies
+	refs    []PromptRef
+}
+
+// LoadBundle registers the partials of bundle with dp, precompiles each of its
+// prompts and resolves their metadata. Partials with a variant are not
+// registered, since templates can only reference partials by name. It returns
+// an error if a partial name is already defined with a different source or if
+// any prompt fails to compile, in which case dp is left unchanged.
+func (dp *Dotprompt) LoadBundle(bundle *PromptBundle) (b *Bundle, err error) {
+	if bundle == nil {
+		return &Bundle{
+			prompts: make(map[string]PromptFunction),
+			meta:    make(map[string]PromptMetadata),
+			deps:    make(map[string]PromptDependencies),
+		}, nil
+	}
+
+	// Every partial is checked before any is registered, so that a conflict
+	// does not leave dp with part of the bundle.
+	added := make(map[string]string)
+	for _, partial := range bundle.Partials {
+		if partial.Variant != "" {
+			continue
+		}
+		existing, ok := dp.Partials[partial.Name]
+		if !ok {
+			existing, ok = added[partial.Name]
+		}
+		if ok && existing != partial.Source {
+			return nil, fmt.Errorf("bundle partial %q conflicts with an existing partial", partial.Name)
+		}
+		if _, registered := dp.Partials[partial.Name]; !registered {
+			added[partial.Name] = partial.Source
+		}
+	}
+	maps.Copy(dp.Partials, added)
+	defer func() {
+		if err != nil {
+			for name := range added {
+				delete(dp.Partials, name)
+			}
+		}
+	}()
+
+	sources := make(map[PartialRef]string, len(dp.Partials)+len(bundle.Partials))
+	for name, source := range dp.Partials {
+		sources[PartialRef{Name: name}] = quotePinnedPartials(source)
+	}
+	for _, partial := range bundle.Partials {
+		sources[PartialRef{Name: partial.Name, Variant: partial.Variant}] = quotePinnedPartials(partial.Source)
+	}
+
+	b = &Bundle{
+		prompts: make(map[string]PromptFunction, len(bundle.Prompts)),
+		meta:    make(map[string]PromptMetadata, len(bundle.Prompts)),
+		deps:    make(map[string]PromptDependencies, len(bundle.Prompts)),
+		refs:    make([]PromptRef, 0, len(bundle.Prompts)),
+	}
+	for _, prompt := range bundle.Prompts {
+		key := bundleKey(prompt.Name, prompt.Variant)
+		if _, ok := b.prompts[key]; ok {
+			return nil, fmt.Errorf("bundle contains duplicate prompt %q", key)
+		}
+		fn, err := dp.Compile(prompt.Source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("compiling bundle prompt %q: %w", key, err)
+		}
+		parsed, err := dp.Parse(prompt.Source)
+		if err != nil {
+			return nil, fmt.Errorf("parsing bundle prompt %q: %w", key, err)
+		}
+		if b.deps[key], err = dp.dependencies(parsed, sources); err != nil {
+			return nil, fmt.Errorf("resolving dependencies of bundle prompt %q: %w", key, err)
+		}
+		if b.meta[key], err = dp.RenderMetadata(parsed, nil); err != nil {
+			return nil, fmt.Errorf("resolving metadata of bundle prompt %q: %w", key, err)
+		}
+		b.prompts[key] = fn
+		b.refs = append(b.refs, prompt.PromptRef)
+	}
+	sort.Slice(b.refs, func(i, j int) bool {
+		if b.refs[i].Name == b.refs[j].Name {
+			return b.refs[i].Variant < b.refs[j].Variant
+		}
+		return b.refs[i].Name < b.refs[j].Name
+	})
+	return b, nil
+}
+
+// Prompt returns the compiled prompt with the given name and variant. An empty
+// variant selects the default variant.
+func (b *Bundle) Prompt(name, variant string) (PromptFunction, bool) {
+	fn, ok := b.prompts[bundleKey(name, variant)]
+	return fn, ok
+}
+
+// Metadata returns the metadata of the prompt with the given name and
+// variant, resolved when the bundle was loaded.
+func (b *Bundle) Metadata(name, variant string) (PromptMetadata, bool) {
+	meta, ok := b.meta[bundleKey(name, variant)]
+	return meta, ok
+}
+
+// Dependencies returns the dependencies of the prompt with the given name and
+// variant.
+func (b *Bundle) Dependencies(name, variant string) (PromptDependencies, bool) {
+	deps, ok := b.deps[bundleKey(name, variant)]
+	return deps, ok
+}
+
+// Render renders the named prompt with the given data and options.
+func (b *Bundle) Render(name, variant string, data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+	fn, ok := b.Prompt(name, variant)
+	if !ok {
+		return RenderedPrompt{}, fmt.Errorf("prompt %q not found in bundle", bundleKey(name, variant))
+	}
+	return fn(data, options)
+}
+
+// Prompts returns the references of all prompts in the bundle, sorted by name
+// and variant.
+func (b *Bundle) Prompts() []PromptRef {
+	return append([]PromptRef(nil), b.refs...)
+}
+
+// bundleKey returns the lookup key for a prompt name and variant.
+func bundleKey(name, variant string) string {
+	if variant == "" {
+		return name
+	}
+	return name + "." + variant
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadBundle(t *testing.T) {
+	dp := NewDotprompt(nil)
+	bundle, err := dp.LoadBundle(&PromptBundle{
+		Partials: []PartialData{
+			{PartialRef: PartialRef{Name: "greeting"}, Source: "Hello, {{name}}!"},
+			{PartialRef: PartialRef{Name: "greeting", Variant: "formal"}, Source: "Good day."},
+		},
+		Prompts: []PromptData{
+			{PromptRef: PromptRef{Name: "welcome"}, Source: "{{> greeting}} Welcome."},
+			{PromptRef: PromptRef{Name: "welcome", Variant: "short"}, Source: "---\nmodel: test/model\n---\nHi {{name}}."},
+			{PromptRef: PromptRef{Name: "bye"}, Source: "Bye {{name}}."},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadBundle() returned error: %v", err)
+	}
+
+	wantRefs := []PromptRef{{Name: "bye"}, {Name: "welcome"}, {Name: "welcome", Variant: "short"}}
+	if diff := cmp.Diff(wantRefs, bundle.Prompts()); diff != "" {
+		t.Errorf("Prompts() mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		name    string
+		variant string
+		want    string
+	}{
+		{name: "welcome", want: "Hello, Ada! Welcome."},
+		{name: "welcome", variant: "short", want: "Hi Ada."},
+		{name: "bye", want: "Bye Ada."},
+	}
+	for _, tt := range tests {
+		rendered, err := bundle.Render(tt.name, tt.variant, &DataArgument{Input: map[string]any{"name": "Ada"}}, nil)
+		if err != nil {
+			t.Fatalf("Render(%q, %q) returned error: %v", tt.name, tt.variant, err)
+		}
+		got := rendered.Messages[0].Content[0].(*TextPart).Text
+		if got != tt.want {
+			t.Errorf("Render(%q, %q) = %q, want %q", tt.name, tt.variant, got, tt.want)
+		}
+	}
+
+	if meta, ok := bundle.Metadata("welcome", "short"); !ok || meta.Model != "test/model" {
+		t.Errorf("Metadata(welcome, short) = %+v, %v, want model test/model", meta, ok)
+	}
+
+	if _, ok := bundle.Prompt("missing", ""); ok {
+		t.Error("Prompt(missing) found, want not found")
+	}
+	if _, err := bundle.Render("missing", "", &DataArgument{}, nil); err == nil {
+		t.Error("Render(missing) returned nil error")
+	}
+}
+
+func TestLoadBundleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		bundle  *PromptBundle
+		wantErr string
+	}{
+		{
+			name: "duplicate prompt",
+			bundle: &PromptBundle{Prompts: []PromptData{
+				{PromptRef: PromptRef{Name: "a"}, Source: "one"},
+				{PromptRef: PromptRef{Name: "a"}, Source: "two"},
+			}},
+			wantErr: "duplicate prompt",
+		},
+		{
+			name: "invalid template",
+			bundle: &PromptBundle{
+				Partials: []PartialData{{PartialRef: PartialRef{Name: "q"}, Source: "new"}},
+				Prompts:  []PromptData{{PromptRef: PromptRef{Name: "a"}, Source: "{{#if x}}"}},
+			},
+			wantErr: "compiling bundle prompt",
+		},
+		{
+			name: "conflicting partial",
+			bundle: &PromptBundle{Partials: []PartialData{
+				{PartialRef: PartialRef{Name: "q"}, Source: "new"},
+				{PartialRef: PartialRef{Name: "p"}, Source: "other"},
+			}},
+			wantErr: "conflicts",
+		},
+		{
+			name: "conflicting bundle partials",
+			bundle: &PromptBundle{Partials: []PartialData{
+				{PartialRef: PartialRef{Name: "q"}, Source: "one"},
+				{PartialRef: PartialRef{Name: "q"}, Source: "two"},
+			}},
+			wantErr: "conflicts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(&DotpromptOptions{Partials: map[string]string{"p": "existing"}})
+			_, err := dp.LoadBundle(tt.bundle)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadBundle() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(map[string]string{"p": "existing"}, dp.Partials); diff != "" {
+				t.Errorf("LoadBundle() changed the partials after failing (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mbleigh/raymond"
+)
+
+// CacheMarkerPrefix is the prefix of the marker written by the
+// `cacheBoundary` helper.
+const CacheMarkerPrefix = "<<<dotprompt:cache"
+
+// cacheMarkerRegex matches cache markers and captures their TTL.
+var cacheMarkerRegex = regexp.MustCompile(`<<<dotprompt:cache(?: ttl=(\S*))?>>>`)
+
+const (
+	// cacheMetadataKey is the message metadata key of a cache boundary.
+	cacheMetadataKey = "cache"
+	// cacheExtNamespace and cacheExtField locate the default TTL in the
+	// frontmatter: `cache.ttl: 1h`.
+	cacheExtNamespace = "cache"
+	cacheExtField     = "ttl"
+)
+
+// CacheBoundary marks the end of the cacheable prefix of a prompt: the
+// message it appears in and the messages before it. It accepts a `ttl` hash
+// argument, such as `{{cacheBoundary ttl="1h"}}`; without one, the TTL is the
+// `cache.ttl` frontmatter value, if any.
+func CacheBoundary(options *raymond.Options) raymond.SafeString {
+	if ttl := options.HashStr("ttl"); ttl != "" {
+		return raymond.SafeString(fmt.Sprintf("%s ttl=%s>>>", CacheMarkerPrefix, ttl))
+	}
+	return raymond.SafeString(CacheMarkerPrefix + ">>>")
+}
+
+// CacheHint is a cache boundary on a message.
+type CacheHint struct {
+	// TTL is how long the provider should keep the cached prefix, or zero
+	// for its default.
+	TTL time.Duration
+}
+
+// extractCacheMarkers removes the cache markers from a message source and
+// returns the message metadata for them, or nil if there are none.
+func extractCacheMarkers(source string) (string, map[string]any, error) {
+	matches := cacheMarkerRegex.FindAllStringSubmatch(source, -1)
+	if len(matches) == 0 {
+		return source, nil, nil
+	}
+	hint := map[string]any{}
+	for _, m := range matches {
+		if m[1] == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(m[1]); err != nil {
+			return "", nil, fmt.Errorf("invalid cache boundary ttl %q: %w", m[1], err)
+		}
+		hint["ttl"] = m[1]
+	}
+	return cacheMarkerRegex.ReplaceAllString(source, ""), hint, nil
+}
+
+// applyCacheTTL sets the TTL of the cache boundaries without one to the
+// `cache.ttl` frontmatter value.
+func applyCacheTTL(messages []Message, ext map[string]map[string]any) error {
+	ttl, ok := ext[cacheExtNamespace][cacheExtField].(string)
+	if !ok || ttl == "" {
+		return nil
+	}
+	if _, err := time.ParseDuration(ttl); err != nil {
+		return fmt.Errorf("invalid frontmatter cache.ttl %q: %w", ttl, err)
+	}
+	for _, msg := range messages {
+		// The hint may belong to a history message from the caller, so it is
+		// replaced rather than modified.
+		if hint, ok := msg.Metadata[cacheMetadataKey].(map[string]any); ok && hint["ttl"] == nil {
+			hint = maps.Clone(hint)
+			hint["ttl"] = ttl
+			msg.Metadata[cacheMetadataKey] = hint
+		}
+	}
+	return nil
+}
+
+// MessageCacheHint returns the cache boundary of msg, if it has one.
+func MessageCacheHint(msg Message) (CacheHint, bool) {
+	hint, ok := msg.Metadata[cacheMetadataKey].(map[string]any)
+	if !ok {
+		return CacheHint{}, false
+	}
+	ttl, _ := hint["ttl"].(string)
+	d, _ := time.ParseDuration(ttl)
+	return CacheHint{TTL: d}, true
+}
+
+// CachePrefix returns the messages up to and including the last one with a
+// cache boundary, with that boundary, for providers that cache a prefix of
+// the conversation such as Gemini context caching. It reports false if no
+// message has a boundary.
+func CachePrefix(messages []Message) ([]Message, CacheHint, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if hint, ok := MessageCacheHint(messages[i]); ok {
+			return messages[:i+1], hint, true
+		}
+	}
+	return nil, CacheHint{}, false
+}
+
+// AnthropicCacheControl returns the Anthropic `cache_control` value for
+// hint, to be set on the last content block of its message. Anthropic
+// supports TTLs of five minutes and one hour, so longer TTLs use an hour.
+func AnthropicCacheControl(hint CacheHint) map[string]any {
+	control := map[string]any{"type": "ephemeral"}
+	switch {
+	case hint.TTL <= 0:
+	case hint.TTL <= 5*time.Minute:
+		control["ttl"] = "5m"
+	default:
+		control["ttl"] = "1h"
+	}
+	return control
+}
+
+// GeminiCacheTTL returns the `ttl` of a Gemini cached content resource for
+// hint, such as "3600s", or "" to use the default.
+func GeminiCacheTTL(hint CacheHint) string {
+	if hint.TTL <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(hint.TTL.Seconds(), 'f', -1, 64) + "s"
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCacheBoundary(t *testing.T) {
+	dp := NewDotprompt(nil)
+	source := "---\ncache.ttl: 10m\n---\n" +
+		"{{role \"system\"}}You know the manual.{{cacheBoundary}}\n" +
+		"{{role \"user\"}}Context: {{doc}}{{cacheBoundary ttl=\"1h\"}}\n" +
+		"{{role \"user\"}}{{question}}"
+	rendered, err := dp.Render(source, &DataArgument{Input: map[string]any{"doc": "D", "question": "Q?"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if len(rendered.Messages) != 3 {
+		t.Fatalf("Render() returned %d messages, want 3", len(rendered.Messages))
+	}
+	if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != "You know the manual.\n" {
+		t.Errorf("Messages[0] text = %q, want marker removed", got)
+	}
+
+	var hints []any
+	for _, msg := range rendered.Messages {
+		hints = append(hints, msg.Metadata[cacheMetadataKey])
+	}
+	want := []any{map[string]any{"ttl": "10m"}, map[string]any{"ttl": "1h"}, nil}
+	if diff := cmp.Diff(want, hints); diff != "" {
+		t.Errorf("cache metadata mismatch (-want +got):\n%s", diff)
+	}
+
+	prefix, hint, ok := CachePrefix(rendered.Messages)
+	if !ok || len(prefix) != 2 || hint.TTL != time.Hour {
+		t.Errorf("CachePrefix() = %d messages, %+v, %v, want 2 messages with a TTL of 1h", len(prefix), hint, ok)
+	}
+	if _, ok := MessageCacheHint(rendered.Messages[2]); ok {
+		t.Error("MessageCacheHint() of message without boundary reported true")
+	}
+
+	if _, err := dp.Render("Hi{{cacheBoundary ttl=\"soon\"}}", &DataArgument{}, nil); err == nil {
+		t.Error("Render() with invalid ttl succeeded, want error")
+	}
+}
+
+func TestCacheProviderControls(t *testing.T) {
+	tests := []struct {
+		ttl           time.Duration
+		wantAnthropic map[string]any
+		wantGemini    string
+	}{
+		{0, map[string]any{"type": "ephemeral"}, ""},
+		{time.Minute, map[string]any{"type": "ephemeral", "ttl": "5m"}, "60s"},
+		{10 * time.Minute, map[string]any{"type": "ephemeral", "ttl": "1h"}, "600s"},
+		{1500 * time.Millisecond, map[string]any{"type": "ephemeral", "ttl": "5m"}, "1.5s"},
+	}
+	for _, tt := range tests {
+		hint := CacheHint{TTL: tt.ttl}
+		if diff := cmp.Diff(tt.wantAnthropic, AnthropicCacheControl(hint)); diff != "" {
+			t.Errorf("AnthropicCacheControl(%v) mismatch (-want +got):\n%s", tt.ttl, diff)
+		}
+		if got := GeminiCacheTTL(hint); got != tt.wantGemini {
+			t.Errorf("GeminiCacheTTL(%v) = %q, want %q", tt.ttl, got, tt.wantGemini)
+		}
+	}
+}
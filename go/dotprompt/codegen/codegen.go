@@ -0,0 +1,386 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package codegen generates Go source that embeds the prompts of a store
+// already parsed, with typed input structs derived from their input schemas.
+// It backs the dotpromptgen command, for use with go:generate:
+//
+//	//go:generate go run github.com/google/dotprompt/go/cmd/dotpromptgen -pkg prompts -o prompts_gen.go ./prompts
+//
+// For each prompt, the generated file declares a dotprompt.ParsedPrompt
+// variable named after the prompt, such as GreetPrompt for `greet.prompt`, to
+// compile with Dotprompt.CompileParsed, and, if the prompt has an input
+// schema, an input struct such as GreetInput whose Map method returns the
+// input to render it with. The partials of the store are declared in the
+// Partials map, to register with DotpromptOptions.Partials. Frontmatter and
+// Picoschema are resolved at generation time, so only the template is
+// compiled at runtime.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+// Options configures Generate.
+type Options struct {
+	// Package is the name of the generated package. It defaults to
+	// "prompts".
+	Package string
+	// Dotprompt parses the prompts and resolves the named schemas they
+	// reference. It defaults to dotprompt.NewDotprompt(nil).
+	Dotprompt *dotprompt.Dotprompt
+}
+
+// Generate returns the formatted Go source embedding every prompt and partial
+// of store.
+func Generate(store dotprompt.PromptStore, options Options) ([]byte, error) {
+	if options.Package == "" {
+		options.Package = "prompts"
+	}
+	dp := options.Dotprompt
+	if dp == nil {
+		dp = dotprompt.NewDotprompt(nil)
+	}
+
+	refs, err := listPrompts(store)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := dotprompt.LoadMany(store, refs)
+	if err != nil {
+		return nil, err
+	}
+	partials, err := loadPartials(store)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{structs: make(map[string]bool)}
+	g.printf("// Code generated by dotpromptgen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", options.Package)
+	g.printf("import %q\n\n", "github.com/google/dotprompt/go/dotprompt")
+
+	g.printf("// Partials are the partials of the prompts, keyed by the name templates\n")
+	g.printf("// reference them with.\n")
+	g.printf("var Partials = %s\n\n", literal(partials))
+
+	idents := make(map[string]string)
+	for _, prompt := range prompts {
+		key := promptKey(prompt.Name, prompt.Variant)
+		ident := exportedIdent(key)
+		if other, ok := idents[ident]; ok {
+			return nil, fmt.Errorf("prompts %q and %q have the same Go name %s", other, key, ident)
+		}
+		idents[ident] = key
+		if err := g.prompt(dp, prompt, ident); err != nil {
+			return nil, fmt.Errorf("generating %s: %w", key, err)
+		}
+	}
+	if g.needsInputMaps {
+		g.printf("%s", inputMapsFunc)
+	}
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %w", err)
+	}
+	return src, nil
+}
+
+// listPrompts returns the references of every prompt in store.
+func listPrompts(store dotprompt.PromptStore) ([]dotprompt.PromptRef, error) {
+	var refs []dotprompt.PromptRef
+	cursor := ""
+	for {
+		list, err := store.List(dotprompt.ListPromptsOptions{Cursor: cursor})
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, list.Items...)
+		if list.Cursor == "" || list.Cursor == cursor {
+			return refs, nil
+		}
+		cursor = list.Cursor
+	}
+}
+
+// loadPartials returns the sources of every partial in store, keyed by name,
+// or by `name@variant` for variants.
+func loadPartials(store dotprompt.PromptStore) (map[string]string, error) {
+	partials := make(map[string]string)
+	cursor := ""
+	for {
+		list, err := store.ListPartials(dotprompt.ListPartialsOptions{Cursor: cursor})
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range list.Items {
+			partial, err := store.LoadPartial(ref.Name, dotprompt.LoadPartialOptions{Variant: ref.Variant})
+			if err != nil {
+				return nil, err
+			}
+			name := ref.Name
+			if ref.Variant != "" {
+				name += "@" + ref.Variant
+			}
+			partials[name] = partial.Source
+		}
+		if list.Cursor == "" || list.Cursor == cursor {
+			return partials, nil
+		}
+		cursor = list.Cursor
+	}
+}
+
+// promptKey returns the name of a prompt with its variant, as in file names.
+func promptKey(name, variant string) string {
+	if variant == "" {
+		return name
+	}
+	return name + "." + variant
+}
+
+// generator accumulates the generated source.
+type generator struct {
+	buf bytes.Buffer
+	// structs are the names of the declared input structs.
+	structs map[string]bool
+	// needsInputMaps is set once a struct maps a slice of structs.
+	needsInputMaps bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// prompt generates the declarations of prompt.
+func (g *generator) prompt(dp *dotprompt.Dotprompt, prompt dotprompt.PromptData, ident string) error {
+	parsed, err := dp.Parse(prompt.Source)
+	if err != nil {
+		return err
+	}
+	if parsed.PromptMetadata, err = dp.RenderPicoschema(parsed.PromptMetadata); err != nil {
+		return err
+	}
+	parsed.Name, parsed.Variant, parsed.Version = prompt.Name, prompt.Variant, prompt.Version
+	// Schemas are generated as JSON values rather than jsonschema types.
+	if parsed.Input.Schema, err = dotprompt.ToJSONValue(parsed.Input.Schema); err != nil {
+		return err
+	}
+	if parsed.Output.Schema, err = dotprompt.ToJSONValue(parsed.Output.Schema); err != nil {
+		return err
+	}
+
+	key := promptKey(prompt.Name, prompt.Variant)
+	if schema, ok := parsed.Input.Schema.(map[string]any); ok {
+		if _, isObject := schema["properties"]; isObject {
+			g.structType(ident+"Input", fmt.Sprintf("is the input of the %s prompt.", key), schema)
+		}
+	}
+	g.printf("// %sPrompt is the %s prompt, parsed at generation time.\n", ident, key)
+	g.printf("var %sPrompt = %s\n\n", ident, literal(parsed))
+	return nil
+}
+
+// field is a property of an input struct.
+type field struct {
+	name, goName, goType, doc string
+	required                  bool
+	// kind is how Map converts the field: "struct", "structs" or "value".
+	kind string
+}
+
+// structType declares the struct name for the object schema, and the structs
+// of its nested objects, with a Map method.
+func (g *generator) structType(name, doc string, schema map[string]any) {
+	g.structs[name] = true
+	properties, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]any)
+
+	names := make([]string, 0, len(properties))
+	for property := range properties {
+		names = append(names, property)
+	}
+	sort.Strings(names)
+
+	var fields []field
+	for _, property := range names {
+		propSchema, _ := properties[property].(map[string]any)
+		f := field{
+			name:     property,
+			goName:   exportedIdent(property),
+			required: slices.Contains(required, any(property)),
+			kind:     "value",
+		}
+		f.doc, _ = propSchema["description"].(string)
+		f.goType, f.kind = g.goType(name+f.goName, propSchema)
+		if !f.required && f.kind != "structs" && !strings.HasPrefix(f.goType, "[]") && !strings.HasPrefix(f.goType, "map[") && f.goType != "any" {
+			f.goType = "*" + f.goType
+		}
+		fields = append(fields, f)
+	}
+
+	g.printf("// %s %s\n", name, doc)
+	g.printf("type %s struct {\n", name)
+	for _, f := range fields {
+		if f.doc != "" {
+			g.printf("// %s\n", strings.ReplaceAll(f.doc, "\n", "\n// "))
+		}
+		tag := f.name
+		if !f.required {
+			tag += ",omitempty"
+		}
+		g.printf("%s %s `json:%s`\n", f.goName, f.goType, strconv.Quote(tag))
+	}
+	g.printf("}\n\n")
+
+	g.printf("// Map returns the input as the map that prompts are rendered with.\n")
+	g.printf("func (in %s) Map() map[string]any {\n", name)
+	g.printf("m := make(map[string]any, %d)\n", len(fields))
+	for _, f := range fields {
+		value := "in." + f.goName
+		pointer := strings.HasPrefix(f.goType, "*")
+		switch f.kind {
+		case "struct":
+			value += ".Map()"
+		case "structs":
+			value = "inputMaps(" + value + ")"
+		default:
+			if pointer {
+				value = "*" + value
+			}
+		}
+		switch {
+		case pointer:
+			g.printf("if in.%s != nil {\nm[%q] = %s\n}\n", f.goName, f.name, value)
+		case !f.required && (f.kind == "structs" || strings.HasPrefix(f.goType, "[]") || strings.HasPrefix(f.goType, "map[") || f.goType == "any"):
+			g.printf("if in.%s != nil {\nm[%q] = %s\n}\n", f.goName, f.name, value)
+		default:
+			g.printf("m[%q] = %s\n", f.name, value)
+		}
+	}
+	g.printf("return m\n}\n\n")
+}
+
+// goType returns the Go type of a value with schema, declaring the struct
+// name for it if it is an object with properties, and how Map converts it.
+func (g *generator) goType(name string, schema map[string]any) (string, string) {
+	switch schemaType(schema) {
+	case "string":
+		return "string", "value"
+	case "number":
+		return "float64", "value"
+	case "integer":
+		return "int", "value"
+	case "boolean":
+		return "bool", "value"
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		itemType, kind := g.goType(name+"Item", items)
+		if kind == "struct" {
+			g.needsInputMaps = true
+			return "[]" + itemType, "structs"
+		}
+		return "[]" + itemType, "value"
+	case "object":
+		if _, ok := schema["properties"].(map[string]any); ok && !g.structs[name] {
+			desc, _ := schema["description"].(string)
+			if desc == "" {
+				desc = "is a nested input object."
+			}
+			g.structType(name, desc, schema)
+			return name, "struct"
+		}
+		return "map[string]any", "value"
+	}
+	return "any", "value"
+}
+
+// schemaType returns the JSON type of schema, ignoring "null" in type lists
+// and in the `anyOf` alternatives of optional Picoschema fields.
+func schemaType(schema map[string]any) string {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = append(types, t)
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	case nil:
+		alternatives, _ := schema["anyOf"].([]any)
+		for _, alternative := range alternatives {
+			if alternative, ok := alternative.(map[string]any); ok {
+				types = append(types, schemaType(alternative))
+			}
+		}
+	}
+	types = slices.DeleteFunc(types, func(t string) bool { return t == "null" || t == "" })
+	if len(types) == 1 {
+		return types[0]
+	}
+	return ""
+}
+
+// exportedIdent returns an exported Go identifier for s, such as
+// `CustomerSupportGreet` for `customer-support/greet`.
+func exportedIdent(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteByte('X')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "X"
+	}
+	return b.String()
+}
+
+// inputMapsFunc is the helper that maps slices of input structs.
+const inputMapsFunc = `// inputMaps returns the maps of items.
+func inputMaps[T interface{ Map() map[string]any }](items []T) []any {
+	if items == nil {
+		return nil
+	}
+	maps := make([]any, len(items))
+	for i, item := range items {
+		maps[i] = item.Map()
+	}
+	return maps
+}
+`
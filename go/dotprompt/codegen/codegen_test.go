@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+func TestGenerate(t *testing.T) {
+	store := storetest.NewDirStore(t, map[string]string{
+		"greet.prompt": `---
+model: test/model
+input:
+  schema:
+    name: string, who to greet
+    age?: integer
+    address?:
+      city: string
+    friends?(array):
+      name: string
+---
+Hello {{name}}! {{> sig}}`,
+		"support/greet.formal.prompt": "Good day {{name}}.",
+		"_sig.prompt":                 "Bye",
+	})
+	src, err := Generate(store, Options{Package: "myprompts"})
+	if err != nil {
+		t.Fatalf("Generate() returned error: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, 0); err != nil {
+		t.Fatalf("Generate() returned invalid Go: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"// Code generated by dotpromptgen. DO NOT EDIT.",
+		"package myprompts",
+		`"sig": "Bye",`,
+		"type GreetInput struct {",
+		"// who to greet\n\tName string `json:\"name\"`",
+		"Age     *int                    `json:\"age,omitempty\"`",
+		"Address *GreetInputAddress",
+		"Friends []GreetInputFriendsItem",
+		"type GreetInputAddress struct {",
+		`m["friends"] = inputMaps(in.Friends)`,
+		"var GreetPrompt = dotprompt.ParsedPrompt{",
+		`Model:   "test/model",`,
+		"var SupportGreetFormalPrompt = dotprompt.ParsedPrompt{",
+		`Variant: "formal",`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("Generate() output does not contain %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), "SupportGreetFormalInput") {
+		t.Errorf("Generate() declared an input struct for a prompt without an input schema")
+	}
+}
+
+func TestGenerateNameCollision(t *testing.T) {
+	store := storetest.NewDirStore(t, map[string]string{
+		"a-b.prompt": "x",
+		"a_b.prompt": "y",
+	})
+	if _, err := Generate(store, Options{}); err == nil || !strings.Contains(err.Error(), "same Go name AB") {
+		t.Errorf("Generate() error = %v, want a name collision error", err)
+	}
+}
+
+func TestExportedIdent(t *testing.T) {
+	tests := map[string]string{
+		"greet":                  "Greet",
+		"customer-support/greet": "CustomerSupportGreet",
+		"greet.formal":           "GreetFormal",
+		"2fa":                    "X2fa",
+		"":                       "X",
+	}
+	for in, want := range tests {
+		if got := exportedIdent(in); got != want {
+			t.Errorf("exportedIdent(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, "nil"},
+		{"a\"b", `"a\"b"`},
+		{map[string]any{"b": 1.5, "a": true}, "map[string]any{\n\"a\": true,\n\"b\": float64(1.5),\n}"},
+		{[]string{"x"}, `[]string{"x"}`},
+		{dotprompt.PromptRef{Name: "n"}, "dotprompt.PromptRef{\nName: \"n\",\n}"},
+		{dotprompt.ToolChoiceAuto, `dotprompt.ToolChoice("auto")`},
+	}
+	for _, tt := range tests {
+		if got := literal(tt.value); got != tt.want {
+			t.Errorf("literal(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
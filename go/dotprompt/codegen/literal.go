@@ -0,0 +1,157 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package codegen
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+// dotpromptPkgPath is the import path of the dotprompt package, whose types
+// literals refer to by the package name.
+var dotpromptPkgPath = reflect.TypeFor[dotprompt.PromptRef]().PkgPath()
+
+// literal returns a Go expression for v, which may hold structs, maps,
+// slices, pointers and scalars of the dotprompt package and of the builtin
+// types. Struct fields that are zero, unexported or excluded from JSON are
+// left out.
+func literal(v any) string {
+	var b strings.Builder
+	writeLiteral(&b, reflect.ValueOf(v), false)
+	return b.String()
+}
+
+// writeLiteral writes the literal of v to b. inInterface is set when v is
+// held by an interface, where untyped constants would lose their type.
+func writeLiteral(b *strings.Builder, v reflect.Value, inInterface bool) {
+	if !v.IsValid() {
+		b.WriteString("nil")
+		return
+	}
+	t := v.Type()
+	named := t.PkgPath() != "" && t.Kind() != reflect.Struct
+	switch t.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		elem := v.Elem()
+		if et := elem.Type(); et.Kind() == reflect.Pointer && et.Elem().PkgPath() != dotpromptPkgPath ||
+			et.Kind() == reflect.Struct && et.PkgPath() != dotpromptPkgPath {
+			// Values of other packages, such as JSON schemas, are written
+			// as their JSON form.
+			if plain, err := dotprompt.ToJSONValue(elem.Interface()); err == nil {
+				elem = reflect.ValueOf(plain)
+			}
+		}
+		writeLiteral(b, elem, true)
+	case reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		b.WriteString("&")
+		writeLiteral(b, v.Elem(), false)
+	case reflect.Struct:
+		fmt.Fprintf(b, "%s{\n", typeName(t))
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() || f.Tag.Get("json") == "-" || isEmpty(v.Field(i)) {
+				continue
+			}
+			fmt.Fprintf(b, "%s: ", f.Name)
+			writeLiteral(b, v.Field(i), false)
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		fmt.Fprintf(b, "%s{", typeName(t))
+		for _, key := range keys {
+			b.WriteString("\n")
+			writeLiteral(b, key, false)
+			b.WriteString(": ")
+			writeLiteral(b, v.MapIndex(key), t.Elem().Kind() == reflect.Interface)
+			b.WriteString(",")
+		}
+		if len(keys) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		fmt.Fprintf(b, "%s{", typeName(t))
+		for i := range v.Len() {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeLiteral(b, v.Index(i), t.Elem().Kind() == reflect.Interface)
+		}
+		b.WriteString("}")
+	case reflect.String:
+		writeConversion(b, t, named, strconv.Quote(v.String()))
+	case reflect.Bool:
+		writeConversion(b, t, named, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeConversion(b, t, named || (inInterface && t.Kind() != reflect.Int), strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		writeConversion(b, t, named || inInterface, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		writeConversion(b, t, named || inInterface, strconv.FormatFloat(v.Float(), 'g', -1, t.Bits()))
+	default:
+		panic(fmt.Sprintf("codegen: cannot generate a literal of type %s", t))
+	}
+}
+
+// writeConversion writes the constant s, converted to t if convert is set.
+func writeConversion(b *strings.Builder, t reflect.Type, convert bool, s string) {
+	if convert {
+		fmt.Fprintf(b, "%s(%s)", typeName(t), s)
+		return
+	}
+	b.WriteString(s)
+}
+
+// typeName returns the name of t in Go source.
+func typeName(t reflect.Type) string {
+	return strings.ReplaceAll(t.String(), "interface {}", "any")
+}
+
+// isEmpty reports whether v is zero or an empty map or slice.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// CompileResult is the outcome of compiling one prompt with CompileAll.
+type CompileResult struct {
+	Ref PromptRef
+	// Prompt is the compiled prompt, or nil if Err is set.
+	Prompt PromptFunction
+	Err    error
+}
+
+// CompileAll lists every prompt in store, then loads and compiles them using
+// up to concurrency workers, or GOMAXPROCS workers if concurrency is not
+// positive. Stores that are a BatchLoader load all the prompts in one batch.
+// The results are in listing order, and a prompt that fails to load or
+// compile has its error in its CompileResult.
+//
+// Each prompt is compiled by a clone of dp, so the compiled prompts do not
+// share state; the partial resolver and store may be called concurrently.
+// CompileAll returns an error if listing fails or ctx is done, in which case
+// the prompts that were not compiled have ctx's error.
+func (dp *Dotprompt) CompileAll(ctx context.Context, store PromptStore, concurrency int) ([]CompileResult, error) {
+	var refs []PromptRef
+	cursor := ""
+	for {
+		list, err := store.List(ListPromptsOptions{Cursor: cursor})
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, list.Items...)
+		if list.Cursor == "" || list.Cursor == cursor {
+			break
+		}
+		cursor = list.Cursor
+	}
+
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	// Load every prompt in one batch if the store can, falling back to
+	// loading each prompt in its worker so that load errors are reported per
+	// prompt.
+	var prompts []PromptData
+	if loader, ok := store.(BatchLoader); ok {
+		if loaded, err := loader.LoadMany(refs); err == nil {
+			prompts = loaded
+		}
+	}
+
+	results := make([]CompileResult, len(refs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(refs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				var prompt *PromptData
+				if prompts != nil {
+					prompt = &prompts[i]
+				}
+				results[i] = dp.compileStorePrompt(ctx, store, refs[i], prompt)
+			}
+		}()
+	}
+	for i := range refs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, ctx.Err()
+}
+
+// compileStorePrompt compiles a single prompt for CompileAll, loading it
+// first unless it was already loaded as prompt.
+func (dp *Dotprompt) compileStorePrompt(ctx context.Context, store PromptStore, ref PromptRef, prompt *PromptData) CompileResult {
+	result := CompileResult{Ref: ref}
+	if result.Err = ctx.Err(); result.Err != nil {
+		return result
+	}
+	if prompt == nil {
+		loaded, err := store.Load(ref.Name, LoadPromptOptions{Variant: ref.Variant})
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		prompt = &loaded
+	}
+	result.Ref = prompt.PromptRef
+	result.Prompt, result.Err = dp.Clone().Compile(prompt.Source, nil)
+	return result
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCompileAll(t *testing.T) {
+	files := map[string]string{
+		"_sig.prompt":     "-- {{author}}",
+		"broken.prompt":   "{{#if x}}never closed",
+		"greet.fr.prompt": "Bonjour {{name}} {{> sig}}",
+		"greet.prompt":    "Hello {{name}} {{> sig}}",
+	}
+	for i := range 20 {
+		files[fmt.Sprintf("p%02d.prompt", i)] = fmt.Sprintf("Prompt %d for {{name}}", i)
+	}
+	store := newTestDirStore(t, files)
+	dp := NewDotprompt(&DotpromptOptions{PartialStore: store})
+
+	results, err := dp.CompileAll(context.Background(), store, 4)
+	if err != nil {
+		t.Fatalf("CompileAll() returned error: %v", err)
+	}
+	if len(results) != 23 {
+		t.Fatalf("CompileAll() returned %d results, want 23", len(results))
+	}
+
+	byKey := make(map[string]CompileResult)
+	for _, r := range results {
+		byKey[bundleKey(r.Ref.Name, r.Ref.Variant)] = r
+	}
+	if byKey["broken"].Err == nil {
+		t.Error("broken prompt compiled without error")
+	}
+	data := &DataArgument{Input: map[string]any{"name": "Ada", "author": "Bob"}}
+	for key, want := range map[string]string{
+		"greet":    "Hello Ada -- Bob",
+		"greet.fr": "Bonjour Ada -- Bob",
+		"p07":      "Prompt 7 for Ada",
+	} {
+		r := byKey[key]
+		if r.Err != nil {
+			t.Fatalf("compiling %s returned error: %v", key, r.Err)
+		}
+		rendered, err := r.Prompt(data, nil)
+		if err != nil {
+			t.Fatalf("rendering %s returned error: %v", key, err)
+		}
+		if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != want {
+			t.Errorf("%s rendered %q, want %q", key, got, want)
+		}
+		if r.Ref.Version == "" {
+			t.Errorf("%s has no version", key)
+		}
+	}
+}
+
+func TestCompileAllCanceled(t *testing.T) {
+	store := newTestDirStore(t, map[string]string{"a.prompt": "A", "b.prompt": "B"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := NewDotprompt(nil).CompileAll(ctx, store, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CompileAll() error = %v, want context.Canceled", err)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s error = %v, want context.Canceled", r.Ref.Name, r.Err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// compiledPrompt is a prompt compiled by a Dotprompt instance, which
+// promptFunction turns into a PromptFunction of any instance compiling
+// prompts the same way.
+type compiledPrompt struct {
+	parsed             ParsedPrompt
+	source             string
+	additionalMetadata *PromptMetadata
+	template           EngineTemplate
+	partialSources     map[string]string
+	usage              *inputUsage
+	metadataTemplates  bool
+	trace              *RenderTrace
+
+	versionOnce sync.Once
+	version     string
+}
+
+// renderVersion returns the version keying the renders of c in a render
+// cache, computing it on first use. It returns "" if c names partials at
+// render time, as those are loaded when first rendered and are not covered by
+// the version.
+func (c *compiledPrompt) renderVersion() string {
+	c.versionOnce.Do(func() {
+		if hasDynamicPartials(c.parsed.Template, c.partialSources) {
+			return
+		}
+		c.version = renderVersion(c.source, c.additionalMetadata, c.partialSources)
+	})
+	return c.version
+}
+
+// hasDynamicPartials reports whether template, or any of partials, names a
+// partial at render time.
+func hasDynamicPartials(template string, partials map[string]string) bool {
+	if strings.Contains(template, dynamicPartialHelperName) {
+		return true
+	}
+	for _, source := range partials {
+		if strings.Contains(source, dynamicPartialHelperName) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileScopes numbers the compile scopes of Dotprompt instances. Instances
+// in the same scope compile prompts the same way, and so share the entries of
+// a compile cache.
+var compileScopes atomic.Uint64
+
+// newCompileScope moves dp to a scope of its own, for an option changing how
+// it compiles prompts, and so how they render.
+func (dp *Dotprompt) newCompileScope() {
+	dp.compileScope = compileScopes.Add(1)
+	dp.newRenderScope()
+}
+
+// compileCacheKey identifies an entry of a compileCache.
+type compileCacheKey struct {
+	scope  uint64
+	source string
+}
+
+// compileCache holds the prompts compiled from the most recently used
+// sources. It is safe for concurrent use.
+type compileCache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // of *compileCacheEntry, most recently used first
+	entries map[compileCacheKey]*list.Element
+}
+
+// compileCacheEntry is an element of compileCache.order.
+type compileCacheEntry struct {
+	key      compileCacheKey
+	compiled *compiledPrompt
+}
+
+// newCompileCache returns a cache holding at most size compiled prompts.
+func newCompileCache(size int) *compileCache {
+	return &compileCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[compileCacheKey]*list.Element),
+	}
+}
+
+// get returns the prompt compiled for key, if it is cached.
+func (c *compileCache) get(key compileCacheKey) (*compiledPrompt, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*compileCacheEntry).compiled, true
+}
+
+// put caches compiled for key, evicting the least recently used entry if the
+// cache is full.
+func (c *compileCache) put(key compileCacheKey, compiled *compiledPrompt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*compileCacheEntry).compiled = compiled
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&compileCacheEntry{key: key, compiled: compiled})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*compileCacheEntry).key)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PromptFormat is the form a prompt renders to, set by the `format`
+// frontmatter key.
+type PromptFormat string
+
+const (
+	// PromptFormatMessages renders a list of messages. It is the default.
+	PromptFormatMessages PromptFormat = "messages"
+	// PromptFormatCompletion renders a single block of text for completion
+	// models, with each message introduced by its role's prefix.
+	PromptFormatCompletion PromptFormat = "completion"
+)
+
+// CompletionFormat configures how prompts with `format: completion` are
+// rendered.
+type CompletionFormat struct {
+	// Prefixes are written before the messages of each role. Roles without
+	// a prefix use DefaultCompletionPrefixes, or the capitalized role name
+	// followed by ": " if it has none either.
+	Prefixes map[Role]string
+	// Separator is written between messages. It defaults to a blank line.
+	Separator string
+	// OmitCue leaves out the model prefix that otherwise ends the text, when
+	// the last message is not the model's, to cue the model to respond.
+	OmitCue bool
+}
+
+// DefaultCompletionPrefixes are the role prefixes of completion prompts.
+var DefaultCompletionPrefixes = map[Role]string{
+	RoleSystem: "System: ",
+	RoleUser:   "User: ",
+	RoleModel:  "Assistant: ",
+}
+
+// prefix returns the prefix written before messages of role.
+func (f *CompletionFormat) prefix(role Role) string {
+	if f != nil {
+		if p, ok := f.Prefixes[role]; ok {
+			return p
+		}
+	}
+	if p, ok := DefaultCompletionPrefixes[role]; ok {
+		return p
+	}
+	name := []rune(string(role))
+	if len(name) == 0 {
+		return ""
+	}
+	return string(unicode.ToUpper(name[0])) + string(name[1:]) + ": "
+}
+
+// separator returns the text written between messages.
+func (f *CompletionFormat) separator() string {
+	if f == nil || f.Separator == "" {
+		return "\n\n"
+	}
+	return f.Separator
+}
+
+// ToCompletion converts a rendered template string into the text of a
+// completion prompt. It splits the string into messages and places the
+// history as ToMessages does, then writes the text of each message, trimmed
+// and introduced by its role's prefix. Blank messages are dropped, section
+// markers are ignored, data is written as JSON and media, which cannot be
+// written as text, is an error.
+func ToCompletion(renderedString string, data *DataArgument, format *CompletionFormat) (string, error) {
+	return toCompletion(renderedString, data, format, nil)
+}
+
+// toCompletion implements ToCompletion, resolving role aliases as toMessages
+// does.
+func toCompletion(renderedString string, data *DataArgument, format *CompletionFormat, aliases map[string]Role) (string, error) {
+	messages, err := toMessages(renderedString, data, messageOptions{aliases: aliases})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	var last Role
+	for _, msg := range messages {
+		text, err := completionText(msg.Content)
+		if err != nil {
+			return "", err
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(format.separator())
+		}
+		b.WriteString(format.prefix(msg.Role))
+		b.WriteString(text)
+		last = msg.Role
+	}
+	if (format == nil || !format.OmitCue) && last != RoleModel {
+		if b.Len() > 0 {
+			b.WriteString(format.separator())
+		}
+		b.WriteString(strings.TrimRightFunc(format.prefix(RoleModel), unicode.IsSpace))
+	}
+	return b.String(), nil
+}
+
+// completionText returns the text of the parts of a message.
+func completionText(parts []Part) (string, error) {
+	var b strings.Builder
+	for _, part := range parts {
+		switch p := part.(type) {
+		case *TextPart:
+			b.WriteString(p.Text)
+		case *DataPart:
+			data, err := json.Marshal(p.Data)
+			if err != nil {
+				return "", err
+			}
+			b.Write(data)
+		case *MediaPart:
+			return "", fmt.Errorf("media is not supported in completion prompts")
+		}
+	}
+	return b.String(), nil
+}
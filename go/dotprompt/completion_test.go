@@ -0,0 +1,126 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToCompletion(t *testing.T) {
+	history := []Message{
+		{Role: RoleUser, Content: []Part{&TextPart{Text: "Hi"}}},
+		{Role: RoleModel, Content: []Part{&TextPart{Text: "Hello!"}}},
+	}
+	tests := []struct {
+		name     string
+		rendered string
+		data     *DataArgument
+		format   *CompletionFormat
+		want     string
+	}{
+		{
+			name:     "plain text",
+			rendered: "  Tell me a joke.\n",
+			want:     "User: Tell me a joke.\n\nAssistant:",
+		},
+		{
+			name:     "roles",
+			rendered: "<<<dotprompt:role:system>>>Be brief.\n<<<dotprompt:role:user>>>Tell me a joke.",
+			want:     "System: Be brief.\n\nUser: Tell me a joke.\n\nAssistant:",
+		},
+		{
+			name:     "history",
+			rendered: "<<<dotprompt:role:system>>>Be brief.<<<dotprompt:history>>><<<dotprompt:role:user>>>Again.",
+			data:     &DataArgument{Messages: history},
+			want:     "System: Be brief.\n\nUser: Hi\n\nAssistant: Hello!\n\nUser: Again.\n\nAssistant:",
+		},
+		{
+			name:     "ends with model",
+			rendered: "Q: 2+2?<<<dotprompt:role:model>>>A:",
+			want:     "User: Q: 2+2?\n\nAssistant: A:",
+		},
+		{
+			name:     "custom format",
+			rendered: "<<<dotprompt:role:system>>>Rules.<<<dotprompt:role:user>>>Go<<<dotprompt:section code>>>",
+			format: &CompletionFormat{
+				Prefixes:  map[Role]string{RoleUser: "Human: ", RoleSystem: ""},
+				Separator: "\n---\n",
+				OmitCue:   true,
+			},
+			want: "Rules.\n---\nHuman: Go",
+		},
+		{
+			name:     "data",
+			rendered: `Order <<<dotprompt:data {"id":1}>>>`,
+			want:     "User: Order {\"id\":1}\n\nAssistant:",
+		},
+		{
+			name:     "unknown role",
+			rendered: "<<<dotprompt:role:tool>>>42",
+			format:   &CompletionFormat{OmitCue: true},
+			want:     "Tool: 42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToCompletion(tt.rendered, tt.data, tt.format)
+			if err != nil {
+				t.Fatalf("ToCompletion() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ToCompletion() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := ToCompletion("Look <<<dotprompt:media:url https://example.com/a.png>>>", nil, nil); err == nil || !strings.Contains(err.Error(), "media") {
+		t.Errorf("ToCompletion() with media error = %v, want media error", err)
+	}
+}
+
+func TestRenderCompletion(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{
+		Completion: &CompletionFormat{Prefixes: map[Role]string{RoleModel: "Bot: "}},
+	})
+	source := "---\nformat: completion\n---\n{{role \"system\"}}You are {{persona}}.\n{{role \"user\"}}{{question}}"
+	rendered, err := dp.Render(source, &DataArgument{Input: map[string]any{"persona": "a pirate", "question": "Where is the treasure?"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	want := "System: You are a pirate.\n\nUser: Where is the treasure?\n\nBot:"
+	if rendered.Completion != want {
+		t.Errorf("Completion = %q, want %q", rendered.Completion, want)
+	}
+	wantMessages := []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: want}}}}
+	if diff := cmp.Diff(wantMessages, rendered.Messages); diff != "" {
+		t.Errorf("Messages mismatch (-want +got):\n%s", diff)
+	}
+	if rendered.Format != PromptFormatCompletion {
+		t.Errorf("Format = %q, want %q", rendered.Format, PromptFormatCompletion)
+	}
+
+	rendered, err = dp.Render("Hi {{name}}", &DataArgument{Input: map[string]any{"name": "Ada"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if rendered.Completion != "" || len(rendered.Messages) != 1 {
+		t.Errorf("Render() without format = %+v, want messages only", rendered)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ModelPricing is the price of a model, in currency units per million tokens.
+type ModelPricing struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+var (
+	modelPricingMu  sync.RWMutex
+	modelPricing    = make(map[string]ModelPricing)
+	modelTokenizers = make(map[string]Tokenizer)
+)
+
+// RegisterModelPricing sets the price of model, such as
+// "googleai/gemini-1.5-pro", in currency units per million input and output
+// tokens. Registering a model again replaces its price.
+func RegisterModelPricing(model string, inRate, outRate float64) error {
+	switch {
+	case model == "":
+		return fmt.Errorf("model name cannot be empty")
+	case inRate < 0 || outRate < 0:
+		return fmt.Errorf("pricing of model %q cannot be negative", model)
+	}
+	modelPricingMu.Lock()
+	defer modelPricingMu.Unlock()
+	modelPricing[model] = ModelPricing{InputPerMillion: inRate, OutputPerMillion: outRate}
+	return nil
+}
+
+// UnregisterModelPricing removes the price registered for model.
+func UnregisterModelPricing(model string) {
+	modelPricingMu.Lock()
+	defer modelPricingMu.Unlock()
+	delete(modelPricing, model)
+}
+
+// LookupModelPricing returns the price registered for model.
+func LookupModelPricing(model string) (ModelPricing, bool) {
+	modelPricingMu.RLock()
+	defer modelPricingMu.RUnlock()
+	pricing, ok := modelPricing[model]
+	return pricing, ok
+}
+
+// RegisterModelTokenizer sets the tokenizer EstimateCost counts the input
+// tokens of model with. Models without one use ApproxTokenizer. A nil
+// tokenizer removes the registration.
+func RegisterModelTokenizer(model string, tokenizer Tokenizer) {
+	modelPricingMu.Lock()
+	defer modelPricingMu.Unlock()
+	if tokenizer == nil {
+		delete(modelTokenizers, model)
+		return
+	}
+	modelTokenizers[model] = tokenizer
+}
+
+// modelTokenizer returns the tokenizer of model.
+func modelTokenizer(model string) Tokenizer {
+	modelPricingMu.RLock()
+	defer modelPricingMu.RUnlock()
+	if tokenizer, ok := modelTokenizers[model]; ok {
+		return tokenizer
+	}
+	return ApproxTokenizer{}
+}
+
+// CostEstimate is the estimated cost of sending a rendered prompt to its
+// model.
+type CostEstimate struct {
+	Model        string  `json:"model"`
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	InputCost    float64 `json:"inputCost"`
+	OutputCost   float64 `json:"outputCost"`
+	Total        float64 `json:"total"`
+}
+
+// EstimateCost estimates the cost of sending rendered to its model and
+// receiving expectedOutputTokens tokens in reply, using the price registered
+// with RegisterModelPricing. The input tokens are counted with
+// EstimateTokens and the model's registered tokenizer. It returns an error if
+// rendered has no model or the model has no registered price.
+func EstimateCost(rendered *RenderedPrompt, expectedOutputTokens int) (CostEstimate, error) {
+	if rendered == nil || rendered.Model == "" {
+		return CostEstimate{}, fmt.Errorf("rendered prompt has no model")
+	}
+	pricing, ok := LookupModelPricing(rendered.Model)
+	if !ok {
+		return CostEstimate{}, fmt.Errorf("no pricing registered for model %q", rendered.Model)
+	}
+	report, err := EstimateTokens(rendered, modelTokenizer(rendered.Model))
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	estimate := CostEstimate{
+		Model:        rendered.Model,
+		InputTokens:  report.Total,
+		OutputTokens: max(expectedOutputTokens, 0),
+	}
+	estimate.InputCost = float64(estimate.InputTokens) * pricing.InputPerMillion / 1e6
+	estimate.OutputCost = float64(estimate.OutputTokens) * pricing.OutputPerMillion / 1e6
+	estimate.Total = estimate.InputCost + estimate.OutputCost
+	return estimate, nil
+}
+
+// CostLimitError is returned by the middleware of NewCostLimitMiddleware when
+// a rendered prompt is estimated to cost more than the limit.
+type CostLimitError struct {
+	Max      float64
+	Estimate CostEstimate
+}
+
+func (e *CostLimitError) Error() string {
+	return fmt.Sprintf("dotprompt: estimated cost %g of model %q exceeds max %g", e.Estimate.Total, e.Estimate.Model, e.Max)
+}
+
+// NewCostLimitMiddleware returns a Middleware that rejects rendered prompts
+// whose EstimateCost, with expectedOutputTokens, exceeds limit. Prompts whose
+// cost cannot be estimated, e.g. because their model has no registered price,
+// are rejected too.
+func NewCostLimitMiddleware(limit float64, expectedOutputTokens int) Middleware {
+	return func(next RenderFunc) RenderFunc {
+		return func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+			rendered, err := next(data, options)
+			if err != nil {
+				return rendered, err
+			}
+			estimate, err := EstimateCost(&rendered, expectedOutputTokens)
+			if err != nil {
+				return RenderedPrompt{}, err
+			}
+			if estimate.Total > limit {
+				return RenderedPrompt{}, &CostLimitError{Max: limit, Estimate: estimate}
+			}
+			return rendered, nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	const model = "test/priced-model"
+	if err := RegisterModelPricing(model, 2, 10); err != nil {
+		t.Fatalf("RegisterModelPricing() returned error: %v", err)
+	}
+	defer UnregisterModelPricing(model)
+
+	rendered := &RenderedPrompt{
+		PromptMetadata: PromptMetadata{Model: model},
+		Messages:       []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: "0123456789abcdef"}}}},
+	}
+	estimate, err := EstimateCost(rendered, 1000)
+	if err != nil {
+		t.Fatalf("EstimateCost() returned error: %v", err)
+	}
+	// 16 characters are 4 tokens with ApproxTokenizer.
+	if estimate.InputTokens != 4 || estimate.OutputTokens != 1000 {
+		t.Errorf("EstimateCost() tokens = %d in, %d out, want 4 in, 1000 out", estimate.InputTokens, estimate.OutputTokens)
+	}
+	if want := 4*2e-6 + 1000*10e-6; math.Abs(estimate.Total-want) > 1e-12 {
+		t.Errorf("EstimateCost().Total = %g, want %g", estimate.Total, want)
+	}
+
+	RegisterModelTokenizer(model, TokenizerFunc(func(text string) (int, error) { return len(text), nil }))
+	defer RegisterModelTokenizer(model, nil)
+	if estimate, err = EstimateCost(rendered, 0); err != nil || estimate.InputTokens != 16 {
+		t.Errorf("EstimateCost() with registered tokenizer = %+v, %v, want 16 input tokens", estimate, err)
+	}
+
+	if _, err := EstimateCost(&RenderedPrompt{PromptMetadata: PromptMetadata{Model: "test/unpriced"}}, 0); err == nil || !strings.Contains(err.Error(), "no pricing") {
+		t.Errorf("EstimateCost() of unpriced model error = %v, want no pricing error", err)
+	}
+	if _, err := EstimateCost(&RenderedPrompt{}, 0); err == nil {
+		t.Error("EstimateCost() without model succeeded, want error")
+	}
+	if err := RegisterModelPricing(model, -1, 0); err == nil {
+		t.Error("RegisterModelPricing() with negative rate succeeded, want error")
+	}
+}
+
+func TestCostLimitMiddleware(t *testing.T) {
+	const model = "test/limited-model"
+	if err := RegisterModelPricing(model, 1e6, 0); err != nil {
+		t.Fatalf("RegisterModelPricing() returned error: %v", err)
+	}
+	defer UnregisterModelPricing(model)
+
+	// Each token costs 1, and 4 characters are a token.
+	dp := NewDotprompt(nil).Use(NewCostLimitMiddleware(3, 0))
+	source := "---\nmodel: " + model + "\n---\nHi {{name}}"
+	if _, err := dp.Render(source, &DataArgument{Input: map[string]any{"name": "Bo"}}, nil); err != nil {
+		t.Errorf("Render() under limit returned error: %v", err)
+	}
+	_, err := dp.Render(source, &DataArgument{Input: map[string]any{"name": "Bartholomew"}}, nil)
+	var limitErr *CostLimitError
+	if !errors.As(err, &limitErr) || limitErr.Estimate.InputTokens != 4 {
+		t.Errorf("Render() over limit error = %v, want CostLimitError for 4 tokens", err)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"slices"
+
+	"github.com/invopop/jsonschema"
+)
+
+// handlebarsHelpers are the helpers built into every Handlebars runtime,
+// which PromptDependencies does not list.
+var handlebarsHelpers = []string{"each", "if", "log", "lookup", "unless", "with"}
+
+// PromptDependencies are the assets a prompt needs to render: the helpers and
+// partials its template uses, directly or through partials, and the named
+// schemas its input and output schemas reference.
+type PromptDependencies struct {
+	// Helpers are the names of the helpers called, sorted. Helpers built into
+	// Handlebars, such as `if` and `each`, are not included.
+	Helpers []string `json:"helpers,omitempty"`
+	// Partials are the partials referenced, sorted by name and variant, with
+	// the versions the template pins them to. Dynamic partials, whose names
+	// are only known at render time, are not included.
+	Partials []PartialRef `json:"partials,omitempty"`
+	// Schemas are the names of the schemas referenced, sorted.
+	Schemas []string `json:"schemas,omitempty"`
+}
+
+// Dependencies returns the dependencies of the prompt source. Partials are
+// followed through the partials registered with dp.
+func (dp *Dotprompt) Dependencies(source string) (PromptDependencies, error) {
+	parsed, err := dp.Parse(source)
+	if err != nil {
+		return PromptDependencies{}, err
+	}
+	sources := make(map[PartialRef]string, len(dp.Partials))
+	for name, partial := range dp.Partials {
+		sources[PartialRef{Name: name}] = quotePinnedPartials(partial)
+	}
+	return dp.dependencies(parsed, sources)
+}
+
+// dependencies returns the dependencies of parsed, following partials through
+// sources.
+func (dp *Dotprompt) dependencies(parsed ParsedPrompt, sources map[PartialRef]string) (PromptDependencies, error) {
+	template := quotePinnedPartials(parsed.Template)
+	partials, err := partialDependencies(template, sources)
+	if err != nil {
+		return PromptDependencies{}, err
+	}
+
+	templates := []string{template}
+	for _, ref := range partials {
+		if source, ok := sources[PartialRef{Name: ref.Name, Variant: ref.Variant}]; ok {
+			templates = append(templates, source)
+		}
+	}
+	var helpers []string
+	for _, t := range templates {
+		calls, err := TemplateHelpers(t)
+		if err != nil {
+			return PromptDependencies{}, err
+		}
+		for _, call := range calls {
+			if !slices.Contains(handlebarsHelpers, call.Name) {
+				helpers = append(helpers, call.Name)
+			}
+		}
+	}
+
+	var schemas []string
+	resolver := func(name string) (*jsonschema.Schema, error) {
+		schemas = append(schemas, name)
+		return dp.WrappedSchemaResolver(name)
+	}
+	for _, schema := range []Schema{parsed.Input.Schema, parsed.Output.Schema} {
+		if schema == nil {
+			continue
+		}
+		if _, err := Picoschema(schema, &PicoschemaOptions{SchemaResolver: resolver}); err != nil {
+			return PromptDependencies{}, err
+		}
+	}
+
+	return PromptDependencies{
+		Helpers:  sortedUnique(helpers),
+		Partials: partials,
+		Schemas:  sortedUnique(schemas),
+	}, nil
+}
+
+// sortedUnique sorts s and removes duplicates.
+func sortedUnique(s []string) []string {
+	slices.Sort(s)
+	return slices.Compact(s)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDependencies(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{
+		Helpers: map[string]any{"shout": func(s string) string { return s }},
+		Partials: map[string]string{
+			"card":   `{{#if name}}{{shout name}}{{/if}}{{> footer@v2}}`,
+			"footer": "unused",
+		},
+	})
+	dp.DefineSchema("Address", map[string]any{"street": "string"})
+
+	source := `---
+input:
+  schema:
+    name: string
+    home: Address
+output:
+  schema: Address
+---
+{{role "system"}}{{#each items}}{{> card}}{{/each}}{{> header version="3f2a"}}{{json this}}`
+	got, err := dp.Dependencies(source)
+	if err != nil {
+		t.Fatalf("Dependencies() returned error: %v", err)
+	}
+	want := PromptDependencies{
+		Helpers: []string{"json", "role", "shout"},
+		Partials: []PartialRef{
+			{Name: "card"},
+			{Name: "footer", Variant: "v2"},
+			{Name: "header", Version: "3f2a"},
+		},
+		Schemas: []string{"Address"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Dependencies() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBundleDependencies(t *testing.T) {
+	dp := NewDotprompt(nil)
+	b, err := dp.LoadBundle(&PromptBundle{
+		Partials: []PartialData{
+			{PartialRef: PartialRef{Name: "greeting"}, Source: "{{json name}}"},
+		},
+		Prompts: []PromptData{
+			{PromptRef: PromptRef{Name: "hello"}, Source: "{{> greeting}}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadBundle() returned error: %v", err)
+	}
+	got, ok := b.Dependencies("hello", "")
+	if !ok {
+		t.Fatal("Dependencies() found no prompt")
+	}
+	want := PromptDependencies{Helpers: []string{"json"}, Partials: []PartialRef{{Name: "greeting"}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Dependencies() mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := b.Dependencies("missing", ""); ok {
+		t.Error("Dependencies() found a prompt missing from the bundle")
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FieldChange describes a changed value at a dotted path, such as
+// `config.temperature` or `output.schema.properties.name`. Old is nil for
+// added fields and New is nil for removed fields.
+type FieldChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// DiffOp is the kind of a line in a template diff.
+type DiffOp string
+
+// Line diff operations.
+const (
+	DiffEqual  DiffOp = " "
+	DiffInsert DiffOp = "+"
+	DiffDelete DiffOp = "-"
+)
+
+// LineDiff is a single line of a template diff.
+type LineDiff struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// PromptDiff summarizes the differences between two parsed prompts.
+type PromptDiff struct {
+	// Metadata lists changes to frontmatter fields other than schemas.
+	Metadata []FieldChange `json:"metadata,omitempty"`
+	// Schema lists changes within the input and output schemas.
+	Schema []FieldChange `json:"schema,omitempty"`
+	// Template is a line diff of the template bodies. It is empty when the
+	// templates are identical.
+	Template []LineDiff `json:"template,omitempty"`
+}
+
+// HasChanges reports whether the diff contains any change.
+func (d PromptDiff) HasChanges() bool {
+	return len(d.Metadata) > 0 || len(d.Schema) > 0 || len(d.Template) > 0
+}
+
+// DiffPrompts compares two parsed prompts field by field.
+func DiffPrompts(a, b ParsedPrompt) PromptDiff {
+	diff := PromptDiff{}
+
+	aMeta, bMeta := metadataForDiff(a.PromptMetadata), metadataForDiff(b.PromptMetadata)
+	diff.Metadata = diffValues("", aMeta, bMeta, nil)
+
+	for _, section := range []string{"input", "output"} {
+		aSchema, _ := ToJSONValue(schemaOf(a.PromptMetadata, section))
+		bSchema, _ := ToJSONValue(schemaOf(b.PromptMetadata, section))
+		diff.Schema = diffValues(section+".schema", aSchema, bSchema, diff.Schema)
+	}
+
+	if a.Template != b.Template {
+		diff.Template = DiffLines(a.Template, b.Template)
+	}
+	return diff
+}
+
+// metadataForDiff returns the JSON form of meta without schemas and the raw
+// frontmatter, which are covered separately.
+func metadataForDiff(meta PromptMetadata) any {
+	meta.Raw = nil
+	meta.Input.Schema = nil
+	meta.Output.Schema = nil
+	value, _ := ToJSONValue(meta)
+	return value
+}
+
+// schemaOf returns the input or output schema of meta.
+func schemaOf(meta PromptMetadata, section string) Schema {
+	if section == "input" {
+		return meta.Input.Schema
+	}
+	return meta.Output.Schema
+}
+
+// diffValues appends the changes between two decoded JSON values to changes.
+func diffValues(path string, a, b any, changes []FieldChange) []FieldChange {
+	aMap, aIsMap := a.(map[string]any)
+	bMap, bIsMap := b.(map[string]any)
+	if aIsMap && bIsMap {
+		keys := make(map[string]bool)
+		for k := range aMap {
+			keys[k] = true
+		}
+		for k := range bMap {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			changes = diffValues(joinPath(path, k), aMap[k], bMap[k], changes)
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return changes
+	}
+	return append(changes, FieldChange{Path: path, Old: a, New: b})
+}
+
+// joinPath appends key to a dotted path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// maxDiffEdits bounds the number of edits DiffLines searches for, which
+// bounds its memory to O(maxDiffEdits²) however long the inputs are.
+const maxDiffEdits = 1000
+
+// DiffLines returns a shortest line diff of a and b, computed with Myers'
+// algorithm after trimming their common leading and trailing lines. If the
+// lines in between differ by more than maxDiffEdits insertions and deletions,
+// they are reported as deleted and then inserted as a whole.
+func DiffLines(a, b string) []LineDiff {
+	aLines, bLines := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	prefix := 0
+	for prefix < len(aLines) && prefix < len(bLines) && aLines[prefix] == bLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(aLines)-prefix && suffix < len(bLines)-prefix &&
+		aLines[len(aLines)-1-suffix] == bLines[len(bLines)-1-suffix] {
+		suffix++
+	}
+
+	out := make([]LineDiff, 0, len(aLines)+len(bLines)-prefix-suffix)
+	for _, line := range aLines[:prefix] {
+		out = append(out, LineDiff{Op: DiffEqual, Text: line})
+	}
+	out = append(out, myersDiff(aLines[prefix:len(aLines)-suffix], bLines[prefix:len(bLines)-suffix])...)
+	for _, line := range aLines[len(aLines)-suffix:] {
+		out = append(out, LineDiff{Op: DiffEqual, Text: line})
+	}
+	return out
+}
+
+// myersDiff returns a shortest diff of a and b, preferring deletions before
+// insertions, or a deletion of all of a followed by an insertion of all of b
+// if it takes more than maxDiffEdits edits.
+func myersDiff(a, b []string) []LineDiff {
+	n, m := len(a), len(b)
+	// trace[d][k+d] is the furthest x reached on diagonal k = x-y with d
+	// edits.
+	var trace [][]int
+search:
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return replaceLines(a, b)
+		}
+		v := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			switch {
+			case d == 0:
+				x = 0
+			case k == -d || (k != d && trace[d-1][k-1+d-1] < trace[d-1][k+1+d-1]):
+				x = trace[d-1][k+1+d-1] // insertion
+			default:
+				x = trace[d-1][k-1+d-1] + 1 // deletion
+			}
+			for y := x - k; x < n && y < m && a[x] == b[y]; y++ {
+				x++
+			}
+			v[k+d] = x
+			if x >= n && x-k >= m {
+				trace = append(trace, v)
+				break search
+			}
+		}
+		trace = append(trace, v)
+	}
+
+	// Walk back from (n, m), collecting the diff in reverse.
+	var out []LineDiff
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		prev := trace[d-1]
+		inserted := k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1])
+		prevK := k - 1
+		if inserted {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		// The snake of equal lines ends at (x, y) and starts after the edit
+		// from (prevX, prevY).
+		startX := prevX + 1
+		if inserted {
+			startX = prevX
+		}
+		for x > startX {
+			x--
+			out = append(out, LineDiff{Op: DiffEqual, Text: a[x]})
+		}
+		if inserted {
+			out = append(out, LineDiff{Op: DiffInsert, Text: b[prevY]})
+		} else {
+			out = append(out, LineDiff{Op: DiffDelete, Text: a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 {
+		x--
+		out = append(out, LineDiff{Op: DiffEqual, Text: a[x]})
+	}
+	slices.Reverse(out)
+	return out
+}
+
+// replaceLines returns the diff deleting all of a and inserting all of b.
+func replaceLines(a, b []string) []LineDiff {
+	out := make([]LineDiff, 0, len(a)+len(b))
+	for _, line := range a {
+		out = append(out, LineDiff{Op: DiffDelete, Text: line})
+	}
+	for _, line := range b {
+		out = append(out, LineDiff{Op: DiffInsert, Text: line})
+	}
+	return out
+}
+
+// FormatLineDiff renders a line diff in unified-diff style, one line per entry
+// prefixed with its operation.
+func FormatLineDiff(lines []LineDiff) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(string(line.Op))
+		sb.WriteString(line.Text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// ChangeKind classifies a message or part change.
+type ChangeKind string
+
+// Change kinds.
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// PartChange describes a change to a single part of a message.
+type PartChange struct {
+	Index int        `json:"index"`
+	Kind  ChangeKind `json:"kind"`
+	Old   Part       `json:"old,omitempty"`
+	New   Part       `json:"new,omitempty"`
+}
+
+// MessageChange describes a change to a single message, aligned by position.
+type MessageChange struct {
+	Index   int          `json:"index"`
+	Kind    ChangeKind   `json:"kind"`
+	OldRole Role         `json:"oldRole,omitempty"`
+	NewRole Role         `json:"newRole,omitempty"`
+	Parts   []PartChange `json:"parts,omitempty"`
+}
+
+// RenderedDiff summarizes the differences between two rendered prompts.
+type RenderedDiff struct {
+	Metadata []FieldChange   `json:"metadata,omitempty"`
+	Messages []MessageChange `json:"messages,omitempty"`
+}
+
+// HasChanges reports whether the diff contains any change.
+func (d RenderedDiff) HasChanges() bool {
+	return len(d.Metadata) > 0 || len(d.Messages) > 0
+}
+
+// DiffRendered compares two rendered prompts at message and part granularity.
+// A nil prompt is treated as having no metadata and no messages.
+func DiffRendered(a, b *RenderedPrompt) RenderedDiff {
+	if a == nil {
+		a = &RenderedPrompt{}
+	}
+	if b == nil {
+		b = &RenderedPrompt{}
+	}
+
+	diff := RenderedDiff{}
+	aMeta, _ := ToJSONValue(a.PromptMetadata)
+	bMeta, _ := ToJSONValue(b.PromptMetadata)
+	diff.Metadata = diffValues("", aMeta, bMeta, nil)
+
+	for i := 0; i < max(len(a.Messages), len(b.Messages)); i++ {
+		switch {
+		case i >= len(a.Messages):
+			diff.Messages = append(diff.Messages, MessageChange{Index: i, Kind: ChangeAdded, NewRole: b.Messages[i].Role})
+		case i >= len(b.Messages):
+			diff.Messages = append(diff.Messages, MessageChange{Index: i, Kind: ChangeRemoved, OldRole: a.Messages[i].Role})
+		default:
+			if change, changed := diffMessage(i, a.Messages[i], b.Messages[i]); changed {
+				diff.Messages = append(diff.Messages, change)
+			}
+		}
+	}
+	return diff
+}
+
+// diffMessage compares two messages at the same position.
+func diffMessage(index int, a, b Message) (MessageChange, bool) {
+	change := MessageChange{Index: index, Kind: ChangeModified}
+	changed := false
+	if a.Role != b.Role {
+		change.OldRole, change.NewRole = a.Role, b.Role
+		changed = true
+	}
+	aMeta, _ := ToJSONValue(a.Metadata)
+	bMeta, _ := ToJSONValue(b.Metadata)
+	if !reflect.DeepEqual(aMeta, bMeta) {
+		changed = true
+	}
+
+	for i := 0; i < max(len(a.Content), len(b.Content)); i++ {
+		switch {
+		case i >= len(a.Content):
+			change.Parts = append(change.Parts, PartChange{Index: i, Kind: ChangeAdded, New: b.Content[i]})
+		case i >= len(b.Content):
+			change.Parts = append(change.Parts, PartChange{Index: i, Kind: ChangeRemoved, Old: a.Content[i]})
+		default:
+			aPart, _ := ToJSONValue(a.Content[i])
+			bPart, _ := ToJSONValue(b.Content[i])
+			if !reflect.DeepEqual(aPart, bPart) || reflect.TypeOf(a.Content[i]) != reflect.TypeOf(b.Content[i]) {
+				change.Parts = append(change.Parts, PartChange{Index: i, Kind: ChangeModified, Old: a.Content[i], New: b.Content[i]})
+			}
+		}
+	}
+	return change, changed || len(change.Parts) > 0
+}
+
+// String summarizes the diff in a short human-readable form suitable for CI
+// output.
+func (d RenderedDiff) String() string {
+	var sb strings.Builder
+	for _, c := range d.Metadata {
+		fmt.Fprintf(&sb, "metadata %s: %v -> %v\n", c.Path, c.Old, c.New)
+	}
+	for _, m := range d.Messages {
+		fmt.Fprintf(&sb, "message %d %s", m.Index, m.Kind)
+		if m.Kind == ChangeModified && m.OldRole != m.NewRole {
+			fmt.Fprintf(&sb, " (role %s -> %s)", m.OldRole, m.NewRole)
+		}
+		sb.WriteString("\n")
+		for _, p := range m.Parts {
+			fmt.Fprintf(&sb, "  part %d %s\n", p.Index, p.Kind)
+		}
+	}
+	return sb.String()
+}
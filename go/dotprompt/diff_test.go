@@ -0,0 +1,200 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mustParse(t *testing.T, source string) ParsedPrompt {
+	t.Helper()
+	parsed, err := ParseDocument(source)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	return parsed
+}
+
+func TestDiffPrompts(t *testing.T) {
+	a := mustParse(t, `---
+model: test/a
+config:
+  temperature: 0.2
+output:
+  schema:
+    name: string
+---
+Hello {{name}}.
+Goodbye.`)
+	b := mustParse(t, `---
+model: test/b
+config:
+  temperature: 0.2
+  topK: 3
+output:
+  schema:
+    name: string
+    age: integer
+---
+Hello {{name}}!
+Goodbye.`)
+
+	diff := DiffPrompts(a, b)
+
+	wantMetadata := []FieldChange{
+		{Path: "config.topK", Old: nil, New: 3.0},
+		{Path: "model", Old: "test/a", New: "test/b"},
+	}
+	if d := cmp.Diff(wantMetadata, diff.Metadata); d != "" {
+		t.Errorf("diff.Metadata mismatch (-want +got):\n%s", d)
+	}
+
+	wantSchema := []FieldChange{{Path: "output.schema.age", Old: nil, New: "integer"}}
+	if d := cmp.Diff(wantSchema, diff.Schema); d != "" {
+		t.Errorf("diff.Schema mismatch (-want +got):\n%s", d)
+	}
+
+	wantTemplate := "-Hello {{name}}.\n+Hello {{name}}!\n Goodbye.\n"
+	if got := FormatLineDiff(diff.Template); got != wantTemplate {
+		t.Errorf("FormatLineDiff() = %q, want %q", got, wantTemplate)
+	}
+
+	if DiffPrompts(a, a).HasChanges() {
+		t.Error("DiffPrompts(a, a).HasChanges() = true, want false")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	got := DiffLines("a\nb\nc", "a\nc\nd")
+	want := []LineDiff{
+		{Op: DiffEqual, Text: "a"},
+		{Op: DiffDelete, Text: "b"},
+		{Op: DiffEqual, Text: "c"},
+		{Op: DiffInsert, Text: "d"},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("DiffLines() mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestDiffLinesShortest(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomLines := func() []string {
+		lines := make([]string, rng.IntN(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(4)))
+		}
+		return lines
+	}
+	for range 500 {
+		aLines, bLines := randomLines(), randomLines()
+		a, b := strings.Join(aLines, "\n"), strings.Join(bLines, "\n")
+		got := DiffLines(a, b)
+
+		var gotA, gotB []string
+		equal := 0
+		for _, line := range got {
+			if line.Op != DiffInsert {
+				gotA = append(gotA, line.Text)
+			}
+			if line.Op != DiffDelete {
+				gotB = append(gotB, line.Text)
+			}
+			if line.Op == DiffEqual {
+				equal++
+			}
+		}
+		if strings.Join(gotA, "\n") != a || strings.Join(gotB, "\n") != b {
+			t.Fatalf("DiffLines(%q, %q) = %v, which does not turn a into b", a, b, got)
+		}
+		if want := lcsLength(strings.Split(a, "\n"), strings.Split(b, "\n")); equal != want {
+			t.Fatalf("DiffLines(%q, %q) kept %d lines, want %d", a, b, equal, want)
+		}
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	aLines := make([]string, 3*maxDiffEdits)
+	bLines := make([]string, 3*maxDiffEdits)
+	for i := range aLines {
+		aLines[i] = fmt.Sprintf("a%d", i)
+		bLines[i] = fmt.Sprintf("b%d", i)
+	}
+	aLines[0], bLines[0] = "same", "same"
+	got := DiffLines(strings.Join(aLines, "\n"), strings.Join(bLines, "\n"))
+
+	if len(got) != 2*len(aLines)-1 || got[0].Op != DiffEqual {
+		t.Fatalf("DiffLines() returned %d lines starting with %v, want %d starting with an equal line", len(got), got[0], 2*len(aLines)-1)
+	}
+	if got[1].Op != DiffDelete || got[len(aLines)].Op != DiffInsert {
+		t.Errorf("DiffLines() = %v..., want the differing lines deleted, then inserted", got[:3])
+	}
+}
+
+func TestDiffRendered(t *testing.T) {
+	a := &RenderedPrompt{
+		PromptMetadata: PromptMetadata{Model: "test/a"},
+		Messages: []Message{
+			{Role: RoleSystem, Content: []Part{&TextPart{Text: "Be nice."}}},
+			{Role: RoleUser, Content: []Part{&TextPart{Text: "Hi"}}},
+		},
+	}
+	b := &RenderedPrompt{
+		PromptMetadata: PromptMetadata{Model: "test/a"},
+		Messages: []Message{
+			{Role: RoleSystem, Content: []Part{&TextPart{Text: "Be nice."}}},
+			{Role: RoleModel, Content: []Part{&TextPart{Text: "Hi"}, &MediaPart{Media: Media{URL: "x.png"}}}},
+			{Role: RoleUser, Content: []Part{&TextPart{Text: "More"}}},
+		},
+	}
+
+	diff := DiffRendered(a, b)
+	if len(diff.Metadata) != 0 {
+		t.Errorf("diff.Metadata = %v, want none", diff.Metadata)
+	}
+	want := "message 1 modified (role user -> model)\n  part 1 added\nmessage 2 added\n"
+	if got := diff.String(); got != want {
+		t.Errorf("diff.String() = %q, want %q", got, want)
+	}
+
+	if DiffRendered(a, a).HasChanges() {
+		t.Error("DiffRendered(a, a).HasChanges() = true, want false")
+	}
+}
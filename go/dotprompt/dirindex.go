@@ -0,0 +1,189 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dirIndexRacyWindow is how long a directory must have been unmodified when
+// it was read for its listing to be cached. Within this window a later change
+// could leave the modification time unchanged on file systems with coarse
+// timestamps, so such directories are read again on every walk.
+const dirIndexRacyWindow = 2 * time.Second
+
+// dirIndexEntry is the cached listing of one directory of a DirStore.
+type dirIndexEntry struct {
+	modTime time.Time
+	// files are the names of the files in the directory with the store's
+	// extension that are not ignored.
+	files []string
+	// subdirs are the names of the subdirectories that are neither hidden nor
+	// ignored.
+	subdirs []string
+}
+
+// Invalidate drops the cached directory listings used by List and
+// ListPartials for the given paths, which may be absolute or relative to the
+// store root and name either files or directories. With no paths, the whole
+// cache is dropped.
+//
+// Listings are also revalidated against directory modification times on
+// every walk, so Invalidate is only needed when a change may not have updated
+// them, e.g. when a watcher reports a change on a file system with coarse
+// timestamps.
+func (ds *DirStore) Invalidate(paths ...string) {
+	ds.indexMu.Lock()
+	defer ds.indexMu.Unlock()
+	if len(paths) == 0 {
+		ds.index = nil
+		return
+	}
+	for _, p := range paths {
+		if filepath.IsAbs(p) {
+			rel, err := filepath.Rel(ds.Root, p)
+			if err != nil {
+				continue
+			}
+			p = rel
+		}
+		key := filepath.ToSlash(filepath.Clean(p))
+		delete(ds.index, key)
+		delete(ds.index, path.Dir(key))
+	}
+}
+
+// promptFiles returns the paths, relative to the root and slash-separated, of
+// all prompt and partial files in the store, skipping hidden directories and
+// ignored paths. Directories are read in parallel, and unchanged directories
+// are served from the index.
+func (ds *DirStore) promptFiles() ([]string, error) {
+	w := &dirWalker{ds: ds, sem: make(chan struct{}, runtime.GOMAXPROCS(0))}
+	w.walk(".")
+	w.wg.Wait()
+	if w.err != nil {
+		return nil, w.err
+	}
+	sort.Strings(w.files)
+	return w.files, nil
+}
+
+// dirWalker walks the directories of a DirStore concurrently, using at most
+// cap(sem) extra goroutines.
+type dirWalker struct {
+	ds  *DirStore
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	mu    sync.Mutex
+	files []string
+	err   error
+}
+
+// walk adds the prompt files under the directory dir to w.files.
+func (w *dirWalker) walk(dir string) {
+	entry, err := w.ds.readDirIndex(dir)
+	w.mu.Lock()
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		w.mu.Unlock()
+		return
+	}
+	for _, name := range entry.files {
+		w.files = append(w.files, path.Join(dir, name))
+	}
+	stop := w.err != nil
+	w.mu.Unlock()
+	if stop {
+		return
+	}
+
+	for _, name := range entry.subdirs {
+		sub := path.Join(dir, name)
+		select {
+		case w.sem <- struct{}{}:
+			w.wg.Add(1)
+			go func() {
+				defer w.wg.Done()
+				defer func() { <-w.sem }()
+				w.walk(sub)
+			}()
+		default:
+			// All workers are busy, so walk the subdirectory on this one.
+			w.walk(sub)
+		}
+	}
+}
+
+// readDirIndex returns the listing of the directory dir, relative to the
+// root, from the index if the directory has not been modified since it was
+// cached, or by reading it otherwise.
+func (ds *DirStore) readDirIndex(dir string) (*dirIndexEntry, error) {
+	full := filepath.Join(ds.Root, filepath.FromSlash(dir))
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, err
+	}
+
+	ds.indexMu.Lock()
+	cached := ds.index[dir]
+	ds.indexMu.Unlock()
+	if cached != nil && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	readAt := time.Now()
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, err
+	}
+	entry := &dirIndexEntry{modTime: info.ModTime()}
+	for _, e := range entries {
+		name := e.Name()
+		if ds.ignored(path.Join(dir, name)) {
+			continue
+		}
+		if e.IsDir() {
+			if !strings.HasPrefix(name, ".") {
+				entry.subdirs = append(entry.subdirs, name)
+			}
+		} else if strings.HasSuffix(name, ds.extension()) {
+			entry.files = append(entry.files, name)
+		}
+	}
+
+	ds.indexMu.Lock()
+	defer ds.indexMu.Unlock()
+	if readAt.Sub(entry.modTime) <= dirIndexRacyWindow {
+		delete(ds.index, dir)
+		return entry, nil
+	}
+	if ds.index == nil {
+		ds.index = make(map[string]*dirIndexEntry)
+	}
+	ds.index[dir] = entry
+	return entry, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writePromptFiles creates the given files under root and backdates every
+// directory so that its listing can be cached.
+func writePromptFiles(t testing.TB, root string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("hi"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backdateDirs(t, root, time.Now().Add(-time.Hour))
+}
+
+// backdateDirs sets the modification time of root and its subdirectories.
+func backdateDirs(t testing.TB, root string, modTime time.Time) {
+	t.Helper()
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, modTime, modTime)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listNames(t *testing.T, store *DirStore) []string {
+	t.Helper()
+	result, err := store.List(ListPromptsOptions{})
+	if err != nil {
+		t.Fatalf("List() returned error: %v", err)
+	}
+	var names []string
+	for _, ref := range result.Items {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+func TestDirStoreIndex(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewDirStore(root)
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	writePromptFiles(t, root, "a.prompt", "sub/b.prompt", ".hidden/c.prompt")
+
+	if diff := cmp.Diff([]string{"a", "sub/b"}, listNames(t, store)); diff != "" {
+		t.Fatalf("List() mismatch (-want +got):\n%s", diff)
+	}
+
+	// A file added without changing the directory's modification time is
+	// not seen until the index is invalidated.
+	modTime := time.Now().Add(-time.Hour)
+	writePromptFiles(t, root, "sub/d.prompt")
+	backdateDirs(t, root, modTime)
+	store.Invalidate()
+	listNames(t, store)
+	if err := os.WriteFile(filepath.Join(root, "sub", "e.prompt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	backdateDirs(t, root, modTime)
+	if diff := cmp.Diff([]string{"a", "sub/b", "sub/d"}, listNames(t, store)); diff != "" {
+		t.Errorf("List() with cached index mismatch (-want +got):\n%s", diff)
+	}
+	store.Invalidate(filepath.Join(root, "sub", "e.prompt"))
+	if diff := cmp.Diff([]string{"a", "sub/b", "sub/d", "sub/e"}, listNames(t, store)); diff != "" {
+		t.Errorf("List() after Invalidate() mismatch (-want +got):\n%s", diff)
+	}
+
+	// A changed modification time causes the directory to be read again.
+	if err := os.Remove(filepath.Join(root, "sub", "b.prompt")); err != nil {
+		t.Fatal(err)
+	}
+	backdateDirs(t, root, modTime.Add(time.Minute))
+	if diff := cmp.Diff([]string{"a", "sub/d", "sub/e"}, listNames(t, store)); diff != "" {
+		t.Errorf("List() after modification mismatch (-want +got):\n%s", diff)
+	}
+
+	// Save invalidates the directory it writes to.
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "sub/f"}, Source: "hi"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"a", "sub/d", "sub/e", "sub/f"}, listNames(t, store)); diff != "" {
+		t.Errorf("List() after Save() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDirStoreParallelWalk(t *testing.T) {
+	root := t.TempDir()
+	var files, want []string
+	for i := range 20 {
+		for j := range 5 {
+			name := fmt.Sprintf("d%02d/e%d/p%d", i, j%2, j)
+			files = append(files, name+".prompt", fmt.Sprintf("d%02d/e%d/_partial%d.prompt", i, j%2, j))
+			want = append(want, name)
+		}
+	}
+	sort.Strings(want)
+	writePromptFiles(t, root, files...)
+	store, err := NewDirStore(root)
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+
+	done := make(chan []string)
+	for range 4 {
+		go func() {
+			result, err := store.List(ListPromptsOptions{})
+			if err != nil {
+				t.Errorf("List() returned error: %v", err)
+			}
+			var names []string
+			for _, ref := range result.Items {
+				names = append(names, ref.Name)
+			}
+			done <- names
+		}()
+	}
+	for range 4 {
+		if diff := cmp.Diff(want, <-done); diff != "" {
+			t.Errorf("List() mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	partials, err := store.ListPartials(ListPartialsOptions{})
+	if err != nil {
+		t.Fatalf("ListPartials() returned error: %v", err)
+	}
+	if got := len(partials.Items); got != len(want) {
+		t.Errorf("len(ListPartials().Items) = %d, want %d", got, len(want))
+	}
+}
+
+func BenchmarkDirStoreList(b *testing.B) {
+	root := b.TempDir()
+	var files []string
+	for i := range 10000 {
+		files = append(files, fmt.Sprintf("group%03d/prompt%d.prompt", i/100, i))
+	}
+	writePromptFiles(b, root, files...)
+	store, err := NewDirStore(root)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("cold", func(b *testing.B) {
+		for b.Loop() {
+			store.Invalidate()
+			if _, err := store.List(ListPromptsOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := store.List(ListPromptsOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DirStoreOptions configures the file layout of a DirStore, so that stores
+// can be opened on existing prompt directories. The zero value is the default
+// layout: `.prompt` files, with partials named with a `_` prefix.
+type DirStoreOptions struct {
+	// Extension is the extension of prompt and partial files, e.g.
+	// ".prompt.md". Defaults to ".prompt".
+	Extension string
+	// PartialPrefix identifies partials. If it ends with "/", it is a
+	// directory relative to the root that holds the partials, named by their
+	// path within it, e.g. "partials/" stores partial "cards/item" as
+	// partials/cards/item.prompt, and the prompts in it are not listed.
+	// Otherwise it is a file name prefix, as in the default "_", which stores
+	// partial "cards/item" as cards/_item.prompt.
+	PartialPrefix string
+	// Ignore holds path.Match patterns of files and directories that the
+	// store does not list or load. Patterns containing "/" are matched
+	// against the slash-separated path relative to the root; others are
+	// matched against the base name, e.g. "drafts" ignores every directory
+	// named drafts and "*.wip.prompt" every such file.
+	Ignore []string
+}
+
+// NewDirStoreWithOptions creates a new DirStore rooted at the given
+// directory, using the file layout described by options.
+func NewDirStoreWithOptions(root string, options DirStoreOptions) (*DirStore, error) {
+	if options.Extension != "" && !strings.HasPrefix(options.Extension, ".") {
+		return nil, fmt.Errorf("invalid extension %q: must start with \".\"", options.Extension)
+	}
+	if dir, ok := strings.CutSuffix(options.PartialPrefix, "/"); ok {
+		if err := ValidatePromptName(dir); err != nil {
+			return nil, fmt.Errorf("invalid partial prefix %q: %w", options.PartialPrefix, err)
+		}
+	} else if strings.Contains(options.PartialPrefix, "/") {
+		return nil, fmt.Errorf("invalid partial prefix %q: must be a file name prefix or end with \"/\"", options.PartialPrefix)
+	}
+	for _, pattern := range options.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+	}
+
+	ds, err := NewDirStore(root)
+	if err != nil {
+		return nil, err
+	}
+	ds.layout = options
+	return ds, nil
+}
+
+// extension returns the extension of the store's files.
+func (ds *DirStore) extension() string {
+	if ds.layout.Extension == "" {
+		return promptExtension
+	}
+	return ds.layout.Extension
+}
+
+// partialPrefix returns the store's partial prefix and whether it names a
+// directory.
+func (ds *DirStore) partialPrefix() (string, bool) {
+	if ds.layout.PartialPrefix == "" {
+		return partialPrefix, false
+	}
+	return ds.layout.PartialPrefix, strings.HasSuffix(ds.layout.PartialPrefix, "/")
+}
+
+// partialPath returns the slash-separated path, relative to the root and
+// without the extension, of the file for the partial with the given name and
+// variant.
+func (ds *DirStore) partialPath(name, variant string) string {
+	prefix, isDir := ds.partialPrefix()
+	p := prefix + name
+	if !isDir {
+		p = path.Join(path.Dir(name), prefix+path.Base(name))
+	}
+	if variant != "" {
+		p += "." + variant
+	}
+	return p
+}
+
+// partialName reports whether the file at relPath, relative to the root and
+// without the extension, is a partial, and if so returns its name including
+// any variant suffix.
+func (ds *DirStore) partialName(relPath string) (string, bool) {
+	prefix, isDir := ds.partialPrefix()
+	if isDir {
+		return strings.CutPrefix(relPath, prefix)
+	}
+	base, ok := strings.CutPrefix(path.Base(relPath), prefix)
+	if !ok {
+		return "", false
+	}
+	if dir := path.Dir(relPath); dir != "." {
+		return dir + "/" + base, true
+	}
+	return base, true
+}
+
+// ignored reports whether the file or directory at relPath, relative to the
+// root and slash-separated, matches one of the store's ignore patterns.
+func (ds *DirStore) ignored(relPath string) bool {
+	for _, pattern := range ds.layout.Ignore {
+		target := relPath
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(relPath)
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readable reports whether the file at fullPath may be loaded: it must be
+// within the root, and neither it nor any of its parent directories may be
+// ignored.
+func (ds *DirStore) readable(fullPath string) bool {
+	rel, err := filepath.Rel(ds.Root, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	for p := filepath.ToSlash(rel); p != "."; p = path.Dir(p) {
+		if ds.ignored(p) {
+			return false
+		}
+	}
+	return true
+}
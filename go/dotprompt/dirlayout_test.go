@@ -0,0 +1,134 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDirStoreLayout(t *testing.T) {
+	root := t.TempDir()
+	writePromptFiles(t, root,
+		"greet.prompt.md",
+		"greet.formal.prompt.md",
+		"notes.md",
+		"partials/header.prompt.md",
+		"partials/cards/item.prompt.md",
+		"partials/cards/item.compact.prompt.md",
+		"_legacy.prompt.md",
+		"drafts/idea.prompt.md",
+		"sub/drafts/idea.prompt.md",
+		"sub/scratch.wip.prompt.md",
+		"sub/keep.prompt.md",
+	)
+	store, err := NewDirStoreWithOptions(root, DirStoreOptions{
+		Extension:     ".prompt.md",
+		PartialPrefix: "partials/",
+		Ignore:        []string{"drafts", "*.wip.prompt.md"},
+	})
+	if err != nil {
+		t.Fatalf("NewDirStoreWithOptions() returned error: %v", err)
+	}
+
+	prompts, err := store.List(ListPromptsOptions{})
+	if err != nil {
+		t.Fatalf("List() returned error: %v", err)
+	}
+	wantPrompts := []PromptRef{
+		{Name: "_legacy"},
+		{Name: "greet"},
+		{Name: "greet", Variant: "formal"},
+		{Name: "sub/keep"},
+	}
+	if diff := cmp.Diff(wantPrompts, prompts.Items); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+
+	partials, err := store.ListPartials(ListPartialsOptions{})
+	if err != nil {
+		t.Fatalf("ListPartials() returned error: %v", err)
+	}
+	wantPartials := []PartialRef{
+		{Name: "cards/item"},
+		{Name: "cards/item", Variant: "compact"},
+		{Name: "header"},
+	}
+	if diff := cmp.Diff(wantPartials, partials.Items); diff != "" {
+		t.Errorf("ListPartials() mismatch (-want +got):\n%s", diff)
+	}
+
+	partial, err := store.LoadPartial("cards/item", LoadPartialOptions{Variant: "compact"})
+	if err != nil {
+		t.Fatalf("LoadPartial() returned error: %v", err)
+	}
+	if partial.Variant != "compact" {
+		t.Errorf("LoadPartial().Variant = %q, want %q", partial.Variant, "compact")
+	}
+
+	for _, name := range []string{"drafts/idea", "sub/drafts/idea", "sub/scratch.wip"} {
+		if _, err := store.Load(name, LoadPromptOptions{}); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Load(%q) error = %v, want not found", name, err)
+		}
+	}
+
+	if err := store.SavePartial(PartialData{PartialRef: PartialRef{Name: "footer"}, Source: "bye"}); err != nil {
+		t.Fatalf("SavePartial() returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "partials", "footer.prompt.md")); err != nil {
+		t.Errorf("SavePartial() did not write partials/footer.prompt.md: %v", err)
+	}
+}
+
+func TestDirStoreLayoutFilePrefix(t *testing.T) {
+	root := t.TempDir()
+	writePromptFiles(t, root, "main.prompt", "sub/partial-nav.prompt", "sub/_page.prompt")
+	store, err := NewDirStoreWithOptions(root, DirStoreOptions{PartialPrefix: "partial-"})
+	if err != nil {
+		t.Fatalf("NewDirStoreWithOptions() returned error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"main", "sub/_page"}, listNames(t, store)); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := store.LoadPartial("sub/nav", LoadPartialOptions{}); err != nil {
+		t.Errorf("LoadPartial() returned error: %v", err)
+	}
+}
+
+func TestNewDirStoreWithOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options DirStoreOptions
+	}{
+		{"extension without dot", DirStoreOptions{Extension: "prompt"}},
+		{"nested file prefix", DirStoreOptions{PartialPrefix: "a/_"}},
+		{"escaping directory", DirStoreOptions{PartialPrefix: "../partials/"}},
+		{"bad pattern", DirStoreOptions{Ignore: []string{"[a-"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDirStoreWithOptions(t.TempDir(), tt.options); err == nil {
+				t.Errorf("NewDirStoreWithOptions(%+v) returned no error", tt.options)
+			}
+		})
+	}
+}
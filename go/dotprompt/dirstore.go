@@ -18,13 +18,15 @@ package dotprompt
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // DirStore is a file-system based prompt store.
@@ -32,10 +34,34 @@ import (
 // Prompts are stored as `.prompt` files.
 // Partials are stored as `_name.prompt` files.
 // Variants are stored as `name.variant.prompt` files.
+// NewDirStoreWithOptions opens stores with other layouts.
 type DirStore struct {
 	Root string
+	// KeepVersions makes Save also archive every version it writes in the
+	// hidden `.versions` directory, so that Load and LoadPartial can return a
+	// requested version after the prompt has changed. Archived versions are
+	// content-addressed: each is stored once per prompt or partial, under its
+	// name and version.
+	KeepVersions bool
+	// Hooks are notified of the changes made with Save, SavePartial and
+	// Delete.
+	Hooks StoreHooks
+
+	// layout is the file layout set by NewDirStoreWithOptions.
+	layout DirStoreOptions
+
+	// indexMu guards index, the cached directory listings keyed by
+	// slash-separated path relative to Root.
+	indexMu sync.Mutex
+	index   map[string]*dirIndexEntry
 }
 
+var (
+	_ WritablePromptStore = (*DirStore)(nil)
+	_ PartialWriter       = (*DirStore)(nil)
+	_ BatchLoader         = (*DirStore)(nil)
+)
+
 // NewDirStore creates a new DirStore rooted at the given directory.
 // The root path is resolved to an absolute path.
 func NewDirStore(root string) (*DirStore, error) {
@@ -61,10 +87,18 @@ func (ds *DirStore) verifyPathContainment(name string) (string, error) {
 	return cleanedPath, nil
 }
 
+// calculateVersion returns the version of content: its SHA-256 hash, prefixed
+// with the algorithm, e.g. `sha256:9f86d0...`.
 func calculateVersion(content string) string {
-	h := sha1.New()
-	h.Write([]byte(content))
-	return hex.EncodeToString(h.Sum(nil))
+	sum := sha256.Sum256([]byte(content))
+	return versionPrefixSHA256 + hex.EncodeToString(sum[:])
+}
+
+// legacyVersion returns the unprefixed SHA-1 version that earlier releases
+// computed for content. It is only used to verify legacy versions.
+func legacyVersion(content string) string {
+	sum := sha1.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
 }
 
 const (
@@ -73,39 +107,25 @@ const (
 )
 
 // List enumerates all prompts in the store that match the given options.
-// It traverses the directory structure recursively.
-// It ignores files starting with `_` (partials) and directories starting with `.` (hidden).
+// It traverses the directory structure recursively, reading directories in
+// parallel and reusing the listings of directories that have not changed
+// since the previous call (see Invalidate).
+// It ignores partials, ignored paths and directories starting with `.` (hidden).
+// Filtering by Tags reads each prompt that passes the other filters.
 func (ds *DirStore) List(options ListPromptsOptions) (ListPromptsResult[PromptRef], error) {
-	var prompts []PromptRef
-
-	err := filepath.WalkDir(ds.Root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			if strings.HasPrefix(d.Name(), ".") && d.Name() != "." {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if !strings.HasSuffix(d.Name(), promptExtension) {
-			return nil
-		}
-
-		relPath, err := filepath.Rel(ds.Root, path)
-		if err != nil {
-			return err
-		}
-
-		// Handle windows paths
-		relPath = filepath.ToSlash(relPath)
-
-		name := strings.TrimSuffix(relPath, promptExtension)
-		fileName := filepath.Base(name)
+	if err := options.validate(); err != nil {
+		return ListPromptsResult[PromptRef]{}, err
+	}
+	files, err := ds.promptFiles()
+	if err != nil {
+		return ListPromptsResult[PromptRef]{}, err
+	}
 
-		if strings.HasPrefix(fileName, partialPrefix) {
-			return nil
+	var prompts []PromptRef
+	for _, relPath := range files {
+		name := strings.TrimSuffix(relPath, ds.extension())
+		if _, ok := ds.partialName(name); ok {
+			continue
 		}
 
 		parts := strings.Split(name, ".")
@@ -117,21 +137,31 @@ func (ds *DirStore) List(options ListPromptsOptions) (ListPromptsResult[PromptRe
 		}
 
 		if options.Variant != "" && variant != options.Variant {
-			return nil
+			continue
+		}
+		if !options.matchesName(promptName) {
+			continue
+		}
+		if len(options.Tags) > 0 {
+			prompt, err := ds.Load(promptName, LoadPromptOptions{Variant: variant})
+			if err != nil {
+				return ListPromptsResult[PromptRef]{}, err
+			}
+			ok, err := options.matchesTags(prompt.Source)
+			if err != nil {
+				return ListPromptsResult[PromptRef]{}, fmt.Errorf("reading tags of %s: %w", relPath, err)
+			}
+			if !ok {
+				continue
+			}
 		}
 
 		prompts = append(prompts, PromptRef{
 			Name:    promptName,
 			Variant: variant,
 		})
-		return nil
-	})
-
-	if err != nil {
-		return ListPromptsResult[PromptRef]{}, err
 	}
 
-	// Simple pagination
 	sort.Slice(prompts, func(i, j int) bool {
 		if prompts[i].Name == prompts[j].Name {
 			return prompts[i].Variant < prompts[j].Variant
@@ -139,60 +169,49 @@ func (ds *DirStore) List(options ListPromptsOptions) (ListPromptsResult[PromptRe
 		return prompts[i].Name < prompts[j].Name
 	})
 
-	result := ListPromptsResult[PromptRef]{
-		Items: prompts,
+	page, cursor, err := paginate(prompts, options.Cursor, options.Limit)
+	if err != nil {
+		return ListPromptsResult[PromptRef]{}, err
 	}
-	// TODO(#500): meaningful cursor/limit implementation
-	// For now returns all as simple implementation
+	return ListPromptsResult[PromptRef]{Items: page, Cursor: cursor}, nil
+}
 
-	if options.Limit > 0 && len(result.Items) > options.Limit {
-		result.Cursor = "more" // Dummy cursor for now
-		result.Items = result.Items[:options.Limit]
+// paginate returns the page of items that starts at cursor, an offset
+// returned by a previous call, and holds at most limit items, or all the
+// remaining items if limit is not positive. It also returns the cursor of the
+// next page, which is empty on the last page.
+func paginate[T any](items []T, cursor string, limit int) ([]T, string, error) {
+	start := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, "", fmt.Errorf("invalid cursor: %q", cursor)
+		}
+		// Items may have been deleted since the cursor was returned.
+		start = min(n, len(items))
 	}
-
-	return result, nil
+	items = items[start:]
+	if limit > 0 && len(items) > limit {
+		return items[:limit], strconv.Itoa(start + limit), nil
+	}
+	return items, "", nil
 }
 
 // ListPartials enumerates all partials in the store that match the given options.
-// It searches for files starting with `_` and ending with `.prompt`.
+// It searches for files with the partial prefix, by default `_`, and the
+// store's extension.
 func (ds *DirStore) ListPartials(options ListPartialsOptions) (ListPartialsResult[PartialRef], error) {
-	var partials []PartialRef
-
-	err := filepath.WalkDir(ds.Root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			if strings.HasPrefix(d.Name(), ".") && d.Name() != "." {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if !strings.HasSuffix(d.Name(), promptExtension) {
-			return nil
-		}
-
-		relPath, err := filepath.Rel(ds.Root, path)
-		if err != nil {
-			return err
-		}
-		relPath = filepath.ToSlash(relPath)
-
-		name := strings.TrimSuffix(relPath, promptExtension)
-		fileName := filepath.Base(name)
-
-		if !strings.HasPrefix(fileName, partialPrefix) {
-			return nil
-		}
-
-		// Remove partial prefix from filename for the exposed name
-		dir := filepath.Dir(name)
-		baseName := strings.TrimPrefix(fileName, partialPrefix)
+	files, err := ds.promptFiles()
+	if err != nil {
+		return ListPartialsResult[PartialRef]{}, err
+	}
 
-		cleanName := baseName
-		if dir != "." {
-			cleanName = dir + "/" + baseName
+	var partials []PartialRef
+	for _, relPath := range files {
+		// Remove partial prefix from the path for the exposed name
+		cleanName, ok := ds.partialName(strings.TrimSuffix(relPath, ds.extension()))
+		if !ok {
+			continue
 		}
 
 		parts := strings.Split(cleanName, ".")
@@ -204,18 +223,13 @@ func (ds *DirStore) ListPartials(options ListPartialsOptions) (ListPartialsResul
 		}
 
 		if options.Variant != "" && variant != options.Variant {
-			return nil
+			continue
 		}
 
 		partials = append(partials, PartialRef{
 			Name:    partialName,
 			Variant: variant,
 		})
-		return nil
-	})
-
-	if err != nil {
-		return ListPartialsResult[PartialRef]{}, err
 	}
 
 	sort.Slice(partials, func(i, j int) bool {
@@ -225,21 +239,18 @@ func (ds *DirStore) ListPartials(options ListPartialsOptions) (ListPartialsResul
 		return partials[i].Name < partials[j].Name
 	})
 
-	result := ListPartialsResult[PartialRef]{
-		Items: partials,
-	}
-
-	if options.Limit > 0 && len(result.Items) > options.Limit {
-		result.Cursor = "more"
-		result.Items = result.Items[:options.Limit]
+	page, cursor, err := paginate(partials, options.Cursor, options.Limit)
+	if err != nil {
+		return ListPartialsResult[PartialRef]{}, err
 	}
-
-	return result, nil
-
+	return ListPartialsResult[PartialRef]{Items: page, Cursor: cursor}, nil
 }
 
 // Load retrieves a prompt by name from the store.
 // It checks for variant-specific files if a variant is requested.
+// If a version is requested, the loaded content must have that version;
+// otherwise it is looked up among the versions archived with KeepVersions,
+// and an error is returned if it is not there.
 // It verifies that the resolved file path is contained within the store's root directory.
 func (ds *DirStore) Load(name string, options LoadPromptOptions) (PromptData, error) {
 	filePath, err := ds.verifyPathContainment(name)
@@ -249,15 +260,18 @@ func (ds *DirStore) Load(name string, options LoadPromptOptions) (PromptData, er
 
 	possiblePaths := []string{}
 	if options.Variant != "" {
-		possiblePaths = append(possiblePaths, filePath+"."+options.Variant+promptExtension)
+		possiblePaths = append(possiblePaths, filePath+"."+options.Variant+ds.extension())
 	}
-	possiblePaths = append(possiblePaths, filePath+promptExtension)
+	possiblePaths = append(possiblePaths, filePath+ds.extension())
 
 	var content []byte
 	var loadedPath string
 	found := false
 
 	for _, p := range possiblePaths {
+		if !ds.readable(p) {
+			continue
+		}
 		b, err := os.ReadFile(p)
 		if err == nil {
 			content = b
@@ -269,18 +283,22 @@ func (ds *DirStore) Load(name string, options LoadPromptOptions) (PromptData, er
 		}
 	}
 
-	if !found {
-		return PromptData{}, fmt.Errorf("prompt not found: %s", name)
+	source, err := ds.resolveVersion("prompt", name, name, options.Version, string(content), found)
+	if err != nil {
+		return PromptData{}, err
 	}
 
 	// determine variant from loaded path
 	// path relative to root
 	relPath, _ := filepath.Rel(ds.Root, loadedPath)
 	relPath = filepath.ToSlash(relPath)
-	trimmed := strings.TrimSuffix(relPath, promptExtension)
+	trimmed := strings.TrimSuffix(relPath, ds.extension())
 
 	variant := ""
-	if trimmed != name {
+	if !found {
+		// An archived version of a prompt that no longer exists.
+		variant = options.Variant
+	} else if trimmed != name {
 		// name.variant -> variant
 		// check if trimmed ends with .variant
 		// careful if name itself has dot?
@@ -293,7 +311,6 @@ func (ds *DirStore) Load(name string, options LoadPromptOptions) (PromptData, er
 		}
 	}
 
-	source := string(content)
 	return PromptData{
 		PromptRef: PromptRef{
 			Name:    name,
@@ -305,34 +322,21 @@ func (ds *DirStore) Load(name string, options LoadPromptOptions) (PromptData, er
 }
 
 // LoadPartial retrieves a partial by name from the store.
-// It automatically handles the `_` prefix convention for partial filenames.
+// It automatically handles the partial prefix convention for partial filenames.
+// Requested versions are verified as in Load.
 // It verifies path containment security.
 func (ds *DirStore) LoadPartial(name string, options LoadPartialOptions) (PartialData, error) {
-	// Partials are stored as _name.prompt
-	dir := filepath.Dir(name)
-	base := filepath.Base(name)
-
-	// We reuse logic but correct the name passed to containment check?
-	// verifyPathContainment takes the name provided.
-	// We need to construct the actual file path we are looking for.
-
 	if err := ValidatePromptName(name); err != nil {
 		return PartialData{}, err
 	}
 
 	// Construct potential full paths with variant
 	// If name is "foo/bar" -> root/foo/_bar.prompt or root/foo/_bar.variant.prompt
-
-	searchBase := filepath.Join(ds.Root, dir, partialPrefix+base)
-	// verify containment of "foo/_bar" effectively
-
-	// Let's rely on standard path construction
-
 	possiblePaths := []string{}
 	if options.Variant != "" {
-		possiblePaths = append(possiblePaths, searchBase+"."+options.Variant+promptExtension)
+		possiblePaths = append(possiblePaths, ds.partialPath(name, options.Variant))
 	}
-	possiblePaths = append(possiblePaths, searchBase+promptExtension)
+	possiblePaths = append(possiblePaths, ds.partialPath(name, ""))
 
 	var content []byte
 	var loadedPath string
@@ -342,8 +346,8 @@ func (ds *DirStore) LoadPartial(name string, options LoadPartialOptions) (Partia
 		// Verify containment for safety for each path we try
 		// Though we constructed it from root + dir + safe-ish components.
 		// It's safer to check the resulting path is in root.
-		cleanP := filepath.Clean(p)
-		if !strings.HasPrefix(cleanP, ds.Root) {
+		cleanP := filepath.Join(ds.Root, filepath.FromSlash(p)+ds.extension())
+		if !ds.readable(cleanP) {
 			continue
 		}
 
@@ -358,27 +362,17 @@ func (ds *DirStore) LoadPartial(name string, options LoadPartialOptions) (Partia
 		}
 	}
 
-	if !found {
-		return PartialData{}, fmt.Errorf("partial not found: %s", name)
+	source, err := ds.resolveVersion("partial", name, ds.partialPath(name, ""), options.Version, string(content), found)
+	if err != nil {
+		return PartialData{}, err
 	}
 
-	source := string(content)
-
 	// Determine variant
-	relPath, _ := filepath.Rel(ds.Root, loadedPath)
-	relPath = filepath.ToSlash(relPath)
-
-	// relPath is like "foo/_bar.variant.prompt"
-	// name is "foo/bar"
-
+	// loadedPath is like "foo/_bar.variant" for name "foo/bar"
 	variant := ""
-	trimmed := strings.TrimSuffix(relPath, promptExtension)
-	// trimmed: foo/_bar.variant or foo/_bar
-
-	expectedBase := filepath.Join(dir, partialPrefix+base)
-	expectedBaseSlash := filepath.ToSlash(expectedBase)
-
-	if after, ok := strings.CutPrefix(trimmed, expectedBaseSlash+"."); ok {
+	if !found {
+		variant = options.Variant
+	} else if after, ok := strings.CutPrefix(loadedPath, ds.partialPath(name, "")+"."); ok {
 		variant = after
 	}
 
@@ -400,19 +394,46 @@ func (ds *DirStore) Save(prompt PromptData) error {
 	if prompt.Variant != "" {
 		pathName += "." + prompt.Variant
 	}
+	if err := ds.writeSource(pathName, prompt.Name, prompt.Source); err != nil {
+		return err
+	}
+	ds.Hooks.saved(prompt)
+	return nil
+}
+
+// SavePartial persists a partial to the store, by default as a `_name.prompt`
+// file.
+func (ds *DirStore) SavePartial(partial PartialData) error {
+	if err := ValidatePromptName(partial.Name); err != nil {
+		return err
+	}
+	if err := ds.writeSource(ds.partialPath(partial.Name, partial.Variant), ds.partialPath(partial.Name, ""), partial.Source); err != nil {
+		return err
+	}
+	ds.Hooks.savedPartial(partial)
+	return nil
+}
 
+// writeSource writes source to the file for pathName, a slash-separated path
+// relative to the root without the extension, and archives it under archive,
+// the path without the variant.
+func (ds *DirStore) writeSource(pathName, archive, source string) error {
 	filePath, err := ds.verifyPathContainment(pathName)
 	if err != nil {
 		return err
 	}
 
-	fullPath := filePath + promptExtension
+	fullPath := filePath + ds.extension()
 
 	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
 		return err
 	}
+	defer ds.Invalidate(fullPath)
 
-	return os.WriteFile(fullPath, []byte(prompt.Source), 0644)
+	if err := ds.archiveVersion(archive, source); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, []byte(source), 0644)
 }
 
 // Delete removes a prompt file from the store.
@@ -427,6 +448,17 @@ func (ds *DirStore) Delete(name string, options PromptStoreDeleteOptions) error
 		return err
 	}
 
-	fullPath := filePath + promptExtension
-	return os.Remove(fullPath)
+	fullPath := filePath + ds.extension()
+	defer ds.Invalidate(fullPath)
+	version := ""
+	if ds.Hooks.OnDelete != nil {
+		if content, err := os.ReadFile(fullPath); err == nil {
+			version = calculateVersion(string(content))
+		}
+	}
+	if err := os.Remove(fullPath); err != nil {
+		return err
+	}
+	ds.Hooks.deleted(name, options.Variant, version)
+	return nil
 }
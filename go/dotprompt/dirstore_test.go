@@ -23,6 +23,28 @@ import (
 	"testing"
 )
 
+// newTestDirStore writes files, keyed by slash-separated path, to a temporary
+// directory and returns a DirStore for it. Tests outside this package use
+// storetest.NewDirStore, which cannot be imported here.
+func newTestDirStore(t *testing.T, files map[string]string) *DirStore {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("os.MkdirAll() returned error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("os.WriteFile() returned error: %v", err)
+		}
+	}
+	store, err := NewDirStore(dir)
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	return store
+}
+
 func TestDirStore(t *testing.T) {
 	tmpDir := t.TempDir()
 	store, err := NewDirStore(tmpDir)
@@ -244,4 +266,28 @@ func TestDirStore(t *testing.T) {
 			t.Error("store.Load() expected error, got nil")
 		}
 	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		all, err := store.List(ListPromptsOptions{})
+		if err != nil {
+			t.Fatalf("store.List() returned error: %v", err)
+		}
+		page, err := store.List(ListPromptsOptions{Limit: 2})
+		if err != nil {
+			t.Fatalf("store.List() returned error: %v", err)
+		}
+		if len(page.Items) != 2 || page.Cursor == "" {
+			t.Fatalf("store.List(Limit: 2) = %d items with cursor %q, want 2 items and a cursor", len(page.Items), page.Cursor)
+		}
+		rest, err := store.List(ListPromptsOptions{Cursor: page.Cursor})
+		if err != nil {
+			t.Fatalf("store.List() returned error: %v", err)
+		}
+		if len(rest.Items) != len(all.Items)-2 || rest.Cursor != "" {
+			t.Errorf("store.List(Cursor: %q) = %d items with cursor %q, want the remaining %d", page.Cursor, len(rest.Items), rest.Cursor, len(all.Items)-2)
+		}
+		if _, err := store.List(ListPromptsOptions{Cursor: "bogus"}); err == nil {
+			t.Errorf("store.List() with an invalid cursor returned no error")
+		}
+	})
 }
@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// versionsDir is the hidden directory of a DirStore that holds the archived
+// versions of its prompts and partials, by name and version.
+const versionsDir = ".versions"
+
+// versionPrefixSHA256 prefixes versions computed with SHA-256.
+const versionPrefixSHA256 = "sha256:"
+
+// splitVersion returns the hash algorithm and hex digest of a version. Versions
+// are either `sha256:<hex>` or, from earlier releases, unprefixed SHA-1
+// digests. It returns false for anything else, so a valid digest is always
+// safe to use as a file name.
+func splitVersion(version string) (algorithm, digest string, ok bool) {
+	algorithm, digest = "sha1", version
+	if after, found := strings.CutPrefix(version, versionPrefixSHA256); found {
+		algorithm, digest = "sha256", after
+	}
+	want := map[string]int{"sha1": 40, "sha256": 64}[algorithm]
+	if len(digest) != want {
+		return "", "", false
+	}
+	for _, c := range digest {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", "", false
+		}
+	}
+	return algorithm, digest, true
+}
+
+// versionMatches reports whether content has the given version, which may be
+// a legacy SHA-1 version.
+func versionMatches(content, version string) bool {
+	algorithm, _, ok := splitVersion(version)
+	switch {
+	case !ok:
+		return false
+	case algorithm == "sha1":
+		return legacyVersion(content) == version
+	default:
+		return calculateVersion(content) == version
+	}
+}
+
+// versionPath returns the file that archives the given valid SHA-256 version
+// of the prompt or partial stored at archive, a slash-separated path relative
+// to the root without the variant and extension. Versions are archived under
+// `.versions/<archive>/sha256/`, so a version of one prompt is never loaded
+// as another.
+func (ds *DirStore) versionPath(archive, version string) string {
+	algorithm, digest, _ := splitVersion(version)
+	return filepath.Join(ds.Root, versionsDir, filepath.FromSlash(archive), algorithm, digest+promptExtension)
+}
+
+// archiveVersion stores source under archive and its version if KeepVersions
+// is set. The archive is content-addressed, so a version is only written once.
+func (ds *DirStore) archiveVersion(archive, source string) error {
+	if !ds.KeepVersions {
+		return nil
+	}
+	path := ds.versionPath(archive, calculateVersion(source))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it, so that a version is never
+	// seen with partial content.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".version-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(source)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// loadVersion returns the source archived under archive with the given
+// version. It returns false if the version is not archived. Only SHA-256
+// versions are archived; legacy SHA-1 versions can only match the current
+// source.
+func (ds *DirStore) loadVersion(archive, version string) (string, bool, error) {
+	if algorithm, _, ok := splitVersion(version); !ok || algorithm != "sha256" {
+		return "", false, nil
+	}
+	b, err := os.ReadFile(ds.versionPath(archive, version))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	source := string(b)
+	if !versionMatches(source, version) {
+		return "", false, fmt.Errorf("archived version %q is corrupt", version)
+	}
+	return source, true, nil
+}
+
+// resolveVersion returns the source of the requested version of a prompt or
+// partial, given its current source if it exists. The current source is
+// returned if it has the requested version or no version is requested;
+// otherwise the version is looked up among those archived under archive, as
+// for versionPath. Legacy SHA-1 versions are accepted for the current source.
+func (ds *DirStore) resolveVersion(kind, name, archive, requested, current string, found bool) (string, error) {
+	if requested == "" {
+		if !found {
+			return "", fmt.Errorf("%s %w: %s", kind, ErrNotFound, name)
+		}
+		return current, nil
+	}
+	if found && versionMatches(current, requested) {
+		return current, nil
+	}
+	source, ok, err := ds.loadVersion(archive, requested)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w", kind, name, err)
+	}
+	if ok {
+		return source, nil
+	}
+	if !found {
+		return "", fmt.Errorf("%s %w: %s", kind, ErrNotFound, name)
+	}
+	return "", fmt.Errorf("%s %s: version %q %w, store has %q", kind, name, requested, ErrNotFound, calculateVersion(current))
+}
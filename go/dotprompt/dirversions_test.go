@@ -0,0 +1,220 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirStoreLoadVersion(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "greet"}, Source: "v1"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	v1 := calculateVersion("v1")
+
+	got, err := store.Load("greet", LoadPromptOptions{Version: v1})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if got.Source != "v1" || got.Version != v1 {
+		t.Errorf("Load() = %q at %q, want %q at %q", got.Source, got.Version, "v1", v1)
+	}
+
+	_, err = store.Load("greet", LoadPromptOptions{Version: calculateVersion("other")})
+	if err == nil || !strings.Contains(err.Error(), "not found, store has") {
+		t.Errorf("Load() with a mismatched version returned error %v, want a version mismatch", err)
+	}
+
+	// Without KeepVersions, older versions are not archived.
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "greet"}, Source: "v2"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if _, err := store.Load("greet", LoadPromptOptions{Version: v1}); err == nil {
+		t.Errorf("Load() of an overwritten version succeeded without KeepVersions")
+	}
+}
+
+func TestDirStoreKeepVersions(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewDirStore(root)
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	store.KeepVersions = true
+
+	for _, source := range []string{"v1", "v2"} {
+		if err := store.Save(PromptData{PromptRef: PromptRef{Name: "greet", Variant: "formal"}, Source: source}); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "_header"}, Source: "h1"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "_header"}, Source: "h2"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	v1 := calculateVersion("v1")
+	got, err := store.Load("greet", LoadPromptOptions{Variant: "formal", Version: v1})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if got.Source != "v1" || got.Version != v1 || got.Variant != "formal" {
+		t.Errorf("Load() = %+v, want archived v1", got)
+	}
+
+	partial, err := store.LoadPartial("header", LoadPartialOptions{Version: calculateVersion("h1")})
+	if err != nil {
+		t.Fatalf("LoadPartial() returned error: %v", err)
+	}
+	if partial.Source != "h1" {
+		t.Errorf("LoadPartial().Source = %q, want %q", partial.Source, "h1")
+	}
+
+	// Archived versions outlive the prompt, and are hidden from List.
+	if err := store.Delete("greet", PromptStoreDeleteOptions{Variant: "formal"}); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if _, err := store.Load("greet", LoadPromptOptions{Variant: "formal", Version: v1}); err != nil {
+		t.Errorf("Load() of a deleted prompt's version returned error: %v", err)
+	}
+	if _, err := store.Load("greet", LoadPromptOptions{Variant: "formal"}); err == nil {
+		t.Errorf("Load() of a deleted prompt without a version succeeded")
+	}
+	list, err := store.List(ListPromptsOptions{})
+	if err != nil {
+		t.Fatalf("List() returned error: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("List() = %v, want no prompts", list.Items)
+	}
+
+	// Corrupt archives are detected.
+	if err := os.WriteFile(store.versionPath("greet", v1), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("greet", LoadPromptOptions{Version: v1}); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("Load() of a tampered version returned error %v, want corruption error", err)
+	}
+
+	// Versions that are not hashes are never used as paths.
+	if _, err := store.Load("greet", LoadPromptOptions{Version: "../_header"}); err == nil {
+		t.Errorf("Load() with a path as version succeeded")
+	}
+}
+
+func TestDirStoreVersionsScopedByName(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	store.KeepVersions = true
+	for _, p := range []PromptData{
+		{PromptRef: PromptRef{Name: "a"}, Source: "a1"},
+		{PromptRef: PromptRef{Name: "b"}, Source: "b1"},
+		{PromptRef: PromptRef{Name: "b"}, Source: "b2"},
+	} {
+		if err := store.Save(p); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	if err := store.SavePartial(PartialData{PartialRef: PartialRef{Name: "a"}, Source: "p1"}); err != nil {
+		t.Fatalf("SavePartial() returned error: %v", err)
+	}
+
+	if got, err := store.Load("b", LoadPromptOptions{Version: calculateVersion("b1")}); err != nil || got.Source != "b1" {
+		t.Errorf("Load(b) of an archived version = %q, %v, want %q", got.Source, err, "b1")
+	}
+	if got, err := store.Load("a", LoadPromptOptions{Version: calculateVersion("b1")}); err == nil {
+		t.Errorf("Load(a) with a version of b = %q, want an error", got.Source)
+	}
+	if got, err := store.Load("a", LoadPromptOptions{Version: calculateVersion("p1")}); err == nil {
+		t.Errorf("Load(a) with a version of partial a = %q, want an error", got.Source)
+	}
+	if got, err := store.LoadPartial("a", LoadPartialOptions{Version: calculateVersion("a1")}); err == nil {
+		t.Errorf("LoadPartial(a) with a version of prompt a = %q, want an error", got.Source)
+	}
+}
+
+func TestDirStoreLegacyVersions(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewDirStore(root)
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "greet"}, Source: "v2"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	// Loaded prompts report SHA-256 versions.
+	got, err := store.Load("greet", LoadPromptOptions{})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if _, _, ok := splitVersion(got.Version); !ok || !strings.HasPrefix(got.Version, "sha256:") {
+		t.Errorf("Version = %q, want a sha256: prefixed digest", got.Version)
+	}
+
+	// Legacy SHA-1 versions of the current content still match.
+	if _, err := store.Load("greet", LoadPromptOptions{Version: legacyVersion("v2")}); err != nil {
+		t.Errorf("Load() with a legacy version returned error: %v", err)
+	}
+
+	// Legacy versions are not looked up in the archive.
+	v1 := legacyVersion("v1")
+	if err := os.MkdirAll(filepath.Join(root, versionsDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, versionsDir, v1+promptExtension), []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("greet", LoadPromptOptions{Version: v1}); err == nil || !strings.Contains(err.Error(), "not found, store has") {
+		t.Errorf("Load() of a legacy version of earlier content returned error %v, want a version mismatch", err)
+	}
+}
+
+func TestSplitVersion(t *testing.T) {
+	sha256Digest := strings.Repeat("ab", 32)
+	sha1Digest := strings.Repeat("cd", 20)
+	tests := []struct {
+		version       string
+		wantAlgorithm string
+		wantOK        bool
+	}{
+		{version: "sha256:" + sha256Digest, wantAlgorithm: "sha256", wantOK: true},
+		{version: sha1Digest, wantAlgorithm: "sha1", wantOK: true},
+		{version: "sha256:" + sha1Digest},
+		{version: sha256Digest},
+		{version: "sha256:" + strings.Repeat("AB", 32)},
+		{version: "md5:" + sha1Digest},
+		{version: "../" + sha1Digest[3:]},
+		{version: ""},
+	}
+	for _, tt := range tests {
+		algorithm, _, ok := splitVersion(tt.version)
+		if algorithm != tt.wantAlgorithm || ok != tt.wantOK {
+			t.Errorf("splitVersion(%q) = %q, %v, want %q, %v", tt.version, algorithm, ok, tt.wantAlgorithm, tt.wantOK)
+		}
+	}
+}
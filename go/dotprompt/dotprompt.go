@@ -17,21 +17,27 @@
 package dotprompt
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"reflect"
-	"regexp"
-	"strings"
+	"slices"
+	"time"
 
 	"maps"
 
 	"github.com/invopop/jsonschema"
-	"github.com/mbleigh/raymond"
 )
 
 // PartialResolver is a function to resolve partial names to their content.
 type PartialResolver func(partialName string) (string, error)
 
+// DocumentParser parses the source of a .prompt file. ParseDocument is the
+// default implementation.
+type DocumentParser func(source string) (ParsedPrompt, error)
+
 // DotpromptOptions defines the options for the Dotprompt instance.
 type DotpromptOptions struct {
 	DefaultModel    string
@@ -43,6 +49,91 @@ type DotpromptOptions struct {
 	Schemas         map[string]*jsonschema.Schema
 	SchemaResolver  SchemaResolver
 	PartialResolver PartialResolver
+	// PartialStore loads partials that are not in Partials, honoring the
+	// variant and version a template pins them to with `{{> name@variant}}`
+	// or `{{> name variant="..." version="..."}}`. It takes precedence over
+	// PartialResolver, which is passed the partial name as written. Partials
+	// named at render time, as in `{{> (lookup . "layout")}}`, are loaded
+	// through either when first rendered.
+	PartialStore PromptStore
+	Limits       RenderLimits
+	// Parser replaces ParseDocument for splitting and parsing prompt sources.
+	Parser DocumentParser
+	// Engine parses and executes templates. It defaults to RaymondEngine.
+	Engine TemplateEngine
+	// MediaRoot is a directory against which the `media` helper resolves
+	// relative URLs; see NewMediaHelper.
+	MediaRoot string
+	// MediaFS is used instead of MediaRoot to resolve relative media URLs.
+	MediaFS fs.FS
+	// IncludeFS holds the files of the `include` helper; see
+	// NewIncludeHelper. The helper is not registered while it is nil, and
+	// renders are not cached while it is set, since included files can change
+	// without the prompt changing.
+	IncludeFS fs.FS
+	// MaxIncludeBytes is the size of the largest file the `include` helper
+	// reads. It defaults to DefaultMaxIncludeBytes.
+	MaxIncludeBytes int64
+	// KeepRawOutput sets RenderedPrompt.RawOutput to the rendered template
+	// text, which helps when debugging marker placement.
+	KeepRawOutput bool
+	// CheckInputs sets RenderedPrompt.InputWarnings to the input fields the
+	// template does not reference and the referenced fields that are missing.
+	CheckInputs bool
+	// Completion configures the rendering of prompts with `format:
+	// completion`.
+	Completion *CompletionFormat
+	// RoleAliases maps role names used in templates to the roles they stand
+	// for, e.g. {"assistant": RoleModel, "human": RoleUser}, so that prompts
+	// written for other ecosystems render correctly. Names are lowercase
+	// letters, as role markers only allow those.
+	RoleAliases map[string]Role
+	// SystemMessages arranges the system messages of rendered prompts for
+	// model APIs that reject several system messages, or system messages
+	// after other messages. See ApplySystemMessagePolicy.
+	SystemMessages SystemMessagePolicy
+	// KeepEmptyMessages keeps the messages started by role markers that have
+	// no content; by default the next role marker replaces them.
+	KeepEmptyMessages bool
+	// TrimMode selects how the whitespace of templates and rendered text is
+	// normalized. It does not apply to templates parsed by Parser.
+	TrimMode TrimMode
+	// SecretResolver resolves the secret references, such as
+	// `secret://projects/x/secrets/y`, in the config and extension fields of
+	// rendered metadata into Secret values. Without it the references are
+	// left as strings. Renders are not cached while it is set, so that
+	// rotated secrets are picked up and resolved ones are not kept in memory.
+	SecretResolver SecretResolver
+	// EnvAllowlist names the environment variables that frontmatter may
+	// reference in its model, config and extension values as `${NAME}`, or
+	// `${NAME:-default}` to fall back when the variable is unset or empty.
+	// `$${` stands for a literal `${`. Expansion is off while the allowlist is
+	// nil; when it is on, referencing any other variable fails the render,
+	// and renders are not cached, as the environment may change between them.
+	EnvAllowlist []string
+	// MetadataTemplates renders the model, config and extension values of
+	// frontmatter that contain `{{` as Handlebars templates when a prompt is
+	// rendered, e.g. `model: "{{#if input.fast}}flash{{else}}pro{{/if}}"`.
+	// The input, including the defaults of the prompt, is available as
+	// `input`; write `\{{` for a literal `{{`. Output is not HTML-escaped, and
+	// config and extension values that render to a number or boolean become
+	// one. Environment variables are expanded before, not after, rendering.
+	MetadataTemplates bool
+	// Translations are the string tables of the `t` helper; see
+	// NewTranslateHelper. The helper is not registered while they are nil.
+	Translations Translations
+	// Flags evaluates the feature flags of the `flag` helper; see
+	// NewFlagHelper. The helper is not registered while it is nil. Renders
+	// are not cached while it is set, since flags change without the prompt
+	// or its data changing.
+	Flags FlagProvider
+	// OutputInstructions places instructions for the output format of
+	// prompts in their rendered messages; see PromptMetadata.FormatInstructions.
+	OutputInstructions OutputInstructions
+	// JSONMode sets RenderedPrompt.JSONMode for prompts with JSON output and
+	// an output schema, and describes the schema to the model in place of
+	// the instructions of OutputInstructions.
+	JSONMode *JSONModeOptions
 }
 
 // Dotprompt is the main struct for the Dotprompt instance.
@@ -50,12 +141,42 @@ type Dotprompt struct {
 	knownHelpers          map[string]bool
 	defaultModel          string
 	modelConfigs          map[string]any
+	modelFamilyConfigs    []modelFamilyConfig
 	tools                 map[string]ToolDefinition
 	toolResolver          ToolResolver
 	schemaResolver        SchemaResolver
 	partialResolver       PartialResolver
+	partialStore          PromptStore
 	knownPartials         map[string]bool
-	Template              *raymond.Template
+	partialSources        map[string]string
+	middleware            []Middleware
+	limits                RenderLimits
+	parser                DocumentParser
+	engine                TemplateEngine
+	mediaFS               fs.FS
+	includeFS             fs.FS
+	maxIncludeBytes       int64
+	keepRawOutput         bool
+	checkInputs           bool
+	completion            *CompletionFormat
+	roleAliases           map[string]Role
+	systemMessages        SystemMessagePolicy
+	keepEmptyMessages     bool
+	trimMode              TrimMode
+	secretResolver        SecretResolver
+	envAllowlist          []string
+	metadataTemplates     bool
+	translations          Translations
+	flags                 FlagProvider
+	outputPlacement       OutputInstructions
+	jsonMode              *JSONModeOptions
+	compileCache          *compileCache
+	compileScope          uint64
+	renderCache           *renderCache
+	renderScope           uint64
+	trace                 *RenderTrace
+	preview               *templatePreview
+	Template              EngineTemplate
 	Helpers               map[string]any
 	Partials              map[string]string
 	Schemas               map[string]*jsonschema.Schema
@@ -68,58 +189,117 @@ func NewDotprompt(options *DotpromptOptions) *Dotprompt {
 	dp := &Dotprompt{
 		knownHelpers:          make(map[string]bool),
 		knownPartials:         make(map[string]bool),
+		partialSources:        make(map[string]string),
 		ExternalSchemaLookups: make([]func(string) any, 0),
 	}
+	dp.setOptions(options)
+	return dp
+}
 
-	if options != nil {
-		dp.modelConfigs = options.ModelConfigs
-		dp.defaultModel = options.DefaultModel
-		dp.tools = options.Tools
-		dp.toolResolver = options.ToolResolver
-		dp.Schemas = options.Schemas
-		dp.schemaResolver = options.SchemaResolver
-		dp.partialResolver = options.PartialResolver
-		dp.Helpers = options.Helpers
-		dp.Partials = options.Partials
-
-		if dp.tools == nil {
-			dp.tools = make(map[string]ToolDefinition)
-		}
-		if dp.Schemas == nil {
-			dp.Schemas = make(map[string]*jsonschema.Schema)
-		}
-		if dp.Helpers == nil {
-			dp.Helpers = make(map[string]any)
-		}
-		if dp.Partials == nil {
-			dp.Partials = make(map[string]string)
-		}
-		if dp.modelConfigs == nil {
-			dp.modelConfigs = make(map[string]any)
-		}
-	} else {
-		// Ensure maps are initialized even if options are nil.
+// setOptions replaces the settings of dp with those of options, which may be
+// nil.
+func (dp *Dotprompt) setOptions(options *DotpromptOptions) {
+	if options == nil {
+		options = &DotpromptOptions{}
+	}
+	dp.modelConfigs = options.ModelConfigs
+	dp.defaultModel = options.DefaultModel
+	dp.tools = options.Tools
+	dp.toolResolver = options.ToolResolver
+	dp.Schemas = options.Schemas
+	dp.schemaResolver = options.SchemaResolver
+	dp.partialResolver = options.PartialResolver
+	dp.partialStore = options.PartialStore
+	dp.Helpers = options.Helpers
+	dp.Partials = options.Partials
+	dp.limits = options.Limits
+	dp.parser = options.Parser
+	dp.engine = options.Engine
+	dp.mediaFS = options.MediaFS
+	dp.includeFS = options.IncludeFS
+	dp.maxIncludeBytes = options.MaxIncludeBytes
+	dp.keepRawOutput = options.KeepRawOutput
+	dp.checkInputs = options.CheckInputs
+	dp.completion = options.Completion
+	dp.roleAliases = maps.Clone(options.RoleAliases)
+	dp.systemMessages = options.SystemMessages
+	dp.keepEmptyMessages = options.KeepEmptyMessages
+	dp.trimMode = options.TrimMode
+	dp.secretResolver = options.SecretResolver
+	dp.envAllowlist = slices.Clone(options.EnvAllowlist)
+	dp.metadataTemplates = options.MetadataTemplates
+	dp.translations = options.Translations
+	dp.flags = options.Flags
+	dp.outputPlacement = options.OutputInstructions
+	dp.jsonMode = options.JSONMode
+	if dp.mediaFS == nil && options.MediaRoot != "" {
+		dp.mediaFS = os.DirFS(options.MediaRoot)
+	}
+
+	if dp.tools == nil {
 		dp.tools = make(map[string]ToolDefinition)
+	}
+	if dp.Schemas == nil {
 		dp.Schemas = make(map[string]*jsonschema.Schema)
+	}
+	if dp.Helpers == nil {
 		dp.Helpers = make(map[string]any)
+	}
+	if dp.Partials == nil {
 		dp.Partials = make(map[string]string)
+	}
+	if dp.modelConfigs == nil {
 		dp.modelConfigs = make(map[string]any)
 	}
-
-	return dp
 }
 
 // Clone creates a deep copy of the Dotprompt instance.
+//
+// The clone shares the compile and render caches of dp, set with WithCache and
+// WithRenderCache, so that cloning per request still reuses the prompts
+// compiled and rendered by earlier requests. Settings that change how prompts
+// render, such as DefineModelConfig and DefineTool, only apply to the
+// instance they are made on, but helpers and partials added to the maps of
+// the clone are not seen by the prompts it shares with dp; use With to add
+// those.
 func (dp *Dotprompt) Clone() *Dotprompt {
 	clone := &Dotprompt{
 		knownHelpers:          make(map[string]bool),
 		defaultModel:          dp.defaultModel,
 		modelConfigs:          make(map[string]any),
+		modelFamilyConfigs:    slices.Clone(dp.modelFamilyConfigs),
 		tools:                 make(map[string]ToolDefinition),
 		toolResolver:          dp.toolResolver,
 		schemaResolver:        dp.schemaResolver,
 		partialResolver:       dp.partialResolver,
+		partialStore:          dp.partialStore,
 		knownPartials:         make(map[string]bool),
+		partialSources:        make(map[string]string),
+		middleware:            make([]Middleware, len(dp.middleware)),
+		limits:                dp.limits,
+		parser:                dp.parser,
+		engine:                dp.engine,
+		mediaFS:               dp.mediaFS,
+		includeFS:             dp.includeFS,
+		maxIncludeBytes:       dp.maxIncludeBytes,
+		keepRawOutput:         dp.keepRawOutput,
+		checkInputs:           dp.checkInputs,
+		completion:            dp.completion,
+		roleAliases:           dp.roleAliases,
+		systemMessages:        dp.systemMessages,
+		keepEmptyMessages:     dp.keepEmptyMessages,
+		trimMode:              dp.trimMode,
+		secretResolver:        dp.secretResolver,
+		envAllowlist:          dp.envAllowlist,
+		metadataTemplates:     dp.metadataTemplates,
+		translations:          dp.translations,
+		flags:                 dp.flags,
+		outputPlacement:       dp.outputPlacement,
+		jsonMode:              dp.jsonMode,
+		compileCache:          dp.compileCache,
+		compileScope:          dp.compileScope,
+		renderCache:           dp.renderCache,
+		renderScope:           dp.renderScope,
 		Template:              dp.Template,
 		Helpers:               make(map[string]any),
 		Partials:              make(map[string]string),
@@ -131,36 +311,47 @@ func (dp *Dotprompt) Clone() *Dotprompt {
 	maps.Copy(clone.modelConfigs, dp.modelConfigs)
 	maps.Copy(clone.tools, dp.tools)
 	maps.Copy(clone.knownPartials, dp.knownPartials)
+	maps.Copy(clone.partialSources, dp.partialSources)
 	maps.Copy(clone.Helpers, dp.Helpers)
 	maps.Copy(clone.Partials, dp.Partials)
 	maps.Copy(clone.Schemas, dp.Schemas)
 	copy(clone.ExternalSchemaLookups, dp.ExternalSchemaLookups)
+	copy(clone.middleware, dp.middleware)
 
 	return clone
 }
 
 // DefineHelper registers a helper function.
-func (dp *Dotprompt) DefineHelper(name string, helper any, tpl *raymond.Template) error {
+func (dp *Dotprompt) DefineHelper(name string, helper any, tpl TemplateRegistrar) error {
 	if dp.knownHelpers[name] {
 		return fmt.Errorf("the helper is already registered: %s", name)
 	}
-	tpl.RegisterHelper(name, helper)
+	if dp.trace != nil {
+		helper = dp.trace.wrapHelper(name, helper)
+	}
+	tpl.RegisterHelper(name, dp.limitHelper(helper))
 	dp.knownHelpers[name] = true
 	return nil
 }
 
 // DefinePartial registers a partial template.
-func (dp *Dotprompt) DefinePartial(name string, source string, tpl *raymond.Template) error {
+func (dp *Dotprompt) DefinePartial(name string, source string, tpl TemplateRegistrar) error {
 	if dp.knownPartials[name] {
 		return fmt.Errorf("the partial is already registered: %s", name)
 	}
-	tpl.RegisterPartial(name, source)
+	source = rewriteDynamicPartials(quotePinnedPartials(source))
+	if dp.trace != nil {
+		tpl.RegisterPartial(name, dp.trace.wrapPartial(name, source))
+	} else {
+		tpl.RegisterPartial(name, source)
+	}
 	dp.knownPartials[name] = true
+	dp.partialSources[name] = source
 	return nil
 }
 
 // TODO(#501): Add register helpers
-func (dp *Dotprompt) RegisterHelpers(tpl *raymond.Template) error {
+func (dp *Dotprompt) RegisterHelpers(tpl TemplateRegistrar) error {
 	if dp.Helpers != nil {
 		for key, helper := range dp.Helpers {
 			if err := dp.DefineHelper(key, helper, tpl); err != nil {
@@ -169,16 +360,41 @@ func (dp *Dotprompt) RegisterHelpers(tpl *raymond.Template) error {
 		}
 	}
 	for name, helper := range templateHelpers {
+		if dp.mediaFS != nil {
+			switch name {
+			case "media":
+				helper = NewMediaHelper(dp.mediaFS)
+			case "audio":
+				helper = newTimedMediaHelper(AudioMarkerPrefix, dp.mediaFS)
+			case "video":
+				helper = newTimedMediaHelper(VideoMarkerPrefix, dp.mediaFS)
+			}
+		}
 		if !dp.knownHelpers[name] {
 			if err := dp.DefineHelper(name, helper, tpl); err != nil {
 				return err
 			}
 		}
 	}
+	if dp.translations != nil && !dp.knownHelpers["t"] {
+		if err := dp.DefineHelper("t", NewTranslateHelper(dp.translations), tpl); err != nil {
+			return err
+		}
+	}
+	if dp.includeFS != nil && !dp.knownHelpers["include"] {
+		if err := dp.DefineHelper("include", NewIncludeHelper(dp.includeFS, dp.maxIncludeBytes), tpl); err != nil {
+			return err
+		}
+	}
+	if dp.flags != nil && !dp.knownHelpers["flag"] {
+		if err := dp.DefineHelper("flag", NewFlagHelper(dp.flags), tpl); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (dp *Dotprompt) RegisterPartials(tpl *raymond.Template, template string) error {
+func (dp *Dotprompt) RegisterPartials(tpl TemplateRegistrar, template string) error {
 	if dp.Partials != nil {
 		for key, partial := range dp.Partials {
 			if err := dp.DefinePartial(key, partial, tpl); err != nil {
@@ -192,20 +408,28 @@ func (dp *Dotprompt) RegisterPartials(tpl *raymond.Template, template string) er
 	return nil
 }
 
-func (dp *Dotprompt) initializeTemplate(tpl *raymond.Template) {
+func (dp *Dotprompt) initializeTemplate(tpl EngineTemplate) {
 	dp.Template = tpl
 	dp.knownHelpers = make(map[string]bool)
 	dp.knownPartials = make(map[string]bool)
+	dp.partialSources = make(map[string]string)
 }
 
 // DefineTool registers a tool definition.
 func (dp *Dotprompt) DefineTool(def ToolDefinition) *Dotprompt {
+	dp.newRenderScope()
 	dp.tools[def.Name] = def
 	return dp
 }
 
 // Parse parses the source string into a ParsedPrompt.
 func (dp *Dotprompt) Parse(source string) (ParsedPrompt, error) {
+	if dp.parser != nil {
+		return dp.parser(source)
+	}
+	if dp.trimMode != TrimDefault {
+		return ParseOptions{Trim: dp.trimMode}.Parse(source)
+	}
 	return ParseDocument(source)
 }
 
@@ -219,18 +443,78 @@ func (dp *Dotprompt) Render(source string, data *DataArgument, options *PromptMe
 }
 
 // Compile compiles the source string into a PromptFunction.
+//
+// If the instance was created with WithCache, the functions compiled without
+// additional metadata are cached by source.
 func (dp *Dotprompt) Compile(source string, additionalMetadata *PromptMetadata) (PromptFunction, error) {
+	if dp.compileCache == nil || additionalMetadata != nil || dp.preview != nil {
+		return dp.compile(source, additionalMetadata, nil)
+	}
+	key := compileCacheKey{scope: dp.compileScope, source: source}
+	if c, ok := dp.compileCache.get(key); ok {
+		return dp.promptFunction(c), nil
+	}
+	parsedPrompt, err := dp.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	c, err := dp.compilePrompt(parsedPrompt, source, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	dp.compileCache.put(key, c)
+	return dp.promptFunction(c), nil
+}
+
+// CompileParsed compiles a prompt that was already parsed, such as one
+// generated by dotpromptgen, into a PromptFunction. It is Compile without the
+// parsing step.
+func (dp *Dotprompt) CompileParsed(parsed ParsedPrompt, additionalMetadata *PromptMetadata) (PromptFunction, error) {
+	// The render cache keys prompts by their source, which the encoded
+	// prompt stands in for.
+	source, _ := json.Marshal(parsed)
+	return dp.compileParsed(parsed, string(source), additionalMetadata, nil)
+}
+
+// compile implements Compile. If trace is not nil, the helpers and partials
+// of the compiled template are instrumented to record into it.
+func (dp *Dotprompt) compile(source string, additionalMetadata *PromptMetadata, trace *RenderTrace) (PromptFunction, error) {
 	parsedPrompt, err := dp.Parse(source)
 	if err != nil {
 		return nil, err
 	}
+	return dp.compileParsed(parsedPrompt, source, additionalMetadata, trace)
+}
+
+// compileParsed implements compile for parsedPrompt, parsed from source.
+func (dp *Dotprompt) compileParsed(parsedPrompt ParsedPrompt, source string, additionalMetadata *PromptMetadata, trace *RenderTrace) (PromptFunction, error) {
+	c, err := dp.compilePrompt(parsedPrompt, source, additionalMetadata, trace)
+	if err != nil {
+		return nil, err
+	}
+	return dp.promptFunction(c), nil
+}
+
+// compilePrompt compiles the template of parsedPrompt, parsed from source,
+// with the helpers and partials of dp.
+func (dp *Dotprompt) compilePrompt(parsedPrompt ParsedPrompt, source string, additionalMetadata *PromptMetadata, trace *RenderTrace) (*compiledPrompt, error) {
+	dp.trace = trace
+	defer func() { dp.trace = nil }()
+
+	var err error
 	if additionalMetadata != nil {
 		parsedPrompt = mergeMetadata(parsedPrompt, additionalMetadata)
 	}
 
-	renderTpl, err := raymond.Parse(parsedPrompt.Template)
+	parsedPrompt.Template = rewriteDynamicPartials(quotePinnedPartials(parsedPrompt.Template))
+	if dp.preview != nil {
+		if parsedPrompt.Template, err = dp.previewTemplate(parsedPrompt.Template); err != nil {
+			return nil, err
+		}
+	}
+	renderTpl, err := dp.engineOrDefault().Parse(parsedPrompt.Template)
 	if err != nil {
-		return nil, err
+		return nil, partialBlockError(parsedPrompt.Template, err)
 	}
 	dp.initializeTemplate(renderTpl)
 
@@ -238,18 +522,66 @@ func (dp *Dotprompt) Compile(source string, additionalMetadata *PromptMetadata)
 	if err = dp.RegisterHelpers(dp.Template); err != nil {
 		return nil, err
 	}
+	if trace != nil {
+		dp.Template.RegisterHelper(traceHelperName, trace.partialHelper)
+	}
+	if dp.preview != nil {
+		dp.Template.RegisterHelper(previewHelperName, previewHelper)
+	}
 	if err = dp.RegisterPartials(dp.Template, parsedPrompt.Template); err != nil {
 		return nil, err
 	}
+	dp.Template.RegisterHelper(dynamicPartialHelperName, dp.newDynamicPartialResolver(dp.Template).helper)
+	if err = dp.checkPartialDepth(parsedPrompt.Template); err != nil {
+		return nil, err
+	}
+	metadataTemplates := dp.metadataTemplates
+	if metadataTemplates {
+		if err = dp.checkMetadataTemplates(parsedPrompt.PromptMetadata); err != nil {
+			return nil, err
+		}
+	}
+	var usage *inputUsage
+	if dp.checkInputs {
+		if usage, err = dp.collectInputUsage(parsedPrompt.Template); err != nil {
+			return nil, err
+		}
+	}
 
-	// Capture the current template for this closure to avoid sharing issues.
-	// Without this, all compiled PromptFunctions would share the same dp.Template,
-	// causing wrong template execution when multiple prompts are compiled.
+	// Capture the current template for the compiled prompt to avoid sharing
+	// issues. Without this, all compiled PromptFunctions would share the same
+	// dp.Template, causing wrong template execution when multiple prompts are
+	// compiled.
 	// See: https://github.com/google/dotprompt/issues/362
-	localTemplate := dp.Template
+	return &compiledPrompt{
+		parsed:             parsedPrompt,
+		source:             source,
+		additionalMetadata: additionalMetadata,
+		template:           dp.Template,
+		partialSources:     dp.partialSources,
+		usage:              usage,
+		metadataTemplates:  metadataTemplates,
+		trace:              trace,
+	}, nil
+}
 
+// promptFunction returns the function rendering c with the settings of dp
+// that apply at render time, such as its default model and middleware.
+func (dp *Dotprompt) promptFunction(c *compiledPrompt) PromptFunction {
+	parsedPrompt, localTemplate, usage, metadataTemplates, trace := c.parsed, c.template, c.usage, c.metadataTemplates, c.trace
 	renderFunc := func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
-		mergedMetadata, err := dp.RenderMetadata(parsedPrompt, options)
+		prompt := parsedPrompt
+		var err error
+		if prompt.PromptMetadata, err = dp.expandEnv(prompt.PromptMetadata); err != nil {
+			return RenderedPrompt{}, err
+		}
+		if metadataTemplates {
+			input := MergeMaps(maps.Clone(prompt.Input.Default), data.Input)
+			if prompt.PromptMetadata, err = dp.renderMetadataTemplates(prompt.PromptMetadata, input, data.Context); err != nil {
+				return RenderedPrompt{}, err
+			}
+		}
+		mergedMetadata, err := dp.renderMetadata(prompt, options)
 		if err != nil {
 			return RenderedPrompt{}, err
 		}
@@ -260,50 +592,72 @@ func (dp *Dotprompt) Compile(source string, additionalMetadata *PromptMetadata)
 			maps.Copy(defaultInput, mergedMetadata.Input.Default)
 		}
 		inputContext = MergeMaps(defaultInput, data.Input)
-		privDF := raymond.NewDataFrame()
-		for k, v := range data.Context {
-			privDF.Set(k, v)
-		}
-
-		renderedString, err := localTemplate.ExecWith(inputContext, privDF, &raymond.ExecOptions{
-			NoEscape: true,
-		})
 
+		start := time.Now()
+		renderedString, err := dp.execTemplate(localTemplate, inputContext, data.Context)
+		if trace != nil {
+			trace.finish(parsedPrompt.Template, time.Since(start))
+		}
 		if err != nil {
 			return RenderedPrompt{}, err
 		}
 
-		messages, err := ToMessages(renderedString, data)
-		if err != nil {
+		rendered := RenderedPrompt{PromptMetadata: mergedMetadata}
+		if mergedMetadata.Format == PromptFormatCompletion {
+			if rendered.Completion, err = toCompletion(renderedString, data, dp.completion, dp.roleAliases); err != nil {
+				return RenderedPrompt{}, err
+			}
+			rendered.Messages = []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: rendered.Completion}}}}
+		} else if examples, err := ExampleMessages(mergedMetadata.Examples); err != nil {
+			return RenderedPrompt{}, err
+		} else if rendered.Messages, err = toMessages(renderedString, data, messageOptions{
+			aliases:   dp.roleAliases,
+			keepEmpty: dp.keepEmptyMessages,
+			trim:      dp.trimMode,
+			examples:  examples,
+		}); err != nil {
+			return RenderedPrompt{}, err
+		} else if rendered.Messages, err = ApplySystemMessagePolicy(rendered.Messages, dp.systemMessages); err != nil {
+			return RenderedPrompt{}, err
+		} else {
+			instructions, placement, jsonMode, err := dp.outputInstructions(mergedMetadata)
+			if err != nil {
+				return RenderedPrompt{}, err
+			}
+			if rendered.Messages, err = applyOutputInstructions(rendered.Messages, instructions, placement); err != nil {
+				return RenderedPrompt{}, err
+			}
+			rendered.JSONMode = jsonMode
+		}
+		if err := applyCacheTTL(rendered.Messages, mergedMetadata.Ext); err != nil {
+			return RenderedPrompt{}, err
+		}
+		if guards, err := mergedMetadata.Guards(); err != nil {
+			return RenderedPrompt{}, err
+		} else if err := guards.Check(rendered.Messages); err != nil {
 			return RenderedPrompt{}, err
 		}
-		return RenderedPrompt{
-			PromptMetadata: mergedMetadata,
-			Messages:       messages,
-		}, nil
+		if dp.keepRawOutput {
+			rendered.RawOutput = renderedString
+		}
+		if usage != nil {
+			rendered.InputWarnings = usage.check(data.Input, inputContext)
+		}
+		return rendered, nil
 	}
 
-	return renderFunc, nil
+	if trace == nil && dp.cachesRenders() {
+		renderFunc = dp.renderCache.wrap(func() uint64 { return dp.renderScope }, c.renderVersion(), renderFunc)
+	}
+	return dp.applyMiddleware(renderFunc)
 }
 
-// IdentifyPartials identifies partials in the template.
-func (d *Dotprompt) identifyPartials(template string) []string {
-	// Simplified partial identification logic
-	var partials []string
-	lines := strings.SplitSeq(template, "\n")
-	for line := range lines {
-		re := regexp.MustCompile(`{{>\s*([^}]+)\s*}}`)
-		// Find all matches in the template
-		matches := re.FindAllStringSubmatch(line, -1)
-
-		for _, match := range matches {
-			if len(match) > 1 {
-				partialName := strings.TrimSpace(match[1])
-				partials = append(partials, partialName)
-			}
-		}
-	}
-	return partials
+// cachesRenders reports whether dp caches renders: it has a render cache and
+// none of the settings that can change a render without the prompt or its
+// data changing.
+func (dp *Dotprompt) cachesRenders() bool {
+	return dp.renderCache != nil && dp.flags == nil && dp.envAllowlist == nil &&
+		dp.secretResolver == nil && dp.includeFS == nil && dp.mediaFS == nil
 }
 
 // resolvePartials resolves and registers partials in the template.
@@ -311,19 +665,23 @@ func (d *Dotprompt) identifyPartials(template string) []string {
 // This method recursively resolves partials, meaning if a partial itself
 // contains partial references, those will also be resolved. Cycle detection
 // prevents infinite loops when partials reference each other.
-func (dp *Dotprompt) resolvePartials(template string, tpl *raymond.Template) error {
+func (dp *Dotprompt) resolvePartials(template string, tpl TemplateRegistrar) error {
 	visited := make(map[string]bool)
 	return dp.resolvePartialsRecursive(template, tpl, visited)
 }
 
 // resolvePartialsRecursive is the internal recursive implementation of partial resolution.
-func (dp *Dotprompt) resolvePartialsRecursive(template string, tpl *raymond.Template, visited map[string]bool) error {
-	if dp.partialResolver == nil {
+func (dp *Dotprompt) resolvePartialsRecursive(template string, tpl TemplateRegistrar, visited map[string]bool) error {
+	if dp.partialResolver == nil && dp.partialStore == nil {
 		return nil
 	}
 
-	partials := dp.identifyPartials(template)
-	for _, partial := range partials {
+	refs, err := partialReferences(template)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		partial := ref.Name
 		// Skip if already registered
 		if _, exists := dp.knownPartials[partial]; exists {
 			continue
@@ -333,28 +691,41 @@ func (dp *Dotprompt) resolvePartialsRecursive(template string, tpl *raymond.Temp
 		if visited[partial] {
 			continue
 		}
-
-		// Mark as being processed
-		visited[partial] = true
-
-		content, err := dp.partialResolver(partial)
-		if err != nil {
+		if err := dp.resolvePartialRef(ref, tpl, visited); err != nil {
 			return err
 		}
-		if content != "" {
-			if err = dp.DefinePartial(partial, content, tpl); err != nil {
-				return err
-			}
-			// Recursively resolve partials in the resolved content
-			err = dp.resolvePartialsRecursive(content, tpl, visited)
-			if err != nil {
-				return err
-			}
-		}
 	}
 	return nil
 }
 
+// resolvePartialRef loads the partial ref, registers it, and resolves the
+// partials it references in turn. A partial that loads as empty is left
+// unregistered.
+func (dp *Dotprompt) resolvePartialRef(ref partialReference, tpl TemplateRegistrar, visited map[string]bool) error {
+	partial := ref.Name
+	// Mark as being processed
+	visited[partial] = true
+
+	var content string
+	var err error
+	if dp.partialStore != nil {
+		content, err = dp.loadPinnedPartial(ref.Ref)
+	} else {
+		content, err = dp.partialResolver(partial)
+	}
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return nil
+	}
+	if err = dp.DefinePartial(partial, content, tpl); err != nil {
+		return err
+	}
+	// Recursively resolve partials in the resolved content
+	return dp.resolvePartialsRecursive(dp.partialSources[partial], tpl, visited)
+}
+
 // mergeMetadata merges additional metadata into the parsed prompt.
 func mergeMetadata(parsedPrompt ParsedPrompt, additionalMetadata *PromptMetadata) ParsedPrompt {
 	if additionalMetadata != nil {
@@ -362,7 +733,7 @@ func mergeMetadata(parsedPrompt ParsedPrompt, additionalMetadata *PromptMetadata
 			parsedPrompt.Model = additionalMetadata.Model
 		}
 		if additionalMetadata.Config != nil {
-			parsedPrompt.Config = additionalMetadata.Config
+			parsedPrompt.Config = mergeModelConfigs(parsedPrompt.Config, additionalMetadata.Config)
 		}
 	}
 	return parsedPrompt
@@ -383,7 +754,15 @@ func (dp *Dotprompt) RenderMetadata(source any, additionalMetadata *PromptMetada
 	default:
 		return PromptMetadata{}, errors.New("invalid source type")
 	}
+	if parsedSource.PromptMetadata, err = dp.expandEnv(parsedSource.PromptMetadata); err != nil {
+		return PromptMetadata{}, err
+	}
+	return dp.renderMetadata(parsedSource, additionalMetadata)
+}
 
+// renderMetadata implements RenderMetadata for a prompt whose environment
+// variables are expanded.
+func (dp *Dotprompt) renderMetadata(parsedSource ParsedPrompt, additionalMetadata *PromptMetadata) (PromptMetadata, error) {
 	if additionalMetadata == nil {
 		additionalMetadata = &PromptMetadata{}
 	}
@@ -395,15 +774,16 @@ func (dp *Dotprompt) RenderMetadata(source any, additionalMetadata *PromptMetada
 		selectedModel = dp.defaultModel
 	}
 
-	modelConfig, ok := dp.modelConfigs[selectedModel].(map[string]any)
-	if !ok {
-		modelConfig = make(map[string]any)
-	}
+	modelConfig := dp.defaultModelConfig(selectedModel)
 	metadata := []*PromptMetadata{}
 	metadata = append(metadata, &parsedSource.PromptMetadata)
 	metadata = append(metadata, additionalMetadata)
 
-	return dp.ResolveMetadata(PromptMetadata{Config: modelConfig}, metadata)
+	resolved, err := dp.ResolveMetadata(PromptMetadata{Config: modelConfig}, metadata)
+	if err != nil {
+		return PromptMetadata{}, err
+	}
+	return dp.resolveSecrets(resolved)
 }
 
 // mergeStructs merges two structures of type PromptMetadata
@@ -430,9 +810,7 @@ func (dp *Dotprompt) ResolveMetadata(base PromptMetadata, merges []*PromptMetada
 		if merge == nil {
 			continue
 		}
-		out = mergeStructs(out, *merge)
-
-		maps.Copy(out.Config, merge.Config)
+		out = MergeMetadata(out, *merge)
 	}
 	out, err := dp.ResolveTools(out)
 	if err != nil {
@@ -484,6 +862,7 @@ func (dp *Dotprompt) RenderPicoschema(meta PromptMetadata) (PromptMetadata, erro
 			SchemaResolver: func(name string) (*jsonschema.Schema, error) {
 				return dp.WrappedSchemaResolver(name)
 			},
+			KeyOrder: meta.RawKeyOrder.Child("input").Child("schema"),
 		})
 		if err != nil {
 			return PromptMetadata{}, err
@@ -495,6 +874,7 @@ func (dp *Dotprompt) RenderPicoschema(meta PromptMetadata) (PromptMetadata, erro
 			SchemaResolver: func(name string) (*jsonschema.Schema, error) {
 				return dp.WrappedSchemaResolver(name)
 			},
+			KeyOrder: meta.RawKeyOrder.Child("output").Child("schema"),
 		})
 		if err != nil {
 			return PromptMetadata{}, err
@@ -20,6 +20,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/mbleigh/raymond"
 )
 
@@ -408,3 +409,112 @@ func TestResolvePartialsCycleDetection(t *testing.T) {
 		t.Errorf("partialB was not marked as known")
 	}
 }
+
+func TestCustomDocumentParser(t *testing.T) {
+	calls := 0
+	dp := NewDotprompt(&DotpromptOptions{
+		Parser: func(source string) (ParsedPrompt, error) {
+			calls++
+			return ParseSections(source, "model: custom/model", strings.ToUpper(source))
+		},
+	})
+
+	for _, d := range []*Dotprompt{dp, dp.Clone()} {
+		rendered, err := d.Render("hello {{name}}", &DataArgument{Input: map[string]any{"NAME": "Ada"}}, nil)
+		if err != nil {
+			t.Fatalf("Render() returned error: %v", err)
+		}
+		if rendered.Model != "custom/model" {
+			t.Errorf("Model = %q, want %q", rendered.Model, "custom/model")
+		}
+		if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != "HELLO Ada" {
+			t.Errorf("rendered text = %q, want %q", got, "HELLO Ada")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("parser calls = %d, want 2", calls)
+	}
+}
+
+func TestKeepRawOutput(t *testing.T) {
+	source := "{{role \"system\"}}Be brief.{{role \"user\"}}Hi {{name}}"
+	data := &DataArgument{Input: map[string]any{"name": "Ada"}}
+
+	dp := NewDotprompt(&DotpromptOptions{KeepRawOutput: true})
+	rendered, err := dp.Render(source, data, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	want := "<<<dotprompt:role:system>>>Be brief.<<<dotprompt:role:user>>>Hi Ada"
+	if rendered.RawOutput != want {
+		t.Errorf("RawOutput = %q, want %q", rendered.RawOutput, want)
+	}
+	if len(rendered.Messages) != 2 {
+		t.Errorf("len(Messages) = %d, want 2", len(rendered.Messages))
+	}
+
+	rendered, err = NewDotprompt(nil).Render(source, data, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if rendered.RawOutput != "" {
+		t.Errorf("RawOutput = %q, want empty without KeepRawOutput", rendered.RawOutput)
+	}
+}
+
+func TestRoleAliases(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{
+		RoleAliases: map[string]Role{"assistant": RoleModel, "human": RoleUser},
+	})
+	source := "{{role \"system\"}}Be nice.{{role \"human\"}}Hi{{role \"assistant\"}}Hello!{{role \"human\"}}{{question}}"
+	history := []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: "Earlier"}}}}
+	rendered, err := dp.Render(source, &DataArgument{Input: map[string]any{"question": "Why?"}, Messages: history}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	var roles []Role
+	for _, msg := range rendered.Messages {
+		roles = append(roles, msg.Role)
+	}
+	// The history goes before the last message, which is a user message
+	// once its alias is resolved.
+	want := []Role{RoleSystem, RoleUser, RoleModel, RoleUser, RoleUser}
+	if diff := cmp.Diff(want, roles); diff != "" {
+		t.Errorf("Render() roles mismatch (-want +got):\n%s", diff)
+	}
+	if text := rendered.Messages[3].Content[0].(*TextPart).Text; text != "Earlier" {
+		t.Errorf("Messages[3] = %q, want the history message", text)
+	}
+
+	messages, err := ToMessages("<<<dotprompt:role:human>>>Hi", nil)
+	if err != nil {
+		t.Fatalf("ToMessages() returned error: %v", err)
+	}
+	if messages[0].Role != "human" {
+		t.Errorf("ToMessages() role = %q, want aliases not applied", messages[0].Role)
+	}
+}
+
+func TestCompileParsed(t *testing.T) {
+	source := "---\ninput:\n  default:\n    name: World\n---\nHello {{name}}!"
+	parsed, err := ParseDocument(source)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	dp := NewDotprompt(nil)
+	fn, err := dp.CompileParsed(parsed, nil)
+	if err != nil {
+		t.Fatalf("CompileParsed() returned error: %v", err)
+	}
+	got, err := fn(&DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("rendering returned error: %v", err)
+	}
+	want, err := dp.Render(source, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if diff := cmp.Diff(want.Messages, got.Messages); diff != "" {
+		t.Errorf("CompileParsed() render mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dotprompthttp serves the prompts of a dotprompt.PromptStore or
+// dotprompt.Bundle over HTTP, as a backend for prompt playgrounds and other
+// tools:
+//
+//	GET  /prompts                 lists the prompts
+//	GET  /prompts/{name}/metadata returns the resolved metadata of a prompt
+//	POST /prompts/{name}/render   renders a prompt with a JSON body of the
+//	                              form {"input": {...}, "context": {...}}
+//
+// With UI set, the handler also serves a playground at `/` that lists the
+// prompts, builds forms from their input schemas, previews renders as the
+// input changes and diffs the renders of variants.
+//
+// Names may contain slashes. The variant and version of a prompt are chosen
+// with the `variant` and `version` query parameters, and List also accepts
+// `cursor`, `limit`, `prefix`, `glob` and `tag`, which may be repeated.
+//
+// Responses are negotiated with the Accept header: JSON by default, plain
+// text, or, for render, a Server-Sent Events stream with one `message` event
+// per rendered message followed by a `done` event with the rest of the
+// result. Errors are JSON objects with an `error` field.
+package dotprompthttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+// Media types offered by the handler.
+const (
+	typeJSON = "application/json"
+	typeText = "text/plain"
+	typeSSE  = "text/event-stream"
+)
+
+// maxRequestBody bounds the size of render requests.
+const maxRequestBody = 1 << 20
+
+// Handler serves the prompts of a store or bundle.
+type Handler struct {
+	dp     *dotprompt.Dotprompt
+	store  dotprompt.PromptStore
+	bundle *dotprompt.Bundle
+	// UI enables the embedded playground at `/` and its assets under
+	// `/ui/`.
+	UI bool
+}
+
+// NewHandler returns a Handler that renders the prompts of store with dp.
+// Each request renders with a clone of dp, so requests are served
+// concurrently.
+func NewHandler(dp *dotprompt.Dotprompt, store dotprompt.PromptStore) *Handler {
+	return &Handler{dp: dp, store: store}
+}
+
+// ServeBundle returns a Handler that serves the prompts of bundle. They are
+// compiled already, so requests neither load nor compile prompts. Listings
+// honor the `variant` and `prefix` query parameters and fit in one page, and
+// the `version` parameter is ignored, since a bundle holds one version of each
+// prompt.
+func ServeBundle(bundle *dotprompt.Bundle) *Handler {
+	return &Handler{bundle: bundle}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == "/prompts" {
+		h.allow(w, r, http.MethodGet, h.list)
+		return
+	}
+	rest, ok := strings.CutPrefix(path, "/prompts/")
+	if !ok {
+		if h.UI && (r.URL.Path == "/" || strings.HasPrefix(r.URL.Path, "/ui/")) {
+			h.allow(w, r, http.MethodGet, playground.ServeHTTP)
+			return
+		}
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if name, ok := strings.CutSuffix(rest, "/render"); ok && name != "" {
+		h.allow(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) { h.render(w, r, name) })
+		return
+	}
+	if name, ok := strings.CutSuffix(rest, "/metadata"); ok && name != "" {
+		h.allow(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { h.metadata(w, r, name) })
+		return
+	}
+	writeError(w, http.StatusNotFound, errors.New("not found"))
+}
+
+// allow calls serve if r uses method, and responds with 405 otherwise.
+func (h *Handler) allow(w http.ResponseWriter, r *http.Request, method string, serve http.HandlerFunc) {
+	if r.Method != method && (method != http.MethodGet || r.Method != http.MethodHead) {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	serve(w, r)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 0
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %q", s))
+			return
+		}
+		limit = n
+	}
+	var result dotprompt.ListPromptsResult[dotprompt.PromptRef]
+	var err error
+	if h.bundle != nil {
+		result.Items = bundlePrompts(h.bundle, query.Get("variant"), query.Get("prefix"))
+	} else {
+		result, err = h.store.List(dotprompt.ListPromptsOptions{
+			Cursor:  query.Get("cursor"),
+			Limit:   limit,
+			Variant: query.Get("variant"),
+			Prefix:  query.Get("prefix"),
+			Glob:    query.Get("glob"),
+			Tags:    query["tag"],
+		})
+	}
+	if err != nil {
+		writeError(w, statusOf(err, http.StatusBadRequest), err)
+		return
+	}
+
+	switch negotiate(r, typeJSON, typeText) {
+	case typeText:
+		var b strings.Builder
+		for _, ref := range result.Items {
+			b.WriteString(ref.Name)
+			if ref.Variant != "" {
+				b.WriteString("." + ref.Variant)
+			}
+			b.WriteByte('\n')
+		}
+		writeText(w, b.String())
+	default:
+		items := result.Items
+		if items == nil {
+			items = []dotprompt.PromptRef{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"prompts": items, "cursor": result.Cursor})
+	}
+}
+
+// load loads the prompt name with the variant and version of the query.
+func (h *Handler) load(r *http.Request, name string) (dotprompt.PromptData, error) {
+	query := r.URL.Query()
+	return h.store.Load(name, dotprompt.LoadPromptOptions{
+		Variant: query.Get("variant"),
+		Version: query.Get("version"),
+	})
+}
+
+// bundlePrompts returns the prompts of bundle with variant, if not empty, and
+// names starting with prefix.
+func bundlePrompts(bundle *dotprompt.Bundle, variant, prefix string) []dotprompt.PromptRef {
+	var refs []dotprompt.PromptRef
+	for _, ref := range bundle.Prompts() {
+		if (variant == "" || ref.Variant == variant) && strings.HasPrefix(ref.Name, prefix) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// errNotInBundle returns the error for a prompt missing from the bundle.
+func errNotInBundle(name, variant string) error {
+	if variant != "" {
+		name += "." + variant
+	}
+	return fmt.Errorf("prompt %q not found in bundle", name)
+}
+
+func (h *Handler) metadata(w http.ResponseWriter, r *http.Request, name string) {
+	var meta dotprompt.PromptMetadata
+	if h.bundle != nil {
+		variant := r.URL.Query().Get("variant")
+		var ok bool
+		if meta, ok = h.bundle.Metadata(name, variant); !ok {
+			writeError(w, http.StatusNotFound, errNotInBundle(name, variant))
+			return
+		}
+	} else {
+		prompt, err := h.load(r, name)
+		if err != nil {
+			writeError(w, statusOf(err, http.StatusNotFound), err)
+			return
+		}
+		if meta, err = h.dp.Clone().RenderMetadata(prompt.Source, nil); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	switch negotiate(r, typeJSON, typeText) {
+	case typeText:
+		// The JSON form is converted so that the text uses the same keys.
+		b, err := json.Marshal(meta)
+		if err == nil {
+			b, err = yaml.JSONToYAML(b)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeText(w, string(b))
+	default:
+		writeJSON(w, http.StatusOK, meta)
+	}
+}
+
+// renderRequest is the body of a render request.
+type renderRequest struct {
+	Input   map[string]any `json:"input"`
+	Context map[string]any `json:"context"`
+}
+
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, name string) {
+	var req renderRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(body) > maxRequestBody {
+		writeError(w, http.StatusRequestEntityTooLarge, errors.New("request body too large"))
+		return
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+
+	data := &dotprompt.DataArgument{Input: req.Input, Context: req.Context}
+	var rendered dotprompt.RenderedPrompt
+	if h.bundle != nil {
+		variant := r.URL.Query().Get("variant")
+		fn, ok := h.bundle.Prompt(name, variant)
+		if !ok {
+			writeError(w, http.StatusNotFound, errNotInBundle(name, variant))
+			return
+		}
+		rendered, err = fn(data, nil)
+	} else {
+		var prompt dotprompt.PromptData
+		if prompt, err = h.load(r, name); err != nil {
+			writeError(w, statusOf(err, http.StatusNotFound), err)
+			return
+		}
+		rendered, err = h.dp.Clone().Render(prompt.Source, data, nil)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	switch negotiate(r, typeJSON, typeText, typeSSE) {
+	case typeText:
+		writeText(w, messagesText(rendered.Messages))
+	case typeSSE:
+		writeEvents(w, rendered)
+	default:
+		writeJSON(w, http.StatusOK, rendered)
+	}
+}
+
+// messagesText formats messages as text, each preceded by its role.
+func messagesText(messages []dotprompt.Message) string {
+	var b strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "[%s]\n", msg.Role)
+		for _, part := range msg.Content {
+			switch p := part.(type) {
+			case *dotprompt.TextPart:
+				b.WriteString(p.Text)
+			case *dotprompt.MediaPart:
+				fmt.Fprintf(&b, "<media %s>", p.Media.URL)
+			default:
+				if data, err := json.Marshal(part); err == nil {
+					b.Write(data)
+				}
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeEvents streams a rendered prompt as Server-Sent Events: one `message`
+// event per message, then a `done` event with the rest of the result.
+func writeEvents(w http.ResponseWriter, rendered dotprompt.RenderedPrompt) {
+	w.Header().Set("Content-Type", typeSSE)
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	send := func(event string, data any) bool {
+		b, err := json.Marshal(data)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"error": err.Error()})
+			event = "error"
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+	for _, msg := range rendered.Messages {
+		if !send("message", msg) {
+			return
+		}
+	}
+	rendered.Messages = nil
+	send("done", rendered)
+}
+
+// negotiate returns the offer preferred by the Accept header of r, or the
+// first offer if none is acceptable or the header is absent.
+func negotiate(r *http.Request, offers ...string) string {
+	header := r.Header.Get("Accept")
+	if header == "" {
+		return offers[0]
+	}
+	type accepted struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []accepted
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(s, 64); err == nil {
+				q = v
+			}
+		}
+		ranges = append(ranges, accepted{mediaType, q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	for _, ar := range ranges {
+		if ar.q <= 0 {
+			break
+		}
+		for _, offer := range offers {
+			if matchMediaType(ar.mediaType, offer) {
+				return offer
+			}
+		}
+	}
+	return offers[0]
+}
+
+// matchMediaType reports whether the media range pattern, e.g. "text/*",
+// matches mediaType.
+func matchMediaType(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// statusOf returns the status for a store error: 403 for denied accesses,
+// and fallback otherwise.
+func statusOf(err error, fallback int) int {
+	if errors.Is(err, dotprompt.ErrAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", typeJSON)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeText(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", typeText+"; charset=utf-8")
+	io.WriteString(w, text)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
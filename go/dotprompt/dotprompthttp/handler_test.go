@@ -0,0 +1,300 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompthttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	store, err := dotprompt.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	prompts := []dotprompt.PromptData{
+		{PromptRef: dotprompt.PromptRef{Name: "team/greet"}, Source: "---\nmodel: test/model\ninput:\n  schema:\n    name: string\n---\n{{role \"system\"}}Be kind.{{role \"user\"}}Hello {{name}}{{@punct}}"},
+		{PromptRef: dotprompt.PromptRef{Name: "team/greet", Variant: "formal"}, Source: "Good day, {{name}}."},
+	}
+	for _, p := range prompts {
+		if err := store.Save(p); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	return NewHandler(dotprompt.NewDotprompt(nil), store)
+}
+
+// serve sends a request to h and returns the response.
+func serve(h http.Handler, method, target, accept, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestList(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := serve(h, http.MethodGet, "/prompts", "", "")
+	var got struct {
+		Prompts []dotprompt.PromptRef `json:"prompts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	want := []dotprompt.PromptRef{{Name: "team/greet"}, {Name: "team/greet", Variant: "formal"}}
+	if diff := cmp.Diff(want, got.Prompts); diff != "" {
+		t.Errorf("GET /prompts mismatch (-want +got):\n%s", diff)
+	}
+
+	rec = serve(h, http.MethodGet, "/prompts?variant=formal", "text/plain", "")
+	if got, want := rec.Body.String(), "team/greet.formal\n"; got != want {
+		t.Errorf("GET /prompts as text = %q, want %q", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	h := newTestHandler(t)
+	body := `{"input": {"name": "Ada"}, "context": {"punct": "!"}}`
+
+	rec := serve(h, http.MethodPost, "/prompts/team/greet/render", "application/json", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("render status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var rendered struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rendered); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if rendered.Model != "test/model" || len(rendered.Messages) != 2 || rendered.Messages[1].Content[0].Text != "Hello Ada!" {
+		t.Errorf("render = %s", rec.Body.String())
+	}
+
+	rec = serve(h, http.MethodPost, "/prompts/team/greet/render", "text/html;q=0.9, text/*;q=0.8", body)
+	if got, want := rec.Body.String(), "[system]\nBe kind.\n\n[user]\nHello Ada!\n"; got != want {
+		t.Errorf("render as text = %q, want %q", got, want)
+	}
+
+	rec = serve(h, http.MethodPost, "/prompts/team/greet/render?variant=formal", "text/event-stream", body)
+	wantSSE := "event: message\ndata: {\"role\":\"user\",\"content\":[{\"text\":\"Good day, Ada.\"}]}\n\nevent: done\n"
+	if got := rec.Body.String(); !strings.HasPrefix(got, wantSSE) {
+		t.Errorf("render as SSE = %q, want prefix %q", got, wantSSE)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+}
+
+func TestRenderCached(t *testing.T) {
+	store, err := dotprompt.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	if err := store.Save(dotprompt.PromptData{PromptRef: dotprompt.PromptRef{Name: "greet"}, Source: "Hello {{count name}}"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	calls := 0
+	dp := dotprompt.New(
+		dotprompt.WithHelpers(map[string]any{"count": func(s string) string {
+			calls++
+			return s
+		}}),
+		dotprompt.WithCache(10),
+		dotprompt.WithRenderCache(dotprompt.RenderCacheOptions{}),
+	)
+	h := NewHandler(dp, store)
+
+	for range 3 {
+		rec := serve(h, http.MethodPost, "/prompts/greet/render", "text/plain", `{"input": {"name": "Ada"}}`)
+		if got, want := rec.Body.String(), "[user]\nHello Ada\n"; got != want {
+			t.Errorf("render = %q, want %q", got, want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("helper called %d times, want 1", calls)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := serve(h, http.MethodGet, "/prompts/team/greet/metadata", "", "")
+	var meta struct {
+		Model string `json:"model"`
+		Input struct {
+			Schema map[string]any `json:"schema"`
+		} `json:"input"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &meta); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if meta.Model != "test/model" || meta.Input.Schema["type"] != "object" {
+		t.Errorf("metadata = %s, want model and expanded input schema", rec.Body.String())
+	}
+
+	rec = serve(h, http.MethodGet, "/prompts/team/greet/metadata", "text/plain", "")
+	if got := rec.Body.String(); !strings.Contains(got, "model: test/model\n") {
+		t.Errorf("metadata as text = %q, want YAML", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	h := newTestHandler(t)
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"unknown path", http.MethodGet, "/other", "", http.StatusNotFound},
+		{"missing prompt", http.MethodGet, "/prompts/missing/metadata", "", http.StatusNotFound},
+		{"render with GET", http.MethodGet, "/prompts/team/greet/render", "", http.StatusMethodNotAllowed},
+		{"invalid body", http.MethodPost, "/prompts/team/greet/render", "{", http.StatusBadRequest},
+		{"invalid limit", http.MethodGet, "/prompts?limit=x", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, tt.method, tt.target, "", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["error"] == "" {
+				t.Errorf("body = %q, want JSON error", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", typeJSON},
+		{"*/*", typeJSON},
+		{"text/event-stream", typeSSE},
+		{"text/plain;q=0.5, application/json;q=0.9", typeJSON},
+		{"application/xml", typeJSON},
+		{"text/*", typeText},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := negotiate(req, typeJSON, typeText, typeSSE); got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestUI(t *testing.T) {
+	h := newTestHandler(t)
+	if rec := serve(h, http.MethodGet, "/", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET / without UI status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	h.UI = true
+	tests := []struct {
+		target      string
+		contentType string
+		contains    string
+	}{
+		{"/", "text/html", "<title>Dotprompt Playground</title>"},
+		{"/ui/app.js", "javascript", "function promptURL"},
+		{"/ui/style.css", "text/css", "#prompts"},
+	}
+	for _, tt := range tests {
+		rec := serve(h, http.MethodGet, tt.target, "", "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, http.StatusOK)
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.Contains(got, tt.contentType) {
+			t.Errorf("GET %s Content-Type = %q, want %s", tt.target, got, tt.contentType)
+		}
+		if !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("GET %s body does not contain %q", tt.target, tt.contains)
+		}
+	}
+	if rec := serve(h, http.MethodGet, "/ui/missing.js", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /ui/missing.js status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestServeBundle(t *testing.T) {
+	bundle, err := dotprompt.NewDotprompt(nil).LoadBundle(&dotprompt.PromptBundle{
+		Prompts: []dotprompt.PromptData{
+			{PromptRef: dotprompt.PromptRef{Name: "team/greet"}, Source: "---\nmodel: test/model\n---\nHello {{name}}"},
+			{PromptRef: dotprompt.PromptRef{Name: "team/greet", Variant: "formal"}, Source: "Good day, {{name}}."},
+			{PromptRef: dotprompt.PromptRef{Name: "other"}, Source: "Other"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadBundle() returned error: %v", err)
+	}
+	h := ServeBundle(bundle)
+
+	rec := serve(h, http.MethodGet, "/prompts?prefix=team/", "", "")
+	var list struct {
+		Prompts []dotprompt.PromptRef `json:"prompts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	wantList := []dotprompt.PromptRef{{Name: "team/greet"}, {Name: "team/greet", Variant: "formal"}}
+	if diff := cmp.Diff(wantList, list.Prompts); diff != "" {
+		t.Errorf("list mismatch (-want +got):\n%s", diff)
+	}
+
+	rec = serve(h, http.MethodGet, "/prompts/team/greet/metadata", "", "")
+	var meta dotprompt.PromptMetadata
+	if err := json.Unmarshal(rec.Body.Bytes(), &meta); err != nil || meta.Model != "test/model" {
+		t.Errorf("metadata = %q, want model test/model", rec.Body.String())
+	}
+
+	rec = serve(h, http.MethodPost, "/prompts/team/greet/render?variant=formal", "text/plain", `{"input": {"name": "Ada"}}`)
+	if got, want := rec.Body.String(), "[user]\nGood day, Ada.\n"; got != want {
+		t.Errorf("render = %q, want %q", got, want)
+	}
+
+	for _, tt := range []struct{ method, target string }{
+		{http.MethodGet, "/prompts/missing/metadata"},
+		{http.MethodPost, "/prompts/team/greet/render?variant=casual"},
+	} {
+		if rec := serve(h, tt.method, tt.target, "", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, rec.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+/**
+ * Copyright 2026 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Dotprompt playground: a single-page UI over the dotprompthttp endpoints.
+// URLs are relative so that the handler can be mounted under any prefix.
+
+'use strict';
+
+const $ = (id) => document.getElementById(id);
+
+const state = {
+  // Prompt name -> sorted variant names, with '' for the default variant.
+  prompts: new Map(),
+  name: null,
+  variant: '',
+  schema: null,
+  renderSeq: 0,
+};
+
+/** Returns the URL of an endpoint of the prompt name. */
+function promptURL(name, endpoint, variant) {
+  const path = name.split('/').map(encodeURIComponent).join('/');
+  const query = variant ? `?variant=${encodeURIComponent(variant)}` : '';
+  return `prompts/${path}/${endpoint}${query}`;
+}
+
+/** Fetches JSON, throwing the server's error message on failure. */
+async function fetchJSON(url, options) {
+  const resp = await fetch(url, {
+    ...options,
+    headers: { Accept: 'application/json', ...(options && options.headers) },
+  });
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+function showError(err) {
+  $('error').textContent = err ? String(err.message || err) : '';
+}
+
+/** Loads every page of the prompt list. */
+async function loadPrompts() {
+  state.prompts.clear();
+  let cursor = '';
+  do {
+    const page = await fetchJSON(`prompts?cursor=${encodeURIComponent(cursor)}`);
+    for (const ref of page.prompts) {
+      if (!state.prompts.has(ref.name)) {
+        state.prompts.set(ref.name, []);
+      }
+      state.prompts.get(ref.name).push(ref.variant || '');
+    }
+    cursor = page.cursor || '';
+  } while (cursor);
+  for (const variants of state.prompts.values()) {
+    variants.sort();
+  }
+  renderPromptList();
+}
+
+function renderPromptList() {
+  const filter = $('filter').value.toLowerCase();
+  const nav = $('prompts');
+  nav.replaceChildren();
+  for (const name of [...state.prompts.keys()].sort()) {
+    if (filter && !name.toLowerCase().includes(filter)) {
+      continue;
+    }
+    const button = document.createElement('button');
+    button.type = 'button';
+    button.textContent = name;
+    button.setAttribute('aria-current', String(name === state.name));
+    button.addEventListener('click', () => selectPrompt(name));
+    nav.append(button);
+  }
+}
+
+function fillSelect(select, variants, selected) {
+  select.replaceChildren(
+    ...variants.map((variant) => {
+      const option = document.createElement('option');
+      option.value = variant;
+      option.textContent = variant || '(default)';
+      option.selected = variant === selected;
+      return option;
+    })
+  );
+}
+
+async function selectPrompt(name) {
+  state.name = name;
+  const variants = state.prompts.get(name) || [''];
+  state.variant = variants.includes('') ? '' : variants[0];
+  renderPromptList();
+  fillSelect($('variant'), variants, state.variant);
+  fillSelect(
+    $('compare'),
+    variants.filter((v) => v !== state.variant),
+    null
+  );
+  $('title').textContent = name;
+  $('editor').hidden = false;
+  $('output').hidden = false;
+  await loadMetadata();
+}
+
+async function loadMetadata() {
+  showError(null);
+  try {
+    const meta = await fetchJSON(promptURL(state.name, 'metadata', state.variant));
+    $('description').textContent = meta.description || '';
+    $('tab-metadata').textContent = JSON.stringify(meta, null, 2);
+    state.schema = (meta.input && meta.input.schema) || null;
+    buildForm(state.schema, (meta.input && meta.input.default) || {});
+  } catch (err) {
+    showError(err);
+    return;
+  }
+  scheduleRender();
+}
+
+/** Builds an input field for each top-level property of the schema. */
+function buildForm(schema, defaults) {
+  const form = $('inputs');
+  form.replaceChildren();
+  const properties = (schema && schema.properties) || {};
+  const required = new Set((schema && schema.required) || []);
+  for (const [key, prop] of Object.entries(properties)) {
+    const label = document.createElement('label');
+    const type = Array.isArray(prop.type) ? prop.type.find((t) => t !== 'null') : prop.type;
+    label.append(`${key}${required.has(key) ? ' *' : ''} `);
+    const hint = document.createElement('span');
+    hint.className = 'hint';
+    hint.textContent = [type, prop.description].filter(Boolean).join(' — ');
+    label.append(hint);
+
+    let field;
+    if (type === 'boolean') {
+      field = document.createElement('input');
+      field.type = 'checkbox';
+      field.checked = Boolean(defaults[key]);
+    } else if (type === 'object' || type === 'array') {
+      field = document.createElement('textarea');
+      field.rows = 3;
+      field.spellcheck = false;
+      field.value = key in defaults ? JSON.stringify(defaults[key], null, 2) : '';
+    } else {
+      field = document.createElement('input');
+      field.type = type === 'number' || type === 'integer' ? 'number' : 'text';
+      field.value = key in defaults ? defaults[key] : '';
+    }
+    field.name = key;
+    field.dataset.type = type || 'string';
+    label.append(field);
+    form.append(label);
+  }
+}
+
+/** Collects the form into the input object of a render request. */
+function collectInput() {
+  const input = {};
+  for (const field of $('inputs').elements) {
+    const type = field.dataset.type;
+    if (type === 'boolean') {
+      input[field.name] = field.checked;
+    } else if (field.value === '') {
+      continue;
+    } else if (type === 'number' || type === 'integer') {
+      input[field.name] = Number(field.value);
+    } else if (type === 'object' || type === 'array') {
+      input[field.name] = JSON.parse(field.value);
+    } else {
+      input[field.name] = field.value;
+    }
+  }
+  return input;
+}
+
+async function renderPrompt(variant) {
+  const body = JSON.stringify({
+    input: collectInput(),
+    context: JSON.parse($('context').value || '{}'),
+  });
+  return fetchJSON(promptURL(state.name, 'render', variant), {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body,
+  });
+}
+
+let renderTimer;
+function scheduleRender() {
+  clearTimeout(renderTimer);
+  renderTimer = setTimeout(updatePreview, 250);
+}
+
+/** Renders the selected variant, and the compared one, into the tabs. */
+async function updatePreview() {
+  const seq = ++state.renderSeq;
+  try {
+    const rendered = await renderPrompt(state.variant);
+    const compare = $('compare').value;
+    const other = $('compare').options.length ? await renderPrompt(compare) : null;
+    if (seq !== state.renderSeq) {
+      return;
+    }
+    showError(null);
+    showMessages(rendered.messages);
+    showDiff(messagesText(rendered.messages), other && messagesText(other.messages));
+  } catch (err) {
+    if (seq === state.renderSeq) {
+      showError(err);
+    }
+  }
+}
+
+function partText(part) {
+  if ('text' in part) {
+    return part.text;
+  }
+  if (part.media) {
+    return `<media ${part.media.url}>`;
+  }
+  return JSON.stringify(part);
+}
+
+function messagesText(messages) {
+  return messages
+    .map((m) => `[${m.role}]\n${m.content.map(partText).join('')}`)
+    .join('\n\n');
+}
+
+function showMessages(messages) {
+  $('tab-preview').replaceChildren(
+    ...messages.map((m) => {
+      const div = document.createElement('div');
+      div.className = 'message';
+      const role = document.createElement('div');
+      role.className = 'role';
+      role.textContent = m.role;
+      div.append(role, m.content.map(partText).join(''));
+      return div;
+    })
+  );
+}
+
+/** Shows a line diff of two texts, computed from their longest common subsequence. */
+function showDiff(a, b) {
+  const pre = $('diff');
+  if (b === null) {
+    pre.textContent = 'This prompt has no other variants.';
+    return;
+  }
+  const x = a.split('\n');
+  const y = b.split('\n');
+  const lcs = Array.from({ length: x.length + 1 }, () => new Array(y.length + 1).fill(0));
+  for (let i = x.length - 1; i >= 0; i--) {
+    for (let j = y.length - 1; j >= 0; j--) {
+      lcs[i][j] = x[i] === y[j] ? lcs[i + 1][j + 1] + 1 : Math.max(lcs[i + 1][j], lcs[i][j + 1]);
+    }
+  }
+  const lines = [];
+  const line = (cls, prefix, text) => {
+    const span = document.createElement('span');
+    span.className = cls;
+    span.textContent = `${prefix} ${text}\n`;
+    lines.push(span);
+  };
+  let i = 0;
+  let j = 0;
+  while (i < x.length || j < y.length) {
+    if (i < x.length && j < y.length && x[i] === y[j]) {
+      line('', ' ', x[i]);
+      i++;
+      j++;
+    } else if (j < y.length && (i === x.length || lcs[i][j + 1] >= lcs[i + 1][j])) {
+      line('added', '+', y[j++]);
+    } else {
+      line('removed', '-', x[i++]);
+    }
+  }
+  pre.replaceChildren(...lines);
+}
+
+function selectTab(tab) {
+  for (const button of document.querySelectorAll('.tabs button')) {
+    button.setAttribute('aria-selected', String(button.dataset.tab === tab));
+  }
+  for (const panel of document.querySelectorAll('.tab')) {
+    panel.hidden = panel.id !== `tab-${tab}`;
+  }
+}
+
+$('filter').addEventListener('input', renderPromptList);
+$('variant').addEventListener('change', (e) => {
+  state.variant = e.target.value;
+  const variants = state.prompts.get(state.name);
+  fillSelect(
+    $('compare'),
+    variants.filter((v) => v !== state.variant),
+    null
+  );
+  loadMetadata();
+});
+$('compare').addEventListener('change', scheduleRender);
+$('inputs').addEventListener('input', scheduleRender);
+$('context').addEventListener('input', scheduleRender);
+for (const button of document.querySelectorAll('.tabs button')) {
+  button.addEventListener('click', () => selectTab(button.dataset.tab));
+}
+
+loadPrompts().catch(showError);
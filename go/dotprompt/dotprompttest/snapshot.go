@@ -0,0 +1,196 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dotprompttest provides utilities for regression testing prompts
+// against golden snapshot files.
+//
+// A typical test renders a prompt for a set of fixtures and compares the
+// result against files checked into testdata:
+//
+//	func TestGreeting(t *testing.T) {
+//		dp := dotprompt.NewDotprompt(nil)
+//		dotprompttest.SnapshotRender(t, dp, source,
+//			dotprompttest.Case{Name: "basic", Data: dotprompt.DataArgument{
+//				Input: map[string]any{"name": "Ada"},
+//			}},
+//		)
+//	}
+//
+// Each case runs as a subtest named after it. Run
+// `go test -args -dotprompt.update` to create or refresh the golden files.
+package dotprompttest
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// The flag is namespaced so that it does not clash with the -update flag of
+// other golden-file helpers in the same test binary.
+var update = flag.Bool("dotprompt.update", false, "update dotprompt golden snapshot files")
+
+// SnapshotDir is the directory, relative to the package under test, in which
+// golden files are stored.
+var SnapshotDir = filepath.Join("testdata", "snapshots")
+
+// Case is a single fixture rendered by SnapshotRender.
+type Case struct {
+	// Name identifies the golden file for this case.
+	Name string
+	// Data is passed to the prompt function.
+	Data dp.DataArgument
+	// Options are passed to the prompt function.
+	Options *dp.PromptMetadata
+}
+
+// Snapshot is the normalized, serializable form of a rendered prompt that is
+// stored in golden files.
+type Snapshot struct {
+	Model    string                  `json:"model,omitempty"`
+	Config   dp.ModelConfig          `json:"config,omitempty"`
+	Output   dp.PromptMetadataOutput `json:"output,omitzero"`
+	Tools    []string                `json:"tools,omitempty"`
+	Messages []dp.Message            `json:"messages"`
+}
+
+// SnapshotRender compiles source with dotprompt and renders each case,
+// comparing the normalized result with testdata/snapshots/<test>/<case>.golden.
+// When the -dotprompt.update flag is set the golden files are rewritten
+// instead. Cases run as subtests when t is a *testing.T.
+func SnapshotRender(t testing.TB, dotprompt *dp.Dotprompt, source string, cases ...Case) {
+	t.Helper()
+
+	render, err := dotprompt.Compile(source, nil)
+	if err != nil {
+		t.Fatalf("dotprompttest: failed to compile prompt: %v", err)
+	}
+
+	dir := goldenDir(t)
+	for _, c := range cases {
+		if tt, ok := t.(*testing.T); ok {
+			tt.Run(c.Name, func(t *testing.T) {
+				t.Helper()
+				snapshotCase(t, render, dir, c)
+			})
+		} else {
+			snapshotCase(t, render, dir, c)
+		}
+	}
+}
+
+// snapshotCase renders c and compares it with, or writes it to, its golden
+// file in dir.
+func snapshotCase(t testing.TB, render dp.PromptFunction, dir string, c Case) {
+	t.Helper()
+
+	data := c.Data
+	rendered, err := render(&data, c.Options)
+	if err != nil {
+		t.Errorf("dotprompttest: case %q: render failed: %v", c.Name, err)
+		return
+	}
+
+	got, err := MarshalSnapshot(rendered)
+	if err != nil {
+		t.Errorf("dotprompttest: case %q: %v", c.Name, err)
+		return
+	}
+
+	path := filepath.Join(dir, sanitize(c.Name)+".golden")
+	if *update {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("dotprompttest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("dotprompttest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("dotprompttest: case %q: missing golden file %s (run with -dotprompt.update): %v", c.Name, path, err)
+		return
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("dotprompttest: case %q: snapshot mismatch (-want +got):\n%s", c.Name, diff)
+	}
+}
+
+// MarshalSnapshot converts a rendered prompt into the normalized JSON stored in
+// golden files.
+func MarshalSnapshot(rendered dp.RenderedPrompt) ([]byte, error) {
+	snapshot := Snapshot{
+		Model:    rendered.Model,
+		Config:   rendered.Config,
+		Output:   rendered.Output,
+		Tools:    rendered.Tools,
+		Messages: make([]dp.Message, len(rendered.Messages)),
+	}
+	for i, msg := range rendered.Messages {
+		content := make([]dp.Part, len(msg.Content))
+		for j, part := range msg.Content {
+			if textPart, ok := part.(*dp.TextPart); ok {
+				part = &dp.TextPart{HasMetadata: textPart.HasMetadata, Text: NormalizeWhitespace(textPart.Text)}
+			}
+			content[j] = part
+		}
+		msg.Content = content
+		snapshot.Messages[i] = msg
+	}
+
+	b, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+var blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+
+// NormalizeWhitespace makes rendered text stable across insignificant edits:
+// line endings are converted to \n, trailing whitespace is removed from each
+// line, runs of blank lines are collapsed to one, and the result is trimmed.
+func NormalizeWhitespace(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text = blankLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// goldenDir returns the directory of the golden files of the running test.
+func goldenDir(t testing.TB) string {
+	return filepath.Join(SnapshotDir, sanitize(t.Name()))
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._\-]+`)
+
+// sanitize makes a test or case name safe to use as a path element.
+func sanitize(name string) string {
+	return unsafePathChars.ReplaceAllString(name, "_")
+}
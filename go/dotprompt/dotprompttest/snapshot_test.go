@@ -0,0 +1,87 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompttest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+const greetingSource = `---
+model: test/model
+config:
+  temperature: 0.2
+---
+{{role "system"}}
+You are a friendly assistant.   
+
+
+{{role "user"}}
+Say hello to {{name}}.
+`
+
+func TestSnapshotRender(t *testing.T) {
+	SnapshotRender(t, dp.NewDotprompt(nil), greetingSource,
+		Case{Name: "ada", Data: dp.DataArgument{Input: map[string]any{"name": "Ada"}}},
+		Case{Name: "model override", Data: dp.DataArgument{Input: map[string]any{"name": "Grace"}},
+			Options: &dp.PromptMetadata{Model: "test/other"}},
+	)
+}
+
+func TestSnapshotRenderUpdate(t *testing.T) {
+	if flag.Lookup("dotprompt.update") == nil || flag.Lookup("update") != nil {
+		t.Fatal("want the update flag registered as -dotprompt.update only")
+	}
+	oldDir, oldUpdate := SnapshotDir, *update
+	defer func() { SnapshotDir, *update = oldDir, oldUpdate }()
+	SnapshotDir, *update = t.TempDir(), true
+
+	SnapshotRender(t, dp.NewDotprompt(nil), greetingSource,
+		Case{Name: "ada", Data: dp.DataArgument{Input: map[string]any{"name": "Ada"}}})
+
+	got, err := os.ReadFile(filepath.Join(SnapshotDir, "TestSnapshotRenderUpdate", "ada.golden"))
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join(oldDir, "TestSnapshotRender", "ada.golden"))
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("updated golden file = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"hello  \r\nworld\t", "hello\nworld"},
+		{"\n\na\n\n\n\nb\n\n", "a\n\nb"},
+		{"unchanged", "unchanged"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeWhitespace(tt.in); got != tt.want {
+			t.Errorf("NormalizeWhitespace(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+// utf8BOM is the UTF-8 encoding of the byte order mark U+FEFF, which some
+// editors, notably on Windows, write at the start of text files.
+const utf8BOM = "\uFEFF"
+
+// NormalizeSourceEncoding returns source as UTF-8 without a byte order mark.
+// A leading UTF-8 byte order mark is removed, and a source that starts with a
+// UTF-16 byte order mark is converted from UTF-16. A source that looks like
+// UTF-16 without a byte order mark is an error, as its byte order cannot be
+// known for certain. Other sources are returned unchanged.
+//
+// ParseDocument normalizes the sources it is given, so that such files do not
+// silently lose their frontmatter.
+func NormalizeSourceEncoding(source string) (string, error) {
+	var order binary.ByteOrder
+	switch {
+	case strings.HasPrefix(source, utf8BOM):
+		return source[len(utf8BOM):], nil
+	case strings.HasPrefix(source, "\xFF\xFE"):
+		order = binary.LittleEndian
+	case strings.HasPrefix(source, "\xFE\xFF"):
+		order = binary.BigEndian
+	default:
+		if looksLikeUTF16(source) {
+			return "", errors.New("prompt source appears to be UTF-16 without a byte order mark; save it as UTF-8")
+		}
+		return source, nil
+	}
+
+	data := source[2:]
+	if len(data)%2 != 0 {
+		return "", errors.New("invalid UTF-16 prompt source: odd number of bytes")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16([]byte(data[2*i : 2*i+2]))
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// looksLikeUTF16 reports whether source starts with two ASCII characters
+// encoded as UTF-16, which text meant as UTF-8 never does as it has no NUL
+// bytes.
+func looksLikeUTF16(source string) bool {
+	if len(source) < 4 {
+		return false
+	}
+	le := source[0] != 0 && source[1] == 0 && source[2] != 0 && source[3] == 0
+	be := source[0] == 0 && source[1] != 0 && source[2] == 0 && source[3] != 0
+	return le || be
+}
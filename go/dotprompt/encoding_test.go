@@ -0,0 +1,90 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encodes s as UTF-16 in the given byte order, after bom.
+func encodeUTF16(s string, order binary.AppendByteOrder, bom string) string {
+	b := []byte(bom)
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = order.AppendUint16(b, u)
+	}
+	return string(b)
+}
+
+func TestNormalizeSourceEncoding(t *testing.T) {
+	const text = "---\nmodel: a/b\n---\nHëllo 👋"
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr string
+	}{
+		{name: "utf-8", source: text, want: text},
+		{name: "utf-8 bom", source: "\uFEFF" + text, want: text},
+		{name: "utf-16le bom", source: encodeUTF16(text, binary.LittleEndian, "\xFF\xFE"), want: text},
+		{name: "utf-16be bom", source: encodeUTF16(text, binary.BigEndian, "\xFE\xFF"), want: text},
+		{name: "utf-16 without bom", source: encodeUTF16(text, binary.LittleEndian, ""), wantErr: "UTF-16 without a byte order mark"},
+		{name: "odd utf-16", source: "\xFF\xFE-\x00-", wantErr: "odd number of bytes"},
+		{name: "empty", source: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeSourceEncoding(tt.source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NormalizeSourceEncoding() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeSourceEncoding() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeSourceEncoding() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDocumentBOM(t *testing.T) {
+	for _, source := range []string{
+		"\uFEFF---\nmodel: a/b\n---\nHello",
+		encodeUTF16("---\r\nmodel: a/b\r\n---\r\nHello", binary.LittleEndian, "\xFF\xFE"),
+	} {
+		parsed, err := ParseDocument(source)
+		if err != nil {
+			t.Fatalf("ParseDocument() returned error: %v", err)
+		}
+		if parsed.Model != "a/b" || parsed.Template != "Hello" {
+			t.Errorf("ParseDocument(%q) = model %q, template %q, want a/b and Hello", source, parsed.Model, parsed.Template)
+		}
+	}
+
+	if _, _, _, ok := FrontmatterBounds("\uFEFF---\nmodel: a/b\n---\nHello"); !ok {
+		t.Error("FrontmatterBounds() did not find frontmatter after a byte order mark")
+	}
+	if _, err := ParseDocument(encodeUTF16("Hello", binary.BigEndian, "")); err == nil {
+		t.Error("ParseDocument() accepted UTF-16 without a byte order mark")
+	}
+}
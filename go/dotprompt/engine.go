@@ -0,0 +1,81 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"github.com/mbleigh/raymond"
+)
+
+// TemplateEngine parses prompt templates. RaymondEngine is the default.
+//
+// The built-in helpers, and the helpers that tracing installs, follow
+// raymond's calling convention: block helpers and hash arguments are received
+// through a trailing *raymond.Options. An engine with a different convention
+// must adapt them in RegisterHelper.
+type TemplateEngine interface {
+	Parse(source string) (EngineTemplate, error)
+}
+
+// TemplateRegistrar is the part of a parsed template that helpers and
+// partials are registered with. *raymond.Template implements it.
+type TemplateRegistrar interface {
+	RegisterHelper(name string, helper any)
+	RegisterPartial(name string, source string)
+}
+
+// EngineTemplate is a template parsed by a TemplateEngine. Helpers and
+// partials registered with it are only visible to it.
+type EngineTemplate interface {
+	TemplateRegistrar
+	// Exec renders the template with input as the root context and data as
+	// the `@` data variables. Output must not be HTML-escaped.
+	Exec(input map[string]any, data map[string]any) (string, error)
+}
+
+// RaymondEngine is the TemplateEngine backed by github.com/mbleigh/raymond.
+type RaymondEngine struct{}
+
+// Parse implements TemplateEngine.
+func (RaymondEngine) Parse(source string) (EngineTemplate, error) {
+	tpl, err := raymond.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return &raymondTemplate{tpl}, nil
+}
+
+// engineOrDefault returns the engine of dp, or RaymondEngine if it has none.
+func (dp *Dotprompt) engineOrDefault() TemplateEngine {
+	if dp.engine == nil {
+		return RaymondEngine{}
+	}
+	return dp.engine
+}
+
+// raymondTemplate adapts a raymond.Template to EngineTemplate.
+type raymondTemplate struct {
+	*raymond.Template
+}
+
+// Exec implements EngineTemplate.
+func (t *raymondTemplate) Exec(input map[string]any, data map[string]any) (string, error) {
+	privDF := raymond.NewDataFrame()
+	for k, v := range data {
+		privDF.Set(k, v)
+	}
+	return t.Template.ExecWith(input, privDF, &raymond.ExecOptions{NoEscape: true})
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// upperEngine wraps RaymondEngine, recording registrations and upper-casing
+// the output.
+type upperEngine struct {
+	helpers  []string
+	partials []string
+}
+
+func (e *upperEngine) Parse(source string) (EngineTemplate, error) {
+	tpl, err := RaymondEngine{}.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return &upperTemplate{EngineTemplate: tpl, engine: e}, nil
+}
+
+type upperTemplate struct {
+	EngineTemplate
+	engine *upperEngine
+}
+
+func (t *upperTemplate) RegisterHelper(name string, helper any) {
+	t.engine.helpers = append(t.engine.helpers, name)
+	t.EngineTemplate.RegisterHelper(name, helper)
+}
+
+func (t *upperTemplate) RegisterPartial(name, source string) {
+	t.engine.partials = append(t.engine.partials, name)
+	t.EngineTemplate.RegisterPartial(name, source)
+}
+
+func (t *upperTemplate) Exec(input, data map[string]any) (string, error) {
+	out, err := t.EngineTemplate.Exec(input, data)
+	return strings.ToUpper(out), err
+}
+
+func TestCustomEngine(t *testing.T) {
+	engine := &upperEngine{}
+	dp := NewDotprompt(&DotpromptOptions{
+		Engine:   engine,
+		Partials: map[string]string{"sig": "-- {{@state.author}}"},
+	})
+	rendered, err := dp.Render("Hi {{name}} {{> sig}}", &DataArgument{
+		Input:   map[string]any{"name": "<Ada>"},
+		Context: map[string]any{"state": map[string]any{"author": "bob"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != "HI <ADA> -- BOB" {
+		t.Errorf("Render() text = %q, want %q", got, "HI <ADA> -- BOB")
+	}
+	if diff := cmp.Diff([]string{"sig"}, engine.partials); diff != "" {
+		t.Errorf("registered partials mismatch (-want +got):\n%s", diff)
+	}
+	if !strings.Contains(strings.Join(engine.helpers, ","), "json") {
+		t.Errorf("registered helpers = %v, want the built-in helpers", engine.helpers)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// envRefRe matches the environment variable references expanded in
+// frontmatter values, `${NAME}` and `${NAME:-default}`, and the escape `$${`.
+var envRefRe = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv expands the environment variable references in the model, config
+// and extension fields of meta. It does nothing unless an allowlist is set;
+// referencing a variable outside it, or an unset variable without a default,
+// is an error.
+func (dp *Dotprompt) expandEnv(meta PromptMetadata) (PromptMetadata, error) {
+	if dp.envAllowlist == nil {
+		return meta, nil
+	}
+	model, ok, err := dp.expandEnvString(meta.Model)
+	if err != nil {
+		return PromptMetadata{}, err
+	}
+	if ok {
+		meta.Model = model
+	}
+	return rewriteMetadataStrings(meta, func(s string) (any, bool, error) {
+		return dp.expandEnvString(s)
+	})
+}
+
+// expandEnvString expands the references in s and reports whether it had
+// any.
+func (dp *Dotprompt) expandEnvString(s string) (string, bool, error) {
+	matches := envRefRe.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s, false, nil
+	}
+	var out []byte
+	last := 0
+	for _, m := range matches {
+		out = append(out, s[last:m[0]]...)
+		last = m[1]
+		if m[2] < 0 {
+			out = append(out, "${"...)
+			continue
+		}
+		name := s[m[2]:m[3]]
+		if !slices.Contains(dp.envAllowlist, name) {
+			return "", false, fmt.Errorf("dotprompt: environment variable %s is not in the allowlist", name)
+		}
+		value, set := os.LookupEnv(name)
+		if value == "" && m[4] >= 0 {
+			value, set = s[m[4]:m[5]], true
+		}
+		if !set {
+			return "", false, fmt.Errorf("dotprompt: environment variable %s is not set", name)
+		}
+		out = append(out, value...)
+	}
+	out = append(out, s[last:]...)
+	return string(out), true, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnvExpansion(t *testing.T) {
+	t.Setenv("DOTPROMPT_TEST_MODEL", "gemini-pro")
+	t.Setenv("DOTPROMPT_TEST_PROJECT", "my-project")
+	t.Setenv("DOTPROMPT_TEST_EMPTY", "")
+
+	source := `---
+model: vertexai/${DOTPROMPT_TEST_MODEL}
+config:
+  endpoint: https://${DOTPROMPT_TEST_REGION:-us-central1}.example.com/${DOTPROMPT_TEST_PROJECT}
+  labels: [a, "${DOTPROMPT_TEST_EMPTY:-none}"]
+  literal: $${DOTPROMPT_TEST_PROJECT}
+---
+Hello`
+	dp := New(WithEnvAllowlist("DOTPROMPT_TEST_MODEL", "DOTPROMPT_TEST_PROJECT", "DOTPROMPT_TEST_REGION", "DOTPROMPT_TEST_EMPTY"))
+	rendered, err := dp.Render(source, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got, want := rendered.Model, "vertexai/gemini-pro"; got != want {
+		t.Errorf("model = %q, want %q", got, want)
+	}
+	want := ModelConfig{
+		"endpoint": "https://us-central1.example.com/my-project",
+		"labels":   []any{"a", "none"},
+		"literal":  "${DOTPROMPT_TEST_PROJECT}",
+	}
+	if diff := cmp.Diff(want, rendered.Config); diff != "" {
+		t.Errorf("config mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEnvExpansionErrors(t *testing.T) {
+	t.Setenv("DOTPROMPT_TEST_SECRET", "s3cret")
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "not allowed",
+			source: "---\nconfig:\n  key: ${DOTPROMPT_TEST_SECRET}\n---\n",
+			want:   "DOTPROMPT_TEST_SECRET is not in the allowlist",
+		},
+		{
+			name:   "unset",
+			source: "---\nmodel: ${DOTPROMPT_TEST_UNSET}\n---\n",
+			want:   "DOTPROMPT_TEST_UNSET is not set",
+		},
+	}
+	dp := New(WithEnvAllowlist("DOTPROMPT_TEST_UNSET"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dp.Render(tt.source, &DataArgument{}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Render() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvExpansionDisabled(t *testing.T) {
+	t.Setenv("DOTPROMPT_TEST_MODEL", "gemini-pro")
+	rendered, err := NewDotprompt(nil).Render("---\nmodel: ${DOTPROMPT_TEST_MODEL}\n---\n", &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got, want := rendered.Model, "${DOTPROMPT_TEST_MODEL}"; got != want {
+		t.Errorf("model = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// exampleMetadataPurpose is the purpose in the metadata of example messages.
+const exampleMetadataPurpose = "example"
+
+// Example is a few-shot example declared in the `examples` list of the
+// frontmatter:
+//
+//	examples:
+//	  - input: What is the capital of France?
+//	    output: Paris
+//	  - input: {city: Tokyo}
+//	    output: {country: Japan}
+//
+// Each example renders as a user message holding Input followed by a model
+// message holding Output. Strings are used as they are; other values are
+// rendered as JSON.
+type Example struct {
+	Input  any `json:"input"`
+	Output any `json:"output"`
+}
+
+// ExampleMessages returns the alternating user and model messages of
+// examples. Their metadata has the purpose "example".
+func ExampleMessages(examples []Example) ([]Message, error) {
+	if len(examples) == 0 {
+		return nil, nil
+	}
+	messages := make([]Message, 0, 2*len(examples))
+	for i, ex := range examples {
+		input, err := exampleText(ex.Input)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: invalid input: %w", i, err)
+		}
+		output, err := exampleText(ex.Output)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: invalid output: %w", i, err)
+		}
+		messages = append(messages,
+			exampleMessage(RoleUser, input),
+			exampleMessage(RoleModel, output))
+	}
+	return messages, nil
+}
+
+// exampleMessage returns an example message holding text.
+func exampleMessage(role Role, text string) Message {
+	return Message{
+		Role:        role,
+		Content:     []Part{&TextPart{Text: text}},
+		HasMetadata: HasMetadata{Metadata: Metadata{"purpose": exampleMetadataPurpose}},
+	}
+}
+
+// exampleText returns the text of an example input or output.
+func exampleText(value any) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parseExamples returns the examples of an `examples` frontmatter value. A
+// value that is not a list is ignored, as for the other reserved keys, but
+// each item of a list must be a mapping with `input` and `output` keys.
+func parseExamples(value any) ([]Example, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, nil
+	}
+	examples := make([]Example, 0, len(list))
+	for i, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid frontmatter \"examples\": item %d is %T, not a mapping", i, item)
+		}
+		for _, key := range []string{"input", "output"} {
+			if _, ok := m[key]; !ok {
+				return nil, fmt.Errorf("invalid frontmatter \"examples\": item %d has no %s", i, key)
+			}
+		}
+		examples = append(examples, Example{Input: m["input"], Output: m["output"]})
+	}
+	return examples, nil
+}
+
+// insertExamples inserts the example messages before the first message that
+// is not a system message, or at the end if there is none.
+func insertExamples(messages, examples []Message) []Message {
+	if len(examples) == 0 {
+		return messages
+	}
+	i := slices.IndexFunc(messages, func(m Message) bool { return m.Role != RoleSystem })
+	if i < 0 {
+		i = len(messages)
+	}
+	return slices.Insert(messages, i, examples...)
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const examplesFrontmatter = `---
+examples:
+  - input: I love it
+    output: POSITIVE
+  - input: {text: meh}
+    output: [NEUTRAL]
+---
+`
+
+func exampleTurn(role Role, text string) Message {
+	msg := textMessage(role, text)
+	msg.Metadata = Metadata{"purpose": "example"}
+	return msg
+}
+
+func TestExamples(t *testing.T) {
+	examples := []Message{
+		exampleTurn(RoleUser, "I love it"),
+		exampleTurn(RoleModel, "POSITIVE"),
+		exampleTurn(RoleUser, `{"text":"meh"}`),
+		exampleTurn(RoleModel, `["NEUTRAL"]`),
+	}
+	tests := []struct {
+		name string
+		body string
+		want []Message
+	}{
+		{
+			name: "automatic placement",
+			body: `{{role "system"}}Classify sentiment.{{role "user"}}{{text}}`,
+			want: append(append([]Message{textMessage(RoleSystem, "Classify sentiment.")},
+				examples...), textMessage(RoleUser, "awful")),
+		},
+		{
+			name: "automatic placement without system message",
+			body: `{{text}}`,
+			want: append(append([]Message{}, examples...), textMessage(RoleUser, "awful")),
+		},
+		{
+			name: "marker",
+			body: "Classify sentiment.\n{{examples}}\nNow classify: {{text}}",
+			want: append(append([]Message{textMessage(RoleUser, "Classify sentiment.\n")},
+				examples...), textMessage(RoleUser, "\nNow classify: awful")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(nil)
+			rendered, err := dp.Render(examplesFrontmatter+tt.body, &DataArgument{Input: map[string]any{"text": "awful"}}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rendered.Messages); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExamplesMetadata(t *testing.T) {
+	parsed, err := ParseDocument(examplesFrontmatter + "{{text}}")
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	want := []Example{
+		{Input: "I love it", Output: "POSITIVE"},
+		{Input: map[string]any{"text": "meh"}, Output: []any{"NEUTRAL"}},
+	}
+	if diff := cmp.Diff(want, parsed.Examples); diff != "" {
+		t.Errorf("ParseDocument() mismatch (-want +got):\n%s", diff)
+	}
+
+	dp := NewDotprompt(nil)
+	rendered, err := dp.Render(examplesFrontmatter+"{{text}}", &DataArgument{}, &PromptMetadata{
+		Examples: []Example{{Input: "a", Output: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got := len(rendered.Messages); got != 2 {
+		t.Errorf("Render() with overriding examples returned %d messages, want 2", got)
+	}
+}
+
+func TestExamplesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{
+			name:    "not a mapping",
+			source:  "---\nexamples: [hello]\n---\n",
+			wantErr: "item 0 is string, not a mapping",
+		},
+		{
+			name:    "missing output",
+			source:  "---\nexamples:\n  - input: a\n    output: b\n  - input: c\n---\n",
+			wantErr: "item 1 has no output",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDocument(tt.source)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseDocument() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// ExtAs decodes the extension fields of namespace into out, which must be a
+// pointer to a struct or map. Struct fields are matched using `mapstructure`
+// tags, or case-insensitively by field name, and strings such as "1h30m" are
+// decoded into time.Duration fields. For example, with
+//
+//	myorg.routing.region: eu
+//	myorg.routing.weight: 3
+//
+// in the frontmatter, ExtAs("myorg.routing", &cfg) fills a struct with Region
+// and Weight fields, and ExtAs("myorg", &cfg) one with a Routing field
+// holding them. If the namespace is absent, out is left unchanged and ExtAs
+// returns nil.
+func (m *PromptMetadata) ExtAs(namespace string, out any) error {
+	fields, ok := m.ExtValue(namespace)
+	if !ok {
+		return nil
+	}
+	if err := decodeFields(fields, out); err != nil {
+		return fmt.Errorf("decoding ext namespace %q: %w", namespace, err)
+	}
+	return nil
+}
+
+// decodeFields decodes frontmatter fields into out as ExtAs does.
+func decodeFields(fields, out any) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           out,
+		WeaklyTypedInput: true,
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(fields)
+}
+
+// ExtTree returns the extension fields nested at every dot of their keys, so
+// that `myorg.team.feature.flag: true` in the frontmatter becomes
+// {"myorg": {"team": {"feature": {"flag": true}}}}. Ext itself only splits
+// keys at their last dot, as the Dotprompt spec requires.
+//
+// A key that is also a prefix of longer keys, as in `a.b: 1` alongside
+// `a.b.c: 2`, holds the map of the longer keys.
+func (m *PromptMetadata) ExtTree() map[string]any {
+	namespaces := make([]string, 0, len(m.Ext))
+	for ns := range m.Ext {
+		namespaces = append(namespaces, ns)
+	}
+	// Fill shallower namespaces first so that deeper ones replace the values
+	// of their prefixes with maps.
+	slices.SortFunc(namespaces, func(a, b string) int {
+		if d := strings.Count(a, ".") - strings.Count(b, "."); d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+
+	tree := make(map[string]any)
+	for _, ns := range namespaces {
+		node := tree
+		for _, segment := range strings.Split(ns, ".") {
+			child, ok := node[segment].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				node[segment] = child
+			}
+			node = child
+		}
+		for field, value := range m.Ext[ns] {
+			if _, ok := node[field].(map[string]any); !ok {
+				node[field] = value
+			}
+		}
+	}
+	return tree
+}
+
+// ExtValue returns the extension field with the dotted key path, such as
+// "myorg.team.feature.flag", or, if path is a prefix of longer keys such as
+// "myorg.team", the fields under it nested as by ExtTree. It reports whether
+// path was found.
+func (m *PromptMetadata) ExtValue(path string) (any, bool) {
+	var node any = m.ExtTree()
+	for _, segment := range strings.Split(path, ".") {
+		fields, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = fields[segment]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
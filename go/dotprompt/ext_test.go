@@ -0,0 +1,134 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtAs(t *testing.T) {
+	type routing struct {
+		Region  string   `mapstructure:"region"`
+		Weight  int      `mapstructure:"weight"`
+		Targets []string `mapstructure:"targets"`
+		Enabled bool
+	}
+
+	parsed, err := ParseDocument(`---
+myorg.routing.region: eu
+myorg.routing.weight: "3"
+myorg.routing.targets: [a, b]
+myorg.routing.enabled: true
+other.flag: 1
+---
+Hello`)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+
+	var got routing
+	if err := parsed.ExtAs("myorg.routing", &got); err != nil {
+		t.Fatalf("ExtAs() returned error: %v", err)
+	}
+	want := routing{Region: "eu", Weight: 3, Targets: []string{"a", "b"}, Enabled: true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExtAs() mismatch (-want +got):\n%s", diff)
+	}
+
+	missing := routing{Region: "default"}
+	if err := parsed.ExtAs("absent", &missing); err != nil {
+		t.Fatalf("ExtAs() returned error for absent namespace: %v", err)
+	}
+	if missing.Region != "default" {
+		t.Errorf("ExtAs() modified out for absent namespace: %+v", missing)
+	}
+
+	var bad struct {
+		Flag []map[string]int `mapstructure:"flag"`
+	}
+	err = parsed.ExtAs("other", &bad)
+	if err == nil || !strings.Contains(err.Error(), `ext namespace "other"`) {
+		t.Errorf("ExtAs() error = %v, want a decoding error", err)
+	}
+}
+
+func TestExtTree(t *testing.T) {
+	parsed, err := ParseDocument(`---
+myorg.team.feature.flag: true
+myorg.team.feature.limit: 3
+myorg.team.name: core
+myorg.region: eu
+a.b: 1
+a.b.c: 2
+---
+Hello`)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+
+	want := map[string]any{
+		"myorg": map[string]any{
+			"region": "eu",
+			"team": map[string]any{
+				"name":    "core",
+				"feature": map[string]any{"flag": true, "limit": uint64(3)},
+			},
+		},
+		"a": map[string]any{"b": map[string]any{"c": uint64(2)}},
+	}
+	if diff := cmp.Diff(want, parsed.ExtTree()); diff != "" {
+		t.Errorf("ExtTree() mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		path   string
+		want   any
+		wantOK bool
+	}{
+		{"myorg.team.feature.flag", true, true},
+		{"myorg.team.feature", map[string]any{"flag": true, "limit": uint64(3)}, true},
+		{"myorg.team.missing", nil, false},
+		{"myorg.region.sub", nil, false},
+		{"absent", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := parsed.ExtValue(tt.path)
+		if ok != tt.wantOK {
+			t.Errorf("ExtValue(%q) ok = %v, want %v", tt.path, ok, tt.wantOK)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("ExtValue(%q) mismatch (-want +got):\n%s", tt.path, diff)
+		}
+	}
+
+	var team struct {
+		Name    string
+		Feature struct {
+			Flag  bool
+			Limit int
+		}
+	}
+	if err := parsed.ExtAs("myorg.team", &team); err != nil {
+		t.Fatalf("ExtAs() returned error: %v", err)
+	}
+	if team.Name != "core" || !team.Feature.Flag || team.Feature.Limit != 3 {
+		t.Errorf("ExtAs() = %+v, want nested fields decoded", team)
+	}
+}
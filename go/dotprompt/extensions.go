@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+)
+
+// MetadataParser converts the frontmatter value of a metadata extension into
+// the value stored in PromptMetadata.Extensions.
+type MetadataParser func(value any) (any, error)
+
+// metadataExtension is a registered top-level frontmatter key.
+type metadataExtension struct {
+	schema *jsonschema.Schema
+	parse  MetadataParser
+}
+
+var (
+	metadataExtensionsMu sync.RWMutex
+	metadataExtensions   = make(map[string]metadataExtension)
+)
+
+// RegisterMetadataExtension lets a plugin claim a top-level frontmatter key.
+// When a prompt is parsed, the value of the key is validated against schema,
+// which may be Picoschema, a JSON Schema map or a *jsonschema.Schema, then
+// passed through parse and stored in PromptMetadata.Extensions. Either may be
+// nil. A value that fails validation or parsing makes the parse fail. The
+// value is also kept in PromptMetadata.Raw, as for any other key.
+//
+// Keys reserved by the specification and keys containing a period, which are
+// extension namespaces, cannot be registered.
+func RegisterMetadataExtension(key string, schema Schema, parse MetadataParser) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("metadata extension key cannot be empty")
+	case slices.Contains(ReservedMetadataKeywords, key):
+		return fmt.Errorf("metadata extension key %q is reserved", key)
+	case strings.Contains(key, "."):
+		return fmt.Errorf("metadata extension key %q cannot contain a period", key)
+	}
+
+	ext := metadataExtension{parse: parse}
+	switch s := schema.(type) {
+	case nil:
+	case *jsonschema.Schema:
+		ext.schema = s
+	default:
+		var err error
+		if ext.schema, err = Picoschema(s, &PicoschemaOptions{}); err != nil {
+			return fmt.Errorf("invalid schema for metadata extension %q: %w", key, err)
+		}
+	}
+
+	metadataExtensionsMu.Lock()
+	defer metadataExtensionsMu.Unlock()
+	if _, ok := metadataExtensions[key]; ok {
+		return fmt.Errorf("metadata extension %q is already registered", key)
+	}
+	metadataExtensions[key] = ext
+	return nil
+}
+
+// UnregisterMetadataExtension removes a key registered with
+// RegisterMetadataExtension.
+func UnregisterMetadataExtension(key string) {
+	metadataExtensionsMu.Lock()
+	defer metadataExtensionsMu.Unlock()
+	delete(metadataExtensions, key)
+}
+
+// isMetadataExtension reports whether key is registered with
+// RegisterMetadataExtension.
+func isMetadataExtension(key string) bool {
+	metadataExtensionsMu.RLock()
+	defer metadataExtensionsMu.RUnlock()
+	_, ok := metadataExtensions[key]
+	return ok
+}
+
+// parseMetadataExtension validates and parses the value of key if it is a
+// registered extension. It reports false if the key is not registered.
+func parseMetadataExtension(key string, value any) (any, bool, error) {
+	metadataExtensionsMu.RLock()
+	ext, ok := metadataExtensions[key]
+	metadataExtensionsMu.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+	if err := ValidateValue(ext.schema, value); err != nil {
+		return nil, true, fmt.Errorf("invalid frontmatter %q: %w", key, err)
+	}
+	if ext.parse == nil {
+		return value, true, nil
+	}
+	parsed, err := ext.parse(value)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid frontmatter %q: %w", key, err)
+	}
+	return parsed, true, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegisterMetadataExtension(t *testing.T) {
+	type cacheConfig struct {
+		TTL time.Duration
+	}
+	err := RegisterMetadataExtension("cache", map[string]any{"ttl": "string"}, func(value any) (any, error) {
+		ttl, err := time.ParseDuration(value.(map[string]any)["ttl"].(string))
+		if err != nil {
+			return nil, err
+		}
+		return cacheConfig{TTL: ttl}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterMetadataExtension() returned error: %v", err)
+	}
+	t.Cleanup(func() { UnregisterMetadataExtension("cache") })
+
+	parsed, err := ParseDocument("---\ncache:\n  ttl: 5m\nunknown: 1\n---\nHi")
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	want := map[string]any{"cache": cacheConfig{TTL: 5 * time.Minute}}
+	if diff := cmp.Diff(want, parsed.Extensions); diff != "" {
+		t.Errorf("Extensions mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := parsed.Raw["cache"]; !ok {
+		t.Error("Raw does not contain the extension key")
+	}
+
+	for _, source := range []string{
+		"---\ncache:\n  ttl: 5\n---\nHi",
+		"---\ncache:\n  ttl: soon\n---\nHi",
+	} {
+		if _, err := ParseDocument(source); err == nil || !strings.Contains(err.Error(), `frontmatter "cache"`) {
+			t.Errorf("ParseDocument(%q) error = %v, want an invalid cache error", source, err)
+		}
+	}
+
+	if err := RegisterMetadataExtension("cache", nil, nil); err == nil {
+		t.Error("RegisterMetadataExtension() succeeded for a duplicate key")
+	}
+}
+
+func TestRegisterMetadataExtensionRejectsKeys(t *testing.T) {
+	for _, key := range []string{"", "model", "myorg.cache"} {
+		if err := RegisterMetadataExtension(key, nil, nil); err == nil {
+			UnregisterMetadataExtension(key)
+			t.Errorf("RegisterMetadataExtension(%q) succeeded, want error", key)
+		}
+	}
+}
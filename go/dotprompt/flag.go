@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/mbleigh/raymond"
+)
+
+// flagsDataKey is the entry of DataArgument.Context holding the attributes
+// flags are evaluated with.
+const flagsDataKey = "flags"
+
+// FlagProvider evaluates the feature flags of the `flag` helper.
+type FlagProvider interface {
+	// Flag reports whether the flag named name is on for a render with the
+	// evaluation attributes attrs, such as the key of the user it is for.
+	Flag(name string, attrs map[string]any) (bool, error)
+}
+
+// FlagFunc adapts a function to the FlagProvider interface.
+type FlagFunc func(name string, attrs map[string]any) (bool, error)
+
+// Flag calls f.
+func (f FlagFunc) Flag(name string, attrs map[string]any) (bool, error) {
+	return f(name, attrs)
+}
+
+// NewFlagHelper returns the `flag` helper, which reports whether a feature
+// flag is on so that prompt copy can be gated on it:
+//
+//	{{#if (flag "new-tone")}}Be playful.{{else}}Be formal.{{/if}}
+//
+// The flag is evaluated by provider with the attributes in the `flags` entry
+// of DataArgument.Context, overridden by the hash arguments of the helper,
+// as in `(flag "new-tone" plan=input.plan)`. Errors from provider fail the
+// render.
+func NewFlagHelper(provider FlagProvider) func(name string, options *raymond.Options) bool {
+	return func(name string, options *raymond.Options) bool {
+		attrs := make(map[string]any)
+		if data, ok := options.Data(flagsDataKey).(map[string]any); ok {
+			maps.Copy(attrs, data)
+		}
+		maps.Copy(attrs, options.Hash())
+		on, err := provider.Flag(name, attrs)
+		if err != nil {
+			panic(fmt.Errorf("flag helper: %q: %w", name, err))
+		}
+		return on
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFlagHelper(t *testing.T) {
+	const source = `{{#if (flag "new-tone" plan=plan)}}Be playful.{{else}}Be formal.{{/if}}`
+	var gotAttrs map[string]any
+	provider := FlagFunc(func(name string, attrs map[string]any) (bool, error) {
+		gotAttrs = attrs
+		switch name {
+		case "new-tone":
+			return attrs["plan"] == "pro", nil
+		}
+		return false, errors.New("unknown flag")
+	})
+	dp := NewDotprompt(&DotpromptOptions{Flags: provider})
+
+	tests := []struct {
+		name      string
+		data      *DataArgument
+		want      string
+		wantAttrs map[string]any
+	}{
+		{
+			name:      "on",
+			data:      &DataArgument{Input: map[string]any{"plan": "pro"}, Context: map[string]any{"flags": map[string]any{"key": "u1"}}},
+			want:      "Be playful.",
+			wantAttrs: map[string]any{"key": "u1", "plan": "pro"},
+		},
+		{
+			name:      "off",
+			data:      &DataArgument{Input: map[string]any{"plan": "free"}},
+			want:      "Be formal.",
+			wantAttrs: map[string]any{"plan": "free"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := dp.Render(source, tt.data, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if got := messagesText(rendered.Messages); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+			if diff := cmp.Diff(tt.wantAttrs, gotAttrs); diff != "" {
+				t.Errorf("Flag() attrs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := dp.Render(`{{flag "missing"}}`, &DataArgument{}, nil); err == nil || !strings.Contains(err.Error(), "unknown flag") {
+		t.Errorf("Render() error = %v, want the provider error", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package flags adapts feature flag services to dotprompt.FlagProvider, the
+// provider of the `flag` helper:
+//
+//	provider := flags.LaunchDarkly(ldClient, func(attrs map[string]any) ldcontext.Context {
+//		return ldcontext.New(fmt.Sprint(attrs["key"]))
+//	})
+//	d := dotprompt.New(dotprompt.WithFlags(provider))
+//
+// The adapters accept the clients of the LaunchDarkly and OpenFeature Go SDKs
+// through the methods they call, so this package does not depend on either
+// SDK. Their type parameters are the SDK types of those methods.
+package flags
+
+import (
+	"context"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// Static serves fixed flag values, for tests and local development. Flags
+// not in the map are off.
+type Static map[string]bool
+
+// Flag implements dotprompt.FlagProvider.
+func (s Static) Flag(name string, _ map[string]any) (bool, error) {
+	return s[name], nil
+}
+
+// LaunchDarklyClient is the method of *ldclient.LDClient used by
+// LaunchDarkly, where C is ldcontext.Context.
+type LaunchDarklyClient[C any] interface {
+	BoolVariation(key string, context C, defaultVal bool) (bool, error)
+}
+
+// LaunchDarkly returns a provider that evaluates flags with client. evalContext
+// builds the LaunchDarkly context of a render from its flag attributes.
+// Flags default to off.
+func LaunchDarkly[C any](client LaunchDarklyClient[C], evalContext func(attrs map[string]any) C) dp.FlagProvider {
+	return dp.FlagFunc(func(name string, attrs map[string]any) (bool, error) {
+		return client.BoolVariation(name, evalContext(attrs), false)
+	})
+}
+
+// OpenFeatureClient is the method of *openfeature.Client used by
+// OpenFeature, where E is openfeature.EvaluationContext and O is
+// openfeature.Option.
+type OpenFeatureClient[E, O any] interface {
+	BooleanValue(ctx context.Context, flag string, defaultValue bool, evalCtx E, options ...O) (bool, error)
+}
+
+// OpenFeature returns a provider that evaluates flags with client.
+// evalContext builds the OpenFeature evaluation context of a render from its
+// flag attributes, for example with openfeature.NewEvaluationContext. Flags
+// default to off. Renders have no context.Context, so flags are evaluated in
+// context.Background(). Go cannot infer the option type from client, so it is
+// written out:
+//
+//	flags.OpenFeature[openfeature.Option](client, evalContext)
+func OpenFeature[O, E any](client OpenFeatureClient[E, O], evalContext func(attrs map[string]any) E) dp.FlagProvider {
+	return dp.FlagFunc(func(name string, attrs map[string]any) (bool, error) {
+		return client.BooleanValue(context.Background(), name, false, evalContext(attrs))
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package flags
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// ldContext and ldClient mimic ldcontext.Context and *ldclient.LDClient.
+type ldContext struct{ key string }
+
+type ldClient struct{ on map[string]string }
+
+func (c *ldClient) BoolVariation(key string, context ldContext, defaultVal bool) (bool, error) {
+	user, ok := c.on[key]
+	if !ok {
+		return defaultVal, errors.New("unknown flag")
+	}
+	return user == context.key, nil
+}
+
+// ofContext, ofOption and ofClient mimic openfeature.EvaluationContext,
+// openfeature.Option and *openfeature.Client.
+type ofContext struct{ attrs map[string]any }
+
+type ofOption func()
+
+type ofClient struct{ on map[string]bool }
+
+func (c *ofClient) BooleanValue(_ context.Context, flag string, defaultValue bool, evalCtx ofContext, _ ...ofOption) (bool, error) {
+	if evalCtx.attrs["plan"] != "pro" {
+		return defaultValue, nil
+	}
+	return c.on[flag], nil
+}
+
+func TestProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider dp.FlagProvider
+		attrs    map[string]any
+		want     bool
+		wantErr  bool
+	}{
+		{name: "static on", provider: Static{"new-tone": true}, want: true},
+		{name: "static off", provider: Static{}},
+		{
+			name: "launchdarkly on",
+			provider: LaunchDarkly(&ldClient{on: map[string]string{"new-tone": "u1"}}, func(attrs map[string]any) ldContext {
+				return ldContext{key: attrs["key"].(string)}
+			}),
+			attrs: map[string]any{"key": "u1"},
+			want:  true,
+		},
+		{
+			name: "launchdarkly error",
+			provider: LaunchDarkly(&ldClient{}, func(map[string]any) ldContext {
+				return ldContext{}
+			}),
+			wantErr: true,
+		},
+		{
+			name: "openfeature on",
+			provider: OpenFeature[ofOption](&ofClient{on: map[string]bool{"new-tone": true}}, func(attrs map[string]any) ofContext {
+				return ofContext{attrs: attrs}
+			}),
+			attrs: map[string]any{"plan": "pro"},
+			want:  true,
+		},
+		{
+			name: "openfeature default",
+			provider: OpenFeature[ofOption](&ofClient{on: map[string]bool{"new-tone": true}}, func(attrs map[string]any) ofContext {
+				return ofContext{attrs: attrs}
+			}),
+			attrs: map[string]any{"plan": "free"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.Flag("new-tone", tt.attrs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Flag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Flag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
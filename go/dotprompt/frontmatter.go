@@ -0,0 +1,81 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+)
+
+// FrontmatterSchema is the JSON Schema of the frontmatter of a .prompt file
+// defined by the specification. It describes the reserved keys; keys that
+// contain a period are extension namespaces and are left to their owners.
+//
+//go:embed frontmatter.schema.json
+var FrontmatterSchema []byte
+
+// frontmatterSchema decodes FrontmatterSchema once.
+var frontmatterSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	schema := &jsonschema.Schema{}
+	if err := json.Unmarshal(FrontmatterSchema, schema); err != nil {
+		return nil, fmt.Errorf("invalid frontmatter schema: %w", err)
+	}
+	return schema, nil
+})
+
+// ValidateFrontmatter checks the raw frontmatter of metadata against
+// FrontmatterSchema. It reports top-level keys that are neither in the schema,
+// namespaced extension keys such as `acme.owner`, nor registered with
+// RegisterMetadataExtension, as well as values of the wrong type, such as
+// `maxTurns: "five"`. Every problem is reported, in frontmatter order.
+func ValidateFrontmatter(metadata PromptMetadata) error {
+	schema, err := frontmatterSchema()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, key := range metadata.RawKeyOrder.Sort(metadata.Raw) {
+		if strings.Contains(key, ".") || isMetadataExtension(key) {
+			continue
+		}
+		keySchema, ok := schema.Properties.Get(key)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown frontmatter key %q", key))
+			continue
+		}
+		if err := validateValue(keySchema, metadata.Raw[key], key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid frontmatter: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// ParseDocumentStrict is like ParseDocument, but fails if the frontmatter is
+// not valid YAML or does not pass ValidateFrontmatter. Set it as
+// DotpromptOptions.Parser to parse prompts strictly.
+func ParseDocumentStrict(source string) (ParsedPrompt, error) {
+	return ParseOptions{Strict: true}.Parse(source)
+}
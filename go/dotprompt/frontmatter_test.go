@@ -0,0 +1,124 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDocumentStrict(t *testing.T) {
+	if err := RegisterMetadataExtension("owner", "string", nil); err != nil {
+		t.Fatalf("RegisterMetadataExtension() returned error: %v", err)
+	}
+	defer UnregisterMetadataExtension("owner")
+
+	tests := []struct {
+		name    string
+		source  string
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			source: "---\nmodel: test\nmaxTurns: 3\ntools: [search]\n" +
+				"input:\n  schema:\n    name: string\noutput:\n  format: json\n" +
+				"acme.team: search\nowner: alice\n---\nHello {{name}}",
+		},
+		{
+			name: "prompt tests",
+			source: "---\ntests:\n  - name: a\n    input: {}\n    assert:\n" +
+				"      - contains: hi\n      - jsonpath: $.messages[0].role\n        equals: user\n---\nhi",
+		},
+		{
+			name:   "examples",
+			source: "---\nexamples:\n  - input: hi\n    output: {greeting: hello}\n---\nhi",
+		},
+		{
+			name:    "invalid examples",
+			source:  "---\nexamples: {input: a, output: b}\n---\nhi",
+			wantErr: []string{"examples: expected array"},
+		},
+		{
+			name:    "invalid prompt test",
+			source:  "---\ntests:\n  - name: a\n    asert: []\n---\nhi",
+			wantErr: []string{"tests[0].asert: no value is allowed"},
+		},
+		{
+			name:   "no frontmatter",
+			source: "Hello",
+		},
+		{
+			name:    "unknown key",
+			source:  "---\nmodel: test\nmodle: test\n---\nHello",
+			wantErr: []string{`unknown frontmatter key "modle"`},
+		},
+		{
+			name:    "type mismatch",
+			source:  "---\nmaxTurns: five\n---\nHello",
+			wantErr: []string{"maxTurns: expected integer, got string"},
+		},
+		{
+			name:    "nested type mismatch",
+			source:  "---\ntoolDefs:\n  - description: no name\ninput:\n  defaults: {}\n---\nHello",
+			wantErr: []string{`toolDefs[0]: missing required field "name"`, "input.defaults: no value is allowed"},
+		},
+		{
+			name:    "all problems",
+			source:  "---\nfoo: 1\nmaxTurns: 1.5\ntools: search\n---\nHello",
+			wantErr: []string{`unknown frontmatter key "foo"`, "maxTurns: expected integer", "tools: expected array"},
+		},
+		{
+			name:    "unknown tool choice",
+			source:  "---\ntoolChoice: sometimes\nstopSequences: [1]\n---\nHello",
+			wantErr: []string{"toolChoice: sometimes is not one of", "stopSequences[0]: expected string"},
+		},
+		{
+			name:    "invalid YAML",
+			source:  "---\nmodel: [test\n---\nHello",
+			wantErr: []string{"invalid YAML frontmatter"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDocumentStrict(tt.source)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("ParseDocumentStrict() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ParseDocumentStrict() succeeded, want error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ParseDocumentStrict() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDocumentStrictParser(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{Parser: ParseDocumentStrict})
+	if _, err := dp.Parse("---\nmaxTurns: five\n---\nHello"); err == nil {
+		t.Error("Parse() succeeded, want error")
+	}
+	if _, err := ParseDocument("---\nmaxTurns: five\n---\nHello"); err != nil {
+		t.Errorf("ParseDocument() returned error: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// guardsExtNamespace is the ext namespace of Guards.
+const guardsExtNamespace = "guards"
+
+// The rules of Guards, as reported by GuardViolation.Rule.
+const (
+	GuardMaxMessages   = "maxMessages"
+	GuardForbidden     = "forbidden"
+	GuardRequireSystem = "requireSystem"
+)
+
+// Guards are assertions on the messages of a rendered prompt, declared in the
+// `guards` ext namespace of its frontmatter:
+//
+//	guards.maxMessages: 20
+//	guards.forbidden: ["BEGIN PRIVATE KEY", "{{"]
+//	guards.requireSystem: true
+//
+// Render checks them after converting the template to messages and fails
+// with GuardViolation errors instead of returning a prompt that breaks them.
+type Guards struct {
+	// MaxMessages is the maximum number of messages, including history.
+	// Zero disables the check.
+	MaxMessages int `mapstructure:"maxMessages"`
+	// Forbidden are substrings no text part may contain.
+	Forbidden []string `mapstructure:"forbidden"`
+	// RequireSystem requires a system message.
+	RequireSystem bool `mapstructure:"requireSystem"`
+}
+
+// GuardViolation is returned by Render when a rendered prompt breaks one of
+// its Guards. When several are broken, the error joins a GuardViolation for
+// each, which errors.As finds.
+type GuardViolation struct {
+	// Rule is the broken rule, such as GuardMaxMessages.
+	Rule string
+	// Message is the index of the offending message, or -1 if the rule
+	// concerns the messages as a whole.
+	Message int
+	// Detail describes the violation.
+	Detail string
+}
+
+func (e *GuardViolation) Error() string {
+	if e.Message < 0 {
+		return fmt.Sprintf("dotprompt: guard %s violated: %s", e.Rule, e.Detail)
+	}
+	return fmt.Sprintf("dotprompt: guard %s violated by message %d: %s", e.Rule, e.Message, e.Detail)
+}
+
+// Guards returns the guards declared in the `guards` ext namespace.
+func (m *PromptMetadata) Guards() (Guards, error) {
+	var guards Guards
+	if err := m.ExtAs(guardsExtNamespace, &guards); err != nil {
+		return Guards{}, err
+	}
+	if guards.MaxMessages < 0 {
+		return Guards{}, fmt.Errorf("invalid frontmatter guards.maxMessages %d: must not be negative", guards.MaxMessages)
+	}
+	for _, s := range guards.Forbidden {
+		if s == "" {
+			return Guards{}, fmt.Errorf("invalid frontmatter guards.forbidden: substrings must not be empty")
+		}
+	}
+	return guards, nil
+}
+
+// Check returns the violations of the guards by messages, joined, or nil if
+// there are none.
+func (g Guards) Check(messages []Message) error {
+	var errs []error
+	if g.MaxMessages > 0 && len(messages) > g.MaxMessages {
+		errs = append(errs, &GuardViolation{
+			Rule:    GuardMaxMessages,
+			Message: -1,
+			Detail:  fmt.Sprintf("%d messages exceed the maximum of %d", len(messages), g.MaxMessages),
+		})
+	}
+	hasSystem := false
+	for i, msg := range messages {
+		hasSystem = hasSystem || msg.Role == RoleSystem
+		for _, part := range msg.Content {
+			text, ok := part.(*TextPart)
+			if !ok {
+				continue
+			}
+			for _, s := range g.Forbidden {
+				if strings.Contains(text.Text, s) {
+					errs = append(errs, &GuardViolation{
+						Rule:    GuardForbidden,
+						Message: i,
+						Detail:  fmt.Sprintf("text contains forbidden substring %q", s),
+					})
+				}
+			}
+		}
+	}
+	if g.RequireSystem && !hasSystem {
+		errs = append(errs, &GuardViolation{
+			Rule:    GuardRequireSystem,
+			Message: -1,
+			Detail:  "no system message",
+		})
+	}
+	return errors.Join(errs...)
+}
@@ -19,36 +19,64 @@ package dotprompt
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/mbleigh/raymond"
 )
 
 var templateHelpers = map[string]any{
-	"json":         JSON,
-	"role":         RoleFn,
-	"history":      History,
-	"section":      Section,
-	"media":        MediaFn,
-	"ifEquals":     IfEquals,
-	"unlessEquals": UnlessEquals,
+	"json":          JSON,
+	"role":          RoleFn,
+	"history":       History,
+	"examples":      Examples,
+	"section":       Section,
+	"media":         MediaFn,
+	"audio":         AudioFn,
+	"video":         VideoFn,
+	"data":          Data,
+	"cacheBoundary": CacheBoundary,
+	"ifEquals":      IfEquals,
+	"unlessEquals":  UnlessEquals,
 }
 
 // TODO(#494): Add pending: true for section helper
-// JSON serializes the given data to a JSON string with optional indentation.
+// JSON serializes the given data to a JSON string. It accepts these hash
+// options:
+//
+//   - indent=N pretty-prints with N spaces of indentation. indent=0 puts
+//     every value on its own line without indenting it.
+//   - sortKeys=true orders object keys alphabetically, including the fields
+//     of Go structs. Map keys are always sorted.
+//   - pick="a,b.c" keeps only the listed fields. Paths are dotted and apply to
+//     every element of an array, so pick="items.name" keeps the name of each
+//     item.
+//
 // Panics on serialization errors to match JavaScript's JSON.stringify fail-fast behavior.
 func JSON(serializable any, options *raymond.Options) raymond.SafeString {
+	var paths [][]string
+	if pick := options.HashStr("pick"); pick != "" {
+		for _, path := range strings.Split(pick, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				paths = append(paths, strings.Split(path, "."))
+			}
+		}
+	}
+	if len(paths) > 0 || options.HashProp("sortKeys") == true {
+		serializable = jsonValue(serializable)
+	}
+	if len(paths) > 0 {
+		serializable = pickPaths(serializable, paths)
+	}
+
 	var jsonData []byte
 	var err error
-	if options.HashProp("indent") == nil {
-		jsonData, err = json.Marshal(serializable)
+	if options.HashProp("indent") != nil {
+		indent := max(hashInt(options, "indent"), 0)
+		jsonData, err = json.MarshalIndent(serializable, "", strings.Repeat(" ", indent))
 	} else {
-		indent := options.HashProp("indent").(int)
-		var indentStr strings.Builder
-		for range indent {
-			indentStr.WriteString(" ")
-		}
-		jsonData, err = json.MarshalIndent(serializable, "", indentStr.String())
+		jsonData, err = json.Marshal(serializable)
 	}
 
 	if err != nil {
@@ -57,6 +85,68 @@ func JSON(serializable any, options *raymond.Options) raymond.SafeString {
 	return raymond.SafeString(string(jsonData))
 }
 
+// jsonValue converts v to its generic JSON form of maps, slices and scalars,
+// which encoding/json marshals with sorted keys. Numbers are kept as
+// json.Number so that large integers survive the round trip.
+func jsonValue(v any) any {
+	out, err := decodeJSONValue(v, true)
+	if err != nil {
+		panic(fmt.Sprintf("json helper: serialization failed: %v", err))
+	}
+	return out
+}
+
+// pickPaths returns the parts of a generic JSON value selected by paths.
+// Arrays are picked element by element and scalars are returned unchanged.
+func pickPaths(v any, paths [][]string) any {
+	switch v := v.(type) {
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = pickPaths(elem, paths)
+		}
+		return out
+	case map[string]any:
+		nested := make(map[string][][]string)
+		whole := make(map[string]bool)
+		for _, path := range paths {
+			if len(path) == 1 {
+				whole[path[0]] = true
+			} else {
+				nested[path[0]] = append(nested[path[0]], path[1:])
+			}
+		}
+		out := make(map[string]any)
+		for key, value := range v {
+			switch {
+			case whole[key]:
+				out[key] = value
+			case nested[key] != nil:
+				out[key] = pickPaths(value, nested[key])
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// hashInt returns an integer hash option, or zero if it is absent or not a
+// number.
+func hashInt(options *raymond.Options, name string) int {
+	switch v := options.HashProp(name).(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
 // Role returns a formatted role string.
 func RoleFn(role string) raymond.SafeString {
 	return raymond.SafeString(fmt.Sprintf("<<<dotprompt:role:%s>>>", role))
@@ -67,6 +157,30 @@ func History() raymond.SafeString {
 	return raymond.SafeString("<<<dotprompt:history>>>")
 }
 
+// Examples returns a formatted examples string, where the few-shot examples
+// of the prompt are placed.
+func Examples() raymond.SafeString {
+	return raymond.SafeString("<<<dotprompt:examples>>>")
+}
+
+// Data returns a marker that becomes a DataPart holding value, which must
+// encode to a JSON object, so that structured content reaches the model as
+// data rather than text:
+//
+//	{{data order}}
+//
+// The JSON in the marker escapes `<` and `>`, so values cannot end it early.
+func Data(value any) raymond.SafeString {
+	b, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Errorf("data helper: %w", err))
+	}
+	if len(b) == 0 || b[0] != '{' {
+		panic(fmt.Errorf("data helper: value must be an object, got %s", b))
+	}
+	return raymond.SafeString(DataMarkerPrefix + " " + string(b) + ">>>")
+}
+
 // Section returns a formatted section string.
 func Section(name string) raymond.SafeString {
 	return raymond.SafeString(fmt.Sprintf("<<<dotprompt:section %s>>>", name))
@@ -82,18 +196,94 @@ func MediaFn(options *raymond.Options) raymond.SafeString {
 	return raymond.SafeString(fmt.Sprintf("<<<dotprompt:media:url %s>>>", url))
 }
 
-// IfEquals compares two values and returns the appropriate template content.
+// IfEquals renders the block if its two arguments are Equal and the inverse
+// block otherwise:
+//
+//	{{#ifEquals status "done"}}Finished{{else}}Pending{{/ifEquals}}
 func IfEquals(arg1, arg2 any, options *raymond.Options) string {
-	if arg1 == arg2 {
+	if Equal(arg1, arg2) {
 		return options.Fn()
 	}
 	return options.Inverse()
 }
 
-// UnlessEquals compares two values and returns the appropriate template content.
+// UnlessEquals renders the block if its two arguments are not Equal and the
+// inverse block otherwise.
 func UnlessEquals(arg1, arg2 any, options *raymond.Options) string {
-	if arg1 != arg2 {
+	if !Equal(arg1, arg2) {
 		return options.Fn()
 	}
 	return options.Inverse()
 }
+
+// Equal reports whether two template values are equal under the rules shared
+// by the dotprompt runtimes:
+//
+//   - Values of different types are never equal, so 5 and "5" differ, as do
+//     true and 1.
+//   - Numbers compare by value regardless of their Go type, so int(5) equals
+//     float64(5), matching JavaScript's single number type.
+//   - nil equals only nil.
+//   - Slices and arrays are equal if they have equal elements in the same
+//     order; maps are equal if they have the same keys with equal values.
+//
+// Equal never panics, even for values that Go's == cannot compare.
+func Equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.String:
+		return vb.Kind() == reflect.String && va.String() == vb.String()
+	case reflect.Bool:
+		return vb.Kind() == reflect.Bool && va.Bool() == vb.Bool()
+	case reflect.Slice, reflect.Array:
+		if vb.Kind() != reflect.Slice && vb.Kind() != reflect.Array || va.Len() != vb.Len() {
+			return false
+		}
+		for i := range va.Len() {
+			if !Equal(va.Index(i).Interface(), vb.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if vb.Kind() != reflect.Map || va.Len() != vb.Len() {
+			return false
+		}
+		// Keys are compared by their string form, as object keys are in
+		// JavaScript.
+		values := make(map[string]any, vb.Len())
+		for it := vb.MapRange(); it.Next(); {
+			values[fmt.Sprint(it.Key().Interface())] = it.Value().Interface()
+		}
+		for it := va.MapRange(); it.Next(); {
+			v, ok := values[fmt.Sprint(it.Key().Interface())]
+			if !ok || !Equal(it.Value().Interface(), v) {
+				return false
+			}
+		}
+		return true
+	}
+	return va.Type() == vb.Type() && reflect.DeepEqual(a, b)
+}
+
+// number returns v as a float64 if it is of a numeric kind.
+func number(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+// StoreHooks are notified of the changes made through a store, so that
+// external systems such as caches, chat notifications or deployment
+// pipelines can react to them. Each hook is optional and is called
+// synchronously after the change succeeds, so slow hooks should hand their
+// work off to another goroutine.
+//
+// Set DirStore.Hooks, or wrap any writable store with WithHooks.
+type StoreHooks struct {
+	// OnSave is called after a prompt is saved, with the version of the saved
+	// source.
+	OnSave func(ref PromptRef)
+	// OnSavePartial is called after a partial is saved, with the version of
+	// the saved source.
+	OnSavePartial func(ref PartialRef)
+	// OnDelete is called after a prompt is deleted, with the version of the
+	// deleted source if it could be read.
+	OnDelete func(ref PromptRef)
+}
+
+// saved calls OnSave for prompt.
+func (h StoreHooks) saved(prompt PromptData) {
+	if h.OnSave != nil {
+		h.OnSave(PromptRef{Name: prompt.Name, Variant: prompt.Variant, Version: calculateVersion(prompt.Source)})
+	}
+}
+
+// savedPartial calls OnSavePartial for partial.
+func (h StoreHooks) savedPartial(partial PartialData) {
+	if h.OnSavePartial != nil {
+		h.OnSavePartial(PartialRef{Name: partial.Name, Variant: partial.Variant, Version: calculateVersion(partial.Source)})
+	}
+}
+
+// deleted calls OnDelete for the prompt name, whose source was version.
+func (h StoreHooks) deleted(name, variant, version string) {
+	if h.OnDelete != nil {
+		h.OnDelete(PromptRef{Name: name, Variant: variant, Version: version})
+	}
+}
+
+// WithHooks returns a store that forwards to store and calls hooks after each
+// change. The returned store is also a PartialWriter if store is.
+func WithHooks(store WritablePromptStore, hooks StoreHooks) WritablePromptStore {
+	hooked := &hookedStore{WritablePromptStore: store, hooks: hooks}
+	if partials, ok := store.(PartialWriter); ok {
+		return &hookedPartialStore{hookedStore: hooked, savePartial: partials.SavePartial}
+	}
+	return hooked
+}
+
+// hookedStore is the store returned by WithHooks.
+type hookedStore struct {
+	WritablePromptStore
+	hooks StoreHooks
+}
+
+func (s *hookedStore) Save(prompt PromptData) error {
+	if err := s.WritablePromptStore.Save(prompt); err != nil {
+		return err
+	}
+	s.hooks.saved(prompt)
+	return nil
+}
+
+func (s *hookedStore) Delete(name string, options PromptStoreDeleteOptions) error {
+	version := ""
+	if s.hooks.OnDelete != nil {
+		if prompt, err := s.WritablePromptStore.Load(name, LoadPromptOptions{Variant: options.Variant}); err == nil && prompt.Variant == options.Variant {
+			version = prompt.Version
+		}
+	}
+	if err := s.WritablePromptStore.Delete(name, options); err != nil {
+		return err
+	}
+	s.hooks.deleted(name, options.Variant, version)
+	return nil
+}
+
+// hookedPartialStore is the store returned by WithHooks for stores that can
+// save partials.
+type hookedPartialStore struct {
+	*hookedStore
+	savePartial func(PartialData) error
+}
+
+var _ PartialWriter = (*hookedPartialStore)(nil)
+
+func (s *hookedPartialStore) SavePartial(partial PartialData) error {
+	if err := s.savePartial(partial); err != nil {
+		return err
+	}
+	s.hooks.savedPartial(partial)
+	return nil
+}
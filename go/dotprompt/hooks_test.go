@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// hookRecorder records the calls of the hooks it returns.
+type hookRecorder struct {
+	calls []string
+}
+
+func (r *hookRecorder) hooks() StoreHooks {
+	return StoreHooks{
+		OnSave:        func(ref PromptRef) { r.calls = append(r.calls, "save "+ref.Name+"."+ref.Variant+" "+ref.Version) },
+		OnSavePartial: func(ref PartialRef) { r.calls = append(r.calls, "partial "+ref.Name+" "+ref.Version) },
+		OnDelete:      func(ref PromptRef) { r.calls = append(r.calls, "delete "+ref.Name+"."+ref.Variant+" "+ref.Version) },
+	}
+}
+
+func TestDirStoreHooks(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	var rec hookRecorder
+	store.Hooks = rec.hooks()
+	testHooks(t, store, &rec)
+}
+
+func TestWithHooks(t *testing.T) {
+	inner, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	var rec hookRecorder
+	testHooks(t, WithHooks(inner, rec.hooks()), &rec)
+
+	readOnlyPartials := WithHooks(struct{ WritablePromptStore }{inner}, StoreHooks{})
+	if _, ok := readOnlyPartials.(interface{ SavePartial(PartialData) error }); ok {
+		t.Errorf("WithHooks() added SavePartial to a store without it")
+	}
+}
+
+func testHooks(t *testing.T, store WritablePromptStore, rec *hookRecorder) {
+	t.Helper()
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "greet", Variant: "formal"}, Source: "v1"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if err := store.(interface{ SavePartial(PartialData) error }).SavePartial(PartialData{PartialRef: PartialRef{Name: "sig"}, Source: "p"}); err != nil {
+		t.Fatalf("SavePartial() returned error: %v", err)
+	}
+	if err := store.Delete("greet", PromptStoreDeleteOptions{Variant: "formal"}); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if err := store.Delete("missing", PromptStoreDeleteOptions{}); err == nil {
+		t.Errorf("Delete() of a missing prompt returned no error")
+	}
+
+	want := []string{
+		"save greet.formal " + calculateVersion("v1"),
+		"partial sig " + calculateVersion("p"),
+		"delete greet.formal " + calculateVersion("v1"),
+	}
+	if diff := cmp.Diff(want, rec.calls); diff != "" {
+		t.Errorf("hook calls mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/mbleigh/raymond"
+)
+
+// DefaultMaxIncludeBytes is the default DotpromptOptions.MaxIncludeBytes.
+const DefaultMaxIncludeBytes = 1 << 20
+
+// IncludeSizeError is returned when a file read by the `include` helper is
+// larger than DotpromptOptions.MaxIncludeBytes.
+type IncludeSizeError struct {
+	Name string
+	Max  int64
+}
+
+func (e *IncludeSizeError) Error() string {
+	return fmt.Sprintf("dotprompt: included file %q exceeds max %d bytes", e.Name, e.Max)
+}
+
+// NewIncludeHelper returns the `include` helper, which inserts the contents
+// of a file of fsys verbatim, as in `{{include "snippets/policy.md"}}`. Unlike
+// a partial, the file is not evaluated as a template, which suits large
+// static text such as policies or schemas. Files larger than maxBytes, or
+// DefaultMaxIncludeBytes if maxBytes is zero or less, fail the render, as do
+// files containing dotprompt markers, which would change the structure of
+// the prompt.
+func NewIncludeHelper(fsys fs.FS, maxBytes int64) func(name string) raymond.SafeString {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxIncludeBytes
+	}
+	return func(name string) raymond.SafeString {
+		text, err := readInclude(fsys, name, maxBytes)
+		if err != nil {
+			panic(fmt.Errorf("include helper: %w", err))
+		}
+		return raymond.SafeString(text)
+	}
+}
+
+// readInclude reads the file name of fsys, of at most maxBytes bytes.
+func readInclude(fsys fs.FS, name string, maxBytes int64) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if !fs.ValidPath(name) || name == "." {
+		return "", fmt.Errorf("invalid include path %q", name)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > maxBytes {
+		return "", &IncludeSizeError{Name: name, Max: maxBytes}
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > maxBytes {
+		return "", &IncludeSizeError{Name: name, Max: maxBytes}
+	}
+	text := string(data)
+	if strings.Contains(text, "<<<dotprompt:") {
+		return "", fmt.Errorf("included file %q contains a dotprompt marker", name)
+	}
+	return text, nil
+}
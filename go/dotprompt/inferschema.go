@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// InferSchema derives a best-effort input schema for template from the
+// fields it references, for prompts that declare no schema. Every field is an
+// optional string, except fields whose properties are referenced, such as
+// `user` in `{{user.name}}`, which are optional objects with those
+// properties. Properties follow the order of their first reference.
+func InferSchema(template string) (*jsonschema.Schema, error) {
+	pico, order, err := inferPicoschema(template)
+	if err != nil {
+		return nil, err
+	}
+	return Picoschema(pico, &PicoschemaOptions{KeyOrder: order})
+}
+
+// InferPicoschema returns the schema of InferSchema in Picoschema form, e.g.
+// `{"name?": "string", "user?(object)": {"email?": "string"}}`.
+func InferPicoschema(template string) (map[string]any, error) {
+	pico, _, err := inferPicoschema(template)
+	return pico, err
+}
+
+// WithInferredSchema returns prompt with the schema of InferSchema as its
+// input schema, in both Input.Schema and Raw, so that Serialize writes it
+// into the frontmatter. Prompts that already declare an input schema are
+// returned unchanged. The maps of prompt are not modified.
+func WithInferredSchema(prompt ParsedPrompt) (ParsedPrompt, error) {
+	if prompt.Input.Schema != nil {
+		return prompt, nil
+	}
+	pico, order, err := inferPicoschema(prompt.Template)
+	if err != nil {
+		return ParsedPrompt{}, err
+	}
+	if len(pico) == 0 {
+		return prompt, nil
+	}
+
+	input := map[string]any{}
+	if existing, ok := prompt.Raw["input"].(map[string]any); ok {
+		maps.Copy(input, existing)
+	}
+	input["schema"] = pico
+	raw := make(map[string]any, len(prompt.Raw)+1)
+	maps.Copy(raw, prompt.Raw)
+	raw["input"] = input
+
+	inputOrder := &KeyOrder{Nested: map[string]*KeyOrder{"schema": order}}
+	if existing := prompt.RawKeyOrder.Child("input"); existing != nil {
+		inputOrder.Keys = existing.Keys
+		maps.Copy(inputOrder.Nested, existing.Nested)
+		inputOrder.Nested["schema"] = order
+	}
+	keyOrder := &KeyOrder{Nested: map[string]*KeyOrder{"input": inputOrder}}
+	if prompt.RawKeyOrder != nil {
+		keyOrder.Keys = prompt.RawKeyOrder.Keys
+		maps.Copy(keyOrder.Nested, prompt.RawKeyOrder.Nested)
+		keyOrder.Nested["input"] = inputOrder
+	}
+
+	prompt.Raw = raw
+	prompt.RawKeyOrder = keyOrder
+	prompt.Input.Schema = pico
+	return prompt, nil
+}
+
+// inferredField is a field of an inferred schema.
+type inferredField struct {
+	name string
+	// properties are the fields referenced under this one, if any.
+	properties []*inferredField
+}
+
+// child returns the field's property with the given name, adding it if
+// needed.
+func (f *inferredField) child(name string) *inferredField {
+	for _, p := range f.properties {
+		if p.name == name {
+			return p
+		}
+	}
+	p := &inferredField{name: name}
+	f.properties = append(f.properties, p)
+	return p
+}
+
+// inferPicoschema infers the Picoschema of template and the order of its
+// keys.
+func inferPicoschema(template string) (map[string]any, *KeyOrder, error) {
+	vars, err := TemplateVariables(template)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := &inferredField{}
+	for _, v := range vars {
+		// Built-in helpers such as {{history}} take no arguments, so their
+		// mustaches look like field references.
+		if _, ok := templateHelpers[v.Name]; ok && v.Path == v.Name {
+			continue
+		}
+		field := root
+		for _, segment := range strings.Split(v.Path, ".") {
+			if segment == "" {
+				break
+			}
+			field = field.child(segment)
+		}
+	}
+	pico, order := root.picoschema()
+	return pico, order, nil
+}
+
+// picoschema returns the Picoschema of the properties of f and their key
+// order.
+func (f *inferredField) picoschema() (map[string]any, *KeyOrder) {
+	out := make(map[string]any, len(f.properties))
+	order := &KeyOrder{}
+	for _, p := range f.properties {
+		if len(p.properties) == 0 {
+			key := p.name + "?"
+			out[key] = "string"
+			order.Keys = append(order.Keys, key)
+			continue
+		}
+		key := p.name + "?(object)"
+		nested, nestedOrder := p.picoschema()
+		out[key] = nested
+		order.Keys = append(order.Keys, key)
+		if order.Nested == nil {
+			order.Nested = make(map[string]*KeyOrder)
+		}
+		order.Nested[key] = nestedOrder
+	}
+	return out, order
+}
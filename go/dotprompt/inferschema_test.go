@@ -0,0 +1,123 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const inferTemplate = `{{role "system"}}You help {{user.name}} ({{user.email}}).
+{{history}}
+{{#if topic}}Talk about {{topic}}.{{/if}}
+{{#each items}}- {{this.title}} by {{@root.user.name}}{{/each}}
+{{json user}}`
+
+func TestInferSchema(t *testing.T) {
+	schema, err := InferSchema(inferTemplate)
+	if err != nil {
+		t.Fatalf("InferSchema() returned error: %v", err)
+	}
+	var names []string
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		names = append(names, pair.Key)
+	}
+	if diff := cmp.Diff([]string{"user", "topic", "items"}, names); diff != "" {
+		t.Errorf("InferSchema() properties mismatch (-want +got):\n%s", diff)
+	}
+	if len(schema.Required) != 0 {
+		t.Errorf("InferSchema().Required = %v, want none", schema.Required)
+	}
+	user, _ := schema.Properties.Get("user")
+	if user.Properties == nil || user.Properties.Len() != 2 {
+		t.Errorf("InferSchema() user = %+v, want object with name and email", user)
+	}
+}
+
+func TestInferPicoschema(t *testing.T) {
+	got, err := InferPicoschema(inferTemplate)
+	if err != nil {
+		t.Fatalf("InferPicoschema() returned error: %v", err)
+	}
+	want := map[string]any{
+		"user?(object)": map[string]any{"name?": "string", "email?": "string"},
+		"topic?":        "string",
+		"items?":        "string",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InferPicoschema() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := InferPicoschema("{{#if}}"); err == nil {
+		t.Errorf("InferPicoschema() of an invalid template returned no error")
+	}
+}
+
+func TestWithInferredSchema(t *testing.T) {
+	prompt, err := ParseDocument("---\nmodel: test/model\ninput:\n  default:\n    topic: cats\n---\nTell {{user.name}} about {{topic}}.\n")
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	inferred, err := WithInferredSchema(prompt)
+	if err != nil {
+		t.Fatalf("WithInferredSchema() returned error: %v", err)
+	}
+	got, err := Serialize(inferred)
+	if err != nil {
+		t.Fatalf("Serialize() returned error: %v", err)
+	}
+	want := `---
+model: test/model
+input:
+  default:
+    topic: cats
+  schema:
+    user?(object):
+      name?: string
+    topic?: string
+---
+Tell {{user.name}} about {{topic}}.
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Serialize(WithInferredSchema()) mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := prompt.Raw["input"].(map[string]any)["schema"]; ok {
+		t.Errorf("WithInferredSchema() modified the input prompt")
+	}
+
+	// The result parses back with the same schema.
+	reparsed, err := ParseDocument(got)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	if diff := cmp.Diff(inferred.Input.Schema, reparsed.Input.Schema); diff != "" {
+		t.Errorf("reparsed schema mismatch (-want +got):\n%s", diff)
+	}
+
+	declared, err := ParseDocument("---\ninput:\n  schema:\n    x: string\n---\n{{y}}")
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	unchanged, err := WithInferredSchema(declared)
+	if err != nil {
+		t.Fatalf("WithInferredSchema() returned error: %v", err)
+	}
+	if diff := cmp.Diff(declared.Input.Schema, unchanged.Input.Schema); diff != "" {
+		t.Errorf("WithInferredSchema() changed a declared schema (-want +got):\n%s", diff)
+	}
+}
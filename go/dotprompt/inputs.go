@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"slices"
+
+	"github.com/mbleigh/raymond/parser"
+)
+
+// InputWarningKind classifies an InputWarning.
+type InputWarningKind string
+
+const (
+	// InputUnused is reported for an input field that the template never
+	// references.
+	InputUnused InputWarningKind = "unused"
+	// InputMissing is reported for a field that the template references but
+	// that is absent from both the input and the input defaults.
+	InputMissing InputWarningKind = "missing"
+)
+
+// InputWarning reports a mismatch between the input passed to a prompt and the
+// fields its template references.
+type InputWarning struct {
+	Kind InputWarningKind `json:"kind"`
+	// Name is the top-level input field.
+	Name string `json:"name"`
+	// Path is the first referenced path for a missing field, e.g.
+	// `user.name`. It is empty for unused fields.
+	Path string `json:"path,omitempty"`
+}
+
+// inputUsage is the set of root input fields referenced by a template and the
+// partials it includes.
+type inputUsage struct {
+	vars []TemplateVariable
+	// wholeContext is set when the template passes the entire input to a
+	// helper, in which case no field is reported as unused.
+	wholeContext bool
+}
+
+// collectInputUsage walks template and the registered partials it reaches.
+// Mustaches that name a registered helper are not treated as field references.
+func (dp *Dotprompt) collectInputUsage(template string) (*inputUsage, error) {
+	usage := &inputUsage{}
+	visited := make(map[string]bool)
+
+	var walk func(source string) error
+	walk = func(source string) error {
+		program, err := parser.Parse(source)
+		if err != nil {
+			return err
+		}
+		w := &variableWalker{}
+		w.program(program, 0, nil)
+		for _, v := range w.vars {
+			if v.Name == v.Path && dp.knownHelpers[v.Name] {
+				continue
+			}
+			usage.vars = append(usage.vars, v)
+		}
+		usage.wholeContext = usage.wholeContext || w.wholeContext
+		for _, p := range w.partials {
+			if visited[p.Name] {
+				continue
+			}
+			visited[p.Name] = true
+			if partialSource, ok := dp.partialSources[p.Name]; ok {
+				if err := walk(partialSource); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(template); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// check compares the fields referenced by the template against input, which
+// holds the caller's fields, and context, which also includes the defaults.
+// Unused fields are reported in sorted order, followed by missing fields in
+// the order they are first referenced.
+func (u *inputUsage) check(input, context map[string]any) []InputWarning {
+	var warnings []InputWarning
+	referenced := make(map[string]bool)
+	for _, v := range u.vars {
+		referenced[v.Name] = true
+	}
+	if !u.wholeContext {
+		var unused []string
+		for name := range input {
+			if !referenced[name] {
+				unused = append(unused, name)
+			}
+		}
+		slices.Sort(unused)
+		for _, name := range unused {
+			warnings = append(warnings, InputWarning{Kind: InputUnused, Name: name})
+		}
+	}
+
+	reported := make(map[string]bool)
+	for _, v := range u.vars {
+		if _, ok := context[v.Name]; ok || reported[v.Name] {
+			continue
+		}
+		reported[v.Name] = true
+		warnings = append(warnings, InputWarning{Kind: InputMissing, Name: v.Name, Path: v.Path})
+	}
+	return warnings
+}
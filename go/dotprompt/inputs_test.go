@@ -0,0 +1,110 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInputWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		input    map[string]any
+		partials map[string]string
+		helpers  map[string]any
+		want     []InputWarning
+	}{
+		{
+			name:   "all used",
+			source: "Hello {{name}}, you are {{age}}.",
+			input:  map[string]any{"name": "Ada", "age": 36},
+		},
+		{
+			name:   "unused and missing",
+			source: "Hello {{user.name}} from {{city}}.",
+			input:  map[string]any{"zeta": 1, "alpha": 2, "city": "Paris"},
+			want: []InputWarning{
+				{Kind: InputUnused, Name: "alpha"},
+				{Kind: InputUnused, Name: "zeta"},
+				{Kind: InputMissing, Name: "user", Path: "user.name"},
+			},
+		},
+		{
+			name:   "defaults satisfy references",
+			source: "---\ninput:\n  default:\n    tone: friendly\n---\nBe {{tone}}.",
+		},
+		{
+			name:   "untaken branch counts as used",
+			source: "{{#if verbose}}{{details}}{{/if}}",
+			input:  map[string]any{"verbose": false, "details": "x"},
+		},
+		{
+			name:     "fields used by partials",
+			source:   "{{> header}}",
+			partials: map[string]string{"header": "# {{title}}"},
+			input:    map[string]any{"title": "Hi", "extra": true},
+			want:     []InputWarning{{Kind: InputUnused, Name: "extra"}},
+		},
+		{
+			name:   "each context",
+			source: "{{#each items}}{{label}}{{/each}}",
+			input:  map[string]any{"items": []any{}},
+		},
+		{
+			name:   "whole context",
+			source: "{{json this}}",
+			input:  map[string]any{"a": 1, "b": 2},
+		},
+		{
+			name:    "user helper without arguments",
+			source:  "{{today}} {{name}}",
+			helpers: map[string]any{"today": func() string { return "Monday" }},
+			input:   map[string]any{"name": "Ada"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(&DotpromptOptions{
+				CheckInputs: true,
+				Partials:    tt.partials,
+				Helpers:     tt.helpers,
+			})
+			rendered, err := dp.Render(tt.source, &DataArgument{Input: tt.input}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rendered.InputWarnings); diff != "" {
+				t.Errorf("InputWarnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInputWarningsDisabledByDefault(t *testing.T) {
+	dp := NewDotprompt(nil)
+	rendered, err := dp.Render("{{missing}}", &DataArgument{Input: map[string]any{"unused": 1}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if rendered.InputWarnings != nil {
+		t.Errorf("InputWarnings = %v, want nil", rendered.InputWarnings)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mbleigh/raymond/ast"
+	"github.com/mbleigh/raymond/parser"
+)
+
+// IRFormat identifies the version of the IR produced by CompileIR. Consumers
+// should reject documents with a format they do not know.
+const IRFormat = "dotprompt.ir/v1"
+
+// IRBundle is a set of prompts compiled into a runtime-neutral JSON form.
+//
+// Templates are stored as Handlebars ASTs using the node shapes of
+// Handlebars.js (`Program`, `MustacheStatement`, `BlockStatement`,
+// `PartialStatement`, `ContentStatement`, `CommentStatement`,
+// `SubExpression`, `PathExpression`, `StringLiteral`, `NumberLiteral`,
+// `BooleanLiteral`, `Hash` and `HashPair`), so a runtime can evaluate them
+// without parsing. Whitespace control (`~`) and standalone-line stripping have
+// already been applied to the `value` of each `ContentStatement`, and partial
+// tags pinned with `name@variant` are bracketed as `[name@variant]`.
+type IRBundle struct {
+	// Format is always IRFormat.
+	Format   string      `json:"format"`
+	Prompts  []IRPrompt  `json:"prompts"`
+	Partials []IRPartial `json:"partials,omitempty"`
+}
+
+// IRPrompt is a compiled prompt.
+type IRPrompt struct {
+	PromptRef
+	// Metadata is the resolved frontmatter, with Picoschema expanded to JSON
+	// Schema and registered tools resolved.
+	Metadata PromptMetadata `json:"metadata"`
+	// Template is the AST of the template body.
+	Template *IRNode `json:"template"`
+	// Partials are the partials the template depends on, directly or through
+	// other partials, sorted by name and variant.
+	Partials []PartialRef `json:"partials,omitempty"`
+	// Helpers and Schemas are the remaining dependencies of the prompt; see
+	// PromptDependencies.
+	Helpers []string `json:"helpers,omitempty"`
+	Schemas []string `json:"schemas,omitempty"`
+}
+
+// IRPartial is a compiled partial.
+type IRPartial struct {
+	PartialRef
+	Template *IRNode `json:"template"`
+}
+
+// IRNode is a node of a Handlebars AST. Which fields are set depends on Type,
+// following the Handlebars.js AST specification.
+type IRNode struct {
+	Type string `json:"type"`
+
+	// Program
+	Body        []*IRNode `json:"body,omitempty"`
+	BlockParams []string  `json:"blockParams,omitempty"`
+
+	// MustacheStatement, BlockStatement, SubExpression
+	Path *IRNode `json:"path,omitempty"`
+	// PartialStatement
+	Name   *IRNode `json:"name,omitempty"`
+	Indent string  `json:"indent,omitempty"`
+	// MustacheStatement, BlockStatement, PartialStatement, SubExpression
+	Params []*IRNode `json:"params,omitempty"`
+	Hash   *IRNode   `json:"hash,omitempty"`
+	// MustacheStatement: false for triple-stash `{{{x}}}`. dotprompt renders
+	// without escaping either way.
+	Escaped bool `json:"escaped,omitempty"`
+
+	// BlockStatement
+	Program *IRNode `json:"program,omitempty"`
+	Inverse *IRNode `json:"inverse,omitempty"`
+
+	// Hash
+	Pairs []*IRNode `json:"pairs,omitempty"`
+	// HashPair
+	Key string `json:"key,omitempty"`
+
+	// ContentStatement, CommentStatement and literals hold a string, number
+	// or boolean; HashPair holds an *IRNode.
+	Value any `json:"value,omitempty"`
+
+	// PathExpression
+	Original string   `json:"original,omitempty"`
+	Parts    []string `json:"parts,omitempty"`
+	Data     bool     `json:"data,omitempty"`
+	Depth    int      `json:"depth,omitempty"`
+}
+
+// CompileIR compiles the prompts and partials of bundle, together with the
+// partials registered with dp, into an IRBundle. Metadata is resolved as
+// RenderMetadata would with no additional metadata.
+func (dp *Dotprompt) CompileIR(bundle *PromptBundle) (*IRBundle, error) {
+	out := &IRBundle{Format: IRFormat, Prompts: []IRPrompt{}}
+	if bundle == nil {
+		bundle = &PromptBundle{}
+	}
+
+	sources := make(map[PartialRef]string)
+	var partialRefs []PartialRef
+	addPartial := func(ref PartialRef, source string) {
+		if _, ok := sources[ref]; ok {
+			return
+		}
+		sources[ref] = quotePinnedPartials(source)
+		partialRefs = append(partialRefs, ref)
+	}
+	for _, partial := range bundle.Partials {
+		addPartial(PartialRef{Name: partial.Name, Variant: partial.Variant}, partial.Source)
+	}
+	for name, source := range dp.Partials {
+		addPartial(PartialRef{Name: name}, source)
+	}
+	sortPartialRefs(partialRefs)
+
+	for _, ref := range partialRefs {
+		node, err := compileIRTemplate(sources[ref])
+		if err != nil {
+			return nil, fmt.Errorf("compiling partial %q: %w", bundleKey(ref.Name, ref.Variant), err)
+		}
+		out.Partials = append(out.Partials, IRPartial{PartialRef: ref, Template: node})
+	}
+
+	for _, prompt := range bundle.Prompts {
+		key := bundleKey(prompt.Name, prompt.Variant)
+		parsed, err := dp.Parse(prompt.Source)
+		if err != nil {
+			return nil, fmt.Errorf("parsing prompt %q: %w", key, err)
+		}
+		parsed.Template = quotePinnedPartials(parsed.Template)
+		metadata, err := dp.RenderMetadata(parsed, nil)
+		if err != nil {
+			return nil, fmt.Errorf("resolving metadata of prompt %q: %w", key, err)
+		}
+		node, err := compileIRTemplate(parsed.Template)
+		if err != nil {
+			return nil, fmt.Errorf("compiling prompt %q: %w", key, err)
+		}
+		deps, err := dp.dependencies(parsed, sources)
+		if err != nil {
+			return nil, fmt.Errorf("compiling prompt %q: %w", key, err)
+		}
+		out.Prompts = append(out.Prompts, IRPrompt{
+			PromptRef: prompt.PromptRef,
+			Metadata:  metadata,
+			Template:  node,
+			Partials:  deps.Partials,
+			Helpers:   deps.Helpers,
+			Schemas:   deps.Schemas,
+		})
+	}
+	return out, nil
+}
+
+// partialDependencies returns the partials template depends on, following
+// references through the partials in sources. Pinned versions are kept in
+// the returned references.
+func partialDependencies(template string, sources map[PartialRef]string) ([]PartialRef, error) {
+	seen := make(map[PartialRef]bool)
+	var deps []PartialRef
+	var walk func(source string) error
+	walk = func(source string) error {
+		refs, err := partialReferences(source)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if seen[ref.Ref] {
+				continue
+			}
+			seen[ref.Ref] = true
+			deps = append(deps, ref.Ref)
+			key := PartialRef{Name: ref.Ref.Name, Variant: ref.Ref.Variant}
+			if partialSource, ok := sources[key]; ok {
+				if err := walk(partialSource); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(template); err != nil {
+		return nil, err
+	}
+	sortPartialRefs(deps)
+	return deps, nil
+}
+
+// sortPartialRefs sorts refs by name, variant and version.
+func sortPartialRefs(refs []PartialRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Name != refs[j].Name {
+			return refs[i].Name < refs[j].Name
+		}
+		if refs[i].Variant != refs[j].Variant {
+			return refs[i].Variant < refs[j].Variant
+		}
+		return refs[i].Version < refs[j].Version
+	})
+}
+
+// compileIRTemplate parses template into an IR program.
+func compileIRTemplate(template string) (*IRNode, error) {
+	program, err := parser.Parse(template)
+	if err != nil {
+		return nil, err
+	}
+	return irProgram(program), nil
+}
+
+func irProgram(program *ast.Program) *IRNode {
+	if program == nil {
+		return nil
+	}
+	node := &IRNode{Type: "Program", Body: []*IRNode{}, BlockParams: program.BlockParams}
+	for _, stmt := range program.Body {
+		if n := irStatement(stmt); n != nil {
+			node.Body = append(node.Body, n)
+		}
+	}
+	return node
+}
+
+func irStatement(stmt ast.Node) *IRNode {
+	switch n := stmt.(type) {
+	case *ast.ContentStatement:
+		if n.Value == "" {
+			return nil
+		}
+		return &IRNode{Type: "ContentStatement", Value: n.Value}
+	case *ast.CommentStatement:
+		return &IRNode{Type: "CommentStatement", Value: n.Value}
+	case *ast.MustacheStatement:
+		node := irExpression("MustacheStatement", n.Expression)
+		node.Escaped = !n.Unescaped
+		return node
+	case *ast.BlockStatement:
+		node := irExpression("BlockStatement", n.Expression)
+		node.Program = irProgram(n.Program)
+		node.Inverse = irProgram(n.Inverse)
+		return node
+	case *ast.PartialStatement:
+		return &IRNode{
+			Type:   "PartialStatement",
+			Name:   irValue(n.Name),
+			Params: irValues(n.Params),
+			Hash:   irHash(n.Hash),
+			Indent: n.Indent,
+		}
+	}
+	return nil
+}
+
+func irExpression(nodeType string, expr *ast.Expression) *IRNode {
+	return &IRNode{
+		Type:   nodeType,
+		Path:   irValue(expr.Path),
+		Params: irValues(expr.Params),
+		Hash:   irHash(expr.Hash),
+	}
+}
+
+func irValues(nodes []ast.Node) []*IRNode {
+	var out []*IRNode
+	for _, n := range nodes {
+		out = append(out, irValue(n))
+	}
+	return out
+}
+
+func irValue(value ast.Node) *IRNode {
+	switch n := value.(type) {
+	case *ast.PathExpression:
+		return &IRNode{Type: "PathExpression", Original: n.Original, Parts: n.Parts, Data: n.Data, Depth: n.Depth}
+	case *ast.SubExpression:
+		return irExpression("SubExpression", n.Expression)
+	case *ast.StringLiteral:
+		return &IRNode{Type: "StringLiteral", Value: n.Value, Original: n.Value}
+	case *ast.NumberLiteral:
+		return &IRNode{Type: "NumberLiteral", Value: n.Value, Original: n.Original}
+	case *ast.BooleanLiteral:
+		return &IRNode{Type: "BooleanLiteral", Value: n.Value, Original: n.Original}
+	}
+	return nil
+}
+
+func irHash(hash *ast.Hash) *IRNode {
+	if hash == nil {
+		return nil
+	}
+	node := &IRNode{Type: "Hash", Pairs: []*IRNode{}}
+	for _, pair := range hash.Pairs {
+		node.Pairs = append(node.Pairs, &IRNode{Type: "HashPair", Key: pair.Key, Value: irValue(pair.Val)})
+	}
+	return node
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/invopop/jsonschema"
+)
+
+func TestCompileIR(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{
+		Partials: map[string]string{"footer": "bye"},
+	})
+	bundle := &PromptBundle{
+		Partials: []PartialData{
+			{PartialRef: PartialRef{Name: "header"}, Source: "Hi {{> footer}}"},
+			{PartialRef: PartialRef{Name: "header", Variant: "v2"}, Source: "Hello"},
+		},
+		Prompts: []PromptData{{
+			PromptRef: PromptRef{Name: "greet"},
+			Source:    "---\nmodel: test/model\ninput:\n  schema:\n    name: string\n---\n{{> header}}{{> header@v2}}",
+		}},
+	}
+
+	ir, err := dp.CompileIR(bundle)
+	if err != nil {
+		t.Fatalf("CompileIR() returned error: %v", err)
+	}
+	if ir.Format != IRFormat {
+		t.Errorf("Format = %q, want %q", ir.Format, IRFormat)
+	}
+
+	var partials []PartialRef
+	for _, p := range ir.Partials {
+		partials = append(partials, p.PartialRef)
+	}
+	wantPartials := []PartialRef{{Name: "footer"}, {Name: "header"}, {Name: "header", Variant: "v2"}}
+	if diff := cmp.Diff(wantPartials, partials); diff != "" {
+		t.Errorf("Partials mismatch (-want +got):\n%s", diff)
+	}
+
+	prompt := ir.Prompts[0]
+	if diff := cmp.Diff(wantPartials, prompt.Partials); diff != "" {
+		t.Errorf("prompt dependencies mismatch (-want +got):\n%s", diff)
+	}
+	if prompt.Metadata.Model != "test/model" {
+		t.Errorf("Metadata.Model = %q, want %q", prompt.Metadata.Model, "test/model")
+	}
+	schema, ok := prompt.Metadata.Input.Schema.(*jsonschema.Schema)
+	if !ok || schema.Type != "object" {
+		t.Errorf("Metadata.Input.Schema = %v, want an expanded JSON schema", prompt.Metadata.Input.Schema)
+	}
+	if got := prompt.Template.Body[1].Name.Original; got != "[header@v2]" {
+		t.Errorf("pinned partial name = %q, want %q", got, "[header@v2]")
+	}
+}
+
+func TestCompileIRTemplateJSON(t *testing.T) {
+	node, err := compileIRTemplate(`Hi {{#if admin}}{{json user indent=2}}{{else}}{{{name}}}{{/if}}`)
+	if err != nil {
+		t.Fatalf("compileIRTemplate() returned error: %v", err)
+	}
+	got, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	want := `{"type":"Program","body":[` +
+		`{"type":"ContentStatement","value":"Hi "},` +
+		`{"type":"BlockStatement","path":{"type":"PathExpression","original":"if","parts":["if"]},` +
+		`"params":[{"type":"PathExpression","original":"admin","parts":["admin"]}],` +
+		`"program":{"type":"Program","body":[{"type":"MustacheStatement",` +
+		`"path":{"type":"PathExpression","original":"json","parts":["json"]},` +
+		`"params":[{"type":"PathExpression","original":"user","parts":["user"]}],` +
+		`"hash":{"type":"Hash","pairs":[{"type":"HashPair","key":"indent","value":{"type":"NumberLiteral","value":2,"original":"2"}}]},` +
+		`"escaped":true}]},` +
+		`"inverse":{"type":"Program","body":[{"type":"MustacheStatement",` +
+		`"path":{"type":"PathExpression","original":"name","parts":["name"]}}]}}]}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("IR JSON mismatch (-want +got):\n%s", diff)
+	}
+}
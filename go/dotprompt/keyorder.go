@@ -0,0 +1,132 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+)
+
+// KeyOrder records the order in which the keys of a YAML mapping, and of the
+// mappings nested in it, appear in the source. Frontmatter is decoded into Go
+// maps, which do not keep that order, so it is recorded alongside them to
+// make output derived from the maps, such as the properties of an expanded
+// Picoschema or the frontmatter written by Serialize, follow the source.
+//
+// A nil *KeyOrder is valid and orders keys alphabetically.
+type KeyOrder struct {
+	Keys []string `json:"keys,omitempty"`
+	// Nested holds the order of the mappings nested in this one, by key. For
+	// mappings nested in a sequence, the key is the index of the item.
+	Nested map[string]*KeyOrder `json:"nested,omitempty"`
+}
+
+// Child returns the order of the mapping nested under key, or nil if none was
+// recorded.
+func (o *KeyOrder) Child(key string) *KeyOrder {
+	if o == nil {
+		return nil
+	}
+	return o.Nested[key]
+}
+
+// Sort returns the keys of m: first those recorded in o, in their recorded
+// order, then the others in alphabetical order.
+func (o *KeyOrder) Sort(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	if o != nil {
+		for _, k := range o.Keys {
+			if _, ok := m[k]; ok && !seen[k] {
+				keys = append(keys, k)
+				seen[k] = true
+			}
+		}
+	}
+	rest := len(keys)
+	for k := range m {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[rest:])
+	return keys
+}
+
+// yamlKeyOrder decodes the YAML document source and returns the order of its
+// keys, or nil if it is not a mapping.
+func yamlKeyOrder(source []byte) *KeyOrder {
+	var ordered yaml.MapSlice
+	if err := yaml.UnmarshalWithOptions(source, &ordered, yaml.UseOrderedMap()); err != nil {
+		return nil
+	}
+	return keyOrderOf(ordered)
+}
+
+// keyOrderOf returns the key order of a value decoded with
+// yaml.UseOrderedMap, or nil if it contains no mappings.
+func keyOrderOf(value any) *KeyOrder {
+	order := &KeyOrder{}
+	nest := func(key string, child any) {
+		if nested := keyOrderOf(child); nested != nil {
+			if order.Nested == nil {
+				order.Nested = make(map[string]*KeyOrder)
+			}
+			order.Nested[key] = nested
+		}
+	}
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for _, item := range v {
+			key := fmt.Sprint(item.Key)
+			order.Keys = append(order.Keys, key)
+			nest(key, item.Value)
+		}
+		return order
+	case []any:
+		for i, item := range v {
+			nest(strconv.Itoa(i), item)
+		}
+		if order.Nested != nil {
+			return order
+		}
+	}
+	return nil
+}
+
+// orderedYAML converts value, as decoded from YAML into Go maps, into a form
+// that encodes its mappings in the given order.
+func orderedYAML(value any, order *KeyOrder) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(yaml.MapSlice, 0, len(v))
+		for _, k := range order.Sort(v) {
+			out = append(out, yaml.MapItem{Key: k, Value: orderedYAML(v[k], order.Child(k))})
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = orderedYAML(item, order.Child(strconv.Itoa(i)))
+		}
+		return out
+	}
+	return value
+}
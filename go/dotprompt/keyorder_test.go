@@ -0,0 +1,97 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKeyOrderSort(t *testing.T) {
+	m := map[string]any{"b": 1, "z": 2, "a": 3, "c": 4}
+	tests := []struct {
+		name  string
+		order *KeyOrder
+		want  []string
+	}{
+		{name: "nil order", order: nil, want: []string{"a", "b", "c", "z"}},
+		{name: "full order", order: &KeyOrder{Keys: []string{"z", "c", "a", "b"}}, want: []string{"z", "c", "a", "b"}},
+		{name: "partial order", order: &KeyOrder{Keys: []string{"z", "gone", "b"}}, want: []string{"z", "b", "a", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tt.order.Sort(m)); diff != "" {
+				t.Errorf("Sort() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseRecordsKeyOrder(t *testing.T) {
+	source := "---\nmodel: m\nzeta.ext: 1\nconfig:\n  topK: 3\n  temperature: 0.5\ntoolDefs:\n  - name: t\n    description: d\n---\nHi"
+	parsed, err := ParseDocument(source)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	want := &KeyOrder{
+		Keys: []string{"model", "zeta.ext", "config", "toolDefs"},
+		Nested: map[string]*KeyOrder{
+			"config": {Keys: []string{"topK", "temperature"}},
+			"toolDefs": {Nested: map[string]*KeyOrder{
+				"0": {Keys: []string{"name", "description"}},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, parsed.RawKeyOrder); diff != "" {
+		t.Errorf("RawKeyOrder mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPicoschemaFollowsSourceOrder(t *testing.T) {
+	source := "---\ninput:\n  schema:\n    zebra: string\n    apple?: integer\n    mango(object):\n      y: string\n      x: string\n---\n{{zebra}}"
+	dp := NewDotprompt(nil)
+	var first string
+	for range 20 {
+		parsed, err := dp.Parse(source)
+		if err != nil {
+			t.Fatalf("Parse() returned error: %v", err)
+		}
+		meta, err := dp.RenderMetadata(parsed, nil)
+		if err != nil {
+			t.Fatalf("RenderMetadata() returned error: %v", err)
+		}
+		b, err := json.Marshal(meta.Input.Schema)
+		if err != nil {
+			t.Fatalf("json.Marshal() returned error: %v", err)
+		}
+		if first == "" {
+			first = string(b)
+			continue
+		}
+		if diff := cmp.Diff(first, string(b)); diff != "" {
+			t.Fatalf("schema JSON is not stable (-first +got):\n%s", diff)
+		}
+	}
+	want := `{"properties":{"zebra":{"type":"string"},"apple":{"anyOf":[{"type":"integer"},{"type":"null"}],"type":"integer"},` +
+		`"mango":{"properties":{"y":{"type":"string"},"x":{"type":"string"}},"type":"object","required":["x","y"]}},` +
+		`"type":"object","required":["mango","zebra"]}`
+	if diff := cmp.Diff(want, first); diff != "" {
+		t.Errorf("schema JSON mismatch (-want +got):\n%s", diff)
+	}
+}
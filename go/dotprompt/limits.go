@@ -0,0 +1,219 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mbleigh/raymond"
+)
+
+// RenderLimits bounds the resources a single prompt may consume. A zero value
+// for any field disables that limit.
+type RenderLimits struct {
+	// MaxPartialDepth is the maximum nesting depth of partials, where a partial
+	// referenced directly from the template has depth 1. It is checked when the
+	// prompt is compiled, and when a partial named at render time is loaded,
+	// counting that partial as depth 1; recursive partials always exceed it.
+	MaxPartialDepth int
+	// MaxOutputBytes is the maximum size of the rendered template string.
+	// The template engine builds the whole string before it can be measured,
+	// so only the output of a single helper is checked as it is produced.
+	MaxOutputBytes int
+	// Timeout is the maximum time allowed for template execution, including
+	// helpers. A render that times out is abandoned rather than stopped: it
+	// runs on until its next helper call, which fails, so a helper that never
+	// returns, or a long loop that calls no helpers, keeps its goroutine busy.
+	Timeout time.Duration
+}
+
+// PartialDepthError is returned when partials nest deeper than
+// RenderLimits.MaxPartialDepth.
+type PartialDepthError struct {
+	Max int
+	// Chain is the sequence of partial names that exceeded the limit.
+	Chain []string
+}
+
+func (e *PartialDepthError) Error() string {
+	return fmt.Sprintf("dotprompt: partial nesting exceeds max depth %d: %s",
+		e.Max, strings.Join(e.Chain, " > "))
+}
+
+// OutputSizeError is returned when the rendered output exceeds
+// RenderLimits.MaxOutputBytes.
+type OutputSizeError struct {
+	Max  int
+	Size int
+}
+
+func (e *OutputSizeError) Error() string {
+	return fmt.Sprintf("dotprompt: rendered output of %d bytes exceeds max %d bytes", e.Size, e.Max)
+}
+
+// RenderTimeoutError is returned when template execution takes longer than
+// RenderLimits.Timeout.
+type RenderTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *RenderTimeoutError) Error() string {
+	return fmt.Sprintf("dotprompt: template execution exceeded timeout of %s", e.Timeout)
+}
+
+// checkPartialDepth walks the partials referenced by template and returns a
+// PartialDepthError if the nesting exceeds the configured maximum.
+func (dp *Dotprompt) checkPartialDepth(template string) error {
+	return dp.checkPartialDepthFrom(template, nil)
+}
+
+// checkPartialDepthFrom is checkPartialDepth for source, the source of the
+// last partial in chain.
+func (dp *Dotprompt) checkPartialDepthFrom(source string, chain []string) error {
+	maxDepth := dp.limits.MaxPartialDepth
+	if maxDepth <= 0 {
+		return nil
+	}
+
+	var walk func(source string, chain []string) error
+	walk = func(source string, chain []string) error {
+		refs, err := partialReferences(source)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			name := ref.Name
+			next := append(chain[:len(chain):len(chain)], name)
+			if len(next) > maxDepth {
+				return &PartialDepthError{Max: maxDepth, Chain: next}
+			}
+			partialSource, ok := dp.partialSources[name]
+			if !ok {
+				continue
+			}
+			if err := walk(partialSource, next); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(chain) > maxDepth {
+		return &PartialDepthError{Max: maxDepth, Chain: chain}
+	}
+	return walk(source, chain)
+}
+
+// abortKey is the `@` data variable holding the flag that execTemplate sets
+// when it abandons a render that timed out.
+const abortKey = "__dotpromptAbort"
+
+// execTemplate executes tpl, enforcing the configured timeout and output size.
+func (dp *Dotprompt) execTemplate(tpl EngineTemplate, ctx map[string]any, data map[string]any) (string, error) {
+	var rendered string
+	var err error
+	if dp.limits.Timeout > 0 {
+		type result struct {
+			rendered string
+			err      error
+		}
+		aborted := new(atomic.Bool)
+		data = maps.Clone(data)
+		if data == nil {
+			data = make(map[string]any)
+		}
+		data[abortKey] = aborted
+		done := make(chan result, 1)
+		go func() {
+			r, e := tpl.Exec(ctx, data)
+			done <- result{r, e}
+		}()
+
+		timer := time.NewTimer(dp.limits.Timeout)
+		defer timer.Stop()
+		select {
+		case r := <-done:
+			rendered, err = r.rendered, r.err
+		case <-timer.C:
+			aborted.Store(true)
+			return "", &RenderTimeoutError{Timeout: dp.limits.Timeout}
+		}
+	} else {
+		rendered, err = tpl.Exec(ctx, data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if maxBytes := dp.limits.MaxOutputBytes; maxBytes > 0 && len(rendered) > maxBytes {
+		return "", &OutputSizeError{Max: maxBytes, Size: len(rendered)}
+	}
+	return rendered, nil
+}
+
+// limitHelper wraps helper to enforce the render limits when it is called:
+// it fails once execTemplate has abandoned the render, and when its own
+// output exceeds MaxOutputBytes. The wrapper always receives a
+// *raymond.Options, whether or not helper does. Helpers are returned
+// unchanged when no limit applies.
+func (dp *Dotprompt) limitHelper(helper any) any {
+	limits := dp.limits
+	fn := reflect.ValueOf(helper)
+	if (limits.Timeout <= 0 && limits.MaxOutputBytes <= 0) || fn.Kind() != reflect.Func || fn.Type().IsVariadic() {
+		return helper
+	}
+	fnType := fn.Type()
+	optionsType := reflect.TypeOf(&raymond.Options{})
+	in := make([]reflect.Type, fnType.NumIn())
+	for i := range in {
+		in[i] = fnType.In(i)
+	}
+	takesOptions := len(in) > 0 && in[len(in)-1] == optionsType
+	if !takesOptions {
+		in = append(in, optionsType)
+	}
+	out := make([]reflect.Type, fnType.NumOut())
+	for i := range out {
+		out[i] = fnType.Out(i)
+	}
+
+	return reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		if options, _ := args[len(args)-1].Interface().(*raymond.Options); options != nil {
+			if aborted, ok := options.DataFrame().Get(abortKey).(*atomic.Bool); ok && aborted.Load() {
+				panic(&RenderTimeoutError{Timeout: limits.Timeout})
+			}
+		}
+		if !takesOptions {
+			args = args[:len(args)-1]
+		}
+		results := fn.Call(args)
+		if limits.MaxOutputBytes > 0 && len(results) > 0 {
+			result := results[0]
+			if result.Kind() == reflect.Interface {
+				result = result.Elem()
+			}
+			if result.Kind() == reflect.String && result.Len() > limits.MaxOutputBytes {
+				panic(&OutputSizeError{Max: limits.MaxOutputBytes, Size: result.Len()})
+			}
+		}
+		return results
+	}).Interface()
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mbleigh/raymond"
+)
+
+func TestMaxPartialDepth(t *testing.T) {
+	partials := map[string]string{
+		"a": "A {{> b}}",
+		"b": "B {{> c}}",
+		"c": "C",
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+		dp := NewDotprompt(&DotpromptOptions{Partials: partials, Limits: RenderLimits{MaxPartialDepth: 3}})
+		got := renderText(t, dp, "{{> a}}", nil)
+		if got != "A B C" {
+			t.Errorf("rendered text = %q, want %q", got, "A B C")
+		}
+	})
+
+	t.Run("exceeds limit", func(t *testing.T) {
+		dp := NewDotprompt(&DotpromptOptions{Partials: partials, Limits: RenderLimits{MaxPartialDepth: 2}})
+		_, err := dp.Compile("{{> a}}", nil)
+		var depthErr *PartialDepthError
+		if !errors.As(err, &depthErr) {
+			t.Fatalf("Compile() error = %v, want *PartialDepthError", err)
+		}
+		if got := strings.Join(depthErr.Chain, ","); got != "a,b,c" {
+			t.Errorf("depthErr.Chain = %q, want %q", got, "a,b,c")
+		}
+	})
+
+	t.Run("recursive partials", func(t *testing.T) {
+		resolver := func(name string) (string, error) {
+			if name == "ping" {
+				return "ping {{> pong}}", nil
+			}
+			return "pong {{> ping}}", nil
+		}
+		dp := NewDotprompt(&DotpromptOptions{PartialResolver: resolver, Limits: RenderLimits{MaxPartialDepth: 10}})
+		_, err := dp.Compile("{{> ping}}", nil)
+		var depthErr *PartialDepthError
+		if !errors.As(err, &depthErr) {
+			t.Fatalf("Compile() error = %v, want *PartialDepthError", err)
+		}
+	})
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{Limits: RenderLimits{MaxOutputBytes: 10}})
+
+	if got := renderText(t, dp, "{{text}}", map[string]any{"text": "short"}); got != "short" {
+		t.Errorf("rendered text = %q, want %q", got, "short")
+	}
+
+	_, err := dp.Render("{{text}}", &DataArgument{Input: map[string]any{"text": "this is far too long"}}, nil)
+	var sizeErr *OutputSizeError
+	if !errors.As(err, &sizeErr) {
+		t.Fatalf("Render() error = %v, want *OutputSizeError", err)
+	}
+	if sizeErr.Size != 20 {
+		t.Errorf("sizeErr.Size = %d, want 20", sizeErr.Size)
+	}
+}
+
+func TestRenderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	dp := NewDotprompt(&DotpromptOptions{
+		Helpers: map[string]any{
+			"slow": func() string {
+				<-release
+				return "done"
+			},
+		},
+		Limits: RenderLimits{Timeout: 10 * time.Millisecond},
+	})
+
+	_, err := dp.Render("{{slow}}", &DataArgument{}, nil)
+	var timeoutErr *RenderTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Render() error = %v, want *RenderTimeoutError", err)
+	}
+}
+
+func TestRenderTimeoutStopsHelpers(t *testing.T) {
+	release := make(chan struct{})
+	var after atomic.Int32
+	dp := NewDotprompt(&DotpromptOptions{
+		Helpers: map[string]any{
+			"slow": func() string {
+				<-release
+				return "done"
+			},
+			"after": func() string {
+				after.Add(1)
+				return "after"
+			},
+		},
+		Limits: RenderLimits{Timeout: 10 * time.Millisecond},
+	})
+
+	_, err := dp.Render("{{slow}}{{#each items}}{{after}}{{/each}}", &DataArgument{Input: map[string]any{"items": []any{1, 2, 3}}}, nil)
+	var timeoutErr *RenderTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Render() error = %v, want *RenderTimeoutError", err)
+	}
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	if got := after.Load(); got != 0 {
+		t.Errorf("abandoned render called a helper %d times, want 0", got)
+	}
+}
+
+func TestMaxOutputBytesHelper(t *testing.T) {
+	var after atomic.Int32
+	dp := NewDotprompt(&DotpromptOptions{
+		Helpers: map[string]any{
+			"big": func(options *raymond.Options) string { return strings.Repeat("x", 1000) },
+			"after": func() string {
+				after.Add(1)
+				return ""
+			},
+		},
+		Limits: RenderLimits{MaxOutputBytes: 10},
+	})
+
+	_, err := dp.Render("{{big}}{{after}}", &DataArgument{}, nil)
+	var sizeErr *OutputSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Size != 1000 {
+		t.Fatalf("Render() error = %v, want *OutputSizeError of 1000 bytes", err)
+	}
+	if got := after.Load(); got != 0 {
+		t.Errorf("helper after the oversized output was called %d times, want 0", got)
+	}
+	if got := renderText(t, dp, "{{after}}ok", nil); got != "ok" {
+		t.Errorf("rendered text = %q, want %q", got, "ok")
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lint provides static checks for `.prompt` files.
+//
+// # Lint Rules
+//
+// Errors:
+//
+//	invalid-yaml        Invalid YAML frontmatter
+//	invalid-template    Handlebars syntax error
+//	invalid-schema      Input or output Picoschema cannot be parsed
+//	missing-partial     Referenced partial not found in the store
+//	circular-partial    Circular partial dependency
+//
+// Warnings:
+//
+//	undefined-variable  Variable used in template but not in input schema
+//	unused-variable     Variable in input schema but not used in template
+//
+// The rule codes match those reported by the promptly CLI. ValidateStore,
+// which checks a whole store for CI gates, reports a few more rules.
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/invopop/jsonschema"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity string
+
+// Diagnostic severities.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic is a single problem found in a prompt file.
+type Diagnostic struct {
+	// File is the path of the file, as given to the linter.
+	File string `json:"file"`
+	// Code is the rule code, e.g. "invalid-yaml".
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Line and Column are 1-based; zero means the whole file.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	loc := d.File
+	if d.Line > 0 {
+		loc = fmt.Sprintf("%s:%d:%d", d.File, d.Line, max(d.Column, 1))
+	}
+	return fmt.Sprintf("%s: %s[%s]: %s", loc, d.Severity, d.Code, d.Message)
+}
+
+// Options configures the linter.
+type Options struct {
+	// Store resolves partials referenced by the prompt. When nil, partial
+	// references are not checked.
+	Store dp.PromptStore
+}
+
+// Source lints the contents of a single prompt file.
+func Source(file, source string, opts *Options) []Diagnostic {
+	if opts == nil {
+		opts = &Options{}
+	}
+	l := &linter{file: file, opts: opts}
+	l.lint(source)
+	l.sort()
+	return l.diags
+}
+
+// Store lints every prompt and partial in store. File names in diagnostics
+// are the prompt names with the `.prompt` extension.
+func Store(store dp.PromptStore) ([]Diagnostic, error) {
+	opts := &Options{Store: store}
+	var diags []Diagnostic
+
+	prompts, err := store.List(dp.ListPromptsOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range prompts.Items {
+		prompt, err := store.Load(ref.Name, dp.LoadPromptOptions{Variant: ref.Variant})
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, Source(fileName(ref.Name, "", ref.Variant), prompt.Source, opts)...)
+	}
+
+	partials, err := store.ListPartials(dp.ListPartialsOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range partials.Items {
+		partial, err := store.LoadPartial(ref.Name, dp.LoadPartialOptions{Variant: ref.Variant})
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, Source(fileName(ref.Name, "_", ref.Variant), partial.Source, opts)...)
+	}
+	return diags, nil
+}
+
+// HasErrors reports whether any diagnostic has error severity.
+func HasErrors(diags []Diagnostic) bool {
+	return slices.ContainsFunc(diags, func(d Diagnostic) bool {
+		return d.Severity == SeverityError
+	})
+}
+
+// fileName reconstructs the store-relative file name of a prompt or partial.
+func fileName(name, prefix, variant string) string {
+	dir, base := "", name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		dir, base = name[:i+1], name[i+1:]
+	}
+	if variant != "" {
+		base += "." + variant
+	}
+	return dir + prefix + base + ".prompt"
+}
+
+// linter holds the state for linting a single file.
+type linter struct {
+	file  string
+	opts  *Options
+	diags []Diagnostic
+	// parsed is the parsed file, set by lint if its frontmatter parses.
+	parsed *dp.ParsedPrompt
+}
+
+func (l *linter) report(code string, severity Severity, line, column int, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		File:     l.file,
+		Code:     code,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Line:     line,
+		Column:   column,
+	})
+}
+
+// sort orders the diagnostics found so far by line.
+func (l *linter) sort() {
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		return l.diags[i].Line < l.diags[j].Line
+	})
+}
+
+func (l *linter) lint(source string) {
+	frontmatter, body, bodyLine := splitSource(source)
+
+	parsed, err := dp.ParseOptions{StrictYAML: true}.Parse(source)
+	if err != nil {
+		line := 1
+		if frontmatter != "" {
+			line = 2
+		}
+		l.report("invalid-yaml", SeverityError, line, 1, "%v", err)
+		return
+	}
+	l.parsed = &parsed
+
+	l.checkSchemas(parsed)
+
+	vars, err := dp.TemplateVariables(body)
+	if err != nil {
+		l.report("invalid-template", SeverityError, bodyLine, 1, "invalid template: %v", err)
+		return
+	}
+	l.checkVariables(parsed, vars, body, bodyLine)
+	l.checkPartials(body, bodyLine)
+}
+
+// checkSchemas verifies that the input and output Picoschemas parse. Named
+// schema references cannot be resolved statically and are accepted.
+func (l *linter) checkSchemas(parsed dp.ParsedPrompt) {
+	opts := &dp.PicoschemaOptions{
+		SchemaResolver: func(string) (*jsonschema.Schema, error) {
+			return &jsonschema.Schema{}, nil
+		},
+	}
+	if parsed.Input.Schema != nil {
+		if _, err := dp.Picoschema(parsed.Input.Schema, opts); err != nil {
+			l.report("invalid-schema", SeverityError, 0, 0, "invalid input schema: %v", err)
+		}
+	}
+	if parsed.Output.Schema != nil {
+		if _, err := dp.Picoschema(parsed.Output.Schema, opts); err != nil {
+			l.report("invalid-schema", SeverityError, 0, 0, "invalid output schema: %v", err)
+		}
+	}
+}
+
+// checkVariables compares template variables against the properties of an
+// inline input schema.
+func (l *linter) checkVariables(parsed dp.ParsedPrompt, vars []dp.TemplateVariable, body string, bodyLine int) {
+	properties, ok := schemaProperties(parsed.Input.Schema)
+	if !ok {
+		return
+	}
+	for _, name := range defaultKeys(parsed.Input.Default) {
+		properties[name] = true
+	}
+
+	used := make(map[string]bool)
+	for _, v := range vars {
+		used[v.Name] = true
+		if !properties[v.Name] {
+			l.report("undefined-variable", SeverityWarning, bodyLine+v.Line-1, column(body, v.Pos),
+				"variable '%s' is used in template but not defined in input schema", v.Name)
+		}
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !used[name] {
+			l.report("unused-variable", SeverityWarning, 0, 0,
+				"variable '%s' is defined in input schema but never used in template", name)
+		}
+	}
+}
+
+// checkPartials verifies that referenced partials exist in the store and do
+// not form a cycle.
+func (l *linter) checkPartials(body string, bodyLine int) {
+	if l.opts.Store == nil {
+		return
+	}
+	refs, err := dp.TemplatePartials(body)
+	if err != nil {
+		return
+	}
+
+	sources := make(map[string]string)
+	var load func(name string) (string, bool)
+	load = func(name string) (string, bool) {
+		if source, ok := sources[name]; ok {
+			return source, true
+		}
+		partial, err := l.opts.Store.LoadPartial(name, dp.LoadPartialOptions{})
+		if err != nil {
+			return "", false
+		}
+		sources[name] = partial.Source
+		return partial.Source, true
+	}
+
+	var findCycle func(name string, stack []string) []string
+	findCycle = func(name string, stack []string) []string {
+		if slices.Contains(stack, name) {
+			return append(stack, name)
+		}
+		source, ok := load(name)
+		if !ok {
+			return nil
+		}
+		nested, err := dp.TemplatePartials(source)
+		if err != nil {
+			return nil
+		}
+		for _, ref := range nested {
+			if cycle := findCycle(ref.Name, append(slices.Clone(stack), name)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	for _, ref := range refs {
+		if _, ok := load(ref.Name); !ok {
+			l.report("missing-partial", SeverityError, bodyLine+ref.Line-1, column(body, ref.Pos),
+				"partial '%s' not found", ref.Name)
+			continue
+		}
+		if cycle := findCycle(ref.Name, nil); cycle != nil {
+			l.report("circular-partial", SeverityError, bodyLine+ref.Line-1, column(body, ref.Pos),
+				"circular partial dependency: %s", strings.Join(cycle, " -> "))
+		}
+	}
+}
+
+// splitSource splits a prompt file into frontmatter and body and returns the
+// 1-based line on which the body starts.
+func splitSource(source string) (frontmatter, body string, bodyLine int) {
+	fmStart, fmEnd, bodyStart, ok := dp.FrontmatterBounds(source)
+	if !ok {
+		return "", source, 1
+	}
+	return source[fmStart:fmEnd], source[bodyStart:], strings.Count(source[:bodyStart], "\n") + 1
+}
+
+// column returns the 1-based column, in characters, of the byte offset pos
+// within text.
+func column(text string, pos int) int {
+	lineStart := strings.LastIndexByte(text[:pos], '\n') + 1
+	return utf8.RuneCountInString(text[lineStart:pos]) + 1
+}
+
+// schemaProperties returns the top-level property names of an inline
+// Picoschema or JSON Schema object. It reports false when the schema is
+// absent, a named reference or not an object.
+func schemaProperties(schema dp.Schema) (map[string]bool, bool) {
+	m, ok := schema.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if props, ok := m["properties"].(map[string]any); ok {
+		m = props
+	} else if _, isJSONSchema := m["type"]; isJSONSchema {
+		return nil, false
+	}
+
+	properties := make(map[string]bool)
+	for key := range m {
+		name := key
+		if i := strings.IndexAny(name, "(?"); i >= 0 {
+			name = name[:i]
+		}
+		properties[strings.TrimSpace(name)] = true
+	}
+	return properties, true
+}
+
+// defaultKeys returns the keys of the input defaults.
+func defaultKeys(defaults map[string]any) []string {
+	keys := make([]string, 0, len(defaults))
+	for k := range defaults {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+func codes(diags []Diagnostic) []string {
+	var out []string
+	for _, d := range diags {
+		out = append(out, d.Code)
+	}
+	return out
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "valid",
+			source: "---\nmodel: test/model\ninput:\n  schema:\n    name: string\n---\nHello {{name}}!\n",
+		},
+		{
+			name:   "no schema skips variable checks",
+			source: "Hello {{name}}!",
+		},
+		{
+			name:   "invalid yaml",
+			source: "---\nmodel: [unclosed\n---\nHello\n",
+			want:   []string{"invalid-yaml"},
+		},
+		{
+			name:   "invalid template",
+			source: "---\nmodel: test/model\n---\n{{#if x}}never closed\n",
+			want:   []string{"invalid-template"},
+		},
+		{
+			name:   "invalid schema",
+			source: "---\noutput:\n  schema:\n    name(string):\n      x: string\n---\nHello\n",
+			want:   []string{"invalid-schema"},
+		},
+		{
+			name:   "variables",
+			source: "---\ninput:\n  schema:\n    name: string\n    age?: integer\n---\nHello {{name}} from {{city}}\n",
+			want:   []string{"unused-variable", "undefined-variable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codes(Source("test.prompt", tt.source, nil))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Source() codes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceLineNumbers(t *testing.T) {
+	source := "---\ninput:\n  schema:\n    name: string\n---\nHello {{name}}\n\n{{city}}\n"
+	diags := Source("test.prompt", source, nil)
+	if len(diags) != 1 {
+		t.Fatalf("Source() = %v, want one diagnostic", diags)
+	}
+	if diags[0].Line != 8 {
+		t.Errorf("diags[0].Line = %d, want 8", diags[0].Line)
+	}
+	want := "test.prompt:8:3: warning[undefined-variable]: variable 'city' is used in template but not defined in input schema"
+	if diags[0].String() != want {
+		t.Errorf("diags[0].String() = %q, want %q", diags[0].String(), want)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := storetest.NewDirStore(t, map[string]string{
+		"ok.prompt":          "Hello {{> header}}",
+		"missing.prompt":     "Hello {{> nope}}",
+		"cycle.prompt":       "{{> a}}",
+		"_header.prompt":     "Header",
+		"_a.prompt":          "{{> b}}",
+		"_b.prompt":          "{{> a}}",
+		"sub/deep.prompt":    "{{#if}}",
+		"sub/deep.v2.prompt": "fine",
+	})
+
+	diags, err := Store(store)
+	if err != nil {
+		t.Fatalf("Store() returned error: %v", err)
+	}
+
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.File+":"+d.Code)
+	}
+	want := []string{
+		"cycle.prompt:circular-partial",
+		"missing.prompt:missing-partial",
+		"sub/deep.prompt:invalid-template",
+		"_a.prompt:circular-partial",
+		"_b.prompt:circular-partial",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Store() = %v, want %v", got, want)
+	}
+	if !HasErrors(diags) {
+		t.Error("HasErrors() = false, want true")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	diags := []Diagnostic{
+		{File: "a.prompt", Code: "invalid-yaml", Severity: SeverityError, Message: "bad", Line: 2, Column: 1},
+		{File: "b.prompt", Code: "unused-variable", Severity: SeverityWarning, Message: "unused"},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, "dotprompt", diags); err != nil {
+		t.Fatalf("WriteSARIF() returned error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("json.Unmarshal() returned error: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v, want one 2.1.0 run", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 2 {
+		t.Errorf("run = %+v, want 2 rules and 2 results", run)
+	}
+	if run.Results[0].Locations[0].PhysicalLocation.Region.StartLine != 2 {
+		t.Errorf("Results[0] start line = %d, want 2", run.Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+	}
+	if run.Results[1].Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("Results[1] region = %+v, want nil", run.Results[1].Locations[0].PhysicalLocation.Region)
+	}
+}
+
+func TestWriteSARIFURIs(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(filepath.Dir(wd), "other dir", "c.prompt")
+	diags := []Diagnostic{
+		{File: "sub/a.prompt", Code: "invalid-yaml", Severity: SeverityError},
+		{File: filepath.ToSlash(filepath.Join(wd, "b.prompt")), Code: "invalid-yaml", Severity: SeverityError},
+		{File: filepath.ToSlash(outside), Code: "invalid-yaml", Severity: SeverityError},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, "dotprompt", diags); err != nil {
+		t.Fatalf("WriteSARIF() returned error: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("json.Unmarshal() returned error: %v", err)
+	}
+
+	var got []sarifArtifactLocation
+	for _, result := range log.Runs[0].Results {
+		got = append(got, result.Locations[0].PhysicalLocation.ArtifactLocation)
+	}
+	want := []sarifArtifactLocation{
+		{URI: "sub/a.prompt", URIBaseID: "%SRCROOT%"},
+		{URI: "b.prompt", URIBaseID: "%SRCROOT%"},
+		{URI: fileURI(outside)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("artifact locations mismatch (-want +got):\n%s", diff)
+	}
+	if !strings.HasSuffix(got[2].URI, "/other%20dir/c.prompt") {
+		t.Errorf("outside URI = %q, want an escaped file URI", got[2].URI)
+	}
+	if base := log.Runs[0].OriginalURIBaseIDs["%SRCROOT%"].URI; base != fileURI(wd)+"/" {
+		t.Errorf("%%SRCROOT%% = %q, want %q", base, fileURI(wd)+"/")
+	}
+}
+
+func TestSourceColumns(t *testing.T) {
+	store := storetest.NewDirStore(t, map[string]string{})
+	source := "---\ninput:\n  schema:\n    name: string\n---\nHi {{name}}, {{nme}}\n  {{> missing}}\n"
+	var got []string
+	for _, d := range Source("test.prompt", source, &Options{Store: store}) {
+		got = append(got, fmt.Sprintf("%d:%d:%s", d.Line, d.Column, d.Code))
+	}
+	want := []string{"6:16:undefined-variable", "7:3:missing-partial"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Source() positions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteJSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, nil); err != nil {
+		t.Fatalf("WriteJSON() returned error: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("WriteJSON(nil) = %q, want %q", got, "[]")
+	}
+}
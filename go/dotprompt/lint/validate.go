@@ -0,0 +1,205 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lint
+
+import (
+	"errors"
+	"strings"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// File kinds in a ValidationReport.
+const (
+	KindPrompt  = "prompt"
+	KindPartial = "partial"
+)
+
+// ValidationReport is the outcome of ValidateStore.
+type ValidationReport struct {
+	// Files has an entry for every prompt and partial in the store, including
+	// those without findings, in listing order: prompts first, then partials.
+	Files []FileReport `json:"files"`
+	// Errors and Warnings count the diagnostics of each severity.
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+// FileReport holds the findings for one prompt or partial.
+type FileReport struct {
+	// File is the store-relative file name, e.g. "sub/_card.v2.prompt".
+	File string `json:"file"`
+	// Kind is KindPrompt or KindPartial.
+	Kind        string       `json:"kind"`
+	Name        string       `json:"name"`
+	Variant     string       `json:"variant,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// HasErrors reports whether any diagnostic in the report has error severity.
+func (r *ValidationReport) HasErrors() bool {
+	return r.Errors > 0
+}
+
+// Diagnostics returns the diagnostics of all files, for writing with
+// WriteText, WriteJSON or WriteSARIF.
+func (r *ValidationReport) Diagnostics() []Diagnostic {
+	var diags []Diagnostic
+	for _, f := range r.Files {
+		diags = append(diags, f.Diagnostics...)
+	}
+	return diags
+}
+
+// ValidateStore checks every prompt and partial in store, following all pages
+// of its listings, and groups the findings by file. Each file is linted as by
+// Store; in addition ValidateStore reports:
+//
+//	load-error           The prompt or partial cannot be loaded (error)
+//	invalid-frontmatter  Unknown frontmatter key or value of the wrong type,
+//	                     as reported by dotprompt.ValidateFrontmatter (error)
+//	unused-partial       Partial not referenced by any prompt or partial (info)
+//
+// It returns an error only if the store cannot be listed.
+func ValidateStore(store dp.PromptStore) (*ValidationReport, error) {
+	prompts, err := listAll(func(cursor string) ([]dp.PromptRef, string, error) {
+		list, err := store.List(dp.ListPromptsOptions{Cursor: cursor})
+		return list.Items, list.Cursor, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	partials, err := listAll(func(cursor string) ([]dp.PartialRef, string, error) {
+		list, err := store.ListPartials(dp.ListPartialsOptions{Cursor: cursor})
+		return list.Items, list.Cursor, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &Options{Store: store}
+	report := &ValidationReport{}
+	referenced := make(map[string]bool)
+	validate := func(file FileReport, load func() (string, error)) {
+		l := &linter{file: file.File, opts: opts}
+		source, err := load()
+		if err != nil {
+			l.report("load-error", SeverityError, 0, 0, "%v", err)
+			file.Diagnostics = l.diags
+		} else {
+			l.lint(source)
+			l.sort()
+			if l.parsed != nil {
+				l.checkFrontmatter(*l.parsed)
+			}
+			file.Diagnostics = l.diags
+			collectPartials(source, referenced)
+		}
+		report.Files = append(report.Files, file)
+	}
+
+	for _, ref := range prompts {
+		validate(FileReport{
+			File:    fileName(ref.Name, "", ref.Variant),
+			Kind:    KindPrompt,
+			Name:    ref.Name,
+			Variant: ref.Variant,
+		}, func() (string, error) {
+			prompt, err := store.Load(ref.Name, dp.LoadPromptOptions{Variant: ref.Variant})
+			return prompt.Source, err
+		})
+	}
+	for _, ref := range partials {
+		validate(FileReport{
+			File:    fileName(ref.Name, "_", ref.Variant),
+			Kind:    KindPartial,
+			Name:    ref.Name,
+			Variant: ref.Variant,
+		}, func() (string, error) {
+			partial, err := store.LoadPartial(ref.Name, dp.LoadPartialOptions{Variant: ref.Variant})
+			return partial.Source, err
+		})
+	}
+
+	for i := range report.Files {
+		f := &report.Files[i]
+		if f.Kind == KindPartial && !referenced[f.Name] {
+			f.Diagnostics = append(f.Diagnostics, Diagnostic{
+				File:     f.File,
+				Code:     "unused-partial",
+				Severity: SeverityInfo,
+				Message:  "partial '" + f.Name + "' is not referenced by any prompt or partial",
+			})
+		}
+		for _, d := range f.Diagnostics {
+			switch d.Severity {
+			case SeverityError:
+				report.Errors++
+			case SeverityWarning:
+				report.Warnings++
+			}
+		}
+	}
+	return report, nil
+}
+
+// listAll calls list with successive cursors until it returns the last page.
+func listAll[T any](list func(cursor string) ([]T, string, error)) ([]T, error) {
+	var all []T
+	cursor := ""
+	for {
+		items, next, err := list(cursor)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if next == "" || next == cursor {
+			return all, nil
+		}
+		cursor = next
+	}
+}
+
+// checkFrontmatter reports the problems found by dp.ValidateFrontmatter in the
+// frontmatter parsed by lint, one diagnostic each.
+func (l *linter) checkFrontmatter(parsed dp.ParsedPrompt) {
+	err := dp.ValidateFrontmatter(parsed.PromptMetadata)
+	if err == nil {
+		return
+	}
+	errs := []error{err}
+	if joined, ok := errors.Unwrap(err).(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		l.report("invalid-frontmatter", SeverityError, 0, 0, "%v", err)
+	}
+}
+
+// collectPartials marks the partials referenced by source in referenced,
+// without the variant a reference may pin.
+func collectPartials(source string, referenced map[string]bool) {
+	_, body, _ := splitSource(source)
+	refs, err := dp.TemplatePartials(body)
+	if err != nil {
+		return
+	}
+	for _, ref := range refs {
+		name, _, _ := strings.Cut(ref.Name, "@")
+		referenced[name] = true
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+// pagedStore lists one prompt per page and fails to load "broken".
+type pagedStore struct {
+	*dp.DirStore
+}
+
+func (s pagedStore) List(options dp.ListPromptsOptions) (dp.ListPromptsResult[dp.PromptRef], error) {
+	all, err := s.DirStore.List(dp.ListPromptsOptions{})
+	if err != nil {
+		return all, err
+	}
+	i := 0
+	if options.Cursor != "" {
+		fmt.Sscan(options.Cursor, &i)
+	}
+	result := dp.ListPromptsResult[dp.PromptRef]{Items: all.Items[i : i+1]}
+	if i+1 < len(all.Items) {
+		result.Cursor = fmt.Sprint(i + 1)
+	}
+	return result, nil
+}
+
+func (s pagedStore) Load(name string, options dp.LoadPromptOptions) (dp.PromptData, error) {
+	if name == "broken" {
+		return dp.PromptData{}, errors.New("permission denied")
+	}
+	return s.DirStore.Load(name, options)
+}
+
+func TestValidateStore(t *testing.T) {
+	store := pagedStore{storetest.NewDirStore(t, map[string]string{
+		"a.prompt":       "---\nmodel: test/model\n---\nHello {{> header}}",
+		"b.prompt":       "---\nmodl: test/model\nmaxTurns: five\n---\n{{> nope}}",
+		"broken.prompt":  "Hello",
+		"_header.prompt": "Header",
+		"_spare.prompt":  "Spare",
+	})}
+
+	report, err := ValidateStore(store)
+	if err != nil {
+		t.Fatalf("ValidateStore() returned error: %v", err)
+	}
+
+	var got []string
+	for _, f := range report.Files {
+		var fileCodes []string
+		for _, d := range f.Diagnostics {
+			if d.File != f.File {
+				t.Errorf("diagnostic %v is in the report of %s", d, f.File)
+			}
+			fileCodes = append(fileCodes, d.Code)
+		}
+		got = append(got, fmt.Sprintf("%s(%s):%s", f.File, f.Kind, strings.Join(fileCodes, "+")))
+	}
+	want := []string{
+		"a.prompt(prompt):",
+		"b.prompt(prompt):missing-partial+invalid-frontmatter+invalid-frontmatter",
+		"broken.prompt(prompt):load-error",
+		"_header.prompt(partial):",
+		"_spare.prompt(partial):unused-partial",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ValidateStore() files = %v, want %v", got, want)
+	}
+	if report.Errors != 4 || report.Warnings != 0 || !report.HasErrors() {
+		t.Errorf("ValidateStore() counts = %d errors, %d warnings, want 4 and 0", report.Errors, report.Warnings)
+	}
+	if n := len(report.Diagnostics()); n != 5 {
+		t.Errorf("Diagnostics() returned %d diagnostics, want 5", n)
+	}
+}
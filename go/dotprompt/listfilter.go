@@ -0,0 +1,94 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// TagsExtKey is the frontmatter field that ListPromptsOptions.Tags matches.
+const TagsExtKey = "ext.tags"
+
+// validate checks the glob of the options.
+func (o ListPromptsOptions) validate() error {
+	if o.Glob != "" {
+		if _, err := path.Match(o.Glob, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", o.Glob, err)
+		}
+	}
+	return nil
+}
+
+// matchesName reports whether the prompt name passes the Prefix and Glob of
+// the options. The glob must have been validated.
+func (o ListPromptsOptions) matchesName(name string) bool {
+	if !strings.HasPrefix(name, o.Prefix) {
+		return false
+	}
+	if o.Glob != "" {
+		ok, _ := path.Match(o.Glob, name)
+		return ok
+	}
+	return true
+}
+
+// matchesTags reports whether the prompt source is tagged with all the Tags
+// of the options.
+func (o ListPromptsOptions) matchesTags(source string) (bool, error) {
+	if len(o.Tags) == 0 {
+		return true, nil
+	}
+	parsed, err := ParseDocument(source)
+	if err != nil {
+		return false, err
+	}
+	tags := PromptTags(parsed.PromptMetadata)
+	for _, tag := range o.Tags {
+		if !slices.Contains(tags, tag) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// PromptTags returns the tags of a prompt, from its `ext.tags` frontmatter
+// field. A single string is read as one tag; values that are not strings are
+// ignored.
+func PromptTags(meta PromptMetadata) []string {
+	value, ok := meta.ExtValue(TagsExtKey)
+	if !ok {
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		tags := make([]string, 0, len(v))
+		for _, item := range v {
+			if tag, ok := item.(string); ok {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
+	}
+	return nil
+}
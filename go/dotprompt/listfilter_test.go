@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPromptTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"list", "---\next.tags: [a, b]\n---\n", []string{"a", "b"}},
+		{"single", "---\next.tags: a\n---\n", []string{"a"}},
+		{"non-strings ignored", "---\next.tags: [a, 1]\n---\n", []string{"a"}},
+		{"absent", "---\nmodel: m\n---\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseDocument(tt.source)
+			if err != nil {
+				t.Fatalf("ParseDocument() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, PromptTags(parsed.PromptMetadata)); diff != "" {
+				t.Errorf("PromptTags() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListPromptsOptionsMatchesName(t *testing.T) {
+	tests := []struct {
+		options ListPromptsOptions
+		name    string
+		want    bool
+	}{
+		{ListPromptsOptions{}, "a/b", true},
+		{ListPromptsOptions{Prefix: "a/"}, "a/b", true},
+		{ListPromptsOptions{Prefix: "a/"}, "ab", false},
+		{ListPromptsOptions{Glob: "a/*"}, "a/b", true},
+		{ListPromptsOptions{Glob: "a/*"}, "a/b/c", false},
+		{ListPromptsOptions{Prefix: "a/", Glob: "*/c"}, "b/c", false},
+	}
+	for _, tt := range tests {
+		if got := tt.options.matchesName(tt.name); got != tt.want {
+			t.Errorf("%+v.matchesName(%q) = %v, want %v", tt.options, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// LoadMany loads the prompts of refs from store, in the order of refs, using
+// the store's BatchLoader implementation if it has one and one Load call per
+// prompt otherwise.
+func LoadMany(store PromptStore, refs []PromptRef) ([]PromptData, error) {
+	if loader, ok := store.(BatchLoader); ok {
+		return loader.LoadMany(refs)
+	}
+	return loadEach(store, refs)
+}
+
+// loadEach loads refs from store one at a time.
+func loadEach(store PromptStore, refs []PromptRef) ([]PromptData, error) {
+	prompts := make([]PromptData, len(refs))
+	for i, ref := range refs {
+		prompt, err := store.Load(ref.Name, LoadPromptOptions{Variant: ref.Variant, Version: ref.Version})
+		if err != nil {
+			return nil, loadManyError(ref, err)
+		}
+		prompts[i] = prompt
+	}
+	return prompts, nil
+}
+
+// loadManyError wraps err, the error of loading ref in a batch.
+func loadManyError(ref PromptRef, err error) error {
+	return fmt.Errorf("loading %s: %w", bundleKey(ref.Name, ref.Variant), err)
+}
+
+// LoadMany retrieves the prompts of refs, reading up to GOMAXPROCS files in
+// parallel. If several prompts fail to load, the error is that of the first
+// in refs.
+func (ds *DirStore) LoadMany(refs []PromptRef) ([]PromptData, error) {
+	prompts := make([]PromptData, len(refs))
+	errs := make([]error, len(refs))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			prompts[i], errs[i] = ds.Load(ref.Name, LoadPromptOptions{Variant: ref.Variant, Version: ref.Version})
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, loadManyError(refs[i], err)
+		}
+	}
+	return prompts, nil
+}
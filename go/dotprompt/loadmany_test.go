@@ -0,0 +1,72 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// countingStore counts the Load calls made to a store without LoadMany.
+type countingStore struct {
+	PromptStore
+	loads int
+}
+
+func (s *countingStore) Load(name string, options LoadPromptOptions) (PromptData, error) {
+	s.loads++
+	return s.PromptStore.Load(name, options)
+}
+
+func TestLoadMany(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	var refs []PromptRef
+	for i := range 20 {
+		name := fmt.Sprintf("p%02d", i)
+		if err := store.Save(PromptData{PromptRef: PromptRef{Name: name}, Source: name}); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+		refs = append(refs, PromptRef{Name: name})
+	}
+
+	prompts, err := store.LoadMany(refs)
+	if err != nil {
+		t.Fatalf("LoadMany() returned error: %v", err)
+	}
+	for i, prompt := range prompts {
+		if prompt.Source != refs[i].Name {
+			t.Errorf("LoadMany()[%d].Source = %q, want %q", i, prompt.Source, refs[i].Name)
+		}
+	}
+
+	_, err = store.LoadMany([]PromptRef{{Name: "p00"}, {Name: "missing1"}, {Name: "missing2"}})
+	if err == nil || !strings.Contains(err.Error(), "loading missing1") {
+		t.Errorf("LoadMany() error = %v, want the error of the first missing prompt", err)
+	}
+
+	counting := &countingStore{PromptStore: store}
+	if _, err := LoadMany(counting, refs[:3]); err != nil {
+		t.Fatalf("LoadMany() returned error: %v", err)
+	}
+	if counting.loads != 3 {
+		t.Errorf("LoadMany() made %d Load calls to a store without LoadMany, want 3", counting.loads)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+
+	"github.com/mbleigh/raymond"
+)
+
+// Translations are per-locale string tables for the `t` helper, keyed by
+// locale and then by message key. The table of the empty locale holds the
+// default strings.
+type Translations map[string]map[string]string
+
+// LocaleFallbacks returns the chain of locales tried for locale, from the most
+// to the least specific, excluding the default: "zh-Hant-TW" gives
+// ["zh-Hant-TW", "zh-Hant", "zh"]. Underscores are read as hyphens.
+func LocaleFallbacks(locale string) []string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	var chain []string
+	for locale != "" {
+		chain = append(chain, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return chain
+}
+
+// LoadLocalized loads the variant of a prompt for locale, treating variants
+// named after locales, as in `greeting.fr-CA.prompt`, as translations. It
+// walks the fallback chain of locale, so fr-CA falls back to fr and then to
+// the prompt without a variant. Failures to load a locale variant move on to
+// the next one.
+func LoadLocalized(store PromptStore, name, locale string) (PromptData, error) {
+	for _, variant := range LocaleFallbacks(locale) {
+		prompt, err := store.Load(name, LoadPromptOptions{Variant: variant})
+		// Stores such as DirStore load the default prompt for a variant
+		// they do not have.
+		if err == nil && prompt.Variant == variant {
+			return prompt, nil
+		}
+	}
+	return store.Load(name, LoadPromptOptions{})
+}
+
+// NewTranslateHelper returns a `t` helper that looks up message keys in
+// translations, as in `{{t "farewell"}}`. The locale is the `locale` hash
+// argument, or else the `@locale` data variable, which is set through
+// DataArgument.Context. Keys missing from the table of a locale are looked up
+// along its fallback chain and then in the default table; keys missing from
+// all of them render as themselves. Other hash arguments fill `{name}`
+// placeholders, so `{{t "welcome" name=user}}` renders "Welcome, {name}!"
+// with the user's name.
+//
+// Dotprompt registers this helper as `t` when DotpromptOptions.Translations
+// is set.
+func NewTranslateHelper(translations Translations) func(key string, options *raymond.Options) string {
+	return func(key string, options *raymond.Options) string {
+		locale := options.HashStr("locale")
+		if locale == "" {
+			locale = options.DataStr("locale")
+		}
+		message, ok := "", false
+		for _, l := range append(LocaleFallbacks(locale), "") {
+			if message, ok = translations[l][key]; ok {
+				break
+			}
+		}
+		if !ok {
+			message = key
+		}
+		for name, value := range options.Hash() {
+			if name != "locale" {
+				message = strings.ReplaceAll(message, "{"+name+"}", raymond.Str(value))
+			}
+		}
+		return message
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocaleFallbacks(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{"", nil},
+		{"fr", []string{"fr"}},
+		{"fr-CA", []string{"fr-CA", "fr"}},
+		{"zh_Hant_TW", []string{"zh-Hant-TW", "zh-Hant", "zh"}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, LocaleFallbacks(tt.locale)); diff != "" {
+			t.Errorf("LocaleFallbacks(%q) mismatch (-want +got):\n%s", tt.locale, diff)
+		}
+	}
+}
+
+func TestLoadLocalized(t *testing.T) {
+	store := newTestDirStore(t, map[string]string{
+		"greeting.prompt":       "Hello",
+		"greeting.fr.prompt":    "Bonjour",
+		"greeting.fr-CA.prompt": "Allô",
+	})
+
+	tests := []struct {
+		locale      string
+		wantVariant string
+		wantSource  string
+	}{
+		{"fr-CA", "fr-CA", "Allô"},
+		{"fr-BE", "fr", "Bonjour"},
+		{"fr", "fr", "Bonjour"},
+		{"de-DE", "", "Hello"},
+		{"", "", "Hello"},
+	}
+	for _, tt := range tests {
+		prompt, err := LoadLocalized(store, "greeting", tt.locale)
+		if err != nil {
+			t.Fatalf("LoadLocalized(%q) returned error: %v", tt.locale, err)
+		}
+		if prompt.Variant != tt.wantVariant || prompt.Source != tt.wantSource {
+			t.Errorf("LoadLocalized(%q) = (%q, %q), want (%q, %q)",
+				tt.locale, prompt.Variant, prompt.Source, tt.wantVariant, tt.wantSource)
+		}
+	}
+
+	if _, err := LoadLocalized(store, "missing", "fr"); err == nil {
+		t.Error("LoadLocalized() returned nil error for a missing prompt")
+	}
+}
+
+func TestTranslateHelper(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{Translations: Translations{
+		"":   {"welcome": "Welcome, {name}!", "bye": "Goodbye"},
+		"fr": {"welcome": "Bienvenue, {name} !"},
+	}})
+
+	tests := []struct {
+		name     string
+		template string
+		context  map[string]any
+		want     string
+	}{
+		{"default", `{{t "welcome" name="Ada"}}`, nil, "Welcome, Ada!"},
+		{"data locale", `{{t "welcome" name="Ada"}}`, map[string]any{"locale": "fr-CA"}, "Bienvenue, Ada !"},
+		{"hash locale", `{{t "welcome" locale="fr" name="Ada"}}`, nil, "Bienvenue, Ada !"},
+		{"default fallback", `{{t "bye"}}`, map[string]any{"locale": "fr"}, "Goodbye"},
+		{"missing key", `{{t "unknown"}}`, nil, "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := dp.Render(tt.template, &DataArgument{Context: tt.context}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
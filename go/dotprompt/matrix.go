@@ -0,0 +1,64 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"runtime"
+	"sync"
+)
+
+// MatrixResult is the outcome of rendering a prompt with one input in
+// RenderMatrix.
+type MatrixResult struct {
+	Input map[string]any
+	// Rendered is the rendered prompt, or the zero value if Err is set.
+	Rendered RenderedPrompt
+	Err      error
+}
+
+// RenderMatrix compiles source once and renders it with each of inputs,
+// using GOMAXPROCS workers. The results are in the order of inputs, each
+// paired with its input, and an input that fails to render has its error in
+// its MatrixResult. RenderMatrix only returns an error if source does not
+// compile.
+func (dp *Dotprompt) RenderMatrix(source string, inputs []map[string]any) ([]MatrixResult, error) {
+	prompt, err := dp.Compile(source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MatrixResult, len(inputs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(inputs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].Input = inputs[i]
+				results[i].Rendered, results[i].Err = prompt(&DataArgument{Input: inputs[i]}, nil)
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
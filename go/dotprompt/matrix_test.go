@@ -0,0 +1,63 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderMatrix(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{Limits: RenderLimits{MaxOutputBytes: 12}})
+	var inputs []map[string]any
+	var want []string
+	for i := range 50 {
+		inputs = append(inputs, map[string]any{"n": i})
+		want = append(want, fmt.Sprintf("Item %d", i))
+	}
+	inputs = append(inputs, map[string]any{"n": "far too long"})
+
+	results, err := dp.RenderMatrix("Item {{n}}", inputs)
+	if err != nil {
+		t.Fatalf("RenderMatrix() returned error: %v", err)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("len(RenderMatrix()) = %d, want %d", len(results), len(inputs))
+	}
+	var got []string
+	for i, result := range results[:len(want)] {
+		if result.Err != nil {
+			t.Fatalf("results[%d].Err = %v", i, result.Err)
+		}
+		if result.Input["n"] != i {
+			t.Errorf("results[%d].Input = %v, want n=%d", i, result.Input, i)
+		}
+		got = append(got, result.Rendered.Messages[0].Content[0].(*TextPart).Text)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RenderMatrix() mismatch (-want +got):\n%s", diff)
+	}
+	if last := results[len(results)-1]; last.Err == nil {
+		t.Errorf("results[%d].Err = nil, want output size error", len(results)-1)
+	}
+
+	if _, err := dp.RenderMatrix("{{#if}}", inputs); err == nil {
+		t.Error("RenderMatrix() with invalid template succeeded, want error")
+	}
+}
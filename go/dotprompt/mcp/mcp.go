@@ -0,0 +1,411 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mcp exposes the prompts of a dotprompt.PromptStore as Model Context
+// Protocol (MCP) prompts, so that MCP clients such as IDE assistants can
+// discover and render them.
+//
+// A Server answers the initialize, ping, prompts/list and prompts/get
+// methods. Each prompt is listed with the top-level properties of its input
+// schema as arguments, and rendered with the arguments as its input. Serve
+// implements the stdio transport:
+//
+//	store, _ := dotprompt.NewDirStore("prompts")
+//	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{PartialStore: store})
+//	err := mcp.NewServer(dp, store).Serve(ctx, os.Stdin, os.Stdout)
+//
+// Variants are listed as separate prompts named `name.variant`.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/invopop/jsonschema"
+)
+
+// ProtocolVersion is the MCP protocol version implemented by Server.
+const ProtocolVersion = "2025-06-18"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Server serves the prompts of a store over MCP.
+type Server struct {
+	dp    *dotprompt.Dotprompt
+	store dotprompt.PromptStore
+	// Name and Version identify the server to clients. Name defaults to
+	// "dotprompt".
+	Name    string
+	Version string
+}
+
+// NewServer returns a Server that renders the prompts of store with dp,
+// which should be configured to resolve the partials of store, e.g. with
+// DotpromptOptions.PartialStore.
+func NewServer(dp *dotprompt.Dotprompt, store dotprompt.PromptStore) *Server {
+	return &Server{dp: dp, store: store, Name: "dotprompt"}
+}
+
+// request is a JSON-RPC request or notification.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads newline-delimited JSON-RPC messages from in and writes the
+// responses to out, as in the MCP stdio transport, until in is exhausted or
+// ctx is done.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		if resp := s.Handle(ctx, line); resp != nil {
+			if _, err := out.Write(append(resp, '\n')); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// Handle handles one JSON-RPC message and returns the encoded response, or
+// nil for notifications. It lets Server be used with other transports, and is
+// safe for concurrent use: each prompt is rendered with its own clone of the
+// Dotprompt instance, as rendering compiles into the instance.
+func (s *Server) Handle(ctx context.Context, message []byte) []byte {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+	}
+	if req.ID == nil {
+		// Notifications, such as notifications/initialized, need no reply.
+		return nil
+	}
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+	result, err := s.call(ctx, req)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return encode(resp)
+}
+
+func encode(resp response) []byte {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		b, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: codeInternalError, Message: err.Error()}})
+	}
+	return b
+}
+
+// call dispatches req to its method.
+func (s *Server) call(ctx context.Context, req request) (any, error) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "jsonrpc must be \"2.0\""}
+	}
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"prompts": map[string]any{}},
+			"serverInfo":      map[string]any{"name": s.Name, "version": s.Version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "prompts/list":
+		var params struct {
+			Cursor string `json:"cursor"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.listPrompts(params.Cursor)
+	case "prompts/get":
+		var params struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.getPrompt(ctx, params.Name, params.Arguments)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func decodeParams(params json.RawMessage, out any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, out); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+// Prompt is an MCP prompt.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument of an MCP prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is a message of a rendered MCP prompt.
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// Content is the content of a PromptMessage: text, an image or audio clip
+// embedded as base64 data, or a link to a resource.
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	URI      string `json:"uri,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+// listPrompts returns one page of the store's prompts.
+func (s *Server) listPrompts(cursor string) (any, error) {
+	result, err := s.store.List(dotprompt.ListPromptsOptions{Cursor: cursor})
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]Prompt, 0, len(result.Items))
+	for _, ref := range result.Items {
+		prompt := Prompt{Name: promptName(ref)}
+		// A prompt that cannot be loaded is still listed, so that getting it
+		// reports the error.
+		if meta, err := s.metadata(ref); err == nil {
+			prompt.Description = meta.Description
+			prompt.Arguments = arguments(meta.Input.Schema)
+		}
+		prompts = append(prompts, prompt)
+	}
+	out := map[string]any{"prompts": prompts}
+	if result.Cursor != "" {
+		out["nextCursor"] = result.Cursor
+	}
+	return out, nil
+}
+
+// promptName returns the MCP name of the prompt ref.
+func promptName(ref dotprompt.PromptRef) string {
+	if ref.Variant == "" {
+		return ref.Name
+	}
+	return ref.Name + "." + ref.Variant
+}
+
+// parsePromptName is the inverse of promptName. As in DirStore, the variant
+// follows the last dot.
+func parsePromptName(name string) dotprompt.PromptRef {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return dotprompt.PromptRef{Name: name[:i], Variant: name[i+1:]}
+	}
+	return dotprompt.PromptRef{Name: name}
+}
+
+// metadata loads the prompt ref and returns its metadata, with the input
+// schema expanded to JSON Schema.
+func (s *Server) metadata(ref dotprompt.PromptRef) (dotprompt.PromptMetadata, error) {
+	data, err := s.store.Load(ref.Name, dotprompt.LoadPromptOptions{Variant: ref.Variant})
+	if err != nil {
+		return dotprompt.PromptMetadata{}, err
+	}
+	return s.dp.Clone().RenderMetadata(data.Source, nil)
+}
+
+// arguments returns the MCP arguments for the top-level properties of schema.
+func arguments(schema dotprompt.Schema) []PromptArgument {
+	js, ok := schema.(*jsonschema.Schema)
+	if !ok || js == nil || js.Properties == nil {
+		return nil
+	}
+	required := make(map[string]bool, len(js.Required))
+	for _, name := range js.Required {
+		required[name] = true
+	}
+	var args []PromptArgument
+	for pair := js.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		args = append(args, PromptArgument{
+			Name:        pair.Key,
+			Description: pair.Value.Description,
+			Required:    required[pair.Key],
+		})
+	}
+	return args
+}
+
+// getPrompt renders the prompt name with the given arguments.
+func (s *Server) getPrompt(ctx context.Context, name string, args map[string]string) (any, error) {
+	if name == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "missing prompt name"}
+	}
+	ref := parsePromptName(name)
+	data, err := s.store.Load(ref.Name, dotprompt.LoadPromptOptions{Variant: ref.Variant})
+	if err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("prompt %q: %v", name, err)}
+	}
+	dp := s.dp.Clone()
+	meta, err := dp.RenderMetadata(data.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	input := make(map[string]any, len(args))
+	for key, value := range args {
+		input[key] = argumentValue(meta.Input.Schema, key, value)
+	}
+	rendered, err := dp.Render(data.Source, &dotprompt.DataArgument{Input: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []PromptMessage
+	for _, msg := range rendered.Messages {
+		role := "user"
+		if msg.Role == dotprompt.RoleModel {
+			role = "assistant"
+		}
+		for _, part := range msg.Content {
+			if content, ok := partContent(part); ok {
+				messages = append(messages, PromptMessage{Role: role, Content: content})
+			}
+		}
+	}
+	out := map[string]any{"messages": messages}
+	if meta.Description != "" {
+		out["description"] = meta.Description
+	}
+	return out, nil
+}
+
+// argumentValue converts the string value of the argument key to the type
+// of its property in schema, falling back to the string itself.
+func argumentValue(schema dotprompt.Schema, key, value string) any {
+	js, ok := schema.(*jsonschema.Schema)
+	if !ok || js == nil || js.Properties == nil {
+		return value
+	}
+	prop, ok := js.Properties.Get(key)
+	if !ok || prop == nil || prop.Type == "string" {
+		return value
+	}
+	var typed any
+	if err := json.Unmarshal([]byte(value), &typed); err != nil {
+		return value
+	}
+	return typed
+}
+
+// partContent converts a rendered part to MCP content. MCP prompts have no
+// system role, so system messages are sent as user messages; parts with no
+// MCP equivalent, such as tool requests, are dropped.
+func partContent(part dotprompt.Part) (Content, bool) {
+	switch p := part.(type) {
+	case *dotprompt.TextPart:
+		return Content{Type: "text", Text: p.Text}, true
+	case *dotprompt.MediaPart:
+		mimeType, data, ok := parseDataURL(p.Media.URL)
+		if ok && (strings.HasPrefix(mimeType, "image/") || strings.HasPrefix(mimeType, "audio/")) {
+			kind, _, _ := strings.Cut(mimeType, "/")
+			return Content{Type: kind, Data: data, MimeType: mimeType}, true
+		}
+		return Content{Type: "resource_link", URI: p.Media.URL, Name: p.Media.URL, MimeType: p.Media.ContentType}, true
+	}
+	return Content{}, false
+}
+
+// parseDataURL returns the MIME type and base64 data of a data URL.
+func parseDataURL(raw string) (mimeType, data string, ok bool) {
+	rest, ok := strings.CutPrefix(raw, "data:")
+	if !ok {
+		return "", "", false
+	}
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", "", false
+	}
+	mimeType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !isBase64 {
+		if unescaped, err := url.PathUnescape(payload); err == nil {
+			payload = unescaped
+		}
+		payload = base64.StdEncoding.EncodeToString([]byte(payload))
+	}
+	return mimeType, payload, true
+}
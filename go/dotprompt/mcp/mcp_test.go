@@ -0,0 +1,233 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	store, err := dotprompt.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	prompts := []dotprompt.PromptData{
+		{PromptRef: dotprompt.PromptRef{Name: "greet"}, Source: "---\ndescription: Greets someone.\ninput:\n  schema:\n    name: string, the person to greet\n    times?: integer\n---\n{{role \"system\"}}Be kind.{{role \"user\"}}{{> sig}}Hello {{name}} x{{times}}!"},
+		{PromptRef: dotprompt.PromptRef{Name: "greet", Variant: "formal"}, Source: "Good day, {{name}}."},
+		{PromptRef: dotprompt.PromptRef{Name: "image"}, Source: "{{media url=\"data:image/png;base64,aGk=\"}}{{media url=\"https://example.com/a.pdf\" contentType=\"application/pdf\"}}"},
+	}
+	for _, p := range prompts {
+		if err := store.Save(p); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	if err := store.SavePartial(dotprompt.PartialData{PartialRef: dotprompt.PartialRef{Name: "sig"}, Source: "[sig] "}); err != nil {
+		t.Fatalf("SavePartial() returned error: %v", err)
+	}
+	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{PartialStore: store})
+	return NewServer(dp, store)
+}
+
+// call sends a request to s and decodes the result into out, failing the test
+// on errors.
+func call(t *testing.T, s *Server, method string, params any, out any) {
+	t.Helper()
+	req, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(s.Handle(context.Background(), req), &resp); err != nil {
+		t.Fatalf("Handle() returned invalid JSON: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("%s returned error: %v", method, resp.Error)
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		t.Fatalf("decoding %s result: %v", method, err)
+	}
+}
+
+func TestListPrompts(t *testing.T) {
+	s := newTestServer(t)
+	var got struct {
+		Prompts []Prompt `json:"prompts"`
+	}
+	call(t, s, "prompts/list", map[string]any{}, &got)
+	want := []Prompt{
+		{
+			Name:        "greet",
+			Description: "Greets someone.",
+			Arguments: []PromptArgument{
+				{Name: "name", Description: "the person to greet", Required: true},
+				{Name: "times"},
+			},
+		},
+		{Name: "greet.formal"},
+		{Name: "image"},
+	}
+	if diff := cmp.Diff(want, got.Prompts); diff != "" {
+		t.Errorf("prompts/list mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetPrompt(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name string
+		args map[string]string
+		want []PromptMessage
+	}{
+		{
+			name: "greet",
+			args: map[string]string{"name": "Ada", "times": "2"},
+			want: []PromptMessage{
+				{Role: "user", Content: Content{Type: "text", Text: "Be kind."}},
+				{Role: "user", Content: Content{Type: "text", Text: "[sig] Hello Ada x2!"}},
+			},
+		},
+		{
+			name: "greet.formal",
+			args: map[string]string{"name": "Ada"},
+			want: []PromptMessage{{Role: "user", Content: Content{Type: "text", Text: "Good day, Ada."}}},
+		},
+		{
+			name: "image",
+			want: []PromptMessage{
+				{Role: "user", Content: Content{Type: "image", Data: "aGk=", MimeType: "image/png"}},
+				{Role: "user", Content: Content{Type: "resource_link", URI: "https://example.com/a.pdf", Name: "https://example.com/a.pdf", MimeType: "application/pdf"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Messages []PromptMessage `json:"messages"`
+			}
+			call(t, s, "prompts/get", map[string]any{"name": tt.name, "arguments": tt.args}, &got)
+			if diff := cmp.Diff(tt.want, got.Messages); diff != "" {
+				t.Errorf("prompts/get mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetPromptConcurrent(t *testing.T) {
+	s := newTestServer(t)
+	req := []byte(`{"jsonrpc": "2.0", "id": 1, "method": "prompts/get", "params": {"name": "greet", "arguments": {"name": "Ada"}}}`)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp := string(s.Handle(context.Background(), req)); !strings.Contains(resp, "Hello Ada") {
+				t.Errorf("prompts/get = %s, want the rendered prompt", resp)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParsePromptName(t *testing.T) {
+	tests := []struct {
+		name string
+		want dotprompt.PromptRef
+	}{
+		{"greet", dotprompt.PromptRef{Name: "greet"}},
+		{"greet.formal", dotprompt.PromptRef{Name: "greet", Variant: "formal"}},
+		{"a.b.c", dotprompt.PromptRef{Name: "a.b", Variant: "c"}},
+	}
+	for _, tt := range tests {
+		if got := parsePromptName(tt.name); got != tt.want {
+			t.Errorf("parsePromptName(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got := promptName(tt.want); got != tt.name {
+			t.Errorf("promptName(%+v) = %q, want %q", tt.want, got, tt.name)
+		}
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name     string
+		message  string
+		wantCode int
+	}{
+		{"parse error", `{`, codeParseError},
+		{"bad version", `{"jsonrpc":"1.0","id":1,"method":"ping"}`, codeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, codeMethodNotFound},
+		{"unknown prompt", `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"missing"}}`, codeInvalidParams},
+		{"bad params", `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":[]}`, codeInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp response
+			if err := json.Unmarshal(s.Handle(context.Background(), []byte(tt.message)), &resp); err != nil {
+				t.Fatalf("Handle() returned invalid JSON: %v", err)
+			}
+			if resp.Error == nil || resp.Error.Code != tt.wantCode {
+				t.Errorf("Handle() error = %+v, want code %d", resp.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	s := newTestServer(t)
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":"two","method":"ping"}`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Serve() wrote %d responses, want 2:\n%s", len(lines), out.String())
+	}
+	var init struct {
+		ID     int `json:"id"`
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &init); err != nil {
+		t.Fatal(err)
+	}
+	if init.ID != 1 || init.Result.ProtocolVersion != ProtocolVersion {
+		t.Errorf("initialize response = %s", lines[0])
+	}
+	if want := `{"jsonrpc":"2.0","id":"two","result":{}}`; lines[1] != want {
+		t.Errorf("ping response = %s, want %s", lines[1], want)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mbleigh/raymond"
+)
+
+// NewMediaHelper returns a `media` helper that resolves relative URLs against
+// fsys. A relative URL such as `{{media url="images/cat.png"}}` is read from
+// fsys and inlined as a base64 data URI, with the content type inferred from
+// the file extension or, failing that, the file contents. URLs with a scheme,
+// such as `https:` or `data:`, are passed through unchanged.
+//
+// Dotprompt uses this helper in place of MediaFn when DotpromptOptions.MediaRoot
+// or DotpromptOptions.MediaFS is set.
+func NewMediaHelper(fsys fs.FS) func(options *raymond.Options) raymond.SafeString {
+	return func(options *raymond.Options) raymond.SafeString {
+		mediaURL := options.HashStr("url")
+		contentType := options.HashStr("contentType")
+		if isLocalMedia(mediaURL) {
+			var err error
+			mediaURL, contentType, err = mediaDataURI(fsys, mediaURL, contentType)
+			if err != nil {
+				panic(fmt.Errorf("media helper: %w", err))
+			}
+		}
+		if contentType != "" {
+			return raymond.SafeString(fmt.Sprintf("<<<dotprompt:media:url %s %s>>>", mediaURL, contentType))
+		}
+		return raymond.SafeString(fmt.Sprintf("<<<dotprompt:media:url %s>>>", mediaURL))
+	}
+}
+
+// isLocalMedia reports whether a media URL is a path rather than a URL with
+// a scheme.
+func isLocalMedia(mediaURL string) bool {
+	if mediaURL == "" {
+		return false
+	}
+	u, err := url.Parse(mediaURL)
+	return err == nil && u.Scheme == ""
+}
+
+// mediaDataURI reads the file at name from fsys and returns it as a data URI
+// along with its content type.
+func mediaDataURI(fsys fs.FS, name, contentType string) (string, string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if !fs.ValidPath(name) || name == "." {
+		return "", "", fmt.Errorf("invalid media path %q", name)
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", "", err
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), contentType, nil
+}
+
+// mediaKindMetadataKey is the MediaPart metadata key that holds the kind of
+// media, "audio" or "video", for parts written by the `audio` and `video`
+// helpers.
+const mediaKindMetadataKey = "kind"
+
+// timedMediaFields are the hash arguments of the `audio` and `video` helpers
+// that are copied into their markers, in marker order.
+var timedMediaFields = []string{"contentType", "duration", "startOffset", "endOffset"}
+
+// AudioFn is the `audio` helper. It produces a MediaPart like `media` and
+// also accepts `duration`, `startOffset` and `endOffset` hash arguments,
+// Go durations such as "1m30s", which are kept in the part's metadata along
+// with a `kind` of "audio":
+//
+//	{{audio url="https://example.com/talk.mp3" startOffset="1m" endOffset="2m"}}
+func AudioFn(options *raymond.Options) raymond.SafeString {
+	return timedMedia(AudioMarkerPrefix, nil, options)
+}
+
+// VideoFn is the `video` helper. It accepts the same arguments as `audio`
+// and sets a `kind` of "video":
+//
+//	{{video url="https://example.com/demo.mp4" startOffset="10s"}}
+func VideoFn(options *raymond.Options) raymond.SafeString {
+	return timedMedia(VideoMarkerPrefix, nil, options)
+}
+
+// newTimedMediaHelper returns an `audio` or `video` helper that resolves
+// relative URLs against fsys, as NewMediaHelper does.
+func newTimedMediaHelper(prefix string, fsys fs.FS) func(options *raymond.Options) raymond.SafeString {
+	return func(options *raymond.Options) raymond.SafeString {
+		return timedMedia(prefix, fsys, options)
+	}
+}
+
+// timedMedia writes the marker of an `audio` or `video` helper call. It
+// panics, as helpers report errors, if an argument is invalid.
+func timedMedia(prefix string, fsys fs.FS, options *raymond.Options) raymond.SafeString {
+	name := strings.TrimPrefix(prefix, MediaMarkerPrefix)
+	mediaURL := options.HashStr("url")
+	if mediaURL == "" || strings.ContainsAny(mediaURL, " \t\n") {
+		panic(fmt.Errorf("%s helper: invalid url %q", name, mediaURL))
+	}
+	values := map[string]string{}
+	for _, key := range timedMediaFields {
+		value := options.HashStr(key)
+		if strings.ContainsAny(value, " \t\n") {
+			panic(fmt.Errorf("%s helper: invalid %s %q", name, key, value))
+		}
+		if value != "" && key != "contentType" {
+			if _, err := time.ParseDuration(value); err != nil {
+				panic(fmt.Errorf("%s helper: invalid %s: %w", name, key, err))
+			}
+		}
+		values[key] = value
+	}
+	if fsys != nil && isLocalMedia(mediaURL) {
+		var err error
+		mediaURL, values["contentType"], err = mediaDataURI(fsys, mediaURL, values["contentType"])
+		if err != nil {
+			panic(fmt.Errorf("%s helper: %w", name, err))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix + " " + mediaURL)
+	for _, key := range timedMediaFields {
+		if values[key] != "" {
+			b.WriteString(" " + key + "=" + values[key])
+		}
+	}
+	b.WriteString(">>>")
+	return raymond.SafeString(b.String())
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMediaHelperLocalFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"images/cat.png": {Data: []byte("\x89PNG\r\n\x1a\nfake")},
+		"notes/readme":   {Data: []byte("plain text")},
+	}
+
+	tests := []struct {
+		name            string
+		template        string
+		wantURL         string
+		wantContentType string
+	}{
+		{
+			name:            "extension",
+			template:        `{{media url="images/cat.png"}}`,
+			wantURL:         "data:image/png;base64,iVBORw0KGgpmYWtl",
+			wantContentType: "image/png",
+		},
+		{
+			name:            "leading slash",
+			template:        `{{media url="/images/cat.png"}}`,
+			wantURL:         "data:image/png;base64,iVBORw0KGgpmYWtl",
+			wantContentType: "image/png",
+		},
+		{
+			name:            "sniffed",
+			template:        `{{media url="notes/readme"}}`,
+			wantURL:         "data:text/plain;base64,cGxhaW4gdGV4dA==",
+			wantContentType: "text/plain",
+		},
+		{
+			name:            "explicit content type",
+			template:        `{{media url="notes/readme" contentType="text/markdown"}}`,
+			wantURL:         "data:text/markdown;base64,cGxhaW4gdGV4dA==",
+			wantContentType: "text/markdown",
+		},
+		{
+			name:     "remote url",
+			template: `{{media url="https://example.com/cat.png"}}`,
+			wantURL:  "https://example.com/cat.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(&DotpromptOptions{MediaFS: fsys})
+			rendered, err := dp.Render(tt.template, &DataArgument{}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			part, ok := rendered.Messages[0].Content[0].(*MediaPart)
+			if !ok {
+				t.Fatalf("Render() part = %#v, want *MediaPart", rendered.Messages[0].Content[0])
+			}
+			if part.Media.URL != tt.wantURL {
+				t.Errorf("Media.URL = %q, want %q", part.Media.URL, tt.wantURL)
+			}
+			if part.Media.ContentType != tt.wantContentType {
+				t.Errorf("Media.ContentType = %q, want %q", part.Media.ContentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestMediaHelperErrors(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{MediaRoot: t.TempDir()})
+	for _, url := range []string{"missing.png", "../secret.png"} {
+		_, err := dp.Render(`{{media url="`+url+`"}}`, &DataArgument{}, nil)
+		if err == nil || !strings.Contains(err.Error(), "media helper") {
+			t.Errorf("Render(%q) error = %v, want media helper error", url, err)
+		}
+	}
+}
+
+func TestMediaHelperWithoutRoot(t *testing.T) {
+	dp := NewDotprompt(nil)
+	rendered, err := dp.Render(`{{media url="images/cat.png"}}`, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	part := rendered.Messages[0].Content[0].(*MediaPart)
+	if part.Media.URL != "images/cat.png" {
+		t.Errorf("Media.URL = %q, want %q", part.Media.URL, "images/cat.png")
+	}
+}
+
+func TestAudioVideoHelpers(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{MediaFS: fstest.MapFS{"clip.wav": {Data: []byte("RIFF")}}})
+	source := `Listen: {{audio url="clip.wav" duration="3s"}}` +
+		` Watch: {{video url="https://example.com/demo.mp4" contentType="video/mp4" startOffset="10s" endOffset="1m30s"}}`
+	rendered, err := dp.Render(source, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	content := rendered.Messages[0].Content
+	if len(content) != 4 {
+		t.Fatalf("Render() returned %d parts, want 4", len(content))
+	}
+
+	audio := content[1].(*MediaPart)
+	if audio.Media.ContentType != "audio/x-wav" && audio.Media.ContentType != "audio/wav" {
+		t.Errorf("audio ContentType = %q, want a WAV type", audio.Media.ContentType)
+	}
+	if !strings.HasPrefix(audio.Media.URL, "data:audio/") {
+		t.Errorf("audio URL = %q, want inlined data URI", audio.Media.URL)
+	}
+	if diff := cmp.Diff(Metadata{"kind": "audio", "duration": "3s"}, audio.Metadata); diff != "" {
+		t.Errorf("audio metadata mismatch (-want +got):\n%s", diff)
+	}
+
+	want := &MediaPart{
+		HasMetadata: HasMetadata{Metadata: Metadata{"kind": "video", "startOffset": "10s", "endOffset": "1m30s"}},
+		Media:       Media{URL: "https://example.com/demo.mp4", ContentType: "video/mp4"},
+	}
+	if diff := cmp.Diff(want, content[3]); diff != "" {
+		t.Errorf("video part mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAudioVideoHelperErrors(t *testing.T) {
+	dp := NewDotprompt(nil)
+	for _, source := range []string{
+		`{{video}}`,
+		`{{video url="a b.mp4"}}`,
+		`{{audio url="a.mp3" startOffset="ten"}}`,
+	} {
+		if _, err := dp.Render(source, &DataArgument{}, nil); err == nil {
+			t.Errorf("Render(%q) succeeded, want error", source)
+		}
+	}
+	if _, err := ToMessages("<<<dotprompt:media:video a.mp4 fps=30>>>", nil); err == nil {
+		t.Error("ToMessages() with unknown video field succeeded, want error")
+	}
+}
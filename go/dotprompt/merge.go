@@ -0,0 +1,185 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"maps"
+	"slices"
+)
+
+// MergeMetadata returns the metadata of base with override layered on top,
+// as Render layers the metadata it is passed over that of the prompt:
+//
+//   - Config, Metadata, Raw, Extensions and Input.Default are merged key by
+//     key, taking the values of override for the keys both set. Values that
+//     are maps in both are merged the same way, recursively; other values,
+//     including lists, are replaced.
+//   - Ext is merged namespace by namespace, and each namespace as above.
+//   - Input.Schema, Output.Schema and Output.Format are replaced if override
+//     sets them. Schemas are never merged, as the union of two schemas is
+//     rarely what either author meant.
+//   - Every other field is replaced if override sets it to a value other than
+//     its zero value, so override cannot clear a field of base.
+//
+// Neither base nor override is modified, but the result shares the values
+// that are not merged with them.
+func MergeMetadata(base, override PromptMetadata) PromptMetadata {
+	out := mergeStructs(base, override)
+	out.Metadata = Metadata(mergeMapsDeep(base.Metadata, override.Metadata))
+	out.Config = mergeModelConfigs(base.Config, override.Config)
+	out.Raw = mergeMapsDeep(base.Raw, override.Raw)
+	out.Extensions = mergeMapsDeep(base.Extensions, override.Extensions)
+
+	out.Input = base.Input
+	out.Input.Default = mergeMapsDeep(base.Input.Default, override.Input.Default)
+	if override.Input.Schema != nil {
+		out.Input.Schema = override.Input.Schema
+	}
+	out.Output = base.Output
+	if override.Output.Schema != nil {
+		out.Output.Schema = override.Output.Schema
+	}
+	if override.Output.Format != "" {
+		out.Output.Format = override.Output.Format
+	}
+
+	out.Ext = nil
+	if base.Ext != nil || override.Ext != nil {
+		out.Ext = make(map[string]map[string]any, len(base.Ext)+len(override.Ext))
+		for ns, values := range base.Ext {
+			out.Ext[ns] = mergeMapsDeep(values, nil)
+		}
+		for ns, values := range override.Ext {
+			out.Ext[ns] = mergeMapsDeep(out.Ext[ns], values)
+		}
+	}
+	return out
+}
+
+// mergeModelConfigs merges two model configurations as MergeMetadata does.
+func mergeModelConfigs(base, override ModelConfig) ModelConfig {
+	return ModelConfig(mergeMapsDeep(base, override))
+}
+
+// mergeMapsDeep returns a new map with the keys of base and override, taking
+// the values of override for the keys both have and merging the values that
+// are maps in both recursively. It returns nil if both are nil.
+func mergeMapsDeep(base, override map[string]any) map[string]any {
+	if base == nil && override == nil {
+		return nil
+	}
+	out := make(map[string]any, len(base)+len(override))
+	maps.Copy(out, base)
+	for k, v := range override {
+		if b, ok := asStringMap(out[k]); ok {
+			if o, ok := asStringMap(v); ok {
+				v = mergeMapsDeep(b, o)
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// asStringMap returns v as a map[string]any if it is one or a ModelConfig.
+func asStringMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case ModelConfig:
+		return m, true
+	}
+	return nil, false
+}
+
+// rewriteMetadataStrings returns meta with the strings in its config and
+// extension fields, at any depth, replaced by rewrite. rewrite reports false
+// to keep a string. The maps and slices of meta are copied before they are
+// changed, as they may be shared with the parsed prompt.
+func rewriteMetadataStrings(meta PromptMetadata, rewrite func(string) (any, bool, error)) (PromptMetadata, error) {
+	config, err := rewriteStrings(map[string]any(meta.Config), rewrite)
+	if err != nil {
+		return PromptMetadata{}, err
+	}
+	if config != nil {
+		meta.Config = ModelConfig(config.(map[string]any))
+	}
+	ext, cloned := meta.Ext, false
+	for ns, fields := range ext {
+		v, err := rewriteStrings(fields, rewrite)
+		if err != nil {
+			return PromptMetadata{}, err
+		}
+		if v != nil {
+			if !cloned {
+				meta.Ext, cloned = maps.Clone(ext), true
+			}
+			meta.Ext[ns] = v.(map[string]any)
+		}
+	}
+	return meta, nil
+}
+
+// rewriteStrings returns a copy of v with its strings replaced by rewrite, or
+// nil if rewrite replaced none.
+func rewriteStrings(v any, rewrite func(string) (any, bool, error)) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		var out map[string]any
+		for key, child := range v {
+			r, err := rewriteStrings(child, rewrite)
+			if err != nil {
+				return nil, err
+			}
+			if r != nil {
+				if out == nil {
+					out = maps.Clone(v)
+				}
+				out[key] = r
+			}
+		}
+		if out == nil {
+			return nil, nil
+		}
+		return out, nil
+	case []any:
+		var out []any
+		for i, child := range v {
+			r, err := rewriteStrings(child, rewrite)
+			if err != nil {
+				return nil, err
+			}
+			if r != nil {
+				if out == nil {
+					out = slices.Clone(v)
+				}
+				out[i] = r
+			}
+		}
+		if out == nil {
+			return nil, nil
+		}
+		return out, nil
+	case string:
+		r, ok, err := rewrite(v)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return r, nil
+	}
+	return nil, nil
+}
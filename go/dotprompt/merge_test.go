@@ -0,0 +1,86 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	base := PromptMetadata{
+		Name:  "greet",
+		Model: "googleai/gemini-2.0-flash",
+		Tools: []string{"search"},
+		Config: ModelConfig{
+			"temperature":    0.2,
+			"stopSequences":  []any{"END"},
+			"thinkingConfig": map[string]any{"budget": 100, "include": true},
+		},
+		Input: PromptMetadataInput{
+			Default: map[string]any{"name": "Ada", "tone": "warm"},
+			Schema:  map[string]any{"name": "string"},
+		},
+		Output: PromptMetadataOutput{Format: "json", Schema: map[string]any{"reply": "string"}},
+		Ext:    map[string]map[string]any{"acme": {"team": "core", "flags": map[string]any{"a": true}}},
+	}
+	override := PromptMetadata{
+		Model: "googleai/gemini-2.5-pro",
+		Config: ModelConfig{
+			"stopSequences":  []any{"STOP"},
+			"thinkingConfig": map[string]any{"budget": 500},
+		},
+		Input:  PromptMetadataInput{Default: map[string]any{"tone": "formal"}},
+		Output: PromptMetadataOutput{Schema: map[string]any{"answer": "string"}},
+		Ext: map[string]map[string]any{
+			"acme":  {"flags": map[string]any{"b": true}},
+			"other": {"x": 1},
+		},
+	}
+
+	want := PromptMetadata{
+		Name:  "greet",
+		Model: "googleai/gemini-2.5-pro",
+		Tools: []string{"search"},
+		Config: ModelConfig{
+			"temperature":    0.2,
+			"stopSequences":  []any{"STOP"},
+			"thinkingConfig": map[string]any{"budget": 500, "include": true},
+		},
+		Input: PromptMetadataInput{
+			Default: map[string]any{"name": "Ada", "tone": "formal"},
+			Schema:  map[string]any{"name": "string"},
+		},
+		Output: PromptMetadataOutput{Format: "json", Schema: map[string]any{"answer": "string"}},
+		Ext: map[string]map[string]any{
+			"acme":  {"team": "core", "flags": map[string]any{"a": true, "b": true}},
+			"other": {"x": 1},
+		},
+	}
+	got := MergeMetadata(base, override)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MergeMetadata() mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(map[string]any{"budget": 100, "include": true}, base.Config["thinkingConfig"]); diff != "" {
+		t.Errorf("MergeMetadata() modified base (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(base, MergeMetadata(base, PromptMetadata{})); diff != "" {
+		t.Errorf("MergeMetadata() with empty override mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+)
+
+// TurnMetadataKey is the message metadata key under which NumberTurns
+// records the turn of each message.
+const TurnMetadataKey = "turn"
+
+// MergeConsecutiveMessages returns messages with each run of consecutive
+// messages of the same role merged into one, for chat APIs that require
+// roles to alternate. A merged message keeps the metadata of the first
+// message of its run, and its content is concatenated, with adjacent text
+// parts joined by a blank line. The messages are not modified.
+func MergeConsecutiveMessages(messages []Message) []Message {
+	out := make([]Message, 0, len(messages))
+	for i, msg := range messages {
+		if i > 0 && msg.Role == messages[i-1].Role {
+			last := &out[len(out)-1]
+			last.Content = appendMergedContent(last.Content, msg.Content)
+			continue
+		}
+		if i+1 < len(messages) && messages[i+1].Role == msg.Role {
+			// Copy the content of the first message of a run, which the
+			// others are appended to.
+			msg.Content = appendMergedContent(nil, msg.Content)
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// MessageHash returns a hash of the role and content of msg. Message
+// metadata, such as the history purpose, is not hashed, so a message hashes
+// the same whether or not it came from history.
+func MessageHash(msg Message) string {
+	b, err := json.Marshal(struct {
+		Role    Role   `json:"role"`
+		Content []Part `json:"content"`
+	}{msg.Role, msg.Content})
+	if err != nil {
+		// Parts hold JSON-compatible values; hash what can be hashed.
+		b = []byte(string(msg.Role) + err.Error())
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// DedupeHistory returns messages without the history messages, those with
+// the `history` purpose, whose role and content repeat an earlier message.
+// Such duplicates appear when history is both passed in DataArgument.Messages
+// and rendered by the template. Other messages are always kept.
+func DedupeHistory(messages []Message) []Message {
+	seen := make(map[string]bool, len(messages))
+	out := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		hash := MessageHash(msg)
+		if seen[hash] && msg.Metadata["purpose"] == "history" {
+			continue
+		}
+		seen[hash] = true
+		out = append(out, msg)
+	}
+	return out
+}
+
+// NumberTurns returns messages with their turn recorded in their metadata
+// under TurnMetadataKey. A turn starts with each user message that does not
+// follow another user message, and turns are numbered from 1; messages
+// before the first user message, such as system instructions, are in turn 0.
+// The messages are not modified.
+func NumberTurns(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	turn := 0
+	for i, msg := range messages {
+		if msg.Role == RoleUser && (i == 0 || messages[i-1].Role != RoleUser) {
+			turn++
+		}
+		metadata := make(Metadata, len(msg.Metadata)+1)
+		maps.Copy(metadata, msg.Metadata)
+		metadata[TurnMetadataKey] = turn
+		msg.Metadata = metadata
+		out[i] = msg
+	}
+	return out
+}
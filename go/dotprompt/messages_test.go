@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func historyMessage(role Role, text string) Message {
+	msg := textMessage(role, text)
+	msg.Metadata = Metadata{"purpose": "history"}
+	return msg
+}
+
+func TestMergeConsecutiveMessages(t *testing.T) {
+	media := &MediaPart{Media: Media{URL: "https://example.com/cat.png"}}
+	messages := []Message{
+		textMessage(RoleSystem, "Be brief."),
+		textMessage(RoleUser, "Hi"),
+		{Role: RoleUser, Content: []Part{&TextPart{Text: "Look"}, media}},
+		textMessage(RoleUser, "Thoughts?"),
+		textMessage(RoleModel, "A cat."),
+	}
+
+	want := []Message{
+		textMessage(RoleSystem, "Be brief."),
+		{Role: RoleUser, Content: []Part{&TextPart{Text: "Hi\n\nLook"}, media, &TextPart{Text: "Thoughts?"}}},
+		textMessage(RoleModel, "A cat."),
+	}
+	if diff := cmp.Diff(want, MergeConsecutiveMessages(messages)); diff != "" {
+		t.Errorf("MergeConsecutiveMessages() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]Part{&TextPart{Text: "Hi"}}, messages[1].Content); diff != "" {
+		t.Errorf("MergeConsecutiveMessages() modified its argument (-want +got):\n%s", diff)
+	}
+}
+
+func TestDedupeHistory(t *testing.T) {
+	messages := []Message{
+		historyMessage(RoleUser, "Hi"),
+		historyMessage(RoleModel, "Hello!"),
+		historyMessage(RoleUser, "Hi"),
+		textMessage(RoleUser, "Hi"),
+		historyMessage(RoleModel, "Bye"),
+	}
+	want := []Message{
+		historyMessage(RoleUser, "Hi"),
+		historyMessage(RoleModel, "Hello!"),
+		textMessage(RoleUser, "Hi"),
+		historyMessage(RoleModel, "Bye"),
+	}
+	if diff := cmp.Diff(want, DedupeHistory(messages)); diff != "" {
+		t.Errorf("DedupeHistory() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMessageHash(t *testing.T) {
+	if MessageHash(textMessage(RoleUser, "Hi")) != MessageHash(historyMessage(RoleUser, "Hi")) {
+		t.Error("MessageHash() depends on message metadata")
+	}
+	if MessageHash(textMessage(RoleUser, "Hi")) == MessageHash(textMessage(RoleModel, "Hi")) {
+		t.Error("MessageHash() does not depend on the role")
+	}
+}
+
+func TestNumberTurns(t *testing.T) {
+	messages := []Message{
+		textMessage(RoleSystem, "Be brief."),
+		textMessage(RoleUser, "Hi"),
+		textMessage(RoleModel, "Hello!"),
+		textMessage(RoleUser, "Weather?"),
+		textMessage(RoleUser, "In Paris."),
+		textMessage(RoleModel, "Sunny."),
+	}
+	var got []any
+	for _, msg := range NumberTurns(messages) {
+		got = append(got, msg.Metadata[TurnMetadataKey])
+	}
+	if diff := cmp.Diff([]any{0, 1, 1, 2, 2, 2}, got); diff != "" {
+		t.Errorf("NumberTurns() turns mismatch (-want +got):\n%s", diff)
+	}
+	if messages[0].Metadata != nil {
+		t.Error("NumberTurns() modified its argument")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// metadataTemplateHelpers are the built-in helpers available to metadata
+// templates. The others emit markers, which have no meaning in metadata.
+var metadataTemplateHelpers = []string{"json", "ifEquals", "unlessEquals"}
+
+// numberRe matches the JSON numbers that metadataScalar converts.
+var numberRe = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
+
+// isMetadataTemplate reports whether a frontmatter string is a template.
+func isMetadataTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// checkMetadataTemplates parses the metadata templates of meta so that syntax
+// errors are reported when the prompt is compiled.
+func (dp *Dotprompt) checkMetadataTemplates(meta PromptMetadata) error {
+	check := func(field, s string) error {
+		if !isMetadataTemplate(s) {
+			return nil
+		}
+		if _, err := dp.engineOrDefault().Parse(s); err != nil {
+			return fmt.Errorf("dotprompt: invalid template in %s: %w", field, err)
+		}
+		return nil
+	}
+	if err := check("model", meta.Model); err != nil {
+		return err
+	}
+	_, err := rewriteMetadataStrings(meta, func(s string) (any, bool, error) {
+		return nil, false, check("metadata", s)
+	})
+	return err
+}
+
+// renderMetadataTemplates renders the metadata templates of meta. Their root
+// context holds the prompt input as `input`, and data holds the `@` data
+// variables, as for the prompt template. Output is not HTML-escaped. Config
+// and extension values that render to a number or boolean become one.
+func (dp *Dotprompt) renderMetadataTemplates(meta PromptMetadata, input map[string]any, data map[string]any) (PromptMetadata, error) {
+	ctx := map[string]any{"input": input}
+	render := func(s string) (string, error) {
+		tpl, err := dp.engineOrDefault().Parse(s)
+		if err != nil {
+			return "", err
+		}
+		for _, name := range metadataTemplateHelpers {
+			tpl.RegisterHelper(name, dp.limitHelper(templateHelpers[name]))
+		}
+		for name, helper := range dp.Helpers {
+			tpl.RegisterHelper(name, dp.limitHelper(helper))
+		}
+		return dp.execTemplate(tpl, ctx, data)
+	}
+
+	if isMetadataTemplate(meta.Model) {
+		model, err := render(meta.Model)
+		if err != nil {
+			return PromptMetadata{}, fmt.Errorf("dotprompt: rendering model: %w", err)
+		}
+		meta.Model = strings.TrimSpace(model)
+	}
+	return rewriteMetadataStrings(meta, func(s string) (any, bool, error) {
+		if !isMetadataTemplate(s) {
+			return nil, false, nil
+		}
+		out, err := render(s)
+		if err != nil {
+			return nil, false, fmt.Errorf("dotprompt: rendering metadata template %q: %w", s, err)
+		}
+		return metadataScalar(out), true, nil
+	})
+}
+
+// metadataScalar converts a rendered metadata value to a number or boolean if
+// it is one.
+func metadataScalar(s string) any {
+	trimmed := strings.TrimSpace(s)
+	switch trimmed {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if !numberRe.MatchString(trimmed) {
+		return s
+	}
+	if i, err := strconv.Atoi(trimmed); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetadataTemplates(t *testing.T) {
+	source := `---
+model: "{{#if input.fast}}gemini-flash{{else}}gemini-pro{{/if}}"
+input:
+  default:
+    level: 2
+config:
+  temperature: "{{#if input.creative}}0.9{{else}}0.2{{/if}}"
+  maxOutputTokens: "{{input.level}}00"
+  stream: "{{#if input.fast}}true{{else}}false{{/if}}"
+  user: "{{input.user}}"
+  literal: "\\{{input.user}}"
+  plain: no template
+---
+Hello`
+	dp := NewDotprompt(&DotpromptOptions{
+		MetadataTemplates: true,
+		ModelConfigs:      map[string]any{"gemini-flash": map[string]any{"topK": 3}},
+	})
+
+	tests := []struct {
+		name       string
+		input      map[string]any
+		wantModel  string
+		wantConfig ModelConfig
+	}{
+		{
+			name:      "fast",
+			input:     map[string]any{"fast": true, "user": "<Ada & co>"},
+			wantModel: "gemini-flash",
+			wantConfig: ModelConfig{
+				"topK":            3,
+				"temperature":     0.2,
+				"maxOutputTokens": 200,
+				"stream":          true,
+				"user":            "<Ada & co>",
+				"literal":         "{{input.user}}",
+				"plain":           "no template",
+			},
+		},
+		{
+			name:      "creative",
+			input:     map[string]any{"creative": true, "level": 5, "user": "Bo"},
+			wantModel: "gemini-pro",
+			wantConfig: ModelConfig{
+				"temperature":     0.9,
+				"maxOutputTokens": 500,
+				"stream":          false,
+				"user":            "Bo",
+				"literal":         "{{input.user}}",
+				"plain":           "no template",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := dp.Render(source, &DataArgument{Input: tt.input}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if rendered.Model != tt.wantModel {
+				t.Errorf("model = %q, want %q", rendered.Model, tt.wantModel)
+			}
+			if diff := cmp.Diff(tt.wantConfig, rendered.Config); diff != "" {
+				t.Errorf("config mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMetadataTemplatesDisabled(t *testing.T) {
+	source := "---\nmodel: \"{{input.model}}\"\n---\nHello"
+	rendered, err := NewDotprompt(nil).Render(source, &DataArgument{Input: map[string]any{"model": "x"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got, want := rendered.Model, "{{input.model}}"; got != want {
+		t.Errorf("model = %q, want %q", got, want)
+	}
+}
+
+func TestMetadataTemplatesInvalid(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{MetadataTemplates: true})
+	_, err := dp.Compile("---\nmodel: \"{{#if input.fast}}\"\n---\nHello", nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid template in model") {
+		t.Errorf("Compile() error = %v, want an invalid template error", err)
+	}
+}
+
+func TestMetadataTemplatesNotEnvExpanded(t *testing.T) {
+	t.Setenv("DOTPROMPT_TEST_PROJECT", "my-project")
+	dp := NewDotprompt(&DotpromptOptions{
+		MetadataTemplates: true,
+		EnvAllowlist:      []string{"DOTPROMPT_TEST_PROJECT"},
+	})
+	source := "---\nconfig:\n  project: \"${DOTPROMPT_TEST_PROJECT}/{{input.suffix}}\"\n---\nHello"
+	rendered, err := dp.Render(source, &DataArgument{Input: map[string]any{"suffix": "${DOTPROMPT_TEST_PROJECT}"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got, want := rendered.Config["project"], "my-project/${DOTPROMPT_TEST_PROJECT}"; got != want {
+		t.Errorf("config.project = %q, want %q", got, want)
+	}
+}
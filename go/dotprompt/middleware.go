@@ -0,0 +1,43 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+// RenderFunc renders a compiled prompt with runtime data and options. It is
+// the unit that middleware wraps.
+type RenderFunc = PromptFunction
+
+// Middleware wraps a RenderFunc to implement cross-cutting concerns such as
+// redaction, scanning, logging or quota enforcement. A middleware may inspect
+// or modify the data and options before calling next, and may inspect, modify
+// or reject the rendered prompt afterwards.
+type Middleware func(next RenderFunc) RenderFunc
+
+// Use registers middleware that wraps every prompt compiled afterwards by this
+// instance. Middleware registered first is the outermost, so it sees the
+// request first and the result last.
+func (dp *Dotprompt) Use(middleware ...Middleware) *Dotprompt {
+	dp.middleware = append(dp.middleware, middleware...)
+	return dp
+}
+
+// applyMiddleware wraps render with the registered middleware chain.
+func (dp *Dotprompt) applyMiddleware(render RenderFunc) RenderFunc {
+	for i := len(dp.middleware) - 1; i >= 0; i-- {
+		render = dp.middleware[i](render)
+	}
+	return render
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUseOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next RenderFunc) RenderFunc {
+			return func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+				calls = append(calls, name+":before")
+				rendered, err := next(data, options)
+				calls = append(calls, name+":after")
+				return rendered, err
+			}
+		}
+	}
+
+	dp := NewDotprompt(nil).Use(trace("outer"), trace("inner"))
+	render, err := dp.Compile("Hello {{name}}", nil)
+	if err != nil {
+		t.Fatalf("Compile() returned error: %v", err)
+	}
+	if _, err := render(&DataArgument{Input: map[string]any{"name": "Ada"}}, nil); err != nil {
+		t.Fatalf("render() returned error: %v", err)
+	}
+
+	want := []string{"outer:before", "inner:before", "inner:after", "outer:after"}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Errorf("middleware call order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUseModifiesInputAndOutput(t *testing.T) {
+	dp := NewDotprompt(nil)
+	dp.Use(func(next RenderFunc) RenderFunc {
+		return func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+			data.Input["name"] = "Grace"
+			rendered, err := next(data, options)
+			if err != nil {
+				return rendered, err
+			}
+			rendered.SetMetadata("intercepted", true)
+			return rendered, nil
+		}
+	})
+
+	rendered, err := dp.Render("Hello {{name}}", &DataArgument{Input: map[string]any{"name": "Ada"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	text := rendered.Messages[0].Content[0].(*TextPart).Text
+	if text != "Hello Grace" {
+		t.Errorf("rendered text = %q, want %q", text, "Hello Grace")
+	}
+	if rendered.Metadata["intercepted"] != true {
+		t.Errorf("rendered.Metadata[intercepted] = %v, want true", rendered.Metadata["intercepted"])
+	}
+}
+
+func TestUseRejectsRender(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	dp := NewDotprompt(nil).Use(func(next RenderFunc) RenderFunc {
+		return func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+			return RenderedPrompt{}, errQuota
+		}
+	})
+
+	_, err := dp.Render("Hello", &DataArgument{}, nil)
+	if !errors.Is(err, errQuota) {
+		t.Errorf("Render() error = %v, want %v", err, errQuota)
+	}
+}
+
+func TestCloneCopiesMiddleware(t *testing.T) {
+	count := 0
+	dp := NewDotprompt(nil).Use(func(next RenderFunc) RenderFunc {
+		return func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+			count++
+			return next(data, options)
+		}
+	})
+
+	clone := dp.Clone()
+	clone.Use(func(next RenderFunc) RenderFunc { return next })
+	if len(dp.middleware) != 1 {
+		t.Errorf("len(dp.middleware) = %d, want 1", len(dp.middleware))
+	}
+
+	if _, err := clone.Render("Hello", &DataArgument{}, nil); err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("middleware call count = %d, want 1", count)
+	}
+}
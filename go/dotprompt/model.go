@@ -0,0 +1,89 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Model is the adapter between dotprompt and a model provider. Generate sends
+// a rendered prompt to the model named by its Model field and returns the
+// text of the response.
+type Model interface {
+	Generate(ctx context.Context, prompt RenderedPrompt) (string, error)
+}
+
+// ModelFunc adapts a function to the Model interface.
+type ModelFunc func(ctx context.Context, prompt RenderedPrompt) (string, error)
+
+// Generate calls f.
+func (f ModelFunc) Generate(ctx context.Context, prompt RenderedPrompt) (string, error) {
+	return f(ctx, prompt)
+}
+
+// GenerateResult is the outcome of Generate.
+type GenerateResult struct {
+	// Response is the text returned by the model.
+	Response string
+	// Output is Response parsed with RenderedPrompt.ParseOutput.
+	Output any
+	// Model is the model that returned Response, which differs from the
+	// model of the prompt if a fallback answered.
+	Model string
+	// Attempts is the number of calls made to the model adapter.
+	Attempts int
+}
+
+// Generate sends prompt to model and parses the response according to the
+// output format of the prompt. It follows the ResiliencePolicy of the prompt:
+// failing calls are retried and then sent to the fallback models, with the
+// Model field of the prompt replaced. If every attempt fails, the returned
+// error joins the errors of the attempts.
+func Generate(ctx context.Context, model Model, prompt RenderedPrompt) (GenerateResult, error) {
+	policy, err := prompt.ResiliencePolicy()
+	if err != nil {
+		return GenerateResult{}, err
+	}
+
+	var errs []error
+	attempts := 0
+	for _, name := range policy.models(prompt.Model) {
+		attempt := prompt
+		attempt.Model = name
+		for retry := range policy.MaxRetries + 1 {
+			if retry > 0 {
+				if err := sleep(ctx, policy.Backoff.Delay(retry-1)); err != nil {
+					return GenerateResult{Attempts: attempts}, errors.Join(append(errs, err)...)
+				}
+			}
+			attempts++
+			resp, err := model.Generate(ctx, attempt)
+			if err == nil {
+				result := GenerateResult{Response: resp, Model: name, Attempts: attempts}
+				result.Output, err = attempt.ParseOutput(resp)
+				return result, err
+			}
+			errs = append(errs, fmt.Errorf("generating with model %q: %w", name, err))
+			if ctx.Err() != nil {
+				return GenerateResult{Attempts: attempts}, errors.Join(errs...)
+			}
+		}
+	}
+	return GenerateResult{Attempts: attempts}, errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerate(t *testing.T) {
+	dp := NewDotprompt(nil)
+	rendered, err := dp.Render(jsonOutputSource, &DataArgument{Input: map[string]any{"text": "Ada, 36"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	model := ModelFunc(func(_ context.Context, prompt RenderedPrompt) (string, error) {
+		if !strings.Contains(messagesText(prompt.Messages), "Ada, 36") {
+			return "", errors.New("prompt not received")
+		}
+		return `{"name": "Ada", "age": 36}`, nil
+	})
+	got, err := Generate(context.Background(), model, rendered)
+	if err != nil {
+		t.Fatalf("Generate() returned error: %v", err)
+	}
+	want := GenerateResult{
+		Response: `{"name": "Ada", "age": 36}`,
+		Output:   map[string]any{"name": "Ada", "age": 36.0},
+		Attempts: 1,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Generate() mismatch (-want +got):\n%s", diff)
+	}
+
+	failing := ModelFunc(func(context.Context, RenderedPrompt) (string, error) {
+		return "", errors.New("unavailable")
+	})
+	if _, err := Generate(context.Background(), failing, rendered); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Generate() error = %v, want the model error", err)
+	}
+}
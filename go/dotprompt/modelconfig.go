@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"slices"
+	"strings"
+)
+
+// modelFamilyConfig is a default model configuration registered with
+// DefineModelConfig.
+type modelFamilyConfig struct {
+	pattern string
+	config  map[string]any
+}
+
+// DefineModelConfig registers the default configuration of the models whose
+// names match pattern, in which `*` stands for any sequence of characters,
+// e.g. "googleai/*" or "googleai/gemini-*-flash". A pattern without `*` names
+// a single model. Registering a pattern again replaces its configuration.
+//
+// The configuration of a rendered prompt is resolved from, in increasing
+// order of precedence: the entry of DotpromptOptions.ModelConfigs for the
+// model, the configurations of the matching patterns, with more specific
+// patterns taking precedence, and the config of the prompt and of the
+// options it is rendered with. Each is merged over the earlier ones as
+// MergeMetadata merges config.
+func (dp *Dotprompt) DefineModelConfig(pattern string, config map[string]any) *Dotprompt {
+	dp.newRenderScope()
+	i := slices.IndexFunc(dp.modelFamilyConfigs, func(f modelFamilyConfig) bool {
+		return f.pattern == pattern
+	})
+	if i < 0 {
+		dp.modelFamilyConfigs = append(dp.modelFamilyConfigs, modelFamilyConfig{pattern: pattern})
+		i = len(dp.modelFamilyConfigs) - 1
+	}
+	dp.modelFamilyConfigs[i].config = config
+	return dp
+}
+
+// defaultModelConfig returns a new map holding the default configuration of
+// model, as described by DefineModelConfig.
+func (dp *Dotprompt) defaultModelConfig(model string) map[string]any {
+	config := make(map[string]any)
+	if options, ok := asStringMap(dp.modelConfigs[model]); ok {
+		config = mergeMapsDeep(config, options)
+	}
+
+	var matches []modelFamilyConfig
+	for _, f := range dp.modelFamilyConfigs {
+		if matchModelPattern(f.pattern, model) {
+			matches = append(matches, f)
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b modelFamilyConfig) int {
+		return patternSpecificity(a.pattern) - patternSpecificity(b.pattern)
+	})
+	for _, f := range matches {
+		config = mergeMapsDeep(config, f.config)
+	}
+	return config
+}
+
+// patternSpecificity orders model patterns: exact names are the most
+// specific, then patterns with more literal characters.
+func patternSpecificity(pattern string) int {
+	if !strings.Contains(pattern, "*") {
+		return len(pattern) + 1
+	}
+	return len(pattern) - strings.Count(pattern, "*")
+}
+
+// matchModelPattern reports whether name matches pattern, in which `*`
+// matches any sequence of characters, including `/`.
+func matchModelPattern(pattern, name string) bool {
+	literals := strings.Split(pattern, "*")
+	if len(literals) == 1 {
+		return pattern == name
+	}
+	first, last := literals[0], literals[len(literals)-1]
+	if len(name) < len(first)+len(last) || !strings.HasPrefix(name, first) || !strings.HasSuffix(name, last) {
+		return false
+	}
+	name = name[len(first) : len(name)-len(last)]
+	for _, literal := range literals[1 : len(literals)-1] {
+		i := strings.Index(name, literal)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(literal):]
+	}
+	return true
+}
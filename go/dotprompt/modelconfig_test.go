@@ -0,0 +1,100 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchModelPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"googleai/gemini-2.0-flash", "googleai/gemini-2.0-flash", true},
+		{"googleai/gemini-2.0-flash", "googleai/gemini-2.0-flash-lite", false},
+		{"googleai/*", "googleai/gemini-2.0-flash", true},
+		{"googleai/*", "googleai/tunedModels/x", true},
+		{"googleai/*", "vertexai/gemini-2.0-flash", false},
+		{"*", "any/model", true},
+		{"googleai/gemini-*-flash", "googleai/gemini-2.0-flash", true},
+		{"googleai/gemini-*-flash", "googleai/gemini-2.0-pro", false},
+		{"*/gemini-*", "vertexai/gemini-1.5", true},
+		{"a*b*b", "ab", false},
+	}
+	for _, tt := range tests {
+		if got := matchModelPattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchModelPattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestModelConfigResolution(t *testing.T) {
+	options := map[string]any{"temperature": 0.1, "topK": 10, "topP": 0.5, "maxOutputTokens": 100}
+	dp := NewDotprompt(&DotpromptOptions{
+		ModelConfigs: map[string]any{"googleai/gemini-2.0-flash": options},
+	})
+	dp.DefineModelConfig("googleai/gemini-*", map[string]any{"topK": 30, "topP": 0.9}).
+		DefineModelConfig("googleai/*", map[string]any{"topK": 20, "candidateCount": 1}).
+		DefineModelConfig("vertexai/*", map[string]any{"topK": 99})
+
+	tests := []struct {
+		name    string
+		source  string
+		options *PromptMetadata
+		want    ModelConfig
+	}{
+		{
+			name:   "defaults",
+			source: "---\nmodel: googleai/gemini-2.0-flash\n---\nHi",
+			want:   ModelConfig{"temperature": 0.1, "topK": 30, "topP": 0.9, "maxOutputTokens": 100, "candidateCount": 1},
+		},
+		{
+			name:    "frontmatter and options",
+			source:  "---\nmodel: googleai/gemini-2.0-flash\nconfig:\n  temperature: 0.7\n---\nHi",
+			options: &PromptMetadata{Config: ModelConfig{"topP": 1}},
+			want:    ModelConfig{"temperature": 0.7, "topK": 30, "topP": 1, "maxOutputTokens": 100, "candidateCount": 1},
+		},
+		{
+			name:   "family only",
+			source: "---\nmodel: googleai/imagen-3\n---\nHi",
+			want:   ModelConfig{"topK": 20, "candidateCount": 1},
+		},
+		{
+			name:   "no match",
+			source: "---\nmodel: openai/gpt-4o\nconfig:\n  temperature: 1\n---\nHi",
+			want:   ModelConfig{"temperature": uint64(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := dp.Render(tt.source, &DataArgument{}, tt.options)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rendered.Config); diff != "" {
+				t.Errorf("Render() config mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff(map[string]any{"temperature": 0.1, "topK": 10, "topP": 0.5, "maxOutputTokens": 100}, options); diff != "" {
+		t.Errorf("Render() modified the configured defaults (-want +got):\n%s", diff)
+	}
+}
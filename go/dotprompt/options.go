@@ -0,0 +1,217 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"io/fs"
+	"maps"
+	"slices"
+)
+
+// Option overrides a setting of a Dotprompt instance. See New and With.
+type Option func(*Dotprompt)
+
+// New creates a Dotprompt instance configured by opts, which are applied in
+// order. New(WithDotpromptOptions(options)) is equivalent to
+// NewDotprompt(options).
+func New(opts ...Option) *Dotprompt {
+	dp := NewDotprompt(nil)
+	for _, opt := range opts {
+		opt(dp)
+	}
+	return dp
+}
+
+// With returns a child of dp with opts applied, for customizing a shared
+// instance per tenant or per request without registering its helpers,
+// partials, schemas and tools again.
+//
+// The child starts as a Clone of dp: registrations made on either instance
+// afterwards do not affect the other. It shares dp's parser, so a parser
+// wrapped by a ParseCache keeps serving both, and its resolvers and stores
+// unless opts replace them. If dp caches compiled prompts, the child shares
+// its cache, and so the prompts either compiles, unless opts change how
+// prompts compile: WithStore, WithLimits, WithTranslations, WithFlags,
+// WithInclude, WithDotpromptOptions, WithHelpers and WithPartials do, while
+// the other options only apply when rendering. It likewise shares the renders
+// of dp's render cache unless opts change how prompts render. Helpers and
+// partials registered on either instance afterwards are not seen by the
+// prompts it shares with the other, so register those before calling With.
+func (dp *Dotprompt) With(opts ...Option) *Dotprompt {
+	child := dp.Clone()
+	for _, opt := range opts {
+		opt(child)
+	}
+	return child
+}
+
+// WithDefaultModel sets the model used by prompts that do not name one.
+func WithDefaultModel(model string) Option {
+	return func(dp *Dotprompt) {
+		dp.newRenderScope()
+		dp.defaultModel = model
+	}
+}
+
+// WithModelConfig sets the default configuration of model, as
+// DotpromptOptions.ModelConfigs does; see DefineModelConfig for how it is
+// merged with the config of prompts.
+func WithModelConfig(model string, config map[string]any) Option {
+	return func(dp *Dotprompt) {
+		dp.newRenderScope()
+		dp.modelConfigs[model] = config
+	}
+}
+
+// WithStore sets the store that the partials a prompt references are loaded
+// from when they are not registered on the instance; see
+// DotpromptOptions.PartialStore. Prompts themselves are loaded from a store by
+// the caller, or by CompileAll and RunPromptTests, which take it as an
+// argument.
+func WithStore(store PromptStore) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		dp.partialStore = store
+	}
+}
+
+// WithPartialStore is WithStore, named after DotpromptOptions.PartialStore.
+func WithPartialStore(store PromptStore) Option {
+	return WithStore(store)
+}
+
+// WithLimits sets the limits that rendering enforces.
+func WithLimits(limits RenderLimits) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		dp.limits = limits
+	}
+}
+
+// WithSecretResolver sets the resolver of the secret references in rendered
+// metadata; see DotpromptOptions.SecretResolver.
+func WithSecretResolver(resolver SecretResolver) Option {
+	return func(dp *Dotprompt) {
+		dp.secretResolver = resolver
+	}
+}
+
+// WithEnvAllowlist allows frontmatter to reference the named environment
+// variables, in addition to those already allowed; see
+// DotpromptOptions.EnvAllowlist.
+func WithEnvAllowlist(names ...string) Option {
+	return func(dp *Dotprompt) {
+		dp.envAllowlist = append(slices.Clip(dp.envAllowlist), names...)
+		if dp.envAllowlist == nil {
+			dp.envAllowlist = []string{}
+		}
+	}
+}
+
+// WithTranslations sets the string tables of the `t` helper; see
+// DotpromptOptions.Translations.
+func WithTranslations(translations Translations) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		dp.translations = translations
+	}
+}
+
+// WithFlags sets the provider of the `flag` helper; see
+// DotpromptOptions.Flags.
+func WithFlags(provider FlagProvider) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		dp.flags = provider
+	}
+}
+
+// WithInclude sets the files and size limit of the `include` helper; see
+// DotpromptOptions.IncludeFS and DotpromptOptions.MaxIncludeBytes.
+func WithInclude(fsys fs.FS, maxBytes int64) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		dp.includeFS = fsys
+		dp.maxIncludeBytes = maxBytes
+	}
+}
+
+// WithDotpromptOptions replaces every setting that DotpromptOptions has a
+// field for, including the helper, partial, schema and tool maps, with that of
+// options. It adapts code written against DotpromptOptions to New and With,
+// and should come before the options it is combined with.
+func WithDotpromptOptions(options *DotpromptOptions) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		dp.setOptions(options)
+	}
+}
+
+// WithHelpers adds helpers, replacing those of the same names.
+func WithHelpers(helpers map[string]any) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		maps.Copy(dp.Helpers, helpers)
+	}
+}
+
+// WithPartials adds partials, replacing those of the same names.
+func WithPartials(partials map[string]string) Option {
+	return func(dp *Dotprompt) {
+		dp.newCompileScope()
+		maps.Copy(dp.Partials, partials)
+	}
+}
+
+// WithCache caches the prompt functions that Compile, and so Render, compile
+// from the size most recently used sources, which saves parsing and compiling
+// prompts rendered repeatedly. A size of zero or less disables the cache.
+//
+// Cached functions keep the helpers and partials registered when they were
+// compiled, so register those before rendering. Clones and children created
+// with With share the cache.
+func WithCache(size int) Option {
+	return func(dp *Dotprompt) {
+		dp.compileCache = nil
+		if size > 0 {
+			dp.compileCache = newCompileCache(size)
+		}
+	}
+}
+
+// WithRenderCache caches the prompts rendered by the prompts compiled
+// afterwards, keyed by the version of the prompt, covering its source and
+// partials, the settings of the instance that apply when rendering, such as
+// its default model and model configs, and a hash of the data and options it
+// is rendered with, so identical requests skip template execution. It suits
+// prompts whose helpers render the same output for the same request.
+// Middleware still runs on every render, around the cache.
+//
+// Renders are not cached while the instance has flags, an environment
+// allowlist, a secret resolver, included files or media files (MediaRoot or
+// MediaFS), as those can change renders without the prompt or its data
+// changing; see DotpromptOptions. Nor are the
+// renders of prompts naming partials at render time, as in
+// `{{> (lookup . "layout")}}`.
+//
+// Cached renders share their parts, which callers must not modify. Clones and
+// children created with With share the cache.
+func WithRenderCache(options RenderCacheOptions) Option {
+	return func(dp *Dotprompt) {
+		dp.renderCache = newRenderCache(options)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWith(t *testing.T) {
+	base := NewDotprompt(&DotpromptOptions{
+		DefaultModel: "base/model",
+		Helpers:      map[string]any{"shout": func(s string) string { return s + "!" }},
+		Partials:     map[string]string{"greeting": "Hello"},
+		PartialStore: newTestDirStore(t, map[string]string{"_footer.prompt": "base footer"}),
+	})
+	tenant := base.With(
+		WithDefaultModel("tenant/model"),
+		WithModelConfig("tenant/model", map[string]any{"temperature": 0.5}),
+		WithPartialStore(newTestDirStore(t, map[string]string{"_footer.prompt": "tenant footer"})),
+	)
+
+	source := "{{> greeting}}, {{shout name}} {{> footer}}"
+	data := &DataArgument{Input: map[string]any{"name": "Ada"}}
+	tests := []struct {
+		name       string
+		dp         *Dotprompt
+		wantText   string
+		wantConfig ModelConfig
+	}{
+		{"base", base, "Hello, Ada! base footer", ModelConfig{}},
+		{"tenant", tenant, "Hello, Ada! tenant footer", ModelConfig{"temperature": 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := tt.dp.Render(source, data, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != tt.wantText {
+				t.Errorf("Render() text = %q, want %q", got, tt.wantText)
+			}
+			if diff := cmp.Diff(tt.wantConfig, rendered.Config); diff != "" {
+				t.Errorf("Render() config mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	tenant.Partials["welcome"] = "Hi"
+	if _, ok := base.Partials["welcome"]; ok {
+		t.Errorf("With() child registrations changed the parent's partials: %v", base.Partials)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dp := New(
+		WithDotpromptOptions(&DotpromptOptions{Partials: map[string]string{"greeting": "Hello"}}),
+		WithHelpers(map[string]any{"shout": func(s string) string { return s + "!" }}),
+		WithPartials(map[string]string{"farewell": "Bye"}),
+		WithStore(newTestDirStore(t, map[string]string{"_footer.prompt": "store footer"})),
+		WithCache(1),
+	)
+	source := "{{> greeting}}, {{shout name}} {{> farewell}} {{> footer}}"
+	rendered, err := dp.Render(source, &DataArgument{Input: map[string]any{"name": "Ada"}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got, want := rendered.Messages[0].Content[0].(*TextPart).Text, "Hello, Ada! Bye store footer"; got != want {
+		t.Errorf("Render() text = %q, want %q", got, want)
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	dp := New(WithCache(2))
+	for _, source := range []string{"a", "b", "a", "c"} {
+		if _, err := dp.Compile(source, nil); err != nil {
+			t.Fatalf("Compile() returned error: %v", err)
+		}
+	}
+	if _, err := dp.Compile("d", &PromptMetadata{Model: "x/y"}); err != nil {
+		t.Fatalf("Compile() returned error: %v", err)
+	}
+
+	// "b" is evicted as the least recently used, and "d" is not cached as it
+	// was compiled with additional metadata.
+	var cached []string
+	for e := dp.compileCache.order.Front(); e != nil; e = e.Next() {
+		cached = append(cached, e.Value.(*compileCacheEntry).key.source)
+	}
+	if diff := cmp.Diff([]string{"c", "a"}, cached); diff != "" {
+		t.Errorf("Compile() cached sources mismatch (-want +got):\n%s", diff)
+	}
+
+	if New(WithCache(0)).compileCache != nil {
+		t.Error("WithCache(0) enabled the compile cache")
+	}
+}
+
+func TestWithSharesCache(t *testing.T) {
+	source := "Hello {{shout name}}"
+	data := &DataArgument{Input: map[string]any{"name": "Ada"}}
+	base := New(
+		WithDefaultModel("base/model"),
+		WithHelpers(map[string]any{"shout": func(s string) string { return s + "!" }}),
+		WithCache(10),
+	)
+	if _, err := base.Compile(source, nil); err != nil {
+		t.Fatalf("Compile() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		dp         *Dotprompt
+		wantText   string
+		wantConfig ModelConfig
+		wantSize   int // of the cache after compiling source
+	}{
+		{"render option", base.With(WithModelConfig("base/model", map[string]any{"temperature": 0.5})), "Hello Ada!", ModelConfig{"temperature": 0.5}, 1},
+		{"compile option", base.With(WithHelpers(map[string]any{"shout": func(s string) string { return s + "!!" }})), "Hello Ada!!", ModelConfig{}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.dp.compileCache != base.compileCache {
+				t.Fatal("With() did not share the compile cache")
+			}
+			rendered, err := tt.dp.Render(source, data, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != tt.wantText {
+				t.Errorf("Render() text = %q, want %q", got, tt.wantText)
+			}
+			if diff := cmp.Diff(tt.wantConfig, rendered.Config); diff != "" {
+				t.Errorf("Render() config mismatch (-want +got):\n%s", diff)
+			}
+			if got := base.compileCache.order.Len(); got != tt.wantSize {
+				t.Errorf("compile cache holds %d prompts, want %d", got, tt.wantSize)
+			}
+		})
+	}
+}
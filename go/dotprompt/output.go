@@ -0,0 +1,280 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Output formats, set by the `output.format` frontmatter key.
+const (
+	// OutputFormatJSON is JSON conforming to the output schema, if any. It
+	// is the format of prompts that have an output schema but no format.
+	OutputFormatJSON = "json"
+	// OutputFormatEnum is one of the `enum` values of the output schema.
+	OutputFormatEnum = "enum"
+	// OutputFormatText is free text.
+	OutputFormatText = "text"
+)
+
+// OutputInstructions selects where Render places the instructions that tell
+// the model the output format of a prompt. Wherever it is set, a template
+// can place them itself with `{{section "output"}}`.
+type OutputInstructions string
+
+const (
+	// OutputInstructionsNone adds no instructions. It is the default.
+	OutputInstructionsNone OutputInstructions = ""
+	// OutputInstructionsUser appends the instructions to the last user
+	// message, adding one if there is none.
+	OutputInstructionsUser OutputInstructions = "user"
+	// OutputInstructionsSystem appends the instructions to the first system
+	// message, adding one at the start if there is none.
+	OutputInstructionsSystem OutputInstructions = "system"
+)
+
+// outputPurpose is the purpose of the parts holding output instructions, and
+// of the sections that place them.
+const outputPurpose = "output"
+
+// OutputFormat returns the output format of the prompt: its `output.format`,
+// or OutputFormatJSON if it only has an output schema, or OutputFormatText.
+func (m PromptMetadata) OutputFormat() string {
+	switch {
+	case m.Output.Format != "":
+		return m.Output.Format
+	case m.Output.Schema != nil:
+		return OutputFormatJSON
+	}
+	return OutputFormatText
+}
+
+// FormatInstructions returns the instructions that tell a model to respond
+// in the output format of the prompt, or "" for free text.
+func (m PromptMetadata) FormatInstructions() (string, error) {
+	schema, err := outputSchema(m)
+	if err != nil {
+		return "", err
+	}
+	switch format := m.OutputFormat(); format {
+	case OutputFormatText:
+		return "", nil
+	case OutputFormatJSON:
+		if schema == nil {
+			return "Output should be in JSON format.", nil
+		}
+		b, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return "Output should be in JSON format and conform to the following schema:\n\n```\n" + string(b) + "\n```\n", nil
+	case OutputFormatEnum:
+		values, err := enumValues(schema)
+		if err != nil {
+			return "", err
+		}
+		return "Output should be ONE of the following enum values. Do not output any additional information or add quotes.\n\n" +
+			strings.Join(values, "\n"), nil
+	default:
+		return "", fmt.Errorf("dotprompt: unknown output format %q", format)
+	}
+}
+
+// ParseOutput parses a model response in the output format of the prompt.
+// JSON responses, which may be wrapped in a Markdown code fence, are decoded
+// and checked against the output schema; enum responses must be one of the
+// enum values once surrounding whitespace and quotes are removed and are
+// returned as a string; text responses are returned unchanged.
+func (m PromptMetadata) ParseOutput(resp string) (any, error) {
+	schema, err := outputSchema(m)
+	if err != nil {
+		return nil, err
+	}
+	switch format := m.OutputFormat(); format {
+	case OutputFormatText:
+		return resp, nil
+	case OutputFormatJSON:
+		var value any
+		if err := json.Unmarshal([]byte(stripCodeFence(resp)), &value); err != nil {
+			return nil, fmt.Errorf("dotprompt: response is not valid JSON: %w", err)
+		}
+		if err := ValidateValue(schema, value); err != nil {
+			return nil, fmt.Errorf("dotprompt: response does not match the output schema: %w", err)
+		}
+		return value, nil
+	case OutputFormatEnum:
+		values, err := enumValues(schema)
+		if err != nil {
+			return nil, err
+		}
+		value := strings.Trim(strings.TrimSpace(resp), `"'`)
+		if !slices.Contains(values, value) {
+			return nil, fmt.Errorf("dotprompt: response %q is not one of %s", value, strings.Join(values, ", "))
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("dotprompt: unknown output format %q", format)
+	}
+}
+
+// outputSchema returns the output schema of m as a JSON Schema. Rendered
+// metadata holds one already; the schemas of parsed prompts are converted
+// from Picoschema.
+func outputSchema(m PromptMetadata) (*jsonschema.Schema, error) {
+	switch schema := m.Output.Schema.(type) {
+	case nil:
+		return nil, nil
+	case *jsonschema.Schema:
+		return schema, nil
+	default:
+		return Picoschema(schema, &PicoschemaOptions{})
+	}
+}
+
+// enumValues returns the values of an enum output schema.
+func enumValues(schema *jsonschema.Schema) ([]string, error) {
+	if schema == nil || len(schema.Enum) == 0 {
+		return nil, fmt.Errorf("dotprompt: enum output requires an output schema with enum values")
+	}
+	values := make([]string, len(schema.Enum))
+	for i, v := range schema.Enum {
+		values[i] = fmt.Sprint(v)
+	}
+	return values, nil
+}
+
+// stripCodeFence returns s without the Markdown code fence around it, if it
+// has one.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	_, body, ok := strings.Cut(s, "\n")
+	if !ok {
+		return s
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(body), "```"))
+}
+
+// DefaultJSONInstructionsTemplate is the template of the instructions added
+// to prompts with JSON output and an output schema in JSON mode.
+const DefaultJSONInstructionsTemplate = "Output should be in JSON format and conform to the following schema:\n\n```\n{{json schema indent=2}}\n```\n"
+
+// JSONModeOptions configures the handling of prompts with JSON output and an
+// output schema. Their rendered prompts have JSONMode set, and the schema is
+// described to the model in instructions.
+type JSONModeOptions struct {
+	// Template is the Handlebars template of the instructions, rendered with
+	// the output JSON Schema as `schema`; the `json` helper is available.
+	// It defaults to DefaultJSONInstructionsTemplate.
+	Template string
+	// Placement selects where the instructions go. It defaults to
+	// OutputInstructionsUser.
+	Placement OutputInstructions
+	// OmitInstructions leaves the instructions out, for models whose native
+	// structured-output mode already enforces the schema.
+	OmitInstructions bool
+}
+
+// outputInstructions returns the instructions for the output format of
+// meta, where they go, and whether the model should be in JSON mode.
+func (dp *Dotprompt) outputInstructions(meta PromptMetadata) (string, OutputInstructions, bool, error) {
+	if dp.jsonMode != nil && meta.OutputFormat() == OutputFormatJSON && meta.Output.Schema != nil {
+		if dp.jsonMode.OmitInstructions {
+			return "", OutputInstructionsNone, true, nil
+		}
+		schema, err := outputSchema(meta)
+		if err != nil {
+			return "", "", false, err
+		}
+		source := dp.jsonMode.Template
+		if source == "" {
+			source = DefaultJSONInstructionsTemplate
+		}
+		tpl, err := dp.engineOrDefault().Parse(source)
+		if err != nil {
+			return "", "", false, fmt.Errorf("dotprompt: parsing JSON instructions template: %w", err)
+		}
+		tpl.RegisterHelper("json", templateHelpers["json"])
+		instructions, err := tpl.Exec(map[string]any{"schema": schema}, nil)
+		if err != nil {
+			return "", "", false, fmt.Errorf("dotprompt: rendering JSON instructions template: %w", err)
+		}
+		placement := dp.jsonMode.Placement
+		if placement == OutputInstructionsNone {
+			placement = OutputInstructionsUser
+		}
+		return instructions, placement, true, nil
+	}
+	if dp.outputPlacement == OutputInstructionsNone {
+		return "", OutputInstructionsNone, false, nil
+	}
+	instructions, err := meta.FormatInstructions()
+	return instructions, dp.outputPlacement, false, err
+}
+
+// applyOutputInstructions returns messages with instructions placed
+// according to placement, or in the output sections of the template. The
+// messages are not modified.
+func applyOutputInstructions(messages []Message, instructions string, placement OutputInstructions) ([]Message, error) {
+	if placement == OutputInstructionsNone || instructions == "" {
+		return messages, nil
+	}
+	part := &TextPart{HasMetadata: HasMetadata{Metadata: Metadata{"purpose": outputPurpose}}, Text: instructions}
+
+	out := slices.Clone(messages)
+	placed := false
+	for i, msg := range out {
+		for j, p := range msg.Content {
+			if pending, ok := p.(*PendingPart); ok && pending.Metadata["purpose"] == outputPurpose {
+				out[i].Content = slices.Clone(msg.Content)
+				out[i].Content[j] = part
+				placed = true
+			}
+		}
+	}
+	if placed {
+		return out, nil
+	}
+
+	switch placement {
+	case OutputInstructionsUser:
+		for i := len(out) - 1; i >= 0; i-- {
+			if out[i].Role == RoleUser {
+				out[i].Content = append(slices.Clip(out[i].Content), part)
+				return out, nil
+			}
+		}
+		return append(out, Message{Role: RoleUser, Content: []Part{part}}), nil
+	case OutputInstructionsSystem:
+		for i := range out {
+			if out[i].Role == RoleSystem {
+				out[i].Content = append(slices.Clip(out[i].Content), part)
+				return out, nil
+			}
+		}
+		return append([]Message{{Role: RoleSystem, Content: []Part{part}}}, out...), nil
+	default:
+		return nil, fmt.Errorf("dotprompt: unknown output instructions placement %q", placement)
+	}
+}
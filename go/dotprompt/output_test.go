@@ -0,0 +1,265 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const jsonOutputSource = `---
+output:
+  format: json
+  schema:
+    name: string
+    age?: integer
+---
+Extract the person from: {{text}}`
+
+const enumOutputSource = `---
+output:
+  format: enum
+  schema:
+    type: string
+    enum: [POSITIVE, NEGATIVE]
+---
+{{role "system"}}Classify sentiment.
+{{role "user"}}{{text}}{{section "output"}} Thanks.`
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		resp    string
+		want    any
+		wantErr string
+	}{
+		{
+			name:   "json",
+			source: jsonOutputSource,
+			resp:   `{"name": "Ada", "age": 36}`,
+			want:   map[string]any{"name": "Ada", "age": 36.0},
+		},
+		{
+			name:   "json in code fence",
+			source: jsonOutputSource,
+			resp:   "```json\n{\"name\": \"Ada\"}\n```",
+			want:   map[string]any{"name": "Ada"},
+		},
+		{
+			name:    "json not matching schema",
+			source:  jsonOutputSource,
+			resp:    `{"age": 36}`,
+			wantErr: "does not match the output schema",
+		},
+		{
+			name:    "invalid json",
+			source:  jsonOutputSource,
+			resp:    `name: Ada`,
+			wantErr: "not valid JSON",
+		},
+		{
+			name:   "enum",
+			source: enumOutputSource,
+			resp:   " \"POSITIVE\"\n",
+			want:   "POSITIVE",
+		},
+		{
+			name:    "enum not a value",
+			source:  enumOutputSource,
+			resp:    "NEUTRAL",
+			wantErr: "not one of POSITIVE, NEGATIVE",
+		},
+		{
+			name:   "text",
+			source: "Hello",
+			resp:   " anything ",
+			want:   " anything ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := NewDotprompt(nil).Render(tt.source, &DataArgument{}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			got, err := rendered.ParseOutput(tt.resp)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseOutput() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOutput() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseOutput() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseOutputParsedPrompt(t *testing.T) {
+	parsed, err := ParseDocument(jsonOutputSource)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	if _, err := parsed.ParseOutput(`{"age": 1}`); err == nil {
+		t.Error("ParseOutput() returned nil error for a response missing a required field")
+	}
+}
+
+func TestOutputInstructions(t *testing.T) {
+	outputPart := func(text string) Part {
+		return &TextPart{HasMetadata: HasMetadata{Metadata: Metadata{"purpose": "output"}}, Text: text}
+	}
+	enumInstructions := "Output should be ONE of the following enum values. Do not output any additional information or add quotes.\n\nPOSITIVE\nNEGATIVE"
+
+	tests := []struct {
+		name      string
+		placement OutputInstructions
+		source    string
+		want      []Message
+	}{
+		{
+			name:      "none",
+			placement: OutputInstructionsNone,
+			source:    "---\noutput:\n  format: json\n---\nHi",
+			want:      []Message{textMessage(RoleUser, "Hi")},
+		},
+		{
+			name:      "user",
+			placement: OutputInstructionsUser,
+			source:    "---\noutput:\n  format: json\n---\nHi",
+			want: []Message{{Role: RoleUser, Content: []Part{
+				&TextPart{Text: "Hi"}, outputPart("Output should be in JSON format."),
+			}}},
+		},
+		{
+			name:      "system",
+			placement: OutputInstructionsSystem,
+			source:    "---\noutput:\n  format: json\n---\nHi",
+			want: []Message{
+				{Role: RoleSystem, Content: []Part{outputPart("Output should be in JSON format.")}},
+				textMessage(RoleUser, "Hi"),
+			},
+		},
+		{
+			name:      "section",
+			placement: OutputInstructionsSystem,
+			source:    enumOutputSource,
+			want: []Message{
+				textMessage(RoleSystem, "Classify sentiment.\n"),
+				{Role: RoleUser, Content: []Part{&TextPart{Text: "great"}, outputPart(enumInstructions), &TextPart{Text: " Thanks."}}},
+			},
+		},
+		{
+			name:      "text",
+			placement: OutputInstructionsUser,
+			source:    "Hi",
+			want:      []Message{textMessage(RoleUser, "Hi")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(&DotpromptOptions{OutputInstructions: tt.placement})
+			rendered, err := dp.Render(tt.source, &DataArgument{Input: map[string]any{"text": "great"}}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rendered.Messages); diff != "" {
+				t.Errorf("Render() messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatInstructionsSchema(t *testing.T) {
+	rendered, err := NewDotprompt(nil).Render(jsonOutputSource, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	got, err := rendered.FormatInstructions()
+	if err != nil {
+		t.Fatalf("FormatInstructions() returned error: %v", err)
+	}
+	for _, want := range []string{"conform to the following schema", `"name"`, `"required"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatInstructions() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestJSONMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      JSONModeOptions
+		source       string
+		wantJSONMode bool
+		wantTexts    []string
+	}{
+		{
+			name:         "default template",
+			source:       jsonOutputSource,
+			wantJSONMode: true,
+			wantTexts:    []string{"Extract the person from: x", "Output should be in JSON format and conform to the following schema:\n\n```\n{\n  \"properties\""},
+		},
+		{
+			name:         "custom template",
+			options:      JSONModeOptions{Template: "Reply with {{json schema.required}} only."},
+			source:       jsonOutputSource,
+			wantJSONMode: true,
+			wantTexts:    []string{"Extract the person from: x", `Reply with ["name"] only.`},
+		},
+		{
+			name:         "omit instructions",
+			options:      JSONModeOptions{OmitInstructions: true},
+			source:       jsonOutputSource,
+			wantJSONMode: true,
+			wantTexts:    []string{"Extract the person from: x"},
+		},
+		{
+			name:      "no schema",
+			source:    "---\noutput:\n  format: json\n---\n{{text}}",
+			wantTexts: []string{"x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(&DotpromptOptions{JSONMode: &tt.options})
+			rendered, err := dp.Render(tt.source, &DataArgument{Input: map[string]any{"text": "x"}}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if rendered.JSONMode != tt.wantJSONMode {
+				t.Errorf("JSONMode = %v, want %v", rendered.JSONMode, tt.wantJSONMode)
+			}
+			parts := rendered.Messages[len(rendered.Messages)-1].Content
+			if len(parts) != len(tt.wantTexts) {
+				t.Fatalf("last message has %d parts, want %d", len(parts), len(tt.wantTexts))
+			}
+			for i, want := range tt.wantTexts {
+				if got := parts[i].(*TextPart).Text; !strings.HasPrefix(got, want) {
+					t.Errorf("part %d = %q, want prefix %q", i, got, want)
+				}
+			}
+		})
+	}
+}
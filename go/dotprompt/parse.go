@@ -17,10 +17,17 @@
 package dotprompt
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/goccy/go-yaml"
 )
@@ -41,11 +48,22 @@ const (
 	// Prefixes for the history markers in the template.
 	HistoryMarkerPrefix = "<<<dotprompt:history"
 
+	// Prefixes for the examples markers in the template.
+	ExamplesMarkerPrefix = "<<<dotprompt:examples"
+
 	// Prefixes for the media markers in the template.
 	MediaMarkerPrefix = "<<<dotprompt:media:"
 
+	// Prefixes for the audio and video markers in the template, which are
+	// media markers that carry timing metadata.
+	AudioMarkerPrefix = "<<<dotprompt:media:audio"
+	VideoMarkerPrefix = "<<<dotprompt:media:video"
+
 	// Prefixes for the section markers in the template.
 	SectionMarkerPrefix = "<<<dotprompt:section"
+
+	// Prefixes for the data markers in the template.
+	DataMarkerPrefix = "<<<dotprompt:data"
 )
 
 var (
@@ -61,8 +79,8 @@ var (
 	EmptyFrontmatterRegex = regexp.MustCompile(`^(?:(?:#[^\n]*|[ \t]*)\n)*---\s*\n---\s*\n([\s\S]*)$`)
 
 	// RoleAndHistoryMarkerRegex is a regular expression to match
-	// <<<dotprompt:role:xxx>>> and <<<dotprompt:history>>> markers in the
-	// template.
+	// <<<dotprompt:role:xxx>>>, <<<dotprompt:history>>> and
+	// <<<dotprompt:examples>>> markers in the template.
 	//
 	// Note: Only lowercase letters are allowed after 'role:'.
 	//
@@ -70,18 +88,22 @@ var (
 	// - <<<dotprompt:role:user>>>
 	// - <<<dotprompt:role:system>>>
 	// - <<<dotprompt:history>>>
+	// - <<<dotprompt:examples>>>
 	RoleAndHistoryMarkerRegex = regexp.MustCompile(
-		`(<<<dotprompt:(?:role:[a-z]+|history))>>>`)
+		`(<<<dotprompt:(?:role:[a-z]+|history|examples))>>>`)
 
 	// MediaAndSectionMarkerRegex is a regular expression to match
-	// <<<dotprompt:media:url>>> and <<<dotprompt:section>>> markers in the
-	// template.
+	// <<<dotprompt:media:url>>>, <<<dotprompt:media:audio>>>,
+	// <<<dotprompt:media:video>>>, <<<dotprompt:section>>> and
+	// <<<dotprompt:data>>> markers in the template.
 	//
 	// Examples of matching patterns:
 	// - <<<dotprompt:media:url>>>
+	// - <<<dotprompt:media:video https://example.com/a.mp4 startOffset=10s>>>
 	// - <<<dotprompt:section>>>
+	// - <<<dotprompt:data {"id":1}>>>
 	MediaAndSectionMarkerRegex = regexp.MustCompile(
-		`(<<<dotprompt:(?:media:url|section).*?)>>>`)
+		`(<<<dotprompt:(?:media:(?:url|audio|video)|section|data).*?)>>>`)
 )
 
 // ReservedMetadataKeywords is a list of keywords that are reserved for metadata
@@ -91,13 +113,18 @@ var ReservedMetadataKeywords = []string{
 	// NOTE: KEEP SORTED
 	"config",
 	"description",
+	"examples",
 	"ext",
+	"format",
 	"input",
 	"maxTurns",
 	"model",
 	"name",
 	"output",
 	"raw",
+	"returnToolRequests",
+	"stopSequences",
+	"toolChoice",
 	"toolDefs",
 	"tools",
 	"variant",
@@ -116,6 +143,12 @@ var ReservedMetadataKeywords = []string{
 // the capturing group in the result.  For simple regexes, it behaves like
 // regexp.Split, removing the matched separators.
 func splitByRegex(source string, regex *regexp.Regexp) []string {
+	return splitByRegexTrim(source, regex, TrimDefault)
+}
+
+// splitByRegexTrim implements splitByRegex. With TrimNone only empty pieces
+// are filtered out.
+func splitByRegexTrim(source string, regex *regexp.Regexp, trim TrimMode) []string {
 	// Check if the regex is one of the marker regexes by looking for capturing
 	// groups in the pattern.
 	hasCapturingGroups := strings.Contains(regex.String(), "(")
@@ -126,7 +159,7 @@ func splitByRegex(source string, regex *regexp.Regexp) []string {
 		// Filter out empty or whitespace-only pieces.
 		var result []string
 		for _, s := range pieces {
-			if strings.TrimSpace(s) != "" {
+			if trim.keepPiece(s) {
 				result = append(result, s)
 			}
 		}
@@ -136,7 +169,7 @@ func splitByRegex(source string, regex *regexp.Regexp) []string {
 	// For marker regexes with capturing groups, include the matched portions.
 	matches := regex.FindAllStringSubmatchIndex(source, -1)
 	if len(matches) == 0 {
-		if strings.TrimSpace(source) != "" {
+		if trim.keepPiece(source) {
 			return []string{source}
 		}
 		return []string{}
@@ -153,7 +186,7 @@ func splitByRegex(source string, regex *regexp.Regexp) []string {
 		// If there's text before the match that isn't empty...
 		if start > lastEnd {
 			textBefore := source[lastEnd:start]
-			if strings.TrimSpace(textBefore) != "" {
+			if trim.keepPiece(textBefore) {
 				result = append(result, textBefore)
 			}
 		}
@@ -164,7 +197,7 @@ func splitByRegex(source string, regex *regexp.Regexp) []string {
 
 		if groupStart >= 0 && groupEnd >= 0 {
 			matchText := source[groupStart:groupEnd]
-			if strings.TrimSpace(matchText) != "" {
+			if trim.keepPiece(matchText) {
 				result = append(result, matchText)
 			}
 		}
@@ -175,7 +208,7 @@ func splitByRegex(source string, regex *regexp.Regexp) []string {
 	// If there's text after the last match that isn't empty...
 	if lastEnd < len(source) {
 		textAfter := source[lastEnd:]
-		if strings.TrimSpace(textAfter) != "" {
+		if trim.keepPiece(textAfter) {
 			result = append(result, textAfter)
 		}
 	}
@@ -202,7 +235,8 @@ func convertNamespacedEntryToNestedObject(
 	value any,
 	obj map[string]map[string]any,
 ) map[string]map[string]any {
-	// NOTE: Goes only a single level deep.
+	// NOTE: Goes only a single level deep, as the spec requires; see
+	// PromptMetadata.ExtTree for keys nested at every dot.
 	if obj == nil {
 		obj = make(map[string]map[string]any)
 	}
@@ -221,26 +255,217 @@ func convertNamespacedEntryToNestedObject(
 }
 
 // extractFrontmatterAndBody extracts the frontmatter and body from a .prompt
-// file.
-func extractFrontmatterAndBody(source string) (string, string) {
-	match := FrontmatterAndBodyRegex.FindStringSubmatch(source)
-	if match == nil {
-		// Try the empty frontmatter pattern
-		match = EmptyFrontmatterRegex.FindStringSubmatch(source)
-		if match == nil {
-			return "", ""
+// file. If the file has no frontmatter block, the whole source is the body and
+// found is false.
+func extractFrontmatterAndBody(source string) (frontmatter, body string, found bool) {
+	fmStart, fmEnd, bodyStart, ok := FrontmatterBounds(source)
+	if !ok {
+		return "", source, false
+	}
+	return source[fmStart:fmEnd], source[bodyStart:], true
+}
+
+// FrontmatterBounds locates the frontmatter and body of a .prompt file: the
+// frontmatter is source[fmStart:fmEnd] and the body is source[bodyStart:]. It
+// reports false if the file has no frontmatter block.
+//
+// The result is the same as matching FrontmatterAndBodyRegex and then
+// EmptyFrontmatterRegex, but the scan stops at the closing `---`, so its cost
+// does not depend on the size of the body, and a leading UTF-8 byte order
+// mark is skipped.
+func FrontmatterBounds(source string) (fmStart, fmEnd, bodyStart int, ok bool) {
+	open, found := frontmatterOpening(source)
+	if !found {
+		return 0, 0, 0, false
+	}
+
+	// `---\s*(?:\r\n|\r|\n)`: \s* is greedy, so the latest line break in the
+	// run of whitespace after the opening `---` is tried first.
+	wsEnd := skipRegexSpace(source, open)
+	for p := wsEnd - 1; p >= open; p-- {
+		for _, n := range lineBreaksAt(source, p) {
+			if fmEnd, bodyStart, ok := frontmatterClosing(source, p+n); ok {
+				return p + n, fmEnd, bodyStart, true
+			}
+		}
+	}
+
+	// EmptyFrontmatterRegex: `---\s*\n---\s*\n([\s\S]*)`.
+	for p := wsEnd - 1; p >= open; p-- {
+		if source[p] != '\n' || !strings.HasPrefix(source[p+1:], "---") {
+			continue
+		}
+		close := p + 4
+		for q := skipRegexSpace(source, close) - 1; q >= close; q-- {
+			if source[q] == '\n' {
+				return p + 1, p + 1, q + 1, true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// frontmatterOpening skips the blank lines and `#` comment lines that may
+// precede the frontmatter and returns the offset just past the opening `---`.
+func frontmatterOpening(source string) (int, bool) {
+	pos := 0
+	if strings.HasPrefix(source, utf8BOM) {
+		pos = len(utf8BOM)
+	}
+	for {
+		rest := source[pos:]
+		if strings.HasPrefix(rest, "#") {
+			i := strings.IndexByte(rest, '\n')
+			if i < 0 {
+				return 0, false
+			}
+			pos += i + 1
+			continue
+		}
+		i := 0
+		for i < len(rest) && (rest[i] == ' ' || rest[i] == '\t') {
+			i++
+		}
+		if i < len(rest) && rest[i] == '\n' {
+			pos += i + 1
+			continue
+		}
+		break
+	}
+	if !strings.HasPrefix(source[pos:], "---") {
+		return 0, false
+	}
+	return pos + 3, true
+}
+
+// frontmatterClosing finds the end of the frontmatter starting at fmStart,
+// matching `([\s\S]*?)(?:\r\n|\r|\n)---\s*(?:\r\n|\r|\n)`. It returns the
+// end of the frontmatter and the start of the body.
+func frontmatterClosing(source string, fmStart int) (fmEnd, bodyStart int, ok bool) {
+	for pos := fmStart; ; {
+		i := strings.IndexAny(source[pos:], "\r\n")
+		if i < 0 {
+			return 0, 0, false
+		}
+		fmEnd = pos + i
+		for _, n := range lineBreaksAt(source, fmEnd) {
+			close := fmEnd + n
+			if !strings.HasPrefix(source[close:], "---") {
+				continue
+			}
+			close += 3
+			for p := skipRegexSpace(source, close) - 1; p >= close; p-- {
+				if breaks := lineBreaksAt(source, p); len(breaks) > 0 {
+					return fmEnd, p + breaks[0], true
+				}
+			}
+		}
+		pos = fmEnd + 1
+	}
+}
+
+// lineBreaksAt returns the lengths of the line breaks `\r\n`, `\r` and `\n`
+// that match at offset i, in the order the regular expression alternation
+// tries them.
+func lineBreaksAt(source string, i int) []int {
+	switch {
+	case source[i] == '\r' && i+1 < len(source) && source[i+1] == '\n':
+		return []int{2, 1}
+	case source[i] == '\r' || source[i] == '\n':
+		return []int{1}
+	}
+	return nil
+}
+
+// skipRegexSpace returns the offset of the first character at or after i that
+// is not matched by the regular expression class \s.
+func skipRegexSpace(source string, i int) int {
+	for i < len(source) {
+		switch source[i] {
+		case ' ', '\t', '\n', '\f', '\r':
+			i++
+		default:
+			return i
 		}
-		return "", match[1]
 	}
-	frontmatter, body := match[1], match[2]
-	return frontmatter, body
+	return i
 }
 
 // ParseDocument parses a document containing YAML frontmatter and a template
 // content section.  The frontmatter contains metadata and configuration for the
 // prompt.
+//
+// The source is first normalized with NormalizeSourceEncoding. ParseDocument
+// is equivalent to the Parse method of a zero ParseOptions.
 func ParseDocument(source string) (ParsedPrompt, error) {
-	frontmatter, body := extractFrontmatterAndBody(source)
+	return ParseOptions{}.Parse(source)
+}
+
+// ParseOptions configures the parsing of .prompt files. Its Parse method
+// value can be used as DotpromptOptions.Parser, e.g.
+// `ParseOptions{RequireFrontmatter: true}.Parse`.
+type ParseOptions struct {
+	// Strict makes frontmatter that is not valid YAML or does not pass
+	// ValidateFrontmatter an error instead of being dropped or ignored.
+	Strict bool
+	// StrictYAML makes frontmatter that is not valid YAML an error, as
+	// Strict does, without checking it with ValidateFrontmatter. Unlike the
+	// default, it never prints the YAML error.
+	StrictYAML bool
+	// RequireFrontmatter makes documents without a frontmatter block an
+	// error.
+	RequireFrontmatter bool
+	// UniformBody parses documents without frontmatter like documents with
+	// an empty frontmatter block, trimming the spaces other than line breaks
+	// around them. It is off by default for compatibility: ParseDocument uses
+	// such documents as the template verbatim, as the other Dotprompt
+	// runtimes do.
+	UniformBody bool
+	// Trim selects how the template is trimmed. With TrimNone, documents
+	// without frontmatter are always used verbatim.
+	Trim TrimMode
+}
+
+// Parse parses source as configured by o.
+func (o ParseOptions) Parse(source string) (ParsedPrompt, error) {
+	source, err := NormalizeSourceEncoding(source)
+	if err != nil {
+		return ParsedPrompt{}, err
+	}
+	frontmatter, body, found := extractFrontmatterAndBody(source)
+	if !found {
+		if o.RequireFrontmatter {
+			return ParsedPrompt{}, errors.New("prompt source has no frontmatter")
+		}
+		if !o.UniformBody {
+			// ParseSections uses the whole source when both are empty.
+			body = ""
+		}
+	}
+	prompt, err := parseSections(source, frontmatter, body, o.Strict || o.StrictYAML, o.Trim)
+	if err != nil {
+		return ParsedPrompt{}, err
+	}
+	if o.Strict {
+		if err := ValidateFrontmatter(prompt.PromptMetadata); err != nil {
+			return ParsedPrompt{}, err
+		}
+	}
+	return prompt, nil
+}
+
+// ParseSections builds a ParsedPrompt from the frontmatter and body of a
+// document that has already been split, such as by an alternative
+// DocumentParser. If both frontmatter and body are empty the whole source is
+// used as the template.
+func ParseSections(source, frontmatter, body string) (ParsedPrompt, error) {
+	return parseSections(source, frontmatter, body, false, TrimDefault)
+}
+
+// parseSections implements ParseSections. If strict is set, frontmatter that
+// is not valid YAML is an error instead of being dropped; trim selects how the
+// template is trimmed.
+func parseSections(source, frontmatter, body string, strict bool, trim TrimMode) (ParsedPrompt, error) {
 	promptMetadata := PromptMetadata{
 		Ext: make(map[string]map[string]any),
 	}
@@ -259,12 +484,15 @@ func ParseDocument(source string) (ParsedPrompt, error) {
 			err = yaml.Unmarshal([]byte(frontmatter), &parsedMetadata)
 		}()
 
+		if err != nil && strict {
+			return ParsedPrompt{}, fmt.Errorf("invalid YAML frontmatter: %w", err)
+		}
 		if err != nil {
 			fmt.Printf("Dotprompt: Error parsing YAML frontmatter: %v\n", err)
 			// Return a basic ParsedPrompt with just the template
 			return ParsedPrompt{
 				PromptMetadata: promptMetadata,
-				Template:       trimUnicodeSpacesExceptNewlines(source),
+				Template:       trim.trim(source, trimUnicodeSpacesExceptNewlines),
 			}, nil
 		}
 
@@ -274,7 +502,9 @@ func ParseDocument(source string) (ParsedPrompt, error) {
 		}
 		ext := make(map[string]map[string]any)
 
-		for key, value := range raw {
+		pruned.RawKeyOrder = yamlKeyOrder([]byte(frontmatter))
+		for _, key := range pruned.RawKeyOrder.Sort(raw) {
+			value := raw[key]
 			if slices.Contains(ReservedMetadataKeywords, key) {
 				// Add to pruned metadata.
 				switch key {
@@ -288,8 +518,22 @@ func ParseDocument(source string) (ParsedPrompt, error) {
 					pruned.Version = stringOrEmpty(value)
 				case "maxTurns":
 					pruned.MaxTurns = intOrZero(value)
+				case "format":
+					pruned.Format = PromptFormat(stringOrEmpty(value))
+				case "toolChoice":
+					pruned.ToolChoice = ToolChoice(stringOrEmpty(value))
+				case "returnToolRequests":
+					pruned.ReturnToolRequests = boolOrFalse(value)
+				case "stopSequences":
+					pruned.StopSequences = stringsOrNil(value)
 				case "model":
 					pruned.Model = stringOrEmpty(value)
+				case "examples":
+					examples, err := parseExamples(value)
+					if err != nil {
+						return ParsedPrompt{}, err
+					}
+					pruned.Examples = examples
 				case "config":
 					if configMap, ok := value.(map[string]any); ok {
 						pruned.Config = configMap
@@ -351,6 +595,14 @@ func ParseDocument(source string) (ParsedPrompt, error) {
 				}
 			} else if strings.Contains(key, ".") {
 				convertNamespacedEntryToNestedObject(key, value, ext)
+			} else if parsed, ok, err := parseMetadataExtension(key, value); ok {
+				if err != nil {
+					return ParsedPrompt{}, err
+				}
+				if pruned.Extensions == nil {
+					pruned.Extensions = make(map[string]any)
+				}
+				pruned.Extensions[key] = parsed
 			}
 		}
 
@@ -360,7 +612,7 @@ func ParseDocument(source string) (ParsedPrompt, error) {
 
 		return ParsedPrompt{
 			PromptMetadata: pruned,
-			Template:       strings.TrimSpace(body),
+			Template:       trim.trim(body, strings.TrimSpace),
 		}, nil
 	}
 
@@ -368,7 +620,7 @@ func ParseDocument(source string) (ParsedPrompt, error) {
 	if body != "" {
 		return ParsedPrompt{
 			PromptMetadata: promptMetadata,
-			Template:       trimUnicodeSpacesExceptNewlines(body),
+			Template:       trim.trim(body, trimUnicodeSpacesExceptNewlines),
 		}, nil
 	}
 
@@ -379,74 +631,171 @@ func ParseDocument(source string) (ParsedPrompt, error) {
 	}, nil
 }
 
+// maxPooledSourceSize is the largest message source buffer returned to
+// messageSourcePool; larger buffers are dropped so that one huge render does
+// not pin its memory for the life of the process.
+const maxPooledSourceSize = 64 << 10
+
+// messageSourceBuilder accumulates one message while ToMessages splits a
+// rendered template. It is the pooled counterpart of MessageSource.
+type messageSourceBuilder struct {
+	role     Role
+	source   bytes.Buffer
+	content  []Part
+	metadata map[string]any
+	// opened is set when a role marker started the message.
+	opened bool
+}
+
+// messageSourceList holds the builders of one ToMessages call.
+type messageSourceList struct {
+	items []*messageSourceBuilder
+}
+
+var (
+	messageSourcePool     = sync.Pool{New: func() any { return new(messageSourceBuilder) }}
+	messageSourceListPool = sync.Pool{New: func() any { return new(messageSourceList) }}
+)
+
+// add appends a builder for a message with the given role.
+func (l *messageSourceList) add(role Role) *messageSourceBuilder {
+	b := messageSourcePool.Get().(*messageSourceBuilder)
+	b.role = role
+	b.opened = false
+	l.items = append(l.items, b)
+	return b
+}
+
+// last returns the builder of the current message.
+func (l *messageSourceList) last() *messageSourceBuilder {
+	return l.items[len(l.items)-1]
+}
+
+// release returns the list and its builders to their pools. Nothing built
+// from them may refer to their buffers afterwards.
+func (l *messageSourceList) release() {
+	for i, b := range l.items {
+		l.items[i] = nil
+		b.content = nil
+		b.metadata = nil
+		if b.source.Cap() > maxPooledSourceSize {
+			continue
+		}
+		b.source.Reset()
+		messageSourcePool.Put(b)
+	}
+	l.items = l.items[:0]
+	messageSourceListPool.Put(l)
+}
+
+// hasContent reports whether the accumulated source is not blank, as
+// trimUnicodeSpacesExceptNewlines would report it. With TrimNone any source
+// is content.
+func (b *messageSourceBuilder) hasContent(trim TrimMode) bool {
+	if trim == TrimNone {
+		return b.source.Len() > 0
+	}
+	return bytes.ContainsFunc(b.source.Bytes(), func(r rune) bool {
+		return !unicode.IsSpace(r) || r == '\n' || r == '\r'
+	})
+}
+
 // ToMessages converts a rendered template string into an array of messages.
+//
+// The intermediate message sources are drawn from a pool, so ToMessages does
+// not allocate them in steady state; it is safe for concurrent use.
 func ToMessages(renderedString string, data *DataArgument) ([]Message, error) {
+	return toMessages(renderedString, data, messageOptions{})
+}
+
+// messageOptions configures toMessages.
+type messageOptions struct {
+	// aliases replaces the roles named in role markers that are its keys.
+	aliases map[string]Role
+	// keepEmpty keeps the messages started by role markers that have no
+	// content, instead of letting the next role marker replace them.
+	keepEmpty bool
+	// trim selects whether whitespace-only text is dropped.
+	trim TrimMode
+	// examples are the few-shot example messages placed at the examples
+	// marker, or before the first non-system message if there is none.
+	examples []Message
+}
+
+// toMessages implements ToMessages.
+func toMessages(renderedString string, data *DataArgument, opts messageOptions) ([]Message, error) {
+	list := messageSourceListPool.Get().(*messageSourceList)
+	defer list.release()
+
 	// Create the initial message source with empty content.
-	ms := &MessageSource{
-		Role:   RoleUser,
-		Source: "",
+	list.add(RoleUser)
+
+	var history []Message
+	if data != nil {
+		history = data.Messages
 	}
-	messageSources := []*MessageSource{ms}
+	examplesPlaced := false
 
-	for _, piece := range splitByRoleAndHistoryMarkers(renderedString) {
+	for _, piece := range splitByRegexTrim(renderedString, RoleAndHistoryMarkerRegex, opts.trim) {
 		if strings.HasPrefix(piece, RoleMarkerPrefix) {
-			roleStr := piece[len(RoleMarkerPrefix):]
-			role := Role(roleStr)
+			role := Role(piece[len(RoleMarkerPrefix):])
+			if alias, ok := opts.aliases[string(role)]; ok {
+				role = alias
+			}
 
-			if messageSources[len(messageSources)-1].Source != "" &&
-				trimUnicodeSpacesExceptNewlines(messageSources[len(messageSources)-1].Source) != "" {
+			if last := list.last(); last.hasContent(opts.trim) || (opts.keepEmpty && last.opened) {
 				// If the current message has content, create a new message.
-				newMs := &MessageSource{
-					Role:   role,
-					Source: "",
-				}
-				messageSources = append(messageSources, newMs)
+				list.add(role).opened = true
 			} else {
 				// Otherwise, update the role of the current message.
-				messageSources[len(messageSources)-1].Role = role
+				last.role = role
+				last.opened = true
 			}
 		} else if strings.HasPrefix(piece, HistoryMarkerPrefix) {
 			// Add the history messages to the message sources.
-			var msgs []Message
-			if data != nil && data.Messages != nil {
-				msgs = data.Messages
+			for _, msg := range history {
+				b := list.add(msg.Role)
+				b.content = msg.Content
+				b.metadata = historyMetadata(msg.Metadata)
 			}
-
-			historyMessages, err := transformMessagesToHistory(msgs)
-			if err != nil {
-				return nil, err
-			}
-
-			if len(historyMessages) > 0 {
-				for _, msg := range historyMessages {
-					messageSources = append(messageSources, &MessageSource{
-						Role:     msg.Role,
-						Content:  msg.Content,
-						Metadata: msg.Metadata,
-					})
+			list.add(RoleModel)
+		} else if strings.HasPrefix(piece, ExamplesMarkerPrefix) {
+			// Add the example messages, once, and resume with the user.
+			if !examplesPlaced {
+				for _, msg := range opts.examples {
+					b := list.add(msg.Role)
+					b.content = msg.Content
+					b.metadata = msg.Metadata
 				}
+				examplesPlaced = true
 			}
-
-			newMs := &MessageSource{
-				Role:   RoleModel,
-				Source: "",
-			}
-			messageSources = append(messageSources, newMs)
+			list.add(RoleUser)
 		} else {
 			// Otherwise, add the piece to the current message source.
-			messageSources[len(messageSources)-1].Source += piece
+			list.last().source.WriteString(piece)
 		}
 	}
 
-	messages, err := messageSourcesToMessages(messageSources)
-	if err != nil {
-		return nil, err
+	messages := make([]Message, 0, len(list.items)+len(opts.examples)+len(history))
+	for _, b := range list.items {
+		msg, ok, err := messageSourceToMessage(&MessageSource{
+			Role:     b.role,
+			Source:   b.source.String(),
+			Content:  b.content,
+			Metadata: b.metadata,
+		}, opts.trim, opts.keepEmpty && b.opened)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			messages = append(messages, msg)
+		}
 	}
 
-	if data != nil {
-		return insertHistory(messages, data.Messages)
+	if !examplesPlaced {
+		messages = insertExamples(messages, opts.examples)
 	}
-	return insertHistory(messages, []Message{})
+	return insertHistory(messages, history)
 }
 
 // messageSourcesToMessages converts an array of message sources to an array of
@@ -457,33 +806,59 @@ func messageSourcesToMessages(
 	messages := []Message{}
 
 	for _, m := range messageSources {
-		// Only skip messages that have both empty Content and empty Source.
-		if m.Content == nil && strings.TrimSpace(m.Source) == "" {
-			continue
+		out, ok, err := messageSourceToMessage(m, TrimDefault, false)
+		if err != nil {
+			return nil, err
 		}
-
-		out := Message{
-			Role: m.Role,
+		if ok {
+			messages = append(messages, out)
 		}
+	}
 
-		if m.Content != nil {
-			out.Content = m.Content
-		} else {
-			parts, err := toParts(m.Source)
-			if err != nil {
-				return nil, err
-			}
-			out.Content = parts
+	return messages, nil
+}
+
+// messageSourceToMessage converts a message source to a message. It returns
+// false if the message source is empty and should be skipped, unless keep is
+// set. trim selects whether whitespace-only text parts are dropped.
+func messageSourceToMessage(m *MessageSource, trim TrimMode, keep bool) (Message, bool, error) {
+	source, cache, err := extractCacheMarkers(m.Source)
+	if err != nil {
+		return Message{}, false, err
+	}
+	if cache != nil {
+		metadata := maps.Clone(m.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]any)
 		}
+		metadata[cacheMetadataKey] = cache
+		m = &MessageSource{Role: m.Role, Source: source, Content: m.Content, Metadata: metadata}
+	}
+
+	// Only skip messages that have both empty Content and empty Source.
+	if !keep && m.Content == nil && strings.TrimSpace(m.Source) == "" {
+		return Message{}, false, nil
+	}
+
+	out := Message{
+		Role: m.Role,
+	}
 
-		if m.Metadata != nil {
-			out.Metadata = m.Metadata
+	if m.Content != nil {
+		out.Content = m.Content
+	} else {
+		parts, err := toParts(m.Source, trim)
+		if err != nil {
+			return Message{}, false, err
 		}
+		out.Content = parts
+	}
 
-		messages = append(messages, out)
+	if m.Metadata != nil {
+		out.Metadata = m.Metadata
 	}
 
-	return messages, nil
+	return out, true, nil
 }
 
 // transformMessagesToHistory adds history metadata to an array of messages.
@@ -491,18 +866,24 @@ func transformMessagesToHistory(messages []Message) ([]Message, error) {
 	result := make([]Message, len(messages))
 
 	for i, message := range messages {
-		newMetadata := copyMapping(message.Metadata)
-		newMetadata["purpose"] = "history"
 		result[i] = Message{
 			Role:        message.Role,
 			Content:     message.Content,
-			HasMetadata: HasMetadata{Metadata: newMetadata},
+			HasMetadata: HasMetadata{Metadata: historyMetadata(message.Metadata)},
 		}
 	}
 
 	return result, nil
 }
 
+// historyMetadata returns a copy of metadata marked with the history purpose.
+func historyMetadata(metadata Metadata) Metadata {
+	out := make(Metadata, len(metadata)+1)
+	maps.Copy(out, metadata)
+	out["purpose"] = "history"
+	return out
+}
+
 // messagesHaveHistory checks if the messages have history metadata.
 func messagesHaveHistory(messages []Message) bool {
 	for _, msg := range messages {
@@ -527,46 +908,57 @@ func messagesHaveHistory(messages []Message) bool {
 // The history is not inserted:
 // - If it already exists in the messages.
 // - If there is no user message.
+//
+// Like append, insertHistory reuses the spare capacity of messages when it can
+// hold the history.
 func insertHistory(messages []Message, history []Message) ([]Message, error) {
-	// If we have no history or find an existing instance of history, return the
-	// original messages.
-	h := len(history)
-	if h == 0 || messagesHaveHistory(messages) {
-		return messages, nil
-	}
-
 	// If there are no messages, return the history.
 	if len(messages) == 0 {
 		return history, nil
 	}
+	return InsertHistoryInPlace(messages, history), nil
+}
 
-	// If the last message is a user message, insert history before it.
-	lastMessage := messages[len(messages)-1]
-	if lastMessage.Role == RoleUser {
-		m := len(messages)
-		result := make([]Message, 0, m-1+h+1)
-
-		// Sandwich the history between the last user message and the new user
-		// message.
-		result = append(result, messages[:m-1]...)
-		result = append(result, history...)
-		result = append(result, lastMessage)
+// InsertHistoryInPlace inserts history into messages where ToMessages would:
+// before the last message if it is a user message, and at the end otherwise.
+// Nothing is inserted if history is empty or messages already contain
+// history.
+//
+// It is meant for callers that manage their own message buffers, such as
+// servers that keep long conversations: the history is copied into the
+// backing array of messages, which grows at most once if it lacks room for
+// it. As with append, the result must be used in place of messages.
+func InsertHistoryInPlace(messages []Message, history []Message) []Message {
+	// If we have no history or find an existing instance of history, return the
+	// original messages.
+	h := len(history)
+	if h == 0 || messagesHaveHistory(messages) {
+		return messages
+	}
 
-		return result, nil
+	m := len(messages)
+	if m == 0 || messages[m-1].Role != RoleUser {
+		// Append history to the end of the messages.
+		return append(messages, history...)
 	}
 
-	// Otherwise, append history to the end of the messages.
-	return append(messages, history...), nil
+	// Sandwich the history between the earlier messages and the last user
+	// message.
+	lastMessage := messages[m-1]
+	messages = slices.Grow(messages, h)[:m+h]
+	messages[m+h-1] = lastMessage
+	copy(messages[m-1:], history)
+	return messages
 }
 
 // toParts converts a source string into an array of parts (text, media, or
 // metadata).
 //
 // Also processes media and section markers.
-func toParts(source string) ([]Part, error) {
+func toParts(source string, trim TrimMode) ([]Part, error) {
 	parts := []Part{}
 
-	for _, piece := range splitByMediaAndSectionMarkers(source) {
+	for _, piece := range splitByRegexTrim(source, MediaAndSectionMarkerRegex, trim) {
 		part, err := parsePart(piece)
 		if err != nil {
 			return nil, err
@@ -583,6 +975,8 @@ func parsePart(piece string) (Part, error) {
 		return parseMediaPart(piece)
 	} else if strings.HasPrefix(piece, SectionMarkerPrefix) {
 		return parseSectionPart(piece)
+	} else if strings.HasPrefix(piece, DataMarkerPrefix+" ") {
+		return parseDataPart(piece)
 	} else {
 		return parseTextPart(piece)
 	}
@@ -598,6 +992,9 @@ func parseMediaPart(piece string) (*MediaPart, error) {
 
 	fields := strings.Split(piece, " ")
 	n := len(fields)
+	if fields[0] == AudioMarkerPrefix || fields[0] == VideoMarkerPrefix {
+		return parseTimedMediaPart(piece, fields)
+	}
 
 	var url, contentType string
 	switch n {
@@ -626,6 +1023,34 @@ func parseMediaPart(piece string) (*MediaPart, error) {
 	return mediaPart, nil
 }
 
+// parseTimedMediaPart parses an audio or video marker: the URL followed by
+// `key=value` fields for the content type and timing.
+func parseTimedMediaPart(piece string, fields []string) (*MediaPart, error) {
+	if len(fields) < 2 || fields[1] == "" {
+		return nil, fmt.Errorf("invalid media piece: %s; missing url", piece)
+	}
+	mediaPart := &MediaPart{Media: Media{URL: fields[1]}}
+	mediaPart.SetMetadata(mediaKindMetadataKey, strings.TrimPrefix(fields[0], MediaMarkerPrefix))
+	for _, field := range fields[2:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid media piece: %s; invalid field %q", piece, field)
+		}
+		switch key {
+		case "contentType":
+			mediaPart.Media.ContentType = value
+		case "duration", "startOffset", "endOffset":
+			if _, err := time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid media piece: %s; invalid %s: %w", piece, key, err)
+			}
+			mediaPart.SetMetadata(key, value)
+		default:
+			return nil, fmt.Errorf("invalid media piece: %s; unknown field %q", piece, key)
+		}
+	}
+	return mediaPart, nil
+}
+
 // parseSectionPart parses a section part from a piece of rendered template.
 func parseSectionPart(piece string) (*PendingPart, error) {
 	if !strings.HasPrefix(piece, SectionMarkerPrefix) {
@@ -648,6 +1073,16 @@ func parseSectionPart(piece string) (*PendingPart, error) {
 	return pendingPart, nil
 }
 
+// parseDataPart parses a data part from a piece of rendered template: the
+// marker followed by the data as a JSON object.
+func parseDataPart(piece string) (*DataPart, error) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(piece, DataMarkerPrefix+" ")), &data); err != nil {
+		return nil, fmt.Errorf("invalid data piece: %s; %w", piece, err)
+	}
+	return &DataPart{Data: data}, nil
+}
+
 // parseTextPart parses a text part from a piece of rendered template.
 func parseTextPart(piece string) (*TextPart, error) {
 	return &TextPart{
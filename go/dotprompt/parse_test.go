@@ -17,8 +17,11 @@
 package dotprompt
 
 import (
+	"fmt"
+	"math/rand"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -399,7 +402,7 @@ func TestConvertNamespacedEntryToNestedObject(t *testing.T) {
 func TestExtractFrontmatterAndBody(t *testing.T) {
 	t.Run("should extract frontmatter and body", func(t *testing.T) {
 		inputStr := "---\nfoo: bar\n---\nThis is the body."
-		frontmatter, body := extractFrontmatterAndBody(inputStr)
+		frontmatter, body, _ := extractFrontmatterAndBody(inputStr)
 		if frontmatter != "foo: bar" {
 			t.Errorf("frontmatter = %q, want %q", frontmatter, "foo: bar")
 		}
@@ -410,7 +413,7 @@ func TestExtractFrontmatterAndBody(t *testing.T) {
 
 	t.Run("should extract frontmatter and body with empty frontmatter", func(t *testing.T) {
 		inputStr := "---\n\n---\nThis is the body."
-		frontmatter, body := extractFrontmatterAndBody(inputStr)
+		frontmatter, body, _ := extractFrontmatterAndBody(inputStr)
 		if frontmatter != "" {
 			t.Errorf("frontmatter = %q, want \"\"", frontmatter)
 		}
@@ -419,21 +422,81 @@ func TestExtractFrontmatterAndBody(t *testing.T) {
 		}
 	})
 
-	t.Run("should return empty strings when there is no frontmatter marker", func(t *testing.T) {
-		// TODO(#495): May be change this behavior to return a matching body when
-		// there is no frontmatter marker and we have a body. This may need to
-		// be done across all the runtimes.
+	t.Run("should return the source as body when there is no frontmatter marker", func(t *testing.T) {
 		inputStr := "Hello World"
-		frontmatter, body := extractFrontmatterAndBody(inputStr)
+		frontmatter, body, found := extractFrontmatterAndBody(inputStr)
 		if frontmatter != "" {
 			t.Errorf("frontmatter = %q, want \"\"", frontmatter)
 		}
-		if body != "" {
-			t.Errorf("body = %q, want \"\"", body)
+		if body != inputStr {
+			t.Errorf("body = %q, want %q", body, inputStr)
+		}
+		if found {
+			t.Error("found = true, want false")
 		}
 	})
 }
 
+// regexFrontmatterBounds is the reference implementation of FrontmatterBounds.
+func regexFrontmatterBounds(source string) (int, int, int, bool) {
+	if m := FrontmatterAndBodyRegex.FindStringSubmatchIndex(source); m != nil {
+		return m[2], m[3], m[4], true
+	}
+	if m := EmptyFrontmatterRegex.FindStringSubmatchIndex(source); m != nil {
+		return -1, -1, m[2], true
+	}
+	return 0, 0, 0, false
+}
+
+func TestFrontmatterBoundsMatchesRegex(t *testing.T) {
+	check := func(source string) {
+		t.Helper()
+		wantStart, wantEnd, wantBody, wantOK := regexFrontmatterBounds(source)
+		start, end, body, ok := FrontmatterBounds(source)
+		if wantStart == -1 {
+			// EmptyFrontmatterRegex has no frontmatter group.
+			wantStart, wantEnd = start, start
+		}
+		if start != wantStart || end != wantEnd || body != wantBody || ok != wantOK {
+			t.Errorf("FrontmatterBounds(%q) = (%d, %d, %d, %v), want (%d, %d, %d, %v)",
+				source, start, end, body, ok, wantStart, wantEnd, wantBody, wantOK)
+		}
+	}
+
+	for _, source := range []string{
+		"",
+		"---",
+		"---\n---\n",
+		"---\n---\nbody",
+		"---\n\n---\nbody",
+		"---\nfoo: bar\n---\nbody\n---\nmore",
+		"---\r\nfoo: bar\r\n---\r\nbody",
+		"---\rfoo: bar\r---\rbody",
+		"# license\n\n  \t\n---\nfoo: 1\n---  \n\n  body",
+		"  # not a comment\n---\nfoo: 1\n---\nbody",
+		"---\nfoo: 1\n----\nbody",
+		"---\nfoo: 1\n---body",
+		"---  \n  foo: 1\n---\n",
+		"#only a comment",
+	} {
+		check(source)
+	}
+
+	// Random documents built from the tokens the patterns are sensitive to.
+	tokens := []string{"---", "-", "\n", "\r", "\r\n", " ", "\t", "\f", "#", "a", ":"}
+	rng := rand.New(rand.NewSource(1))
+	for range 20000 {
+		var b strings.Builder
+		if rng.Intn(2) == 0 {
+			b.WriteString("---\n")
+		}
+		for range rng.Intn(12) {
+			b.WriteString(tokens[rng.Intn(len(tokens))])
+		}
+		check(b.String())
+	}
+}
+
 func TestTransformMessagesToHistory(t *testing.T) {
 	t.Run("add history metadata to messages", func(t *testing.T) {
 		messages := []Message{
@@ -1716,3 +1779,332 @@ Template content`
 		}
 	})
 }
+
+func TestToMessagesConcurrent(t *testing.T) {
+	history := []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: "Earlier"}}}}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 200 {
+				text := fmt.Sprintf("question %d-%d", i, j)
+				rendered := "<<<dotprompt:role:system>>>Be brief.<<<dotprompt:role:user>>>" + text
+				got, err := ToMessages(rendered, &DataArgument{Messages: history})
+				if err != nil {
+					t.Errorf("ToMessages() returned error: %v", err)
+					return
+				}
+				want := []Message{
+					{Role: RoleSystem, Content: []Part{&TextPart{Text: "Be brief."}}},
+					history[0],
+					{Role: RoleUser, Content: []Part{&TextPart{Text: text}}},
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("ToMessages() mismatch (-want +got):\n%s", diff)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestInsertHistoryReusesCapacity(t *testing.T) {
+	messages := make([]Message, 0, 4)
+	messages = append(messages,
+		Message{Role: RoleSystem, Content: []Part{&TextPart{Text: "sys"}}},
+		Message{Role: RoleUser, Content: []Part{&TextPart{Text: "now"}}},
+	)
+	history := []Message{
+		{Role: RoleUser, Content: []Part{&TextPart{Text: "q"}}},
+		{Role: RoleModel, Content: []Part{&TextPart{Text: "a"}}},
+	}
+
+	got, err := insertHistory(messages, history)
+	if err != nil {
+		t.Fatalf("insertHistory() returned error: %v", err)
+	}
+	want := []Message{messages[0], history[0], history[1], {Role: RoleUser, Content: []Part{&TextPart{Text: "now"}}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("insertHistory() mismatch (-want +got):\n%s", diff)
+	}
+	if &got[0] != &messages[0] {
+		t.Errorf("insertHistory() allocated a new slice despite spare capacity")
+	}
+}
+
+const benchmarkRendered = "<<<dotprompt:role:system>>>You are a helpful assistant. Answer briefly.\n" +
+	"<<<dotprompt:role:user>>>Here is some context:\n<<<dotprompt:media:url https://example.com/a.png image/png>>>\n" +
+	"<<<dotprompt:history>>>" +
+	"<<<dotprompt:role:user>>>What is in the picture? Please describe it in detail.\n"
+
+func BenchmarkToMessages(b *testing.B) {
+	data := &DataArgument{Messages: []Message{
+		{Role: RoleUser, Content: []Part{&TextPart{Text: "Hi"}}},
+		{Role: RoleModel, Content: []Part{&TextPart{Text: "Hello! How can I help?"}}},
+	}}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := ToMessages(benchmarkRendered, data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkInsertHistory(b *testing.B) {
+	history := []Message{
+		{Role: RoleUser, Content: []Part{&TextPart{Text: "Hi"}}},
+		{Role: RoleModel, Content: []Part{&TextPart{Text: "Hello! How can I help?"}}},
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		messages := make([]Message, 2, 2+len(history))
+		messages[0] = Message{Role: RoleSystem}
+		messages[1] = Message{Role: RoleUser}
+		if _, err := insertHistory(messages, history); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestInsertHistoryInPlace(t *testing.T) {
+	text := func(role Role, s string) Message {
+		return Message{Role: role, Content: []Part{&TextPart{Text: s}}}
+	}
+	history := []Message{text(RoleUser, "q"), text(RoleModel, "a")}
+
+	tests := []struct {
+		name     string
+		messages []Message
+		capacity int
+		want     []Message
+	}{
+		{
+			name:     "before last user message",
+			messages: []Message{text(RoleSystem, "sys"), text(RoleUser, "now")},
+			capacity: 4,
+			want:     []Message{text(RoleSystem, "sys"), history[0], history[1], text(RoleUser, "now")},
+		},
+		{
+			name:     "grows buffer",
+			messages: []Message{text(RoleUser, "now")},
+			capacity: 1,
+			want:     []Message{history[0], history[1], text(RoleUser, "now")},
+		},
+		{
+			name:     "after last model message",
+			messages: []Message{text(RoleUser, "hi"), text(RoleModel, "hello")},
+			capacity: 2,
+			want:     []Message{text(RoleUser, "hi"), text(RoleModel, "hello"), history[0], history[1]},
+		},
+		{
+			name:     "empty buffer",
+			capacity: 2,
+			want:     history,
+		},
+		{
+			name: "existing history",
+			messages: []Message{
+				{Role: RoleUser, HasMetadata: HasMetadata{Metadata: Metadata{"purpose": "history"}}},
+				text(RoleUser, "now"),
+			},
+			capacity: 2,
+			want: []Message{
+				{Role: RoleUser, HasMetadata: HasMetadata{Metadata: Metadata{"purpose": "history"}}},
+				text(RoleUser, "now"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := append(make([]Message, 0, tt.capacity), tt.messages...)
+			got := InsertHistoryInPlace(buf, history)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("InsertHistoryInPlace() mismatch (-want +got):\n%s", diff)
+			}
+			if cap(buf) >= len(got) && len(got) > 0 && &got[0] != &buf[:1][0] {
+				t.Errorf("InsertHistoryInPlace() did not reuse a buffer with enough capacity")
+			}
+		})
+	}
+}
+
+// longHistory returns a conversation of n alternating user and model
+// messages.
+func longHistory(n int) []Message {
+	history := make([]Message, n)
+	for i := range history {
+		role := RoleUser
+		if i%2 == 1 {
+			role = RoleModel
+		}
+		history[i] = Message{Role: role, Content: []Part{&TextPart{Text: "turn"}}, HasMetadata: HasMetadata{Metadata: Metadata{"turn": i}}}
+	}
+	return history
+}
+
+func BenchmarkInsertHistoryLong(b *testing.B) {
+	history := longHistory(10000)
+	b.Run("insertHistory", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			messages := []Message{{Role: RoleSystem}, {Role: RoleUser}}
+			if _, err := insertHistory(messages, history); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("InsertHistoryInPlace", func(b *testing.B) {
+		buf := make([]Message, 0, len(history)+2)
+		b.ReportAllocs()
+		for b.Loop() {
+			buf = append(buf[:0], Message{Role: RoleSystem}, Message{Role: RoleUser})
+			buf = InsertHistoryInPlace(buf, history)
+		}
+	})
+}
+
+func BenchmarkTransformMessagesToHistory(b *testing.B) {
+	history := longHistory(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := transformMessagesToHistory(history); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkToMessagesLongHistory(b *testing.B) {
+	data := &DataArgument{Messages: longHistory(10000)}
+	for _, rendered := range []string{
+		"<<<dotprompt:role:system>>>Be brief.<<<dotprompt:history>>><<<dotprompt:role:user>>>Next?",
+		"<<<dotprompt:role:system>>>Be brief.<<<dotprompt:role:user>>>Next?",
+	} {
+		name := "implicit"
+		if strings.Contains(rendered, HistoryMarkerPrefix) {
+			name = "marker"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := ToMessages(rendered, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDocumentGenerationControls(t *testing.T) {
+	source := "---\nmaxTurns: 5\ntoolChoice: none\nreturnToolRequests: true\n" +
+		"stopSequences:\n  - END\n  - \"\\n\\n\"\n---\nHello"
+	result, err := ParseDocument(source)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	want := PromptMetadata{
+		MaxTurns:           5,
+		ToolChoice:         ToolChoiceNone,
+		ReturnToolRequests: true,
+		StopSequences:      []string{"END", "\n\n"},
+	}
+	got := PromptMetadata{
+		MaxTurns:           result.MaxTurns,
+		ToolChoice:         result.ToolChoice,
+		ReturnToolRequests: result.ReturnToolRequests,
+		StopSequences:      result.StopSequences,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseDocument() mismatch (-want +got):\n%s", diff)
+	}
+
+	dp := NewDotprompt(nil)
+	rendered, err := dp.Render(source, &DataArgument{}, &PromptMetadata{StopSequences: []string{"STOP"}})
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if rendered.MaxTurns != 5 || rendered.ToolChoice != ToolChoiceNone || !rendered.ReturnToolRequests {
+		t.Errorf("Render() metadata = %+v, want generation controls from frontmatter", rendered.PromptMetadata)
+	}
+	if diff := cmp.Diff([]string{"STOP"}, rendered.StopSequences); diff != "" {
+		t.Errorf("Render() StopSequences mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      ParseOptions
+		source       string
+		wantTemplate string
+		wantErr      string
+	}{
+		{
+			name:         "default body-only",
+			source:       "  Hello World\n",
+			wantTemplate: "  Hello World\n",
+		},
+		{
+			name:         "uniform body",
+			options:      ParseOptions{UniformBody: true},
+			source:       "  Hello World\n",
+			wantTemplate: "Hello World\n",
+		},
+		{
+			name:         "uniform body trim none",
+			options:      ParseOptions{UniformBody: true, Trim: TrimNone},
+			source:       "  Hello World\n",
+			wantTemplate: "  Hello World\n",
+		},
+		{
+			name:    "require frontmatter",
+			options: ParseOptions{RequireFrontmatter: true},
+			source:  "Hello World",
+			wantErr: "no frontmatter",
+		},
+		{
+			name:         "require frontmatter present",
+			options:      ParseOptions{RequireFrontmatter: true},
+			source:       "---\nmodel: a/b\n---\nHello World",
+			wantTemplate: "Hello World",
+		},
+		{
+			name:    "strict",
+			options: ParseOptions{Strict: true},
+			source:  "---\nmodl: a/b\n---\nHello World",
+			wantErr: `unknown frontmatter key "modl"`,
+		},
+		{
+			name:    "strict yaml",
+			options: ParseOptions{StrictYAML: true},
+			source:  "---\nmodel: [\n---\nHello World",
+			wantErr: "invalid YAML frontmatter",
+		},
+		{
+			name:         "strict yaml unknown key",
+			options:      ParseOptions{StrictYAML: true},
+			source:       "---\nmodl: a/b\n---\nHello World",
+			wantTemplate: "Hello World",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.options.Parse(tt.source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() returned error: %v", err)
+			}
+			if parsed.Template != tt.wantTemplate {
+				t.Errorf("Parse() template = %q, want %q", parsed.Template, tt.wantTemplate)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// parseCacheFormat is bumped whenever ParsedPrompt or the parser's output
+// changes, so entries written by older releases are ignored.
+const parseCacheFormat = 2
+
+func init() {
+	// Concrete types that YAML frontmatter decodes into.
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+	gob.Register(uint64(0))
+	gob.Register(int64(0))
+}
+
+// ParseCache persists parsed prompts in a directory, keyed by a hash of their
+// source, so that a cold start does not have to parse and validate the
+// frontmatter of every prompt again.
+//
+// Only the output of the DocumentParser is persisted: frontmatter, metadata
+// and the template source. Compiled templates are not, because the
+// TemplateEngine can only build a template from its source, so templates are
+// still parsed when their prompt is first compiled. Keep compiled prompts in
+// memory with WithCache.
+//
+// A ParseCache is safe for concurrent use, including by several processes
+// sharing the directory.
+type ParseCache struct {
+	// Dir is the directory holding the cache entries.
+	Dir string
+	// Version is stored with each entry; entries with a different version are
+	// reparsed and overwritten. Change it when the output of the wrapped
+	// parser changes, e.g. when metadata extensions are registered or
+	// changed.
+	Version string
+}
+
+// parseCacheEntry is the gob-encoded content of a cache file.
+type parseCacheEntry struct {
+	Format  int
+	Version string
+	Parsed  ParsedPrompt
+}
+
+// NewParseCache returns a ParseCache that stores its entries in dir,
+// creating it if needed.
+func NewParseCache(dir string, version string) (*ParseCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ParseCache{Dir: dir, Version: version}, nil
+}
+
+// Wrap returns a DocumentParser that serves parsed prompts from the cache and
+// falls back to parser, or ParseDocument if parser is nil, on a miss. It is
+// meant to be used as DotpromptOptions.Parser. Failures to read or write the
+// cache are treated as misses; prompts whose metadata cannot be gob-encoded
+// are not cached.
+func (c *ParseCache) Wrap(parser DocumentParser) DocumentParser {
+	if parser == nil {
+		parser = ParseDocument
+	}
+	return func(source string) (ParsedPrompt, error) {
+		path := c.entryPath(source)
+		if parsed, ok := c.read(path); ok {
+			return parsed, nil
+		}
+		parsed, err := parser(source)
+		if err != nil {
+			return ParsedPrompt{}, err
+		}
+		c.write(path, parsed)
+		return parsed, nil
+	}
+}
+
+// entryPath returns the file that caches source.
+func (c *ParseCache) entryPath(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".gob")
+}
+
+// read returns the cached prompt at path if it exists and was written with
+// the current format and version.
+func (c *ParseCache) read(path string) (ParsedPrompt, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ParsedPrompt{}, false
+	}
+	defer f.Close()
+	var entry parseCacheEntry
+	if err := gob.NewDecoder(f).Decode(&entry); err != nil {
+		return ParsedPrompt{}, false
+	}
+	if entry.Format != parseCacheFormat || entry.Version != c.Version {
+		return ParsedPrompt{}, false
+	}
+	// gob does not transmit empty maps, but the parser always sets Ext.
+	if entry.Parsed.Ext == nil {
+		entry.Parsed.Ext = make(map[string]map[string]any)
+	}
+	return entry.Parsed, true
+}
+
+// write stores parsed at path. The entry is written to a temporary file and
+// renamed so that readers never see a partial entry.
+func (c *ParseCache) write(path string, parsed ParsedPrompt) {
+	var buf bytes.Buffer
+	entry := parseCacheEntry{Format: parseCacheFormat, Version: c.Version, Parsed: parsed}
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.Dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
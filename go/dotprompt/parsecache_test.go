@@ -0,0 +1,112 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const parseCacheSource = `---
+model: test/model
+config:
+  temperature: 0.5
+  maxOutputTokens: 100
+input:
+  default:
+    name: World
+    tags: [a, null]
+  schema:
+    name: string
+myext.flag: true
+---
+Hello {{name}}!`
+
+func TestParseCache(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	counting := func(source string) (ParsedPrompt, error) {
+		calls++
+		return ParseDocument(source)
+	}
+
+	cache, err := NewParseCache(dir, "v1")
+	if err != nil {
+		t.Fatalf("NewParseCache() returned error: %v", err)
+	}
+	parse := cache.Wrap(counting)
+	first, err := parse(parseCacheSource)
+	if err != nil {
+		t.Fatalf("parse() returned error: %v", err)
+	}
+
+	// A new cache over the same directory simulates a cold start.
+	cold, _ := NewParseCache(dir, "v1")
+	second, err := cold.Wrap(counting)(parseCacheSource)
+	if err != nil {
+		t.Fatalf("parse() returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("parser called %d times, want 1", calls)
+	}
+	if diff := cmp.Diff(first, second); diff != "" {
+		t.Errorf("cached prompt mismatch (-want +got):\n%s", diff)
+	}
+
+	rendered, err := NewDotprompt(&DotpromptOptions{Parser: cold.Wrap(nil)}).Render(parseCacheSource, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != "Hello World!" {
+		t.Errorf("Render() text = %q, want %q", got, "Hello World!")
+	}
+
+	upgraded, _ := NewParseCache(dir, "v2")
+	if _, err := upgraded.Wrap(counting)(parseCacheSource); err != nil {
+		t.Fatalf("parse() returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("parser called %d times after version change, want 2", calls)
+	}
+}
+
+func TestParseCacheCorruptEntry(t *testing.T) {
+	cache, err := NewParseCache(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("NewParseCache() returned error: %v", err)
+	}
+	if err := os.WriteFile(cache.entryPath("Hi"), []byte("not gob"), 0644); err != nil {
+		t.Fatalf("os.WriteFile() returned error: %v", err)
+	}
+	parsed, err := cache.Wrap(nil)("Hi")
+	if err != nil {
+		t.Fatalf("parse() returned error: %v", err)
+	}
+	if parsed.Template != "Hi" {
+		t.Errorf("Template = %q, want %q", parsed.Template, "Hi")
+	}
+	if _, ok := cache.read(cache.entryPath("Hi")); !ok {
+		t.Error("corrupt entry was not replaced")
+	}
+	entries, _ := filepath.Glob(filepath.Join(cache.Dir, ".entry-*"))
+	if len(entries) != 0 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mbleigh/raymond/ast"
+	"github.com/mbleigh/raymond/parser"
+)
+
+// pinnedPartialPattern matches the `{{> name@variant}}` shorthand.
+var pinnedPartialPattern = regexp.MustCompile(`(\{\{~?>\s*)([\w/.-]+)@([\w.-]+)`)
+
+// partialBlockPattern matches the opening of a partial block, `{{#> name}}`.
+var partialBlockPattern = regexp.MustCompile(`\{\{~?#\*?>`)
+
+// quotePinnedPartials rewrites `{{> name@variant}}` as `{{> [name@variant]}}`.
+// Without the brackets Handlebars reads `@variant` as a data variable passed as
+// the partial's context. Partials pinned with hash arguments, such as
+// `{{> header variant="v2"}}`, are rewritten to reference the qualified name
+// of their pin instead, as in `{{> [header@v2]}}`, so that tags pinning the
+// same partial differently are registered as different partials. Templates
+// that do not parse are returned with only the shorthand rewritten, for the
+// engine to report the error.
+func quotePinnedPartials(template string) string {
+	template = pinnedPartialPattern.ReplaceAllString(template, "$1[$2@$3]")
+	program, err := parser.Parse(template)
+	if err != nil {
+		return template
+	}
+	w := &variableWalker{}
+	w.program(program, 0, nil)
+
+	// edit replaces template[start:end] with text.
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, stmt := range w.partialStatements {
+		pins := pinPairs(stmt)
+		if len(pins) == 0 {
+			continue
+		}
+		name, _ := partialName(stmt.Name)
+		start, end, ok := tokenSpan(template, stmt.Name.Location().Pos)
+		if !ok {
+			continue
+		}
+		edits = append(edits, edit{start, end, "[" + qualifiedPartialName(pinnedRef(name, stmt)) + "]"})
+		for _, pair := range pins {
+			_, valueEnd, ok := tokenSpan(template, pair.Val.Location().Pos)
+			if !ok {
+				continue
+			}
+			pairStart := pair.Location().Pos
+			for pairStart > 0 && strings.ContainsRune(" \t\r\n", rune(template[pairStart-1])) {
+				pairStart--
+			}
+			edits = append(edits, edit{pairStart, valueEnd, ""})
+		}
+	}
+	if len(edits) == 0 {
+		return template
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.WriteString(template[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(template[last:])
+	return b.String()
+}
+
+// pinPairs returns the `variant` and `version` hash arguments of stmt that
+// pin the partial it references.
+func pinPairs(stmt *ast.PartialStatement) []*ast.HashPair {
+	if stmt.Hash == nil {
+		return nil
+	}
+	var pins []*ast.HashPair
+	for _, pair := range stmt.Hash.Pairs {
+		if _, ok := pair.Val.(*ast.StringLiteral); ok && (pair.Key == "variant" || pair.Key == "version") {
+			pins = append(pins, pair)
+		}
+	}
+	return pins
+}
+
+// tokenSpan returns the span of the name or string literal token whose
+// content starts at pos: a bracketed name, a quoted string or a bare name.
+func tokenSpan(template string, pos int) (start, end int, ok bool) {
+	if pos <= 0 || pos > len(template) {
+		return 0, 0, false
+	}
+	if quote := template[pos-1]; quote == '"' || quote == '\'' {
+		for i := pos; i < len(template); i++ {
+			switch template[i] {
+			case '\\':
+				i++
+			case quote:
+				return pos - 1, i + 1, true
+			}
+		}
+		return 0, 0, false
+	}
+	if template[pos] == '[' {
+		if i := strings.IndexByte(template[pos:], ']'); i >= 0 {
+			return pos, pos + i + 1, true
+		}
+		return 0, 0, false
+	}
+	end = pos
+	for end < len(template) && !strings.ContainsRune(" \t\r\n}~()", rune(template[end])) {
+		end++
+	}
+	return pos, end, end > pos
+}
+
+// partialReference is a partial tag in a template. Name is the name the
+// partial is registered under, and Ref is what to load from a store.
+type partialReference struct {
+	Name string
+	Ref  PartialRef
+}
+
+// partialReferences returns the static partial references in template. A
+// reference is pinned either with the `name@variant` shorthand or with
+// `variant` and `version` hash arguments, e.g.
+// `{{> header variant="v2" version="3f2a..."}}`, and is named by the
+// qualified name of its pin, as quotePinnedPartials rewrites its tag.
+func partialReferences(template string) ([]partialReference, error) {
+	program, err := parser.Parse(template)
+	if err != nil {
+		return nil, err
+	}
+	w := &variableWalker{}
+	w.program(program, 0, nil)
+
+	refs := make([]partialReference, 0, len(w.partialStatements))
+	for _, stmt := range w.partialStatements {
+		name, _ := partialName(stmt.Name)
+		ref := pinnedRef(name, stmt)
+		refs = append(refs, partialReference{Name: qualifiedPartialName(ref), Ref: ref})
+	}
+	return refs, nil
+}
+
+// pinnedRef returns the reference to load for the partial name referenced by
+// stmt, pinned by the name or the hash arguments of stmt.
+func pinnedRef(name string, stmt *ast.PartialStatement) PartialRef {
+	ref := newPartialRef(name)
+	for _, pair := range pinPairs(stmt) {
+		value := pair.Val.(*ast.StringLiteral).Value
+		switch pair.Key {
+		case "variant":
+			ref.Variant = value
+		case "version":
+			ref.Version = value
+		}
+	}
+	return ref
+}
+
+// newPartialRef returns the reference to load for the partial name, which may
+// use the `name@variant` shorthand or be a qualified name.
+func newPartialRef(name string) PartialRef {
+	name, version, _ := strings.Cut(name, "#")
+	ref := PartialRef{Name: name, Version: version}
+	if base, variant, ok := strings.Cut(name, "@"); ok {
+		ref.Name, ref.Variant = base, variant
+	}
+	return ref
+}
+
+// qualifiedPartialName returns the name a partial pinned to ref is registered
+// under: `name`, `name@variant`, `name#version` or `name@variant#version`.
+func qualifiedPartialName(ref PartialRef) string {
+	name := ref.Name
+	if ref.Variant != "" {
+		name += "@" + ref.Variant
+	}
+	if ref.Version != "" {
+		name += "#" + ref.Version
+	}
+	return name
+}
+
+// loadPinnedPartial loads ref from the partial store and checks that the
+// loaded version matches the pinned one.
+func (dp *Dotprompt) loadPinnedPartial(ref PartialRef) (string, error) {
+	partial, err := dp.partialStore.LoadPartial(ref.Name, LoadPartialOptions{
+		Variant: ref.Variant,
+		Version: ref.Version,
+	})
+	if err != nil {
+		return "", err
+	}
+	if ref.Variant != "" && partial.Variant != ref.Variant {
+		return "", fmt.Errorf("partial %s: variant %q not found", ref.Name, ref.Variant)
+	}
+	if ref.Version != "" && partial.Version != ref.Version && !versionMatches(partial.Source, ref.Version) {
+		return "", fmt.Errorf("partial %s: version %q not found, store has %q", ref.Name, ref.Version, partial.Version)
+	}
+	return partial.Source, nil
+}
+
+// dynamicPartialHelperName is the helper that dynamic partial names are
+// passed through; see rewriteDynamicPartials.
+const dynamicPartialHelperName = "__dotpromptDynamicPartial"
+
+// rewriteDynamicPartials rewrites the dynamic partials of template, such as
+// `{{> (lookup . "layout")}}`, to pass the name they compute through the
+// dynamic partial helper, which fails with an explicit error if no partial of
+// that name is registered. Templates that do not parse are returned
+// unchanged, for the engine to report the error.
+func rewriteDynamicPartials(template string) string {
+	program, err := parser.Parse(template)
+	if err != nil {
+		return template
+	}
+	w := &variableWalker{}
+	w.program(program, 0, nil)
+	if len(w.dynamicPartials) == 0 {
+		return template
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, name := range w.dynamicPartials {
+		end, ok := subexpressionEnd(template, name.Pos)
+		if !ok {
+			continue
+		}
+		b.WriteString(template[last:name.Pos])
+		fmt.Fprintf(&b, "(%s %s)", dynamicPartialHelperName, template[name.Pos:end])
+		last = end
+	}
+	b.WriteString(template[last:])
+	return b.String()
+}
+
+// subexpressionEnd returns the offset just past the `)` that closes the
+// subexpression opened at pos, skipping nested subexpressions and string
+// literals.
+func subexpressionEnd(template string, pos int) (int, bool) {
+	if !strings.HasPrefix(template[pos:], "(") {
+		return 0, false
+	}
+	depth := 0
+	var quote byte
+	for i := pos; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// dynamicPartialResolver resolves the partials a compiled template names at
+// render time. Partials that are not registered are loaded lazily from the
+// partial store or resolver, along with the partials they reference, and
+// registered on the template, so each is loaded at most once per compiled
+// prompt.
+type dynamicPartialResolver struct {
+	mu sync.Mutex
+	// dp holds the partials registered on tpl and the loaders to resolve
+	// further ones with.
+	dp  *Dotprompt
+	tpl TemplateRegistrar
+	// rejected records the partials that were loaded but exceed the
+	// partial depth limit.
+	rejected map[string]error
+}
+
+// newDynamicPartialResolver returns the dynamic partial resolver of tpl, a
+// template compiled by dp.
+func (dp *Dotprompt) newDynamicPartialResolver(tpl TemplateRegistrar) *dynamicPartialResolver {
+	return &dynamicPartialResolver{
+		dp: &Dotprompt{
+			partialResolver: dp.partialResolver,
+			partialStore:    dp.partialStore,
+			limits:          dp.limits,
+			trace:           dp.trace,
+			knownPartials:   maps.Clone(dp.knownPartials),
+			partialSources:  maps.Clone(dp.partialSources),
+		},
+		tpl:      tpl,
+		rejected: make(map[string]error),
+	}
+}
+
+// helper is the dynamic partial helper. It returns name once a partial of
+// that name is registered.
+func (r *dynamicPartialResolver) helper(name any) string {
+	s, ok := name.(string)
+	if !ok || s == "" {
+		panic(fmt.Errorf("dynamic partial name must be a non-empty string, got %v", name))
+	}
+	if err := r.resolve(s); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// resolve registers the partial name on the template, loading it if needed.
+// A lazily loaded partial counts as depth 1 for RenderLimits.MaxPartialDepth,
+// since the depth it is rendered at is only known during execution.
+func (r *dynamicPartialResolver) resolve(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.rejected[name]; err != nil {
+		return err
+	}
+	if r.dp.knownPartials[name] {
+		return nil
+	}
+	if r.dp.partialResolver == nil && r.dp.partialStore == nil {
+		return fmt.Errorf("dynamic partial %q is not registered; partials named at render time must be registered with Partials or DefinePartial", name)
+	}
+
+	ref := partialReference{Name: name, Ref: newPartialRef(name)}
+	if err := r.dp.resolvePartialRef(ref, r.tpl, make(map[string]bool)); err != nil {
+		return fmt.Errorf("dynamic partial %q: %w", name, err)
+	}
+	if !r.dp.knownPartials[name] {
+		return fmt.Errorf("dynamic partial %q not found", name)
+	}
+	if err := r.dp.checkPartialDepthFrom(r.dp.partialSources[name], []string{name}); err != nil {
+		r.rejected[name] = err
+		return err
+	}
+	return nil
+}
+
+// partialBlockError returns an explicit error in place of err, the error of
+// parsing template, if template uses partial blocks, which the template
+// engine does not support.
+func partialBlockError(template string, err error) error {
+	if partialBlockPattern.MatchString(template) {
+		return fmt.Errorf("partial blocks such as {{#> name}} are not supported: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,283 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPartialReferences(t *testing.T) {
+	refs, err := partialReferences(`{{> plain}}{{> header@v2}}{{> [footer@b]}}{{> card variant="x" version="abc"}}`)
+	if err != nil {
+		t.Fatalf("partialReferences() returned error: %v", err)
+	}
+	want := []partialReference{
+		{Name: "plain", Ref: PartialRef{Name: "plain"}},
+		{Name: "header", Ref: PartialRef{Name: "header"}},
+		{Name: "footer@b", Ref: PartialRef{Name: "footer", Variant: "b"}},
+		{Name: "card@x#abc", Ref: PartialRef{Name: "card", Variant: "x", Version: "abc"}},
+	}
+	if diff := cmp.Diff(want, refs); diff != "" {
+		t.Errorf("partialReferences() mismatch (-want +got):\n%s", diff)
+	}
+
+	quoteTests := []struct {
+		template string
+		want     string
+	}{
+		{"{{> header@v2}} {{~> dir/card@b.1 x}}", "{{> [header@v2]}} {{~> [dir/card@b.1] x}}"},
+		{`{{> header variant="v2"}}`, "{{> [header@v2]}}"},
+		{`{{~> "card" x title="t"  version='abc' ~}}`, `{{~> [card#abc] x title="t" ~}}`},
+		{`{{> header@v2 version="abc"}}`, "{{> [header@v2#abc]}}"},
+		{`{{> header variant=name}}`, `{{> header variant=name}}`},
+	}
+	for _, tt := range quoteTests {
+		if got := quotePinnedPartials(tt.template); got != tt.want {
+			t.Errorf("quotePinnedPartials(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestPinnedPartials(t *testing.T) {
+	store := newTestDirStore(t, map[string]string{
+		"_header.prompt":       "latest",
+		"_header.v2.prompt":    "pinned {{> footer@short}}",
+		"_footer.prompt":       "long footer",
+		"_footer.short.prompt": "short footer",
+	})
+	v2, err := store.LoadPartial("header", LoadPartialOptions{Variant: "v2"})
+	if err != nil {
+		t.Fatalf("LoadPartial() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr string
+	}{
+		{name: "latest", source: "{{> header}}", want: "latest"},
+		{name: "shorthand", source: "{{> header@v2}}", want: "pinned short footer"},
+		{name: "both", source: "{{> header}} / {{> header@v2}}", want: "latest / pinned short footer"},
+		{name: "hash", source: `{{> header variant="v2"}}`, want: "pinned short footer"},
+		{name: "unpinned then hash", source: `{{> header}} / {{> header variant="v2"}}`, want: "latest / pinned short footer"},
+		{name: "hash then unpinned", source: `{{> header variant="v2"}} / {{> header}}`, want: "pinned short footer / latest"},
+		{name: "version", source: `{{> header variant="v2" version="` + v2.Version + `"}}`, want: "pinned short footer"},
+		{name: "legacy version", source: `{{> header variant="v2" version="` + legacyVersion(v2.Source) + `"}}`, want: "pinned short footer"},
+		{name: "wrong version", source: `{{> header version="0000"}}`, wantErr: `version "0000" not found`},
+		{name: "missing variant", source: "{{> header@v9}}", wantErr: `variant "v9" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(&DotpromptOptions{PartialStore: store})
+			rendered, err := dp.Render(tt.source, &DataArgument{}, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != tt.want {
+				t.Errorf("Render() text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinnedPartialWithResolver(t *testing.T) {
+	var requested []string
+	dp := NewDotprompt(&DotpromptOptions{
+		PartialResolver: func(name string) (string, error) {
+			requested = append(requested, name)
+			return "[" + name + "]", nil
+		},
+	})
+	rendered, err := dp.Render("{{> header@v2}}", &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != "[header@v2]" {
+		t.Errorf("Render() text = %q, want %q", got, "[header@v2]")
+	}
+	if diff := cmp.Diff([]string{"header@v2"}, requested); diff != "" {
+		t.Errorf("resolver calls mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDynamicPartials(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{
+		Partials: map[string]string{"card": "Card for {{name}}", "list": "{{#each items}}{{> (lookup ../kinds this)}};{{/each}}"},
+	})
+	tests := []struct {
+		name    string
+		source  string
+		input   map[string]any
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "lookup",
+			source: `{{> (lookup . "layout")}}`,
+			input:  map[string]any{"layout": "card", "name": "Ada"},
+			want:   "Card for Ada",
+		},
+		{
+			name:   "in registered partial",
+			source: "{{> list}}",
+			input:  map[string]any{"items": []any{"a", "b"}, "kinds": map[string]any{"a": "card", "b": "card"}},
+			want:   "Card for ;Card for ;",
+		},
+		{
+			name:    "unregistered",
+			source:  `{{> (lookup . "layout")}}`,
+			input:   map[string]any{"layout": "nope"},
+			wantErr: `dynamic partial "nope" is not registered`,
+		},
+		{
+			name:    "partial block",
+			source:  "{{#> card}}fallback{{/card}}",
+			wantErr: "partial blocks such as {{#> name}} are not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := dp.Render(tt.source, &DataArgument{Input: tt.input}, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != tt.want {
+				t.Errorf("Render() text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteDynamicPartials(t *testing.T) {
+	source := `{{> static}} {{~> (concat "a)" (lower x)) ctx}} {{> [x@y]}}`
+	want := `{{> static}} {{~> (__dotpromptDynamicPartial (concat "a)" (lower x))) ctx}} {{> [x@y]}}`
+	if got := rewriteDynamicPartials(source); got != want {
+		t.Errorf("rewriteDynamicPartials() = %q, want %q", got, want)
+	}
+	if got := rewriteDynamicPartials("{{#if}}"); got != "{{#if}}" {
+		t.Errorf("rewriteDynamicPartials() = %q for an invalid template, want it unchanged", got)
+	}
+
+	vars, err := TemplateVariables(`{{> (lookup . which)}}`)
+	if err != nil {
+		t.Fatalf("TemplateVariables() returned error: %v", err)
+	}
+	if len(vars) != 1 || vars[0].Name != "which" {
+		t.Errorf("TemplateVariables() = %v, want the variable naming the partial", vars)
+	}
+}
+
+func TestDynamicPartialsResolvedAtRenderTime(t *testing.T) {
+	sources := map[string]string{
+		"card":    "{{> header}}Card for {{name}}",
+		"header":  "# ",
+		"nested":  "{{> a}}",
+		"a":       "{{> b}}",
+		"b":       "deep",
+		"missing": "",
+	}
+	var loads []string
+	newDotprompt := func(limits RenderLimits) *Dotprompt {
+		loads = nil
+		return NewDotprompt(&DotpromptOptions{
+			PartialResolver: func(name string) (string, error) {
+				loads = append(loads, name)
+				return sources[name], nil
+			},
+			Limits: limits,
+		})
+	}
+	render := func(dp *Dotprompt, prompt PromptFunction, layout string) (string, error) {
+		rendered, err := prompt(&DataArgument{Input: map[string]any{"layout": layout, "name": "Ada"}}, nil)
+		if err != nil {
+			return "", err
+		}
+		return rendered.Messages[0].Content[0].(*TextPart).Text, nil
+	}
+
+	t.Run("loads once", func(t *testing.T) {
+		dp := newDotprompt(RenderLimits{})
+		prompt, err := dp.Compile(`{{> (lookup . "layout")}}`, nil)
+		if err != nil {
+			t.Fatalf("Compile() returned error: %v", err)
+		}
+		if len(loads) != 0 {
+			t.Fatalf("Compile() loaded %v, want no partials loaded before rendering", loads)
+		}
+		for range 2 {
+			got, err := render(dp, prompt, "card")
+			if err != nil {
+				t.Fatalf("render returned error: %v", err)
+			}
+			if want := "# Card for Ada"; got != want {
+				t.Errorf("render = %q, want %q", got, want)
+			}
+		}
+		if diff := cmp.Diff([]string{"card", "header"}, loads); diff != "" {
+			t.Errorf("loaded partials mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		dp := newDotprompt(RenderLimits{})
+		prompt, err := dp.Compile(`{{> (lookup . "layout")}}`, nil)
+		if err != nil {
+			t.Fatalf("Compile() returned error: %v", err)
+		}
+		if _, err := render(dp, prompt, "missing"); err == nil || !strings.Contains(err.Error(), `dynamic partial "missing" not found`) {
+			t.Errorf("render error = %v, want a not found error", err)
+		}
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		dp := newDotprompt(RenderLimits{MaxPartialDepth: 2})
+		prompt, err := dp.Compile(`{{> (lookup . "layout")}}`, nil)
+		if err != nil {
+			t.Fatalf("Compile() returned error: %v", err)
+		}
+		if _, err := render(dp, prompt, "card"); err != nil {
+			t.Errorf("render returned error for a partial within the limit: %v", err)
+		}
+		for range 2 {
+			_, err := render(dp, prompt, "nested")
+			var depthErr *PartialDepthError
+			if !errors.As(err, &depthErr) {
+				t.Fatalf("render error = %v, want a PartialDepthError", err)
+			}
+			if diff := cmp.Diff([]string{"nested", "a", "b"}, depthErr.Chain); diff != "" {
+				t.Errorf("PartialDepthError.Chain mismatch (-want +got):\n%s", diff)
+			}
+		}
+	})
+}
@@ -43,6 +43,10 @@ const WildcardPropertyName = "(*)"
 // PicoschemaOptions defines options for the Picoschema parser.
 type PicoschemaOptions struct {
 	SchemaResolver SchemaResolver
+	// KeyOrder is the order of the keys of the schema in its source, which
+	// the properties of the expanded schema follow. Properties without a
+	// recorded order are sorted by name.
+	KeyOrder *KeyOrder
 }
 
 // Picoschema parses a schema with the given options.
@@ -54,12 +58,14 @@ func Picoschema(schema any, options *PicoschemaOptions) (*jsonschema.Schema, err
 // PicoschemaParser is a parser for Picoschema.
 type PicoschemaParser struct {
 	SchemaResolver SchemaResolver
+	KeyOrder       *KeyOrder
 }
 
 // NewPicoschemaParser creates a new PicoschemaParser with the given options.
 func NewPicoschemaParser(options *PicoschemaOptions) *PicoschemaParser {
 	return &PicoschemaParser{
 		SchemaResolver: options.SchemaResolver,
+		KeyOrder:       options.KeyOrder,
 	}
 }
 
@@ -203,8 +209,13 @@ func (p *PicoschemaParser) parsePico(obj any, path ...string) (*jsonschema.Schem
 	}
 
 	// Handle wildcard properties
+	order := p.KeyOrder
+	for _, key := range path {
+		order = order.Child(key)
+	}
 	objMap := obj.(map[string]any)
-	for key, value := range objMap {
+	for _, key := range order.Sort(objMap) {
+		value := objMap[key]
 		// wildcard property
 		if key == WildcardPropertyName {
 			parsedValue, err := p.parsePico(value, append(path, key)...)
@@ -0,0 +1,204 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pipeline chains prompts, feeding the parsed output of each step into
+// the input of the next.
+//
+// A Pipeline declares its steps instead of hand-coding the calls between
+// them. Each step names a prompt in a store and maps its input variables to
+// selectors, JSONPath expressions evaluated against the state of the run:
+//
+//	{"input": <pipeline input>, "steps": {<step name>: <output>}, "prev": <output of the previous step>}
+//
+// For example:
+//
+//	p, err := pipeline.New(dp, store, model,
+//		pipeline.Step{Prompt: "extract"},
+//		pipeline.Step{Prompt: "summarize", Input: map[string]string{
+//			"people": "$.steps.extract.people",
+//			"tone":   "$.input.tone",
+//		}},
+//	)
+//	result, err := p.Run(ctx, map[string]any{"text": text, "tone": "formal"})
+//
+// Steps are rendered with the Dotprompt, sent to the model adapter and their
+// responses parsed according to the output format of their prompts.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// Step is a prompt of a pipeline.
+type Step struct {
+	// Name identifies the output of the step to the selectors of later
+	// steps. It defaults to Prompt.
+	Name string
+	// Prompt and Variant name the prompt in the store.
+	Prompt  string
+	Variant string
+	// Input maps the input variables of the prompt to selectors. When Input
+	// is nil the step receives the output of the previous step, or the
+	// pipeline input for the first step, which must then be an object.
+	Input map[string]string
+}
+
+// name returns the name under which the output of the step is recorded.
+func (s Step) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Prompt
+}
+
+// StepResult is the outcome of a step of a run.
+type StepResult struct {
+	dp.GenerateResult
+	// Name is the name of the step.
+	Name string
+	// Prompt is the prompt sent to the model.
+	Prompt dp.RenderedPrompt
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	// Output is the parsed output of the last step.
+	Output any
+	// Steps holds the results of the steps in order.
+	Steps []StepResult
+}
+
+// Pipeline is a sequence of prompts. It is safe for concurrent use.
+type Pipeline struct {
+	dp    *dp.Dotprompt
+	store dp.PromptStore
+	model dp.Model
+	steps []Step
+}
+
+// New returns a pipeline of steps whose prompts are loaded from store,
+// rendered with d and generated with model.
+func New(d *dp.Dotprompt, store dp.PromptStore, model dp.Model, steps ...Step) (*Pipeline, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("pipeline: no steps")
+	}
+	names := make(map[string]bool, len(steps))
+	for i, step := range steps {
+		if step.Prompt == "" {
+			return nil, fmt.Errorf("pipeline: step %d has no prompt", i)
+		}
+		if names[step.name()] {
+			return nil, fmt.Errorf("pipeline: duplicate step name %q", step.name())
+		}
+		names[step.name()] = true
+		for variable, selector := range step.Input {
+			if !strings.HasPrefix(selector, "$") {
+				return nil, fmt.Errorf("pipeline: step %q: invalid selector %q for input %q: must start with $",
+					step.name(), selector, variable)
+			}
+		}
+	}
+	return &Pipeline{dp: d, store: store, model: model, steps: append([]Step(nil), steps...)}, nil
+}
+
+// Steps returns the steps of the pipeline.
+func (p *Pipeline) Steps() []Step {
+	return append([]Step(nil), p.steps...)
+}
+
+// Run executes the steps in order with input as the pipeline input. It stops
+// at the first step that fails, returning the results of the steps before
+// it.
+func (p *Pipeline) Run(ctx context.Context, input map[string]any) (Result, error) {
+	if input == nil {
+		input = map[string]any{}
+	}
+	doc, err := dp.ToJSONValue(input)
+	if err != nil {
+		return Result{}, fmt.Errorf("pipeline: invalid input: %w", err)
+	}
+	outputs := make(map[string]any, len(p.steps))
+	state := map[string]any{"input": doc, "steps": outputs, "prev": doc}
+
+	var result Result
+	for _, step := range p.steps {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		stepResult, err := p.runStep(ctx, step, state)
+		if err != nil {
+			return result, fmt.Errorf("pipeline: step %q: %w", step.name(), err)
+		}
+		result.Steps = append(result.Steps, stepResult)
+
+		output, err := dp.ToJSONValue(stepResult.Output)
+		if err != nil {
+			return result, fmt.Errorf("pipeline: step %q: invalid output: %w", step.name(), err)
+		}
+		outputs[step.name()] = output
+		state["prev"] = output
+		result.Output = stepResult.Output
+	}
+	return result, nil
+}
+
+// runStep renders, generates and parses a step.
+func (p *Pipeline) runStep(ctx context.Context, step Step, state map[string]any) (StepResult, error) {
+	input, err := stepInput(step, state)
+	if err != nil {
+		return StepResult{}, err
+	}
+	prompt, err := p.store.Load(step.Prompt, dp.LoadPromptOptions{Variant: step.Variant})
+	if err != nil {
+		return StepResult{}, fmt.Errorf("loading prompt %q: %w", step.Prompt, err)
+	}
+	rendered, err := p.dp.Render(prompt.Source, &dp.DataArgument{Input: input}, nil)
+	if err != nil {
+		return StepResult{}, fmt.Errorf("rendering prompt %q: %w", step.Prompt, err)
+	}
+	generated, err := dp.Generate(ctx, p.model, rendered)
+	if err != nil {
+		return StepResult{}, err
+	}
+	return StepResult{GenerateResult: generated, Name: step.name(), Prompt: rendered}, nil
+}
+
+// stepInput evaluates the selectors of step against the state of the run.
+func stepInput(step Step, state map[string]any) (map[string]any, error) {
+	if step.Input == nil {
+		prev, ok := state["prev"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("previous output is %T, not an object; map it with Input", state["prev"])
+		}
+		return prev, nil
+	}
+	input := make(map[string]any, len(step.Input))
+	for variable, selector := range step.Input {
+		value, found, err := dp.LookupJSONPath(state, selector)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("selector %q for input %q selects nothing", selector, variable)
+		}
+		input[variable] = value
+	}
+	return input, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+// fakeModel answers each prompt with the response registered for the text
+// of its messages.
+func fakeModel(responses map[string]string) dp.Model {
+	return dp.ModelFunc(func(_ context.Context, prompt dp.RenderedPrompt) (string, error) {
+		var texts []string
+		for _, msg := range prompt.Messages {
+			for _, part := range msg.Content {
+				if text, ok := part.(*dp.TextPart); ok {
+					texts = append(texts, text.Text)
+				}
+			}
+		}
+		text := strings.Join(texts, "\n")
+		if resp, ok := responses[text]; ok {
+			return resp, nil
+		}
+		return "", fmt.Errorf("unexpected prompt %q", text)
+	})
+}
+
+var testPrompts = map[string]string{
+	"extract.prompt":   "---\noutput:\n  format: json\n---\nExtract people from: {{text}}",
+	"summarize.prompt": "Summarize {{json people}} in a {{tone}} tone.",
+	"shout.prompt":     "Shout {{name}}",
+}
+
+func TestRun(t *testing.T) {
+	store := storetest.NewDirStore(t, testPrompts)
+	model := fakeModel(map[string]string{
+		"Extract people from: Ada met Alan":          `{"people": ["Ada", "Alan"], "count": 2}`,
+		`Summarize ["Ada","Alan"] in a formal tone.`: "Ada and Alan met.",
+	})
+	p, err := New(dp.NewDotprompt(nil), store, model,
+		Step{Prompt: "extract"},
+		Step{Name: "summary", Prompt: "summarize", Input: map[string]string{
+			"people": "$.steps.extract.people",
+			"tone":   "$.input.tone",
+		}},
+	)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	result, err := p.Run(context.Background(), map[string]any{"text": "Ada met Alan", "tone": "formal"})
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+	if diff := cmp.Diff("Ada and Alan met.", result.Output); diff != "" {
+		t.Errorf("Run() output mismatch (-want +got):\n%s", diff)
+	}
+	var names []string
+	for _, step := range result.Steps {
+		names = append(names, step.Name)
+	}
+	if diff := cmp.Diff([]string{"extract", "summary"}, names); diff != "" {
+		t.Errorf("Run() steps mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	store := storetest.NewDirStore(t, testPrompts)
+	tests := []struct {
+		name    string
+		steps   []Step
+		model   dp.Model
+		wantErr string
+	}{
+		{
+			name:    "selector selects nothing",
+			steps:   []Step{{Prompt: "shout", Input: map[string]string{"name": "$.input.missing"}}},
+			model:   fakeModel(nil),
+			wantErr: `pipeline: step "shout": selector "$.input.missing" for input "name" selects nothing`,
+		},
+		{
+			name: "previous output not an object",
+			steps: []Step{
+				{Prompt: "shout"},
+				{Name: "again", Prompt: "shout"},
+			},
+			model:   fakeModel(map[string]string{"Shout Ada": "ADA"}),
+			wantErr: `pipeline: step "again": previous output is string, not an object`,
+		},
+		{
+			name:    "model error",
+			steps:   []Step{{Prompt: "shout"}},
+			model:   fakeModel(nil),
+			wantErr: `unexpected prompt "Shout Ada"`,
+		},
+		{
+			name:    "missing prompt",
+			steps:   []Step{{Prompt: "missing"}},
+			model:   fakeModel(nil),
+			wantErr: `loading prompt "missing"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(dp.NewDotprompt(nil), store, tt.model, tt.steps...)
+			if err != nil {
+				t.Fatalf("New() returned error: %v", err)
+			}
+			_, err = p.Run(context.Background(), map[string]any{"name": "Ada"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []Step
+		wantErr string
+	}{
+		{name: "no steps", wantErr: "no steps"},
+		{name: "no prompt", steps: []Step{{Name: "a"}}, wantErr: "step 0 has no prompt"},
+		{name: "duplicate name", steps: []Step{{Prompt: "a"}, {Prompt: "a"}}, wantErr: `duplicate step name "a"`},
+		{name: "invalid selector", steps: []Step{{Prompt: "a", Input: map[string]string{"x": "input.x"}}}, wantErr: "must start with $"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(dp.NewDotprompt(nil), nil, nil, tt.steps...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
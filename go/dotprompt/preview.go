@@ -0,0 +1,286 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mbleigh/raymond"
+	"github.com/mbleigh/raymond/ast"
+	"github.com/mbleigh/raymond/parser"
+)
+
+// PreviewOptions configures RenderPreview.
+type PreviewOptions struct {
+	// Open and Close are written around every substituted value.
+	Open  string
+	Close string
+}
+
+// DefaultPreviewOptions are the markers RenderPreview uses when it is given
+// no options.
+var DefaultPreviewOptions = PreviewOptions{Open: "⟦", Close: "⟧"}
+
+// PreviewSpan locates a substituted value in a rendered prompt.
+type PreviewSpan struct {
+	// Message and Part are the indexes of the text part holding the value.
+	Message int `json:"message"`
+	Part    int `json:"part"`
+	// Start and End are the byte offsets of the value in the text of the
+	// part, excluding the markers around it.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Expression is the template expression that produced the value, e.g.
+	// `user.name` or `json data indent=2`.
+	Expression string `json:"expression"`
+	// Line is the 1-based line of the expression within the template.
+	Line int `json:"line"`
+}
+
+// previewHelperName is the helper that wraps substituted values while
+// previewing. It is not a valid name for a user helper.
+const previewHelperName = "__dotpromptPreview"
+
+// structuralHelpers are the built-in helpers whose output is markup for
+// ToMessages rather than a substituted value.
+var structuralHelpers = []string{"role", "history", "examples", "section", "media", "audio", "video", "data", "cacheBoundary"}
+
+// The characters that delimit substituted values in the output of a
+// previewed template: previewStart, the expression index, previewIndexEnd,
+// the value, then previewEnd. They are in the Unicode private use area.
+const (
+	previewStart    = '\uE000'
+	previewIndexEnd = '\uE001'
+	previewEnd      = '\uE002'
+)
+
+// templatePreview is the state of a preview compilation.
+type templatePreview struct {
+	// expressions are the wrapped expressions, by index.
+	expressions []previewExpression
+}
+
+// previewExpression is a mustache wrapped by previewTemplate.
+type previewExpression struct {
+	source string
+	line   int
+}
+
+// RenderPreview renders source like Render, but writes options.Open and
+// options.Close around every value the template substitutes, and returns the
+// location of each value, so that a UI can tell input from static prompt text.
+// Values substituted by partials and the markup written by the `role`,
+// `history`, `section`, `media`, `audio`, `video`, `data` and `cacheBoundary`
+// helpers are not marked. A nil options uses
+// DefaultPreviewOptions.
+func (dp *Dotprompt) RenderPreview(source string, data *DataArgument, options *PromptMetadata, preview *PreviewOptions) (RenderedPrompt, []PreviewSpan, error) {
+	if preview == nil {
+		preview = &DefaultPreviewOptions
+	}
+	dp.preview = &templatePreview{}
+	state := dp.preview
+	defer func() { dp.preview = nil }()
+
+	renderer, err := dp.Compile(source, options)
+	if err != nil {
+		return RenderedPrompt{}, nil, err
+	}
+	rendered, err := renderer(data, options)
+	if err != nil {
+		return RenderedPrompt{}, nil, err
+	}
+
+	var spans []PreviewSpan
+	for i, msg := range rendered.Messages {
+		content := slices.Clone(msg.Content)
+		for j, part := range content {
+			text, ok := part.(*TextPart)
+			if !ok || !strings.ContainsRune(text.Text, previewStart) {
+				continue
+			}
+			marked, partSpans := state.mark(text.Text, preview)
+			for _, span := range partSpans {
+				span.Message, span.Part = i, j
+				spans = append(spans, span)
+			}
+			content[j] = &TextPart{HasMetadata: text.HasMetadata, Text: marked}
+		}
+		rendered.Messages[i].Content = content
+	}
+	if rendered.Completion != "" {
+		rendered.Completion, _ = state.mark(rendered.Completion, preview)
+	}
+	if rendered.RawOutput != "" {
+		rendered.RawOutput, _ = state.mark(rendered.RawOutput, preview)
+	}
+	return rendered, spans, nil
+}
+
+// mark replaces the delimiters in text with the preview markers and returns
+// the spans of the values, relative to the marked text.
+func (p *templatePreview) mark(text string, options *PreviewOptions) (string, []PreviewSpan) {
+	var b strings.Builder
+	var spans []PreviewSpan
+	var open []int
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch r {
+		case previewStart:
+			end := strings.IndexRune(text[i:], previewIndexEnd)
+			if end < 0 {
+				i += size
+				continue
+			}
+			index, _ := strconv.Atoi(text[i+size : i+end])
+			b.WriteString(options.Open)
+			spans = append(spans, PreviewSpan{Start: b.Len()})
+			if index >= 0 && index < len(p.expressions) {
+				spans[len(spans)-1].Expression = p.expressions[index].source
+				spans[len(spans)-1].Line = p.expressions[index].line
+			}
+			open = append(open, len(spans)-1)
+			i += end + utf8.RuneLen(previewIndexEnd)
+		case previewEnd:
+			if len(open) > 0 {
+				spans[open[len(open)-1]].End = b.Len()
+				open = open[:len(open)-1]
+				b.WriteString(options.Close)
+			}
+			i += size
+		default:
+			b.WriteString(text[i : i+size])
+			i += size
+		}
+	}
+	// A value split by a role or history marker has no end in this text.
+	for _, i := range slices.Backward(open) {
+		spans = slices.Delete(spans, i, i+1)
+	}
+	return b.String(), spans
+}
+
+// previewTemplate rewrites template so that the value of each mustache is
+// passed through the preview helper, which delimits it in the output.
+func (dp *Dotprompt) previewTemplate(template string) (string, error) {
+	program, err := parser.Parse(template)
+	if err != nil {
+		return "", err
+	}
+	var mustaches []*ast.MustacheStatement
+	var walk func(*ast.Program)
+	walk = func(program *ast.Program) {
+		if program == nil {
+			return
+		}
+		for _, node := range program.Body {
+			switch n := node.(type) {
+			case *ast.MustacheStatement:
+				mustaches = append(mustaches, n)
+			case *ast.BlockStatement:
+				walk(n.Program)
+				walk(n.Inverse)
+			}
+		}
+	}
+	walk(program)
+
+	var b strings.Builder
+	last := 0
+	for _, m := range mustaches {
+		name := m.Expression.HelperName()
+		if slices.Contains(structuralHelpers, name) {
+			continue
+		}
+		start, end, ok := mustacheExpressionBounds(template, m.Pos)
+		if !ok {
+			continue
+		}
+		expr := template[start:end]
+		isCall := len(m.Expression.Params) > 0 || m.Expression.Hash != nil || dp.isHelper(name)
+		if isCall {
+			expr = "(" + expr + ")"
+		}
+		index := len(dp.preview.expressions)
+		dp.preview.expressions = append(dp.preview.expressions, previewExpression{
+			source: strings.TrimSpace(template[start:end]),
+			line:   m.Line,
+		})
+		b.WriteString(template[last:start])
+		fmt.Fprintf(&b, "%s %d %s", previewHelperName, index, expr)
+		last = end
+	}
+	b.WriteString(template[last:])
+	return b.String(), nil
+}
+
+// isHelper reports whether name is a helper known to dp, so that a mustache
+// with no arguments calls it rather than looking up a field.
+func (dp *Dotprompt) isHelper(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, builtin := templateHelpers[name]
+	_, user := dp.Helpers[name]
+	return builtin || user || dp.knownHelpers[name]
+}
+
+// mustacheExpressionBounds returns the offsets of the expression inside the
+// mustache at pos, between the opening `{{`, `{{~`, `{{{` or `{{&` and the
+// closing braces, skipping string literals.
+func mustacheExpressionBounds(template string, pos int) (start, end int, ok bool) {
+	if !strings.HasPrefix(template[pos:], "{{") {
+		return 0, 0, false
+	}
+	start = pos + 2
+	if strings.HasPrefix(template[start:], "~") {
+		start++
+	}
+	if strings.HasPrefix(template[start:], "{") || strings.HasPrefix(template[start:], "&") {
+		start++
+	}
+	var quote byte
+	for i := start; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(template[i:], "}}"):
+			end = i
+			if end > start && template[end-1] == '~' {
+				end--
+			}
+			if end > start && template[end-1] == '}' {
+				end--
+			}
+			return start, end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// previewHelper delimits a substituted value for RenderPreview.
+func previewHelper(index int, value any) raymond.SafeString {
+	return raymond.SafeString(string(previewStart) + strconv.Itoa(index) + string(previewIndexEnd) + raymond.Str(value) + string(previewEnd))
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderPreview(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{
+		Helpers: map[string]any{"today": func() string { return "Monday" }},
+	})
+	source := "---\ninput:\n  schema:\n    name: string\n---\n" +
+		"{{role \"system\"}}Today is {{today}}.\n" +
+		"{{role \"user\"}}Héllo {{~ name ~}}! {{#each items}}[{{{this}}}]{{/each}} {{json data}}"
+	data := &DataArgument{Input: map[string]any{
+		"name":  "Ada",
+		"items": []any{"a", "b"},
+		"data":  map[string]any{"k": 1},
+	}}
+	rendered, spans, err := dp.RenderPreview(source, data, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderPreview() returned error: %v", err)
+	}
+
+	var texts []string
+	for _, msg := range rendered.Messages {
+		texts = append(texts, msg.Content[0].(*TextPart).Text)
+	}
+	wantTexts := []string{
+		"Today is ⟦Monday⟧.\n",
+		`Héllo⟦Ada⟧! [⟦a⟧][⟦b⟧] ⟦{"k":1}⟧`,
+	}
+	if diff := cmp.Diff(wantTexts, texts); diff != "" {
+		t.Fatalf("RenderPreview() messages mismatch (-want +got):\n%s", diff)
+	}
+
+	wantValues := []string{"Monday", "Ada", "a", "b", `{"k":1}`}
+	wantExprs := []string{"today", "name", "this", "this", "json data"}
+	var values, exprs []string
+	for _, span := range spans {
+		values = append(values, texts[span.Message][span.Start:span.End])
+		exprs = append(exprs, span.Expression)
+	}
+	if diff := cmp.Diff(wantValues, values); diff != "" {
+		t.Errorf("span values mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantExprs, exprs); diff != "" {
+		t.Errorf("span expressions mismatch (-want +got):\n%s", diff)
+	}
+	if spans[0].Message != 0 || spans[1].Message != 1 || spans[1].Line != 2 {
+		t.Errorf("spans = %+v, want first in message 0 and second in message 1 on line 2", spans[:2])
+	}
+
+	// Rendering normally afterwards is unaffected.
+	plain, err := dp.Render(source, data, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if text := plain.Messages[1].Content[0].(*TextPart).Text; strings.ContainsAny(text, "⟦⟧") {
+		t.Errorf("Render() after RenderPreview() = %q, want no markers", text)
+	}
+}
+
+func TestRenderPreviewOptions(t *testing.T) {
+	dp := NewDotprompt(nil)
+	rendered, spans, err := dp.RenderPreview("Hi {{name}}, {{greeting}}", &DataArgument{Input: map[string]any{"name": "Bo"}}, nil, &PreviewOptions{Open: "<mark>", Close: "</mark>"})
+	if err != nil {
+		t.Fatalf("RenderPreview() returned error: %v", err)
+	}
+	text := rendered.Messages[0].Content[0].(*TextPart).Text
+	if want := "Hi <mark>Bo</mark>, <mark></mark>"; text != want {
+		t.Errorf("RenderPreview() = %q, want %q", text, want)
+	}
+	want := []PreviewSpan{
+		{Start: 9, End: 11, Expression: "name", Line: 1},
+		{Start: 26, End: 26, Expression: "greeting", Line: 1},
+	}
+	if diff := cmp.Diff(want, spans); diff != "" {
+		t.Errorf("RenderPreview() spans mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// PromptTestCase is a single test declared in the `tests:` section of a
+// prompt's frontmatter:
+//
+//	tests:
+//	  - name: greets the user
+//	    input: {name: Ada}
+//	    assert:
+//	      - contains: Ada
+//	      - regex: "^Hello"
+//	      - jsonpath: $.messages[0].role
+//	        equals: user
+type PromptTestCase struct {
+	Name    string            `mapstructure:"name"`
+	Input   map[string]any    `mapstructure:"input"`
+	Context map[string]any    `mapstructure:"context"`
+	Model   string            `mapstructure:"model"`
+	Assert  []PromptAssertion `mapstructure:"assert"`
+}
+
+// PromptAssertion is a check applied to a rendered prompt. Every one of
+// Contains, NotContains, Regex and JSONPath that is set is checked, and at
+// least one must be. Text assertions are applied to the text of all messages
+// joined by newlines. JSONPath assertions are applied to the JSON form of the
+// rendered prompt and check that the path exists, or that it equals Equals
+// when set.
+type PromptAssertion struct {
+	Contains    string `mapstructure:"contains"`
+	NotContains string `mapstructure:"notContains"`
+	Regex       string `mapstructure:"regex"`
+	JSONPath    string `mapstructure:"jsonpath"`
+	Equals      any    `mapstructure:"equals"`
+	// HasEquals reports that Equals is set even if it is nil, as for
+	// `equals: null`. ParsePromptTestCases sets it from the frontmatter.
+	HasEquals bool `mapstructure:"-"`
+}
+
+// PromptTestResult is the outcome of a single PromptTestCase.
+type PromptTestResult struct {
+	Prompt   string   `json:"prompt"`
+	Variant  string   `json:"variant,omitempty"`
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+	// Duration is the time taken to run the test, including compiling the
+	// prompt for its first test.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// PromptTestReport aggregates the results of RunPromptTests.
+type PromptTestReport struct {
+	Results []PromptTestResult `json:"results"`
+	Passed  int                `json:"passed"`
+	Failed  int                `json:"failed"`
+}
+
+// RunPromptTests renders every test case declared in the prompts of store and
+// reports the results. Partials are resolved from the store. The filter is a
+// regular expression matched against "prompt/test name"; an empty filter runs
+// every test.
+func RunPromptTests(store PromptStore, filter string) (PromptTestReport, error) {
+	dp := NewDotprompt(&DotpromptOptions{PartialStore: store})
+	return dp.RunPromptTests(store, filter)
+}
+
+// RunPromptTests renders every test case declared in the prompts of store
+// using this instance's helpers, partials and resolvers. See RunPromptTests.
+func (dp *Dotprompt) RunPromptTests(store PromptStore, filter string) (PromptTestReport, error) {
+	var filterRegex *regexp.Regexp
+	if filter != "" {
+		var err error
+		if filterRegex, err = regexp.Compile(filter); err != nil {
+			return PromptTestReport{}, fmt.Errorf("invalid test filter: %w", err)
+		}
+	}
+
+	var refs []PromptRef
+	cursor := ""
+	for {
+		list, err := store.List(ListPromptsOptions{Cursor: cursor})
+		if err != nil {
+			return PromptTestReport{}, err
+		}
+		refs = append(refs, list.Items...)
+		if list.Cursor == "" || list.Cursor == cursor {
+			break
+		}
+		cursor = list.Cursor
+	}
+
+	report := PromptTestReport{}
+	for _, ref := range refs {
+		prompt, err := store.Load(ref.Name, LoadPromptOptions{Variant: ref.Variant})
+		if err != nil {
+			return PromptTestReport{}, err
+		}
+		for _, result := range dp.runPromptSourceTests(ref, prompt.Source, filterRegex) {
+			if result.Passed {
+				report.Passed++
+			} else {
+				report.Failed++
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	return report, nil
+}
+
+// runPromptSourceTests runs the test cases declared in a single prompt.
+func (dp *Dotprompt) runPromptSourceTests(ref PromptRef, source string, filter *regexp.Regexp) []PromptTestResult {
+	parsed, err := dp.Parse(source)
+	if err != nil {
+		return []PromptTestResult{{Prompt: ref.Name, Variant: ref.Variant, Failures: []string{err.Error()}}}
+	}
+	cases, err := ParsePromptTestCases(parsed.Raw)
+	if err != nil {
+		return []PromptTestResult{{Prompt: ref.Name, Variant: ref.Variant, Failures: []string{err.Error()}}}
+	}
+
+	var results []PromptTestResult
+	var render PromptFunction
+	for i, tc := range cases {
+		name := tc.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		if filter != nil && !filter.MatchString(ref.Name+"/"+name) {
+			continue
+		}
+
+		start := time.Now()
+		result := PromptTestResult{Prompt: ref.Name, Variant: ref.Variant, Name: name}
+		if render == nil {
+			if render, err = dp.Compile(source, nil); err != nil {
+				result.Failures = []string{fmt.Sprintf("compile failed: %v", err)}
+				result.Duration = time.Since(start)
+				results = append(results, result)
+				continue
+			}
+		}
+
+		var options *PromptMetadata
+		if tc.Model != "" {
+			options = &PromptMetadata{Model: tc.Model}
+		}
+		rendered, err := render(&DataArgument{Input: tc.Input, Context: tc.Context}, options)
+		if err != nil {
+			result.Failures = []string{fmt.Sprintf("render failed: %v", err)}
+		} else {
+			result.Failures = CheckPromptAssertions(rendered, tc.Assert)
+		}
+		result.Passed = len(result.Failures) == 0
+		result.Duration = time.Since(start)
+		results = append(results, result)
+	}
+	return results
+}
+
+// ParsePromptTestCases decodes the `tests` entry of raw frontmatter.
+func ParsePromptTestCases(raw map[string]any) ([]PromptTestCase, error) {
+	value, ok := raw["tests"]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	var cases []PromptTestCase
+	if err := mapstructure.Decode(value, &cases); err != nil {
+		return nil, fmt.Errorf("invalid tests in frontmatter: %w", err)
+	}
+	// A null equals decodes like a missing one, so look for the key.
+	rawCases, _ := value.([]any)
+	for i := range cases {
+		if i >= len(rawCases) {
+			break
+		}
+		rawCase, _ := rawCases[i].(map[string]any)
+		rawAssertions, _ := rawCase["assert"].([]any)
+		for j := range cases[i].Assert {
+			if j >= len(rawAssertions) {
+				break
+			}
+			if rawAssertion, ok := rawAssertions[j].(map[string]any); ok {
+				_, cases[i].Assert[j].HasEquals = rawAssertion["equals"]
+			}
+		}
+	}
+	return cases, nil
+}
+
+// CheckPromptAssertions applies assertions to a rendered prompt and returns a
+// description of each failure.
+func CheckPromptAssertions(rendered RenderedPrompt, assertions []PromptAssertion) []string {
+	text := messagesText(rendered.Messages)
+
+	var doc any
+	var docErr error
+	var failures []string
+	for _, a := range assertions {
+		hasEquals := a.HasEquals || a.Equals != nil
+		if a.Contains == "" && a.NotContains == "" && a.Regex == "" && a.JSONPath == "" && !hasEquals {
+			failures = append(failures, "assertion has no check")
+			continue
+		}
+		if a.Contains != "" && !strings.Contains(text, a.Contains) {
+			failures = append(failures, fmt.Sprintf("expected output to contain %q", a.Contains))
+		}
+		if a.NotContains != "" && strings.Contains(text, a.NotContains) {
+			failures = append(failures, fmt.Sprintf("expected output not to contain %q", a.NotContains))
+		}
+		if a.Regex != "" {
+			re, err := regexp.Compile(a.Regex)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("invalid regex %q: %v", a.Regex, err))
+			} else if !re.MatchString(text) {
+				failures = append(failures, fmt.Sprintf("expected output to match %q", a.Regex))
+			}
+		}
+		if a.JSONPath == "" {
+			if hasEquals {
+				failures = append(failures, "equals requires a jsonpath")
+			}
+			continue
+		}
+		if doc == nil && docErr == nil {
+			doc, docErr = ToJSONValue(rendered)
+		}
+		if docErr != nil {
+			failures = append(failures, fmt.Sprintf("failed to encode rendered prompt: %v", docErr))
+			continue
+		}
+		value, found, err := LookupJSONPath(doc, a.JSONPath)
+		switch {
+		case err != nil:
+			failures = append(failures, err.Error())
+		case !found:
+			failures = append(failures, fmt.Sprintf("expected %s to exist", a.JSONPath))
+		case hasEquals && !jsonEqual(value, a.Equals):
+			failures = append(failures, fmt.Sprintf("expected %s to equal %v, got %v", a.JSONPath, a.Equals, value))
+		}
+	}
+	return failures
+}
+
+// messagesText joins the text parts of messages with newlines.
+func messagesText(messages []Message) string {
+	var texts []string
+	for _, msg := range messages {
+		for _, part := range msg.Content {
+			if textPart, ok := part.(*TextPart); ok {
+				texts = append(texts, textPart.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// jsonEqual compares a decoded JSON value with an expected value from YAML.
+func jsonEqual(got, want any) bool {
+	normalized, err := ToJSONValue(want)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, normalized)
+}
+
+// jsonPathSegmentRegex matches a single `.key`, `['key']` or `[0]` segment.
+var jsonPathSegmentRegex = regexp.MustCompile(`^(?:\.([A-Za-z_$][\w$-]*)|\['([^']*)'\]|\[(\d+)\])`)
+
+// LookupJSONPath evaluates a simple JSONPath expression consisting of member
+// and index accessors, such as `$.messages[0].content[0].text`, against a
+// decoded JSON document. It reports false if the path selects nothing.
+func LookupJSONPath(doc any, path string) (any, bool, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, false, fmt.Errorf("invalid jsonpath %q: must start with $", path)
+	}
+
+	current := doc
+	for rest != "" {
+		match := jsonPathSegmentRegex.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, false, fmt.Errorf("invalid jsonpath %q: unexpected %q", path, rest)
+		}
+		rest = rest[len(match[0]):]
+
+		if match[3] != "" {
+			index, _ := strconv.Atoi(match[3])
+			list, ok := current.([]any)
+			if !ok || index >= len(list) {
+				return nil, false, nil
+			}
+			current = list[index]
+			continue
+		}
+
+		key := match[1] + match[2]
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, false, nil
+		}
+		if current, ok = obj[key]; !ok {
+			return nil, false, nil
+		}
+	}
+	return current, true, nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+)
+
+const greetingWithTests = `---
+model: test/model
+tests:
+  - name: greets
+    input:
+      name: Ada
+    assert:
+      - contains: Hello Ada
+      - regex: "^Hello"
+      - notContains: Grace
+      - jsonpath: $.messages[0].role
+        equals: user
+      - jsonpath: $.model
+        equals: test/model
+  - name: wrong
+    input:
+      name: Grace
+    assert:
+      - contains: Ada
+      - jsonpath: $.messages[3]
+---
+Hello {{name}}{{> signoff}}
+`
+
+// promptTestFiles is a prompt directory with tests, a partial, and a prompt
+// without tests.
+var promptTestFiles = map[string]string{
+	"greeting.prompt": greetingWithTests,
+	"_signoff.prompt": "!",
+	"plain.prompt":    "No tests here.",
+}
+
+func TestRunPromptTests(t *testing.T) {
+	report, err := RunPromptTests(newTestDirStore(t, promptTestFiles), "")
+	if err != nil {
+		t.Fatalf("RunPromptTests() returned error: %v", err)
+	}
+	if report.Passed != 1 || report.Failed != 1 {
+		t.Fatalf("report = %d passed, %d failed; want 1 passed, 1 failed: %+v", report.Passed, report.Failed, report.Results)
+	}
+
+	failed := report.Results[1]
+	if failed.Name != "wrong" || failed.Passed {
+		t.Fatalf("Results[1] = %+v, want failing test 'wrong'", failed)
+	}
+	if len(failed.Failures) != 2 {
+		t.Errorf("len(failed.Failures) = %d, want 2: %v", len(failed.Failures), failed.Failures)
+	}
+	if !strings.Contains(failed.Failures[1], "$.messages[3]") {
+		t.Errorf("failed.Failures[1] = %q, want it to mention the path", failed.Failures[1])
+	}
+	for _, r := range report.Results {
+		if r.Duration <= 0 {
+			t.Errorf("Results[%q].Duration = %v, want it timed", r.Name, r.Duration)
+		}
+	}
+}
+
+func TestRunPromptTestsFilter(t *testing.T) {
+	report, err := RunPromptTests(newTestDirStore(t, promptTestFiles), "greeting/greets$")
+	if err != nil {
+		t.Fatalf("RunPromptTests() returned error: %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Name != "greets" {
+		t.Errorf("report.Results = %+v, want only 'greets'", report.Results)
+	}
+
+	if _, err := RunPromptTests(newTestDirStore(t, promptTestFiles), "("); err == nil {
+		t.Error("RunPromptTests() with invalid filter returned nil error")
+	}
+}
+
+// onePerPageStore lists the prompts of a store one per page.
+type onePerPageStore struct {
+	PromptStore
+}
+
+func (s onePerPageStore) List(options ListPromptsOptions) (ListPromptsResult[PromptRef], error) {
+	options.Limit = 1
+	return s.PromptStore.List(options)
+}
+
+func TestRunPromptTestsPages(t *testing.T) {
+	store := newTestDirStore(t, promptTestFiles)
+	// Listed before the prompt with tests, so that it is on a later page.
+	if err := store.Save(PromptData{PromptRef: PromptRef{Name: "first"}, Source: "First."}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	report, err := RunPromptTests(onePerPageStore{store}, "")
+	if err != nil {
+		t.Fatalf("RunPromptTests() returned error: %v", err)
+	}
+	if report.Passed != 1 || report.Failed != 1 {
+		t.Errorf("report = %d passed, %d failed; want 1 passed, 1 failed: %+v", report.Passed, report.Failed, report.Results)
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	doc := map[string]any{
+		"a": []any{map[string]any{"b": "c"}},
+		"x": map[string]any{"y z": 1.0},
+	}
+	tests := []struct {
+		path    string
+		want    any
+		found   bool
+		wantErr bool
+	}{
+		{"$.a[0].b", "c", true, false},
+		{"$.x['y z']", 1.0, true, false},
+		{"$.a[1]", nil, false, false},
+		{"$.missing", nil, false, false},
+		{"a.b", nil, false, true},
+		{"$.a[", nil, false, true},
+	}
+	for _, tt := range tests {
+		got, found, err := LookupJSONPath(doc, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LookupJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if found != tt.found || (found && got != tt.want) {
+			t.Errorf("LookupJSONPath(%q) = (%v, %v), want (%v, %v)", tt.path, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestCheckPromptAssertions(t *testing.T) {
+	parsed, err := ParseDocument(`---
+tests:
+  - assert:
+      - contains: Hello
+        notContains: Ada
+      - jsonpath: $.raw.note
+        equals: null
+      - jsonpath: $.raw.model
+        equals: null
+      - jsonpath: $.raw.model
+      - equals: 1
+      - {}
+---
+`)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	cases, err := ParsePromptTestCases(parsed.Raw)
+	if err != nil {
+		t.Fatalf("ParsePromptTestCases() returned error: %v", err)
+	}
+	if !cases[0].Assert[1].HasEquals || cases[0].Assert[3].HasEquals {
+		t.Errorf("HasEquals = %v, %v; want true, false", cases[0].Assert[1].HasEquals, cases[0].Assert[3].HasEquals)
+	}
+
+	rendered := RenderedPrompt{
+		PromptMetadata: PromptMetadata{Raw: map[string]any{"note": nil, "model": "test/model"}},
+		Messages:       []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: "Hello Ada"}}}},
+	}
+	got := CheckPromptAssertions(rendered, cases[0].Assert)
+	want := []string{
+		`expected output not to contain "Ada"`,
+		"expected $.raw.model to equal <nil>, got test/model",
+		"equals requires a jsonpath",
+		"assertion has no check",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CheckPromptAssertions() = %q, want %q", got, want)
+	}
+}
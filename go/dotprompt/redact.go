@@ -0,0 +1,221 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrPIIDetected is returned by the redaction middleware in RedactReject mode
+// when a rendered message matches one of the configured patterns.
+var ErrPIIDetected = errors.New("dotprompt: rendered prompt contains PII")
+
+// RedactionPattern is a named pattern scanned for by the redaction middleware.
+type RedactionPattern struct {
+	Name  string
+	Regex *regexp.Regexp
+}
+
+// Built-in redaction patterns.
+var (
+	// EmailPattern matches email addresses.
+	EmailPattern = RedactionPattern{
+		Name:  "email",
+		Regex: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+
+	// PhonePattern matches North American and international phone numbers.
+	// It is anchored on both sides so that it does not match the digits of a
+	// longer number.
+	PhonePattern = RedactionPattern{
+		Name:  "phone",
+		Regex: regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?(?:\(\d{3}\)|\d{3})|\(\d{3}\)|\b\d{3})[\s.\-]?\d{3}[\s.\-]?\d{4}\b`),
+	}
+
+	// CreditCardPattern matches 13 to 16 digit card numbers, optionally
+	// separated into groups by spaces or dashes.
+	CreditCardPattern = RedactionPattern{
+		Name:  "creditCard",
+		Regex: regexp.MustCompile(`\b(?:\d[ \-]?){12,15}\d\b`),
+	}
+)
+
+// DefaultRedactionPatterns is the set of patterns used when no patterns are
+// configured. Patterns are applied in order, so card numbers are masked
+// before phone numbers can match part of them.
+var DefaultRedactionPatterns = []RedactionPattern{
+	EmailPattern,
+	CreditCardPattern,
+	PhonePattern,
+}
+
+// RedactionMode controls what the redaction middleware does on a match.
+type RedactionMode int
+
+const (
+	// RedactMask replaces each match with the mask string.
+	RedactMask RedactionMode = iota
+	// RedactReject fails the render with ErrPIIDetected.
+	RedactReject
+)
+
+// RedactionOptions configures the redaction middleware.
+type RedactionOptions struct {
+	// Patterns to scan for. Defaults to DefaultRedactionPatterns.
+	Patterns []RedactionPattern
+	// Mode selects masking or rejection. Defaults to RedactMask.
+	Mode RedactionMode
+	// Mask is substituted for each match in RedactMask mode. A "%s" verb is
+	// replaced with the pattern name. Defaults to "[REDACTED:%s]".
+	Mask string
+}
+
+// redactionExtNamespace and redactionExtField name the extension field that
+// opts a prompt out of redaction. It is written as `security.redact: false` in
+// frontmatter and read back as ext.security.redact.
+const (
+	redactionExtNamespace = "security"
+	redactionExtField     = "redact"
+)
+
+// NewRedactionMiddleware returns a Middleware that scans the text parts of
+// rendered messages, the strings in their data parts, the completion and the
+// raw output for PII and masks or rejects them. Prompts can opt out by
+// setting `security.redact: false` in their frontmatter.
+func NewRedactionMiddleware(options *RedactionOptions) Middleware {
+	opts := RedactionOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Patterns == nil {
+		opts.Patterns = DefaultRedactionPatterns
+	}
+	if opts.Mask == "" {
+		opts.Mask = "[REDACTED:%s]"
+	}
+
+	return func(next RenderFunc) RenderFunc {
+		return func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+			rendered, err := next(data, options)
+			if err != nil {
+				return rendered, err
+			}
+			if redactionDisabled(rendered.PromptMetadata) {
+				return rendered, nil
+			}
+
+			messages := make([]Message, len(rendered.Messages))
+			for i, msg := range rendered.Messages {
+				content := make([]Part, len(msg.Content))
+				for j, part := range msg.Content {
+					switch part := part.(type) {
+					case *TextPart:
+						text, err := redactText(part.Text, &opts)
+						if err != nil {
+							return RenderedPrompt{}, err
+						}
+						content[j] = &TextPart{HasMetadata: part.HasMetadata, Text: text}
+					case *DataPart:
+						data, err := redactValue(part.Data, &opts)
+						if err != nil {
+							return RenderedPrompt{}, err
+						}
+						content[j] = &DataPart{HasMetadata: part.HasMetadata, Data: data.(map[string]any)}
+					default:
+						content[j] = part
+					}
+				}
+				msg.Content = content
+				messages[i] = msg
+			}
+			rendered.Messages = messages
+			if rendered.Completion, err = redactText(rendered.Completion, &opts); err != nil {
+				return RenderedPrompt{}, err
+			}
+			if rendered.RawOutput, err = redactText(rendered.RawOutput, &opts); err != nil {
+				return RenderedPrompt{}, err
+			}
+			return rendered, nil
+		}
+	}
+}
+
+// redactText applies the configured patterns to text.
+func redactText(text string, opts *RedactionOptions) (string, error) {
+	for _, pattern := range opts.Patterns {
+		if !pattern.Regex.MatchString(text) {
+			continue
+		}
+		if opts.Mode == RedactReject {
+			return "", fmt.Errorf("%w: matched pattern '%s'", ErrPIIDetected, pattern.Name)
+		}
+		mask := opts.Mask
+		if strings.Contains(mask, "%s") {
+			mask = fmt.Sprintf(mask, pattern.Name)
+		}
+		text = pattern.Regex.ReplaceAllLiteralString(text, mask)
+	}
+	return text, nil
+}
+
+// redactValue applies the configured patterns to the strings in v, a value
+// decoded from JSON such as the data of a DataPart. Maps and slices are
+// copied rather than modified.
+func redactValue(v any, opts *RedactionOptions) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return redactText(v, opts)
+	case map[string]any:
+		if v == nil {
+			return v, nil
+		}
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			redacted, err := redactValue(item, opts)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = redacted
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			redacted, err := redactValue(item, opts)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = redacted
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// redactionDisabled reports whether the prompt opted out of redaction.
+func redactionDisabled(meta PromptMetadata) bool {
+	security, ok := meta.Ext[redactionExtNamespace]
+	if !ok {
+		return false
+	}
+	enabled, ok := security[redactionExtField].(bool)
+	return ok && !enabled
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func renderText(t *testing.T, dp *Dotprompt, source string, input map[string]any) string {
+	t.Helper()
+	rendered, err := dp.Render(source, &DataArgument{Input: input}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	return rendered.Messages[0].Content[0].(*TextPart).Text
+}
+
+func TestRedactionMiddlewareMasks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"email", "Contact ada@example.com now", "Contact [REDACTED:email] now"},
+		{"phone", "Call (555) 123-4567 today", "Call [REDACTED:phone] today"},
+		{"international phone", "Call +1 415-555-1234 today", "Call [REDACTED:phone] today"},
+		{"credit card", "Card 4111 1111 1111 1111 on file", "Card [REDACTED:creditCard] on file"},
+		{"credit card without separators", "Card 4111111111111111 on file", "Card [REDACTED:creditCard] on file"},
+		{"long digit run", "Order 12345678901 shipped", "Order 12345678901 shipped"},
+		{"clean", "Nothing to see here", "Nothing to see here"},
+	}
+
+	dp := NewDotprompt(nil).Use(NewRedactionMiddleware(nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderText(t, dp, "{{text}}", map[string]any{"text": tt.input})
+			if got != tt.want {
+				t.Errorf("rendered text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactionMiddlewareCustomPattern(t *testing.T) {
+	dp := NewDotprompt(nil).Use(NewRedactionMiddleware(&RedactionOptions{
+		Patterns: []RedactionPattern{{Name: "ssn", Regex: regexp.MustCompile(`\d{3}-\d{2}-\d{4}`)}},
+		Mask:     "***",
+	}))
+
+	got := renderText(t, dp, "SSN {{ssn}}", map[string]any{"ssn": "123-45-6789"})
+	if got != "SSN ***" {
+		t.Errorf("rendered text = %q, want %q", got, "SSN ***")
+	}
+}
+
+func TestRedactionMiddlewareRejects(t *testing.T) {
+	dp := NewDotprompt(nil).Use(NewRedactionMiddleware(&RedactionOptions{Mode: RedactReject}))
+
+	_, err := dp.Render("{{text}}", &DataArgument{Input: map[string]any{"text": "ada@example.com"}}, nil)
+	if !errors.Is(err, ErrPIIDetected) {
+		t.Errorf("Render() error = %v, want %v", err, ErrPIIDetected)
+	}
+}
+
+func TestRedactionMiddlewareDataParts(t *testing.T) {
+	input := map[string]any{"obj": map[string]any{
+		"email":    "alice@example.com",
+		"contacts": []any{"Call (555) 123-4567", 42},
+		"note":     "fine",
+	}}
+	dp := NewDotprompt(nil).Use(NewRedactionMiddleware(nil))
+	rendered, err := dp.Render("{{data obj}}", &DataArgument{Input: input}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	var got map[string]any
+	for _, part := range rendered.Messages[0].Content {
+		if data, ok := part.(*DataPart); ok {
+			got = data.Data
+		}
+	}
+	want := map[string]any{
+		"email":    "[REDACTED:email]",
+		"contacts": []any{"Call [REDACTED:phone]", float64(42)},
+		"note":     "fine",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("data part mismatch (-want +got):\n%s", diff)
+	}
+	if email := input["obj"].(map[string]any)["email"]; email != "alice@example.com" {
+		t.Errorf("input modified: email = %q", email)
+	}
+
+	dp = NewDotprompt(nil).Use(NewRedactionMiddleware(&RedactionOptions{Mode: RedactReject}))
+	if _, err := dp.Render("{{data obj}}", &DataArgument{Input: input}, nil); !errors.Is(err, ErrPIIDetected) {
+		t.Errorf("Render() error = %v, want %v", err, ErrPIIDetected)
+	}
+}
+
+func TestRedactionMiddlewareOptOut(t *testing.T) {
+	dp := NewDotprompt(nil).Use(NewRedactionMiddleware(nil))
+	source := "---\nsecurity.redact: false\n---\n{{text}}"
+
+	got := renderText(t, dp, source, map[string]any{"text": "ada@example.com"})
+	if got != "ada@example.com" {
+		t.Errorf("rendered text = %q, want %q", got, "ada@example.com")
+	}
+}
+
+func TestRedactionMiddlewareDoesNotMutateHistory(t *testing.T) {
+	history := []Message{{
+		Role:    RoleUser,
+		Content: []Part{&TextPart{Text: "my email is ada@example.com"}},
+	}}
+	dp := NewDotprompt(nil).Use(NewRedactionMiddleware(nil))
+
+	if _, err := dp.Render("{{history}}", &DataArgument{Messages: history}, nil); err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got := history[0].Content[0].(*TextPart).Text; got != "my email is ada@example.com" {
+		t.Errorf("history text = %q, want it unchanged", got)
+	}
+}
+
+func TestRedactionMiddlewareCompletionAndRawOutput(t *testing.T) {
+	source := "---\nformat: completion\n---\nEmail {{email}}"
+	input := &DataArgument{Input: map[string]any{"email": "bob@example.com"}}
+
+	dp := NewDotprompt(&DotpromptOptions{KeepRawOutput: true}).Use(NewRedactionMiddleware(nil))
+	rendered, err := dp.Render(source, input, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	for _, got := range []struct{ field, text string }{
+		{"Completion", rendered.Completion},
+		{"RawOutput", rendered.RawOutput},
+		{"Messages", rendered.Messages[0].Content[0].(*TextPart).Text},
+	} {
+		if !strings.Contains(got.text, "Email [REDACTED:email]") || strings.Contains(got.text, "bob@example.com") {
+			t.Errorf("%s = %q, want the email redacted", got.field, got.text)
+		}
+	}
+
+	dp = NewDotprompt(&DotpromptOptions{KeepRawOutput: true}).Use(NewRedactionMiddleware(&RedactionOptions{Mode: RedactReject}))
+	if _, err := dp.Render(source, input, nil); !errors.Is(err, ErrPIIDetected) {
+		t.Errorf("Render() error = %v, want %v", err, ErrPIIDetected)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// Client is a dotprompt.WritablePromptStore backed by a registry Server.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+var (
+	_ dp.WritablePromptStore = (*Client)(nil)
+	_ dp.PartialWriter       = (*Client)(nil)
+	_ dp.BatchLoader         = (*Client)(nil)
+)
+
+// NewClient returns a Client for the registry served at baseURL, e.g.
+// "http://registry.internal:8080". Calls are made with httpClient, or with
+// http.DefaultClient if it is nil.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), http: httpClient}
+}
+
+// post sends req to method and returns the response, which the caller must
+// close. Failed calls are returned as *Error.
+func (c *Client) post(ctx context.Context, method string, req any) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+ServicePath+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var status Status
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil || status.Code == "" {
+			return nil, fmt.Errorf("registry: %s: unexpected response: %s", method, resp.Status)
+		}
+		return nil, &Error{status}
+	}
+	return resp, nil
+}
+
+// call makes a unary call of method and decodes its response into resp.
+func (c *Client) call(method string, req, resp any) error {
+	httpResp, err := c.post(context.Background(), method, req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("registry: %s: decoding response: %w", method, err)
+	}
+	return nil
+}
+
+// List enumerates the prompts in the registry.
+func (c *Client) List(options dp.ListPromptsOptions) (dp.ListPromptsResult[dp.PromptRef], error) {
+	var resp ListResponse
+	err := c.call(MethodList, ListRequest{
+		Cursor:  options.Cursor,
+		Limit:   options.Limit,
+		Variant: options.Variant,
+		Prefix:  options.Prefix,
+		Glob:    options.Glob,
+		Tags:    options.Tags,
+	}, &resp)
+	if err != nil {
+		return dp.ListPromptsResult[dp.PromptRef]{}, err
+	}
+	return dp.ListPromptsResult[dp.PromptRef]{Items: resp.Prompts, Cursor: resp.Cursor}, nil
+}
+
+// ListPartials enumerates the partials in the registry.
+func (c *Client) ListPartials(options dp.ListPartialsOptions) (dp.ListPartialsResult[dp.PartialRef], error) {
+	var resp ListPartialsResponse
+	err := c.call(MethodListPartials, ListRequest{Cursor: options.Cursor, Limit: options.Limit, Variant: options.Variant}, &resp)
+	if err != nil {
+		return dp.ListPartialsResult[dp.PartialRef]{}, err
+	}
+	return dp.ListPartialsResult[dp.PartialRef]{Items: resp.Partials, Cursor: resp.Cursor}, nil
+}
+
+// Load retrieves a prompt from the registry.
+func (c *Client) Load(name string, options dp.LoadPromptOptions) (dp.PromptData, error) {
+	var resp dp.PromptData
+	err := c.call(MethodLoad, LoadRequest{Name: name, Variant: options.Variant, Version: options.Version}, &resp)
+	return resp, err
+}
+
+// LoadMany retrieves several prompts from the registry in one call. Against
+// a server without LoadMany, it loads the prompts one at a time.
+func (c *Client) LoadMany(refs []dp.PromptRef) ([]dp.PromptData, error) {
+	var resp LoadManyResponse
+	err := c.call(MethodLoadMany, LoadManyRequest{Prompts: refs}, &resp)
+	var regErr *Error
+	if errors.As(err, &regErr) && regErr.Code == CodeUnimplemented {
+		prompts := make([]dp.PromptData, len(refs))
+		for i, ref := range refs {
+			if prompts[i], err = c.Load(ref.Name, dp.LoadPromptOptions{Variant: ref.Variant, Version: ref.Version}); err != nil {
+				return nil, err
+			}
+		}
+		return prompts, nil
+	}
+	return resp.Prompts, err
+}
+
+// LoadPartial retrieves a partial from the registry.
+func (c *Client) LoadPartial(name string, options dp.LoadPartialOptions) (dp.PartialData, error) {
+	var resp dp.PartialData
+	err := c.call(MethodLoadPartial, LoadRequest{Name: name, Variant: options.Variant, Version: options.Version}, &resp)
+	return resp, err
+}
+
+// Save saves a prompt in the registry.
+func (c *Client) Save(prompt dp.PromptData) error {
+	return c.call(MethodSave, prompt, &struct{}{})
+}
+
+// SavePartial saves a partial in the registry.
+func (c *Client) SavePartial(partial dp.PartialData) error {
+	return c.call(MethodSavePartial, partial, &struct{}{})
+}
+
+// Delete deletes a prompt from the registry.
+func (c *Client) Delete(name string, options dp.PromptStoreDeleteOptions) error {
+	return c.call(MethodDelete, DeleteRequest{Name: name, Variant: options.Variant}, &struct{}{})
+}
+
+// Watch subscribes to the changes in the registry. It returns once the
+// server has recorded the current state of the store, so every later change
+// is reported. The channel is closed when ctx is done or the stream ends.
+func (c *Client) Watch(ctx context.Context) (<-chan WatchEvent, error) {
+	var body bytes.Buffer
+	if err := writeEnvelope(&body, 0, WatchRequest{}); err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+ServicePath+MethodWatch, &body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/connect+json")
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("registry: %s: unexpected response: %s", MethodWatch, resp.Status)
+	}
+
+	var sync WatchEvent
+	if err := readEvent(resp.Body, &sync); err != nil || sync.Type != EventSync {
+		resp.Body.Close()
+		if err == nil {
+			err = fmt.Errorf("registry: %s: stream did not start with a sync event", MethodWatch)
+		}
+		return nil, err
+	}
+
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		for {
+			var event WatchEvent
+			if err := readEvent(resp.Body, &event); err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// readEvent reads the next WatchEvent of a stream. It returns io.EOF when the
+// stream ends cleanly and *Error when it ends with a failure.
+func readEvent(r io.Reader, event *WatchEvent) error {
+	var raw json.RawMessage
+	flags, err := readEnvelope(r, &raw)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return fmt.Errorf("registry: %s: %w", MethodWatch, err)
+	}
+	if flags&flagEndStream != 0 {
+		var end endStream
+		if err := json.Unmarshal(raw, &end); err != nil {
+			return fmt.Errorf("registry: %s: %w", MethodWatch, err)
+		}
+		if end.Error != nil {
+			return &Error{*end.Error}
+		}
+		return io.EOF
+	}
+	if err := json.Unmarshal(raw, event); err != nil {
+		return fmt.Errorf("registry: %s: %w", MethodWatch, err)
+	}
+	return nil
+}
@@ -0,0 +1,436 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	pb "github.com/google/dotprompt/go/dotprompt/registry/registrypb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// isGRPC reports whether r is a gRPC call.
+func isGRPC(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// RegisterGRPC registers the registry service of s with a gRPC server, for
+// serving it next to other gRPC services. ServeHTTP serves gRPC calls
+// without it.
+func (s *Server) RegisterGRPC(registrar grpc.ServiceRegistrar) {
+	pb.RegisterRegistryServer(registrar, grpcService{s: s})
+}
+
+// grpcServer returns the gRPC server that ServeHTTP hands gRPC calls to.
+func (s *Server) grpcServer() *grpc.Server {
+	s.grpcOnce.Do(func() {
+		s.grpc = grpc.NewServer(grpc.MaxRecvMsgSize(maxRequestSize))
+		s.RegisterGRPC(s.grpc)
+	})
+	return s.grpc
+}
+
+// grpcService implements the registry service of registry.proto for a
+// Server.
+type grpcService struct {
+	pb.UnimplementedRegistryServer
+	s *Server
+}
+
+func (g grpcService) List(_ context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
+	resp, err := g.s.list(ListRequest{
+		Cursor:  req.GetCursor(),
+		Limit:   int(req.GetLimit()),
+		Variant: req.GetVariant(),
+		Prefix:  req.GetPrefix(),
+		Glob:    req.GetGlob(),
+		Tags:    req.GetTags(),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	prompts := make([]*pb.PromptRef, len(resp.Prompts))
+	for i, ref := range resp.Prompts {
+		prompts[i] = promptRefToProto(ref)
+	}
+	return &pb.ListResponse{Prompts: prompts, Cursor: resp.Cursor}, nil
+}
+
+func (g grpcService) ListPartials(_ context.Context, req *pb.ListRequest) (*pb.ListPartialsResponse, error) {
+	resp, err := g.s.listPartials(ListRequest{Cursor: req.GetCursor(), Limit: int(req.GetLimit()), Variant: req.GetVariant()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	partials := make([]*pb.PartialRef, len(resp.Partials))
+	for i, ref := range resp.Partials {
+		partials[i] = partialRefToProto(ref)
+	}
+	return &pb.ListPartialsResponse{Partials: partials, Cursor: resp.Cursor}, nil
+}
+
+func (g grpcService) Load(_ context.Context, req *pb.LoadRequest) (*pb.PromptData, error) {
+	prompt, err := g.s.store.Load(req.GetName(), dp.LoadPromptOptions{Variant: req.GetVariant(), Version: req.GetVersion()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return promptToProto(prompt), nil
+}
+
+func (g grpcService) LoadPartial(_ context.Context, req *pb.LoadRequest) (*pb.PartialData, error) {
+	partial, err := g.s.store.LoadPartial(req.GetName(), dp.LoadPartialOptions{Variant: req.GetVariant(), Version: req.GetVersion()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return partialToProto(partial), nil
+}
+
+func (g grpcService) LoadMany(_ context.Context, req *pb.LoadManyRequest) (*pb.LoadManyResponse, error) {
+	refs := make([]dp.PromptRef, len(req.GetPrompts()))
+	for i, ref := range req.GetPrompts() {
+		refs[i] = promptRefFromProto(ref)
+	}
+	prompts, err := dp.LoadMany(g.s.store, refs)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &pb.LoadManyResponse{Prompts: make([]*pb.PromptData, len(prompts))}
+	for i, prompt := range prompts {
+		resp.Prompts[i] = promptToProto(prompt)
+	}
+	return resp, nil
+}
+
+func (g grpcService) Save(_ context.Context, req *pb.PromptData) (*emptypb.Empty, error) {
+	_, err := g.s.write(func(store dp.WritablePromptStore) error { return store.Save(promptFromProto(req)) })
+	return empty(err)
+}
+
+func (g grpcService) SavePartial(_ context.Context, req *pb.PartialData) (*emptypb.Empty, error) {
+	_, err := g.s.savePartial(partialFromProto(req))
+	return empty(err)
+}
+
+func (g grpcService) Delete(_ context.Context, req *pb.DeleteRequest) (*emptypb.Empty, error) {
+	_, err := g.s.write(func(store dp.WritablePromptStore) error {
+		return store.Delete(req.GetName(), dp.PromptStoreDeleteOptions{Variant: req.GetVariant()})
+	})
+	return empty(err)
+}
+
+func (g grpcService) Watch(_ *pb.WatchRequest, stream grpc.ServerStreamingServer[pb.WatchEvent]) error {
+	return grpcError(g.s.watch(stream.Context(), func(event WatchEvent) error {
+		return stream.Send(watchEventToProto(event))
+	}))
+}
+
+// empty returns the response of a write method that failed if err is not
+// nil.
+func empty(err error) (*emptypb.Empty, error) {
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// grpcCode returns the gRPC status code of failures with the given code.
+func grpcCode(code string) codes.Code {
+	switch code {
+	case CodeInvalidArgument:
+		return codes.InvalidArgument
+	case CodeNotFound:
+		return codes.NotFound
+	case CodePermissionDenied:
+		return codes.PermissionDenied
+	case CodeResourceExhausted:
+		return codes.ResourceExhausted
+	case CodeUnimplemented:
+		return codes.Unimplemented
+	default:
+		return codes.Unknown
+	}
+}
+
+// grpcError returns err as a gRPC status error. Errors that already carry a
+// gRPC status, such as those of sending to a stream, are returned as is.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	st := statusOf(err)
+	return status.Error(grpcCode(st.Code), st.Message)
+}
+
+// errorFromGRPC returns the error of a failed gRPC call: *Error for the
+// status codes the Server responds with, and err itself for others, such as
+// those of canceled calls.
+func errorFromGRPC(err error) error {
+	st, ok := status.FromError(err)
+	if err == nil || !ok {
+		return err
+	}
+	var code string
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = CodeInvalidArgument
+	case codes.NotFound:
+		code = CodeNotFound
+	case codes.PermissionDenied:
+		code = CodePermissionDenied
+	case codes.ResourceExhausted:
+		code = CodeResourceExhausted
+	case codes.Unimplemented:
+		code = CodeUnimplemented
+	case codes.Unknown:
+		code = CodeUnknown
+	default:
+		return err
+	}
+	return &Error{Status{Code: code, Message: st.Message()}}
+}
+
+// GRPCClient is a dotprompt.WritablePromptStore backed by a registry Server,
+// called over gRPC.
+type GRPCClient struct {
+	client pb.RegistryClient
+}
+
+var (
+	_ dp.WritablePromptStore = (*GRPCClient)(nil)
+	_ dp.PartialWriter       = (*GRPCClient)(nil)
+	_ dp.BatchLoader         = (*GRPCClient)(nil)
+)
+
+// NewGRPCClient returns a GRPCClient that calls the registry over conn,
+// typically made with grpc.NewClient.
+func NewGRPCClient(conn grpc.ClientConnInterface) *GRPCClient {
+	return &GRPCClient{client: pb.NewRegistryClient(conn)}
+}
+
+// List enumerates the prompts in the registry.
+func (c *GRPCClient) List(options dp.ListPromptsOptions) (dp.ListPromptsResult[dp.PromptRef], error) {
+	resp, err := c.client.List(context.Background(), &pb.ListRequest{
+		Cursor:  options.Cursor,
+		Limit:   int32(options.Limit),
+		Variant: options.Variant,
+		Prefix:  options.Prefix,
+		Glob:    options.Glob,
+		Tags:    options.Tags,
+	})
+	if err != nil {
+		return dp.ListPromptsResult[dp.PromptRef]{}, errorFromGRPC(err)
+	}
+	result := dp.ListPromptsResult[dp.PromptRef]{Items: make([]dp.PromptRef, len(resp.GetPrompts())), Cursor: resp.GetCursor()}
+	for i, ref := range resp.GetPrompts() {
+		result.Items[i] = promptRefFromProto(ref)
+	}
+	return result, nil
+}
+
+// ListPartials enumerates the partials in the registry.
+func (c *GRPCClient) ListPartials(options dp.ListPartialsOptions) (dp.ListPartialsResult[dp.PartialRef], error) {
+	resp, err := c.client.ListPartials(context.Background(), &pb.ListRequest{
+		Cursor:  options.Cursor,
+		Limit:   int32(options.Limit),
+		Variant: options.Variant,
+	})
+	if err != nil {
+		return dp.ListPartialsResult[dp.PartialRef]{}, errorFromGRPC(err)
+	}
+	result := dp.ListPartialsResult[dp.PartialRef]{Items: make([]dp.PartialRef, len(resp.GetPartials())), Cursor: resp.GetCursor()}
+	for i, ref := range resp.GetPartials() {
+		result.Items[i] = partialRefFromProto(ref)
+	}
+	return result, nil
+}
+
+// Load retrieves a prompt from the registry.
+func (c *GRPCClient) Load(name string, options dp.LoadPromptOptions) (dp.PromptData, error) {
+	resp, err := c.client.Load(context.Background(), &pb.LoadRequest{Name: name, Variant: options.Variant, Version: options.Version})
+	if err != nil {
+		return dp.PromptData{}, errorFromGRPC(err)
+	}
+	return promptFromProto(resp), nil
+}
+
+// LoadMany retrieves several prompts from the registry in one call. Against
+// a server without LoadMany, it loads the prompts one at a time.
+func (c *GRPCClient) LoadMany(refs []dp.PromptRef) ([]dp.PromptData, error) {
+	req := &pb.LoadManyRequest{Prompts: make([]*pb.PromptRef, len(refs))}
+	for i, ref := range refs {
+		req.Prompts[i] = promptRefToProto(ref)
+	}
+	resp, err := c.client.LoadMany(context.Background(), req)
+	if status.Code(err) == codes.Unimplemented {
+		prompts := make([]dp.PromptData, len(refs))
+		for i, ref := range refs {
+			if prompts[i], err = c.Load(ref.Name, dp.LoadPromptOptions{Variant: ref.Variant, Version: ref.Version}); err != nil {
+				return nil, err
+			}
+		}
+		return prompts, nil
+	}
+	if err != nil {
+		return nil, errorFromGRPC(err)
+	}
+	prompts := make([]dp.PromptData, len(resp.GetPrompts()))
+	for i, prompt := range resp.GetPrompts() {
+		prompts[i] = promptFromProto(prompt)
+	}
+	return prompts, nil
+}
+
+// LoadPartial retrieves a partial from the registry.
+func (c *GRPCClient) LoadPartial(name string, options dp.LoadPartialOptions) (dp.PartialData, error) {
+	resp, err := c.client.LoadPartial(context.Background(), &pb.LoadRequest{Name: name, Variant: options.Variant, Version: options.Version})
+	if err != nil {
+		return dp.PartialData{}, errorFromGRPC(err)
+	}
+	return partialFromProto(resp), nil
+}
+
+// Save saves a prompt in the registry.
+func (c *GRPCClient) Save(prompt dp.PromptData) error {
+	_, err := c.client.Save(context.Background(), promptToProto(prompt))
+	return errorFromGRPC(err)
+}
+
+// SavePartial saves a partial in the registry.
+func (c *GRPCClient) SavePartial(partial dp.PartialData) error {
+	_, err := c.client.SavePartial(context.Background(), partialToProto(partial))
+	return errorFromGRPC(err)
+}
+
+// Delete deletes a prompt from the registry.
+func (c *GRPCClient) Delete(name string, options dp.PromptStoreDeleteOptions) error {
+	_, err := c.client.Delete(context.Background(), &pb.DeleteRequest{Name: name, Variant: options.Variant})
+	return errorFromGRPC(err)
+}
+
+// Watch subscribes to the changes in the registry. It returns once the
+// server has recorded the current state of the store, so every later change
+// is reported. The channel is closed when ctx is done or the stream ends.
+func (c *GRPCClient) Watch(ctx context.Context) (<-chan WatchEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.client.Watch(ctx, &pb.WatchRequest{})
+	if err != nil {
+		cancel()
+		return nil, errorFromGRPC(err)
+	}
+	sync, err := stream.Recv()
+	if err != nil || sync.GetType() != EventSync {
+		cancel()
+		if err == nil {
+			return nil, fmt.Errorf("registry: %s: stream did not start with a sync event", MethodWatch)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("registry: %s: stream ended before the sync event", MethodWatch)
+		}
+		return nil, errorFromGRPC(err)
+	}
+
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		defer cancel()
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- watchEventFromProto(event):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func promptRefToProto(ref dp.PromptRef) *pb.PromptRef {
+	return &pb.PromptRef{Name: ref.Name, Variant: ref.Variant, Version: ref.Version}
+}
+
+func promptRefFromProto(ref *pb.PromptRef) dp.PromptRef {
+	return dp.PromptRef{Name: ref.GetName(), Variant: ref.GetVariant(), Version: ref.GetVersion()}
+}
+
+func partialRefToProto(ref dp.PartialRef) *pb.PartialRef {
+	return &pb.PartialRef{Name: ref.Name, Variant: ref.Variant, Version: ref.Version}
+}
+
+func partialRefFromProto(ref *pb.PartialRef) dp.PartialRef {
+	return dp.PartialRef{Name: ref.GetName(), Variant: ref.GetVariant(), Version: ref.GetVersion()}
+}
+
+func promptToProto(prompt dp.PromptData) *pb.PromptData {
+	return &pb.PromptData{Name: prompt.Name, Variant: prompt.Variant, Version: prompt.Version, Source: prompt.Source}
+}
+
+func promptFromProto(prompt *pb.PromptData) dp.PromptData {
+	return dp.PromptData{
+		PromptRef: dp.PromptRef{Name: prompt.GetName(), Variant: prompt.GetVariant(), Version: prompt.GetVersion()},
+		Source:    prompt.GetSource(),
+	}
+}
+
+func partialToProto(partial dp.PartialData) *pb.PartialData {
+	return &pb.PartialData{Name: partial.Name, Variant: partial.Variant, Version: partial.Version, Source: partial.Source}
+}
+
+func partialFromProto(partial *pb.PartialData) dp.PartialData {
+	return dp.PartialData{
+		PartialRef: dp.PartialRef{Name: partial.GetName(), Variant: partial.GetVariant(), Version: partial.GetVersion()},
+		Source:     partial.GetSource(),
+	}
+}
+
+func watchEventToProto(event WatchEvent) *pb.WatchEvent {
+	msg := &pb.WatchEvent{Type: event.Type}
+	if event.Prompt != nil {
+		msg.Prompt = promptRefToProto(*event.Prompt)
+	}
+	if event.Partial != nil {
+		msg.Partial = partialRefToProto(*event.Partial)
+	}
+	return msg
+}
+
+func watchEventFromProto(msg *pb.WatchEvent) WatchEvent {
+	event := WatchEvent{Type: msg.GetType()}
+	if msg.Prompt != nil {
+		ref := promptRefFromProto(msg.Prompt)
+		event.Prompt = &ref
+	}
+	if msg.Partial != nil {
+		ref := partialRefFromProto(msg.Partial)
+		event.Partial = &ref
+	}
+	return event
+}
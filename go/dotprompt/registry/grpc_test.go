@@ -0,0 +1,179 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	pb "github.com/google/dotprompt/go/dotprompt/registry/registrypb"
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// newGRPCConn serves server over unencrypted HTTP/2, as dotprompt-registry
+// does, and returns a gRPC connection to it and a Connect client for it.
+func newGRPCConn(t *testing.T, server *Server) (*grpc.ClientConn, *Client) {
+	t.Helper()
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	ts := httptest.NewUnstartedServer(server)
+	ts.Config.Protocols = &protocols
+	ts.Start()
+	t.Cleanup(ts.Close)
+	conn, err := grpc.NewClient(strings.TrimPrefix(ts.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() returned error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, NewClient(ts.URL, ts.Client())
+}
+
+func TestGRPCClientConformance(t *testing.T) {
+	storetest.RunConformanceTests(t, func() dp.PromptStore {
+		store := newDirStore(t)
+		store.KeepVersions = true
+		conn, _ := newGRPCConn(t, NewServer(store))
+		return NewGRPCClient(conn)
+	})
+}
+
+func TestGRPC(t *testing.T) {
+	conn, connect := newGRPCConn(t, NewServer(newDirStore(t)))
+	client := pb.NewRegistryClient(conn)
+	ctx := context.Background()
+
+	if _, err := client.Save(ctx, &pb.PromptData{Name: "greet", Variant: "formal", Source: "Good day"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	got, err := client.Load(ctx, &pb.LoadRequest{Name: "greet", Variant: "formal"})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if got.GetName() != "greet" || got.GetVariant() != "formal" || got.GetSource() != "Good day" || got.GetVersion() == "" {
+		t.Errorf("Load() = %v, want the saved prompt", got)
+	}
+
+	list, err := client.List(ctx, &pb.ListRequest{Variant: "formal"})
+	if err != nil {
+		t.Fatalf("List() returned error: %v", err)
+	}
+	want := &pb.ListResponse{Prompts: []*pb.PromptRef{{Name: "greet", Variant: "formal"}}}
+	if diff := cmp.Diff(want, list, protocmp.Transform()); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The gRPC and Connect protocols serve the same store.
+	prompt, err := connect.Load("greet", dp.LoadPromptOptions{Variant: "formal"})
+	if err != nil || prompt.Source != "Good day" {
+		t.Errorf("Connect Load() = %+v, %v, want the prompt saved over gRPC", prompt, err)
+	}
+}
+
+func TestGRPCStatus(t *testing.T) {
+	server := NewServer(newDirStore(t))
+	conn, _ := newGRPCConn(t, server)
+	client := pb.NewRegistryClient(conn)
+	ctx := context.Background()
+
+	if _, err := client.Load(ctx, &pb.LoadRequest{Name: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Load() of a missing prompt: error = %v, want NotFound", err)
+	}
+	large := &pb.PromptData{Name: "large", Source: strings.Repeat("a", maxRequestSize)}
+	if _, err := client.Save(ctx, large); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Save() of a large prompt: error = %v, want ResourceExhausted", err)
+	}
+	if err := conn.Invoke(ctx, ServicePath+"Frobnicate", &pb.WatchRequest{}, &pb.WatchRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("unknown method: error = %v, want Unimplemented", err)
+	}
+	server.ReadOnly = true
+	if _, err := client.Delete(ctx, &pb.DeleteRequest{Name: "a"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Delete() on a read-only server: error = %v, want PermissionDenied", err)
+	}
+}
+
+func TestGRPCClientErrors(t *testing.T) {
+	conn, _ := newGRPCConn(t, NewServer(newDirStore(t)))
+	client := NewGRPCClient(conn)
+	_, err := client.Load("missing", dp.LoadPromptOptions{})
+	var regErr *Error
+	if !errors.Is(err, dp.ErrNotFound) || !errors.As(err, &regErr) || regErr.Code != CodeNotFound {
+		t.Errorf("Load() error = %v, want %s wrapping ErrNotFound", err, CodeNotFound)
+	}
+
+	conn, _ = newGRPCConn(t, NewServer(struct{ dp.PromptStore }{newDirStore(t)}))
+	err = NewGRPCClient(conn).Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "a"}, Source: "a"})
+	if !errors.As(err, &regErr) || regErr.Code != CodeUnimplemented {
+		t.Errorf("Save() error = %v, want %s", err, CodeUnimplemented)
+	}
+}
+
+func TestGRPCWatch(t *testing.T) {
+	server := NewServer(newDirStore(t))
+	server.PollInterval = time.Hour
+	conn, _ := newGRPCConn(t, server)
+	client := NewGRPCClient(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := client.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() returned error: %v", err)
+	}
+	if err := client.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "a"}, Source: "a"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	select {
+	case event := <-events:
+		if event.Type != EventPut || event.Prompt == nil || event.Prompt.Name != "a" || event.Partial != nil {
+			t.Errorf("event = %+v, want put of prompt a", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("events received after the context was canceled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("events not closed after the context was canceled")
+	}
+}
+
+func TestGRPCWatchReportsStreamErrors(t *testing.T) {
+	conn, _ := newGRPCConn(t, NewServer(failingListStore{newDirStore(t)}))
+	_, err := NewGRPCClient(conn).Watch(context.Background())
+	var regErr *Error
+	if !errors.As(err, &regErr) || regErr.Code != CodeUnknown || !strings.Contains(regErr.Message, "store is down") {
+		t.Errorf("Watch() error = %v, want %s error from the store", err, CodeUnknown)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package registry implements a central prompt registry: a Server that
+// exposes a dotprompt.PromptStore over HTTP, and a Client that implements
+// dotprompt.WritablePromptStore on top of it, so that services can share
+// prompts through one registry instead of each reading its own directory.
+//
+// The service is defined in registry.proto, whose generated Go code is in
+// registrypb. Server serves it with both the gRPC protocol
+// (https://grpc.io/docs/what-is-grpc/core-concepts/), through grpc-go, and the
+// Connect protocol (https://connectrpc.com/docs/protocol) with JSON messages,
+// so it can be called from gRPC and Connect clients generated from
+// registry.proto in any language. GRPCClient calls it with gRPC, and Client
+// with Connect. gRPC calls need HTTP/2: serve the Server over TLS, or enable
+// unencrypted HTTP/2 as dotprompt-registry does. Every method is a POST to
+//
+//	/dotprompt.registry.v1.Registry/<method>
+//
+// Requests with a Content-Type starting with application/grpc are gRPC calls;
+// the rest use Connect. With Connect, the unary methods List, ListPartials,
+// Load, LoadPartial, LoadMany, Save, SavePartial and Delete take and respond
+// with one application/json message; failures respond with a non-200 status
+// and a JSON Status. Watch is a server stream of application/connect+json
+// envelopes: WatchEvents, starting with a "sync" event once the server has
+// recorded the current state of the store, and an end-of-stream message that
+// carries the Status of a failed call.
+//
+// Messages only ever gain optional fields, so clients and servers of
+// different releases interoperate.
+package registry
+
+//go:generate protoc --go_out=registrypb --go_opt=paths=source_relative --go-grpc_out=registrypb --go-grpc_opt=paths=source_relative registry.proto
+
+import (
+	"errors"
+	"net/http"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// ServicePath is the path prefix of the registry methods.
+const ServicePath = "/dotprompt.registry.v1.Registry/"
+
+// Registry method names.
+const (
+	MethodList         = "List"
+	MethodListPartials = "ListPartials"
+	MethodLoad         = "Load"
+	MethodLoadPartial  = "LoadPartial"
+	MethodLoadMany     = "LoadMany"
+	MethodSave         = "Save"
+	MethodSavePartial  = "SavePartial"
+	MethodDelete       = "Delete"
+	MethodWatch        = "Watch"
+)
+
+// ListRequest is the request of List and ListPartials.
+type ListRequest struct {
+	Cursor  string `json:"cursor,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+	Variant string `json:"variant,omitempty"`
+	// Prefix, Glob and Tags filter List; see dotprompt.ListPromptsOptions.
+	Prefix string   `json:"prefix,omitempty"`
+	Glob   string   `json:"glob,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// ListResponse is the response of List.
+type ListResponse struct {
+	Prompts []dp.PromptRef `json:"prompts"`
+	Cursor  string         `json:"cursor,omitempty"`
+}
+
+// ListPartialsResponse is the response of ListPartials.
+type ListPartialsResponse struct {
+	Partials []dp.PartialRef `json:"partials"`
+	Cursor   string          `json:"cursor,omitempty"`
+}
+
+// LoadRequest is the request of Load and LoadPartial, which respond with a
+// dotprompt.PromptData and a dotprompt.PartialData.
+type LoadRequest struct {
+	Name    string `json:"name"`
+	Variant string `json:"variant,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// LoadManyRequest is the request of LoadMany.
+type LoadManyRequest struct {
+	Prompts []dp.PromptRef `json:"prompts"`
+}
+
+// LoadManyResponse is the response of LoadMany, with the prompts in the order
+// of the request.
+type LoadManyResponse struct {
+	Prompts []dp.PromptData `json:"prompts"`
+}
+
+// DeleteRequest is the request of Delete. Save and SavePartial take a
+// dotprompt.PromptData and a dotprompt.PartialData. The write methods respond
+// with an empty message.
+type DeleteRequest struct {
+	Name    string `json:"name"`
+	Variant string `json:"variant,omitempty"`
+}
+
+// WatchRequest is the request of Watch.
+type WatchRequest struct{}
+
+// Watch event types.
+const (
+	// EventSync is the first event of every Watch stream.
+	EventSync = "sync"
+	// EventPut reports a prompt or partial that was added or changed.
+	EventPut = "put"
+	// EventDelete reports a prompt or partial that was removed.
+	EventDelete = "delete"
+)
+
+// WatchEvent is a change reported by Watch. Exactly one of Prompt and Partial
+// is set, except for sync events.
+type WatchEvent struct {
+	Type    string         `json:"type"`
+	Prompt  *dp.PromptRef  `json:"prompt,omitempty"`
+	Partial *dp.PartialRef `json:"partial,omitempty"`
+}
+
+// Status codes.
+const (
+	CodeInvalidArgument   = "invalid_argument"
+	CodeNotFound          = "not_found"
+	CodePermissionDenied  = "permission_denied"
+	CodeResourceExhausted = "resource_exhausted"
+	CodeUnimplemented     = "unimplemented"
+	CodeUnknown           = "unknown"
+)
+
+// Status is the response of a failed call.
+type Status struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// httpStatus returns the HTTP status of responses with the given code.
+func httpStatus(code string) int {
+	switch code {
+	case CodeInvalidArgument:
+		return http.StatusBadRequest
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodePermissionDenied:
+		return http.StatusForbidden
+	case CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case CodeUnimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error is a failed call, as returned by Client and GRPCClient. It wraps
+// dotprompt.ErrNotFound for not_found failures and dotprompt.ErrAccessDenied
+// for permission_denied failures.
+type Error struct {
+	Status
+}
+
+func (e *Error) Error() string {
+	return "registry: " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	switch e.Code {
+	case CodeNotFound:
+		return dp.ErrNotFound
+	case CodePermissionDenied:
+		return dp.ErrAccessDenied
+	}
+	return nil
+}
+
+// statusOf returns the Status reported for err.
+func statusOf(err error) Status {
+	var regErr *Error
+	if errors.As(err, &regErr) {
+		return regErr.Status
+	}
+	code := CodeUnknown
+	switch {
+	case errors.Is(err, dp.ErrNotFound):
+		code = CodeNotFound
+	case errors.Is(err, dp.ErrAccessDenied):
+		code = CodePermissionDenied
+	}
+	return Status{Code: code, Message: err.Error()}
+}
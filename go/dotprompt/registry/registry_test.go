@@ -0,0 +1,294 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newTestRegistry serves store and returns a client for it.
+func newTestRegistry(t *testing.T, store dp.PromptStore) (*Server, *Client) {
+	t.Helper()
+	server := NewServer(store)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return server, NewClient(ts.URL, ts.Client())
+}
+
+func newDirStore(t *testing.T) *dp.DirStore {
+	t.Helper()
+	store, err := dp.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	return store
+}
+
+func TestClientConformance(t *testing.T) {
+	storetest.RunConformanceTests(t, func() dp.PromptStore {
+		store := newDirStore(t)
+		store.KeepVersions = true
+		_, client := newTestRegistry(t, store)
+		return client
+	})
+}
+
+func TestClientReadOnlyStore(t *testing.T) {
+	_, client := newTestRegistry(t, struct{ dp.PromptStore }{newDirStore(t)})
+	err := client.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "a"}, Source: "a"})
+	var regErr *Error
+	if !errors.As(err, &regErr) || regErr.Code != CodeUnimplemented {
+		t.Errorf("Save() error = %v, want %s", err, CodeUnimplemented)
+	}
+}
+
+func TestClientAccessDenied(t *testing.T) {
+	store := newDirStore(t)
+	if err := store.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "secret"}, Source: "---\nacl.read: [carol]\n---\nsecret"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	acls := dp.NewACLStore(store, nil)
+	_, client := newTestRegistry(t, acls.For(dp.WithPrincipal(context.Background(), "bob")))
+	if _, err := client.Load("secret", dp.LoadPromptOptions{}); !errors.Is(err, dp.ErrAccessDenied) {
+		t.Errorf("Load() error = %v, want ErrAccessDenied", err)
+	}
+}
+
+func TestClientNotFound(t *testing.T) {
+	_, client := newTestRegistry(t, newDirStore(t))
+	_, err := client.Load("missing", dp.LoadPromptOptions{})
+	var regErr *Error
+	if !errors.Is(err, dp.ErrNotFound) || !errors.As(err, &regErr) || regErr.Code != CodeNotFound {
+		t.Errorf("Load() error = %v, want %s wrapping ErrNotFound", err, CodeNotFound)
+	}
+}
+
+func TestServerRejectsBadRequests(t *testing.T) {
+	server := NewServer(newDirStore(t))
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"get", http.MethodGet, ServicePath + MethodList, "", http.StatusMethodNotAllowed},
+		{"unknown method", http.MethodPost, ServicePath + "Frobnicate", "", http.StatusNotImplemented},
+		{"invalid JSON", http.MethodPost, ServicePath + MethodLoad, "{", http.StatusBadRequest},
+		{"too large", http.MethodPost, ServicePath + MethodLoad, `{"name": "` + strings.Repeat("a", maxRequestSize) + `"}`, http.StatusTooManyRequests},
+		{"not found", http.MethodPost, ServicePath + MethodLoad, `{"name": "missing"}`, http.StatusNotFound},
+		{"other path", http.MethodPost, "/other", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	store := newDirStore(t)
+	if err := store.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "a"}, Source: "a"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	server, client := newTestRegistry(t, store)
+	server.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() returned error: %v", err)
+	}
+	next := func() WatchEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a watch event")
+			return WatchEvent{}
+		}
+	}
+
+	// A change through the registry.
+	if err := client.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "b"}, Source: "b"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	b, err := store.Load("b", dp.LoadPromptOptions{})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	want := WatchEvent{Type: EventPut, Prompt: &dp.PromptRef{Name: "b", Version: b.Version}}
+	if diff := cmp.Diff(want, next()); diff != "" {
+		t.Errorf("event mismatch (-want +got):\n%s", diff)
+	}
+
+	// A change made directly to the store is found by polling.
+	if err := os.Remove(filepath.Join(store.Root, "a.prompt")); err != nil {
+		t.Fatal(err)
+	}
+	want = WatchEvent{Type: EventDelete, Prompt: &dp.PromptRef{Name: "a"}}
+	if diff := cmp.Diff(want, next()); diff != "" {
+		t.Errorf("event mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := client.SavePartial(dp.PartialData{PartialRef: dp.PartialRef{Name: "p"}, Source: "p"}); err != nil {
+		t.Fatalf("SavePartial() returned error: %v", err)
+	}
+	if event := next(); event.Type != EventPut || event.Partial == nil || event.Partial.Name != "p" {
+		t.Errorf("event = %+v, want put of partial p", event)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestClientLoadManyFallsBackToLoad(t *testing.T) {
+	store := newDirStore(t)
+	for _, name := range []string{"a", "b"} {
+		if err := store.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: name}, Source: name}); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	// A server from before LoadMany reports it as an unknown method.
+	server := NewServer(store)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == ServicePath+MethodLoadMany {
+			r.URL.Path = ServicePath + "Unknown"
+		}
+		server.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	client := NewClient(ts.URL, ts.Client())
+
+	prompts, err := client.LoadMany([]dp.PromptRef{{Name: "b"}, {Name: "a"}})
+	if err != nil {
+		t.Fatalf("LoadMany() returned error: %v", err)
+	}
+	if len(prompts) != 2 || prompts[0].Source != "b" || prompts[1].Source != "a" {
+		t.Errorf("LoadMany() = %+v, want prompts b and a", prompts)
+	}
+}
+
+func TestServerReadOnly(t *testing.T) {
+	store := newDirStore(t)
+	if err := store.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "a"}, Source: "a"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	server, client := newTestRegistry(t, store)
+	server.ReadOnly = true
+
+	writes := map[string]func() error{
+		"Save": func() error { return client.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "b"}, Source: "b"}) },
+		"SavePartial": func() error {
+			return client.SavePartial(dp.PartialData{PartialRef: dp.PartialRef{Name: "p"}, Source: "p"})
+		},
+		"Delete": func() error { return client.Delete("a", dp.PromptStoreDeleteOptions{}) },
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, dp.ErrAccessDenied) {
+			t.Errorf("%s() error = %v, want ErrAccessDenied", name, err)
+		}
+	}
+	if _, err := client.Load("a", dp.LoadPromptOptions{}); err != nil {
+		t.Errorf("Load() returned error: %v", err)
+	}
+}
+
+// failingListStore fails to list its prompts.
+type failingListStore struct{ dp.PromptStore }
+
+func (failingListStore) List(dp.ListPromptsOptions) (dp.ListPromptsResult[dp.PromptRef], error) {
+	return dp.ListPromptsResult[dp.PromptRef]{}, errors.New("store is down")
+}
+
+func TestWatchReportsStreamErrors(t *testing.T) {
+	_, client := newTestRegistry(t, failingListStore{newDirStore(t)})
+	_, err := client.Watch(context.Background())
+	var regErr *Error
+	if !errors.As(err, &regErr) || regErr.Code != CodeUnknown || !strings.Contains(regErr.Message, "store is down") {
+		t.Errorf("Watch() error = %v, want %s error from the store", err, CodeUnknown)
+	}
+}
+
+// countingStore counts the calls of List.
+type countingStore struct {
+	dp.WritablePromptStore
+	lists atomic.Int32
+}
+
+func (s *countingStore) List(options dp.ListPromptsOptions) (dp.ListPromptsResult[dp.PromptRef], error) {
+	s.lists.Add(1)
+	return s.WritablePromptStore.List(options)
+}
+
+func TestWatchSharesSnapshots(t *testing.T) {
+	store := &countingStore{WritablePromptStore: newDirStore(t)}
+	server, client := newTestRegistry(t, store)
+	server.PollInterval = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var streams []<-chan WatchEvent
+	for range 3 {
+		events, err := client.Watch(ctx)
+		if err != nil {
+			t.Fatalf("Watch() returned error: %v", err)
+		}
+		streams = append(streams, events)
+	}
+	if got := store.lists.Load(); got != 1 {
+		t.Errorf("store listed %d times for 3 new streams, want 1", got)
+	}
+
+	if err := client.Save(dp.PromptData{PromptRef: dp.PromptRef{Name: "a"}, Source: "a"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	for i, events := range streams {
+		select {
+		case event := <-events:
+			if event.Type != EventPut || event.Prompt == nil || event.Prompt.Name != "a" {
+				t.Errorf("stream %d: event = %+v, want put of prompt a", i, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("stream %d: timed out waiting for an event", i)
+		}
+	}
+	if got := store.lists.Load(); got != 2 {
+		t.Errorf("store listed %d times after one write, want 2", got)
+	}
+}
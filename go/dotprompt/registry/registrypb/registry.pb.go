@@ -0,0 +1,914 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: registry.proto
+
+package registrypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PromptRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Variant       string                 `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromptRef) Reset() {
+	*x = PromptRef{}
+	mi := &file_registry_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromptRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromptRef) ProtoMessage() {}
+
+func (x *PromptRef) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromptRef.ProtoReflect.Descriptor instead.
+func (*PromptRef) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PromptRef) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PromptRef) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *PromptRef) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type PromptData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Variant       string                 `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Source        string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromptData) Reset() {
+	*x = PromptData{}
+	mi := &file_registry_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromptData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromptData) ProtoMessage() {}
+
+func (x *PromptData) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromptData.ProtoReflect.Descriptor instead.
+func (*PromptData) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PromptData) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PromptData) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *PromptData) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PromptData) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type PartialRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Variant       string                 `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartialRef) Reset() {
+	*x = PartialRef{}
+	mi := &file_registry_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartialRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartialRef) ProtoMessage() {}
+
+func (x *PartialRef) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartialRef.ProtoReflect.Descriptor instead.
+func (*PartialRef) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PartialRef) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PartialRef) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *PartialRef) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type PartialData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Variant       string                 `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Source        string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartialData) Reset() {
+	*x = PartialData{}
+	mi := &file_registry_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartialData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartialData) ProtoMessage() {}
+
+func (x *PartialData) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartialData.ProtoReflect.Descriptor instead.
+func (*PartialData) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PartialData) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PartialData) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *PartialData) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PartialData) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type ListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cursor        string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Variant       string                 `protobuf:"bytes,3,opt,name=variant,proto3" json:"variant,omitempty"`
+	Prefix        string                 `protobuf:"bytes,4,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Glob          string                 `protobuf:"bytes,5,opt,name=glob,proto3" json:"glob,omitempty"`
+	Tags          []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_registry_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *ListRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ListRequest) GetGlob() string {
+	if x != nil {
+		return x.Glob
+	}
+	return ""
+}
+
+func (x *ListRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prompts       []*PromptRef           `protobuf:"bytes,1,rep,name=prompts,proto3" json:"prompts,omitempty"`
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_registry_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListResponse) GetPrompts() []*PromptRef {
+	if x != nil {
+		return x.Prompts
+	}
+	return nil
+}
+
+func (x *ListResponse) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type ListPartialsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Partials      []*PartialRef          `protobuf:"bytes,1,rep,name=partials,proto3" json:"partials,omitempty"`
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPartialsResponse) Reset() {
+	*x = ListPartialsResponse{}
+	mi := &file_registry_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPartialsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPartialsResponse) ProtoMessage() {}
+
+func (x *ListPartialsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPartialsResponse.ProtoReflect.Descriptor instead.
+func (*ListPartialsResponse) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListPartialsResponse) GetPartials() []*PartialRef {
+	if x != nil {
+		return x.Partials
+	}
+	return nil
+}
+
+func (x *ListPartialsResponse) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type LoadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Variant       string                 `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadRequest) Reset() {
+	*x = LoadRequest{}
+	mi := &file_registry_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadRequest) ProtoMessage() {}
+
+func (x *LoadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadRequest.ProtoReflect.Descriptor instead.
+func (*LoadRequest) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LoadRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LoadRequest) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *LoadRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type LoadManyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prompts       []*PromptRef           `protobuf:"bytes,1,rep,name=prompts,proto3" json:"prompts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadManyRequest) Reset() {
+	*x = LoadManyRequest{}
+	mi := &file_registry_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadManyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadManyRequest) ProtoMessage() {}
+
+func (x *LoadManyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadManyRequest.ProtoReflect.Descriptor instead.
+func (*LoadManyRequest) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LoadManyRequest) GetPrompts() []*PromptRef {
+	if x != nil {
+		return x.Prompts
+	}
+	return nil
+}
+
+type LoadManyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prompts       []*PromptData          `protobuf:"bytes,1,rep,name=prompts,proto3" json:"prompts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadManyResponse) Reset() {
+	*x = LoadManyResponse{}
+	mi := &file_registry_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadManyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadManyResponse) ProtoMessage() {}
+
+func (x *LoadManyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadManyResponse.ProtoReflect.Descriptor instead.
+func (*LoadManyResponse) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *LoadManyResponse) GetPrompts() []*PromptData {
+	if x != nil {
+		return x.Prompts
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Variant       string                 `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_registry_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_registry_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{11}
+}
+
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Prompt        *PromptRef             `protobuf:"bytes,2,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	Partial       *PartialRef            `protobuf:"bytes,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_registry_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_registry_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetPrompt() *PromptRef {
+	if x != nil {
+		return x.Prompt
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetPartial() *PartialRef {
+	if x != nil {
+		return x.Partial
+	}
+	return nil
+}
+
+var File_registry_proto protoreflect.FileDescriptor
+
+const file_registry_proto_rawDesc = "" +
+	"\n" +
+	"\x0eregistry.proto\x12\x15dotprompt.registry.v1\x1a\x1bgoogle/protobuf/empty.proto\"S\n" +
+	"\tPromptRef\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\avariant\x18\x02 \x01(\tR\avariant\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\"l\n" +
+	"\n" +
+	"PromptData\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\avariant\x18\x02 \x01(\tR\avariant\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\"T\n" +
+	"\n" +
+	"PartialRef\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\avariant\x18\x02 \x01(\tR\avariant\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\"m\n" +
+	"\vPartialData\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\avariant\x18\x02 \x01(\tR\avariant\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\"\x95\x01\n" +
+	"\vListRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x18\n" +
+	"\avariant\x18\x03 \x01(\tR\avariant\x12\x16\n" +
+	"\x06prefix\x18\x04 \x01(\tR\x06prefix\x12\x12\n" +
+	"\x04glob\x18\x05 \x01(\tR\x04glob\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\"b\n" +
+	"\fListResponse\x12:\n" +
+	"\aprompts\x18\x01 \x03(\v2 .dotprompt.registry.v1.PromptRefR\aprompts\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\"m\n" +
+	"\x14ListPartialsResponse\x12=\n" +
+	"\bpartials\x18\x01 \x03(\v2!.dotprompt.registry.v1.PartialRefR\bpartials\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\"U\n" +
+	"\vLoadRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\avariant\x18\x02 \x01(\tR\avariant\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\"M\n" +
+	"\x0fLoadManyRequest\x12:\n" +
+	"\aprompts\x18\x01 \x03(\v2 .dotprompt.registry.v1.PromptRefR\aprompts\"O\n" +
+	"\x10LoadManyResponse\x12;\n" +
+	"\aprompts\x18\x01 \x03(\v2!.dotprompt.registry.v1.PromptDataR\aprompts\"=\n" +
+	"\rDeleteRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\avariant\x18\x02 \x01(\tR\avariant\"\x0e\n" +
+	"\fWatchRequest\"\x97\x01\n" +
+	"\n" +
+	"WatchEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x128\n" +
+	"\x06prompt\x18\x02 \x01(\v2 .dotprompt.registry.v1.PromptRefR\x06prompt\x12;\n" +
+	"\apartial\x18\x03 \x01(\v2!.dotprompt.registry.v1.PartialRefR\apartial2\xfa\x05\n" +
+	"\bRegistry\x12Q\n" +
+	"\x04List\x12\".dotprompt.registry.v1.ListRequest\x1a#.dotprompt.registry.v1.ListResponse\"\x00\x12a\n" +
+	"\fListPartials\x12\".dotprompt.registry.v1.ListRequest\x1a+.dotprompt.registry.v1.ListPartialsResponse\"\x00\x12O\n" +
+	"\x04Load\x12\".dotprompt.registry.v1.LoadRequest\x1a!.dotprompt.registry.v1.PromptData\"\x00\x12W\n" +
+	"\vLoadPartial\x12\".dotprompt.registry.v1.LoadRequest\x1a\".dotprompt.registry.v1.PartialData\"\x00\x12]\n" +
+	"\bLoadMany\x12&.dotprompt.registry.v1.LoadManyRequest\x1a'.dotprompt.registry.v1.LoadManyResponse\"\x00\x12C\n" +
+	"\x04Save\x12!.dotprompt.registry.v1.PromptData\x1a\x16.google.protobuf.Empty\"\x00\x12K\n" +
+	"\vSavePartial\x12\".dotprompt.registry.v1.PartialData\x1a\x16.google.protobuf.Empty\"\x00\x12H\n" +
+	"\x06Delete\x12$.dotprompt.registry.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\"\x00\x12S\n" +
+	"\x05Watch\x12#.dotprompt.registry.v1.WatchRequest\x1a!.dotprompt.registry.v1.WatchEvent\"\x000\x01B>Z<github.com/google/dotprompt/go/dotprompt/registry/registrypbb\x06proto3"
+
+var (
+	file_registry_proto_rawDescOnce sync.Once
+	file_registry_proto_rawDescData []byte
+)
+
+func file_registry_proto_rawDescGZIP() []byte {
+	file_registry_proto_rawDescOnce.Do(func() {
+		file_registry_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_registry_proto_rawDesc), len(file_registry_proto_rawDesc)))
+	})
+	return file_registry_proto_rawDescData
+}
+
+var file_registry_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_registry_proto_goTypes = []any{
+	(*PromptRef)(nil),            // 0: dotprompt.registry.v1.PromptRef
+	(*PromptData)(nil),           // 1: dotprompt.registry.v1.PromptData
+	(*PartialRef)(nil),           // 2: dotprompt.registry.v1.PartialRef
+	(*PartialData)(nil),          // 3: dotprompt.registry.v1.PartialData
+	(*ListRequest)(nil),          // 4: dotprompt.registry.v1.ListRequest
+	(*ListResponse)(nil),         // 5: dotprompt.registry.v1.ListResponse
+	(*ListPartialsResponse)(nil), // 6: dotprompt.registry.v1.ListPartialsResponse
+	(*LoadRequest)(nil),          // 7: dotprompt.registry.v1.LoadRequest
+	(*LoadManyRequest)(nil),      // 8: dotprompt.registry.v1.LoadManyRequest
+	(*LoadManyResponse)(nil),     // 9: dotprompt.registry.v1.LoadManyResponse
+	(*DeleteRequest)(nil),        // 10: dotprompt.registry.v1.DeleteRequest
+	(*WatchRequest)(nil),         // 11: dotprompt.registry.v1.WatchRequest
+	(*WatchEvent)(nil),           // 12: dotprompt.registry.v1.WatchEvent
+	(*emptypb.Empty)(nil),        // 13: google.protobuf.Empty
+}
+var file_registry_proto_depIdxs = []int32{
+	0,  // 0: dotprompt.registry.v1.ListResponse.prompts:type_name -> dotprompt.registry.v1.PromptRef
+	2,  // 1: dotprompt.registry.v1.ListPartialsResponse.partials:type_name -> dotprompt.registry.v1.PartialRef
+	0,  // 2: dotprompt.registry.v1.LoadManyRequest.prompts:type_name -> dotprompt.registry.v1.PromptRef
+	1,  // 3: dotprompt.registry.v1.LoadManyResponse.prompts:type_name -> dotprompt.registry.v1.PromptData
+	0,  // 4: dotprompt.registry.v1.WatchEvent.prompt:type_name -> dotprompt.registry.v1.PromptRef
+	2,  // 5: dotprompt.registry.v1.WatchEvent.partial:type_name -> dotprompt.registry.v1.PartialRef
+	4,  // 6: dotprompt.registry.v1.Registry.List:input_type -> dotprompt.registry.v1.ListRequest
+	4,  // 7: dotprompt.registry.v1.Registry.ListPartials:input_type -> dotprompt.registry.v1.ListRequest
+	7,  // 8: dotprompt.registry.v1.Registry.Load:input_type -> dotprompt.registry.v1.LoadRequest
+	7,  // 9: dotprompt.registry.v1.Registry.LoadPartial:input_type -> dotprompt.registry.v1.LoadRequest
+	8,  // 10: dotprompt.registry.v1.Registry.LoadMany:input_type -> dotprompt.registry.v1.LoadManyRequest
+	1,  // 11: dotprompt.registry.v1.Registry.Save:input_type -> dotprompt.registry.v1.PromptData
+	3,  // 12: dotprompt.registry.v1.Registry.SavePartial:input_type -> dotprompt.registry.v1.PartialData
+	10, // 13: dotprompt.registry.v1.Registry.Delete:input_type -> dotprompt.registry.v1.DeleteRequest
+	11, // 14: dotprompt.registry.v1.Registry.Watch:input_type -> dotprompt.registry.v1.WatchRequest
+	5,  // 15: dotprompt.registry.v1.Registry.List:output_type -> dotprompt.registry.v1.ListResponse
+	6,  // 16: dotprompt.registry.v1.Registry.ListPartials:output_type -> dotprompt.registry.v1.ListPartialsResponse
+	1,  // 17: dotprompt.registry.v1.Registry.Load:output_type -> dotprompt.registry.v1.PromptData
+	3,  // 18: dotprompt.registry.v1.Registry.LoadPartial:output_type -> dotprompt.registry.v1.PartialData
+	9,  // 19: dotprompt.registry.v1.Registry.LoadMany:output_type -> dotprompt.registry.v1.LoadManyResponse
+	13, // 20: dotprompt.registry.v1.Registry.Save:output_type -> google.protobuf.Empty
+	13, // 21: dotprompt.registry.v1.Registry.SavePartial:output_type -> google.protobuf.Empty
+	13, // 22: dotprompt.registry.v1.Registry.Delete:output_type -> google.protobuf.Empty
+	12, // 23: dotprompt.registry.v1.Registry.Watch:output_type -> dotprompt.registry.v1.WatchEvent
+	15, // [15:24] is the sub-list for method output_type
+	6,  // [6:15] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_registry_proto_init() }
+func file_registry_proto_init() {
+	if File_registry_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_registry_proto_rawDesc), len(file_registry_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_registry_proto_goTypes,
+		DependencyIndexes: file_registry_proto_depIdxs,
+		MessageInfos:      file_registry_proto_msgTypes,
+	}.Build()
+	File_registry_proto = out.File
+	file_registry_proto_goTypes = nil
+	file_registry_proto_depIdxs = nil
+}
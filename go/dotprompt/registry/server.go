@@ -0,0 +1,546 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	"google.golang.org/grpc"
+)
+
+// DefaultPollInterval is the default Server.PollInterval.
+const DefaultPollInterval = 2 * time.Second
+
+// Server serves a PromptStore with the registry protocol. The write methods
+// are unimplemented unless the store is a dotprompt.WritablePromptStore, and
+// SavePartial unless it is also a dotprompt.PartialWriter.
+//
+// Server does not authenticate callers. Serve it behind an authenticating
+// handler, or set ReadOnly, wherever untrusted clients can reach it.
+type Server struct {
+	store dp.PromptStore
+	// PollInterval is how often Watch streams check the store for changes
+	// made by other writers. Changes made through the server are reported
+	// immediately. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// ReadOnly rejects Save, SavePartial and Delete with permission_denied,
+	// whatever the store.
+	ReadOnly bool
+
+	// mu guards changed, which is closed and replaced on every write, and
+	// generation, which counts the writes.
+	mu         sync.Mutex
+	changed    chan struct{}
+	generation uint64
+
+	// snapMu guards the snapshot shared by the Watch streams, taken at
+	// snapTime after snapGeneration writes.
+	snapMu         sync.Mutex
+	snap           map[entryKey]string
+	snapTime       time.Time
+	snapGeneration uint64
+
+	// grpc serves the gRPC calls, once grpcOnce has created it.
+	grpcOnce sync.Once
+	grpc     *grpc.Server
+}
+
+// NewServer returns a Server for store.
+func NewServer(store dp.PromptStore) *Server {
+	return &Server{store: store, changed: make(chan struct{})}
+}
+
+// maxRequestSize bounds the bodies of requests.
+const maxRequestSize = 4 << 20
+
+// ServeHTTP implements http.Handler. gRPC calls are served by a grpc.Server
+// with the service registered by RegisterGRPC.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isGRPC(r) {
+		s.grpcServer().ServeHTTP(w, r)
+		return
+	}
+	method, ok := strings.CutPrefix(r.URL.Path, ServicePath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+	if method == MethodWatch {
+		s.serveWatch(w, r)
+		return
+	}
+
+	resp, err := s.call(method, func(req any) error { return decode(r, req) })
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// call makes the unary call of method, decoding its request with decode,
+// which is passed a pointer to the request message.
+func (s *Server) call(method string, decode func(req any) error) (any, error) {
+	switch method {
+	case MethodList:
+		var req ListRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.list(req)
+	case MethodListPartials:
+		var req ListRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.listPartials(req)
+	case MethodLoad:
+		var req LoadRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.store.Load(req.Name, dp.LoadPromptOptions{Variant: req.Variant, Version: req.Version})
+	case MethodLoadPartial:
+		var req LoadRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.store.LoadPartial(req.Name, dp.LoadPartialOptions{Variant: req.Variant, Version: req.Version})
+	case MethodLoadMany:
+		var req LoadManyRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		prompts, err := dp.LoadMany(s.store, req.Prompts)
+		if err != nil {
+			return nil, err
+		}
+		return LoadManyResponse{Prompts: nonNil(prompts)}, nil
+	case MethodSave:
+		var req dp.PromptData
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.write(func(store dp.WritablePromptStore) error { return store.Save(req) })
+	case MethodSavePartial:
+		var req dp.PartialData
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.savePartial(req)
+	case MethodDelete:
+		var req DeleteRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.write(func(store dp.WritablePromptStore) error {
+			return store.Delete(req.Name, dp.PromptStoreDeleteOptions{Variant: req.Variant})
+		})
+	default:
+		return nil, &Error{Status{Code: CodeUnimplemented, Message: "unknown method " + method}}
+	}
+}
+
+// decode decodes the JSON request of r into req. An empty body is an empty
+// message.
+func decode(r *http.Request, req any) error {
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return invalidRequest(err)
+	}
+	return nil
+}
+
+// invalidRequest returns the error of a request that could not be read.
+func invalidRequest(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &Error{Status{Code: CodeResourceExhausted, Message: "request too large"}}
+	}
+	return &Error{Status{Code: CodeInvalidArgument, Message: "invalid request: " + err.Error()}}
+}
+
+// writeError writes the Status of err.
+func writeError(w http.ResponseWriter, err error) {
+	status := statusOf(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(status.Code))
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) list(req ListRequest) (ListResponse, error) {
+	result, err := s.store.List(dp.ListPromptsOptions{
+		Cursor:  req.Cursor,
+		Limit:   req.Limit,
+		Variant: req.Variant,
+		Prefix:  req.Prefix,
+		Glob:    req.Glob,
+		Tags:    req.Tags,
+	})
+	if err != nil {
+		return ListResponse{}, err
+	}
+	return ListResponse{Prompts: nonNil(result.Items), Cursor: result.Cursor}, nil
+}
+
+func (s *Server) listPartials(req ListRequest) (ListPartialsResponse, error) {
+	result, err := s.store.ListPartials(dp.ListPartialsOptions{Cursor: req.Cursor, Limit: req.Limit, Variant: req.Variant})
+	if err != nil {
+		return ListPartialsResponse{}, err
+	}
+	return ListPartialsResponse{Partials: nonNil(result.Items), Cursor: result.Cursor}, nil
+}
+
+// nonNil returns items, or an empty slice if it is nil, so that lists are
+// encoded as arrays.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// errReadOnly is returned by the write methods of a ReadOnly server.
+var errReadOnly = &Error{Status{Code: CodePermissionDenied, Message: "registry is read-only"}}
+
+// write calls fn with the store if it is writable and notifies the Watch
+// streams.
+func (s *Server) write(fn func(store dp.WritablePromptStore) error) (struct{}, error) {
+	if s.ReadOnly {
+		return struct{}{}, errReadOnly
+	}
+	store, ok := s.store.(dp.WritablePromptStore)
+	if !ok {
+		return struct{}{}, &Error{Status{Code: CodeUnimplemented, Message: "store is read-only"}}
+	}
+	defer s.notify()
+	return struct{}{}, fn(store)
+}
+
+func (s *Server) savePartial(partial dp.PartialData) (struct{}, error) {
+	if s.ReadOnly {
+		return struct{}{}, errReadOnly
+	}
+	store, ok := s.store.(dp.PartialWriter)
+	if !ok {
+		return struct{}{}, &Error{Status{Code: CodeUnimplemented, Message: "store cannot save partials"}}
+	}
+	defer s.notify()
+	return struct{}{}, store.SavePartial(partial)
+}
+
+// notify wakes the Watch streams.
+func (s *Server) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.generation++
+}
+
+// changes returns a channel that is closed on the next write.
+func (s *Server) changes() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// serveWatch serves a Connect Watch call.
+func (s *Server) serveWatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/connect+json")
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeEndStream(w, &Error{Status{Code: CodeUnimplemented, Message: "streaming is not supported"}})
+		return
+	}
+	var req WatchRequest
+	if _, err := readEnvelope(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeEndStream(w, invalidRequest(err))
+		return
+	}
+	err := s.watch(r.Context(), func(event WatchEvent) error {
+		if err := writeEnvelope(w, 0, event); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		writeEndStream(w, err)
+	}
+}
+
+// watch calls send with the changes to the store until ctx is done:
+// WatchEvents, starting with a sync event. It returns the error of a failed
+// call, or that of send.
+func (s *Server) watch(ctx context.Context, send func(WatchEvent) error) error {
+	changed := s.changes()
+	state, err := s.sharedSnapshot()
+	if err != nil {
+		return err
+	}
+	if err := send(WatchEvent{Type: EventSync}); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-ticker.C:
+		}
+		changed = s.changes()
+		next, err := s.sharedSnapshot()
+		if err != nil {
+			// The error is not worth ending the stream for; the store is
+			// checked again on the next change or tick.
+			continue
+		}
+		for _, event := range diffSnapshots(state, next) {
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		state = next
+	}
+}
+
+// flagEndStream marks the Connect envelope that ends a stream.
+const flagEndStream = 0x02
+
+// writeEnvelope writes msg as a Connect envelope with the given flags: a
+// flags byte, the big-endian length of the JSON message, and the message.
+func writeEnvelope(w io.Writer, flags byte, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return writeEnvelopeData(w, flags, data)
+}
+
+// writeEnvelopeData writes data as an envelope with the given flags.
+func writeEnvelopeData(w io.Writer, flags byte, data []byte) error {
+	header := make([]byte, 5)
+	header[0] = flags
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// maxEnvelopeSize bounds the messages read from streams.
+const maxEnvelopeSize = 4 << 20
+
+// readEnvelope reads a Connect envelope from r, decoding its message into
+// msg, and returns its flags. It returns io.EOF at the end of r.
+func readEnvelope(r io.Reader, msg any) (byte, error) {
+	flags, data, err := readEnvelopeData(r)
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return 0, err
+	}
+	return flags, nil
+}
+
+// readEnvelopeData reads an envelope from r and returns its flags and
+// message. It returns io.EOF at the end of r.
+func readEnvelopeData(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, errors.New("truncated message")
+		}
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxEnvelopeSize {
+		return 0, nil, errors.New("message too large")
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return 0, nil, err
+		}
+		return 0, nil, errors.New("truncated message")
+	}
+	return header[0], data, nil
+}
+
+// endStream is the message of the last envelope of a stream.
+type endStream struct {
+	Error *Status `json:"error,omitempty"`
+}
+
+// writeEndStream ends a stream, with the Status of err if it is not nil.
+func writeEndStream(w io.Writer, err error) {
+	var end endStream
+	if err != nil {
+		status := statusOf(err)
+		end.Error = &status
+	}
+	writeEnvelope(w, flagEndStream, end)
+}
+
+func (s *Server) pollInterval() time.Duration {
+	if s.PollInterval <= 0 {
+		return DefaultPollInterval
+	}
+	return s.PollInterval
+}
+
+// sharedSnapshot returns a snapshot of the store shared by the Watch
+// streams, so that the store is read at most once per poll interval and
+// write however many clients watch it. The snapshot must not be modified.
+func (s *Server) sharedSnapshot() (map[entryKey]string, error) {
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	s.snapMu.Lock()
+	defer s.snapMu.Unlock()
+	if s.snap != nil && s.snapGeneration == generation && time.Since(s.snapTime) < s.pollInterval() {
+		return s.snap, nil
+	}
+	snap, err := s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	s.snap, s.snapTime, s.snapGeneration = snap, time.Now(), generation
+	return snap, nil
+}
+
+// entryKey identifies a prompt or partial in a snapshot.
+type entryKey struct {
+	partial       bool
+	name, variant string
+}
+
+// snapshot returns the versions of all prompts and partials in the store.
+// Items are only loaded when the store does not list their version.
+func (s *Server) snapshot() (map[entryKey]string, error) {
+	state := make(map[entryKey]string)
+	for cursor := ""; ; {
+		result, err := s.store.List(dp.ListPromptsOptions{Cursor: cursor})
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range result.Items {
+			version := ref.Version
+			if version == "" {
+				prompt, err := s.store.Load(ref.Name, dp.LoadPromptOptions{Variant: ref.Variant})
+				if err != nil {
+					return nil, err
+				}
+				version = prompt.Version
+			}
+			state[entryKey{name: ref.Name, variant: ref.Variant}] = version
+		}
+		if result.Cursor == "" || result.Cursor == cursor {
+			break
+		}
+		cursor = result.Cursor
+	}
+	for cursor := ""; ; {
+		result, err := s.store.ListPartials(dp.ListPartialsOptions{Cursor: cursor})
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range result.Items {
+			version := ref.Version
+			if version == "" {
+				partial, err := s.store.LoadPartial(ref.Name, dp.LoadPartialOptions{Variant: ref.Variant})
+				if err != nil {
+					return nil, err
+				}
+				version = partial.Version
+			}
+			state[entryKey{partial: true, name: ref.Name, variant: ref.Variant}] = version
+		}
+		if result.Cursor == "" || result.Cursor == cursor {
+			break
+		}
+		cursor = result.Cursor
+	}
+	return state, nil
+}
+
+// diffSnapshots returns the events that turn old into next, ordered by kind,
+// name and variant.
+func diffSnapshots(old, next map[entryKey]string) []WatchEvent {
+	var keys []entryKey
+	for key, version := range next {
+		if old[key] != version {
+			keys = append(keys, key)
+		}
+	}
+	for key := range old {
+		if _, ok := next[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.partial != b.partial {
+			return !a.partial
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.variant < b.variant
+	})
+
+	events := make([]WatchEvent, 0, len(keys))
+	for _, key := range keys {
+		event := WatchEvent{Type: EventPut}
+		version, ok := next[key]
+		if !ok {
+			event.Type = EventDelete
+		}
+		if key.partial {
+			event.Partial = &dp.PartialRef{Name: key.name, Variant: key.variant, Version: version}
+		} else {
+			event.Prompt = &dp.PromptRef{Name: key.name, Variant: key.variant, Version: version}
+		}
+		events = append(events, event)
+	}
+	return events
+}
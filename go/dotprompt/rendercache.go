@@ -0,0 +1,188 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRenderCacheEntries is the default RenderCacheOptions.MaxEntries.
+const DefaultRenderCacheEntries = 1000
+
+// RenderCacheOptions configures the render cache set with WithRenderCache.
+type RenderCacheOptions struct {
+	// MaxEntries is the maximum number of cached renders, beyond which the
+	// least recently used is evicted. It defaults to
+	// DefaultRenderCacheEntries.
+	MaxEntries int
+	// TTL is how long a render stays cached. Zero keeps renders until they
+	// are evicted.
+	TTL time.Duration
+}
+
+// renderCache holds the prompts rendered for the most recently used requests,
+// keyed by the version of the prompt and a hash of the request. It is safe
+// for concurrent use.
+type renderCache struct {
+	options RenderCacheOptions
+	now     func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // of *renderCacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// renderCacheEntry is an element of renderCache.order.
+type renderCacheEntry struct {
+	key      string
+	rendered RenderedPrompt
+	expires  time.Time
+}
+
+// newRenderCache returns a render cache configured by options.
+func newRenderCache(options RenderCacheOptions) *renderCache {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = DefaultRenderCacheEntries
+	}
+	return &renderCache{
+		options: options,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// renderScopes numbers the render scopes of Dotprompt instances. Instances
+// in the same scope render compiled prompts the same way, and so share the
+// entries of a render cache.
+var renderScopes atomic.Uint64
+
+// newRenderScope moves dp to a scope of its own, for a setting changing how it
+// renders prompts, such as its default model or model configs.
+func (dp *Dotprompt) newRenderScope() {
+	dp.renderScope = renderScopes.Add(1)
+}
+
+// renderVersion returns the version of the prompt source compiled with
+// additionalMetadata and partials, which covers everything the compiled prompt
+// renders from, so that recompiling after a partial changes does not hit
+// stale renders. It returns "" if the prompt cannot be encoded.
+func renderVersion(source string, additionalMetadata *PromptMetadata, partials map[string]string) string {
+	prompt, err := json.Marshal(struct {
+		Source   string            `json:"source"`
+		Metadata *PromptMetadata   `json:"metadata"`
+		Partials map[string]string `json:"partials"`
+	}{source, additionalMetadata, partials})
+	if err != nil {
+		return ""
+	}
+	return calculateVersion(string(prompt))
+}
+
+// wrap returns render, the render function of the prompt of the given
+// renderVersion, reading through the cache. Renders are keyed by the render
+// scope that scope returns when rendering, so that instances sharing the
+// cache do not see each other's renders once their settings differ. Requests
+// that cannot be encoded as JSON, such as those holding functions, are not
+// cached, nor are prompts without a version.
+func (c *renderCache) wrap(scope func() uint64, version string, render RenderFunc) RenderFunc {
+	if version == "" {
+		return render
+	}
+	return func(data *DataArgument, options *PromptMetadata) (RenderedPrompt, error) {
+		key, ok := renderCacheKey(strconv.FormatUint(scope(), 10)+":"+version, data, options)
+		if !ok {
+			return render(data, options)
+		}
+		if rendered, ok := c.get(key); ok {
+			return rendered, nil
+		}
+		rendered, err := render(data, options)
+		if err != nil {
+			return RenderedPrompt{}, err
+		}
+		c.put(key, rendered)
+		return rendered, nil
+	}
+}
+
+// renderCacheKey returns the cache key of rendering the prompt with version
+// with data and options. Maps are encoded with sorted keys, so equal inputs
+// have equal keys.
+func renderCacheKey(version string, data *DataArgument, options *PromptMetadata) (string, bool) {
+	request, err := json.Marshal(struct {
+		Data    *DataArgument   `json:"data"`
+		Options *PromptMetadata `json:"options"`
+	}{data, options})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(request)
+	return version + ":" + hex.EncodeToString(sum[:]), true
+}
+
+// get returns the prompt rendered for key, if it is cached and has not
+// expired. The returned prompt has its own Messages slice, but shares the
+// parts of the cached one.
+func (c *renderCache) get(key string) (RenderedPrompt, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return RenderedPrompt{}, false
+	}
+	entry := e.Value.(*renderCacheEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return RenderedPrompt{}, false
+	}
+	c.order.MoveToFront(e)
+	rendered := entry.rendered
+	rendered.Messages = slices.Clone(rendered.Messages)
+	return rendered, true
+}
+
+// put caches rendered for key, evicting the least recently used entry if the
+// cache is full.
+func (c *renderCache) put(key string, rendered RenderedPrompt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &renderCacheEntry{key: key, rendered: rendered}
+	if c.options.TTL > 0 {
+		entry.expires = c.now().Add(c.options.TTL)
+	}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.options.MaxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*renderCacheEntry).key)
+	}
+}
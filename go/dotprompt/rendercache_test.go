@@ -0,0 +1,219 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRenderCache(t *testing.T) {
+	calls := 0
+	count := func(s string) string {
+		calls++
+		return s
+	}
+	newDotprompt := func(options RenderCacheOptions) (*Dotprompt, *time.Time) {
+		calls = 0
+		dp := New(WithHelpers(map[string]any{"count": count}), WithRenderCache(options))
+		now := time.Unix(0, 0)
+		dp.renderCache.now = func() time.Time { return now }
+		return dp, &now
+	}
+	render := func(t *testing.T, dp *Dotprompt, source string, data *DataArgument) string {
+		t.Helper()
+		rendered, err := dp.Render(source, data, nil)
+		if err != nil {
+			t.Fatalf("Render() returned error: %v", err)
+		}
+		return rendered.Messages[0].Content[0].(*TextPart).Text
+	}
+	input := func(name string) *DataArgument {
+		return &DataArgument{Input: map[string]any{"name": name, "n": 1}}
+	}
+	const source = "Hi {{count name}}"
+
+	t.Run("hits", func(t *testing.T) {
+		dp, _ := newDotprompt(RenderCacheOptions{})
+		for range 3 {
+			if got := render(t, dp, source, input("Ada")); got != "Hi Ada" {
+				t.Errorf("Render() = %q, want %q", got, "Hi Ada")
+			}
+		}
+		render(t, dp, source, input("Bob"))
+		render(t, dp, "Hello {{count name}}", input("Ada"))
+		if calls != 3 {
+			t.Errorf("helper called %d times, want 3", calls)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		dp, now := newDotprompt(RenderCacheOptions{TTL: time.Minute})
+		render(t, dp, source, input("Ada"))
+		*now = now.Add(59 * time.Second)
+		render(t, dp, source, input("Ada"))
+		*now = now.Add(time.Second)
+		render(t, dp, source, input("Ada"))
+		if calls != 2 {
+			t.Errorf("helper called %d times, want 2", calls)
+		}
+	})
+
+	t.Run("max entries", func(t *testing.T) {
+		dp, _ := newDotprompt(RenderCacheOptions{MaxEntries: 1})
+		for _, name := range []string{"Ada", "Bob", "Ada"} {
+			render(t, dp, source, input(name))
+		}
+		if calls != 3 {
+			t.Errorf("helper called %d times, want 3", calls)
+		}
+	})
+
+	t.Run("uncacheable input", func(t *testing.T) {
+		dp, _ := newDotprompt(RenderCacheOptions{})
+		data := &DataArgument{Input: map[string]any{"name": "Ada"}, Context: map[string]any{"fn": func() {}}}
+		render(t, dp, source, data)
+		render(t, dp, source, data)
+		if calls != 2 {
+			t.Errorf("helper called %d times, want 2", calls)
+		}
+	})
+
+	t.Run("uncacheable instance", func(t *testing.T) {
+		tests := []struct {
+			name string
+			opt  Option
+		}{
+			{"flags", WithFlags(FlagFunc(func(string, map[string]any) (bool, error) { return false, nil }))},
+			{"env", WithEnvAllowlist("HOME")},
+			{"secrets", WithSecretResolver(func(ref string) (string, error) { return ref, nil })},
+			{"include", WithInclude(fstest.MapFS{}, 0)},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				dp, _ := newDotprompt(RenderCacheOptions{})
+				dp = dp.With(tt.opt)
+				render(t, dp, source, input("Ada"))
+				render(t, dp, source, input("Ada"))
+				if calls != 2 {
+					t.Errorf("helper called %d times, want 2", calls)
+				}
+			})
+		}
+	})
+
+	t.Run("media changed", func(t *testing.T) {
+		media := fstest.MapFS{"a.txt": {Data: []byte("one")}}
+		dp := NewDotprompt(&DotpromptOptions{MediaFS: media}).With(WithRenderCache(RenderCacheOptions{}))
+		const source = `{{media url="a.txt"}}`
+		mediaURL := func() string {
+			t.Helper()
+			rendered, err := dp.Render(source, &DataArgument{}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			for _, part := range rendered.Messages[0].Content {
+				if media, ok := part.(*MediaPart); ok {
+					return media.Media.URL
+				}
+			}
+			t.Fatalf("Render() = %+v, want a media part", rendered.Messages[0].Content)
+			return ""
+		}
+		mediaURL()
+		media["a.txt"] = &fstest.MapFile{Data: []byte("two")}
+		if got, want := mediaURL(), "data:text/plain;base64,dHdv"; got != want {
+			t.Errorf("media URL after the file changed = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("partial changed", func(t *testing.T) {
+		dp, _ := newDotprompt(RenderCacheOptions{})
+		dp.Partials["greeting"] = "Hi"
+		if got := render(t, dp, "{{> greeting}}", input("Ada")); got != "Hi" {
+			t.Errorf("Render() = %q, want %q", got, "Hi")
+		}
+		dp.Partials["greeting"] = "Hello"
+		if got := render(t, dp, "{{> greeting}}", input("Ada")); got != "Hello" {
+			t.Errorf("Render() after changing the partial = %q, want %q", got, "Hello")
+		}
+	})
+
+	t.Run("shared by clones", func(t *testing.T) {
+		dp, _ := newDotprompt(RenderCacheOptions{})
+		for range 3 {
+			render(t, dp.Clone(), source, input("Ada"))
+		}
+		render(t, dp.With(), source, input("Ada"))
+		if calls != 1 {
+			t.Errorf("helper called %d times, want 1", calls)
+		}
+		render(t, dp.With(WithHelpers(map[string]any{"other": count})), source, input("Ada"))
+		if calls != 2 {
+			t.Errorf("helper called %d times after changing helpers, want 2", calls)
+		}
+	})
+
+	t.Run("settings changed", func(t *testing.T) {
+		dp, _ := newDotprompt(RenderCacheOptions{})
+		temperature := func(t *testing.T, dp *Dotprompt) any {
+			t.Helper()
+			rendered, err := dp.Render(source, input("Ada"), nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			return rendered.Config["temperature"]
+		}
+		dp = dp.With(
+			WithModelConfig("test/a", map[string]any{"temperature": 0.1}),
+			WithModelConfig("test/b", map[string]any{"temperature": 0.2}),
+			WithDefaultModel("test/a"),
+		)
+		if got := temperature(t, dp); got != 0.1 {
+			t.Errorf("temperature = %v, want 0.1", got)
+		}
+		if got := temperature(t, dp.With(WithDefaultModel("test/b"))); got != 0.2 {
+			t.Errorf("temperature after changing the default model = %v, want 0.2", got)
+		}
+		dp.DefineModelConfig("test/*", map[string]any{"temperature": 0.5})
+		if got := temperature(t, dp); got != 0.5 {
+			t.Errorf("temperature after DefineModelConfig = %v, want 0.5", got)
+		}
+		clone := dp.Clone()
+		clone.DefineModelConfig("test/*", map[string]any{"temperature": 0.9})
+		if got := temperature(t, clone); got != 0.9 {
+			t.Errorf("temperature of the clone = %v, want 0.9", got)
+		}
+		if got := temperature(t, dp); got != 0.5 {
+			t.Errorf("temperature after configuring the clone = %v, want 0.5", got)
+		}
+	})
+
+	t.Run("dynamic partial", func(t *testing.T) {
+		partials := map[string]string{"layout": "Hi"}
+		dp, _ := newDotprompt(RenderCacheOptions{})
+		dp.partialResolver = func(name string) (string, error) { return partials[name], nil }
+		const source = `{{> (lookup . "layout")}} {{count name}}`
+		data := &DataArgument{Input: map[string]any{"name": "Ada", "layout": "layout"}}
+		render(t, dp, source, data)
+		render(t, dp, source, data)
+		if calls != 2 {
+			t.Errorf("helper called %d times, want 2", calls)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// resilienceExtNamespace is the ext namespace of ResiliencePolicy.
+const resilienceExtNamespace = "resilience"
+
+// defaultBackoffMultiplier is the growth of backoff delays when the
+// frontmatter does not set one.
+const defaultBackoffMultiplier = 2
+
+// ResiliencePolicy is the degradation strategy of a prompt, declared in the
+// `resilience` ext namespace of its frontmatter:
+//
+//	resilience.fallbacks: [googleai/gemini-2.0-flash, openai/gpt-4o-mini]
+//	resilience.maxRetries: 2
+//	resilience.backoff.initial: 500ms
+//	resilience.backoff.max: 8s
+//	resilience.backoff.multiplier: 3
+//
+// Generate tries the model of the prompt, then each fallback in order,
+// retrying each one up to MaxRetries times with Backoff between attempts.
+type ResiliencePolicy struct {
+	// Fallbacks are the models tried, in order, when the model of the prompt
+	// fails.
+	Fallbacks []string `mapstructure:"fallbacks"`
+	// MaxRetries is the number of times a failing model is retried before
+	// moving to the next one.
+	MaxRetries int `mapstructure:"maxRetries"`
+	// Backoff sets the delays between retries of a model.
+	Backoff BackoffPolicy `mapstructure:"backoff"`
+}
+
+// BackoffPolicy sets exponentially growing delays.
+type BackoffPolicy struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration `mapstructure:"initial"`
+	// Max caps the delays. Zero leaves them uncapped.
+	Max time.Duration `mapstructure:"max"`
+	// Multiplier is the growth of each delay over the previous one. Zero
+	// means 2.
+	Multiplier float64 `mapstructure:"multiplier"`
+}
+
+// Delay returns the delay before the retry following retry earlier ones.
+func (b BackoffPolicy) Delay(retry int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = defaultBackoffMultiplier
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(retry))
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+// ResiliencePolicy returns the policy declared in the `resilience` ext
+// namespace, or the zero policy, which makes a single attempt, if there is
+// none.
+func (m *PromptMetadata) ResiliencePolicy() (ResiliencePolicy, error) {
+	var policy ResiliencePolicy
+	if err := m.ExtAs(resilienceExtNamespace, &policy); err != nil {
+		return ResiliencePolicy{}, err
+	}
+	switch {
+	case policy.MaxRetries < 0:
+		return ResiliencePolicy{}, fmt.Errorf("invalid frontmatter resilience.maxRetries %d: must not be negative", policy.MaxRetries)
+	case policy.Backoff.Initial < 0, policy.Backoff.Max < 0:
+		return ResiliencePolicy{}, fmt.Errorf("invalid frontmatter resilience.backoff: delays must not be negative")
+	case policy.Backoff.Multiplier != 0 && policy.Backoff.Multiplier < 1:
+		return ResiliencePolicy{}, fmt.Errorf("invalid frontmatter resilience.backoff.multiplier %v: must be at least 1", policy.Backoff.Multiplier)
+	}
+	return policy, nil
+}
+
+// models returns the models tried by the policy for a prompt whose model is
+// primary.
+func (p ResiliencePolicy) models(primary string) []string {
+	models := []string{primary}
+	for _, fallback := range p.Fallbacks {
+		if !slices.Contains(models, fallback) {
+			models = append(models, fallback)
+		}
+	}
+	return models
+}
+
+// sleep waits for d or until ctx is done. It is a variable so that tests do
+// not wait.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
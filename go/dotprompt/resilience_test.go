@@ -0,0 +1,160 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const resilientSource = `---
+model: primary
+resilience.fallbacks: [secondary, tertiary]
+resilience.maxRetries: 1
+resilience.backoff.initial: 100ms
+resilience.backoff.max: 250ms
+---
+Hello`
+
+func TestResiliencePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    ResiliencePolicy
+		wantErr string
+	}{
+		{
+			name:   "declared",
+			source: resilientSource,
+			want: ResiliencePolicy{
+				Fallbacks:  []string{"secondary", "tertiary"},
+				MaxRetries: 1,
+				Backoff:    BackoffPolicy{Initial: 100 * time.Millisecond, Max: 250 * time.Millisecond},
+			},
+		},
+		{
+			name:   "nested backoff",
+			source: "---\nresilience.backoff: {initial: 1s, multiplier: 3}\n---\n",
+			want:   ResiliencePolicy{Backoff: BackoffPolicy{Initial: time.Second, Multiplier: 3}},
+		},
+		{
+			name:   "absent",
+			source: "Hello",
+		},
+		{
+			name:    "negative retries",
+			source:  "---\nresilience.maxRetries: -1\n---\n",
+			wantErr: "must not be negative",
+		},
+		{
+			name:    "invalid duration",
+			source:  "---\nresilience.backoff.initial: soon\n---\n",
+			wantErr: "invalid duration",
+		},
+		{
+			name:    "shrinking multiplier",
+			source:  "---\nresilience.backoff.multiplier: 0.5\n---\n",
+			wantErr: "must be at least 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseDocument(tt.source)
+			if err != nil {
+				t.Fatalf("ParseDocument() returned error: %v", err)
+			}
+			got, err := parsed.ResiliencePolicy()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResiliencePolicy() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResiliencePolicy() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ResiliencePolicy() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := BackoffPolicy{Initial: 100 * time.Millisecond, Max: time.Second}
+	var got []time.Duration
+	for retry := range 5 {
+		got = append(got, b.Delay(retry))
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Delay() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateResilience(t *testing.T) {
+	var slept []time.Duration
+	defer func(orig func(context.Context, time.Duration) error) { sleep = orig }(sleep)
+	sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	rendered, err := NewDotprompt(nil).Render(resilientSource, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	var calls []string
+	model := ModelFunc(func(_ context.Context, prompt RenderedPrompt) (string, error) {
+		calls = append(calls, prompt.Model)
+		if prompt.Model == "tertiary" {
+			return "Hi", nil
+		}
+		return "", errors.New("overloaded")
+	})
+	got, err := Generate(context.Background(), model, rendered)
+	if err != nil {
+		t.Fatalf("Generate() returned error: %v", err)
+	}
+	want := GenerateResult{Response: "Hi", Output: "Hi", Model: "tertiary", Attempts: 5}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Generate() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"primary", "primary", "secondary", "secondary", "tertiary"}, calls); diff != "" {
+		t.Errorf("Generate() calls mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]time.Duration{100 * time.Millisecond, 100 * time.Millisecond}, slept); diff != "" {
+		t.Errorf("Generate() backoff mismatch (-want +got):\n%s", diff)
+	}
+
+	failing := ModelFunc(func(context.Context, RenderedPrompt) (string, error) {
+		return "", errors.New("overloaded")
+	})
+	got, err = Generate(context.Background(), failing, rendered)
+	if err == nil || !strings.Contains(err.Error(), `generating with model "tertiary": overloaded`) {
+		t.Errorf("Generate() error = %v, want the errors of every model", err)
+	}
+	if got.Attempts != 6 {
+		t.Errorf("Generate() made %d attempts, want 6", got.Attempts)
+	}
+}
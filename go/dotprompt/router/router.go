@@ -0,0 +1,248 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package router selects which of several prompts renders a request.
+//
+// Applications often keep variants of a prompt for different inputs, locales
+// or experiment arms and choose between them with hand-written glue. A Router
+// holds those choices as routes, each naming a prompt in a store and the
+// criteria under which it applies:
+//
+//	r := router.New(dp, store)
+//	r.Register(router.Route{Name: "greeting"})
+//	r.Register(router.Route{Name: "greeting", Variant: "fr", Match: router.Criteria{Locales: []string{"fr"}}})
+//	r.Register(router.Route{Name: "greeting_v2", Match: router.Criteria{Buckets: []string{"treatment"}}})
+//
+//	ctx = router.WithLocale(ctx, "fr-CA")
+//	result, err := r.Route(ctx, &dotprompt.DataArgument{Input: input})
+//
+// The locale and experiment bucket of a request are carried by its context.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// ErrNoRoute is returned when no registered route matches a request.
+var ErrNoRoute = errors.New("router: no route matches the request")
+
+// Criteria are the conditions under which a route applies. A route with no
+// criteria matches every request, which makes it a fallback.
+type Criteria struct {
+	// Input reports whether the route applies to the input of a request. Nil
+	// matches any input.
+	Input func(input map[string]any) bool
+	// Locales are the locales the route applies to. A locale matches itself
+	// and its more specific forms, so "fr" matches "fr-CA". Matching is case
+	// insensitive and treats "_" as "-". Empty matches any locale, including
+	// none.
+	Locales []string
+	// Buckets are the experiment buckets the route applies to. Empty matches
+	// any bucket, including none.
+	Buckets []string
+}
+
+// specificity is the number of criteria set.
+func (c Criteria) specificity() int {
+	n := 0
+	if c.Input != nil {
+		n++
+	}
+	if len(c.Locales) > 0 {
+		n++
+	}
+	if len(c.Buckets) > 0 {
+		n++
+	}
+	return n
+}
+
+// matches reports whether the criteria admit a request.
+func (c Criteria) matches(ctx context.Context, input map[string]any) bool {
+	if len(c.Locales) > 0 {
+		locale, ok := LocaleFromContext(ctx)
+		if !ok || !slices.ContainsFunc(c.Locales, func(l string) bool { return localeMatches(l, locale) }) {
+			return false
+		}
+	}
+	if len(c.Buckets) > 0 {
+		bucket, ok := BucketFromContext(ctx)
+		if !ok || !slices.Contains(c.Buckets, bucket) {
+			return false
+		}
+	}
+	return c.Input == nil || c.Input(input)
+}
+
+// Route is a prompt and the criteria under which it renders a request.
+type Route struct {
+	// Name and Variant identify the prompt in the store of the router.
+	Name    string
+	Variant string
+	Match   Criteria
+	// Priority orders the routes that match a request: the highest wins.
+	// Ties go to the route with more criteria set, then to the route
+	// registered first.
+	Priority int
+}
+
+// Result is the outcome of routing a request.
+type Result struct {
+	// Route is the route that won.
+	Route  Route
+	Prompt dp.RenderedPrompt
+}
+
+// Router picks the prompt that renders a request. It is safe for concurrent
+// use.
+type Router struct {
+	dp    *dp.Dotprompt
+	store dp.PromptStore
+
+	mu     sync.RWMutex
+	routes []Route
+}
+
+// New returns a router that loads prompts from store and renders them with
+// prompts.
+func New(prompts *dp.Dotprompt, store dp.PromptStore) *Router {
+	return &Router{dp: prompts, store: store}
+}
+
+// Register adds a route.
+func (r *Router) Register(route Route) error {
+	if route.Name == "" {
+		return errors.New("router: route has no prompt name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, route)
+	return nil
+}
+
+// Routes returns the registered routes in registration order.
+func (r *Router) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.routes)
+}
+
+// Select returns the route that wins a request without rendering it.
+func (r *Router) Select(ctx context.Context, data *dp.DataArgument) (Route, error) {
+	var input map[string]any
+	if data != nil {
+		input = data.Input
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	best := -1
+	for i, route := range r.routes {
+		if !route.Match.matches(ctx, input) {
+			continue
+		}
+		if best < 0 || outranks(route, r.routes[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Route{}, ErrNoRoute
+	}
+	return r.routes[best], nil
+}
+
+// Route selects the route that wins a request and renders its prompt with
+// data.
+func (r *Router) Route(ctx context.Context, data *dp.DataArgument) (Result, error) {
+	if data == nil {
+		data = &dp.DataArgument{}
+	}
+	route, err := r.Select(ctx, data)
+	if err != nil {
+		return Result{}, err
+	}
+	prompt, err := r.store.Load(route.Name, dp.LoadPromptOptions{Variant: route.Variant})
+	if err != nil {
+		return Result{}, fmt.Errorf("router: loading %s: %w", routeName(route), err)
+	}
+	rendered, err := r.dp.Render(prompt.Source, data, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("router: rendering %s: %w", routeName(route), err)
+	}
+	return Result{Route: route, Prompt: rendered}, nil
+}
+
+// outranks reports whether a wins over b, which was registered before it.
+func outranks(a, b Route) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.Match.specificity() > b.Match.specificity()
+}
+
+// routeName returns the name of the prompt of route, with its variant.
+func routeName(route Route) string {
+	if route.Variant == "" {
+		return route.Name
+	}
+	return route.Name + "." + route.Variant
+}
+
+// localeMatches reports whether the route locale admits the request locale.
+func localeMatches(route, request string) bool {
+	route = normalizeLocale(route)
+	request = normalizeLocale(request)
+	return request == route || strings.HasPrefix(request, route+"-")
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
+
+type localeKey struct{}
+
+type bucketKey struct{}
+
+// WithLocale returns a copy of ctx that carries the locale of a request, such
+// as "fr-CA".
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns the locale set with WithLocale.
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
+
+// WithBucket returns a copy of ctx that carries the experiment bucket of a
+// request.
+func WithBucket(ctx context.Context, bucket string) context.Context {
+	return context.WithValue(ctx, bucketKey{}, bucket)
+}
+
+// BucketFromContext returns the experiment bucket set with WithBucket.
+func BucketFromContext(ctx context.Context) (string, bool) {
+	bucket, ok := ctx.Value(bucketKey{}).(string)
+	return bucket, ok
+}
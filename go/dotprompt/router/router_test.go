@@ -0,0 +1,122 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/dotprompt/go/dotprompt/storetest"
+)
+
+func newRouter(t *testing.T) *Router {
+	t.Helper()
+	store := storetest.NewDirStore(t, map[string]string{
+		"greeting.prompt":    "Hello {{name}}",
+		"greeting.fr.prompt": "Bonjour {{name}}",
+		"greeting_v2.prompt": "Hi there {{name}}",
+		"vip.prompt":         "Welcome back, {{name}}",
+	})
+	r := New(dp.NewDotprompt(nil), store)
+	routes := []Route{
+		{Name: "greeting"},
+		{Name: "greeting", Variant: "fr", Match: Criteria{Locales: []string{"fr"}}},
+		{Name: "greeting_v2", Match: Criteria{Buckets: []string{"treatment"}}},
+		{Name: "vip", Priority: 1, Match: Criteria{Input: func(input map[string]any) bool {
+			vip, _ := input["vip"].(bool)
+			return vip
+		}}},
+	}
+	for _, route := range routes {
+		if err := r.Register(route); err != nil {
+			t.Fatalf("Register() returned error: %v", err)
+		}
+	}
+	return r
+}
+
+func TestRoute(t *testing.T) {
+	r := newRouter(t)
+	tests := []struct {
+		name   string
+		locale string
+		bucket string
+		input  map[string]any
+		want   string
+	}{
+		{name: "fallback", want: "Hello Ada"},
+		{name: "locale", locale: "fr", want: "Bonjour Ada"},
+		{name: "regional locale", locale: "fr_CA", want: "Bonjour Ada"},
+		{name: "other locale", locale: "de-DE", want: "Hello Ada"},
+		{name: "bucket", bucket: "treatment", want: "Hi there Ada"},
+		{name: "other bucket", bucket: "control", want: "Hello Ada"},
+		{name: "priority", locale: "fr", input: map[string]any{"vip": true}, want: "Welcome back, Ada"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.locale != "" {
+				ctx = WithLocale(ctx, tt.locale)
+			}
+			if tt.bucket != "" {
+				ctx = WithBucket(ctx, tt.bucket)
+			}
+			input := map[string]any{"name": "Ada"}
+			for k, v := range tt.input {
+				input[k] = v
+			}
+			result, err := r.Route(ctx, &dp.DataArgument{Input: input})
+			if err != nil {
+				t.Fatalf("Route() returned error: %v", err)
+			}
+			if got := result.Prompt.Messages[0].Content[0].(*dp.TextPart).Text; got != tt.want {
+				t.Errorf("Route() rendered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectSpecificity(t *testing.T) {
+	r := New(dp.NewDotprompt(nil), nil)
+	r.Register(Route{Name: "any-fr", Match: Criteria{Locales: []string{"fr"}}})
+	r.Register(Route{Name: "treatment-fr", Match: Criteria{Locales: []string{"fr"}, Buckets: []string{"treatment"}}})
+
+	ctx := WithBucket(WithLocale(context.Background(), "fr"), "treatment")
+	route, err := r.Select(ctx, nil)
+	if err != nil {
+		t.Fatalf("Select() returned error: %v", err)
+	}
+	if route.Name != "treatment-fr" {
+		t.Errorf("Select() = %q, want %q", route.Name, "treatment-fr")
+	}
+}
+
+func TestSelectNoRoute(t *testing.T) {
+	r := New(dp.NewDotprompt(nil), nil)
+	r.Register(Route{Name: "fr", Match: Criteria{Locales: []string{"fr"}}})
+	if _, err := r.Select(context.Background(), nil); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("Select() error = %v, want %v", err, ErrNoRoute)
+	}
+}
+
+func TestRegisterRequiresName(t *testing.T) {
+	if err := New(nil, nil).Register(Route{}); err == nil {
+		t.Error("Register() returned nil error for a route without a name")
+	}
+}
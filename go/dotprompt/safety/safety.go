@@ -0,0 +1,340 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package safety provides heuristics for detecting prompt injection attempts
+// in rendered dotprompt messages and in the inputs they are rendered from.
+//
+// The scanner is intentionally conservative and pattern based. It is meant to
+// flag suspicious content for review or stripping before messages are sent to
+// a model, not to act as a complete defense.
+//
+// Rendering turns dotprompt markers into message boundaries, media and
+// history, so markers injected through an input value leave no trace in the
+// rendered messages for ScanMessages to report. Scan the inputs before
+// rendering instead, with NewInputScanMiddleware or ScanText.
+package safety
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// Rule is a named injection heuristic.
+type Rule struct {
+	Name        string
+	Description string
+	Regex       *regexp.Regexp
+	// Roles restricts the rule to messages of these roles, for heuristics
+	// that legitimate system or model messages would trigger. Nil applies
+	// the rule to every message. ScanText applies every rule.
+	Roles []dp.Role
+}
+
+// appliesTo reports whether r scans messages of role.
+func (r Rule) appliesTo(role dp.Role) bool {
+	return r.Roles == nil || slices.Contains(r.Roles, role)
+}
+
+// rulesFor returns the rules applying to messages of role.
+func rulesFor(rules []Rule, role dp.Role) []Rule {
+	var applied []Rule
+	for _, rule := range rules {
+		if rule.appliesTo(role) {
+			applied = append(applied, rule)
+		}
+	}
+	return applied
+}
+
+// Finding describes a single suspicious match within a message.
+type Finding struct {
+	// Rule is the name of the rule that matched.
+	Rule string `json:"rule"`
+	// Message is the index of the message containing the match.
+	Message int `json:"message"`
+	// Part is the index of the text or data part within the message.
+	Part int `json:"part"`
+	// Path locates the matched string within the data of a data part, as in
+	// `user.emails[0]`. It is empty for text parts.
+	Path string `json:"path,omitempty"`
+	// Start and End are the byte offsets of the match within the part text,
+	// or within the string at Path.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Match is the matched text.
+	Match string `json:"match"`
+}
+
+// DefaultRules are the heuristics applied by ScanMessages.
+var DefaultRules = []Rule{
+	{
+		Name:        "ignore-instructions",
+		Description: "Instruction to ignore or override earlier instructions.",
+		Regex: regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\b(?:\s+\w+){0,3}?\s+` +
+			`(?:previous|prior|above|earlier|system|all)\s+(?:\w+\s+)?(?:instructions|prompts?|rules|directions|messages?)\b`),
+	},
+	{
+		Name:        "reveal-system-prompt",
+		Description: "Request to reveal the system prompt or hidden instructions.",
+		Regex: regexp.MustCompile(`(?i)\b(?:reveal|print|show|repeat|output)\b(?:\s+\w+){0,3}?\s+` +
+			`(?:system\s+prompt|hidden\s+instructions|initial\s+instructions)\b`),
+	},
+	{
+		Name:        "dotprompt-marker",
+		Description: "Dotprompt marker syntax that could spoof roles, history or media.",
+		// Rendering consumes the markers of rendered text, so this rule only
+		// reports them in inputs and in the strings of data parts.
+		Regex: regexp.MustCompile(`<<<dotprompt:[^>]*>>>`),
+	},
+	{
+		Name:        "chat-template-token",
+		Description: "Chat template control tokens used by common model families.",
+		Regex:       regexp.MustCompile(`<\|(?:im_start|im_end|system|user|assistant|endoftext)\|>|\[/?INST\]|<</?SYS>>`),
+	},
+	{
+		Name:        "role-prefix",
+		Description: "Line starting with a role label that mimics a conversation turn.",
+		Regex:       regexp.MustCompile(`(?im)^\s*(?:system|assistant|model)\s*:`),
+		// System prompts commonly label turns themselves, as in few-shot
+		// transcripts, so only user messages are scanned.
+		Roles: []dp.Role{dp.RoleUser},
+	},
+}
+
+// ScanMessages scans the text parts of msgs, and the strings in their data
+// parts, using DefaultRules.
+func ScanMessages(msgs []dp.Message) []Finding {
+	return ScanMessagesWithRules(msgs, DefaultRules)
+}
+
+// ScanMessagesWithRules scans the text parts of msgs, and the strings in
+// their data parts, using the given rules, each applied to the messages of
+// its Roles. Findings are ordered by message, part, path and offset.
+func ScanMessagesWithRules(msgs []dp.Message, rules []Rule) []Finding {
+	var findings []Finding
+	for i, msg := range msgs {
+		msgRules := rulesFor(rules, msg.Role)
+		for j, part := range msg.Content {
+			var partFindings []Finding
+			switch part := part.(type) {
+			case *dp.TextPart:
+				partFindings = scanText(part.Text, msgRules)
+			case *dp.DataPart:
+				walkStrings(part.Data, "", func(path, text string) {
+					for _, f := range scanText(text, msgRules) {
+						f.Path = path
+						partFindings = append(partFindings, f)
+					}
+				})
+			}
+			for _, f := range partFindings {
+				f.Message, f.Part = i, j
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
+
+// ScanText scans a single string, such as an input value prior to rendering,
+// using DefaultRules. The Message and Part fields of the findings are zero.
+func ScanText(text string) []Finding {
+	return scanText(text, DefaultRules)
+}
+
+// ErrInjectionDetected is wrapped by the errors of the middleware returned by
+// NewInputScanMiddleware.
+var ErrInjectionDetected = errors.New("safety: render input matches an injection rule")
+
+// InputError is returned by the middleware of NewInputScanMiddleware when
+// the input of a render matches a rule. It wraps ErrInjectionDetected.
+type InputError struct {
+	// Findings holds every match, with the Path of the matched string in the
+	// DataArgument, such as `input.notes[0]` or `context.state.name`. Their
+	// Message and Part fields are zero.
+	Findings []Finding
+}
+
+func (e *InputError) Error() string {
+	f := e.Findings[0]
+	msg := fmt.Sprintf("%v: %s in %s: %q", ErrInjectionDetected, f.Rule, f.Path, f.Match)
+	if len(e.Findings) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Findings)-1)
+	}
+	return msg
+}
+
+func (e *InputError) Unwrap() error {
+	return ErrInjectionDetected
+}
+
+// NewInputScanMiddleware returns a dotprompt.Middleware that scans the
+// strings in the input and context of every render with rules, or with
+// DefaultRules if rules is nil, and fails the render with an *InputError if
+// any matches, before the template runs. Like ScanText, it applies every rule
+// whatever its Roles. Values are scanned in their JSON encoding, and those
+// that cannot be encoded are skipped.
+func NewInputScanMiddleware(rules []Rule) dp.Middleware {
+	if rules == nil {
+		rules = DefaultRules
+	}
+	return func(next dp.RenderFunc) dp.RenderFunc {
+		return func(data *dp.DataArgument, options *dp.PromptMetadata) (dp.RenderedPrompt, error) {
+			if data != nil {
+				var findings []Finding
+				scan := func(path, text string) {
+					for _, f := range scanText(text, rules) {
+						f.Path = path
+						findings = append(findings, f)
+					}
+				}
+				scanValues("input", data.Input, scan)
+				scanValues("context", data.Context, scan)
+				if len(findings) > 0 {
+					return dp.RenderedPrompt{}, &InputError{Findings: findings}
+				}
+			}
+			return next(data, options)
+		}
+	}
+}
+
+// scanValues calls fn with each string in the JSON encoding of values, a
+// field of a DataArgument named root, in the order of their paths.
+func scanValues(root string, values map[string]any, fn func(path, text string)) {
+	decoded := make(map[string]any, len(values))
+	for key, value := range values {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(encoded, &v); err != nil {
+			continue
+		}
+		decoded[key] = v
+	}
+	walkStrings(decoded, root, fn)
+}
+
+// Strip returns a copy of msgs with every match of the given rules removed from
+// their text parts and the strings in their data parts, each rule applied to
+// the messages of its Roles. The input messages are not modified.
+func Strip(msgs []dp.Message, rules []Rule) []dp.Message {
+	out := make([]dp.Message, len(msgs))
+	for i, msg := range msgs {
+		msgRules := rulesFor(rules, msg.Role)
+		strip := func(text string) string {
+			for _, rule := range msgRules {
+				text = rule.Regex.ReplaceAllLiteralString(text, "")
+			}
+			return text
+		}
+		content := make([]dp.Part, len(msg.Content))
+		for j, part := range msg.Content {
+			switch part := part.(type) {
+			case *dp.TextPart:
+				content[j] = &dp.TextPart{HasMetadata: part.HasMetadata, Text: strip(part.Text)}
+			case *dp.DataPart:
+				data, _ := mapStrings(part.Data, strip).(map[string]any)
+				content[j] = &dp.DataPart{HasMetadata: part.HasMetadata, Data: data}
+			default:
+				content[j] = part
+			}
+		}
+		msg.Content = content
+		out[i] = msg
+	}
+	return out
+}
+
+// walkStrings calls fn with each string in v, a value decoded from JSON such
+// as the data of a data part, and its path from the root at path. Map keys
+// are visited in sorted order.
+func walkStrings(v any, path string, fn func(path, text string)) {
+	switch v := v.(type) {
+	case string:
+		fn(path, v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			walkStrings(v[key], keyPath, fn)
+		}
+	case []any:
+		for i, item := range v {
+			walkStrings(item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	}
+}
+
+// mapStrings returns a copy of v, a value decoded from JSON, with fn applied
+// to each of its strings.
+func mapStrings(v any, fn func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = mapStrings(item, fn)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = mapStrings(item, fn)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// scanText applies rules to text and returns findings sorted by offset.
+func scanText(text string, rules []Rule) []Finding {
+	var findings []Finding
+	for _, rule := range rules {
+		for _, loc := range rule.Regex.FindAllStringIndex(text, -1) {
+			findings = append(findings, Finding{
+				Rule:  rule.Name,
+				Start: loc[0],
+				End:   loc[1],
+				Match: text[loc[0]:loc[1]],
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Start < findings[j].Start
+	})
+	return findings
+}
@@ -45,6 +45,7 @@ func (dp *Dotprompt) DefineSchema(name string, definition any) *jsonschema.Schem
 		dp.Schemas = make(map[string]*jsonschema.Schema)
 	}
 
+	dp.newRenderScope()
 	dp.Schemas[name] = schema
 	return schema
 }
@@ -62,6 +63,7 @@ func (dp *Dotprompt) LookupSchema(name string) (*jsonschema.Schema, bool) {
 // RegisterExternalSchemaLookup registers a function that can look up schemas
 // from an external source.
 func (dp *Dotprompt) RegisterExternalSchemaLookup(lookup func(string) any) {
+	dp.newRenderScope()
 	if dp.ExternalSchemaLookups == nil {
 		dp.ExternalSchemaLookups = make([]func(string) any, 0)
 	}
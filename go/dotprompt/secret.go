@@ -0,0 +1,123 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// SecretScheme is the URI scheme of secret references. A frontmatter string
+// such as `secret://projects/x/secrets/y` declares that a value is held in a
+// secret manager rather than written in the prompt:
+//
+//	config:
+//	  apiKeyRef: secret://projects/x/secrets/y
+const SecretScheme = "secret://"
+
+// SecretResolver resolves a secret reference, including its scheme, to the
+// secret value.
+type SecretResolver func(ref string) (string, error)
+
+// Secret is a resolved secret reference. Formatting it, encoding it as JSON
+// or logging it with log/slog shows only the reference, so rendered metadata
+// can be logged and traced without leaking the value; call Reveal to use it.
+type Secret struct {
+	ref   string
+	value string
+}
+
+// Ref returns the reference the secret was resolved from.
+func (s Secret) Ref() string { return s.ref }
+
+// Reveal returns the secret value.
+func (s Secret) Reveal() string { return s.value }
+
+// String returns the reference followed by a redaction marker.
+func (s Secret) String() string { return s.ref + " [REDACTED]" }
+
+// GoString implements fmt.GoStringer so that %#v is redacted too.
+func (s Secret) GoString() string { return fmt.Sprintf("dotprompt.Secret(%q)", s.ref) }
+
+// MarshalJSON encodes the secret as its reference.
+func (s Secret) MarshalJSON() ([]byte, error) { return json.Marshal(s.ref) }
+
+// LogValue implements slog.LogValuer.
+func (s Secret) LogValue() slog.Value { return slog.StringValue(s.String()) }
+
+// IsSecretRef reports whether v is a secret reference.
+func IsSecretRef(v any) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, SecretScheme) && len(s) > len(SecretScheme)
+}
+
+// SecretRefs returns the secret references in the config and extension
+// fields of meta, sorted and without duplicates. It lets tooling list the
+// secrets a prompt needs without resolving them.
+func SecretRefs(meta PromptMetadata) []string {
+	var refs []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		case string:
+			if IsSecretRef(v) {
+				refs = append(refs, v)
+			}
+		}
+	}
+	walk(map[string]any(meta.Config))
+	for _, ext := range meta.Ext {
+		walk(ext)
+	}
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
+
+// resolveSecrets replaces the secret references in the config and extension
+// fields of meta with Secret values, resolving each reference once.
+func (dp *Dotprompt) resolveSecrets(meta PromptMetadata) (PromptMetadata, error) {
+	if dp.secretResolver == nil {
+		return meta, nil
+	}
+	resolved := make(map[string]Secret)
+	return rewriteMetadataStrings(meta, func(ref string) (any, bool, error) {
+		if !IsSecretRef(ref) {
+			return nil, false, nil
+		}
+		if s, ok := resolved[ref]; ok {
+			return s, true, nil
+		}
+		value, err := dp.secretResolver(ref)
+		if err != nil {
+			return nil, false, fmt.Errorf("dotprompt: resolving secret %s: %w", ref, err)
+		}
+		s := Secret{ref: ref, value: value}
+		resolved[ref] = s
+		return s, true, nil
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const secretSource = `---
+model: test-model
+config:
+  apiKeyRef: secret://projects/x/secrets/key
+  temperature: 0.5
+tools.search:
+  tokenRef: secret://projects/x/secrets/search
+---
+Hello`
+
+func TestSecretResolution(t *testing.T) {
+	var calls []string
+	dp := NewDotprompt(&DotpromptOptions{
+		SecretResolver: func(ref string) (string, error) {
+			calls = append(calls, ref)
+			return "value-of-" + strings.TrimPrefix(ref, SecretScheme), nil
+		},
+	})
+	rendered, err := dp.Render(secretSource, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	key, ok := rendered.Config["apiKeyRef"].(Secret)
+	if !ok {
+		t.Fatalf("config.apiKeyRef = %#v, want a Secret", rendered.Config["apiKeyRef"])
+	}
+	if got, want := key.Reveal(), "value-of-projects/x/secrets/key"; got != want {
+		t.Errorf("Reveal() = %q, want %q", got, want)
+	}
+	if got := rendered.Config["temperature"]; got != 0.5 {
+		t.Errorf("config.temperature = %v, want 0.5", got)
+	}
+	search := rendered.Ext["tools"]["search"].(map[string]any)
+	if _, ok := search["tokenRef"].(Secret); !ok {
+		t.Errorf("ext.tools.search.tokenRef = %#v, want a Secret", search["tokenRef"])
+	}
+	if len(calls) != 2 {
+		t.Errorf("resolver called %d times, want 2", len(calls))
+	}
+
+	// The parsed prompt is not modified.
+	parsed, err := dp.Parse(secretSource)
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if got := parsed.Config["apiKeyRef"]; got != "secret://projects/x/secrets/key" {
+		t.Errorf("parsed config.apiKeyRef = %#v, want the reference", got)
+	}
+}
+
+func TestSecretRedaction(t *testing.T) {
+	s := Secret{ref: "secret://a/b", value: "hunter2"}
+	encoded, err := json.Marshal(map[string]any{"key": s})
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	for _, got := range []string{
+		fmt.Sprint(s),
+		fmt.Sprintf("%v", map[string]any{"key": s}),
+		fmt.Sprintf("%+v", s),
+		fmt.Sprintf("%#v", s),
+		string(encoded),
+		s.LogValue().String(),
+	} {
+		if strings.Contains(got, "hunter2") {
+			t.Errorf("formatted secret %q contains the value", got)
+		}
+		if !strings.Contains(got, "secret://a/b") {
+			t.Errorf("formatted secret %q does not contain the reference", got)
+		}
+	}
+}
+
+func TestSecretUnresolved(t *testing.T) {
+	rendered, err := NewDotprompt(nil).Render(secretSource, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got := rendered.Config["apiKeyRef"]; got != "secret://projects/x/secrets/key" {
+		t.Errorf("config.apiKeyRef = %#v, want the reference", got)
+	}
+}
+
+func TestSecretResolverError(t *testing.T) {
+	errNotFound := errors.New("not found")
+	dp := New(WithSecretResolver(func(string) (string, error) { return "", errNotFound }))
+	_, err := dp.Render(secretSource, &DataArgument{}, nil)
+	if !errors.Is(err, errNotFound) {
+		t.Fatalf("Render() error = %v, want %v", err, errNotFound)
+	}
+}
+
+func TestSecretRefs(t *testing.T) {
+	parsed, err := ParseDocument(secretSource)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	want := []string{"secret://projects/x/secrets/key", "secret://projects/x/secrets/search"}
+	if diff := cmp.Diff(want, SecretRefs(parsed.PromptMetadata)); diff != "" {
+		t.Errorf("SecretRefs() mismatch (-want +got):\n%s", diff)
+	}
+}
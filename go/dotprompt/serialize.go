@@ -0,0 +1,46 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Serialize writes prompt in the `.prompt` format: its raw frontmatter,
+// followed by its template. Frontmatter keys are written in the order
+// recorded in RawKeyOrder, and keys without a recorded order alphabetically,
+// so serializing the same prompt always produces the same bytes and parsing
+// the result gives back the same Raw and Template.
+func Serialize(prompt ParsedPrompt) (string, error) {
+	var sb strings.Builder
+	if len(prompt.Raw) > 0 {
+		frontmatter, err := yaml.Marshal(orderedYAML(prompt.Raw, prompt.RawKeyOrder))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("---\n")
+		sb.Write(frontmatter)
+		sb.WriteString("---\n")
+	}
+	sb.WriteString(prompt.Template)
+	if prompt.Template != "" && !strings.HasSuffix(prompt.Template, "\n") {
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
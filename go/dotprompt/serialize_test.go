@@ -0,0 +1,74 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSerialize(t *testing.T) {
+	source := "---\nmodel: vertexai/gemini\nconfig:\n  topK: 3\n  temperature: 0.5\ninput:\n  schema:\n    name: string\n    age?: integer\nmyext.flag: true\n---\nHello {{name}}!\n"
+	parsed, err := ParseDocument(source)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+
+	got, err := Serialize(parsed)
+	if err != nil {
+		t.Fatalf("Serialize() returned error: %v", err)
+	}
+	if diff := cmp.Diff(source, got); diff != "" {
+		t.Errorf("Serialize() mismatch (-want +got):\n%s", diff)
+	}
+
+	reparsed, err := ParseDocument(got)
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %v", err)
+	}
+	if diff := cmp.Diff(parsed, reparsed); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSerializeWithoutKeyOrder(t *testing.T) {
+	prompt := ParsedPrompt{
+		PromptMetadata: PromptMetadata{Raw: map[string]any{"model": "m", "config": map[string]any{"b": 1, "a": 2}}},
+		Template:       "Hi",
+	}
+	want := "---\nconfig:\n  a: 2\n  b: 1\nmodel: m\n---\nHi\n"
+	for range 10 {
+		got, err := Serialize(prompt)
+		if err != nil {
+			t.Fatalf("Serialize() returned error: %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("Serialize() mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestSerializeTemplateOnly(t *testing.T) {
+	got, err := Serialize(ParsedPrompt{Template: "Just text"})
+	if err != nil {
+		t.Fatalf("Serialize() returned error: %v", err)
+	}
+	if got != "Just text\n" {
+		t.Errorf("Serialize() = %q, want %q", got, "Just text\n")
+	}
+}
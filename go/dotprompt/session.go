@@ -0,0 +1,299 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrSessionNotFound is returned by SessionStore.LoadSession for a session
+// that has no transcript.
+var ErrSessionNotFound = errors.New("dotprompt: session not found")
+
+// SessionStore persists the encoded transcripts of conversations between
+// turns, keyed by session ID. SaveTranscript and LoadTranscript encode and
+// decode the messages; stores only hold the bytes.
+type SessionStore interface {
+	// SaveSession replaces the transcript of a session.
+	SaveSession(sessionID string, transcript []byte) error
+	// LoadSession returns the transcript of a session, or an error wrapping
+	// ErrSessionNotFound.
+	LoadSession(sessionID string) ([]byte, error)
+	// DeleteSession removes a session. Deleting a session that does not
+	// exist is not an error.
+	DeleteSession(sessionID string) error
+}
+
+// TranscriptWindow limits the messages LoadTranscript returns to the most
+// recent turns. A turn starts with a user message, as in NumberTurns, and
+// windows never split one. Zero fields impose no limit.
+type TranscriptWindow struct {
+	// MaxTurns is the number of most recent turns kept.
+	MaxTurns int
+	// MaxMessages is the number of messages kept. Older turns are dropped
+	// whole until the rest fit, so fewer messages may be kept; the most
+	// recent turn is always kept.
+	MaxMessages int
+}
+
+// SaveTranscript saves messages as the transcript of a session, replacing
+// the previous one.
+func SaveTranscript(store SessionStore, sessionID string, messages []Message) error {
+	if err := validateSessionID(sessionID); err != nil {
+		return err
+	}
+	if messages == nil {
+		messages = []Message{}
+	}
+	b, err := json.Marshal(messages)
+	if err != nil {
+		return fmt.Errorf("dotprompt: encoding transcript: %w", err)
+	}
+	return store.SaveSession(sessionID, b)
+}
+
+// LoadTranscript loads the transcript of a session, limited to window if it
+// is not nil, ready to be passed as DataArgument.Messages.
+func LoadTranscript(store SessionStore, sessionID string, window *TranscriptWindow) ([]Message, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+	b, err := store.LoadSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := decodeTranscript(b)
+	if err != nil {
+		return nil, fmt.Errorf("dotprompt: decoding transcript of session %s: %w", sessionID, err)
+	}
+	if window != nil {
+		messages = window.apply(messages)
+	}
+	return messages, nil
+}
+
+// apply returns the messages of the turns in the window.
+func (w TranscriptWindow) apply(messages []Message) []Message {
+	// starts holds the index of the first message of each turn.
+	var starts []int
+	for i, msg := range messages {
+		if i == 0 || msg.Role == RoleUser && messages[i-1].Role != RoleUser {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) == 0 {
+		return messages
+	}
+	first := 0
+	if w.MaxTurns > 0 && len(starts) > w.MaxTurns {
+		first = len(starts) - w.MaxTurns
+	}
+	if w.MaxMessages > 0 {
+		for first < len(starts)-1 && len(messages)-starts[first] > w.MaxMessages {
+			first++
+		}
+	}
+	return messages[starts[first]:]
+}
+
+// transcriptMessage is the encoding of a Message, whose parts are decoded by
+// decodePart.
+type transcriptMessage struct {
+	Metadata Metadata          `json:"metadata,omitempty"`
+	Role     Role              `json:"role"`
+	Content  []json.RawMessage `json:"content"`
+}
+
+// decodeTranscript decodes messages encoded as JSON.
+func decodeTranscript(b []byte) ([]Message, error) {
+	var encoded []transcriptMessage
+	if err := json.Unmarshal(b, &encoded); err != nil {
+		return nil, err
+	}
+	messages := make([]Message, len(encoded))
+	for i, m := range encoded {
+		messages[i] = Message{HasMetadata: HasMetadata{Metadata: m.Metadata}, Role: m.Role}
+		for _, raw := range m.Content {
+			part, err := decodePart(raw)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			messages[i].Content = append(messages[i].Content, part)
+		}
+	}
+	return messages, nil
+}
+
+// decodePart decodes a part encoded as JSON, telling its type by its fields.
+func decodePart(raw json.RawMessage) (Part, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	var part Part
+	switch {
+	case fields["text"] != nil:
+		part = &TextPart{}
+	case fields["media"] != nil:
+		part = &MediaPart{}
+	case fields["data"] != nil:
+		part = &DataPart{}
+	case fields["toolRequest"] != nil:
+		part = &ToolRequestPart{}
+	case fields["toolResponse"] != nil:
+		part = &ToolResponsePart{}
+	case fields["metadata"] != nil:
+		part = &PendingPart{}
+	default:
+		return nil, fmt.Errorf("unknown part %s", raw)
+	}
+	if err := json.Unmarshal(raw, part); err != nil {
+		return nil, err
+	}
+	return part, nil
+}
+
+// validateSessionID rejects the session IDs that are empty or could escape
+// the directory of a DirSessionStore.
+func validateSessionID(sessionID string) error {
+	if sessionID == "" || sessionID == "." || sessionID == ".." || strings.ContainsAny(sessionID, `/\`) {
+		return fmt.Errorf("dotprompt: invalid session ID %q", sessionID)
+	}
+	return nil
+}
+
+// MemorySessionStore is a SessionStore that keeps transcripts in memory,
+// for tests and single-process servers. It is safe for concurrent use.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string][]byte
+}
+
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+// NewMemorySessionStore returns an empty MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string][]byte)}
+}
+
+// SaveSession implements SessionStore.
+func (s *MemorySessionStore) SaveSession(sessionID string, transcript []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sessionID] = append([]byte(nil), transcript...)
+	return nil
+}
+
+// LoadSession implements SessionStore.
+func (s *MemorySessionStore) LoadSession(sessionID string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// DeleteSession implements SessionStore.
+func (s *MemorySessionStore) DeleteSession(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// DirSessionStore is a SessionStore that keeps each transcript in a JSON
+// file named after its session in a directory.
+type DirSessionStore struct {
+	Root string
+}
+
+var _ SessionStore = (*DirSessionStore)(nil)
+
+// NewDirSessionStore returns a DirSessionStore rooted at root, which is
+// created if it does not exist.
+func NewDirSessionStore(root string) (*DirSessionStore, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirSessionStore{Root: absRoot}, nil
+}
+
+func (s *DirSessionStore) path(sessionID string) (string, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Root, sessionID+".json"), nil
+}
+
+// SaveSession implements SessionStore. The file is replaced atomically, so
+// a concurrent LoadSession sees the old or the new transcript.
+func (s *DirSessionStore) SaveSession(sessionID string, transcript []byte) error {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Root, ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(transcript); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSession implements SessionStore.
+func (s *DirSessionStore) LoadSession(sessionID string) ([]byte, error) {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return b, err
+}
+
+// DeleteSession implements SessionStore.
+func (s *DirSessionStore) DeleteSession(sessionID string) error {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTranscriptRoundTrip(t *testing.T) {
+	messages := []Message{
+		textMessage(RoleSystem, "Be brief."),
+		{Role: RoleUser, Content: []Part{
+			&TextPart{Text: "Look"},
+			&MediaPart{Media: Media{URL: "https://example.com/cat.png", ContentType: "image/png"}},
+			&DataPart{Data: map[string]any{"n": 1.0}},
+		}},
+		{Role: RoleModel, Content: []Part{&ToolRequestPart{ToolRequest: map[string]any{"name": "search"}}}},
+		{Role: RoleTool, Content: []Part{&ToolResponsePart{ToolResponse: map[string]any{"name": "search"}}}},
+		{HasMetadata: HasMetadata{Metadata: Metadata{"purpose": "history"}}, Role: RoleModel, Content: []Part{NewPendingPart()}},
+	}
+
+	dir := t.TempDir()
+	dirStore, err := NewDirSessionStore(dir)
+	if err != nil {
+		t.Fatalf("NewDirSessionStore() returned error: %v", err)
+	}
+	sqlStore, err := NewSQLSessionStore(sql.OpenDB(&fakeConnector{tables: make(map[string]string)}), SQLSessionStoreOptions{})
+	if err != nil {
+		t.Fatalf("NewSQLSessionStore() returned error: %v", err)
+	}
+	stores := map[string]SessionStore{
+		"memory": NewMemorySessionStore(),
+		"dir":    dirStore,
+		"sql":    sqlStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadTranscript(store, "s1", nil); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("LoadTranscript() error = %v, want %v", err, ErrSessionNotFound)
+			}
+			if err := SaveTranscript(store, "s1", messages[:1]); err != nil {
+				t.Fatalf("SaveTranscript() returned error: %v", err)
+			}
+			if err := SaveTranscript(store, "s1", messages); err != nil {
+				t.Fatalf("SaveTranscript() returned error: %v", err)
+			}
+			got, err := LoadTranscript(store, "s1", nil)
+			if err != nil {
+				t.Fatalf("LoadTranscript() returned error: %v", err)
+			}
+			if diff := cmp.Diff(messages, got); diff != "" {
+				t.Errorf("LoadTranscript() mismatch (-want +got):\n%s", diff)
+			}
+			if err := store.DeleteSession("s1"); err != nil {
+				t.Fatalf("DeleteSession() returned error: %v", err)
+			}
+			if _, err := LoadTranscript(store, "s1", nil); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("LoadTranscript() after delete error = %v, want %v", err, ErrSessionNotFound)
+			}
+		})
+	}
+}
+
+func TestTranscriptWindow(t *testing.T) {
+	messages := []Message{
+		textMessage(RoleSystem, "Be brief."),
+		textMessage(RoleUser, "1"),
+		textMessage(RoleModel, "1"),
+		textMessage(RoleUser, "2"),
+		textMessage(RoleUser, "2"),
+		textMessage(RoleModel, "2"),
+		textMessage(RoleUser, "3"),
+		textMessage(RoleModel, "3"),
+	}
+	store := NewMemorySessionStore()
+	if err := SaveTranscript(store, "s", messages); err != nil {
+		t.Fatalf("SaveTranscript() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		window TranscriptWindow
+		want   int
+	}{
+		{"unlimited", TranscriptWindow{}, 0},
+		{"turns", TranscriptWindow{MaxTurns: 2}, 3},
+		{"messages", TranscriptWindow{MaxMessages: 4}, 6},
+		{"messages within turn", TranscriptWindow{MaxMessages: 1}, 6},
+		{"both", TranscriptWindow{MaxTurns: 3, MaxMessages: 6}, 3},
+		{"both within limits", TranscriptWindow{MaxTurns: 3, MaxMessages: 7}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadTranscript(store, "s", &tt.window)
+			if err != nil {
+				t.Fatalf("LoadTranscript() returned error: %v", err)
+			}
+			if diff := cmp.Diff(messages[tt.want:], got); diff != "" {
+				t.Errorf("LoadTranscript() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSessionIDValidation(t *testing.T) {
+	store := NewMemorySessionStore()
+	for _, id := range []string{"", "..", "a/b", `a\b`} {
+		if err := SaveTranscript(store, id, nil); err == nil {
+			t.Errorf("SaveTranscript(%q) returned nil error", id)
+		}
+	}
+}
+
+func TestSQLSessionStorePlaceholders(t *testing.T) {
+	conn := &fakeConnector{tables: make(map[string]string)}
+	store, err := NewSQLSessionStore(sql.OpenDB(conn), SQLSessionStoreOptions{Table: "chat.sessions", NumberedPlaceholders: true})
+	if err != nil {
+		t.Fatalf("NewSQLSessionStore() returned error: %v", err)
+	}
+	if err := SaveTranscript(store, "s", nil); err != nil {
+		t.Fatalf("SaveTranscript() returned error: %v", err)
+	}
+	want := []string{
+		"DELETE FROM chat.sessions WHERE session_id = $1",
+		"INSERT INTO chat.sessions (session_id, transcript) VALUES ($1, $2)",
+	}
+	if diff := cmp.Diff(want, conn.queries); diff != "" {
+		t.Errorf("queries mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewSQLSessionStore(nil, SQLSessionStoreOptions{Table: "x; DROP TABLE y"}); err == nil {
+		t.Error("NewSQLSessionStore() returned nil error for an invalid table name")
+	}
+}
+
+// fakeConnector is a database/sql driver that understands the statements of
+// SQLSessionStore, keeping the transcripts of a single table in memory.
+type fakeConnector struct {
+	mu      sync.Mutex
+	tables  map[string]string
+	queries []string
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ c *fakeConnector }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.c, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	c     *fakeConnector
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	s.c.queries = append(s.c.queries, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(s.c.tables, args[0].(string))
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.c.tables[args[0].(string)] = args[1].(string)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	transcript, ok := s.c.tables[args[0].(string)]
+	return &fakeRows{transcript: transcript, done: !ok}, nil
+}
+
+type fakeRows struct {
+	transcript string
+	done       bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"transcript"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	dest[0], r.done = r.transcript, true
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SQLSessionStoreOptions configures a SQLSessionStore.
+type SQLSessionStoreOptions struct {
+	// Table is the name of the table holding the transcripts. It defaults to
+	// "dotprompt_sessions".
+	Table string
+	// NumberedPlaceholders selects the `$1` placeholders of PostgreSQL
+	// instead of the `?` placeholders of MySQL and SQLite.
+	NumberedPlaceholders bool
+}
+
+// SQLSessionStore is a SessionStore that keeps transcripts in a table of a
+// SQL database, with the columns created by the statement of Schema. The
+// caller opens the database with the driver of its choice.
+type SQLSessionStore struct {
+	db    *sql.DB
+	table string
+	bind  func(query string) string
+}
+
+var _ SessionStore = (*SQLSessionStore)(nil)
+
+// sqlIdentifierRe matches the table names accepted by NewSQLSessionStore,
+// which are written into statements unquoted.
+var sqlIdentifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// NewSQLSessionStore returns a SQLSessionStore using db. The table must
+// already exist; see Schema.
+func NewSQLSessionStore(db *sql.DB, options SQLSessionStoreOptions) (*SQLSessionStore, error) {
+	table := options.Table
+	if table == "" {
+		table = "dotprompt_sessions"
+	}
+	if !sqlIdentifierRe.MatchString(table) {
+		return nil, fmt.Errorf("dotprompt: invalid table name %q", table)
+	}
+	s := &SQLSessionStore{db: db, table: table, bind: func(query string) string { return query }}
+	if options.NumberedPlaceholders {
+		s.bind = numberPlaceholders
+	}
+	return s, nil
+}
+
+// Schema returns a statement that creates the table of the store if it does
+// not exist.
+func (s *SQLSessionStore) Schema() string {
+	return "CREATE TABLE IF NOT EXISTS " + s.table +
+		" (session_id VARCHAR(255) PRIMARY KEY, transcript TEXT NOT NULL)"
+}
+
+// SaveSession implements SessionStore. It replaces the row of the session in
+// a transaction, which works on databases without an upsert statement.
+func (s *SQLSessionStore) SaveSession(sessionID string, transcript []byte) (err error) {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, s.bind("DELETE FROM "+s.table+" WHERE session_id = ?"), sessionID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, s.bind("INSERT INTO "+s.table+" (session_id, transcript) VALUES (?, ?)"), sessionID, string(transcript)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadSession implements SessionStore.
+func (s *SQLSessionStore) LoadSession(sessionID string) ([]byte, error) {
+	var transcript string
+	err := s.db.QueryRow(s.bind("SELECT transcript FROM "+s.table+" WHERE session_id = ?"), sessionID).Scan(&transcript)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(transcript), nil
+}
+
+// DeleteSession implements SessionStore.
+func (s *SQLSessionStore) DeleteSession(sessionID string) error {
+	_, err := s.db.Exec(s.bind("DELETE FROM "+s.table+" WHERE session_id = ?"), sessionID)
+	return err
+}
+
+// numberPlaceholders replaces the `?` placeholders of query with `$1`, `$2`
+// and so on. The statements of SQLSessionStore have no other question marks.
+func numberPlaceholders(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package storetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// WriteFiles writes files, keyed by slash-separated paths such as
+// "sub/greet.prompt", into a new temporary directory and returns it.
+func WriteFiles(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// NewDirStore returns a DirStore over a new temporary directory holding
+// files, as written by WriteFiles.
+func NewDirStore(t testing.TB, files map[string]string) *dp.DirStore {
+	t.Helper()
+	store, err := dp.NewDirStore(WriteFiles(t, files))
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	return store
+}
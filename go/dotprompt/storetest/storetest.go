@@ -0,0 +1,491 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package storetest provides a conformance test suite for implementations of
+// dotprompt.PromptStore.
+//
+// A store implementation verifies its behavior with one call:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.RunConformanceTests(t, func() dotprompt.PromptStore {
+//			return mystore.New(...)
+//		})
+//	}
+//
+// The suite writes its fixtures through the store, so tests that need data
+// are skipped for stores that do not implement
+// dotprompt.WritablePromptStore, and partial tests are skipped for stores
+// that do not implement PartialWriter.
+//
+// WriteFiles and NewDirStore set up directories of prompt files for tests.
+package storetest
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+// PartialWriter is implemented by stores that can save partials. The suite
+// uses it to create the partials it loads and lists.
+type PartialWriter = dp.PartialWriter
+
+// RunConformanceTests runs the conformance suite against the stores returned
+// by newStore, which must return a new, empty store on every call.
+func RunConformanceTests(t *testing.T, newStore func() dp.PromptStore) {
+	t.Helper()
+	tests := []struct {
+		name string
+		run  func(t *testing.T, store dp.PromptStore)
+	}{
+		{"LoadMissing", testLoadMissing},
+		{"SaveAndLoad", testSaveAndLoad},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"NestedNames", testNestedNames},
+		{"Variants", testVariants},
+		{"List", testList},
+		{"Pagination", testPagination},
+		{"Filters", testFilters},
+		{"LoadMany", testLoadMany},
+		{"Partials", testPartials},
+		{"PartialPagination", testPartialPagination},
+		{"PathSafety", testPathSafety},
+		{"Versions", testVersions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStore())
+		})
+	}
+}
+
+// writable returns store as a writable store, or skips the test.
+func writable(t *testing.T, store dp.PromptStore) dp.WritablePromptStore {
+	t.Helper()
+	w, ok := store.(dp.WritablePromptStore)
+	if !ok {
+		t.Skip("store does not implement dotprompt.WritablePromptStore")
+	}
+	return w
+}
+
+// partialWriter returns store as a PartialWriter, or skips the test.
+func partialWriter(t *testing.T, store dp.PromptStore) PartialWriter {
+	t.Helper()
+	w, ok := store.(PartialWriter)
+	if !ok {
+		t.Skip("store does not implement storetest.PartialWriter")
+	}
+	return w
+}
+
+// save saves the given prompts, failing the test on error.
+func save(t *testing.T, store dp.WritablePromptStore, prompts ...dp.PromptData) {
+	t.Helper()
+	for _, prompt := range prompts {
+		if err := store.Save(prompt); err != nil {
+			t.Fatalf("Save(%q, variant %q) returned error: %v", prompt.Name, prompt.Variant, err)
+		}
+	}
+}
+
+// prompt returns a PromptData with the given name, variant and source.
+func prompt(name, variant, source string) dp.PromptData {
+	return dp.PromptData{PromptRef: dp.PromptRef{Name: name, Variant: variant}, Source: source}
+}
+
+// load loads a prompt, failing the test on error.
+func load(t *testing.T, store dp.PromptStore, name string, options dp.LoadPromptOptions) dp.PromptData {
+	t.Helper()
+	got, err := store.Load(name, options)
+	if err != nil {
+		t.Fatalf("Load(%q, %+v) returned error: %v", name, options, err)
+	}
+	return got
+}
+
+// listAll lists every prompt, following cursors with the given page size.
+func listAll(t *testing.T, store dp.PromptStore, options dp.ListPromptsOptions) []dp.PromptRef {
+	t.Helper()
+	var refs []dp.PromptRef
+	for page := 0; ; page++ {
+		if page > 1000 {
+			t.Fatalf("List() did not finish after %d pages; cursors must advance", page)
+		}
+		result, err := store.List(options)
+		if err != nil {
+			t.Fatalf("List(%+v) returned error: %v", options, err)
+		}
+		if options.Limit > 0 && len(result.Items) > options.Limit {
+			t.Errorf("List(%+v) returned %d items, more than the limit", options, len(result.Items))
+		}
+		refs = append(refs, result.Items...)
+		if result.Cursor == "" {
+			return refs
+		}
+		if result.Cursor == options.Cursor {
+			t.Fatalf("List(%+v) returned the same cursor again", options)
+		}
+		options.Cursor = result.Cursor
+	}
+}
+
+// listAllPartials lists every partial, following cursors.
+func listAllPartials(t *testing.T, store dp.PromptStore, options dp.ListPartialsOptions) []dp.PartialRef {
+	t.Helper()
+	var refs []dp.PartialRef
+	for page := 0; ; page++ {
+		if page > 1000 {
+			t.Fatalf("ListPartials() did not finish after %d pages; cursors must advance", page)
+		}
+		result, err := store.ListPartials(options)
+		if err != nil {
+			t.Fatalf("ListPartials(%+v) returned error: %v", options, err)
+		}
+		if options.Limit > 0 && len(result.Items) > options.Limit {
+			t.Errorf("ListPartials(%+v) returned %d items, more than the limit", options, len(result.Items))
+		}
+		refs = append(refs, result.Items...)
+		if result.Cursor == "" {
+			return refs
+		}
+		if result.Cursor == options.Cursor {
+			t.Fatalf("ListPartials(%+v) returned the same cursor again", options)
+		}
+		options.Cursor = result.Cursor
+	}
+}
+
+// keys returns the sorted `name` or `name.variant` keys of refs, ignoring
+// versions, which stores may or may not report when listing.
+func keys[T dp.PromptRef | dp.PartialRef](refs []T) []string {
+	out := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		r := dp.PromptRef(ref)
+		if r.Variant != "" {
+			out = append(out, r.Name+"."+r.Variant)
+		} else {
+			out = append(out, r.Name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// checkKeys reports a mismatch between the keys of refs and want.
+func checkKeys[T dp.PromptRef | dp.PartialRef](t *testing.T, call string, refs []T, want ...string) {
+	t.Helper()
+	got := keys(refs)
+	sort.Strings(want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s = %v, want %v", call, got, want)
+	}
+}
+
+func testLoadMissing(t *testing.T, store dp.PromptStore) {
+	if _, err := store.Load("missing", dp.LoadPromptOptions{}); err == nil {
+		t.Errorf("Load() of a missing prompt returned no error")
+	}
+	if _, err := store.LoadPartial("missing", dp.LoadPartialOptions{}); err == nil {
+		t.Errorf("LoadPartial() of a missing partial returned no error")
+	}
+}
+
+func testSaveAndLoad(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	source := "---\nmodel: test/model\n---\nHello {{name}}!\n"
+	save(t, w, prompt("greet", "", source))
+
+	got := load(t, store, "greet", dp.LoadPromptOptions{})
+	if got.Name != "greet" || got.Variant != "" || got.Source != source {
+		t.Errorf("Load() = %+v, want prompt %q with source %q", got, "greet", source)
+	}
+	if got.Version == "" {
+		t.Errorf("Load().Version is empty")
+	}
+	if again := load(t, store, "greet", dp.LoadPromptOptions{}); again.Version != got.Version {
+		t.Errorf("Load().Version changed between loads: %q, then %q", got.Version, again.Version)
+	}
+}
+
+func testOverwrite(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	save(t, w, prompt("greet", "", "v1"))
+	first := load(t, store, "greet", dp.LoadPromptOptions{})
+	save(t, w, prompt("greet", "", "v2"))
+	second := load(t, store, "greet", dp.LoadPromptOptions{})
+	if second.Source != "v2" {
+		t.Errorf("Load().Source after overwrite = %q, want %q", second.Source, "v2")
+	}
+	if second.Version == first.Version {
+		t.Errorf("Load().Version = %q for different sources", second.Version)
+	}
+}
+
+func testDelete(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	save(t, w, prompt("keep", "", "a"), prompt("gone", "", "b"), prompt("gone", "fr", "c"))
+
+	if err := w.Delete("gone", dp.PromptStoreDeleteOptions{}); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if _, err := store.Load("gone", dp.LoadPromptOptions{}); err == nil {
+		t.Errorf("Load() of a deleted prompt returned no error")
+	}
+	if got := load(t, store, "gone", dp.LoadPromptOptions{Variant: "fr"}); got.Source != "c" {
+		t.Errorf("Load() of a variant of a deleted prompt = %q, want %q", got.Source, "c")
+	}
+	checkKeys(t, "List()", listAll(t, store, dp.ListPromptsOptions{}), "gone.fr", "keep")
+
+	if err := w.Delete("gone", dp.PromptStoreDeleteOptions{Variant: "fr"}); err != nil {
+		t.Fatalf("Delete() of a variant returned error: %v", err)
+	}
+	checkKeys(t, "List()", listAll(t, store, dp.ListPromptsOptions{}), "keep")
+	if err := w.Delete("gone", dp.PromptStoreDeleteOptions{}); err == nil {
+		t.Errorf("Delete() of a missing prompt returned no error")
+	}
+}
+
+func testNestedNames(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	save(t, w, prompt("team/support/greet", "", "nested"), prompt("team/support/greet", "short", "nested short"))
+
+	got := load(t, store, "team/support/greet", dp.LoadPromptOptions{})
+	if got.Name != "team/support/greet" || got.Source != "nested" {
+		t.Errorf("Load() = %+v, want nested prompt", got)
+	}
+	got = load(t, store, "team/support/greet", dp.LoadPromptOptions{Variant: "short"})
+	if got.Variant != "short" || got.Source != "nested short" {
+		t.Errorf("Load() with variant = %+v, want nested variant", got)
+	}
+	checkKeys(t, "List()", listAll(t, store, dp.ListPromptsOptions{}), "team/support/greet", "team/support/greet.short")
+}
+
+func testVariants(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	save(t, w, prompt("greet", "", "default"), prompt("greet", "formal", "formal"), prompt("other", "formal", "other formal"))
+
+	got := load(t, store, "greet", dp.LoadPromptOptions{Variant: "formal"})
+	if got.Name != "greet" || got.Variant != "formal" || got.Source != "formal" {
+		t.Errorf("Load() with variant = %+v, want the formal variant", got)
+	}
+	got = load(t, store, "greet", dp.LoadPromptOptions{})
+	if got.Variant != "" || got.Source != "default" {
+		t.Errorf("Load() without variant = %+v, want the default prompt", got)
+	}
+	checkKeys(t, "List(Variant: formal)", listAll(t, store, dp.ListPromptsOptions{Variant: "formal"}), "greet.formal", "other.formal")
+}
+
+func testList(t *testing.T, store dp.PromptStore) {
+	if refs := listAll(t, store, dp.ListPromptsOptions{}); len(refs) != 0 {
+		t.Errorf("List() of an empty store = %v, want no prompts", refs)
+	}
+	w := writable(t, store)
+	save(t, w, prompt("b", "", "b"), prompt("a", "", "a"), prompt("a", "x", "ax"), prompt("dir/c", "", "c"))
+	if pw, ok := store.(PartialWriter); ok {
+		if err := pw.SavePartial(dp.PartialData{PartialRef: dp.PartialRef{Name: "header"}, Source: "h"}); err != nil {
+			t.Fatalf("SavePartial() returned error: %v", err)
+		}
+	}
+	checkKeys(t, "List()", listAll(t, store, dp.ListPromptsOptions{}), "a", "a.x", "b", "dir/c")
+}
+
+func testFilters(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	save(t, w,
+		prompt("support/greet", "", "---\next.tags: [faq, billing]\n---\nhi"),
+		prompt("support/greet", "short", "---\next.tags: faq\n---\nhi"),
+		prompt("support/escalate/urgent", "", "urgent"),
+		prompt("sales/greet", "", "---\next.tags: [billing]\n---\nhello"),
+	)
+	checkKeys(t, "List(Prefix: support/)", listAll(t, store, dp.ListPromptsOptions{Prefix: "support/"}),
+		"support/escalate/urgent", "support/greet", "support/greet.short")
+	checkKeys(t, "List(Glob: */greet)", listAll(t, store, dp.ListPromptsOptions{Glob: "*/greet"}),
+		"sales/greet", "support/greet", "support/greet.short")
+	checkKeys(t, "List(Tags: billing)", listAll(t, store, dp.ListPromptsOptions{Tags: []string{"billing"}}),
+		"sales/greet", "support/greet")
+	checkKeys(t, "List(Prefix: support/, Tags: faq)", listAll(t, store, dp.ListPromptsOptions{Prefix: "support/", Tags: []string{"faq"}}),
+		"support/greet", "support/greet.short")
+	checkKeys(t, "List(Tags: faq, billing, Limit: 1)", listAll(t, store, dp.ListPromptsOptions{Tags: []string{"faq", "billing"}, Limit: 1}),
+		"support/greet")
+	if _, err := store.List(dp.ListPromptsOptions{Glob: "["}); err == nil {
+		t.Errorf("List() with an invalid glob returned no error")
+	}
+}
+
+func testLoadMany(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	save(t, w, prompt("a", "", "a"), prompt("a", "x", "ax"), prompt("dir/b", "", "b"))
+
+	refs := []dp.PromptRef{{Name: "dir/b"}, {Name: "a", Variant: "x"}, {Name: "a"}}
+	prompts, err := dp.LoadMany(store, refs)
+	if err != nil {
+		t.Fatalf("LoadMany() returned error: %v", err)
+	}
+	var got []string
+	for _, p := range prompts {
+		got = append(got, p.Source)
+	}
+	if want := []string{"b", "ax", "a"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("LoadMany() sources = %v, want %v", got, want)
+	}
+	if len(prompts) == 3 {
+		if _, err := dp.LoadMany(store, []dp.PromptRef{{Name: "a", Version: prompts[2].Version}}); err != nil {
+			t.Errorf("LoadMany() with the current version returned error: %v", err)
+		}
+	}
+	if _, err := dp.LoadMany(store, []dp.PromptRef{{Name: "a"}, {Name: "missing"}}); err == nil {
+		t.Errorf("LoadMany() with a missing prompt returned no error")
+	}
+	if prompts, err := dp.LoadMany(store, nil); err != nil || len(prompts) != 0 {
+		t.Errorf("LoadMany(nil) = %v, %v, want no prompts", prompts, err)
+	}
+}
+
+func testPagination(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	var want []string
+	for i := range 7 {
+		name := fmt.Sprintf("p%d", i)
+		save(t, w, prompt(name, "", name))
+		want = append(want, name)
+	}
+	for _, limit := range []int{1, 2, 3, 7, 10} {
+		refs := listAll(t, store, dp.ListPromptsOptions{Limit: limit})
+		checkKeys(t, fmt.Sprintf("List(Limit: %d) over all pages", limit), refs, want...)
+	}
+}
+
+func testPartials(t *testing.T, store dp.PromptStore) {
+	w := partialWriter(t, store)
+	for _, partial := range []dp.PartialData{
+		{PartialRef: dp.PartialRef{Name: "header"}, Source: "Hello"},
+		{PartialRef: dp.PartialRef{Name: "header", Variant: "formal"}, Source: "Greetings"},
+		{PartialRef: dp.PartialRef{Name: "cards/item"}, Source: "{{this}}"},
+	} {
+		if err := w.SavePartial(partial); err != nil {
+			t.Fatalf("SavePartial(%q) returned error: %v", partial.Name, err)
+		}
+	}
+
+	got, err := store.LoadPartial("header", dp.LoadPartialOptions{})
+	if err != nil {
+		t.Fatalf("LoadPartial() returned error: %v", err)
+	}
+	if got.Name != "header" || got.Source != "Hello" || got.Version == "" {
+		t.Errorf("LoadPartial() = %+v, want header with a version", got)
+	}
+	got, err = store.LoadPartial("header", dp.LoadPartialOptions{Variant: "formal"})
+	if err != nil {
+		t.Fatalf("LoadPartial() with variant returned error: %v", err)
+	}
+	if got.Variant != "formal" || got.Source != "Greetings" {
+		t.Errorf("LoadPartial() with variant = %+v, want the formal variant", got)
+	}
+	got, err = store.LoadPartial("cards/item", dp.LoadPartialOptions{})
+	if err != nil {
+		t.Fatalf("LoadPartial() of a nested partial returned error: %v", err)
+	}
+	if got.Source != "{{this}}" {
+		t.Errorf("LoadPartial().Source = %q, want %q", got.Source, "{{this}}")
+	}
+
+	checkKeys(t, "ListPartials()", listAllPartials(t, store, dp.ListPartialsOptions{}), "cards/item", "header", "header.formal")
+	checkKeys(t, "ListPartials(Variant: formal)", listAllPartials(t, store, dp.ListPartialsOptions{Variant: "formal"}), "header.formal")
+	if refs := listAll(t, store, dp.ListPromptsOptions{}); len(refs) != 0 {
+		t.Errorf("List() = %v, want partials to be excluded", refs)
+	}
+	if _, err := store.Load("header", dp.LoadPromptOptions{}); err == nil {
+		t.Errorf("Load() of a partial's name returned no error")
+	}
+}
+
+func testPartialPagination(t *testing.T, store dp.PromptStore) {
+	w := partialWriter(t, store)
+	var want []string
+	for i := range 5 {
+		name := fmt.Sprintf("part%d", i)
+		if err := w.SavePartial(dp.PartialData{PartialRef: dp.PartialRef{Name: name}, Source: name}); err != nil {
+			t.Fatalf("SavePartial() returned error: %v", err)
+		}
+		want = append(want, name)
+	}
+	for _, limit := range []int{1, 2, 5} {
+		refs := listAllPartials(t, store, dp.ListPartialsOptions{Limit: limit})
+		checkKeys(t, fmt.Sprintf("ListPartials(Limit: %d) over all pages", limit), refs, want...)
+	}
+}
+
+// unsafeNames are prompt names that must never resolve outside the store.
+var unsafeNames = []string{
+	"../escape",
+	"a/../../escape",
+	"/etc/passwd",
+	"%2e%2e/escape",
+	"..\\escape",
+	"",
+}
+
+func testPathSafety(t *testing.T, store dp.PromptStore) {
+	for _, name := range unsafeNames {
+		if got, err := store.Load(name, dp.LoadPromptOptions{}); err == nil {
+			t.Errorf("Load(%q) = %+v, want an error", name, got)
+		}
+		if got, err := store.LoadPartial(name, dp.LoadPartialOptions{}); err == nil {
+			t.Errorf("LoadPartial(%q) = %+v, want an error", name, got)
+		}
+	}
+	w, ok := store.(dp.WritablePromptStore)
+	if !ok {
+		return
+	}
+	for _, name := range unsafeNames {
+		if err := w.Save(prompt(name, "", "x")); err == nil {
+			t.Errorf("Save(%q) returned no error", name)
+		}
+		if err := w.Delete(name, dp.PromptStoreDeleteOptions{}); err == nil {
+			t.Errorf("Delete(%q) returned no error", name)
+		}
+	}
+	if err := w.Save(prompt("ok", "/../../escape", "x")); err == nil {
+		t.Errorf("Save() with variant %q returned no error", "/../../escape")
+	}
+}
+
+func testVersions(t *testing.T, store dp.PromptStore) {
+	w := writable(t, store)
+	save(t, w, prompt("greet", "", "v1"))
+	v1 := load(t, store, "greet", dp.LoadPromptOptions{})
+
+	got := load(t, store, "greet", dp.LoadPromptOptions{Version: v1.Version})
+	if got.Source != "v1" || got.Version != v1.Version {
+		t.Errorf("Load() of the current version = %+v, want %+v", got, v1)
+	}
+	if got, err := store.Load("greet", dp.LoadPromptOptions{Version: "not-a-version"}); err == nil {
+		t.Errorf("Load() of an unknown version = %+v, want an error", got)
+	}
+
+	// A store may keep old versions, but must never return other content
+	// for a requested version.
+	save(t, w, prompt("greet", "", "v2"))
+	got, err := store.Load("greet", dp.LoadPromptOptions{Version: v1.Version})
+	if err == nil && (got.Source != "v1" || got.Version != v1.Version) {
+		t.Errorf("Load() of an overwritten version = %+v, want %+v or an error", got, v1)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package storetest
+
+import (
+	"testing"
+
+	dp "github.com/google/dotprompt/go/dotprompt"
+)
+
+func TestDirStoreConformance(t *testing.T) {
+	for _, keepVersions := range []bool{false, true} {
+		name := "Latest"
+		if keepVersions {
+			name = "KeepVersions"
+		}
+		t.Run(name, func(t *testing.T) {
+			RunConformanceTests(t, func() dp.PromptStore {
+				store, err := dp.NewDirStore(t.TempDir())
+				if err != nil {
+					t.Fatalf("NewDirStore() returned error: %v", err)
+				}
+				store.KeepVersions = keepVersions
+				return store
+			})
+		})
+	}
+}
+
+func TestDirStoreLayoutConformance(t *testing.T) {
+	RunConformanceTests(t, func() dp.PromptStore {
+		store, err := dp.NewDirStoreWithOptions(t.TempDir(), dp.DirStoreOptions{
+			Extension:     ".prompt.md",
+			PartialPrefix: "partials/",
+		})
+		if err != nil {
+			t.Fatalf("NewDirStoreWithOptions() returned error: %v", err)
+		}
+		return store
+	})
+}
+
+func TestWithHooksConformance(t *testing.T) {
+	RunConformanceTests(t, func() dp.PromptStore {
+		store, err := dp.NewDirStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewDirStore() returned error: %v", err)
+		}
+		return dp.WithHooks(store, dp.StoreHooks{OnSave: func(dp.PromptRef) {}})
+	})
+}
+
+// readOnlyStore hides the write methods of a store.
+type readOnlyStore struct {
+	dp.PromptStore
+}
+
+func TestReadOnlyStoreSkipsWrites(t *testing.T) {
+	RunConformanceTests(t, func() dp.PromptStore {
+		store, err := dp.NewDirStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewDirStore() returned error: %v", err)
+		}
+		return readOnlyStore{store}
+	})
+}
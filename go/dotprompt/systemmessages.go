@@ -0,0 +1,96 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import "fmt"
+
+// SystemMessagePolicy controls how rendered system messages are arranged for
+// model APIs that accept only one system message, or only one at the start.
+type SystemMessagePolicy string
+
+const (
+	// SystemMessagesKeep leaves system messages as the template wrote them.
+	// It is the default.
+	SystemMessagesKeep SystemMessagePolicy = ""
+	// SystemMessagesMerge merges all system messages into the first one,
+	// where it is.
+	SystemMessagesMerge SystemMessagePolicy = "merge"
+	// SystemMessagesFront merges all system messages into one and moves it
+	// before the other messages.
+	SystemMessagesFront SystemMessagePolicy = "front"
+)
+
+// mergedTextSeparator is the text between the texts of merged messages.
+const mergedTextSeparator = "\n\n"
+
+// ApplySystemMessagePolicy returns messages with their system messages
+// arranged according to policy. Merged messages keep the metadata of the
+// first system message, and their content is concatenated, with adjacent
+// text parts joined by a blank line. The messages are not modified.
+func ApplySystemMessagePolicy(messages []Message, policy SystemMessagePolicy) ([]Message, error) {
+	switch policy {
+	case SystemMessagesKeep:
+		return messages, nil
+	case SystemMessagesMerge, SystemMessagesFront:
+	default:
+		return nil, fmt.Errorf("unknown system message policy %q", policy)
+	}
+
+	first := -1
+	var system Message
+	rest := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role != RoleSystem {
+			rest = append(rest, msg)
+			continue
+		}
+		if first < 0 {
+			first = len(rest)
+			system = Message{HasMetadata: msg.HasMetadata, Role: RoleSystem}
+		}
+		system.Content = appendMergedContent(system.Content, msg.Content)
+	}
+	if first < 0 {
+		return messages, nil
+	}
+	if policy == SystemMessagesFront {
+		first = 0
+	}
+	out := make([]Message, 0, len(rest)+1)
+	out = append(out, rest[:first]...)
+	out = append(out, system)
+	return append(out, rest[first:]...), nil
+}
+
+// appendMergedContent appends the parts of a message to the content of the
+// message it is merged into.
+func appendMergedContent(content, parts []Part) []Part {
+	if len(content) == 0 {
+		return append(content, parts...)
+	}
+	if len(parts) == 0 {
+		return content
+	}
+	last, lastText := content[len(content)-1].(*TextPart)
+	next, nextText := parts[0].(*TextPart)
+	if !lastText || !nextText {
+		return append(content, parts...)
+	}
+	joined := &TextPart{HasMetadata: last.HasMetadata, Text: last.Text + mergedTextSeparator + next.Text}
+	content = append(content[:len(content)-1], joined)
+	return append(content, parts[1:]...)
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func textMessage(role Role, text string) Message {
+	return Message{Role: role, Content: []Part{&TextPart{Text: text}}}
+}
+
+func TestApplySystemMessagePolicy(t *testing.T) {
+	media := &MediaPart{Media: Media{URL: "https://example.com/a.png"}}
+	messages := []Message{
+		textMessage(RoleUser, "Hi"),
+		textMessage(RoleSystem, "Be brief."),
+		textMessage(RoleModel, "Hello"),
+		{Role: RoleSystem, Content: []Part{&TextPart{Text: "Be kind."}, media}},
+		textMessage(RoleUser, "Bye"),
+	}
+	merged := Message{Role: RoleSystem, Content: []Part{&TextPart{Text: "Be brief.\n\nBe kind."}, media}}
+
+	tests := []struct {
+		policy SystemMessagePolicy
+		want   []Message
+	}{
+		{SystemMessagesKeep, messages},
+		{SystemMessagesMerge, []Message{messages[0], merged, messages[2], messages[4]}},
+		{SystemMessagesFront, []Message{merged, messages[0], messages[2], messages[4]}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			got, err := ApplySystemMessagePolicy(messages, tt.policy)
+			if err != nil {
+				t.Fatalf("ApplySystemMessagePolicy() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ApplySystemMessagePolicy() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if got := messages[1].Content[0].(*TextPart).Text; got != "Be brief." {
+		t.Errorf("ApplySystemMessagePolicy() modified its input: %q", got)
+	}
+	if _, err := ApplySystemMessagePolicy(messages, "sideways"); err == nil {
+		t.Error("ApplySystemMessagePolicy() with unknown policy succeeded, want error")
+	}
+}
+
+func TestRenderSystemMessagePolicy(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{SystemMessages: SystemMessagesFront})
+	source := "{{role \"user\"}}Question{{role \"system\"}}Rule one{{#each rules}}{{role \"system\"}}{{this}}{{/each}}"
+	rendered, err := dp.Render(source, &DataArgument{Input: map[string]any{"rules": []any{"Rule two"}}}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	want := []Message{textMessage(RoleSystem, "Rule one\n\nRule two"), textMessage(RoleUser, "Question")}
+	if diff := cmp.Diff(want, rendered.Messages); diff != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+	}
+}
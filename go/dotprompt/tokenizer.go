@@ -0,0 +1,241 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pre-tokenization patterns of common byte-pair encodings, for
+// NewBPETokenizer. Go regular expressions have no lookahead, so they omit the
+// `\s+(?!\S)` alternative of the originals; BPETokenizer applies it instead.
+const (
+	// CL100KPattern is the pattern of the cl100k_base encoding.
+	CL100KPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`
+	// GPT2Pattern is the pattern of the r50k_base and p50k_base encodings.
+	GPT2Pattern = `'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+`
+)
+
+// BPETokenizer counts tokens with a byte-pair encoding in the format used by
+// tiktoken.
+type BPETokenizer struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// NewBPETokenizer reads the mergeable ranks of a byte-pair encoding in the
+// tiktoken file format, a line per token of its base64 encoding and its rank,
+// and returns a tokenizer that splits text with pattern before encoding it.
+// An empty pattern uses CL100KPattern. Special tokens are not recognized.
+func NewBPETokenizer(ranks io.Reader, pattern string) (*BPETokenizer, error) {
+	if pattern == "" {
+		pattern = CL100KPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	t := &BPETokenizer{ranks: make(map[string]int), pattern: re}
+	scanner := bufio.NewScanner(ranks)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want a token and a rank", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid token: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rank: %w", line, err)
+		}
+		t.ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CountTokens implements Tokenizer.
+func (t *BPETokenizer) CountTokens(text string) (int, error) {
+	n := 0
+	for pos := 0; pos < len(text); {
+		loc := t.pattern.FindStringIndex(text[pos:])
+		if loc == nil {
+			// Text the pattern does not cover is encoded as a whole.
+			n += t.encodedLen(text[pos:])
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if start > pos {
+			n += t.encodedLen(text[pos:start])
+		}
+		if end == start {
+			_, size := utf8.DecodeRuneInString(text[start:])
+			end += size
+		}
+		end = whitespaceLookahead(text, start, end)
+		n += t.encodedLen(text[start:end])
+		pos = end
+	}
+	return n, nil
+}
+
+// whitespaceLookahead applies the `\s+(?!\S)` rule: a run of whitespace that
+// does not end in a line break and is followed by other text leaves its last
+// character to the next piece.
+func whitespaceLookahead(text string, start, end int) int {
+	if end >= len(text) || strings.IndexFunc(text[start:end], func(r rune) bool { return !unicode.IsSpace(r) }) >= 0 {
+		return end
+	}
+	last, size := utf8.DecodeLastRuneInString(text[start:end])
+	if last == '\n' || last == '\r' || end-size == start {
+		return end
+	}
+	if next, _ := utf8.DecodeRuneInString(text[end:]); unicode.IsSpace(next) {
+		return end
+	}
+	return end - size
+}
+
+// encodedLen returns the number of tokens of piece after merging its bytes
+// in rank order.
+func (t *BPETokenizer) encodedLen(piece string) int {
+	if _, ok := t.ranks[piece]; ok {
+		return 1
+	}
+	// bounds are the start offsets of the current parts, then len(piece).
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := t.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// sentencePieceSpace is the character SentencePiece replaces spaces with.
+const sentencePieceSpace = "▁"
+
+// SentencePieceTokenizer counts tokens with a SentencePiece vocabulary.
+type SentencePieceTokenizer struct {
+	scores       map[string]float64
+	maxLen       int
+	minScore     float64
+	byteFallback bool
+}
+
+// NewSentencePieceTokenizer reads a SentencePiece vocabulary in the format of
+// the .vocab file written by spm_train, a line per piece of the piece and its
+// score separated by a tab, and returns a tokenizer that segments text into
+// the pieces with the highest total score, as the unigram model does. Spaces
+// are replaced with "▁" and one is added before the text. Characters not in
+// the vocabulary count as one token each, or as one per byte if the
+// vocabulary has byte fallback pieces such as `<0x41>`.
+func NewSentencePieceTokenizer(vocab io.Reader) (*SentencePieceTokenizer, error) {
+	t := &SentencePieceTokenizer{scores: make(map[string]float64)}
+	scanner := bufio.NewScanner(vocab)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		piece, scoreText, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want a piece and a score", line)
+		}
+		score, err := strconv.ParseFloat(scoreText, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid score: %w", line, err)
+		}
+		switch {
+		case piece == "<unk>" || piece == "<s>" || piece == "</s>":
+			continue
+		case len(piece) == 6 && strings.HasPrefix(piece, "<0x") && strings.HasSuffix(piece, ">"):
+			t.byteFallback = true
+			continue
+		}
+		t.scores[piece] = score
+		t.maxLen = max(t.maxLen, len(piece))
+		t.minScore = min(t.minScore, score)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CountTokens implements Tokenizer.
+func (t *SentencePieceTokenizer) CountTokens(text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+	text = sentencePieceSpace + strings.ReplaceAll(text, " ", sentencePieceSpace)
+
+	// best[i] is the highest score of a segmentation of text[:i], reached
+	// with tokens[i] tokens.
+	best := make([]float64, len(text)+1)
+	tokens := make([]int, len(text)+1)
+	for i := 1; i <= len(text); i++ {
+		best[i] = math.Inf(-1)
+	}
+	unknown := t.minScore - 10
+	for i := 0; i < len(text); {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		if !math.IsInf(best[i], -1) {
+			for j := i + 1; j <= min(len(text), i+t.maxLen); j++ {
+				score, ok := t.scores[text[i:j]]
+				if ok && best[i]+score > best[j] {
+					best[j], tokens[j] = best[i]+score, tokens[i]+1
+				}
+			}
+			if best[i]+unknown > best[i+size] {
+				n := 1
+				if t.byteFallback {
+					n = size
+				}
+				best[i+size], tokens[i+size] = best[i]+unknown, tokens[i]+n
+			}
+		}
+		i += size
+	}
+	return tokens[len(text)], nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens of text as a model would. NewBPETokenizer and
+// NewSentencePieceTokenizer build tokenizers from vocabulary files, and
+// ApproxTokenizer estimates without one.
+type Tokenizer interface {
+	CountTokens(text string) (int, error)
+}
+
+// TokenizerFunc adapts a function to a Tokenizer.
+type TokenizerFunc func(text string) (int, error)
+
+// CountTokens calls f(text).
+func (f TokenizerFunc) CountTokens(text string) (int, error) {
+	return f(text)
+}
+
+// MediaTokenCounter is implemented by tokenizers that know what media costs,
+// e.g. a fixed number of tokens per image. Media parts count as zero tokens
+// with other tokenizers.
+type MediaTokenCounter interface {
+	CountMediaTokens(media Media) (int, error)
+}
+
+// ApproxTokenizer estimates one token per CharsPerToken characters, or per
+// four characters if it is not positive, which is close for English text
+// with most tokenizers.
+type ApproxTokenizer struct {
+	CharsPerToken float64
+}
+
+// CountTokens implements Tokenizer.
+func (t ApproxTokenizer) CountTokens(text string) (int, error) {
+	perToken := t.CharsPerToken
+	if perToken <= 0 {
+		perToken = 4
+	}
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / perToken)), nil
+}
+
+// TokenReport is the token count of a rendered prompt.
+type TokenReport struct {
+	// Total is the sum of the tokens of the messages and the tools.
+	Total    int             `json:"total"`
+	Messages []MessageTokens `json:"messages"`
+	// Tools is the number of tokens of the tool definitions, serialized as
+	// JSON.
+	Tools int `json:"tools,omitempty"`
+}
+
+// MessageTokens is the token count of one message of a rendered prompt.
+type MessageTokens struct {
+	Role   Role         `json:"role"`
+	Tokens int          `json:"tokens"`
+	Parts  []PartTokens `json:"parts"`
+}
+
+// PartTokens is the token count of one part of a message.
+type PartTokens struct {
+	// Type is the kind of part: "text", "media", "data", "toolRequest",
+	// "toolResponse" or "pending".
+	Type   string `json:"type"`
+	Tokens int    `json:"tokens"`
+}
+
+// EstimateTokens counts the tokens of each message and part of rendered with
+// tokenizer, so that callers can budget the context window before calling the
+// model. Text parts are counted as is; data and tool parts and tool
+// definitions as their JSON serialization. Media parts are counted by
+// tokenizer if it implements MediaTokenCounter and as zero otherwise. The
+// counts do not include the tokens a model adds to delimit messages.
+func EstimateTokens(rendered *RenderedPrompt, tokenizer Tokenizer) (TokenReport, error) {
+	var report TokenReport
+	if rendered == nil {
+		return report, nil
+	}
+	for i, msg := range rendered.Messages {
+		mt := MessageTokens{Role: msg.Role, Parts: make([]PartTokens, 0, len(msg.Content))}
+		for j, part := range msg.Content {
+			pt, err := partTokens(part, tokenizer)
+			if err != nil {
+				return TokenReport{}, fmt.Errorf("message %d, part %d: %w", i, j, err)
+			}
+			mt.Parts = append(mt.Parts, pt)
+			mt.Tokens += pt.Tokens
+		}
+		report.Messages = append(report.Messages, mt)
+		report.Total += mt.Tokens
+	}
+	for _, def := range rendered.ToolDefs {
+		n, err := jsonTokens(def, tokenizer)
+		if err != nil {
+			return TokenReport{}, fmt.Errorf("tool %q: %w", def.Name, err)
+		}
+		report.Tools += n
+	}
+	report.Total += report.Tools
+	return report, nil
+}
+
+// partTokens counts the tokens of a single part.
+func partTokens(part Part, tokenizer Tokenizer) (PartTokens, error) {
+	var pt PartTokens
+	var err error
+	switch p := part.(type) {
+	case *TextPart:
+		pt.Type = "text"
+		pt.Tokens, err = tokenizer.CountTokens(p.Text)
+	case *MediaPart:
+		pt.Type = "media"
+		if counter, ok := tokenizer.(MediaTokenCounter); ok {
+			pt.Tokens, err = counter.CountMediaTokens(p.Media)
+		}
+	case *DataPart:
+		pt.Type = "data"
+		pt.Tokens, err = jsonTokens(p.Data, tokenizer)
+	case *ToolRequestPart:
+		pt.Type = "toolRequest"
+		pt.Tokens, err = jsonTokens(p.ToolRequest, tokenizer)
+	case *ToolResponsePart:
+		pt.Type = "toolResponse"
+		pt.Tokens, err = jsonTokens(p.ToolResponse, tokenizer)
+	case *PendingPart:
+		pt.Type = "pending"
+	default:
+		return PartTokens{}, fmt.Errorf("unsupported part type %T", part)
+	}
+	return pt, err
+}
+
+// jsonTokens counts the tokens of the JSON serialization of value.
+func jsonTokens(value any, tokenizer Tokenizer) (int, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return 0, err
+	}
+	return tokenizer.CountTokens(string(b))
+}
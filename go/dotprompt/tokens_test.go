@@ -0,0 +1,178 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testRanks returns a tiktoken ranks file with every byte and the given
+// merges, ranked in order after the bytes.
+func testRanks(merges ...string) string {
+	var b strings.Builder
+	for i := range 256 {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, m := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), 256+i)
+	}
+	return b.String()
+}
+
+func TestBPETokenizer(t *testing.T) {
+	tokenizer, err := NewBPETokenizer(strings.NewReader(testRanks("he", "ll", "hell", " w", "or", " wor", " world", "12")), "")
+	if err != nil {
+		t.Fatalf("NewBPETokenizer() returned error: %v", err)
+	}
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 2},        // hell + o
+		{"hello world", 3},  // hell + o + " world"
+		{"hello  world", 4}, // hell + o + " " + " world"
+		{"1234", 3},         // 12 + 3, then 4
+		{"hi!\n\n", 5},      // h + i, then "!\n\n" as three bytes
+		{"héllo", 5},        // h + é (2 bytes) + ll + o
+		{"  \n  world", 5},  // "  \n" as three bytes, " " and " world"
+	}
+	for _, tt := range tests {
+		got, err := tokenizer.CountTokens(tt.text)
+		if err != nil {
+			t.Fatalf("CountTokens(%q) returned error: %v", tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := NewBPETokenizer(strings.NewReader("aGk=\n"), ""); err == nil {
+		t.Error("NewBPETokenizer() with missing rank succeeded, want error")
+	}
+	if _, err := NewBPETokenizer(strings.NewReader(""), "(?!x)"); err == nil {
+		t.Error("NewBPETokenizer() with invalid pattern succeeded, want error")
+	}
+}
+
+func TestSentencePieceTokenizer(t *testing.T) {
+	vocab := "<unk>\t0\n<s>\t0\n</s>\t0\n▁hello\t-1\n▁\t-2\nhe\t-3\nllo\t-3\n▁world\t-1.5\nw\t-4\no\t-4\nr\t-4\nl\t-4\nd\t-4\n"
+	tokenizer, err := NewSentencePieceTokenizer(strings.NewReader(vocab))
+	if err != nil {
+		t.Fatalf("NewSentencePieceTokenizer() returned error: %v", err)
+	}
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello world", 2}, // ▁hello ▁world
+		{"world", 1},       // ▁world
+		{"wold", 5},        // ▁ w o l d
+		{"hello ∑", 3},     // ▁hello ▁ ∑
+	}
+	for _, tt := range tests {
+		got, err := tokenizer.CountTokens(tt.text)
+		if err != nil {
+			t.Fatalf("CountTokens(%q) returned error: %v", tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	byteFallback, err := NewSentencePieceTokenizer(strings.NewReader(vocab + "<0x00>\t0\n"))
+	if err != nil {
+		t.Fatalf("NewSentencePieceTokenizer() returned error: %v", err)
+	}
+	if got, err := byteFallback.CountTokens("hello ∑"); err != nil || got != 5 {
+		t.Errorf("CountTokens() with byte fallback = %d, %v, want 5", got, err)
+	}
+
+	if _, err := NewSentencePieceTokenizer(strings.NewReader("piece\n")); err == nil {
+		t.Error("NewSentencePieceTokenizer() with missing score succeeded, want error")
+	}
+}
+
+// imageTokenizer counts a token per word and a fixed cost per media part.
+type imageTokenizer struct{}
+
+func (imageTokenizer) CountTokens(text string) (int, error) {
+	return len(strings.Fields(text)), nil
+}
+
+func (imageTokenizer) CountMediaTokens(media Media) (int, error) {
+	return 258, nil
+}
+
+func TestEstimateTokens(t *testing.T) {
+	rendered := &RenderedPrompt{
+		PromptMetadata: PromptMetadata{
+			ToolDefs: []ToolDefinition{{Name: "search", Description: "Searches"}},
+		},
+		Messages: []Message{
+			{Role: RoleSystem, Content: []Part{&TextPart{Text: "Be brief and kind."}}},
+			{Role: RoleUser, Content: []Part{
+				&TextPart{Text: "What is this?"},
+				&MediaPart{Media: Media{URL: "https://example.com/a.png"}},
+				NewPendingPart(),
+			}},
+			{Role: RoleModel, Content: []Part{&DataPart{Data: map[string]any{"answer": "a cat"}}}},
+		},
+	}
+
+	got, err := EstimateTokens(rendered, imageTokenizer{})
+	if err != nil {
+		t.Fatalf("EstimateTokens() returned error: %v", err)
+	}
+	want := TokenReport{
+		Total: 4 + 3 + 258 + 2 + 1,
+		Messages: []MessageTokens{
+			{Role: RoleSystem, Tokens: 4, Parts: []PartTokens{{Type: "text", Tokens: 4}}},
+			{Role: RoleUser, Tokens: 3 + 258, Parts: []PartTokens{
+				{Type: "text", Tokens: 3},
+				{Type: "media", Tokens: 258},
+				{Type: "pending"},
+			}},
+			// {"answer":"a cat"} is two words.
+			{Role: RoleModel, Tokens: 2, Parts: []PartTokens{{Type: "data", Tokens: 2}}},
+		},
+		// The JSON of the tool definition has no spaces.
+		Tools: 1,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("EstimateTokens() mismatch (-want +got):\n%s", diff)
+	}
+
+	approx, err := EstimateTokens(rendered, ApproxTokenizer{})
+	if err != nil {
+		t.Fatalf("EstimateTokens() returned error: %v", err)
+	}
+	if approx.Messages[0].Tokens != 5 || approx.Messages[1].Parts[1].Tokens != 0 {
+		t.Errorf("EstimateTokens() with ApproxTokenizer = %+v, want 5 tokens for 18 characters and no media tokens", approx)
+	}
+
+	failing := TokenizerFunc(func(string) (int, error) { return 0, fmt.Errorf("boom") })
+	if _, err := EstimateTokens(rendered, failing); err == nil || !strings.Contains(err.Error(), "message 0, part 0") {
+		t.Errorf("EstimateTokens() error = %v, want error locating the part", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mbleigh/raymond"
+)
+
+// RenderTrace records how a prompt was rendered, to answer "why does my
+// prompt look like this?".
+type RenderTrace struct {
+	// Partials are the partial expansions, in the order they started.
+	Partials []PartialTrace `json:"partials,omitempty"`
+	// Helpers are the calls to dotprompt and user-defined helpers, in the
+	// order they were made. Handlebars built-ins such as `if` and `each` are
+	// not recorded.
+	Helpers []HelperCall `json:"helpers,omitempty"`
+	// Fields are the input fields referenced by the template and by the
+	// partials it expanded. References inside branches that were not taken
+	// are included.
+	Fields []TemplateVariable `json:"fields,omitempty"`
+	// Duration is the time taken to execute the template.
+	Duration time.Duration `json:"duration"`
+
+	mu      sync.Mutex
+	stack   []int
+	sources map[string]string
+}
+
+// PartialTrace is a single expansion of a partial.
+type PartialTrace struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	// Depth is the nesting depth of the expansion; partials included directly
+	// by the template have depth 0.
+	Depth    int           `json:"depth"`
+	Duration time.Duration `json:"duration"`
+}
+
+// HelperCall is a single helper invocation.
+type HelperCall struct {
+	Name string `json:"name"`
+	Args []any  `json:"args,omitempty"`
+	// Hash holds the hash arguments, e.g. `indent` for `{{json x indent=2}}`.
+	Hash map[string]any `json:"hash,omitempty"`
+}
+
+// traceHelperName is the block helper that wraps partial sources while
+// tracing. It is not a valid name for a user helper.
+const traceHelperName = "__dotpromptTracePartial"
+
+// RenderWithTrace renders source like Render and also returns a trace of the
+// partials expanded, helpers called and input fields referenced.
+func (dp *Dotprompt) RenderWithTrace(source string, data *DataArgument, options *PromptMetadata) (RenderedPrompt, *RenderTrace, error) {
+	trace := &RenderTrace{}
+	renderer, err := dp.compile(source, options, trace)
+	if err != nil {
+		return RenderedPrompt{}, nil, err
+	}
+	rendered, err := renderer(data, options)
+	if err != nil {
+		return RenderedPrompt{}, trace, err
+	}
+	return rendered, trace, nil
+}
+
+// wrapHelper returns helper instrumented to record its calls. The wrapper has
+// the same signature as helper, so raymond passes it the same arguments.
+func (t *RenderTrace) wrapHelper(name string, helper any) any {
+	fn := reflect.ValueOf(helper)
+	if fn.Kind() != reflect.Func {
+		return helper
+	}
+	optionsType := reflect.TypeOf(&raymond.Options{})
+	return reflect.MakeFunc(fn.Type(), func(in []reflect.Value) []reflect.Value {
+		call := HelperCall{Name: name}
+		for _, arg := range in {
+			if arg.Type() == optionsType {
+				if hash := arg.Interface().(*raymond.Options).Hash(); len(hash) > 0 {
+					call.Hash = hash
+				}
+				continue
+			}
+			call.Args = append(call.Args, arg.Interface())
+		}
+		t.mu.Lock()
+		t.Helpers = append(t.Helpers, call)
+		t.mu.Unlock()
+		if fn.Type().IsVariadic() {
+			return fn.CallSlice(in)
+		}
+		return fn.Call(in)
+	}).Interface()
+}
+
+// wrapPartial returns the source of a partial wrapped in the trace block
+// helper. The opening tag stands alone on its line, so Handlebars strips it
+// along with its newline and the partial renders unchanged.
+func (t *RenderTrace) wrapPartial(name, source string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sources == nil {
+		t.sources = make(map[string]string)
+	}
+	t.sources[name] = source
+	return "{{#" + traceHelperName + " " + quoteHandlebars(name) + "}}\n" + source + "{{/" + traceHelperName + "}}"
+}
+
+// partialHelper is the block helper that records a partial expansion.
+func (t *RenderTrace) partialHelper(name string, options *raymond.Options) raymond.SafeString {
+	t.mu.Lock()
+	i := len(t.Partials)
+	t.Partials = append(t.Partials, PartialTrace{Name: name, Source: t.sources[name], Depth: len(t.stack)})
+	t.stack = append(t.stack, i)
+	t.mu.Unlock()
+
+	start := time.Now()
+	out := options.Fn()
+	elapsed := time.Since(start)
+
+	t.mu.Lock()
+	t.Partials[i].Duration = elapsed
+	t.stack = t.stack[:len(t.stack)-1]
+	t.mu.Unlock()
+	return raymond.SafeString(out)
+}
+
+// finish records the template duration and the fields referenced by the
+// template and the expanded partials.
+func (t *RenderTrace) finish(template string, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Duration = elapsed
+	t.Fields, _ = TemplateVariables(template)
+	seen := make(map[string]bool)
+	for _, p := range t.Partials {
+		if seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+		vars, _ := TemplateVariables(p.Source)
+		t.Fields = append(t.Fields, vars...)
+	}
+}
+
+// quoteHandlebars quotes s as a Handlebars string literal.
+func quoteHandlebars(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
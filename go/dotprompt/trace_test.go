@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderWithTrace(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{
+		Partials: map[string]string{
+			"header": "{{#if title}}\n# {{title}}\n{{/if}}\n{{> footer}}",
+			"footer": "-- {{signature}}\n",
+			"unused": "{{never}}",
+		},
+		Helpers: map[string]any{
+			"shout": func(s string) string { return s + "!" },
+		},
+	})
+	source := "{{> header}}\nHi {{shout name}} {{json tags indent=2}}"
+	data := &DataArgument{Input: map[string]any{
+		"title":     "Welcome",
+		"name":      "Ada",
+		"signature": "Bob",
+		"tags":      []any{"a"},
+	}}
+
+	rendered, trace, err := dp.RenderWithTrace(source, data, nil)
+	if err != nil {
+		t.Fatalf("RenderWithTrace() returned error: %v", err)
+	}
+	plain, err := NewDotprompt(&DotpromptOptions{
+		Partials: dp.Partials,
+		Helpers:  map[string]any{"shout": func(s string) string { return s + "!" }},
+	}).Render(source, data, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if diff := cmp.Diff(plain.Messages, rendered.Messages); diff != "" {
+		t.Errorf("RenderWithTrace() messages differ from Render() (-want +got):\n%s", diff)
+	}
+
+	var partials []string
+	for _, p := range trace.Partials {
+		partials = append(partials, p.Name)
+		if p.Source != dp.Partials[p.Name] {
+			t.Errorf("partial %q source = %q, want %q", p.Name, p.Source, dp.Partials[p.Name])
+		}
+	}
+	if diff := cmp.Diff([]string{"header", "footer"}, partials); diff != "" {
+		t.Errorf("Partials mismatch (-want +got):\n%s", diff)
+	}
+	if trace.Partials[1].Depth != 1 {
+		t.Errorf("footer depth = %d, want 1", trace.Partials[1].Depth)
+	}
+
+	wantHelpers := []HelperCall{
+		{Name: "shout", Args: []any{"Ada"}},
+		{Name: "json", Args: []any{[]any{"a"}}, Hash: map[string]any{"indent": 2}},
+	}
+	if diff := cmp.Diff(wantHelpers, trace.Helpers); diff != "" {
+		t.Errorf("Helpers mismatch (-want +got):\n%s", diff)
+	}
+
+	var fields []string
+	for _, f := range trace.Fields {
+		fields = append(fields, f.Name)
+	}
+	if diff := cmp.Diff([]string{"name", "tags", "title", "title", "signature"}, fields); diff != "" {
+		t.Errorf("Fields mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderWithTraceLeavesCompileUntraced(t *testing.T) {
+	dp := NewDotprompt(&DotpromptOptions{Partials: map[string]string{"p": "x"}})
+	if _, _, err := dp.RenderWithTrace("{{> p}}", &DataArgument{}, nil); err != nil {
+		t.Fatalf("RenderWithTrace() returned error: %v", err)
+	}
+	rendered, err := dp.Render("{{> p}}{{json 1}}", &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	if got := rendered.Messages[0].Content[0].(*TextPart).Text; got != "x1" {
+		t.Errorf("Render() text = %q, want %q", got, "x1")
+	}
+	if dp.trace != nil {
+		t.Error("dp.trace is set after RenderWithTrace")
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package treesitter
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml"
+)
+
+// DefaultIndentWidth is the indentation of nested frontmatter fields when
+// FormatOptions.IndentWidth is zero.
+const DefaultIndentWidth = 2
+
+// FormatOptions configures FormatPrompt.
+type FormatOptions struct {
+	// IndentWidth is the indentation of each level of nested frontmatter
+	// fields. Zero means DefaultIndentWidth.
+	IndentWidth int
+	// WrapColumn, if positive, wraps lines of template text longer than it at
+	// spaces. Headings, tables, quotes, code and Handlebars expressions are
+	// never wrapped.
+	WrapColumn int
+}
+
+// FormatPrompt formats the source of a `.prompt` file:
+//
+//   - The frontmatter is reindented with IndentWidth spaces per level,
+//     separates keys from values with a single space, loses trailing
+//     whitespace and runs of blank lines, and keeps its comments. If the
+//     frontmatter is not valid YAML, or the result would decode to a
+//     different value, it is kept as it was.
+//   - Role, history, section and media markers written as helpers are
+//     normalized to `{{role "user"}}` spacing.
+//   - With WrapColumn set, prose is wrapped at that column.
+//
+// Everything else, including Handlebars expressions, blocks and comments,
+// is preserved byte for byte.
+func FormatPrompt(src []byte, opts FormatOptions) ([]byte, error) {
+	if opts.IndentWidth <= 0 {
+		opts.IndentWidth = DefaultIndentWidth
+	}
+	t, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	newline := "\n"
+	if bytes.Contains(src, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+
+	// Format the body from the tokens, noting which bytes are spaces of
+	// template text that may be broken for wrapping.
+	bodyStart := t.Body().StartByte
+	syntaxErrors := t.Errors()
+	var out []byte
+	var breakable []bool
+	for _, tok := range t.Tokens() {
+		text := tok.Text
+		if tok.Kind == TokenMarker {
+			text = normalizeMarker(text)
+		}
+		canBreak := tok.Kind == TokenText && tok.StartByte >= bodyStart && !overlapsAny(tok.Span, syntaxErrors)
+		for i := 0; i < len(text); i++ {
+			breakable = append(breakable, canBreak && text[i] == ' ')
+		}
+		out = append(out, text...)
+	}
+	if opts.WrapColumn > 0 {
+		out = wrap(out, breakable, bodyStart, opts.WrapColumn, newline)
+	}
+
+	// The frontmatter precedes every change so far.
+	frontmatter, ok := t.Frontmatter()
+	if !ok {
+		return out, nil
+	}
+	formatted := formatFrontmatter(frontmatter.Text, opts.IndentWidth, newline)
+	var b bytes.Buffer
+	b.Write(out[:frontmatter.StartByte])
+	b.WriteString(formatted)
+	b.Write(out[frontmatter.EndByte:])
+	return b.Bytes(), nil
+}
+
+// overlapsAny reports whether span overlaps one of errs.
+func overlapsAny(span Span, errs []SyntaxError) bool {
+	for _, e := range errs {
+		if e.StartByte < span.EndByte && span.StartByte < e.EndByte {
+			return true
+		}
+	}
+	return false
+}
+
+// markerRe matches a marker helper expression, capturing its delimiters and
+// content.
+var markerRe = regexp.MustCompile(`^(\{\{~?)\s*([\s\S]*?)\s*(~?\}\})$`)
+
+// normalizeMarker trims the space inside the delimiters of a marker helper
+// and collapses the space between its arguments, outside string literals.
+// Literal `<<<dotprompt:...>>>` markers are returned unchanged.
+func normalizeMarker(marker string) string {
+	m := markerRe.FindStringSubmatch(marker)
+	if m == nil {
+		return marker
+	}
+	var b strings.Builder
+	var quote byte
+	space := false
+	for i := 0; i < len(m[2]); i++ {
+		c := m[2][i]
+		switch {
+		case quote != 0:
+			if c == quote && m[2][i-1] != '\\' {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(c)
+	}
+	return m[1] + b.String() + m[3]
+}
+
+var (
+	// listItemRe matches the marker of a Markdown list item and the space
+	// after it.
+	listItemRe = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d+[.)])[ \t]+`)
+	// blockStartRe matches words that would start a Markdown block at the
+	// start of a line.
+	blockStartRe = regexp.MustCompile(`^(?:[-*+#>|=]+|\d+[.)])$`)
+)
+
+// wrap wraps the lines of src after offset start that are longer than
+// column, breaking only at the breakable spaces.
+func wrap(src []byte, breakable []bool, start, column int, newline string) []byte {
+	out := append([]byte(nil), src[:start]...)
+	fenced := false
+	for offset := start; offset < len(src); {
+		end := bytes.IndexByte(src[offset:], '\n')
+		if end < 0 {
+			end = len(src)
+		} else {
+			end += offset
+		}
+		line := bytes.TrimSuffix(src[offset:end], []byte("\r"))
+		trimmed := bytes.TrimLeft(line, " \t")
+		if bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")) {
+			fenced = !fenced
+			out = append(out, line...)
+		} else if fenced || skipWrap(line, trimmed) || utf8.RuneCount(line) <= column {
+			out = append(out, line...)
+		} else {
+			out = append(out, wrapLine(line, breakable[offset:offset+len(line)], column, newline)...)
+		}
+		out = append(out, src[offset+len(line):min(end+1, len(src))]...)
+		offset = end + 1
+	}
+	return out
+}
+
+// skipWrap reports whether line must not be wrapped: headings, tables,
+// quotes and indented code.
+func skipWrap(line, trimmed []byte) bool {
+	if len(trimmed) == 0 || bytes.HasPrefix(line, []byte("    ")) || bytes.HasPrefix(line, []byte("\t")) {
+		return true
+	}
+	switch trimmed[0] {
+	case '#', '|', '>':
+		return true
+	}
+	return false
+}
+
+// wrapLine wraps a line greedily at column. Continuation lines are indented
+// like the line, or past the marker of a list item.
+func wrapLine(line []byte, breakable []bool, column int, newline string) []byte {
+	prefixLen := len(line) - len(bytes.TrimLeft(line, " \t"))
+	indent := string(line[:prefixLen])
+	if m := listItemRe.Find(line); m != nil {
+		indent = string(line[:prefixLen]) + strings.Repeat(" ", len(m)-prefixLen)
+	}
+
+	// Split the line into words at runs of breakable spaces.
+	type word struct{ text, sep string }
+	var words []word
+	wordStart := prefixLen
+	for i := prefixLen; i <= len(line); i++ {
+		if i < len(line) && !breakable[i] {
+			continue
+		}
+		sepEnd := i
+		for sepEnd < len(line) && breakable[sepEnd] {
+			sepEnd++
+		}
+		if i > wordStart || sepEnd == len(line) {
+			words = append(words, word{string(line[wordStart:i]), string(line[i:sepEnd])})
+		} else if len(words) > 0 {
+			words[len(words)-1].sep += string(line[i:sepEnd])
+		}
+		wordStart = sepEnd
+		i = sepEnd
+	}
+
+	var b strings.Builder
+	b.WriteString(indent[:prefixLen])
+	width := utf8.RuneCountInString(indent[:prefixLen])
+	for i, w := range words {
+		n := utf8.RuneCountInString(w.text)
+		if i > 0 {
+			sep := words[i-1].sep
+			if width+len(sep)+n > column && !blockStartRe.MatchString(w.text) {
+				b.WriteString(newline)
+				b.WriteString(indent)
+				width = utf8.RuneCountInString(indent)
+			} else {
+				b.WriteString(sep)
+				width += len(sep)
+			}
+		}
+		b.WriteString(w.text)
+		width += n
+	}
+	if len(words) > 0 {
+		b.WriteString(words[len(words)-1].sep)
+	}
+	return []byte(b.String())
+}
+
+// blockScalarRe matches a line that starts a YAML block scalar.
+var blockScalarRe = regexp.MustCompile(`:[ \t]+[|>][-+0-9]*[ \t]*(?:#.*)?$|^[ \t]*-[ \t]+[|>][-+0-9]*[ \t]*$`)
+
+// keySpaceRe matches a plain mapping key followed by more than one space.
+var keySpaceRe = regexp.MustCompile(`^((?:-[ \t]+)?[A-Za-z0-9_.\-]+:)[ \t]{2,}(\S)`)
+
+// formatFrontmatter formats YAML as described by FormatPrompt.
+func formatFrontmatter(src string, width int, newline string) string {
+	before, err := decodeYAML(src)
+	if err != nil {
+		// Picoschema shorthands such as `items: {name: string}[]` are not
+		// YAML, but the frontmatter may still be read by other tools.
+		return src
+	}
+
+	type level struct {
+		orig, indent int
+		// dash is the width of the `- ` of a sequence entry, whose mapping
+		// continues at that offset.
+		dash int
+	}
+	var stack []level
+	var lines []string
+	// block is the original indentation of the key of the block scalar
+	// being copied, or -1, and blockIndent the indentation of its content.
+	block, blockOrig, blockIndent := -1, -1, 0
+	blank := false
+	for _, raw := range strings.Split(src, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		content := strings.TrimLeft(raw, " ")
+		orig := len(raw) - len(content)
+
+		if block >= 0 {
+			if strings.TrimSpace(raw) == "" {
+				lines = append(lines, "")
+				continue
+			}
+			if orig > block {
+				if blockOrig < 0 {
+					blockOrig = orig
+				}
+				if orig >= blockOrig {
+					lines = append(lines, strings.Repeat(" ", blockIndent)+raw[blockOrig:])
+				} else {
+					lines = append(lines, raw)
+				}
+				continue
+			}
+			block = -1
+		}
+
+		content = strings.TrimRight(content, " \t")
+		if content == "" {
+			if len(lines) > 0 {
+				blank = true
+			}
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].orig > orig {
+			stack = stack[:len(stack)-1]
+		}
+		var indent int
+		switch {
+		case strings.HasPrefix(content, "#"):
+			// Comments take the indentation of their level.
+			if len(stack) > 0 {
+				indent = stack[len(stack)-1].indent
+			}
+		case len(stack) > 0 && stack[len(stack)-1].orig == orig:
+			indent = stack[len(stack)-1].indent
+			stack[len(stack)-1].dash = dashWidth(content)
+		default:
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				indent = top.indent + width
+				if top.dash > 0 && orig == top.orig+top.dash {
+					indent = top.indent + top.dash
+				}
+			}
+			stack = append(stack, level{orig: orig, indent: indent, dash: dashWidth(content)})
+		}
+
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		content = keySpaceRe.ReplaceAllString(content, "$1 $2")
+		lines = append(lines, strings.Repeat(" ", indent)+content)
+		if blockScalarRe.MatchString(content) {
+			block, blockOrig, blockIndent = orig, -1, indent+width
+		}
+	}
+	// Blank lines kept inside a trailing block scalar are dropped.
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	formatted := strings.Join(lines, newline)
+
+	after, err := decodeYAML(formatted)
+	if err != nil || !reflect.DeepEqual(before, after) {
+		return src
+	}
+	return formatted
+}
+
+// dashWidth returns the width of the `- ` that starts a sequence entry, or 0.
+func dashWidth(content string) int {
+	if !strings.HasPrefix(content, "-") {
+		return 0
+	}
+	rest := strings.TrimLeft(content[1:], " ")
+	if len(rest) == len(content)-1 {
+		return 0
+	}
+	return len(content) - len(rest)
+}
+
+// decodeYAML decodes YAML, recovering from the panics of the YAML library on
+// some malformed input.
+func decodeYAML(src string) (v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while parsing YAML: %v", r)
+		}
+	}()
+	err = yaml.Unmarshal([]byte(src), &v)
+	return v, err
+}
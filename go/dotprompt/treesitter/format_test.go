@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package treesitter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatPrompt(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		opts   FormatOptions
+		want   string
+	}{
+		{
+			name:   "frontmatter indentation and spacing",
+			source: "---\nmodel:    a/b   \n\n\n\nconfig:\n    temperature: 1\n    stop:\n    - a\n    - b\ninput:\n    schema:\n        name: string # the name\n---\nHi\n",
+			want:   "---\nmodel: a/b\n\nconfig:\n  temperature: 1\n  stop:\n  - a\n  - b\ninput:\n  schema:\n    name: string # the name\n---\nHi\n",
+		},
+		{
+			name:   "sequence of mappings",
+			source: "---\ntools:\n   - name: a\n     description: b\n---\n",
+			opts:   FormatOptions{IndentWidth: 4},
+			want:   "---\ntools:\n    - name: a\n      description: b\n---\n",
+		},
+		{
+			name:   "block scalar",
+			source: "---\ndescription: |\n      line one\n        indented\n\n      line three\nmodel: a/b\n---\n",
+			want:   "---\ndescription: |\n  line one\n    indented\n\n  line three\nmodel: a/b\n---\n",
+		},
+		{
+			name:   "markers",
+			source: "{{ role  \"system\" }}Hi{{~role \"user\"~}}{{  history }}{{media url=\"a  b\"}}",
+			want:   "{{role \"system\"}}Hi{{~role \"user\"~}}{{history}}{{media url=\"a  b\"}}",
+		},
+		{
+			name:   "expressions are preserved",
+			source: "{{#if  x }}{{ name }}{{/if}}{{! keep   this }}",
+			want:   "{{#if  x }}{{ name }}{{/if}}{{! keep   this }}",
+		},
+		{
+			name:   "wrap",
+			source: "---\nmodel: a/b\n---\nThe quick brown fox jumps over {{the lazy}} dog and keeps running.\n",
+			opts:   FormatOptions{WrapColumn: 20},
+			want:   "---\nmodel: a/b\n---\nThe quick brown fox\njumps over\n{{the lazy}} dog and\nkeeps running.\n",
+		},
+		{
+			name:   "wrap list items and skip headings and code",
+			source: "# A heading that is much too long\n- a list item that is too long\n```\ncode that is also too long\n```\n",
+			opts:   FormatOptions{WrapColumn: 16},
+			want:   "# A heading that is much too long\n- a list item\n  that is too\n  long\n```\ncode that is also too long\n```\n",
+		},
+		{
+			name:   "crlf",
+			source: "---\r\nmodel:  a/b\r\n---\r\none two three\r\n",
+			opts:   FormatOptions{WrapColumn: 8},
+			want:   "---\r\nmodel: a/b\r\n---\r\none two\r\nthree\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatPrompt([]byte(tt.source), tt.opts)
+			if err != nil {
+				t.Fatalf("FormatPrompt() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("FormatPrompt() mismatch (-want +got):\n%s", diff)
+			}
+			again, err := FormatPrompt(got, tt.opts)
+			if err != nil {
+				t.Fatalf("FormatPrompt() of formatted source returned error: %v", err)
+			}
+			if diff := cmp.Diff(string(got), string(again)); diff != "" {
+				t.Errorf("FormatPrompt() is not idempotent (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatPromptKeepsInvalidFrontmatter(t *testing.T) {
+	source := "---\nitems:   {name: string}[]\n---\n{{ role  \"user\" }}Hi"
+	got, err := FormatPrompt([]byte(source), FormatOptions{})
+	if err != nil {
+		t.Fatalf("FormatPrompt() returned error: %v", err)
+	}
+	want := "---\nitems:   {name: string}[]\n---\n{{role \"user\"}}Hi"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("FormatPrompt() mismatch (-want +got):\n%s", diff)
+	}
+}
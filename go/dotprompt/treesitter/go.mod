@@ -0,0 +1,28 @@
+module github.com/google/dotprompt/go/dotprompt/treesitter
+
+go 1.24.11
+
+require (
+	github.com/goccy/go-yaml v1.19.0
+	github.com/google/dotprompt/go v0.0.0
+	github.com/google/dotprompt/packages/treesitter/bindings/go v0.0.0
+	github.com/google/go-cmp v0.7.0
+	github.com/smacker/go-tree-sitter v0.0.0-20240827094217-dd81d9e9be82
+)
+
+require (
+	github.com/bahlo/generic-list-go v0.2.0 // indirect
+	github.com/buger/jsonparser v1.1.1 // indirect
+	github.com/go-viper/mapstructure/v2 v2.4.0 // indirect
+	github.com/invopop/jsonschema v0.13.0 // indirect
+	github.com/mailru/easyjson v0.9.1 // indirect
+	github.com/mbleigh/raymond v0.0.0-20250414171441-6b3a58ab9e0a // indirect
+	github.com/wk8/go-ordered-map/v2 v2.1.8 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/google/dotprompt/go => ../..
+	github.com/google/dotprompt/packages/treesitter/bindings/go => ../../../packages/treesitter/bindings/go
+)
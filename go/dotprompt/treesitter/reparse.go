@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package treesitter
+
+import (
+	"context"
+	"slices"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	grammar "github.com/google/dotprompt/packages/treesitter/bindings/go"
+)
+
+// Edit replaces the bytes source[StartByte:OldEndByte] of a parsed file with
+// Text, as an editor does on each keystroke.
+type Edit struct {
+	StartByte  int    `json:"startByte"`
+	OldEndByte int    `json:"oldEndByte"`
+	Text       string `json:"text"`
+}
+
+// Reparse applies edit to the source of old and parses the result, reusing
+// the unchanged parts of old's syntax tree. old is left untouched and remains
+// valid. The edit offsets must lie within old's source; they are clamped
+// otherwise.
+func Reparse(old *PromptTree, edit Edit) *PromptTree {
+	start := min(max(edit.StartByte, 0), len(old.source))
+	oldEnd := min(max(edit.OldEndByte, start), len(old.source))
+	newEnd := start + len(edit.Text)
+
+	source := slices.Concat(old.source[:start], []byte(edit.Text), old.source[oldEnd:])
+
+	tree := old.tree.Copy()
+	tree.Edit(sitter.EditInput{
+		StartIndex:  uint32(start),
+		OldEndIndex: uint32(oldEnd),
+		NewEndIndex: uint32(newEnd),
+		StartPoint:  point(old.source, start),
+		OldEndPoint: point(old.source, oldEnd),
+		NewEndPoint: point(source, newEnd),
+	})
+
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(grammar.GetLanguage())
+	next, err := parser.ParseCtx(context.Background(), tree, source)
+	if err != nil {
+		// Parsing without a deadline only fails if the language is unusable,
+		// in which case Parse would have failed for old too.
+		next = tree
+	}
+	return &PromptTree{source: source, tree: next}
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package treesitter
+
+import (
+	"testing"
+)
+
+func TestReparse(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		edit   Edit
+		want   string
+	}{
+		{
+			name:   "insert expression",
+			source: "---\nmodel: a/b\n---\nHello !\n",
+			edit:   Edit{StartByte: 25, OldEndByte: 25, Text: "{{name}}"},
+			want:   "---\nmodel: a/b\n---\nHello {{name}}!\n",
+		},
+		{
+			name:   "replace frontmatter",
+			source: "---\nmodel: a/b\n---\nHello\n",
+			edit:   Edit{StartByte: 11, OldEndByte: 14, Text: "c/d\nconfig:\n  temperature: 1"},
+			want:   "---\nmodel: c/d\nconfig:\n  temperature: 1\n---\nHello\n",
+		},
+		{
+			name:   "delete across lines",
+			source: "Hello\n{{#if x}}\nthere\n{{/if}}\n",
+			edit:   Edit{StartByte: 5, OldEndByte: 22, Text: ""},
+			want:   "Hello{{/if}}\n",
+		},
+		{
+			name:   "out of range offsets",
+			source: "Hello",
+			edit:   Edit{StartByte: 10, OldEndByte: 20, Text: " there"},
+			want:   "Hello there",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := Parse([]byte(tt.source))
+			if err != nil {
+				t.Fatalf("Parse() returned error: %v", err)
+			}
+			oldTree := old.Root().String()
+
+			got := Reparse(old, tt.edit)
+			if string(got.Source()) != tt.want {
+				t.Errorf("Reparse().Source() = %q, want %q", got.Source(), tt.want)
+			}
+			fresh, err := Parse([]byte(tt.want))
+			if err != nil {
+				t.Fatalf("Parse() returned error: %v", err)
+			}
+			if got.Root().String() != fresh.Root().String() {
+				t.Errorf("Reparse() tree = %s, want %s", got.Root(), fresh.Root())
+			}
+			if old.Root().String() != oldTree || string(old.Source()) != tt.source {
+				t.Errorf("Reparse() modified the old tree")
+			}
+		})
+	}
+}
+
+func TestReparseSequence(t *testing.T) {
+	tree, err := Parse([]byte("Hi"))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	for _, c := range " {{name}}" {
+		n := len(tree.Source())
+		tree = Reparse(tree, Edit{StartByte: n, OldEndByte: n, Text: string(c)})
+	}
+	exprs := tree.Expressions()
+	if len(exprs) != 1 || exprs[0].Text != "{{name}}" || exprs[0].StartByte != 3 {
+		t.Errorf("Expressions() = %+v, want {{name}} at byte 3", exprs)
+	}
+}
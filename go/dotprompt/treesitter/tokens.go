@@ -0,0 +1,198 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package treesitter
+
+import (
+	"sort"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	grammar "github.com/google/dotprompt/packages/treesitter/bindings/go"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind string
+
+const (
+	// TokenComment is a comment of the license header or a Handlebars
+	// comment.
+	TokenComment TokenKind = "comment"
+	// TokenFrontmatterDelimiter is a `---` line around the frontmatter.
+	TokenFrontmatterDelimiter TokenKind = "frontmatterDelimiter"
+	// TokenFrontmatterKey is the key of a top-level frontmatter field.
+	TokenFrontmatterKey TokenKind = "frontmatterKey"
+	// TokenFrontmatterValue is the value of a top-level frontmatter field,
+	// including nested fields.
+	TokenFrontmatterValue TokenKind = "frontmatterValue"
+	// TokenYAML is other frontmatter text, such as punctuation and line
+	// endings, left to a YAML highlighter.
+	TokenYAML TokenKind = "yaml"
+	// TokenExpression is a Handlebars expression, including partials.
+	TokenExpression TokenKind = "expression"
+	// TokenBlockOpen is the opening expression of a block, such as `{{#if x}}`.
+	TokenBlockOpen TokenKind = "blockOpen"
+	// TokenBlockClose is the closing expression of a block, such as `{{/if}}`.
+	TokenBlockClose TokenKind = "blockClose"
+	// TokenMarker is a role, history, section or media marker, written either
+	// as a helper such as `{{role "user"}}` or as a literal
+	// `<<<dotprompt:...>>>` marker.
+	TokenMarker TokenKind = "marker"
+	// TokenText is a run of template text.
+	TokenText TokenKind = "text"
+)
+
+// markerHelpers are the helpers that render markers.
+var markerHelpers = map[string]bool{
+	"role":    true,
+	"history": true,
+	"section": true,
+	"media":   true,
+}
+
+// Token is a lexical token of a `.prompt` file.
+type Token struct {
+	Kind TokenKind `json:"kind"`
+	Text string    `json:"text"`
+	Span
+}
+
+// Tokenize parses source and returns its tokens; see PromptTree.Tokens.
+func Tokenize(source []byte) ([]Token, error) {
+	t, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return t.Tokens(), nil
+}
+
+// Tokens returns a flat token stream of the file, in source order. The tokens
+// cover the whole source without overlapping: the frontmatter is tokenized as
+// the YAML it injects, and the body as Handlebars and text. Source the grammar
+// does not recognize, such as syntax errors, becomes TokenYAML in the
+// frontmatter and TokenText elsewhere.
+func (t *PromptTree) Tokens() []Token {
+	var tokens []Token
+	var frontmatter []Span
+	var visit func(n *sitter.Node)
+	visit = func(n *sitter.Node) {
+		kind, ok := t.tokenKind(n)
+		if ok {
+			tokens = append(tokens, Token{Kind: kind, Span: Span{StartByte: int(n.StartByte()), EndByte: int(n.EndByte())}})
+			return
+		}
+		if n.Type() == grammar.NodeFrontmatter {
+			frontmatter = append(frontmatter, Span{StartByte: int(n.StartByte()), EndByte: int(n.EndByte())})
+		}
+		for i := 0; i < int(n.ChildCount()); i++ {
+			visit(n.Child(i))
+		}
+	}
+	visit(t.Root())
+	sort.SliceStable(tokens, func(i, j int) bool { return tokens[i].StartByte < tokens[j].StartByte })
+
+	// Fill the gaps, merging adjacent text and YAML.
+	var out []Token
+	add := func(kind TokenKind, start, end int) {
+		if start >= end {
+			return
+		}
+		if n := len(out); n > 0 && out[n-1].Kind == kind && (kind == TokenText || kind == TokenYAML) && out[n-1].EndByte == start {
+			out[n-1].EndByte = end
+			return
+		}
+		out = append(out, Token{Kind: kind, Span: Span{StartByte: start, EndByte: end}})
+	}
+	gap := func(start, end int) {
+		for _, y := range frontmatter {
+			if start >= end {
+				return
+			}
+			if y.EndByte <= start || y.StartByte >= end {
+				continue
+			}
+			add(TokenText, start, max(start, y.StartByte))
+			add(TokenYAML, max(start, y.StartByte), min(end, y.EndByte))
+			start = min(end, y.EndByte)
+		}
+		add(TokenText, start, end)
+	}
+	offset := 0
+	for _, tok := range tokens {
+		if tok.StartByte < offset {
+			continue
+		}
+		gap(offset, tok.StartByte)
+		add(tok.Kind, tok.StartByte, tok.EndByte)
+		offset = tok.EndByte
+	}
+	gap(offset, len(t.source))
+
+	for i, tok := range out {
+		n := t.node("", tok.StartByte, tok.EndByte)
+		out[i].Text = n.Text
+		out[i].Span = n.Span
+	}
+	return out
+}
+
+// tokenKind returns the kind of the token of n, or false if n is not a
+// token itself.
+func (t *PromptTree) tokenKind(n *sitter.Node) (TokenKind, bool) {
+	switch n.Type() {
+	case grammar.NodeHeaderComment, grammar.NodeHandlebarsComment:
+		return TokenComment, true
+	case grammar.NodeFrontmatterDelimiter:
+		return TokenFrontmatterDelimiter, true
+	case grammar.NodeYAMLKey:
+		return TokenFrontmatterKey, true
+	case grammar.NodeYAMLValue:
+		return TokenFrontmatterValue, true
+	case grammar.NodeBlockExpression:
+		return TokenBlockOpen, true
+	case grammar.NodeCloseBlock:
+		return TokenBlockClose, true
+	case grammar.NodeDotpromptMarker:
+		return TokenMarker, true
+	case grammar.NodeText:
+		return TokenText, true
+	case grammar.NodeHandlebarsExpression:
+		if markerHelpers[t.helperName(n)] {
+			return TokenMarker, true
+		}
+		return TokenExpression, true
+	}
+	return "", false
+}
+
+// helperName returns the helper name of a handlebars_expression, if any.
+// Helpers called without arguments, such as `{{history}}`, parse as
+// variable references and are returned too.
+func (t *PromptTree) helperName(n *sitter.Node) string {
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		content := n.NamedChild(i)
+		if content.Type() != grammar.NodeExpressionContent {
+			continue
+		}
+		for j := 0; j < int(content.NamedChildCount()); j++ {
+			switch c := content.NamedChild(j); c.Type() {
+			case grammar.NodeHelperName, grammar.NodeVariableReference:
+				return c.Content(t.source)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package treesitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTokens(t *testing.T) {
+	type tok struct {
+		Kind TokenKind
+		Text string
+	}
+	tests := []struct {
+		name   string
+		source string
+		want   []tok
+	}{
+		{
+			name:   "text and expressions",
+			source: "Hello {{name}}!",
+			want: []tok{
+				{TokenText, "Hello "},
+				{TokenExpression, "{{name}}"},
+				{TokenText, "!"},
+			},
+		},
+		{
+			name:   "frontmatter",
+			source: "---\nmodel: a/b\nconfig:\n  temperature: 1\n---\nHi",
+			want: []tok{
+				{TokenFrontmatterDelimiter, "---"},
+				{TokenYAML, "\n"},
+				{TokenFrontmatterKey, "model"},
+				{TokenYAML, ":"},
+				{TokenFrontmatterValue, " a/b"},
+				{TokenYAML, "\n"},
+				{TokenFrontmatterKey, "config"},
+				{TokenYAML, ":"},
+				{TokenFrontmatterValue, "\n  temperature: 1"},
+				{TokenYAML, "\n"},
+				{TokenFrontmatterDelimiter, "---"},
+				{TokenYAML, "\n"},
+				{TokenText, "Hi"},
+			},
+		},
+		{
+			name:   "markers",
+			source: "{{role \"user\"}}Hi<<<dotprompt:history>>>{{media url=u}}{{history}}",
+			want: []tok{
+				{TokenMarker, "{{role \"user\"}}"},
+				{TokenText, "Hi"},
+				{TokenMarker, "<<<dotprompt:history>>>"},
+				{TokenMarker, "{{media url=u}}"},
+				{TokenMarker, "{{history}}"},
+			},
+		},
+		{
+			name:   "blocks and comments",
+			source: "{{! note }}{{#if x}}yes{{else}}no{{/if}}",
+			want: []tok{
+				{TokenComment, "{{! note }}"},
+				{TokenBlockOpen, "{{#if x}}"},
+				{TokenText, "yes"},
+				{TokenExpression, "{{else}}"},
+				{TokenText, "no"},
+				{TokenBlockClose, "{{/if}}"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize([]byte(tt.source))
+			if err != nil {
+				t.Fatalf("Tokenize() returned error: %v", err)
+			}
+			var got []tok
+			for _, token := range tokens {
+				got = append(got, tok{token.Kind, token.Text})
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Tokenize() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTokensCoverSource(t *testing.T) {
+	sources := []string{
+		"",
+		"# Copyright 2026 Google LLC\n---\nmodel: a/b # comment\n---\n\n{{#each items}}{{> item this}}{{/each}}\n",
+		"---\nmodel: a/b\n---\n{{#if}}unclosed {{",
+		"line one\nline two {{x}}\n",
+	}
+	for _, source := range sources {
+		tokens, err := Tokenize([]byte(source))
+		if err != nil {
+			t.Fatalf("Tokenize() returned error: %v", err)
+		}
+		var b strings.Builder
+		offset := 0
+		for _, token := range tokens {
+			if token.StartByte != offset || token.EndByte <= token.StartByte {
+				t.Errorf("Tokenize(%q): token %+v does not start at %d", source, token, offset)
+			}
+			b.WriteString(token.Text)
+			offset = token.EndByte
+		}
+		if b.String() != source {
+			t.Errorf("Tokenize(%q) tokens join to %q", source, b.String())
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	tokens, err := Tokenize([]byte("a\nb {{x}}"))
+	if err != nil {
+		t.Fatalf("Tokenize() returned error: %v", err)
+	}
+	last := tokens[len(tokens)-1]
+	if last.Kind != TokenExpression || last.Start.Row != 1 || last.Start.Column != 2 {
+		t.Errorf("last token = %+v, want an expression at 1:2", last)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package treesitter is a parser backend for `.prompt` files built on the
+// tree-sitter grammar in packages/treesitter. Unlike the regular expression
+// parser in the dotprompt package it yields a full syntax tree with positions
+// for the frontmatter, Handlebars expressions and markers.
+//
+// Select it with DotpromptOptions.Parser:
+//
+//	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{
+//		Parser: treesitter.ParseDocumentTS,
+//	})
+//
+// This package uses cgo and lives in its own module so that the dotprompt
+// module does not require a C toolchain.
+package treesitter
+
+import (
+	"fmt"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	grammar "github.com/google/dotprompt/packages/treesitter/bindings/go"
+)
+
+// Span is a region of the source. Points are zero-based rows and byte
+// columns, as reported by tree-sitter.
+type Span struct {
+	StartByte int          `json:"startByte"`
+	EndByte   int          `json:"endByte"`
+	Start     sitter.Point `json:"start"`
+	End       sitter.Point `json:"end"`
+}
+
+// Node is a syntax node and its source text.
+type Node struct {
+	// Kind is one of the node kind constants of the grammar binding, such as
+	// grammar.NodeHandlebarsExpression.
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+	Span
+}
+
+// SyntaxError is an ERROR or MISSING node in the tree.
+type SyntaxError struct {
+	Message string `json:"message"`
+	Span
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Start.Row+1, e.Start.Column+1, e.Message)
+}
+
+// PromptTree is a parsed `.prompt` file.
+type PromptTree struct {
+	source []byte
+	tree   *sitter.Tree
+}
+
+// Parse parses the source of a `.prompt` file. Syntax errors are recorded in
+// the tree rather than returned; see Errors.
+func Parse(source []byte) (*PromptTree, error) {
+	tree, err := grammar.ParsePrompt(source)
+	if err != nil {
+		return nil, err
+	}
+	return &PromptTree{source: source, tree: tree}, nil
+}
+
+// ParseDocumentTS parses source with the tree-sitter grammar and builds the
+// same ParsedPrompt as dotprompt.ParseDocument. It satisfies
+// dotprompt.DocumentParser.
+//
+// The grammar only covers a subset of Handlebars, so syntax errors in the
+// template body do not cause an error; the body is compiled by the Handlebars
+// engine as usual.
+func ParseDocumentTS(source string) (dotprompt.ParsedPrompt, error) {
+	t, err := Parse([]byte(source))
+	if err != nil {
+		return dotprompt.ParsedPrompt{}, err
+	}
+	return t.ParsedPrompt()
+}
+
+// Source returns the parsed source.
+func (t *PromptTree) Source() []byte {
+	return t.source
+}
+
+// Tree returns the underlying tree-sitter tree.
+func (t *PromptTree) Tree() *sitter.Tree {
+	return t.tree
+}
+
+// Root returns the root `document` node.
+func (t *PromptTree) Root() *sitter.Node {
+	return t.tree.RootNode()
+}
+
+// ParsedPrompt builds a ParsedPrompt from the frontmatter and body of the
+// tree.
+func (t *PromptTree) ParsedPrompt() (dotprompt.ParsedPrompt, error) {
+	source := string(t.source)
+	frontmatter, ok := t.Frontmatter()
+	if !ok {
+		return dotprompt.ParseSections(source, "", "")
+	}
+	return dotprompt.ParseSections(source, frontmatter.Text, t.Body().Text)
+}
+
+// Frontmatter returns the YAML between the frontmatter delimiters. It reports
+// false if the file has no frontmatter.
+func (t *PromptTree) Frontmatter() (Node, bool) {
+	delimiters := t.frontmatterDelimiters()
+	if delimiters == nil {
+		return Node{}, false
+	}
+	start := skipNewline(t.source, int(delimiters[0].EndByte()))
+	end := max(int(delimiters[1].StartByte()), start)
+	// The newline before the closing delimiter is not part of the YAML.
+	if end > start && t.source[end-1] == '\n' {
+		end--
+		if end > start && t.source[end-1] == '\r' {
+			end--
+		}
+	}
+	return t.node(grammar.NodeYAMLContent, start, end), true
+}
+
+// Body returns the template body: everything after the frontmatter and the
+// blank lines that follow it, or the whole file if there is no frontmatter.
+func (t *PromptTree) Body() Node {
+	start := 0
+	if delimiters := t.frontmatterDelimiters(); delimiters != nil {
+		start = skipBlankLines(t.source, int(delimiters[1].EndByte()))
+	}
+	return t.node(grammar.NodeTemplateBody, start, len(t.source))
+}
+
+// Expressions returns the Handlebars expressions, block openers and closers
+// and comments of the template, in source order.
+func (t *PromptTree) Expressions() []Node {
+	return t.Find(
+		grammar.NodeHandlebarsExpression,
+		grammar.NodeBlockExpression,
+		grammar.NodeCloseBlock,
+		grammar.NodeHandlebarsComment,
+	)
+}
+
+// Markers returns the literal `<<<dotprompt:...>>>` markers of the template.
+func (t *PromptTree) Markers() []Node {
+	return t.Find(grammar.NodeDotpromptMarker)
+}
+
+// Find returns the nodes of the given kinds, in source order.
+func (t *PromptTree) Find(kinds ...string) []Node {
+	want := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		want[k] = true
+	}
+	var nodes []Node
+	t.walk(t.Root(), func(n *sitter.Node) {
+		if want[n.Type()] {
+			nodes = append(nodes, t.node(n.Type(), int(n.StartByte()), int(n.EndByte())))
+		}
+	})
+	return nodes
+}
+
+// Errors returns the syntax errors in the tree, in source order.
+func (t *PromptTree) Errors() []SyntaxError {
+	var errs []SyntaxError
+	t.walk(t.Root(), func(n *sitter.Node) {
+		var message string
+		switch {
+		case n.IsMissing():
+			message = fmt.Sprintf("missing %q", n.Type())
+		case n.IsError():
+			message = fmt.Sprintf("unexpected %q", n.Content(t.source))
+		default:
+			return
+		}
+		errs = append(errs, SyntaxError{
+			Message: message,
+			Span:    t.node(n.Type(), int(n.StartByte()), int(n.EndByte())).Span,
+		})
+	})
+	return errs
+}
+
+// frontmatterDelimiters returns the opening and closing frontmatter
+// delimiters, or nil if there is no frontmatter.
+func (t *PromptTree) frontmatterDelimiters() []*sitter.Node {
+	root := t.Root()
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		if child.Type() != grammar.NodeFrontmatter {
+			continue
+		}
+		var delimiters []*sitter.Node
+		for j := 0; j < int(child.NamedChildCount()); j++ {
+			if d := child.NamedChild(j); d.Type() == grammar.NodeFrontmatterDelimiter {
+				delimiters = append(delimiters, d)
+			}
+		}
+		if len(delimiters) == 2 {
+			return delimiters
+		}
+	}
+	return nil
+}
+
+// walk calls fn for n and each of its descendants in pre-order.
+func (t *PromptTree) walk(n *sitter.Node, fn func(*sitter.Node)) {
+	fn(n)
+	for i := 0; i < int(n.ChildCount()); i++ {
+		t.walk(n.Child(i), fn)
+	}
+}
+
+// node returns a Node spanning source[start:end].
+func (t *PromptTree) node(kind string, start, end int) Node {
+	return Node{
+		Kind: kind,
+		Text: string(t.source[start:end]),
+		Span: Span{
+			StartByte: start,
+			EndByte:   end,
+			Start:     point(t.source, start),
+			End:       point(t.source, end),
+		},
+	}
+}
+
+// point returns the row and byte column of offset in source.
+func point(source []byte, offset int) sitter.Point {
+	var p sitter.Point
+	for _, c := range source[:offset] {
+		if c == '\n' {
+			p.Row++
+			p.Column = 0
+		} else {
+			p.Column++
+		}
+	}
+	return p
+}
+
+// skipNewline skips a single line ending at offset.
+func skipNewline(source []byte, offset int) int {
+	if offset < len(source) && source[offset] == '\r' {
+		offset++
+	}
+	if offset < len(source) && source[offset] == '\n' {
+		offset++
+	}
+	return offset
+}
+
+// skipBlankLines skips the longest run of whitespace at offset that ends with
+// a line ending, matching the `---\s*\n` delimiter of the regular expression
+// parser.
+func skipBlankLines(source []byte, offset int) int {
+	end := offset
+	for i := offset; i < len(source); i++ {
+		switch source[i] {
+		case '\n', '\r':
+			end = i + 1
+		case ' ', '\t', '\f', '\v':
+		default:
+			return end
+		}
+	}
+	return end
+}
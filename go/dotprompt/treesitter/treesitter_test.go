@@ -0,0 +1,167 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package treesitter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	grammar "github.com/google/dotprompt/packages/treesitter/bindings/go"
+)
+
+func TestParseDocumentTS(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "no frontmatter", source: "Hello {{name}}!"},
+		{name: "frontmatter", source: "---\nmodel: test/model\n---\nHello {{name}}!\n"},
+		{name: "empty frontmatter", source: "---\n---\nHello\n"},
+		{name: "blank lines after frontmatter", source: "---\nmodel: a/b\n---\n\n\n  Hello\n"},
+		{name: "crlf", source: "---\r\nmodel: a/b\r\n---\r\nHello\r\n"},
+		{name: "license header", source: "# Copyright 2026 Google LLC\n# SPDX-License-Identifier: Apache-2.0\n---\nmodel: a/b\n---\nHello\n"},
+		{name: "markers", source: "---\nmodel: a/b\n---\n{{role \"user\"}}Hi<<<dotprompt:history>>>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compareParsers(t, tt.source)
+		})
+	}
+}
+
+func TestParseDocumentTSCorpus(t *testing.T) {
+	root := filepath.Join("..", "..", "..")
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "node_modules" {
+			return filepath.SkipDir
+		}
+		if strings.HasSuffix(path, ".prompt") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("no .prompt files found")
+	}
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareParsers(t, string(source))
+		})
+	}
+}
+
+func compareParsers(t *testing.T, source string) {
+	t.Helper()
+	want, wantErr := dotprompt.ParseDocument(source)
+	got, gotErr := ParseDocumentTS(source)
+	if (gotErr != nil) != (wantErr != nil) {
+		t.Fatalf("ParseDocumentTS() error = %v, ParseDocument() error = %v", gotErr, wantErr)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseDocumentTS() mismatch (-ParseDocument +ParseDocumentTS):\n%s", diff)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	source := "---\nmodel: a/b\n---\nHello {{name}}!\n{{#if x}}<<<dotprompt:history>>>{{/if}}\n"
+	tree, err := Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	fm, ok := tree.Frontmatter()
+	if !ok {
+		t.Fatal("Frontmatter() ok = false, want true")
+	}
+	if fm.Text != "model: a/b" || fm.Start.Row != 1 || fm.Start.Column != 0 {
+		t.Errorf("Frontmatter() = %+v, want %q at 1:0", fm, "model: a/b")
+	}
+
+	body := tree.Body()
+	if body.Start.Row != 3 || !strings.HasPrefix(body.Text, "Hello") {
+		t.Errorf("Body() = %+v, want body starting at row 3", body)
+	}
+
+	var got []string
+	for _, n := range tree.Expressions() {
+		got = append(got, n.Kind+" "+n.Text)
+	}
+	want := []string{
+		grammar.NodeHandlebarsExpression + " {{name}}",
+		grammar.NodeBlockExpression + " {{#if x}}",
+		grammar.NodeCloseBlock + " {{/if}}",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Expressions() mismatch (-want +got):\n%s", diff)
+	}
+	if exprs := tree.Expressions(); len(exprs) > 0 {
+		if p := exprs[0].Start; p.Row != 3 || p.Column != 6 {
+			t.Errorf("Expressions()[0].Start = %+v, want 3:6", p)
+		}
+	}
+
+	markers := tree.Markers()
+	if len(markers) != 1 || markers[0].Text != "<<<dotprompt:history>>>" || markers[0].Start.Row != 4 {
+		t.Errorf("Markers() = %+v, want one history marker on row 4", markers)
+	}
+	if errs := tree.Errors(); len(errs) != 0 {
+		t.Errorf("Errors() = %v, want none", errs)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tree, err := Parse([]byte("Hello {{#if x}}unclosed"))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	errs := tree.Errors()
+	if len(errs) == 0 {
+		t.Fatal("Errors() = none, want at least one")
+	}
+	if !strings.HasPrefix(errs[0].Error(), "1:") {
+		t.Errorf("Errors()[0].Error() = %q, want a 1-based line prefix", errs[0].Error())
+	}
+}
+
+func TestDotpromptParserOption(t *testing.T) {
+	dp := dotprompt.NewDotprompt(&dotprompt.DotpromptOptions{Parser: ParseDocumentTS})
+	parsed, err := dp.Parse("---\nmodel: a/b\n---\nHello\n")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if parsed.Model != "a/b" || parsed.Template != "Hello" {
+		t.Errorf("Parse() = %+v, want model a/b and template %q", parsed, "Hello")
+	}
+}
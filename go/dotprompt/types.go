@@ -17,6 +17,8 @@
 package dotprompt
 
 import (
+	"errors"
+
 	"github.com/invopop/jsonschema"
 )
 
@@ -98,6 +100,15 @@ type PromptMetadataOutput struct {
 	Schema Schema `json:"schema,omitempty"`
 }
 
+// ToolChoice controls whether a model may, must or must not call tools.
+type ToolChoice string
+
+const (
+	ToolChoiceAuto     ToolChoice = "auto"
+	ToolChoiceRequired ToolChoice = "required"
+	ToolChoiceNone     ToolChoice = "none"
+)
+
 // PromptMetadata contains metadata about a prompt.
 type PromptMetadata struct {
 	HasMetadata
@@ -111,12 +122,23 @@ type PromptMetadata struct {
 	Description string `json:"description,omitempty"`
 	// The name of the model to use for this prompt, e.g. `vertexai/gemini-1.0-pro`
 	Model string `json:"model,omitempty"`
+	// The form the prompt renders to. It defaults to PromptFormatMessages.
+	Format PromptFormat `json:"format,omitempty"`
 	// Number of tool max turns
 	MaxTurns int `json:"maxTurns,omitempty"`
+	// How the model may call tools: "auto", "required" or "none".
+	ToolChoice ToolChoice `json:"toolChoice,omitempty"`
+	// Whether tool requests are returned to the caller instead of being
+	// executed automatically.
+	ReturnToolRequests bool `json:"returnToolRequests,omitempty"`
+	// Sequences that stop generation when the model produces them.
+	StopSequences []string `json:"stopSequences,omitempty"`
 	// Names of tools (registered separately) to allow use of in this prompt.
 	Tools []string `json:"tools,omitempty"`
 	// Definitions of tools to allow use of in this prompt.
 	ToolDefs []ToolDefinition `json:"toolDefs,omitempty"`
+	// Few-shot input/output pairs rendered as messages before the body.
+	Examples []Example `json:"examples,omitempty"`
 	// Model configuration. Not all models support all options.
 	Config ModelConfig `json:"config,omitempty"`
 	// Configuration for input variables.
@@ -127,12 +149,18 @@ type PromptMetadata struct {
 	// processing or substitutions. If your implementation requires custom
 	// fields they will be available here.
 	Raw map[string]any `json:"raw,omitempty"`
+	// The order of the keys of Raw and its nested mappings in the
+	// frontmatter.
+	RawKeyOrder *KeyOrder `json:"-"`
 	// Fields that contain a period will be considered "extension fields" in the
 	// frontmatter and will be gathered by namespace. For example, `myext.foo:
 	// 123` would be available at `parsedPrompt.ext.myext.foo`. Nested
 	// namespaces will be flattened, so `myext.foo.bar: 123` would be available
 	// at `parsedPrompt.ext["myext.foo"].bar`.
 	Ext map[string]map[string]any `json:"ext,omitempty"`
+	// Values of the top-level keys claimed with RegisterMetadataExtension,
+	// after validation and parsing.
+	Extensions map[string]any `json:"extensions,omitempty"`
 }
 
 // ParsedPrompt represents a parsed prompt template with metadata.
@@ -269,6 +297,23 @@ type ToolResolver func(toolName string) (ToolDefinition, error)
 type RenderedPrompt struct {
 	PromptMetadata
 	Messages []Message `json:"messages"`
+	// Completion is the rendered text of a prompt with `format:
+	// completion`; Messages then holds it as a single user message.
+	Completion string `json:"completion,omitempty"`
+	// RawOutput is the text produced by the template, with its role, media
+	// and history markers, before it was split into Messages. It is only set
+	// when DotpromptOptions.KeepRawOutput is true. (It is not named Raw, which
+	// is the raw frontmatter of the embedded PromptMetadata.)
+	RawOutput string `json:"rawOutput,omitempty"`
+	// InputWarnings lists unused and missing input fields. It is only set
+	// when DotpromptOptions.CheckInputs is true. Fields referenced only inside
+	// branches that were not taken still count as used.
+	InputWarnings []InputWarning `json:"inputWarnings,omitempty"`
+	// JSONMode reports that the model should be put in its native JSON or
+	// structured-output mode, constrained by Output.Schema. It is set for
+	// prompts with JSON output and an output schema when
+	// DotpromptOptions.JSONMode is set.
+	JSONMode bool `json:"jsonMode,omitempty"`
 }
 
 // PromptFunction is a function that takes runtime data/context and returns a
@@ -302,6 +347,15 @@ type ListPromptsOptions struct {
 	Cursor  string
 	Limit   int
 	Variant string
+	// Prefix lists only the prompts whose name starts with it, e.g.
+	// "customer-support/".
+	Prefix string
+	// Glob lists only the prompts whose name matches it, using the syntax of
+	// path.Match, so `*` does not match `/`, e.g. "*/greet*".
+	Glob string
+	// Tags lists only the prompts tagged with all of them in the `ext.tags`
+	// frontmatter field, a list of strings.
+	Tags []string
 }
 
 // ListPromptsResult represents a list of items and a cursor.
@@ -335,7 +389,22 @@ type LoadPartialOptions struct {
 	Version string
 }
 
-// PromptStore is an interface for storing and retrieving prompts.
+// PromptStore is the interface for storing and retrieving prompts and
+// partials. It is the read side that every store implements, such as
+// DirStore and registry.Client, and the one that code which only reads
+// prompts should accept.
+//
+// Stores opt into further capabilities by also implementing
+// WritablePromptStore, to save and delete prompts, PartialWriter, to save
+// partials, and BatchLoader, to load prompts in batches. Callers discover them
+// with a type assertion:
+//
+//	if w, ok := store.(WritablePromptStore); ok {
+//		err = w.Save(prompt)
+//	}
+//
+// Stores that wrap another store, such as those returned by WithHooks and
+// ACLStore.For, have a capability only if the wrapped store does.
 type PromptStore interface {
 	// List returns a list of all prompts in the store (optionally paginated).
 	List(options ListPromptsOptions) (ListPromptsResult[PromptRef], error)
@@ -343,21 +412,28 @@ type PromptStore interface {
 	// ListPartials returns a list of partial names available in this store.
 	ListPartials(options ListPartialsOptions) (ListPartialsResult[PartialRef], error)
 
-	// Load retrieves a prompt from the store.
+	// Load retrieves a prompt from the store. The error wraps ErrNotFound
+	// if the store does not have the prompt or the requested version.
 	Load(name string, options LoadPromptOptions) (PromptData, error)
 
-	// LoadPartial retrieves a partial from the store.
+	// LoadPartial retrieves a partial from the store. The error wraps
+	// ErrNotFound if the store does not have the partial or the requested
+	// version.
 	LoadPartial(name string, options LoadPartialOptions) (PartialData, error)
 }
 
+// ErrNotFound is wrapped by the errors of PromptStore loads for prompts,
+// partials and versions that the store does not have.
+var ErrNotFound = errors.New("not found")
+
 // PromptStoreDeleteOptions represents options for deleting a prompt or partial.
 type PromptStoreDeleteOptions struct {
 	Variant string
 }
 
-// PromptStoreWritable is a PromptStore that also has built-in methods for
+// WritablePromptStore is a PromptStore that also has built-in methods for
 // writing prompts.
-type PromptStoreWritable interface {
+type WritablePromptStore interface {
 	PromptStore
 
 	// Save saves a prompt in the store. May be destructive for prompt stores
@@ -368,6 +444,26 @@ type PromptStoreWritable interface {
 	Delete(name string, options PromptStoreDeleteOptions) error
 }
 
+// PromptStoreWritable is the former name of WritablePromptStore.
+//
+// Deprecated: Use WritablePromptStore.
+type PromptStoreWritable = WritablePromptStore
+
+// BatchLoader is implemented by stores that load several prompts faster than
+// one Load call per prompt, such as by reading in parallel or in a single
+// round trip. Use the LoadMany function to load from any store.
+type BatchLoader interface {
+	// LoadMany retrieves the prompts with the names, variants and versions
+	// of refs, in the order of refs. It fails if any prompt fails to load.
+	LoadMany(refs []PromptRef) ([]PromptData, error)
+}
+
+// PartialWriter is implemented by stores that can save partials.
+type PartialWriter interface {
+	// SavePartial saves a partial in the store.
+	SavePartial(partial PartialData) error
+}
+
 // PromptBundle represents a bundle of prompts and partials.
 type PromptBundle struct {
 	Partials []PartialData `json:"partials"`
@@ -17,6 +17,7 @@
 package dotprompt
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"maps"
@@ -54,6 +55,28 @@ func intOrZero(value any) int {
 	return 0
 }
 
+// boolOrFalse returns the bool value of an any or false if it's not a bool.
+func boolOrFalse(value any) bool {
+	b, _ := value.(bool)
+	return b
+}
+
+// stringsOrNil returns the string items of an any that is a slice, or nil if
+// it's not a slice. Items that are not strings are skipped.
+func stringsOrNil(value any) []string {
+	items, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 // getMapOrNil returns the map value of an any or nil if it's not a map.
 func getMapOrNil(m map[string]any, key string) map[string]any {
 	if value, ok := m[key]; ok {
@@ -72,6 +95,30 @@ func copyMapping[K comparable, V any](mapping map[K]V) map[K]V {
 	return newMapping
 }
 
+// ToJSONValue returns v encoded as JSON and decoded into the generic form
+// of maps, slices and scalars that encoding/json decodes into an any, with
+// numbers as float64. It lets values of any Go type be compared with, or
+// navigated like, decoded JSON or YAML.
+func ToJSONValue(v any) (any, error) {
+	return decodeJSONValue(v, false)
+}
+
+// decodeJSONValue implements ToJSONValue, keeping numbers as json.Number if
+// useNumber is set.
+func decodeJSONValue(v any, useNumber bool) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if useNumber {
+		dec.UseNumber()
+	}
+	var out any
+	err = dec.Decode(&out)
+	return out, err
+}
+
 // MergeMaps merges two map[string]any objects and handles nil maps.
 func MergeMaps(map1, map2 map[string]any) map[string]any {
 	// If map1 is nil, initialize it as an empty map
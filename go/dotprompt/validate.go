@@ -0,0 +1,182 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+
+	"github.com/invopop/jsonschema"
+)
+
+// ValidateValue checks value, as decoded from YAML or JSON, against schema. It
+// supports the subset of JSON Schema that Picoschema produces: `type`,
+// `enum`, `const`, `anyOf`, `properties`, `required`, `additionalProperties`
+// and `items`. Other keywords are ignored. A nil schema accepts any value.
+func ValidateValue(schema *jsonschema.Schema, value any) error {
+	return validateValue(schema, value, "")
+}
+
+// validateValue implements ValidateValue. path locates value in error
+// messages.
+func validateValue(schema *jsonschema.Schema, value any, path string) error {
+	if schema == nil || schema == jsonschema.TrueSchema {
+		return nil
+	}
+	if isFalseSchema(schema) {
+		return fmt.Errorf("%s: no value is allowed", displayPath(path))
+	}
+
+	// Picoschema marks optional fields with an `anyOf` of the field's schema
+	// and `null` while keeping the field's `type`, so a matching `anyOf`
+	// branch takes precedence over `type`.
+	if len(schema.AnyOf) > 0 {
+		var firstErr error
+		for _, alt := range schema.AnyOf {
+			err := validateValue(alt, value, path)
+			if err == nil {
+				firstErr = nil
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return firstErr
+		}
+	} else if schema.Type != "" && !hasJSONType(value, schema.Type) {
+		return fmt.Errorf("%s: expected %s, got %s", displayPath(path), schema.Type, jsonTypeName(value))
+	}
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(e any) bool { return enumValueEqual(e, value) }) {
+		return fmt.Errorf("%s: %v is not one of %v", displayPath(path), value, schema.Enum)
+	}
+	if schema.Const != nil && !enumValueEqual(schema.Const, value) {
+		return fmt.Errorf("%s: %v is not %v", displayPath(path), value, schema.Const)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required field %q", displayPath(path), name)
+			}
+		}
+		for name, fieldValue := range v {
+			var fieldSchema *jsonschema.Schema
+			if schema.Properties != nil {
+				fieldSchema, _ = schema.Properties.Get(name)
+			}
+			if fieldSchema == nil {
+				fieldSchema = schema.AdditionalProperties
+			}
+			if err := validateValue(fieldSchema, fieldValue, joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if err := validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isFalseSchema reports whether schema is the boolean schema `false`.
+func isFalseSchema(schema *jsonschema.Schema) bool {
+	if schema == jsonschema.FalseSchema {
+		return true
+	}
+	b, err := json.Marshal(schema)
+	return err == nil && string(b) == "false"
+}
+
+// hasJSONType reports whether value is of the JSON Schema type typ.
+func hasJSONType(value any, typ string) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "any":
+		return true
+	}
+	return false
+}
+
+// jsonTypeName returns the JSON type of value for error messages.
+func jsonTypeName(value any) string {
+	for _, typ := range []string{"null", "boolean", "string", "integer", "number", "object", "array"} {
+		if hasJSONType(value, typ) {
+			return typ
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// enumValueEqual compares two decoded values, treating numbers of different
+// Go types as equal if their values are.
+func enumValueEqual(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts any Go numeric value to a float64.
+func toFloat(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// displayPath returns path, or a placeholder for the root value.
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateValue(t *testing.T) {
+	schema, err := Picoschema(map[string]any{
+		"name":            "string",
+		"age?":            "integer",
+		"tags(array)":     "string",
+		"tier(enum)":      []any{"free", "pro"},
+		"address(object)": map[string]any{"city": "string"},
+	}, &PicoschemaOptions{})
+	if err != nil {
+		t.Fatalf("Picoschema() returned error: %v", err)
+	}
+
+	valid := map[string]any{
+		"name":    "Ada",
+		"age":     uint64(36),
+		"tags":    []any{"a"},
+		"tier":    "pro",
+		"address": map[string]any{"city": "London"},
+	}
+	if err := ValidateValue(schema, valid); err != nil {
+		t.Errorf("ValidateValue() returned error for valid value: %v", err)
+	}
+	withNull := map[string]any{"name": "Ada", "age": nil, "tags": []any{}, "tier": "free", "address": map[string]any{"city": "x"}}
+	if err := ValidateValue(schema, withNull); err != nil {
+		t.Errorf("ValidateValue() returned error for null optional field: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(map[string]any)
+		wantErr string
+	}{
+		{"missing required", func(v map[string]any) { delete(v, "name") }, `missing required field "name"`},
+		{"wrong type", func(v map[string]any) { v["age"] = 1.5 }, "age: expected integer, got number"},
+		{"wrong item", func(v map[string]any) { v["tags"] = []any{"a", 2} }, "tags[1]: expected string"},
+		{"not in enum", func(v map[string]any) { v["tier"] = "gold" }, "tier: gold is not one of"},
+		{"nested", func(v map[string]any) { v["address"] = map[string]any{"city": true} }, "address.city: expected string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := map[string]any{}
+			for k, v := range valid {
+				value[k] = v
+			}
+			tt.modify(value)
+			err := ValidateValue(schema, value)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateValue() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
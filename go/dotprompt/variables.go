@@ -0,0 +1,240 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mbleigh/raymond/ast"
+	"github.com/mbleigh/raymond/parser"
+)
+
+// TemplateVariable is a reference from a template to a field of the root
+// input context.
+type TemplateVariable struct {
+	// Name is the top-level input field, e.g. `user` for `{{user.name}}`.
+	Name string `json:"name"`
+	// Path is the full dotted path, e.g. `user.name`.
+	Path string `json:"path"`
+	// Line is the 1-based line of the reference within the template.
+	Line int `json:"line"`
+	// Pos is the byte offset of the reference within the template.
+	Pos int `json:"pos"`
+}
+
+// TemplatePartial is a reference from a template to a partial.
+type TemplatePartial struct {
+	// Name is the partial name, e.g. `card` for `{{> card}}`.
+	Name string `json:"name"`
+	// Line is the 1-based line of the reference within the template.
+	Line int `json:"line"`
+	// Pos is the byte offset of the reference within the template.
+	Pos int `json:"pos"`
+}
+
+// TemplateHelper is a reference from a template to a helper.
+type TemplateHelper struct {
+	// Name is the helper name, e.g. `json` for `{{json data}}`.
+	Name string `json:"name"`
+	// Block reports whether the helper is invoked as a block, e.g. `{{#if}}`.
+	Block bool `json:"block,omitempty"`
+	// Line is the 1-based line of the reference within the template.
+	Line int `json:"line"`
+	// Pos is the byte offset of the helper name within the template.
+	Pos int `json:"pos"`
+}
+
+// contextChangingHelpers are block helpers whose body is evaluated against a
+// different context than the enclosing one.
+var contextChangingHelpers = []string{"each", "with"}
+
+// TemplateVariables returns the references to root input fields made by a
+// Handlebars template, in source order. References to helpers, `@` data
+// variables, block parameters and fields of a nested `#each` or `#with`
+// context are not included.
+func TemplateVariables(template string) ([]TemplateVariable, error) {
+	program, err := parser.Parse(template)
+	if err != nil {
+		return nil, err
+	}
+	w := &variableWalker{}
+	w.program(program, 0, nil)
+	return w.vars, nil
+}
+
+// TemplatePartials returns the static partial references made by a Handlebars
+// template, in source order. Dynamic partials such as `{{> (lookup . "x")}}`
+// are not included.
+func TemplatePartials(template string) ([]TemplatePartial, error) {
+	program, err := parser.Parse(template)
+	if err != nil {
+		return nil, err
+	}
+	w := &variableWalker{}
+	w.program(program, 0, nil)
+	return w.partials, nil
+}
+
+// TemplateHelpers returns the helper calls made by a Handlebars template, in
+// source order. A mustache with no arguments is only reported as a helper call
+// if its name is a built-in dotprompt helper.
+func TemplateHelpers(template string) ([]TemplateHelper, error) {
+	program, err := parser.Parse(template)
+	if err != nil {
+		return nil, err
+	}
+	w := &variableWalker{}
+	w.program(program, 0, nil)
+	return w.helpers, nil
+}
+
+// variableWalker collects TemplateVariables, TemplatePartials and
+// TemplateHelpers from a parsed template.
+type variableWalker struct {
+	vars     []TemplateVariable
+	partials []TemplatePartial
+	helpers  []TemplateHelper
+	// partialStatements are the partial tags with a static name.
+	partialStatements []*ast.PartialStatement
+	// dynamicPartials are the subexpressions that compute the names of
+	// dynamic partials, such as `(lookup . "layout")`.
+	dynamicPartials []*ast.SubExpression
+	// wholeContext is set when the template references the root input
+	// context itself, e.g. `{{json this}}` or `{{json @root}}`.
+	wholeContext bool
+}
+
+// program walks a program body. depth is the number of enclosing
+// context-changing blocks, and params are the block parameters in scope.
+func (w *variableWalker) program(program *ast.Program, depth int, params []string) {
+	if program == nil {
+		return
+	}
+	for _, node := range program.Body {
+		switch n := node.(type) {
+		case *ast.MustacheStatement:
+			w.expression(n.Expression, depth, params, true, false)
+		case *ast.BlockStatement:
+			w.expression(n.Expression, depth, params, false, true)
+			innerDepth := depth
+			if slices.Contains(contextChangingHelpers, n.Expression.HelperName()) {
+				innerDepth++
+			}
+			innerParams := params
+			if n.Program != nil && len(n.Program.BlockParams) > 0 {
+				innerParams = append(slices.Clone(params), n.Program.BlockParams...)
+			}
+			w.program(n.Program, innerDepth, innerParams)
+			w.program(n.Inverse, depth, params)
+		case *ast.PartialStatement:
+			if name, ok := partialName(n.Name); ok {
+				w.partials = append(w.partials, TemplatePartial{Name: name, Line: n.Line, Pos: n.Pos})
+				w.partialStatements = append(w.partialStatements, n)
+			} else if sexpr, ok := n.Name.(*ast.SubExpression); ok {
+				w.dynamicPartials = append(w.dynamicPartials, sexpr)
+				w.param(sexpr, depth, params)
+			}
+			for _, param := range n.Params {
+				w.param(param, depth, params)
+			}
+			w.hash(n.Hash, depth, params)
+		}
+	}
+}
+
+// expression walks a mustache, block or subexpression. A mustache with no
+// arguments may be either a helper call or a variable; it is treated as a
+// variable unless its name is a built-in helper.
+func (w *variableWalker) expression(expr *ast.Expression, depth int, params []string, mustache, block bool) {
+	if expr == nil {
+		return
+	}
+	isCall := len(expr.Params) > 0 || expr.Hash != nil || !mustache
+	if path, ok := expr.Path.(*ast.PathExpression); ok {
+		_, isHelper := templateHelpers[expr.HelperName()]
+		switch {
+		case !isCall && !isHelper:
+			w.path(path, depth, params)
+		case !path.Data:
+			w.helpers = append(w.helpers, TemplateHelper{
+				Name:  path.Original,
+				Block: block,
+				Line:  path.Line,
+				Pos:   path.Pos,
+			})
+		}
+	}
+	for _, param := range expr.Params {
+		w.param(param, depth, params)
+	}
+	w.hash(expr.Hash, depth, params)
+}
+
+// param walks a helper argument.
+func (w *variableWalker) param(node ast.Node, depth int, params []string) {
+	switch n := node.(type) {
+	case *ast.PathExpression:
+		w.path(n, depth, params)
+	case *ast.SubExpression:
+		w.expression(n.Expression, depth, params, false, false)
+	}
+}
+
+// hash walks the values of a helper's hash arguments.
+func (w *variableWalker) hash(hash *ast.Hash, depth int, params []string) {
+	if hash == nil {
+		return
+	}
+	for _, pair := range hash.Pairs {
+		w.param(pair.Val, depth, params)
+	}
+}
+
+// path records a path expression if it refers to the root input context.
+func (w *variableWalker) path(path *ast.PathExpression, depth int, params []string) {
+	parts := path.Parts
+	switch {
+	case path.Data && len(parts) == 1 && parts[0] == "root",
+		!path.Data && len(parts) == 0 && path.Depth == depth:
+		w.wholeContext = true
+		return
+	case path.Data && len(parts) > 1 && parts[0] == "root":
+		// @root.foo always refers to the root context.
+		parts = parts[1:]
+	case path.Data || len(parts) == 0 || path.Depth != depth:
+		return
+	case slices.Contains(params, parts[0]):
+		return
+	}
+	w.vars = append(w.vars, TemplateVariable{
+		Name: parts[0],
+		Path: strings.Join(parts, "."),
+		Line: path.Line,
+		Pos:  path.Pos,
+	})
+}
+
+// partialName returns the static name of a partial statement, as Handlebars
+// looks it up: `{{> [a b]}}` names the partial `a b`.
+func partialName(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.PathExpression, *ast.StringLiteral:
+		return ast.HelperNameStr(n)
+	}
+	return "", false
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{"simple", "Hello {{name}}!", []string{"name"}},
+		{"nested path", "{{user.address.city}}", []string{"user.address.city"}},
+		{"helper args", `{{json data indent=2}} {{media url=imageUrl}}`, []string{"data", "imageUrl"}},
+		{"builtin helpers", `{{role "user"}}{{history}}`, nil},
+		{"if block", "{{#if show}}{{message}}{{else}}{{fallback}}{{/if}}", []string{"show", "message", "fallback"}},
+		{"each block", "{{#each items}}{{name}} {{../prefix}} {{@index}}{{/each}}", []string{"items", "prefix"}},
+		{"block params", "{{#each items as |item|}}{{item.name}}{{/each}}", []string{"items"}},
+		{"root data", "{{#with user}}{{@root.greeting}} {{name}}{{/with}}", []string{"user", "greeting"}},
+		{"subexpression", "{{#if (eq a b)}}x{{/if}}", []string{"a", "b"}},
+		{"partial args", "{{> card title=heading}}", []string{"heading"}},
+		{"this", "{{this}}", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, err := TemplateVariables(tt.template)
+			if err != nil {
+				t.Fatalf("TemplateVariables() returned error: %v", err)
+			}
+			var got []string
+			for _, v := range vars {
+				got = append(got, v.Path)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("TemplateVariables(%q) mismatch (-want +got):\n%s", tt.template, diff)
+			}
+		})
+	}
+}
+
+func TestTemplateVariablesPosition(t *testing.T) {
+	vars, err := TemplateVariables("line one\nline {{two}}")
+	if err != nil {
+		t.Fatalf("TemplateVariables() returned error: %v", err)
+	}
+	if len(vars) != 1 || vars[0].Line != 2 || vars[0].Pos != 16 {
+		t.Errorf("TemplateVariables() = %+v, want two at line 2, pos 16", vars)
+	}
+}
+
+func TestTemplateVariablesSyntaxError(t *testing.T) {
+	if _, err := TemplateVariables("{{#if x}}unclosed"); err == nil {
+		t.Error("TemplateVariables() returned nil error for unclosed block")
+	}
+}
+
+func TestTemplatePartials(t *testing.T) {
+	partials, err := TemplatePartials("{{> header}}\n{{#if x}}{{> body title=t}}{{/if}}\n{{> (dynamic)}}{{> \"quoted\"}}")
+	if err != nil {
+		t.Fatalf("TemplatePartials() returned error: %v", err)
+	}
+	want := []TemplatePartial{
+		{Name: "header", Line: 1, Pos: 0},
+		{Name: "body", Line: 2, Pos: 22},
+		{Name: "quoted", Line: 3, Pos: 63},
+	}
+	if diff := cmp.Diff(want, partials); diff != "" {
+		t.Errorf("TemplatePartials() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	helpers, err := TemplateHelpers("{{role \"system\"}}{{history}}\n{{#if (eq a b)}}{{json data}}{{/if}}{{name}}")
+	if err != nil {
+		t.Fatalf("TemplateHelpers() returned error: %v", err)
+	}
+	want := []TemplateHelper{
+		{Name: "role", Line: 1, Pos: 2},
+		{Name: "history", Line: 1, Pos: 19},
+		{Name: "if", Block: true, Line: 2, Pos: 32},
+		{Name: "eq", Line: 2, Pos: 36},
+		{Name: "json", Line: 2, Pos: 47},
+	}
+	if diff := cmp.Diff(want, helpers); diff != "" {
+		t.Errorf("TemplateHelpers() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import "strings"
+
+// TrimMode selects how the whitespace of prompt templates and of the text they
+// render is normalized.
+type TrimMode string
+
+const (
+	// TrimDefault trims the whitespace around the template body, removes
+	// Unicode spaces other than ' ' and line breaks from bodies without
+	// frontmatter, and drops whitespace-only text between markers.
+	TrimDefault TrimMode = ""
+	// TrimNone keeps the template body exactly as written and keeps
+	// whitespace-only text between markers as text parts, for model APIs
+	// that need whitespace preserved.
+	TrimNone TrimMode = "none"
+)
+
+// keepPiece reports whether a piece of text split off by a marker regex is
+// kept in mode m.
+func (m TrimMode) keepPiece(piece string) bool {
+	if m == TrimNone {
+		return piece != ""
+	}
+	return strings.TrimSpace(piece) != ""
+}
+
+// trim applies fn, one of the default trimming functions, to s unless m is
+// TrimNone.
+func (m TrimMode) trim(s string, fn func(string) string) string {
+	if m == TrimNone {
+		return s
+	}
+	return fn(s)
+}
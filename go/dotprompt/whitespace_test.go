@@ -0,0 +1,88 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWhitespaceOptions(t *testing.T) {
+	source := "---\nmodel: a/b\n---\n{{role \"system\"}}{{role \"user\"}}  Hi, {{name}}\n\n{{media url=\"https://example.com/a.png\"}}\n"
+	media := &MediaPart{Media: Media{URL: "https://example.com/a.png"}}
+
+	tests := []struct {
+		name    string
+		options DotpromptOptions
+		want    []Message
+	}{
+		{
+			name: "default",
+			want: []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: "  Hi, Ada\n\n"}, media}}},
+		},
+		{
+			name:    "keep empty messages",
+			options: DotpromptOptions{KeepEmptyMessages: true},
+			want: []Message{
+				{Role: RoleSystem, Content: []Part{}},
+				{Role: RoleUser, Content: []Part{&TextPart{Text: "  Hi, Ada\n\n"}, media}},
+			},
+		},
+		{
+			name:    "trim none",
+			options: DotpromptOptions{TrimMode: TrimNone},
+			want:    []Message{{Role: RoleUser, Content: []Part{&TextPart{Text: "  Hi, Ada\n\n"}, media, &TextPart{Text: "\n"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewDotprompt(&tt.options)
+			rendered, err := dp.Render(source, &DataArgument{Input: map[string]any{"name": "Ada"}}, nil)
+			if err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, rendered.Messages); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTrimNoneKeepsWhitespace(t *testing.T) {
+	source := "\u2003Hello\u2003\n{{role \"model\"}}\n"
+	dp := NewDotprompt(&DotpromptOptions{TrimMode: TrimNone, KeepEmptyMessages: true})
+	parsed, err := dp.Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if parsed.Template != source {
+		t.Errorf("Parse() template = %q, want %q", parsed.Template, source)
+	}
+
+	rendered, err := dp.Render(source, &DataArgument{}, nil)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	want := []Message{
+		{Role: RoleUser, Content: []Part{&TextPart{Text: "\u2003Hello\u2003\n"}}},
+		{Role: RoleModel, Content: []Part{&TextPart{Text: "\n"}}},
+	}
+	if diff := cmp.Diff(want, rendered.Messages); diff != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package genkit registers the prompts of a dotprompt.PromptStore with
+// Genkit. Register compiles every prompt in a store, with partials resolved
+// from the same store, and hands each one to a Registrar as an Action: its
+// Genkit name, its input and output JSON schemas, its metadata and a render
+// function.
+//
+// The package does not import Genkit itself, which depends on dotprompt, so
+// a Registrar adapts Actions to the Genkit API of the application, typically
+// by defining a prompt action for each.
+package genkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/dotprompt/go/dotprompt"
+)
+
+// Action is a prompt ready to be registered as a Genkit action.
+type Action struct {
+	// Name is the Genkit name of the prompt: its store name, followed by
+	// `.variant` for variants, as Genkit looks variants up.
+	Name string
+	Ref  dotprompt.PromptRef
+	// InputSchema and OutputSchema are the JSON schemas of the prompt's
+	// input and output, with Picoschema expanded, or nil if it declares
+	// none.
+	InputSchema  map[string]any
+	OutputSchema map[string]any
+	// Metadata is the prompt's metadata, as resolved for rendering.
+	Metadata dotprompt.PromptMetadata
+	// Render renders the prompt with the given input and Genkit context.
+	Render func(ctx context.Context, input map[string]any, genkitContext map[string]any) (dotprompt.RenderedPrompt, error)
+}
+
+// Registrar registers Actions with Genkit.
+type Registrar interface {
+	RegisterPrompt(action Action) error
+}
+
+// RegistrarFunc adapts a function to a Registrar.
+type RegistrarFunc func(action Action) error
+
+// RegisterPrompt calls f(action).
+func (f RegistrarFunc) RegisterPrompt(action Action) error {
+	return f(action)
+}
+
+// Options configures Register.
+type Options struct {
+	// Dotprompt configures the instance that compiles the prompts. Its
+	// PartialStore defaults to the store being registered.
+	Dotprompt *dotprompt.DotpromptOptions
+	// Concurrency is the number of prompts compiled at once; see
+	// Dotprompt.CompileAll.
+	Concurrency int
+}
+
+// Register compiles every prompt in store and registers it with registrar.
+// Prompts that fail to compile or register do not stop the others; their
+// errors are joined in the returned error. It returns the registered
+// actions.
+func Register(ctx context.Context, store dotprompt.PromptStore, registrar Registrar, options *Options) ([]Action, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	dpOptions := dotprompt.DotpromptOptions{}
+	if options.Dotprompt != nil {
+		dpOptions = *options.Dotprompt
+	}
+	if dpOptions.PartialStore == nil {
+		dpOptions.PartialStore = store
+	}
+	dp := dotprompt.NewDotprompt(&dpOptions)
+
+	results, err := dp.CompileAll(ctx, store, options.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []Action
+	var errs []error
+	for _, result := range results {
+		action, err := newAction(dp, store, result)
+		if err == nil {
+			err = registrar.RegisterPrompt(action)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("prompt %q: %w", actionName(result.Ref), err))
+			continue
+		}
+		actions = append(actions, action)
+	}
+	return actions, errors.Join(errs...)
+}
+
+// actionName returns the Genkit name of the prompt ref.
+func actionName(ref dotprompt.PromptRef) string {
+	if ref.Variant == "" {
+		return ref.Name
+	}
+	return ref.Name + "." + ref.Variant
+}
+
+// newAction builds the Action for a compiled prompt.
+func newAction(dp *dotprompt.Dotprompt, store dotprompt.PromptStore, result dotprompt.CompileResult) (Action, error) {
+	if result.Err != nil {
+		return Action{}, result.Err
+	}
+	prompt, err := store.Load(result.Ref.Name, dotprompt.LoadPromptOptions{Variant: result.Ref.Variant, Version: result.Ref.Version})
+	if err != nil {
+		return Action{}, err
+	}
+	meta, err := dp.RenderMetadata(prompt.Source, nil)
+	if err != nil {
+		return Action{}, err
+	}
+	input, err := schemaMap(meta.Input.Schema)
+	if err != nil {
+		return Action{}, fmt.Errorf("input schema: %w", err)
+	}
+	output, err := schemaMap(meta.Output.Schema)
+	if err != nil {
+		return Action{}, fmt.Errorf("output schema: %w", err)
+	}
+
+	render := result.Prompt
+	return Action{
+		Name:         actionName(result.Ref),
+		Ref:          result.Ref,
+		InputSchema:  input,
+		OutputSchema: output,
+		Metadata:     meta,
+		Render: func(ctx context.Context, input, genkitContext map[string]any) (dotprompt.RenderedPrompt, error) {
+			if err := ctx.Err(); err != nil {
+				return dotprompt.RenderedPrompt{}, err
+			}
+			return render(&dotprompt.DataArgument{Input: input, Context: genkitContext}, nil)
+		},
+	}, nil
+}
+
+// GenerateOptions returns the generation controls of the prompt's metadata as
+// the fields of a Genkit generate request: model, config, tools, toolChoice,
+// maxTurns and returnToolRequests. Tools lists both unresolved tool names and
+// the names of resolved tool definitions. Genkit takes stop sequences from the model
+// config, so StopSequences is added to config as stopSequences unless the
+// config sets them itself. Unset controls are omitted.
+func (a Action) GenerateOptions() map[string]any {
+	meta := a.Metadata
+	out := map[string]any{}
+	if meta.Model != "" {
+		out["model"] = meta.Model
+	}
+	config := map[string]any(maps.Clone(meta.Config))
+	if len(meta.StopSequences) > 0 {
+		if config == nil {
+			config = map[string]any{}
+		}
+		if _, ok := config["stopSequences"]; !ok {
+			config["stopSequences"] = meta.StopSequences
+		}
+	}
+	if len(config) > 0 {
+		out["config"] = config
+	}
+	tools := slices.Clone(meta.Tools)
+	for _, def := range meta.ToolDefs {
+		tools = append(tools, def.Name)
+	}
+	if len(tools) > 0 {
+		out["tools"] = tools
+	}
+	if meta.ToolChoice != "" {
+		out["toolChoice"] = string(meta.ToolChoice)
+	}
+	if meta.MaxTurns > 0 {
+		out["maxTurns"] = meta.MaxTurns
+	}
+	if meta.ReturnToolRequests {
+		out["returnToolRequests"] = true
+	}
+	return out
+}
+
+// schemaMap converts a schema to the map form Genkit uses for JSON schemas.
+func schemaMap(schema dotprompt.Schema) (map[string]any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package genkit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestStore(t *testing.T) *dotprompt.DirStore {
+	t.Helper()
+	store, err := dotprompt.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() returned error: %v", err)
+	}
+	prompts := []dotprompt.PromptData{
+		{PromptRef: dotprompt.PromptRef{Name: "greet"}, Source: "---\ninput:\n  schema:\n    name: string\n  default:\n    name: World\noutput:\n  schema:\n    reply: string\n---\n{{> sig}}Hello {{name}}{{@mood}}"},
+		{PromptRef: dotprompt.PromptRef{Name: "greet", Variant: "formal"}, Source: "Good day, {{name}}."},
+	}
+	for _, p := range prompts {
+		if err := store.Save(p); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+	if err := store.SavePartial(dotprompt.PartialData{PartialRef: dotprompt.PartialRef{Name: "sig"}, Source: "[sig] "}); err != nil {
+		t.Fatalf("SavePartial() returned error: %v", err)
+	}
+	return store
+}
+
+// renderText renders action and returns the text of its first message.
+func renderText(t *testing.T, action Action, input, genkitContext map[string]any) string {
+	t.Helper()
+	rendered, err := action.Render(context.Background(), input, genkitContext)
+	if err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	return rendered.Messages[0].Content[0].(*dotprompt.TextPart).Text
+}
+
+func TestRegister(t *testing.T) {
+	store := newTestStore(t)
+	registered := map[string]Action{}
+	actions, err := Register(context.Background(), store, RegistrarFunc(func(action Action) error {
+		registered[action.Name] = action
+		return nil
+	}), nil)
+	if err != nil {
+		t.Fatalf("Register() returned error: %v", err)
+	}
+	if len(actions) != 2 || len(registered) != 2 {
+		t.Fatalf("Register() registered %d actions and returned %d, want 2", len(registered), len(actions))
+	}
+
+	greet := registered["greet"]
+	wantInput := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"name": map[string]any{"type": "string"}},
+		"required":   []any{"name"},
+	}
+	if diff := cmp.Diff(wantInput, greet.InputSchema); diff != "" {
+		t.Errorf("InputSchema mismatch (-want +got):\n%s", diff)
+	}
+	if greet.OutputSchema["properties"] == nil {
+		t.Errorf("OutputSchema = %v, want reply property", greet.OutputSchema)
+	}
+	if got, want := renderText(t, greet, nil, map[string]any{"mood": "!"}), "[sig] Hello World!"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	formal := registered["greet.formal"]
+	if formal.Ref.Variant != "formal" || formal.InputSchema != nil {
+		t.Errorf("formal action = %+v, want variant formal without input schema", formal)
+	}
+	if got, want := renderText(t, formal, map[string]any{"name": "Ada"}, nil), "Good day, Ada."; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.Save(dotprompt.PromptData{PromptRef: dotprompt.PromptRef{Name: "broken"}, Source: "{{> missing}}"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	errRejected := errors.New("rejected")
+	actions, err := Register(context.Background(), store, RegistrarFunc(func(action Action) error {
+		if action.Name == "greet.formal" {
+			return errRejected
+		}
+		return nil
+	}), &Options{Concurrency: 1})
+	if !errors.Is(err, errRejected) || !strings.Contains(err.Error(), `prompt "broken"`) {
+		t.Errorf("Register() error = %v, want errors for broken and greet.formal", err)
+	}
+	if len(actions) != 1 || actions[0].Name != "greet" {
+		t.Errorf("Register() = %v, want only greet", actions)
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	store := newTestStore(t)
+	source := "---\nmodel: googleai/gemini-2.0-flash\nconfig:\n  temperature: 0.5\n" +
+		"tools: [search]\ntoolChoice: required\nmaxTurns: 3\nreturnToolRequests: true\n" +
+		"stopSequences: [END, STOP]\n---\nSearch for {{query}}"
+	if err := store.Save(dotprompt.PromptData{PromptRef: dotprompt.PromptRef{Name: "search"}, Source: source}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	options := &Options{Dotprompt: &dotprompt.DotpromptOptions{
+		Tools: map[string]dotprompt.ToolDefinition{"search": {Name: "search"}},
+	}}
+	var search Action
+	if _, err := Register(context.Background(), store, RegistrarFunc(func(action Action) error {
+		if action.Name == "search" {
+			search = action
+		}
+		return nil
+	}), options); err != nil {
+		t.Fatalf("Register() returned error: %v", err)
+	}
+
+	want := map[string]any{
+		"model":              "googleai/gemini-2.0-flash",
+		"config":             map[string]any{"temperature": 0.5, "stopSequences": []string{"END", "STOP"}},
+		"tools":              []string{"search"},
+		"toolChoice":         "required",
+		"maxTurns":           3,
+		"returnToolRequests": true,
+	}
+	if diff := cmp.Diff(want, search.GenerateOptions()); diff != "" {
+		t.Errorf("GenerateOptions() mismatch (-want +got):\n%s", diff)
+	}
+	if got := (Action{}).GenerateOptions(); len(got) != 0 {
+		t.Errorf("GenerateOptions() of empty metadata = %v, want empty", got)
+	}
+}
@@ -25,6 +25,8 @@ import com.fasterxml.jackson.databind.ObjectMapper;
 import com.github.jknack.handlebars.Handlebars;
 import com.github.jknack.handlebars.Options;
 import java.io.IOException;
+import java.util.List;
+import java.util.Map;
 
 /**
  * Standard Handlebars helpers for Dotprompt.
@@ -239,15 +241,43 @@ public class Helpers {
   }
 
   /**
-   * Performs a strict equality check between two objects.
+   * Performs a strict equality check between two template values.
+   *
+   * <p>Values of different types are never equal, numbers compare by value regardless of their
+   * Java type, and maps and lists are compared element by element. The other runtimes follow the
+   * same rules.
    *
    * @param arg0 The first object.
    * @param arg1 The second object.
    * @return True if objects are equal, false otherwise.
    */
-  private static boolean strictEquals(Object arg0, Object arg1) {
+  static boolean strictEquals(Object arg0, Object arg1) {
     if (arg0 == null) return arg1 == null;
     if (arg1 == null) return false;
+    if (arg0 instanceof Number && arg1 instanceof Number) {
+      return ((Number) arg0).doubleValue() == ((Number) arg1).doubleValue();
+    }
+    if (arg0 instanceof List && arg1 instanceof List) {
+      List<?> a = (List<?>) arg0;
+      List<?> b = (List<?>) arg1;
+      if (a.size() != b.size()) return false;
+      for (int i = 0; i < a.size(); i++) {
+        if (!strictEquals(a.get(i), b.get(i))) return false;
+      }
+      return true;
+    }
+    if (arg0 instanceof Map && arg1 instanceof Map) {
+      Map<?, ?> a = (Map<?, ?>) arg0;
+      Map<?, ?> b = (Map<?, ?>) arg1;
+      if (a.size() != b.size()) return false;
+      for (Map.Entry<?, ?> entry : a.entrySet()) {
+        if (!b.containsKey(entry.getKey())
+            || !strictEquals(entry.getValue(), b.get(entry.getKey()))) {
+          return false;
+        }
+      }
+      return true;
+    }
     return arg0.equals(arg1);
   }
 }
@@ -26,6 +26,7 @@ import com.github.jknack.handlebars.Template;
 import java.io.IOException;
 import java.util.Arrays;
 import java.util.HashMap;
+import java.util.List;
 import java.util.Map;
 import org.junit.Before;
 import org.junit.Test;
@@ -342,6 +343,31 @@ public class HelpersTest {
     assertThat(template.apply(context)).isEqualTo("not equal");
   }
 
+  @Test
+  public void testIfEquals_numbersCompareByValue() throws IOException {
+    Template template =
+        handlebars.compileInline("{{#ifEquals a b}}equal{{else}}not equal{{/ifEquals}}");
+
+    Map<String, Object> context = new HashMap<>();
+    context.put("a", 5);
+    context.put("b", 5.0);
+    assertThat(template.apply(context)).isEqualTo("equal");
+  }
+
+  @Test
+  public void testIfEquals_deepComparison() throws IOException {
+    Template template =
+        handlebars.compileInline("{{#ifEquals a b}}equal{{else}}not equal{{/ifEquals}}");
+
+    Map<String, Object> context = new HashMap<>();
+    context.put("a", Map.of("x", 1, "y", List.of(2)));
+    context.put("b", Map.of("y", List.of(2.0), "x", 1));
+    assertThat(template.apply(context)).isEqualTo("equal");
+
+    context.put("b", Map.of("x", 1));
+    assertThat(template.apply(context)).isEqualTo("not equal");
+  }
+
   @Test
   public void testUnlessEquals_typeSafety_intVsString() throws IOException {
     // Tests that 5 (Integer) != "5" (String) - strict type inequality
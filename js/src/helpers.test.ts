@@ -192,6 +192,23 @@ describe('helpers', () => {
         'not equal'
       );
     });
+
+    it('should compare objects and arrays by value', () => {
+      const options = createOptions(
+        () => 'equal',
+        () => 'not equal'
+      );
+      expect(
+        ifEquals.call(mockContext, { a: 1, b: [2] }, { b: [2], a: 1 }, options)
+      ).toBe('equal');
+      expect(ifEquals.call(mockContext, { a: 1 }, { b: 1 }, options)).toBe(
+        'not equal'
+      );
+      expect(ifEquals.call(mockContext, [1, 2], [2, 1], options)).toBe(
+        'not equal'
+      );
+      expect(ifEquals.call(mockContext, [], {}, options)).toBe('not equal');
+    });
   });
 
   describe('unlessEquals', () => {
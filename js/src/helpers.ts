@@ -49,13 +49,47 @@ export function media(options: Handlebars.HelperOptions) {
   );
 }
 
+/**
+ * Reports whether two template values are equal. Values of different types
+ * are never equal, and objects and arrays are compared by value rather than by
+ * identity. The Go and Python runtimes follow the same rules.
+ */
+export function strictEquals(a: any, b: any): boolean {
+  if (a === b) {
+    return true;
+  }
+  if (
+    a === null ||
+    b === null ||
+    typeof a !== 'object' ||
+    typeof b !== 'object' ||
+    Array.isArray(a) !== Array.isArray(b)
+  ) {
+    return false;
+  }
+  if (Array.isArray(a)) {
+    return (
+      a.length === b.length &&
+      a.every((v: any, i: number) => strictEquals(v, b[i]))
+    );
+  }
+  const keys = Object.keys(a);
+  return (
+    keys.length === Object.keys(b).length &&
+    keys.every(
+      (k) =>
+        Object.prototype.hasOwnProperty.call(b, k) && strictEquals(a[k], b[k])
+    )
+  );
+}
+
 export function ifEquals(
   this: any,
   arg1: any,
   arg2: any,
   options: Handlebars.HelperOptions
 ) {
-  return arg1 === arg2 ? options.fn(this) : options.inverse(this);
+  return strictEquals(arg1, arg2) ? options.fn(this) : options.inverse(this);
 }
 
 export function unlessEquals(
@@ -64,5 +98,5 @@ export function unlessEquals(
   arg2: any,
   options: Handlebars.HelperOptions
 ) {
-  return arg1 !== arg2 ? options.fn(this) : options.inverse(this);
+  return !strictEquals(arg1, arg2) ? options.fn(this) : options.inverse(this);
 }
@@ -1,4 +1,4 @@
-module github.com/tree-sitter/tree-sitter-dotprompt
+module github.com/google/dotprompt/packages/treesitter/bindings/go
 
 go 1.22
 
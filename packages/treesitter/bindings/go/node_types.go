@@ -0,0 +1,62 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tree_sitter_dotprompt
+
+// Named node kinds produced by the grammar, as listed in src/node-types.json.
+// Compare them against Node.Type().
+const (
+	NodeArgument             = "argument"
+	NodeBlockExpression      = "block_expression"
+	NodeBlockName            = "block_name"
+	NodeBoolean              = "boolean"
+	NodeCloseBlock           = "close_block"
+	NodeDocument             = "document"
+	NodeDotpromptMarker      = "dotprompt_marker"
+	NodeExpressionContent    = "expression_content"
+	NodeFrontmatter          = "frontmatter"
+	NodeFrontmatterDelimiter = "frontmatter_delimiter"
+	NodeHandlebarsBlock      = "handlebars_block"
+	NodeHandlebarsComment    = "handlebars_comment"
+	NodeHandlebarsExpression = "handlebars_expression"
+	NodeHashParam            = "hash_param"
+	NodeHeaderComment        = "header_comment"
+	NodeHelperName           = "helper_name"
+	NodeKey                  = "key"
+	NodeLicenseHeader        = "license_header"
+	NodeMarkerContent        = "marker_content"
+	NodeNumber               = "number"
+	NodePartialReference     = "partial_reference"
+	NodePath                 = "path"
+	NodeStringLiteral        = "string_literal"
+	NodeTemplateBody         = "template_body"
+	NodeText                 = "text"
+	NodeVariableReference    = "variable_reference"
+	NodeYAMLContent          = "yaml_content"
+	NodeYAMLKey              = "yaml_key"
+	NodeYAMLLine             = "yaml_line"
+	NodeYAMLValue            = "yaml_value"
+)
+
+// Field names used by the grammar. Pass them to Node.ChildByFieldName().
+const (
+	// FieldName is the block name of a block_expression or close_block.
+	FieldName = "name"
+	// FieldKey is the key of a hash_param or yaml_line.
+	FieldKey = "key"
+	// FieldValue is the value of a hash_param or yaml_line.
+	FieldValue = "value"
+)
@@ -0,0 +1,92 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tree_sitter_dotprompt_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	tree_sitter_dotprompt "github.com/google/dotprompt/packages/treesitter/bindings/go"
+)
+
+// nodeKinds lists every node kind constant.
+var nodeKinds = []string{
+	tree_sitter_dotprompt.NodeArgument,
+	tree_sitter_dotprompt.NodeBlockExpression,
+	tree_sitter_dotprompt.NodeBlockName,
+	tree_sitter_dotprompt.NodeBoolean,
+	tree_sitter_dotprompt.NodeCloseBlock,
+	tree_sitter_dotprompt.NodeDocument,
+	tree_sitter_dotprompt.NodeDotpromptMarker,
+	tree_sitter_dotprompt.NodeExpressionContent,
+	tree_sitter_dotprompt.NodeFrontmatter,
+	tree_sitter_dotprompt.NodeFrontmatterDelimiter,
+	tree_sitter_dotprompt.NodeHandlebarsBlock,
+	tree_sitter_dotprompt.NodeHandlebarsComment,
+	tree_sitter_dotprompt.NodeHandlebarsExpression,
+	tree_sitter_dotprompt.NodeHashParam,
+	tree_sitter_dotprompt.NodeHeaderComment,
+	tree_sitter_dotprompt.NodeHelperName,
+	tree_sitter_dotprompt.NodeKey,
+	tree_sitter_dotprompt.NodeLicenseHeader,
+	tree_sitter_dotprompt.NodeMarkerContent,
+	tree_sitter_dotprompt.NodeNumber,
+	tree_sitter_dotprompt.NodePartialReference,
+	tree_sitter_dotprompt.NodePath,
+	tree_sitter_dotprompt.NodeStringLiteral,
+	tree_sitter_dotprompt.NodeTemplateBody,
+	tree_sitter_dotprompt.NodeText,
+	tree_sitter_dotprompt.NodeVariableReference,
+	tree_sitter_dotprompt.NodeYAMLContent,
+	tree_sitter_dotprompt.NodeYAMLKey,
+	tree_sitter_dotprompt.NodeYAMLLine,
+	tree_sitter_dotprompt.NodeYAMLValue,
+}
+
+func TestNodeKindsMatchGrammar(t *testing.T) {
+	content, err := os.ReadFile("../../src/node-types.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []struct {
+		Type  string `json:"type"`
+		Named bool   `json:"named"`
+	}
+	if err := json.Unmarshal(content, &types); err != nil {
+		t.Fatal(err)
+	}
+
+	want := make(map[string]bool)
+	for _, nt := range types {
+		if nt.Named {
+			want[nt.Type] = true
+		}
+	}
+	got := make(map[string]bool)
+	for _, kind := range nodeKinds {
+		got[kind] = true
+		if !want[kind] {
+			t.Errorf("node kind %q is not in node-types.json", kind)
+		}
+	}
+	for kind := range want {
+		if !got[kind] {
+			t.Errorf("node-types.json kind %q has no constant", kind)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tree_sitter_dotprompt
+
+import (
+	"context"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// GetLanguage returns the grammar as a go-tree-sitter Language.
+func GetLanguage() *sitter.Language {
+	return sitter.NewLanguage(Language())
+}
+
+// ParsePrompt parses the source of a .prompt file. Syntax errors do not cause
+// an error; they are reported as ERROR and MISSING nodes in the tree.
+func ParsePrompt(src []byte) (*sitter.Tree, error) {
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(GetLanguage())
+	return parser.ParseCtx(context.Background(), nil, src)
+}
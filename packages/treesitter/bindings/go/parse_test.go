@@ -0,0 +1,50 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tree_sitter_dotprompt_test
+
+import (
+	"testing"
+
+	tree_sitter_dotprompt "github.com/google/dotprompt/packages/treesitter/bindings/go"
+)
+
+func TestParsePrompt(t *testing.T) {
+	src := []byte("---\nmodel: gemini\n---\nHello {{name}}!\n{{> footer}}\n")
+	tree, err := tree_sitter_dotprompt.ParsePrompt(src)
+	if err != nil {
+		t.Fatalf("ParsePrompt() returned error: %v", err)
+	}
+	root := tree.RootNode()
+	if root.HasError() {
+		t.Fatalf("tree has errors: %s", root.String())
+	}
+
+	want := "(document (frontmatter (frontmatter_delimiter) (yaml_content (yaml_line key: (yaml_key) value: (yaml_value))) (frontmatter_delimiter)) " +
+		"(template_body (text) (handlebars_expression (expression_content (variable_reference (path)))) (text) " +
+		"(handlebars_expression (expression_content (partial_reference))) (text)))"
+	if got := root.String(); got != want {
+		t.Errorf("tree = %s, want %s", got, want)
+	}
+
+	line := root.NamedChild(0).NamedChild(1).NamedChild(0)
+	if line.Type() != tree_sitter_dotprompt.NodeYAMLLine {
+		t.Fatalf("frontmatter child = %q, want %q", line.Type(), tree_sitter_dotprompt.NodeYAMLLine)
+	}
+	if key := line.ChildByFieldName(tree_sitter_dotprompt.FieldKey); key == nil || key.Content(src) != "model" {
+		t.Errorf("yaml_line key = %v, want model", key)
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tree_sitter_dotprompt
+
+// Builds with the go tool compile the generated parser from the grammar's src
+// directory. Bazel builds link //packages/treesitter:parser instead and leave
+// this file out of the library's srcs.
+
+// #cgo CFLAGS: -I${SRCDIR}/../../src -std=c11 -fPIC
+// #include "../../src/parser.c"
+import "C"
@@ -0,0 +1,34 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tree_sitter_dotprompt
+
+import _ "embed"
+
+// The query files are copies of those in the grammar's queries directory,
+// since go:embed cannot reach outside the package. Run `make go-queries` in
+// packages/treesitter after editing them.
+
+// HighlightsQuery is the content of queries/highlights.scm.
+//
+//go:embed queries/highlights.scm
+var HighlightsQuery string
+
+// InjectionsQuery is the content of queries/injections.scm. It injects YAML
+// into the frontmatter.
+//
+//go:embed queries/injections.scm
+var InjectionsQuery string
@@ -0,0 +1,54 @@
+;; Copyright 2026 Google LLC
+;;
+;; Licensed under the Apache License, Version 2.0 (the "License");
+;; you may not use this file except in compliance with the License.
+;; You may obtain a copy of the License at
+;;
+;;     http://www.apache.org/licenses/LICENSE-2.0
+;;
+;; Unless required by applicable law or agreed to in writing, software
+;; distributed under the License is distributed on an "AS IS" BASIS,
+;; WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+;; See the License for the specific language governing permissions and
+;; limitations under the License.
+;;
+;; SPDX-License-Identifier: Apache-2.0
+
+; Frontmatter
+(frontmatter) @meta
+
+(yaml_key) @property
+(yaml_value) @string
+
+(header_comment) @comment
+(handlebars_comment) @comment
+
+; Handlebars
+(handlebars_block
+  (block_expression
+    "{{#" @punctuation.bracket
+    (block_name) @keyword
+    "}}" @punctuation.bracket
+  )
+  (close_block
+    "{{/" @punctuation.bracket
+    (block_name) @keyword
+    "}}" @punctuation.bracket
+  )
+)
+
+(handlebars_expression
+  "{{" @punctuation.bracket
+  "}}" @punctuation.bracket
+)
+
+(helper_name) @function
+
+(variable_reference) @variable
+(string_literal) @string
+(number) @number
+(boolean) @constant.builtin
+(key) @attribute
+
+; Dotprompt markers
+(dotprompt_marker) @keyword
@@ -0,0 +1,49 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tree_sitter_dotprompt_test
+
+import (
+	"os"
+	"testing"
+
+	tree_sitter_dotprompt "github.com/google/dotprompt/packages/treesitter/bindings/go"
+	tree_sitter "github.com/smacker/go-tree-sitter"
+)
+
+func TestQueries(t *testing.T) {
+	tests := []struct {
+		file  string
+		query string
+	}{
+		{"highlights.scm", tree_sitter_dotprompt.HighlightsQuery},
+		{"injections.scm", tree_sitter_dotprompt.InjectionsQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			want, err := os.ReadFile("../../queries/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.query != string(want) {
+				t.Errorf("embedded %s is out of date; run `make go-queries`", tt.file)
+			}
+			if _, err := tree_sitter.NewQuery([]byte(tt.query), tree_sitter_dotprompt.GetLanguage()); err != nil {
+				t.Errorf("NewQuery(%s) returned error: %v", tt.file, err)
+			}
+		})
+	}
+}
@@ -161,6 +161,32 @@ def media_helper(params: list[Any], options: HelperOptions) -> str:
         return f'<<<dotprompt:media:url {url}>>>'
 
 
+def strict_equals(a: Any, b: Any) -> bool:
+    """Report whether two template values are equal.
+
+    Values of different types are never equal, so ``5`` and ``'5'`` differ, as
+    do ``True`` and ``1``. Integers and floats compare by value. Dicts and
+    lists are compared element by element. The JS and Go runtimes follow the
+    same rules.
+
+    Args:
+        a: The first value.
+        b: The second value.
+
+    Returns:
+        True if the values are equal.
+    """
+    if isinstance(a, bool) or isinstance(b, bool):
+        return isinstance(a, bool) and isinstance(b, bool) and a == b
+    if isinstance(a, int | float) and isinstance(b, int | float):
+        return a == b
+    if isinstance(a, dict) and isinstance(b, dict):
+        return a.keys() == b.keys() and all(strict_equals(a[k], b[k]) for k in a)
+    if isinstance(a, list | tuple) and isinstance(b, list | tuple):
+        return len(a) == len(b) and all(strict_equals(x, y) for x, y in zip(a, b, strict=True))
+    return type(a) is type(b) and a == b
+
+
 def if_equals_helper(params: list[Any], options: HelperOptions) -> str:
     """Compares two values and returns appropriate content.
 
@@ -183,7 +209,7 @@ def if_equals_helper(params: list[Any], options: HelperOptions) -> str:
         return ''
 
     a, b = params[0], params[1]
-    return options.fn() if a == b else options.inverse()
+    return options.fn() if strict_equals(a, b) else options.inverse()
 
 
 def unless_equals_helper(params: list[Any], options: HelperOptions) -> str:
@@ -208,7 +234,7 @@ def unless_equals_helper(params: list[Any], options: HelperOptions) -> str:
         return ''
 
     a, b = params[0], params[1]
-    return options.fn() if a != b else options.inverse()
+    return options.fn() if not strict_equals(a, b) else options.inverse()
 
 
 BUILTIN_HELPERS: dict[str, HelperFn] = {
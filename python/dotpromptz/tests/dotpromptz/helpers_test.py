@@ -26,6 +26,7 @@ from dotpromptz.helpers import (
     media_helper,
     role_helper,
     section_helper,
+    strict_equals,
     unless_equals_helper,
 )
 from handlebarrz import Handlebars
@@ -249,6 +250,22 @@ class TestDotpromptHelpers(unittest.TestCase):
         result = self.handlebars.render('null_test', {'arg1': None, 'arg2': 0})
         self.assertEqual(result, 'not equal')
 
+    def test_if_equals_bool_vs_int(self) -> None:
+        """Test ifEquals treats booleans and numbers as different types."""
+        self.handlebars.register_template(
+            'bool_int_test',
+            '{{#ifEquals arg1 arg2}}equal{{else}}not equal{{/ifEquals}}',
+        )
+        result = self.handlebars.render('bool_int_test', {'arg1': True, 'arg2': 1})
+        self.assertEqual(result, 'not equal')
+
+    def test_strict_equals_deep(self) -> None:
+        """Test strict_equals compares dicts and lists by value."""
+        self.assertTrue(strict_equals({'a': 1, 'b': [2]}, {'b': [2.0], 'a': 1}))
+        self.assertFalse(strict_equals({'a': 1}, {'b': 1}))
+        self.assertFalse(strict_equals([1, 2], [2, 1]))
+        self.assertFalse(strict_equals([True], [1]))
+
     def test_unless_equals_type_safety_int_vs_string(self) -> None:
         """Test unlessEquals uses strict inequality (int 5 != string '5').
 
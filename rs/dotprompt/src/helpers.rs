@@ -20,6 +20,7 @@
 //! functionality like role markers, media references, and JSON serialization.
 
 use handlebars::{Context, Handlebars, Helper, HelperResult, Output, RenderContext, Renderable};
+use serde_json::Value;
 
 /// Registers all built-in helpers with a Handlebars instance.
 ///
@@ -236,7 +237,7 @@ fn if_equals_helper<'reg, 'rc>(
         handlebars::RenderErrorReason::Other("ifEquals requires two parameters".to_string())
     })?;
 
-    let are_equal = param0.value() == param1.value();
+    let are_equal = values_equal(param0.value(), param1.value());
 
     let template_to_render = if are_equal { h.template() } else { h.inverse() };
 
@@ -275,7 +276,7 @@ fn unless_equals_helper<'reg, 'rc>(
         handlebars::RenderErrorReason::Other("unlessEquals requires two parameters".to_string())
     })?;
 
-    let are_equal = param0.value() == param1.value();
+    let are_equal = values_equal(param0.value(), param1.value());
 
     let template_to_render = if are_equal { h.inverse() } else { h.template() };
 
@@ -287,6 +288,26 @@ fn unless_equals_helper<'reg, 'rc>(
     Ok(())
 }
 
+/// Reports whether two template values are equal.
+///
+/// Values of different types are never equal, numbers compare by value
+/// regardless of whether they are integers or floats, and objects and arrays
+/// are compared element by element. The other runtimes follow the same rules.
+pub fn values_equal(a: &Value, b: &Value) -> bool {
+    match (a, b) {
+        (Value::Number(x), Value::Number(y)) => x.as_f64() == y.as_f64(),
+        (Value::Array(x), Value::Array(y)) => {
+            x.len() == y.len() && x.iter().zip(y).all(|(x, y)| values_equal(x, y))
+        }
+        (Value::Object(x), Value::Object(y)) => {
+            x.len() == y.len()
+                && x.iter()
+                    .all(|(k, v)| y.get(k).is_some_and(|w| values_equal(v, w)))
+        }
+        _ => a == b,
+    }
+}
+
 #[cfg(test)]
 #[allow(clippy::expect_used)]
 mod tests {
@@ -590,6 +611,19 @@ mod tests {
 
     // unlessEquals helper tests
 
+    #[test]
+    fn test_values_equal() {
+        assert!(values_equal(&json!(5), &json!(5.0)));
+        assert!(!values_equal(&json!(true), &json!(1)));
+        assert!(values_equal(
+            &json!({"a": 1, "b": [2]}),
+            &json!({"b": [2.0], "a": 1})
+        ));
+        assert!(!values_equal(&json!({"a": 1}), &json!({"b": 1})));
+        assert!(!values_equal(&json!([1, 2]), &json!([2, 1])));
+        assert!(!values_equal(&json!([]), &json!({})));
+    }
+
     #[test]
     fn test_unless_equals_unequal_values() {
         let mut hbs = Handlebars::new();